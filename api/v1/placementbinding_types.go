@@ -12,28 +12,70 @@ type Subject struct {
 	APIGroup string `json:"apiGroup,omitempty"`
 	Kind     string `json:"kind,omitempty"`
 	Name     string `json:"name,omitempty"`
+	// Selector binds every policy in the namespace that matches the label selector, in place of
+	// the policy named by Name. It is only used in the subjects and is ignored when Name is set.
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+	// BindingOverrides overrides the policies bound by the subject on the clusters selected by the
+	// placementRef. It is only used in the subjects.
+	BindingOverrides *BindingOverrides `json:"bindingOverrides,omitempty"`
 }
 
+// BindingOverrides overrides the replicated policies on the clusters of a PlacementBinding
+type BindingOverrides struct {
+	// RemediationAction overrides the remediationAction of the replicated policies. Only enforce
+	// is supported, so that a binding can enforce a policy that is only informed on the other
+	// clusters.
+	// +kubebuilder:validation:Enum=Enforce;enforce
+	RemediationAction string `json:"remediationAction,omitempty"`
+}
+
+// SubFilter restricts the clusters a PlacementBinding applies to
+// +kubebuilder:validation:Enum=restricted
+type SubFilter string
+
+// Restricted makes a PlacementBinding only apply to the clusters selected by the other
+// PlacementBindings of the policy, so that it can override the policy on a subset of its clusters
+const Restricted SubFilter = "restricted"
+
+// BindingValid is the condition type set on a PlacementBinding to indicate whether its
+// placementRef resolves and its subjects exist
+const BindingValid = "BindingValid"
+
 // PlacementBindingStatus defines the observed state of PlacementBinding
 type PlacementBindingStatus struct {
-	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
-	// Important: Run "operator-sdk generate k8s" to regenerate code after modifying this file
-	// Add custom validation using kubebuilder tags: https://book-v1.book.kubebuilder.io/beyond_basics/generating_crd.html
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// BoundObjects are the existing subjects the PlacementBinding currently binds
+	BoundObjects []Subject `json:"boundObjects,omitempty"`
+	// DecisionCount is the number of clusters the placementRef currently resolves to
+	DecisionCount int `json:"decisionCount,omitempty"`
 }
 
 //+kubebuilder:object:root=true
 
-// PlacementBinding is the Schema for the placementbindings API
+// PlacementBinding is the Schema for the placementbindings API. A policy bound by several subjects
+// or PlacementBindings is replicated once to every cluster selected by any of their placements. A
+// cluster selected by a subject with bindingOverrides gets the overrides, even when other
+// PlacementBindings of the policy select it too. A restricted PlacementBinding doesn't add clusters,
+// it only applies to the clusters selected by the other PlacementBindings.
 // +kubebuilder:subresource:status
 // +kubebuilder:resource:path=placementbindings,scope=Namespaced
 // +kubebuilder:resource:path=placementbindings,shortName=pb
+// +kubebuilder:printcolumn:name="Valid",type="string",JSONPath=".status.conditions[?(@.type==\"BindingValid\")].status"
+// +kubebuilder:printcolumn:name="Decisions",type="integer",JSONPath=".status.decisionCount"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 type PlacementBinding struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
-	PlacementRef Subject                `json:"placementRef,omitempty"`
-	Subjects     []Subject              `json:"subjects,omitempty"`
-	Status       PlacementBindingStatus `json:"status,omitempty"`
+	PlacementRef Subject   `json:"placementRef,omitempty"`
+	Subjects     []Subject `json:"subjects,omitempty"`
+	// SubFilter set to restricted only applies the PlacementBinding to the clusters selected by the
+	// other PlacementBindings of the policy
+	SubFilter SubFilter `json:"subFilter,omitempty"`
+	// BindingPolicyOverrides overrides all the policies bound by the PlacementBinding on the
+	// clusters selected by the placementRef, like the bindingOverrides of its subjects
+	BindingPolicyOverrides *BindingOverrides      `json:"bindingPolicyOverrides,omitempty"`
+	Status                 PlacementBindingStatus `json:"status,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -7,6 +7,7 @@ import (
 	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // RemediationAction describes weather to enforce or inform
@@ -24,6 +25,12 @@ const (
 type PolicyTemplate struct {
 	// +kubebuilder:pruning:PreserveUnknownFields
 	ObjectDefinition runtime.RawExtension `json:"objectDefinition,omitempty"`
+	// ExtraDependencies are the dependencies of the policy template in addition to the ones of the
+	// policy. They are checked by the policy framework on the managed cluster.
+	ExtraDependencies []PolicyDependency `json:"extraDependencies,omitempty"`
+	// IgnorePending excludes the policy template from the compliance of the policy while it is
+	// Pending, such as when it waits for its dependencies
+	IgnorePending bool `json:"ignorePending,omitempty"`
 }
 
 // ComplianceState shows the state of enforcement
@@ -35,6 +42,41 @@ const (
 
 	// NonCompliant is an ComplianceState
 	NonCompliant ComplianceState = "NonCompliant"
+
+	// Pending is an ComplianceState. The policy is waiting for its dependencies to be met.
+	Pending ComplianceState = "Pending"
+
+	// NonCompliantLow is the ComplianceState summarized in the root policy status details for the
+	// policy templates of low severity that are NonCompliant with the weighted compliance
+	// calculation
+	NonCompliantLow ComplianceState = "NonCompliant(Low)"
+)
+
+// The compliance calculations of a policy
+const (
+	// ComplianceWorstCase makes a cluster NonCompliant if any of its policy templates is
+	// NonCompliant
+	ComplianceWorstCase = "worstCase"
+	// ComplianceWeighted doesn't make a cluster NonCompliant for the policy templates of low
+	// severity that are NonCompliant
+	ComplianceWeighted = "weighted"
+)
+
+// AlertThresholdExceeded is the condition type set on a root policy when more clusters are
+// noncompliant than its alertThreshold allows
+const AlertThresholdExceeded = "AlertThresholdExceeded"
+
+// The condition types set on a root policy for the phases of its propagation
+const (
+	// PlacementResolvedCondition is whether the placement decisions of the root policy were resolved
+	PlacementResolvedCondition = "PlacementResolved"
+	// TemplatesResolvedCondition is whether the hub templates of the root policy were resolved for
+	// all of its clusters
+	TemplatesResolvedCondition = "TemplatesResolved"
+	// PropagatedCondition is whether the root policy was replicated to all of its clusters
+	PropagatedCondition = "Propagated"
+	// CompliantCondition is whether all of the clusters of the root policy are compliant
+	CompliantCondition = "Compliant"
 )
 
 // PolicySpec defines the desired state of Policy
@@ -42,6 +84,109 @@ type PolicySpec struct {
 	Disabled          bool              `json:"disabled"`
 	RemediationAction RemediationAction `json:"remediationAction,omitempty"` // Enforce, Inform
 	PolicyTemplates   []*PolicyTemplate `json:"policy-templates,omitempty"`
+	// AlertThreshold is the number (e.g. 5) or percentage (e.g. "25%") of noncompliant clusters
+	// above which the AlertThresholdExceeded condition is set on the root policy
+	// +kubebuilder:validation:XIntOrString
+	// +kubebuilder:validation:Pattern=`^\d+%?$`
+	AlertThreshold *intstr.IntOrString `json:"alertThreshold,omitempty"`
+	// ClusterRequirements are the capabilities a managed cluster must have for the policy to be
+	// replicated to it. The clusters that don't meet them are skipped.
+	ClusterRequirements *ClusterRequirements `json:"clusterRequirements,omitempty"`
+	// ClusterSelector restricts the policy to the clusters selected by its placements that have
+	// matching ManagedCluster labels. The other clusters are skipped as if they weren't selected.
+	ClusterSelector *metav1.LabelSelector `json:"clusterSelector,omitempty"`
+	// Dependencies are the policies that must have the required compliance state on a managed
+	// cluster before the policy is replicated to it
+	Dependencies []PolicyDependency `json:"dependencies,omitempty"`
+	// ComplianceCalculation is how the compliance of a cluster is calculated from the compliance of
+	// the policy templates, worstCase (the default) or weighted. When it is set, the compliance of
+	// each policy template across the clusters is summarized in the root policy status details.
+	// +kubebuilder:validation:Enum=worstCase;weighted
+	ComplianceCalculation string `json:"complianceCalculation,omitempty"`
+	// RolloutStrategy is how the policy is replicated to the clusters selected by its placements.
+	// It defaults to the rollout strategy of the PropagationConfig of the namespace, or All. It is
+	// ignored unless the RolloutStrategies feature gate is enabled.
+	RolloutStrategy *RolloutStrategy `json:"rolloutStrategy,omitempty"`
+	// MaintenanceWindow restricts the updates of the existing replicated policies to a recurring
+	// window. The updates detected outside of it are pushed when it opens, and the clusters waiting
+	// for them have the PendingUpdate reason in the root policy status.
+	MaintenanceWindow *MaintenanceWindow `json:"maintenanceWindow,omitempty"`
+	// RemoveAfterCompliant is how long a cluster must remain compliant before the policy is removed
+	// from it, such as 72h for a one-shot remediation policy. The removed clusters have the
+	// RemovedAfterCompliant reason in the root policy status until they are no longer selected.
+	RemoveAfterCompliant *metav1.Duration `json:"removeAfterCompliant,omitempty"`
+	// SkipUnavailableClusters pauses the replication to the clusters whose ManagedCluster is not
+	// available until they are available again. They are Pending with the ClusterUnavailable reason
+	// in the root policy status instead of reporting the compliance of a cluster that is offline.
+	SkipUnavailableClusters bool `json:"skipUnavailableClusters,omitempty"`
+}
+
+// MaintenanceWindow is a recurring window during which the replicated policies may be updated
+type MaintenanceWindow struct {
+	// Schedule is the cron expression of when the window opens, in the format of
+	// "minute hour day-of-month month day-of-week" in UTC, such as "0 2 * * 6" for every Saturday
+	// at 02:00
+	// +kubebuilder:validation:MinLength=1
+	Schedule string `json:"schedule"`
+	// Duration is how long the window stays open, such as 2h
+	Duration metav1.Duration `json:"duration"`
+}
+
+// PolicyDependency is an object that must have the compliance state on the managed cluster. The
+// Policy dependencies are checked by the propagator before replicating the policy, the others are
+// checked by the policy framework on the managed cluster.
+type PolicyDependency struct {
+	metav1.TypeMeta `json:",inline"`
+	Name            string `json:"name"`
+	// Namespace of the dependency. It defaults to the namespace of the policy for the Policy
+	// dependencies.
+	Namespace string `json:"namespace,omitempty"`
+	// Compliance is the compliance state the dependency must have. It defaults to Compliant.
+	// +kubebuilder:validation:Enum=Compliant;NonCompliant;Pending
+	Compliance ComplianceState `json:"compliance,omitempty"`
+}
+
+// The rollout strategy types
+const (
+	// RolloutAll replicates the policy to all the clusters at once
+	RolloutAll = "All"
+	// RolloutProgressive replicates the policy to MaxConcurrency clusters at a time
+	RolloutProgressive = "Progressive"
+	// RolloutProgressivePerGroup replicates the policy to a Placement decision group at a time
+	RolloutProgressivePerGroup = "ProgressivePerGroup"
+)
+
+// RolloutStrategy defines how the policy is replicated to the clusters in waves. A wave is only
+// rolled out when all the clusters of the previous waves are compliant.
+type RolloutStrategy struct {
+	// Type is All (the default), Progressive, or ProgressivePerGroup
+	// +kubebuilder:validation:Enum=All;Progressive;ProgressivePerGroup
+	Type string `json:"type,omitempty"`
+	// MaxConcurrency is the number of clusters in each wave of the Progressive rollout. It defaults
+	// to 1.
+	// +kubebuilder:validation:Minimum=1
+	MaxConcurrency int `json:"maxConcurrency,omitempty"`
+}
+
+// ClusterRequirements are the capabilities a managed cluster must have, based on the status of its
+// ManagedCluster
+type ClusterRequirements struct {
+	// MinKubernetesVersion is the lowest Kubernetes version the cluster may run, such as v1.20
+	// +kubebuilder:validation:Pattern=`^v?[0-9]+(\.[0-9]+)*$`
+	MinKubernetesVersion string `json:"minKubernetesVersion,omitempty"`
+	// MaxKubernetesVersion is the Kubernetes version the cluster must be older than, such as v1.25
+	// +kubebuilder:validation:Pattern=`^v?[0-9]+(\.[0-9]+)*$`
+	MaxKubernetesVersion string `json:"maxKubernetesVersion,omitempty"`
+	// ClusterClaims are the ClusterClaims the cluster must have. Required CRDs can be declared with
+	// the claims published by the add-ons that install them.
+	ClusterClaims []ClusterClaimRequirement `json:"clusterClaims,omitempty"`
+}
+
+// ClusterClaimRequirement is a ClusterClaim the cluster must have. When the value is empty, the
+// claim only needs to exist.
+type ClusterClaimRequirement struct {
+	Name  string `json:"name"`
+	Value string `json:"value,omitempty"`
 }
 
 // PlacementDecision defines the decision made by controller
@@ -56,6 +201,19 @@ type Placement struct {
 	PlacementRule    string                     `json:"placementRule,omitempty"`
 	Placement        string                     `json:"placement,omitempty"`
 	Decisions        []appsv1.PlacementDecision `json:"decisions,omitempty"`
+	// DecisionGroups are the decision groups of the Placement in the order of their index, with
+	// the compliance of the clusters in each group
+	DecisionGroups []DecisionGroup `json:"decisionGroups,omitempty"`
+	// Message is why the Placement can't select some of its clusters, such as its ManagedClusterSets
+	// not being bound to the namespace of the policy by a ManagedClusterSetBinding
+	Message string `json:"message,omitempty"`
+}
+
+// DecisionGroup defines the compliance of a decision group of a Placement
+type DecisionGroup struct {
+	Name            string          `json:"name"`
+	Clusters        []string        `json:"clusters,omitempty"`
+	ComplianceState ComplianceState `json:"compliant,omitempty"`
 }
 
 // CompliancePerClusterStatus defines compliance per cluster status
@@ -63,6 +221,68 @@ type CompliancePerClusterStatus struct {
 	ComplianceState  ComplianceState `json:"compliant,omitempty"`
 	ClusterName      string          `json:"clustername,omitempty"`
 	ClusterNamespace string          `json:"clusternamespace,omitempty"`
+	// Message is why the policy could not be replicated to the cluster, such as the denial message
+	// of the admission hook
+	Message string `json:"message,omitempty"`
+	// Reason is why the policy could not be replicated to the cluster, such as CircuitOpen when
+	// the replication is paused after repeated failures
+	Reason string `json:"reason,omitempty"`
+	// DecisionGroup is the name of the Placement decision group that selected the cluster
+	DecisionGroup string `json:"decisionGroup,omitempty"`
+	// CompliantSince is when the cluster became compliant. It is only set when the policy has
+	// removeAfterCompliant.
+	CompliantSince *metav1.Time `json:"compliantSince,omitempty"`
+	// Templates are the compliance of the policy templates of the replicated policy on the cluster.
+	// They are only set when the propagator runs with --enable-detailed-root-status.
+	Templates []*TemplateStatus `json:"templates,omitempty"`
+	// LastTransitionTime is when the compliance of the replicated policy last changed, which is the
+	// time of the most recent transition of its compliance history
+	LastTransitionTime *metav1.Time `json:"lastTransitionTime,omitempty"`
+	// ComplianceHistory are the latest compliance transitions of the replicated policy on the
+	// cluster, the most recent first. They are recorded by the propagator on the hub when the
+	// compliance differs from the previous one of the cluster.
+	// +kubebuilder:validation:MaxItems=10
+	ComplianceHistory []ComplianceTransition `json:"complianceHistory,omitempty"`
+	// OperatorPolicies are the ClusterServiceVersions installed by the OperatorPolicy templates of the
+	// replicated policy on the cluster
+	OperatorPolicies []*OperatorPolicyStatus `json:"operatorPolicies,omitempty"`
+}
+
+// OperatorPolicyStatus defines the operator installed by an OperatorPolicy template on a cluster
+type OperatorPolicyStatus struct {
+	Name string `json:"name,omitempty"`
+	// InstalledCSV is the ClusterServiceVersion in the latest compliance message of the
+	// OperatorPolicy
+	InstalledCSV string `json:"installedCSV,omitempty"`
+}
+
+// TemplateStatus defines the compliance of a policy template on a cluster
+type TemplateStatus struct {
+	Name            string          `json:"name,omitempty"`
+	ComplianceState ComplianceState `json:"compliant,omitempty"`
+	// Message is the message of the latest compliance event of the policy template
+	Message string `json:"message,omitempty"`
+}
+
+// The states of a progressive rollout
+const (
+	// RolloutProgressing is set while the clusters of the current wave are not all compliant yet
+	RolloutProgressing = "Progressing"
+	// RolloutPaused is set when a cluster of the current wave is noncompliant
+	RolloutPaused = "Paused"
+	// RolloutCompleted is set when the policy is replicated to all the clusters
+	RolloutCompleted = "Completed"
+)
+
+// RolloutStatus defines the progress of a progressive rollout of the policy
+type RolloutStatus struct {
+	// State is Progressing, Paused, or Completed
+	State string `json:"state"`
+	// Wave is the index of the wave being rolled out, starting at 0
+	Wave int `json:"wave"`
+	// DecisionGroup is the decision group being rolled out with the ProgressivePerGroup strategy
+	DecisionGroup string `json:"decisionGroup,omitempty"`
+	Message       string `json:"message,omitempty"`
 }
 
 // DetailsPerTemplate defines compliance details and history
@@ -85,9 +305,38 @@ type PolicyStatus struct {
 	Placement []*Placement                  `json:"placement,omitempty"` // used by root policy
 	Status    []*CompliancePerClusterStatus `json:"status,omitempty"`    // used by root policy
 
-	// +kubebuilder:validation:Enum=Compliant;NonCompliant
+	// +kubebuilder:validation:Enum=Compliant;NonCompliant;Pending
 	ComplianceState ComplianceState       `json:"compliant,omitempty"` // used by replicated policy
-	Details         []*DetailsPerTemplate `json:"details,omitempty"`   // used by replicated policy
+	Details         []*DetailsPerTemplate `json:"details,omitempty"`   // used by replicated policy, and root policy with a complianceCalculation
+
+	Conditions []metav1.Condition `json:"conditions,omitempty"` // used by root policy
+
+	// Rollout is the progress of the progressive rollout of the root policy
+	Rollout *RolloutStatus `json:"rollout,omitempty"`
+
+	// SummaryByControl is the compliance of the clusters of the root policy for each control of its
+	// controls annotation, so that compliance tooling can report the coverage of the controls from
+	// the root policy
+	SummaryByControl map[string]ControlSummary `json:"summaryByControl,omitempty"` // used by root policy
+}
+
+// ControlSummary defines the compliance of the clusters of a root policy for one of its controls
+type ControlSummary struct {
+	// Standards are the standards of the root policy, from its standards annotation
+	Standards []string `json:"standards,omitempty"`
+	// Categories are the categories of the root policy, from its categories annotation
+	Categories []string `json:"categories,omitempty"`
+	// Compliant is the number of clusters that are Compliant
+	Compliant int `json:"compliant"`
+	// Total is the number of clusters the policy applies to, which excludes the clusters that don't
+	// meet its cluster requirements
+	Total int `json:"total"`
+}
+
+// ComplianceTransition defines a change of the compliance of a replicated policy on a cluster
+type ComplianceTransition struct {
+	ComplianceState    ComplianceState `json:"compliant"`
+	LastTransitionTime metav1.Time     `json:"lastTransitionTime"`
 }
 
 //+kubebuilder:object:root=true
@@ -9,9 +9,61 @@ package v1
 
 import (
 	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BindingOverrides) DeepCopyInto(out *BindingOverrides) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BindingOverrides.
+func (in *BindingOverrides) DeepCopy() *BindingOverrides {
+	if in == nil {
+		return nil
+	}
+	out := new(BindingOverrides)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterClaimRequirement) DeepCopyInto(out *ClusterClaimRequirement) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterClaimRequirement.
+func (in *ClusterClaimRequirement) DeepCopy() *ClusterClaimRequirement {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterClaimRequirement)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterRequirements) DeepCopyInto(out *ClusterRequirements) {
+	*out = *in
+	if in.ClusterClaims != nil {
+		in, out := &in.ClusterClaims, &out.ClusterClaims
+		*out = make([]ClusterClaimRequirement, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterRequirements.
+func (in *ClusterRequirements) DeepCopy() *ClusterRequirements {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterRequirements)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ComplianceHistory) DeepCopyInto(out *ComplianceHistory) {
 	*out = *in
@@ -31,6 +83,43 @@ func (in *ComplianceHistory) DeepCopy() *ComplianceHistory {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CompliancePerClusterStatus) DeepCopyInto(out *CompliancePerClusterStatus) {
 	*out = *in
+	if in.CompliantSince != nil {
+		in, out := &in.CompliantSince, &out.CompliantSince
+		*out = (*in).DeepCopy()
+	}
+	if in.Templates != nil {
+		in, out := &in.Templates, &out.Templates
+		*out = make([]*TemplateStatus, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(TemplateStatus)
+				**out = **in
+			}
+		}
+	}
+	if in.LastTransitionTime != nil {
+		in, out := &in.LastTransitionTime, &out.LastTransitionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.ComplianceHistory != nil {
+		in, out := &in.ComplianceHistory, &out.ComplianceHistory
+		*out = make([]ComplianceTransition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.OperatorPolicies != nil {
+		in, out := &in.OperatorPolicies, &out.OperatorPolicies
+		*out = make([]*OperatorPolicyStatus, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(OperatorPolicyStatus)
+				**out = **in
+			}
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CompliancePerClusterStatus.
@@ -43,6 +132,67 @@ func (in *CompliancePerClusterStatus) DeepCopy() *CompliancePerClusterStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ComplianceTransition) DeepCopyInto(out *ComplianceTransition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ComplianceTransition.
+func (in *ComplianceTransition) DeepCopy() *ComplianceTransition {
+	if in == nil {
+		return nil
+	}
+	out := new(ComplianceTransition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ControlSummary) DeepCopyInto(out *ControlSummary) {
+	*out = *in
+	if in.Standards != nil {
+		in, out := &in.Standards, &out.Standards
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Categories != nil {
+		in, out := &in.Categories, &out.Categories
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControlSummary.
+func (in *ControlSummary) DeepCopy() *ControlSummary {
+	if in == nil {
+		return nil
+	}
+	out := new(ControlSummary)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DecisionGroup) DeepCopyInto(out *DecisionGroup) {
+	*out = *in
+	if in.Clusters != nil {
+		in, out := &in.Clusters, &out.Clusters
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DecisionGroup.
+func (in *DecisionGroup) DeepCopy() *DecisionGroup {
+	if in == nil {
+		return nil
+	}
+	out := new(DecisionGroup)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DetailsPerTemplate) DeepCopyInto(out *DetailsPerTemplate) {
 	*out = *in
@@ -66,6 +216,37 @@ func (in *DetailsPerTemplate) DeepCopy() *DetailsPerTemplate {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceWindow) DeepCopyInto(out *MaintenanceWindow) {
+	*out = *in
+	out.Duration = in.Duration
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceWindow.
+func (in *MaintenanceWindow) DeepCopy() *MaintenanceWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OperatorPolicyStatus) DeepCopyInto(out *OperatorPolicyStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperatorPolicyStatus.
+func (in *OperatorPolicyStatus) DeepCopy() *OperatorPolicyStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(OperatorPolicyStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Placement) DeepCopyInto(out *Placement) {
 	*out = *in
@@ -74,6 +255,13 @@ func (in *Placement) DeepCopyInto(out *Placement) {
 		*out = make([]appsv1.PlacementDecision, len(*in))
 		copy(*out, *in)
 	}
+	if in.DecisionGroups != nil {
+		in, out := &in.DecisionGroups, &out.DecisionGroups
+		*out = make([]DecisionGroup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Placement.
@@ -91,13 +279,20 @@ func (in *PlacementBinding) DeepCopyInto(out *PlacementBinding) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.PlacementRef = in.PlacementRef
+	in.PlacementRef.DeepCopyInto(&out.PlacementRef)
 	if in.Subjects != nil {
 		in, out := &in.Subjects, &out.Subjects
 		*out = make([]Subject, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.BindingPolicyOverrides != nil {
+		in, out := &in.BindingPolicyOverrides, &out.BindingPolicyOverrides
+		*out = new(BindingOverrides)
+		**out = **in
 	}
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlacementBinding.
@@ -153,6 +348,20 @@ func (in *PlacementBindingList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PlacementBindingStatus) DeepCopyInto(out *PlacementBindingStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.BoundObjects != nil {
+		in, out := &in.BoundObjects, &out.BoundObjects
+		*out = make([]Subject, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlacementBindingStatus.
@@ -207,6 +416,22 @@ func (in *Policy) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolicyDependency) DeepCopyInto(out *PolicyDependency) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolicyDependency.
+func (in *PolicyDependency) DeepCopy() *PolicyDependency {
+	if in == nil {
+		return nil
+	}
+	out := new(PolicyDependency)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PolicyList) DeepCopyInto(out *PolicyList) {
 	*out = *in
@@ -253,6 +478,41 @@ func (in *PolicySpec) DeepCopyInto(out *PolicySpec) {
 			}
 		}
 	}
+	if in.AlertThreshold != nil {
+		in, out := &in.AlertThreshold, &out.AlertThreshold
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.ClusterRequirements != nil {
+		in, out := &in.ClusterRequirements, &out.ClusterRequirements
+		*out = new(ClusterRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ClusterSelector != nil {
+		in, out := &in.ClusterSelector, &out.ClusterSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Dependencies != nil {
+		in, out := &in.Dependencies, &out.Dependencies
+		*out = make([]PolicyDependency, len(*in))
+		copy(*out, *in)
+	}
+	if in.RolloutStrategy != nil {
+		in, out := &in.RolloutStrategy, &out.RolloutStrategy
+		*out = new(RolloutStrategy)
+		**out = **in
+	}
+	if in.MaintenanceWindow != nil {
+		in, out := &in.MaintenanceWindow, &out.MaintenanceWindow
+		*out = new(MaintenanceWindow)
+		**out = **in
+	}
+	if in.RemoveAfterCompliant != nil {
+		in, out := &in.RemoveAfterCompliant, &out.RemoveAfterCompliant
+		*out = new(metav1.Duration)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolicySpec.
@@ -286,7 +546,7 @@ func (in *PolicyStatus) DeepCopyInto(out *PolicyStatus) {
 			if (*in)[i] != nil {
 				in, out := &(*in)[i], &(*out)[i]
 				*out = new(CompliancePerClusterStatus)
-				**out = **in
+				(*in).DeepCopyInto(*out)
 			}
 		}
 	}
@@ -301,6 +561,25 @@ func (in *PolicyStatus) DeepCopyInto(out *PolicyStatus) {
 			}
 		}
 	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Rollout != nil {
+		in, out := &in.Rollout, &out.Rollout
+		*out = new(RolloutStatus)
+		**out = **in
+	}
+	if in.SummaryByControl != nil {
+		in, out := &in.SummaryByControl, &out.SummaryByControl
+		*out = make(map[string]ControlSummary, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolicyStatus.
@@ -317,6 +596,11 @@ func (in *PolicyStatus) DeepCopy() *PolicyStatus {
 func (in *PolicyTemplate) DeepCopyInto(out *PolicyTemplate) {
 	*out = *in
 	in.ObjectDefinition.DeepCopyInto(&out.ObjectDefinition)
+	if in.ExtraDependencies != nil {
+		in, out := &in.ExtraDependencies, &out.ExtraDependencies
+		*out = make([]PolicyDependency, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolicyTemplate.
@@ -329,9 +613,49 @@ func (in *PolicyTemplate) DeepCopy() *PolicyTemplate {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RolloutStatus) DeepCopyInto(out *RolloutStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RolloutStatus.
+func (in *RolloutStatus) DeepCopy() *RolloutStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RolloutStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RolloutStrategy) DeepCopyInto(out *RolloutStrategy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RolloutStrategy.
+func (in *RolloutStrategy) DeepCopy() *RolloutStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(RolloutStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Subject) DeepCopyInto(out *Subject) {
 	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.BindingOverrides != nil {
+		in, out := &in.BindingOverrides, &out.BindingOverrides
+		*out = new(BindingOverrides)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Subject.
@@ -343,3 +667,18 @@ func (in *Subject) DeepCopy() *Subject {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TemplateStatus) DeepCopyInto(out *TemplateStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TemplateStatus.
+func (in *TemplateStatus) DeepCopy() *TemplateStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TemplateStatus)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -4,6 +4,7 @@
 package v1beta1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
@@ -13,8 +14,10 @@ type PolicyAutomationSpec struct {
 	// PolicyRef is the name of the policy automation is going to binding with.
 	// +kubebuilder:validation:Required
 	PolicyRef string `json:"policyRef"`
-	// Mode decides how automation is going to be triggered
-	// +kubebuilder:validation:Enum={once,disabled}
+	// Mode decides how automation is going to be triggered. The once mode runs the automation for
+	// the first violation and then disables itself, while the everyEvent mode runs it every time a
+	// cluster becomes NonCompliant.
+	// +kubebuilder:validation:Enum={once,everyEvent,disabled}
 	// +kubebuilder:validation:Required
 	Mode string `json:"mode"`
 	// EventHook decides when automation is going to be triggered
@@ -22,31 +25,114 @@ type PolicyAutomationSpec struct {
 	// +kubebuilder:validation:Required
 	EventHook   string `json:"eventHook,omitempty"`
 	RescanAfter string `json:"rescanAfter,omitempty"`
+	// DelayAfterRunSeconds is the minimum number of seconds before the automation runs again for
+	// a cluster in the everyEvent mode. A cluster violating the policy again during the delay is
+	// handled once it expires.
+	// +kubebuilder:validation:Minimum=0
+	DelayAfterRunSeconds uint `json:"delayAfterRunSeconds,omitempty"`
 	// +kubebuilder:validation:Required
 	Automation AutomationDef `json:"automationDef"`
 }
 
+// The automation types a PolicyAutomation can invoke
+const (
+	// AutomationTypeAnsibleJob runs an Ansible Template in Tower through an AnsibleJob. This is the
+	// default when the type is not set.
+	AutomationTypeAnsibleJob = "AnsibleJob"
+	// AutomationTypeJob runs a Kubernetes Job on the hub
+	AutomationTypeJob = "Job"
+	// AutomationTypePipelineRun runs a Tekton Pipeline on the hub through a PipelineRun
+	AutomationTypePipelineRun = "PipelineRun"
+)
+
 // AutomationDef defines the automation to invoke
 type AutomationDef struct {
-	// Type of the automation to invoke
+	// Type of the automation to invoke. It is either AnsibleJob (the default), Job, or PipelineRun.
 	Type string `json:"type,omitempty"`
-	// Name of the Ansible Template to run in Tower as a job
-	// +kubebuilder:validation:Required
-	Name string `json:"name"`
+	// Name of the Ansible Template to run in Tower as a job. It is required when the type is
+	// AnsibleJob.
+	Name string `json:"name,omitempty"`
 	// ExtraVars is passed to the Ansible job at execution time and is a known Ansible entity.
 	// +kubebuilder:pruning:PreserveUnknownFields
 	ExtraVars *runtime.RawExtension `json:"extra_vars,omitempty"`
+	// TowerSecret is the secret with the Tower credentials. It is required when the type is
+	// AnsibleJob.
+	TowerSecret string `json:"secret,omitempty"`
+	// MaxRetries is the number of times a failed AnsibleJob is launched again, waiting twice as
+	// long before every retry. It only applies to the AnsibleJob type.
+	// +kubebuilder:validation:Minimum=0
+	MaxRetries int `json:"maxRetries,omitempty"`
+	// Job is the Kubernetes Job to run on the hub. It is required when the type is Job.
+	Job *JobDef `json:"job,omitempty"`
+	// PipelineRun is the Tekton PipelineRun to create on the hub. It is required when the type is
+	// PipelineRun.
+	PipelineRun *PipelineRunDef `json:"pipelineRun,omitempty"`
+}
+
+// JobDef defines the container of the Kubernetes Job run by a PolicyAutomation. The Job is
+// created with the POLICY_NAME, POLICY_NAMESPACE, AUTOMATION_MODE, TARGET_CLUSTERS, and
+// EXTRA_VARS environment variables describing the violation in addition to the configured ones.
+type JobDef struct {
+	// Image of the Job's container
+	// +kubebuilder:validation:Required
+	Image string `json:"image"`
+	// Command of the Job's container
+	Command []string `json:"command,omitempty"`
+	// Args of the Job's container
+	Args []string `json:"args,omitempty"`
+	// Env is additional environment variables to set on the Job's container
+	Env []corev1.EnvVar `json:"env,omitempty"`
+	// ServiceAccountName is the service account to run the Job as
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+	// BackoffLimit is the number of retries before the Job is considered failed
+	BackoffLimit *int32 `json:"backoffLimit,omitempty"`
+}
+
+// PipelineRunDef defines the Tekton PipelineRun created by a PolicyAutomation
+type PipelineRunDef struct {
+	// PipelineRef is the name of the Tekton Pipeline to run
+	// +kubebuilder:validation:Required
+	PipelineRef string `json:"pipelineRef"`
+	// Params are passed to the Pipeline. Their values are Go templates with access to the
+	// .PolicyName, .PolicyNamespace, .Mode, and .TargetClusters fields of the violation and to the
+	// join function (e.g. '{{ join .TargetClusters "," }}').
+	Params []PipelineParam `json:"params,omitempty"`
+	// ServiceAccountName is the service account to run the Pipeline as
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+}
+
+// PipelineParam is a parameter passed to a Tekton Pipeline
+type PipelineParam struct {
 	// +kubebuilder:validation:Required
-	TowerSecret string `json:"secret"`
+	Name  string `json:"name"`
+	Value string `json:"value,omitempty"`
 }
 
 // PolicyAutomationStatus defines the observed state of PolicyAutomation
 type PolicyAutomationStatus struct {
-	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
-	// Important: Run "operator-sdk generate k8s" to regenerate code after modifying this file
-	// Add custom validation using kubebuilder tags: https://book-v1.book.kubebuilder.io/beyond_basics/generating_crd.html
+	// LastAnsibleJob is the name of the last AnsibleJob launched by the PolicyAutomation
+	LastAnsibleJob string `json:"lastAnsibleJob,omitempty"`
+	// LastAnsibleJobURL is the URL of the last AnsibleJob in Ansible Automation Platform
+	LastAnsibleJobURL string `json:"lastAnsibleJobURL,omitempty"`
+	// Conditions has the AnsibleJobSucceeded condition with the result of the last AnsibleJob
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// ClustersWithEvent tracks the automation runs of the clusters in the everyEvent mode so that
+	// a cluster staying NonCompliant doesn't run the automation again
+	ClustersWithEvent map[string]ClusterEvent `json:"clustersWithEvent,omitempty"`
 }
 
+// ClusterEvent is the last automation run for a cluster in the everyEvent mode
+type ClusterEvent struct {
+	// AutomationStartTime is when the automation last ran for the cluster, in the RFC 3339 format
+	AutomationStartTime string `json:"automationStartTime"`
+	// EventTime is when the cluster last became NonCompliant, in the RFC 3339 format. It is empty
+	// when the cluster is no longer NonCompliant.
+	EventTime string `json:"eventTime,omitempty"`
+}
+
+// AnsibleJobSucceeded is the condition type set to the result of the last AnsibleJob
+const AnsibleJobSucceeded = "AnsibleJobSucceeded"
+
 //+kubebuilder:object:root=true
 
 // PolicyAutomation is the Schema for the policyautomations API
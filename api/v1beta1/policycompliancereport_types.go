@@ -0,0 +1,68 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ComplianceSummary counts compliance states
+type ComplianceSummary struct {
+	Compliant    int `json:"compliant"`
+	NonCompliant int `json:"noncompliant"`
+	Unknown      int `json:"unknown"`
+}
+
+// PolicyComplianceSummary summarizes the compliance of a root policy across its clusters
+type PolicyComplianceSummary struct {
+	Name              string `json:"name"`
+	ComplianceState   string `json:"complianceState,omitempty"`
+	ComplianceSummary `json:",inline"`
+}
+
+// GroupComplianceSummary summarizes the compliance of the root policies in a cluster, standard,
+// or category
+type GroupComplianceSummary struct {
+	Name              string `json:"name"`
+	ComplianceSummary `json:",inline"`
+}
+
+//+kubebuilder:object:root=true
+
+// PolicyComplianceReport is a periodic snapshot of the compliance of the root policies in a
+// namespace
+// +kubebuilder:resource:path=policycompliancereports,scope=Namespaced
+// +kubebuilder:resource:path=policycompliancereports,shortName=plcr
+// +kubebuilder:printcolumn:name="Compliant",type="integer",JSONPath=".summary.compliant"
+// +kubebuilder:printcolumn:name="NonCompliant",type="integer",JSONPath=".summary.noncompliant"
+// +kubebuilder:printcolumn:name="Generated",type="date",JSONPath=".generatedAt"
+type PolicyComplianceReport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// GeneratedAt is when the report was last written
+	GeneratedAt metav1.Time `json:"generatedAt"`
+	// Summary counts the root policies in the namespace by compliance state
+	Summary ComplianceSummary `json:"summary"`
+	// Policies counts the clusters of each root policy by compliance state
+	Policies []PolicyComplianceSummary `json:"policies,omitempty"`
+	// Clusters counts the root policies of each cluster by compliance state
+	Clusters []GroupComplianceSummary `json:"clusters,omitempty"`
+	// Standards counts the root policies of each standard by compliance state
+	Standards []GroupComplianceSummary `json:"standards,omitempty"`
+	// Categories counts the root policies of each category by compliance state
+	Categories []GroupComplianceSummary `json:"categories,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// PolicyComplianceReportList contains a list of PolicyComplianceReport
+type PolicyComplianceReportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PolicyComplianceReport `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&PolicyComplianceReport{}, &PolicyComplianceReportList{})
+}
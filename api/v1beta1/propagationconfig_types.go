@@ -0,0 +1,67 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+)
+
+// PropagationConfigName is the name of the PropagationConfig that applies to the root policies in
+// its namespace. PropagationConfigs with other names are ignored.
+const PropagationConfigName = "default"
+
+// PropagationConfigSpec defines the defaults and limits of the propagation of the root policies in
+// the namespace
+type PropagationConfigSpec struct {
+	// TemplateLookupNamespace is the namespace the hub templates of the policies can look up
+	// objects in. It defaults to the namespace of the policies.
+	TemplateLookupNamespace string `json:"templateLookupNamespace,omitempty"`
+	// TemplateServiceAccount is the ServiceAccount in the namespace whose permissions the lookups of
+	// the hub templates of the policies are made with when the propagator impersonates the template
+	// users. It defaults to the author of the policy, or else the default ServiceAccount.
+	TemplateServiceAccount string `json:"templateServiceAccount,omitempty"`
+	// MaxClusters is the maximum number of clusters a policy is replicated to. The clusters past
+	// the limit are skipped. There is no limit when it is not set.
+	// +kubebuilder:validation:Minimum=1
+	MaxClusters int `json:"maxClusters,omitempty"`
+	// ExcludeLabels are the glob patterns of the labels of the root policies that are not copied to
+	// the replicated policies
+	ExcludeLabels []string `json:"excludeLabels,omitempty"`
+	// ExcludeAnnotations are the glob patterns of the annotations of the root policies that are
+	// not copied to the replicated policies
+	ExcludeAnnotations []string `json:"excludeAnnotations,omitempty"`
+	// DefaultRemediationAction is the remediationAction of the replicated policies when the root
+	// policy doesn't set one
+	// +kubebuilder:validation:Enum=Inform;inform;Enforce;enforce
+	DefaultRemediationAction policiesv1.RemediationAction `json:"defaultRemediationAction,omitempty"`
+	// RolloutStrategy is the rollout strategy of the policies that don't set one
+	RolloutStrategy *policiesv1.RolloutStrategy `json:"rolloutStrategy,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// PropagationConfig sets the defaults and limits of the propagation of the root policies in its
+// namespace. Only the PropagationConfig named default is used.
+// +kubebuilder:resource:path=propagationconfigs,scope=Namespaced
+// +kubebuilder:resource:path=propagationconfigs,shortName=pcfg
+type PropagationConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec PropagationConfigSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// PropagationConfigList contains a list of PropagationConfig
+type PropagationConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PropagationConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&PropagationConfig{}, &PropagationConfigList{})
+}
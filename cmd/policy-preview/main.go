@@ -0,0 +1,188 @@
+// Copyright Contributors to the Open Cluster Management project
+
+// The policy-preview command prints the replicated policy the propagator would create on a managed
+// cluster for a root policy, with its hub templates resolved against the hub of the kubeconfig.
+// Nothing is created or updated on the hub.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/kubernetes"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+
+	clusterv1 "github.com/open-cluster-management/api/cluster/v1"
+	clusterv1alpha1 "github.com/open-cluster-management/api/cluster/v1alpha1"
+	policyv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	policyv1beta1 "github.com/open-cluster-management/governance-policy-propagator/api/v1beta1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+	propagatorctrl "github.com/open-cluster-management/governance-policy-propagator/controllers/propagator"
+	"github.com/open-cluster-management/governance-policy-propagator/pkg/propagation"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+var scheme = k8sruntime.NewScheme()
+
+func init() {
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(clusterv1.AddToScheme(scheme))
+	utilruntime.Must(clusterv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(appsv1.AddToScheme(scheme))
+	utilruntime.Must(policyv1.AddToScheme(scheme))
+	utilruntime.Must(policyv1beta1.AddToScheme(scheme))
+}
+
+func main() {
+	var policyFile string
+	var clusterName string
+	var clusterNamespace string
+	var featureGates string
+	var disabledTemplateFunctions string
+	var templateEligibleKinds string
+
+	flag.StringVar(&policyFile, "policy", "-",
+		"The path of the YAML file of the root policy, or - to read it from the standard input.")
+	flag.StringVar(&clusterName, "cluster", "", "The name of the managed cluster to preview the replicated policy of.")
+	flag.StringVar(&clusterNamespace, "cluster-namespace", "",
+		"The namespace of the managed cluster when the placements of the root policy don't select it. Defaults "+
+			"to the cluster name.")
+	flag.StringVar(&featureGates, "feature-gates", "",
+		"The feature gates of the propagator, as a comma separated list of <feature>=<bool> pairs. "+
+			"The available features and their defaults are: "+strings.Join(common.KnownFeatures(), ", "))
+	flag.StringVar(&disabledTemplateFunctions, "disabled-template-functions", "fromSecret",
+		"A comma separated list of the hub template functions disabled in the propagator.")
+	flag.StringVar(&templateEligibleKinds, "template-eligible-kinds",
+		strings.Join(propagatorctrl.DefaultTemplateEligibleKinds, ","),
+		"A comma separated list of the kinds of the policy templates that may have hub templates in the propagator.")
+	flag.Parse()
+
+	if clusterName == "" {
+		fmt.Fprintln(os.Stderr, "The --cluster flag is required")
+		os.Exit(2)
+	}
+
+	if err := common.SetFeatureGates(featureGates); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid feature gates: %v\n", err)
+		os.Exit(2)
+	}
+
+	root, err := readPolicy(policyFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read the root policy: %v\n", err)
+		os.Exit(1)
+	}
+
+	cfg, err := config.GetConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load the kubeconfig: %v\n", err)
+		os.Exit(1)
+	}
+
+	c, err := client.New(cfg, client.Options{Scheme: scheme})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create the client: %v\n", err)
+		os.Exit(1)
+	}
+
+	// The propagator options are read from the same environment variables as the propagator
+	var kubeClient kubernetes.Interface = kubernetes.NewForConfigOrDie(cfg)
+	opts := propagatorctrl.PolicyReconcilerOptionsFromEnv(cfg, &kubeClient)
+	opts.DisabledTemplateFunctions = splitList(disabledTemplateFunctions)
+	opts.TemplateEligibleKinds = splitList(templateEligibleKinds)
+
+	previewer := propagation.NewPreviewer(c, opts)
+
+	replicatedPlc, err := preview(context.TODO(), previewer, root, clusterName, clusterNamespace, os.Stderr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to preview the replicated policy: %v\n", err)
+		os.Exit(1)
+	}
+
+	output, err := yaml.Marshal(replicatedPlc)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to encode the replicated policy: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Print(string(output))
+}
+
+// readPolicy reads the root policy from the YAML file, or from the standard input when the path is -
+func readPolicy(path string) (*policyv1.Policy, error) {
+	var data []byte
+	var err error
+
+	if path == "-" {
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(path)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	root := &policyv1.Policy{}
+	if err := yaml.Unmarshal(data, root); err != nil {
+		return nil, err
+	}
+
+	if root.Kind != policyv1.Kind || root.GetName() == "" || root.GetNamespace() == "" {
+		return nil, fmt.Errorf("expected a %s with a name and a namespace", policyv1.Kind)
+	}
+
+	return root, nil
+}
+
+// preview returns the replicated policy of the cluster. When the placements of the root policy
+// don't select the cluster, a warning is written and the replicated policy is previewed in the
+// cluster namespace, which defaults to the cluster name.
+func preview(
+	ctx context.Context, previewer *propagation.Previewer, root *policyv1.Policy,
+	clusterName string, clusterNamespace string, warnings io.Writer,
+) (*policyv1.Policy, error) {
+	decisions, err := previewer.PlacementDecisions(ctx, root)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, decision := range decisions {
+		if decision.ClusterName == clusterName {
+			return previewer.ReplicatedPolicy(ctx, root, decision)
+		}
+	}
+
+	fmt.Fprintf(warnings, "Warning: the policy %s/%s is not propagated to the cluster %s by its placements\n",
+		root.GetNamespace(), root.GetName(), clusterName)
+
+	if clusterNamespace == "" {
+		clusterNamespace = clusterName
+	}
+
+	return previewer.ReplicatedPolicy(
+		ctx, root, appsv1.PlacementDecision{ClusterName: clusterName, ClusterNamespace: clusterNamespace},
+	)
+}
+
+func splitList(value string) []string {
+	items := []string{}
+
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
@@ -0,0 +1,308 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package automation
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	policyv1beta1 "github.com/open-cluster-management/governance-policy-propagator/api/v1beta1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+)
+
+const AnsibleJobControllerName string = "policy-automation-ansiblejob"
+
+// The annotations and labels on the AnsibleJobs to track their retries
+const (
+	// retriesLabel is the number of times the launch of the AnsibleJob was retried
+	retriesLabel = "policy.open-cluster-management.io/automation-retries"
+	// retryAfterAnnotation is when the failed AnsibleJob is launched again
+	retryAfterAnnotation = "policy.open-cluster-management.io/retry-after"
+	// retriedByAnnotation is the name of the AnsibleJob that retried the failed AnsibleJob
+	retriedByAnnotation = "policy.open-cluster-management.io/retried-by"
+)
+
+// The delay before the first retry of a failed AnsibleJob. It doubles with every retry, up to
+// retryDelayMax.
+const (
+	retryDelayBase = 30 * time.Second
+	retryDelayMax  = 10 * time.Minute
+)
+
+// The results of an AnsibleJob reported in status.ansibleJobResult.status
+const (
+	ansibleJobSuccessful = "successful"
+	ansibleJobFailed     = "failed"
+	ansibleJobError      = "error"
+	ansibleJobCanceled   = "canceled"
+)
+
+// SetupWithManager sets up the controller with the Manager. The controller is not started when
+// the AnsibleJob CRD is not installed.
+func (r *AnsibleJobReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	_, err := mgr.GetRESTMapper().RESTMapping(common.AnsibleJobGVK.GroupKind(), common.AnsibleJobGVK.Version)
+	if err != nil {
+		if meta.IsNoMatchError(err) {
+			log.Info("The AnsibleJob CRD is not installed, the AnsibleJobs will not be tracked...")
+
+			return nil
+		}
+
+		return err
+	}
+
+	ansibleJob := &unstructured.Unstructured{}
+	ansibleJob.SetGroupVersionKind(common.AnsibleJobGVK)
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(AnsibleJobControllerName).
+		For(ansibleJob, builder.WithPredicates(predicate.NewPredicateFuncs(func(object client.Object) bool {
+			_, ok := object.GetLabels()[common.PolicyAutomationLabel]
+
+			return ok
+		}))).
+		Complete(r)
+}
+
+// blank assignment to verify that AnsibleJobReconciler implements reconcile.Reconciler
+var _ reconcile.Reconciler = &AnsibleJobReconciler{}
+
+// AnsibleJobReconciler reports the result of the AnsibleJobs launched by the PolicyAutomations on
+// their status and launches the failed ones again up to the maxRetries of the PolicyAutomation
+type AnsibleJobReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	// Clock is used to schedule the retries. It defaults to the real clock.
+	Clock clock.Clock
+}
+
+func (r *AnsibleJobReconciler) now() time.Time {
+	if r.Clock == nil {
+		return time.Now()
+	}
+
+	return r.Clock.Now()
+}
+
+// Reconcile handles the result of an AnsibleJob launched by a PolicyAutomation
+func (r *AnsibleJobReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+
+	ansibleJob := &unstructured.Unstructured{}
+	ansibleJob.SetGroupVersionKind(common.AnsibleJobGVK)
+
+	err := r.Get(ctx, request.NamespacedName, ansibleJob)
+	if err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+
+	policyAutomation := &policyv1beta1.PolicyAutomation{}
+
+	err = r.Get(ctx, types.NamespacedName{
+		Namespace: request.Namespace, Name: ansibleJob.GetLabels()[common.PolicyAutomationLabel],
+	}, policyAutomation)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			reqLogger.Info("The PolicyAutomation of the AnsibleJob was deleted, doing nothing...")
+
+			return reconcile.Result{}, nil
+		}
+
+		return reconcile.Result{}, err
+	}
+
+	result, _, _ := unstructured.NestedString(ansibleJob.Object, "status", "ansibleJobResult", "status")
+	url, _, _ := unstructured.NestedString(ansibleJob.Object, "status", "ansibleJobResult", "url")
+
+	condition := metav1.Condition{Type: policyv1beta1.AnsibleJobSucceeded}
+
+	switch result {
+	case ansibleJobSuccessful:
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "Successful"
+		condition.Message = fmt.Sprintf("The AnsibleJob %s succeeded", ansibleJob.GetName())
+	case ansibleJobFailed, ansibleJobError, ansibleJobCanceled:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "Failed"
+		condition.Message = fmt.Sprintf("The AnsibleJob %s finished with the status %s", ansibleJob.GetName(), result)
+	default:
+		condition.Status = metav1.ConditionUnknown
+		condition.Reason = "Running"
+		condition.Message = fmt.Sprintf("The AnsibleJob %s is running", ansibleJob.GetName())
+	}
+
+	if url != "" {
+		condition.Message += ": " + url
+	}
+
+	err = r.updateStatus(ctx, policyAutomation, ansibleJob, url, condition)
+	if err != nil {
+		reqLogger.Error(err, "Failed to update the status of the PolicyAutomation...")
+
+		return reconcile.Result{}, err
+	}
+
+	if condition.Status != metav1.ConditionFalse {
+		return reconcile.Result{}, nil
+	}
+
+	return r.retry(ctx, policyAutomation, ansibleJob)
+}
+
+// updateStatus records the result of the AnsibleJob on the PolicyAutomation if it is the last
+// AnsibleJob it launched, and records an event when the AnsibleJob finishes
+func (r *AnsibleJobReconciler) updateStatus(
+	ctx context.Context, policyAutomation *policyv1beta1.PolicyAutomation, ansibleJob *unstructured.Unstructured,
+	url string, condition metav1.Condition,
+) error {
+	status := &policyAutomation.Status
+
+	// An older AnsibleJob finishing doesn't replace the result of a newer one
+	if status.LastAnsibleJob != "" && status.LastAnsibleJob != ansibleJob.GetName() {
+		lastAnsibleJob := &unstructured.Unstructured{}
+		lastAnsibleJob.SetGroupVersionKind(common.AnsibleJobGVK)
+
+		err := r.Get(ctx, types.NamespacedName{
+			Namespace: ansibleJob.GetNamespace(), Name: status.LastAnsibleJob,
+		}, lastAnsibleJob)
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+
+		if err == nil && ansibleJob.GetCreationTimestamp().Time.Before(lastAnsibleJob.GetCreationTimestamp().Time) {
+			return nil
+		}
+	}
+
+	existing := meta.FindStatusCondition(status.Conditions, condition.Type)
+	if status.LastAnsibleJob == ansibleJob.GetName() && status.LastAnsibleJobURL == url && existing != nil &&
+		existing.Status == condition.Status && existing.Message == condition.Message {
+		return nil
+	}
+
+	status.LastAnsibleJob = ansibleJob.GetName()
+	status.LastAnsibleJobURL = url
+	meta.SetStatusCondition(&status.Conditions, condition)
+
+	err := r.Status().Update(ctx, policyAutomation)
+	if err != nil {
+		return err
+	}
+
+	if condition.Status == metav1.ConditionTrue {
+		r.Recorder.Event(policyAutomation, "Normal", "AnsibleJobSucceeded", condition.Message)
+	} else if condition.Status == metav1.ConditionFalse {
+		r.Recorder.Event(policyAutomation, "Warning", "AnsibleJobFailed", condition.Message)
+	}
+
+	return nil
+}
+
+// retry launches the failed AnsibleJob again after the backoff if the PolicyAutomation allows more
+// retries
+func (r *AnsibleJobReconciler) retry(
+	ctx context.Context, policyAutomation *policyv1beta1.PolicyAutomation, ansibleJob *unstructured.Unstructured,
+) (ctrl.Result, error) {
+	reqLogger := log.WithValues("Request.Namespace", ansibleJob.GetNamespace(), "Request.Name", ansibleJob.GetName())
+
+	annotations := ansibleJob.GetAnnotations()
+	if annotations[retriedByAnnotation] != "" {
+		return reconcile.Result{}, nil
+	}
+
+	retries, _ := strconv.Atoi(ansibleJob.GetLabels()[retriesLabel])
+	if retries >= policyAutomation.Spec.Automation.MaxRetries {
+		return reconcile.Result{}, nil
+	}
+
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+
+	retryAfter, err := time.Parse(time.RFC3339, annotations[retryAfterAnnotation])
+	if err != nil {
+		// Schedule the retry on the AnsibleJob so that the backoff survives restarts
+		retryAfter = r.now().Add(retryDelay(retries)).UTC()
+		annotations[retryAfterAnnotation] = retryAfter.Format(time.RFC3339)
+		ansibleJob.SetAnnotations(annotations)
+
+		err = r.Update(ctx, ansibleJob)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	if wait := retryAfter.Sub(r.now()); wait > 0 {
+		reqLogger.Info("Retrying the failed AnsibleJob later...", "RetryAfter", retryAfter)
+
+		return reconcile.Result{RequeueAfter: wait}, nil
+	}
+
+	retryJob := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": ansibleJob.GetAPIVersion(),
+		"kind":       ansibleJob.GetKind(),
+		"spec":       ansibleJob.Object["spec"],
+	}}
+	retryJob.SetNamespace(ansibleJob.GetNamespace())
+	retryJob.SetGenerateName(policyAutomation.GetName() + "-retry-")
+	retryJob.SetLabels(map[string]string{
+		common.PolicyAutomationLabel: policyAutomation.GetName(),
+		retriesLabel:                 strconv.Itoa(retries + 1),
+	})
+	retryJob.SetOwnerReferences([]metav1.OwnerReference{
+		*metav1.NewControllerRef(policyAutomation, policyv1beta1.GroupVersion.WithKind("PolicyAutomation")),
+	})
+
+	reqLogger.Info("Retrying the failed AnsibleJob...", "Retry", retries+1)
+
+	err = r.Create(ctx, retryJob)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	annotations[retriedByAnnotation] = retryJob.GetName()
+	ansibleJob.SetAnnotations(annotations)
+
+	err = r.Update(ctx, ansibleJob)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	r.Recorder.Event(policyAutomation, "Normal", "AnsibleJobRetried",
+		fmt.Sprintf("The failed AnsibleJob %s was launched again as %s (retry %d of %d)", ansibleJob.GetName(),
+			retryJob.GetName(), retries+1, policyAutomation.Spec.Automation.MaxRetries))
+
+	return reconcile.Result{}, nil
+}
+
+// retryDelay returns how long to wait before retrying an AnsibleJob that was already retried the
+// given number of times
+func retryDelay(retries int) time.Duration {
+	delay := retryDelayBase
+	for i := 0; i < retries && delay < retryDelayMax; i++ {
+		delay *= 2
+	}
+
+	if delay > retryDelayMax {
+		return retryDelayMax
+	}
+
+	return delay
+}
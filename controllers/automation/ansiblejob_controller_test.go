@@ -0,0 +1,178 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package automation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	policyv1beta1 "github.com/open-cluster-management/governance-policy-propagator/api/v1beta1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+)
+
+func newTestAnsibleJob(name string, result string) *unstructured.Unstructured {
+	ansibleJob := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{"job_template_name": "remediate"},
+		"status": map[string]interface{}{
+			"ansibleJobResult": map[string]interface{}{"status": result, "url": "https://aap.example.com/1"},
+		},
+	}}
+	ansibleJob.SetGroupVersionKind(common.AnsibleJobGVK)
+	ansibleJob.SetName(name)
+	ansibleJob.SetNamespace("policies")
+	ansibleJob.SetLabels(map[string]string{common.PolicyAutomationLabel: "automation"})
+
+	return ansibleJob
+}
+
+func newTestAnsibleJobReconciler(t *testing.T, objects ...client.Object) (*AnsibleJobReconciler, *clock.FakeClock) {
+	t.Helper()
+
+	scheme := runtime.NewScheme()
+	if err := policyv1beta1.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to build the scheme: %v", err)
+	}
+
+	scheme.AddKnownTypeWithName(common.AnsibleJobGVK, &unstructured.Unstructured{})
+	scheme.AddKnownTypeWithName(
+		common.AnsibleJobGVK.GroupVersion().WithKind("AnsibleJobList"), &unstructured.UnstructuredList{},
+	)
+
+	fakeClock := clock.NewFakeClock(time.Now())
+
+	return &AnsibleJobReconciler{
+		Client:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(),
+		Scheme:   scheme,
+		Recorder: record.NewFakeRecorder(10),
+		Clock:    fakeClock,
+	}, fakeClock
+}
+
+func newTestPolicyAutomation(maxRetries int) *policyv1beta1.PolicyAutomation {
+	return &policyv1beta1.PolicyAutomation{
+		ObjectMeta: metav1.ObjectMeta{Name: "automation", Namespace: "policies"},
+		Spec: policyv1beta1.PolicyAutomationSpec{
+			PolicyRef: "policy",
+			Mode:      "once",
+			Automation: policyv1beta1.AutomationDef{
+				Name: "remediate", TowerSecret: "tower", MaxRetries: maxRetries,
+			},
+		},
+	}
+}
+
+func TestAnsibleJobSucceeded(t *testing.T) {
+	r, _ := newTestAnsibleJobReconciler(
+		t, newTestPolicyAutomation(0), newTestAnsibleJob("automation-once-1", ansibleJobSuccessful),
+	)
+
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "policies", Name: "automation-once-1"}}
+	if _, err := r.Reconcile(context.TODO(), request); err != nil {
+		t.Fatalf("Reconcile returned an error: %v", err)
+	}
+
+	policyAutomation := &policyv1beta1.PolicyAutomation{}
+	key := types.NamespacedName{Namespace: "policies", Name: "automation"}
+
+	if err := r.Get(context.TODO(), key, policyAutomation); err != nil {
+		t.Fatalf("failed to get the PolicyAutomation: %v", err)
+	}
+
+	if policyAutomation.Status.LastAnsibleJob != "automation-once-1" ||
+		policyAutomation.Status.LastAnsibleJobURL != "https://aap.example.com/1" {
+		t.Fatalf("expected the AnsibleJob in the status, got %+v", policyAutomation.Status)
+	}
+
+	if !meta.IsStatusConditionTrue(policyAutomation.Status.Conditions, policyv1beta1.AnsibleJobSucceeded) {
+		t.Fatalf("expected the AnsibleJobSucceeded condition to be true, got %v", policyAutomation.Status.Conditions)
+	}
+}
+
+func TestAnsibleJobRetry(t *testing.T) {
+	r, fakeClock := newTestAnsibleJobReconciler(
+		t, newTestPolicyAutomation(1), newTestAnsibleJob("automation-once-1", ansibleJobFailed),
+	)
+
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "policies", Name: "automation-once-1"}}
+
+	result, err := r.Reconcile(context.TODO(), request)
+	if err != nil {
+		t.Fatalf("Reconcile returned an error: %v", err)
+	}
+
+	if result.RequeueAfter != retryDelayBase {
+		t.Fatalf("expected the retry to be scheduled after %v, got %v", retryDelayBase, result.RequeueAfter)
+	}
+
+	fakeClock.Step(retryDelayBase)
+
+	if _, err := r.Reconcile(context.TODO(), request); err != nil {
+		t.Fatalf("Reconcile returned an error: %v", err)
+	}
+
+	ansibleJobs := listAnsibleJobs(t, r)
+
+	var retryJob *unstructured.Unstructured
+
+	for i := range ansibleJobs {
+		if ansibleJobs[i].GetName() != "automation-once-1" {
+			retryJob = &ansibleJobs[i]
+		}
+	}
+
+	if retryJob == nil || retryJob.GetLabels()[retriesLabel] != "1" {
+		t.Fatalf("expected the AnsibleJob to be retried once, got %v", ansibleJobs)
+	}
+
+	// The retry failing doesn't launch another AnsibleJob since there are no retries left
+	retryJob.Object["status"] = map[string]interface{}{
+		"ansibleJobResult": map[string]interface{}{"status": ansibleJobFailed},
+	}
+	if err := r.Update(context.TODO(), retryJob); err != nil {
+		t.Fatalf("failed to update the retried AnsibleJob: %v", err)
+	}
+
+	retryRequest := ctrl.Request{NamespacedName: types.NamespacedName{
+		Namespace: "policies", Name: retryJob.GetName(),
+	}}
+
+	result, err = r.Reconcile(context.TODO(), retryRequest)
+	if err != nil || result.RequeueAfter != 0 {
+		t.Fatalf("expected no more retries, got %+v (error: %v)", result, err)
+	}
+
+	if ansibleJobs := len(listAnsibleJobs(t, r)); ansibleJobs != 2 {
+		t.Fatalf("expected two AnsibleJobs, got %d", ansibleJobs)
+	}
+}
+
+func listAnsibleJobs(t *testing.T, r *AnsibleJobReconciler) []unstructured.Unstructured {
+	t.Helper()
+
+	ansibleJobList := &unstructured.UnstructuredList{}
+	ansibleJobList.SetGroupVersionKind(common.AnsibleJobGVK.GroupVersion().WithKind("AnsibleJobList"))
+
+	if err := r.List(context.TODO(), ansibleJobList, client.InNamespace("policies")); err != nil {
+		t.Fatalf("failed to list the AnsibleJobs: %v", err)
+	}
+
+	return ansibleJobList.Items
+}
+
+func TestRetryDelay(t *testing.T) {
+	if retryDelay(0) != retryDelayBase || retryDelay(1) != 2*retryDelayBase || retryDelay(10) != retryDelayMax {
+		t.Fatal("expected the retry delay to double up to the maximum")
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package automation
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	policyv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	policyv1beta1 "github.com/open-cluster-management/governance-policy-propagator/api/v1beta1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+)
+
+// everyEvent runs the automation for the clusters that became NonCompliant since its last run for
+// them. A cluster staying NonCompliant only runs the automation once, and the automation runs at
+// most once per delayAfterRunSeconds for a cluster.
+func (r *PolicyAutomationReconciler) everyEvent(
+	ctx context.Context, policyAutomation *policyv1beta1.PolicyAutomation, policy *policyv1.Policy,
+) (ctrl.Result, error) {
+	reqLogger := log.WithValues("Request.Namespace", policyAutomation.GetNamespace(),
+		"Request.Name", policyAutomation.GetName())
+	reqLogger.Info("Triggering everyEvent mode...")
+
+	now := r.now().UTC()
+	delay := time.Duration(policyAutomation.Spec.DelayAfterRunSeconds) * time.Second
+
+	original := policyAutomation.Status.ClustersWithEvent
+	clusterEvents := make(map[string]policyv1beta1.ClusterEvent, len(original))
+
+	for cluster, clusterEvent := range original {
+		clusterEvents[cluster] = clusterEvent
+	}
+
+	noncompliant := map[string]bool{}
+	for _, cluster := range common.FindNonCompliantClustersForPolicy(policy) {
+		noncompliant[cluster] = true
+	}
+
+	targetList := []string{}
+	var requeueAfter time.Duration
+
+	for cluster, clusterEvent := range clusterEvents {
+		if noncompliant[cluster] {
+			continue
+		}
+
+		// The next violation of the cluster runs the automation again once the delay expires
+		if !now.Before(nextRun(clusterEvent, delay)) {
+			delete(clusterEvents, cluster)
+		} else if clusterEvent.EventTime != "" {
+			clusterEvent.EventTime = ""
+			clusterEvents[cluster] = clusterEvent
+		}
+	}
+
+	for cluster := range noncompliant {
+		clusterEvent, ok := clusterEvents[cluster]
+		if !ok {
+			targetList = append(targetList, cluster)
+
+			continue
+		}
+
+		if clusterEvent.EventTime == "" {
+			// The cluster violated the policy again during the delay
+			clusterEvent.EventTime = now.Format(time.RFC3339)
+			clusterEvents[cluster] = clusterEvent
+		}
+
+		if clusterEvent.EventTime == clusterEvent.AutomationStartTime {
+			// The automation already ran for this violation
+			continue
+		}
+
+		if wait := nextRun(clusterEvent, delay).Sub(now); wait > 0 {
+			if requeueAfter == 0 || wait < requeueAfter {
+				requeueAfter = wait
+			}
+
+			continue
+		}
+
+		targetList = append(targetList, cluster)
+	}
+
+	if len(targetList) > 0 {
+		sort.Strings(targetList)
+
+		reqLogger.Info("Creating the automation job with targetList", "targetList", targetList)
+
+		err := common.CreateAutomation(policyAutomation, r.DynamicClient, "everyEvent", targetList)
+		if err != nil {
+			reqLogger.Error(err, "Failed to create the automation job...")
+
+			return reconcile.Result{}, err
+		}
+
+		for _, cluster := range targetList {
+			clusterEvents[cluster] = policyv1beta1.ClusterEvent{
+				AutomationStartTime: now.Format(time.RFC3339),
+				EventTime:           now.Format(time.RFC3339),
+			}
+		}
+
+		if delay > 0 && (requeueAfter == 0 || delay < requeueAfter) {
+			requeueAfter = delay
+		}
+	} else {
+		reqLogger.Info("No cluster became noncompliant since the last run, doing nothing...")
+	}
+
+	if len(clusterEvents) == 0 {
+		clusterEvents = nil
+	}
+
+	if !equality.Semantic.DeepEqual(clusterEvents, original) {
+		policyAutomation.Status.ClustersWithEvent = clusterEvents
+
+		err := r.Status().Update(ctx, policyAutomation)
+		if err != nil {
+			reqLogger.Error(err, "Failed to update the clusters with event...")
+
+			return reconcile.Result{}, err
+		}
+	}
+
+	return reconcile.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// nextRun returns when the automation may run again for the cluster
+func nextRun(clusterEvent policyv1beta1.ClusterEvent, delay time.Duration) time.Time {
+	started, err := time.Parse(time.RFC3339, clusterEvent.AutomationStartTime)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return started.Add(delay)
+}
@@ -0,0 +1,155 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package automation
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	policyv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	policyv1beta1 "github.com/open-cluster-management/governance-policy-propagator/api/v1beta1"
+)
+
+var jobRes = schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "jobs"}
+
+func TestEveryEvent(t *testing.T) {
+	scheme := runtime.NewScheme()
+	for _, addToScheme := range []func(*runtime.Scheme) error{policyv1.AddToScheme, policyv1beta1.AddToScheme} {
+		if err := addToScheme(scheme); err != nil {
+			t.Fatalf("failed to build the scheme: %v", err)
+		}
+	}
+
+	policy := &policyv1.Policy{
+		ObjectMeta: metav1.ObjectMeta{Name: "policy", Namespace: "policies"},
+		Status: policyv1.PolicyStatus{
+			ComplianceState: policyv1.NonCompliant,
+			Status: []*policyv1.CompliancePerClusterStatus{
+				{ClusterName: "cluster1", ClusterNamespace: "cluster1", ComplianceState: policyv1.NonCompliant},
+				{ClusterName: "cluster2", ClusterNamespace: "cluster2", ComplianceState: policyv1.Compliant},
+			},
+		},
+	}
+	policyAutomation := &policyv1beta1.PolicyAutomation{
+		ObjectMeta: metav1.ObjectMeta{Name: "automation", Namespace: "policies"},
+		Spec: policyv1beta1.PolicyAutomationSpec{
+			PolicyRef:            "policy",
+			Mode:                 "everyEvent",
+			DelayAfterRunSeconds: 60,
+			Automation: policyv1beta1.AutomationDef{
+				Type: policyv1beta1.AutomationTypeJob,
+				Job:  &policyv1beta1.JobDef{Image: "quay.io/example/remediate:latest"},
+			},
+		},
+	}
+
+	fakeClock := clock.NewFakeClock(time.Date(2021, 8, 1, 0, 0, 0, 0, time.UTC))
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(
+		runtime.NewScheme(), map[schema.GroupVersionResource]string{jobRes: "JobList"},
+	)
+	// The fake client doesn't generate names
+	created := 0
+	dynamicClient.PrependReactor("create", "jobs", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		created++
+		job := action.(clienttesting.CreateAction).GetObject().(*unstructured.Unstructured)
+		job.SetName(fmt.Sprintf("%s%d", job.GetGenerateName(), created))
+
+		return false, nil, nil
+	})
+
+	r := &PolicyAutomationReconciler{
+		Client:        fake.NewClientBuilder().WithScheme(scheme).WithObjects(policy, policyAutomation).Build(),
+		DynamicClient: dynamicClient,
+		Scheme:        scheme,
+		Recorder:      record.NewFakeRecorder(10),
+		Clock:         fakeClock,
+	}
+
+	reconcileAutomation := func(expectedJobs int) ctrl.Result {
+		t.Helper()
+
+		result, err := r.Reconcile(context.TODO(), ctrl.Request{
+			NamespacedName: types.NamespacedName{Namespace: "policies", Name: "automation"},
+		})
+		if err != nil {
+			t.Fatalf("Reconcile returned an error: %v", err)
+		}
+
+		jobs, err := dynamicClient.Resource(jobRes).Namespace("policies").List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			t.Fatalf("failed to list the Jobs: %v", err)
+		}
+
+		if len(jobs.Items) != expectedJobs {
+			t.Fatalf("expected %d automation runs, got %d", expectedJobs, len(jobs.Items))
+		}
+
+		return result
+	}
+
+	setCompliance := func(cluster1 policyv1.ComplianceState, cluster2 policyv1.ComplianceState) {
+		t.Helper()
+
+		policy.Status.Status[0].ComplianceState = cluster1
+		policy.Status.Status[1].ComplianceState = cluster2
+
+		if err := r.Update(context.TODO(), policy); err != nil {
+			t.Fatalf("failed to update the policy: %v", err)
+		}
+	}
+
+	reconcileAutomation(1)
+
+	// A cluster staying NonCompliant doesn't run the automation again
+	result := reconcileAutomation(1)
+	if result.RequeueAfter != 0 {
+		t.Fatalf("expected no requeue, got %v", result.RequeueAfter)
+	}
+
+	// Another cluster becoming NonCompliant runs the automation for it
+	setCompliance(policyv1.NonCompliant, policyv1.NonCompliant)
+	reconcileAutomation(2)
+
+	// A violation during the delay is handled once it expires
+	setCompliance(policyv1.Compliant, policyv1.NonCompliant)
+	reconcileAutomation(2)
+
+	setCompliance(policyv1.NonCompliant, policyv1.NonCompliant)
+	fakeClock.Step(30 * time.Second)
+
+	result = reconcileAutomation(2)
+	if result.RequeueAfter != 30*time.Second {
+		t.Fatalf("expected a requeue when the delay expires, got %v", result.RequeueAfter)
+	}
+
+	fakeClock.Step(30 * time.Second)
+	reconcileAutomation(3)
+
+	// Clusters that are compliant after the delay are no longer tracked
+	setCompliance(policyv1.Compliant, policyv1.Compliant)
+	fakeClock.Step(time.Minute)
+	reconcileAutomation(3)
+
+	updated := &policyv1beta1.PolicyAutomation{}
+	if err := r.Get(context.TODO(), types.NamespacedName{Namespace: "policies", Name: "automation"}, updated); err != nil {
+		t.Fatalf("failed to get the PolicyAutomation: %v", err)
+	}
+
+	if len(updated.Status.ClustersWithEvent) != 0 {
+		t.Fatalf("expected no clusters with event, got %v", updated.Status.ClustersWithEvent)
+	}
+}
@@ -34,7 +34,7 @@ func policyMapper(c client.Client) handler.MapFunc {
 		if found {
 			if policyAutomation.Spec.Mode == "scan" {
 				// scan mode, do not queue
-			} else if policyAutomation.Spec.Mode == "once" {
+			} else if policyAutomation.Spec.Mode == "once" || policyAutomation.Spec.Mode == "everyEvent" {
 				request := reconcile.Request{NamespacedName: types.NamespacedName{
 					Name:      policyAutomation.GetName(),
 					Namespace: policyAutomation.GetNamespace(),
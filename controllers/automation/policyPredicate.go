@@ -4,6 +4,7 @@ package automation
 
 import (
 	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 )
@@ -16,7 +17,9 @@ var policyPredicateFuncs = predicate.Funcs{
 			return false
 		}
 		plcObjOld := e.ObjectOld.(*policiesv1.Policy)
-		return plcObjNew.Status.ComplianceState != plcObjOld.Status.ComplianceState
+		// The compliance of a single cluster changing matters to the everyEvent mode
+		return plcObjNew.Status.ComplianceState != plcObjOld.Status.ComplianceState ||
+			!equality.Semantic.DeepEqual(plcObjNew.Status.Status, plcObjOld.Status.Status)
 	},
 	CreateFunc: func(e event.CreateEvent) bool {
 		return false
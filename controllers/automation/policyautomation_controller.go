@@ -11,6 +11,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -33,6 +34,8 @@ var log = logf.Log.WithName(ControllerName)
 //+kubebuilder:rbac:groups=policy.open-cluster-management.io,resources=policyautomations/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=policy.open-cluster-management.io,resources=policyautomations/finalizers,verbs=update
 //+kubebuilder:rbac:groups=tower.ansible.com,resources=ansiblejobs,verbs=get;list;watch;create;update;patch;delete;deletecollection
+//+kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=tekton.dev,resources=pipelineruns,verbs=get;list;watch;create;update;patch;delete
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *PolicyAutomationReconciler) SetupWithManager(mgr ctrl.Manager) error {
@@ -57,7 +60,17 @@ type PolicyAutomationReconciler struct {
 	DynamicClient dynamic.Interface
 	Scheme        *runtime.Scheme
 	Recorder      record.EventRecorder
-	counter       int
+	// Clock is used to delay the runs of the everyEvent mode. It defaults to the real clock.
+	Clock   clock.Clock
+	counter int
+}
+
+func (r *PolicyAutomationReconciler) now() time.Time {
+	if r.Clock == nil {
+		return time.Now()
+	}
+
+	return r.Clock.Now()
 }
 
 // Reconcile reads that state of the cluster for a Policy object and makes changes based on the state read
@@ -92,9 +105,9 @@ func (r *PolicyAutomationReconciler) Reconcile(ctx context.Context, request ctrl
 
 	if policyAutomation.Annotations["policy.open-cluster-management.io/rerun"] == "true" {
 		reqLogger.Info("Triggering manual run...")
-		err = common.CreateAnsibleJob(policyAutomation, r.DynamicClient, "manual", nil)
+		err = common.CreateAutomation(policyAutomation, r.DynamicClient, "manual", nil)
 		if err != nil {
-			reqLogger.Error(err, "Failed to create ansible job...")
+			reqLogger.Error(err, "Failed to create the automation job...")
 			return reconcile.Result{}, err
 		}
 		// manual run suceeded, remove annotation
@@ -137,8 +150,8 @@ func (r *PolicyAutomationReconciler) Reconcile(ctx context.Context, request ctrl
 			}
 			targetList := common.FindNonCompliantClustersForPolicy(policy)
 			if len(targetList) > 0 {
-				reqLogger.Info("Creating ansible job with targetList", "targetList", targetList)
-				err = common.CreateAnsibleJob(policyAutomation, r.DynamicClient, "scan", targetList)
+				reqLogger.Info("Creating the automation job with targetList", "targetList", targetList)
+				err = common.CreateAutomation(policyAutomation, r.DynamicClient, "scan", targetList)
 				if err != nil {
 					return reconcile.Result{RequeueAfter: requeueAfter}, err
 				}
@@ -150,14 +163,16 @@ func (r *PolicyAutomationReconciler) Reconcile(ctx context.Context, request ctrl
 			r.counter++
 			reqLogger.Info("RequeueAfter.", "RequeueAfter", requeueAfter.String(), "Counter", fmt.Sprintf("%d", r.counter))
 			return reconcile.Result{RequeueAfter: requeueAfter}, nil
+		} else if policyAutomation.Spec.Mode == "everyEvent" {
+			return r.everyEvent(ctx, policyAutomation, policy)
 		} else if policyAutomation.Spec.Mode == "once" {
 			reqLogger.Info("Triggering once mode...")
 			targetList := common.FindNonCompliantClustersForPolicy(policy)
 			if len(targetList) > 0 {
-				reqLogger.Info("Creating ansible job with targetList", "targetList", targetList)
-				err = common.CreateAnsibleJob(policyAutomation, r.DynamicClient, "once", targetList)
+				reqLogger.Info("Creating the automation job with targetList", "targetList", targetList)
+				err = common.CreateAutomation(policyAutomation, r.DynamicClient, "once", targetList)
 				if err != nil {
-					reqLogger.Error(err, "Failed to create ansible job...")
+					reqLogger.Error(err, "Failed to create the automation job...")
 					return reconcile.Result{}, err
 				}
 				policyAutomation.Spec.Mode = "disabled"
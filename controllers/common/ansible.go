@@ -5,6 +5,7 @@ package common
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -15,9 +16,22 @@ import (
 	policyv1beta1 "github.com/open-cluster-management/governance-policy-propagator/api/v1beta1"
 )
 
+// PolicyAutomationLabel is set on the AnsibleJobs with the name of the PolicyAutomation that
+// launched them
+const PolicyAutomationLabel = "policy.open-cluster-management.io/policyautomation"
+
+// AnsibleJobGVK is the group, version, and kind of the AnsibleJobs launched by PolicyAutomations
+var AnsibleJobGVK = schema.GroupVersionKind{Group: "tower.ansible.com", Version: "v1alpha1", Kind: "AnsibleJob"}
+
 // CreateAnsibleJob creates ansiblejob with given PolicyAutomation
 func CreateAnsibleJob(policyAutomation *policyv1beta1.PolicyAutomation,
 	dynamicClient dynamic.Interface, mode string, targetClusters []string) error {
+	if policyAutomation.Spec.Automation.Name == "" || policyAutomation.Spec.Automation.TowerSecret == "" {
+		return fmt.Errorf(
+			"the automation type %s requires the name and secret fields", policyv1beta1.AutomationTypeAnsibleJob,
+		)
+	}
+
 	ansibleJob := &unstructured.Unstructured{
 		Object: map[string]interface{}{
 			"apiVersion": "tower.ansible.com/v1alpha1",
@@ -46,6 +60,7 @@ func CreateAnsibleJob(policyAutomation *policyv1beta1.PolicyAutomation,
 	ansibleJobRes := schema.GroupVersionResource{Group: "tower.ansible.com", Version: "v1alpha1",
 		Resource: "ansiblejobs"}
 	ansibleJob.SetGenerateName(policyAutomation.GetName() + "-" + mode + "-")
+	ansibleJob.SetLabels(map[string]string{PolicyAutomationLabel: policyAutomation.GetName()})
 	ansibleJob.SetOwnerReferences([]metav1.OwnerReference{
 		*metav1.NewControllerRef(policyAutomation, policyAutomation.GroupVersionKind()),
 	})
@@ -0,0 +1,166 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package common
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	policyv1beta1 "github.com/open-cluster-management/governance-policy-propagator/api/v1beta1"
+)
+
+// CreateAutomation invokes the automation of the given PolicyAutomation based on its type
+func CreateAutomation(policyAutomation *policyv1beta1.PolicyAutomation,
+	dynamicClient dynamic.Interface, mode string, targetClusters []string) error {
+	switch policyAutomation.Spec.Automation.Type {
+	case "", policyv1beta1.AutomationTypeAnsibleJob:
+		return CreateAnsibleJob(policyAutomation, dynamicClient, mode, targetClusters)
+	case policyv1beta1.AutomationTypeJob:
+		return CreateJob(policyAutomation, dynamicClient, mode, targetClusters)
+	case policyv1beta1.AutomationTypePipelineRun:
+		return CreatePipelineRun(policyAutomation, dynamicClient, mode, targetClusters)
+	default:
+		return fmt.Errorf("the automation type %s is not supported", policyAutomation.Spec.Automation.Type)
+	}
+}
+
+// CreateJob creates a Kubernetes Job on the hub with given PolicyAutomation. The violation is
+// passed to the Job's container through environment variables.
+func CreateJob(policyAutomation *policyv1beta1.PolicyAutomation,
+	dynamicClient dynamic.Interface, mode string, targetClusters []string) error {
+	jobDef := policyAutomation.Spec.Automation.Job
+	if jobDef == nil {
+		return fmt.Errorf("the automation type %s requires the job field", policyv1beta1.AutomationTypeJob)
+	}
+
+	extraVars := "{}"
+	if policyAutomation.Spec.Automation.ExtraVars != nil {
+		extraVars = string(policyAutomation.Spec.Automation.ExtraVars.Raw)
+	}
+
+	env := []corev1.EnvVar{
+		{Name: "POLICY_NAME", Value: policyAutomation.Spec.PolicyRef},
+		{Name: "POLICY_NAMESPACE", Value: policyAutomation.GetNamespace()},
+		{Name: "AUTOMATION_MODE", Value: mode},
+		{Name: "TARGET_CLUSTERS", Value: strings.Join(targetClusters, ",")},
+		{Name: "EXTRA_VARS", Value: extraVars},
+	}
+	env = append(env, jobDef.Env...)
+
+	job := &batchv1.Job{
+		TypeMeta: metav1.TypeMeta{APIVersion: "batch/v1", Kind: "Job"},
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: policyAutomation.GetName() + "-" + mode + "-",
+			Namespace:    policyAutomation.GetNamespace(),
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(policyAutomation, policyAutomation.GroupVersionKind()),
+			},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: jobDef.BackoffLimit,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					RestartPolicy:      corev1.RestartPolicyNever,
+					ServiceAccountName: jobDef.ServiceAccountName,
+					Containers: []corev1.Container{{
+						Name:    "automation",
+						Image:   jobDef.Image,
+						Command: jobDef.Command,
+						Args:    jobDef.Args,
+						Env:     env,
+					}},
+				},
+			},
+		},
+	}
+
+	unstructuredJob, err := runtime.DefaultUnstructuredConverter.ToUnstructured(job)
+	if err != nil {
+		return err
+	}
+
+	jobRes := schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "jobs"}
+	_, err = dynamicClient.Resource(jobRes).Namespace(policyAutomation.GetNamespace()).
+		Create(context.TODO(), &unstructured.Unstructured{Object: unstructuredJob}, metav1.CreateOptions{})
+
+	return err
+}
+
+// violationContext is the data available to the templated parameters of a PipelineRun
+type violationContext struct {
+	PolicyName      string
+	PolicyNamespace string
+	Mode            string
+	TargetClusters  []string
+}
+
+// CreatePipelineRun creates a Tekton PipelineRun on the hub with given PolicyAutomation. The
+// parameter values are resolved as Go templates using the violation.
+func CreatePipelineRun(policyAutomation *policyv1beta1.PolicyAutomation,
+	dynamicClient dynamic.Interface, mode string, targetClusters []string) error {
+	pipelineRunDef := policyAutomation.Spec.Automation.PipelineRun
+	if pipelineRunDef == nil {
+		return fmt.Errorf(
+			"the automation type %s requires the pipelineRun field", policyv1beta1.AutomationTypePipelineRun,
+		)
+	}
+
+	violation := violationContext{
+		PolicyName:      policyAutomation.Spec.PolicyRef,
+		PolicyNamespace: policyAutomation.GetNamespace(),
+		Mode:            mode,
+		TargetClusters:  targetClusters,
+	}
+
+	params := make([]interface{}, 0, len(pipelineRunDef.Params))
+	for _, param := range pipelineRunDef.Params {
+		tmpl, err := template.New(param.Name).Funcs(template.FuncMap{"join": strings.Join}).Parse(param.Value)
+		if err != nil {
+			return fmt.Errorf("failed to parse the value of the parameter %s: %w", param.Name, err)
+		}
+
+		var value bytes.Buffer
+		if err := tmpl.Execute(&value, violation); err != nil {
+			return fmt.Errorf("failed to resolve the value of the parameter %s: %w", param.Name, err)
+		}
+
+		params = append(params, map[string]interface{}{"name": param.Name, "value": value.String()})
+	}
+
+	spec := map[string]interface{}{
+		"pipelineRef": map[string]interface{}{"name": pipelineRunDef.PipelineRef},
+		"params":      params,
+	}
+	if pipelineRunDef.ServiceAccountName != "" {
+		spec["serviceAccountName"] = pipelineRunDef.ServiceAccountName
+	}
+
+	pipelineRun := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "tekton.dev/v1beta1",
+			"kind":       "PipelineRun",
+			"spec":       spec,
+		},
+	}
+	pipelineRun.SetGenerateName(policyAutomation.GetName() + "-" + mode + "-")
+	pipelineRun.SetOwnerReferences([]metav1.OwnerReference{
+		*metav1.NewControllerRef(policyAutomation, policyAutomation.GroupVersionKind()),
+	})
+
+	pipelineRunRes := schema.GroupVersionResource{Group: "tekton.dev", Version: "v1beta1", Resource: "pipelineruns"}
+	_, err := dynamicClient.Resource(pipelineRunRes).Namespace(policyAutomation.GetNamespace()).
+		Create(context.TODO(), pipelineRun, metav1.CreateOptions{})
+
+	return err
+}
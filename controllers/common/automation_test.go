@@ -0,0 +1,132 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package common
+
+import (
+	"context"
+	"testing"
+
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	policyv1beta1 "github.com/open-cluster-management/governance-policy-propagator/api/v1beta1"
+)
+
+func TestCreateAutomationJob(t *testing.T) {
+	jobRes := schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "jobs"}
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(
+		runtime.NewScheme(), map[schema.GroupVersionResource]string{jobRes: "JobList"},
+	)
+	policyAutomation := &policyv1beta1.PolicyAutomation{
+		ObjectMeta: metav1.ObjectMeta{Name: "automation", Namespace: "policies"},
+		Spec: policyv1beta1.PolicyAutomationSpec{
+			PolicyRef: "policy",
+			Automation: policyv1beta1.AutomationDef{
+				Type: policyv1beta1.AutomationTypeJob,
+				Job:  &policyv1beta1.JobDef{Image: "quay.io/example/remediate:latest"},
+			},
+		},
+	}
+
+	err := CreateAutomation(policyAutomation, dynamicClient, "once", []string{"cluster1", "cluster2"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	jobList, err := dynamicClient.Resource(jobRes).Namespace("policies").List(context.TODO(), metav1.ListOptions{})
+	if err != nil || len(jobList.Items) != 1 {
+		t.Fatalf("Expected one Job to be created, got %v (err: %v)", jobList, err)
+	}
+
+	job := &batchv1.Job{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(jobList.Items[0].Object, job); err != nil {
+		t.Fatalf("Failed to convert the Job: %v", err)
+	}
+
+	env := map[string]string{}
+	for _, envVar := range job.Spec.Template.Spec.Containers[0].Env {
+		env[envVar.Name] = envVar.Value
+	}
+
+	if env["POLICY_NAME"] != "policy" || env["TARGET_CLUSTERS"] != "cluster1,cluster2" ||
+		env["AUTOMATION_MODE"] != "once" {
+		t.Fatalf("Unexpected environment variables on the Job: %v", env)
+	}
+}
+
+func TestCreateAutomationPipelineRun(t *testing.T) {
+	pipelineRunRes := schema.GroupVersionResource{Group: "tekton.dev", Version: "v1beta1", Resource: "pipelineruns"}
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(
+		runtime.NewScheme(), map[schema.GroupVersionResource]string{pipelineRunRes: "PipelineRunList"},
+	)
+	policyAutomation := &policyv1beta1.PolicyAutomation{
+		ObjectMeta: metav1.ObjectMeta{Name: "automation", Namespace: "policies"},
+		Spec: policyv1beta1.PolicyAutomationSpec{
+			PolicyRef: "policy",
+			Automation: policyv1beta1.AutomationDef{
+				Type: policyv1beta1.AutomationTypePipelineRun,
+				PipelineRun: &policyv1beta1.PipelineRunDef{
+					PipelineRef: "remediate",
+					Params: []policyv1beta1.PipelineParam{
+						{Name: "policy", Value: "{{ .PolicyNamespace }}/{{ .PolicyName }}"},
+						{Name: "clusters", Value: `{{ join .TargetClusters "," }}`},
+					},
+				},
+			},
+		},
+	}
+
+	err := CreateAutomation(policyAutomation, dynamicClient, "scan", []string{"cluster1", "cluster2"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	pipelineRunList, err := dynamicClient.Resource(pipelineRunRes).Namespace("policies").
+		List(context.TODO(), metav1.ListOptions{})
+	if err != nil || len(pipelineRunList.Items) != 1 {
+		t.Fatalf("Expected one PipelineRun to be created, got %v (err: %v)", pipelineRunList, err)
+	}
+
+	params, _, _ := unstructured.NestedSlice(pipelineRunList.Items[0].Object, "spec", "params")
+	expected := []string{"policies/policy", "cluster1,cluster2"}
+
+	for i, param := range params {
+		if value := param.(map[string]interface{})["value"]; value != expected[i] {
+			t.Fatalf("Expected the parameter value %s, got %v", expected[i], value)
+		}
+	}
+
+	policyAutomation.Spec.Automation.PipelineRun.Params[0].Value = "{{ .Unknown }}"
+
+	if err := CreateAutomation(policyAutomation, dynamicClient, "scan", nil); err == nil {
+		t.Fatal("Expected an error for an invalid parameter template")
+	}
+}
+
+func TestCreateAutomationInvalid(t *testing.T) {
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+
+	tests := map[string]policyv1beta1.AutomationDef{
+		"unknown type":        {Type: "Unknown"},
+		"missing job":         {Type: policyv1beta1.AutomationTypeJob},
+		"missing pipelineRun": {Type: policyv1beta1.AutomationTypePipelineRun},
+		"missing tower name":  {TowerSecret: "toweraccess"},
+	}
+
+	for name, automation := range tests {
+		t.Run(name, func(t *testing.T) {
+			policyAutomation := &policyv1beta1.PolicyAutomation{
+				ObjectMeta: metav1.ObjectMeta{Name: "automation", Namespace: "policies"},
+				Spec:       policyv1beta1.PolicyAutomationSpec{Automation: automation},
+			}
+
+			if err := CreateAutomation(policyAutomation, dynamicClient, "once", nil); err == nil {
+				t.Fatal("Expected an error")
+			}
+		})
+	}
+}
@@ -4,9 +4,13 @@
 package common
 
 import (
+	"context"
+
 	clusterv1 "github.com/open-cluster-management/api/cluster/v1"
 	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
-	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 const APIGroup string = "policy.open-cluster-management.io"
@@ -14,10 +18,52 @@ const ClusterNameLabel string = APIGroup + "/cluster-name"
 const ClusterNamespaceLabel string = APIGroup + "/cluster-namespace"
 const RootPolicyLabel string = APIGroup + "/root-policy"
 
+// KlusterletNamespaceAnnotation is set on the ManagedCluster of a hosted control plane to its
+// cluster namespace on the hub when the namespace isn't named after the cluster
+const KlusterletNamespaceAnnotation string = "import.open-cluster-management.io/klusterlet-namespace"
+
+// PolicyIDAnnotation is set on the policy templates of the replicated policies to the ID of the
+// policy template in the compliance events database
+const PolicyIDAnnotation string = APIGroup + "/policy-compliance-db-id"
+
+// ParentPolicyIDAnnotation is set on the replicated policies to the ID of their root policy in the
+// compliance events database
+const ParentPolicyIDAnnotation string = APIGroup + "/parent-policy-compliance-db-id"
+
+// TemplateLookupsAnnotation is set on the replicated policies to the JSON encoded versions of the
+// objects looked up by the hub templates of their root policy when they were rendered
+const TemplateLookupsAnnotation string = APIGroup + "/hub-template-lookups"
+
+// RootPolicyFinalizer is set on the root policies so that their replicated policies are deleted
+// before they are removed
+const RootPolicyFinalizer string = "propagator." + APIGroup + "/replicated-policy-cleanup"
+
+// TemplateUserAnnotation is set on the root policies by the mutating webhook to the user who last
+// changed their spec. The hub template lookups of the root policy may impersonate the user.
+const TemplateUserAnnotation string = APIGroup + "/template-user"
+
+// DependenciesAnnotation is set on the policy templates of the replicated policies to the JSON
+// encoded dependencies of their root policy and their extra dependencies, so that the policy
+// framework on the managed cluster can check the ones the propagator can't
+const DependenciesAnnotation string = APIGroup + "/dependencies"
+
+// ClusterNamespace returns the namespace on the hub of the managed cluster. It is named after the
+// cluster unless the HostedClusterNamespaces feature is enabled and the ManagedCluster of a hosted
+// control plane has the klusterlet namespace annotation.
+func ClusterNamespace(cluster *clusterv1.ManagedCluster) string {
+	if FeatureEnabled(HostedClusterNamespaces) {
+		if namespace := cluster.GetAnnotations()[KlusterletNamespaceAnnotation]; namespace != "" {
+			return namespace
+		}
+	}
+
+	return cluster.GetName()
+}
+
 // IsInClusterNamespace check if policy is in cluster namespace
 func IsInClusterNamespace(ns string, allClusters []clusterv1.ManagedCluster) bool {
-	for _, cluster := range allClusters {
-		if ns == cluster.GetName() {
+	for i := range allClusters {
+		if ns == ClusterNamespace(&allClusters[i]) {
 			return true
 		}
 	}
@@ -35,14 +81,6 @@ func FullNameForPolicy(plc *policiesv1.Policy) string {
 	return plc.GetNamespace() + "." + plc.GetName()
 }
 
-// CompareSpecAndAnnotation compares annotation and spec for given policies
-// true if matches, false if doesn't match
-func CompareSpecAndAnnotation(plc1 *policiesv1.Policy, plc2 *policiesv1.Policy) bool {
-	annotationMatch := equality.Semantic.DeepEqual(plc1.GetAnnotations(), plc2.GetAnnotations())
-	specMatch := equality.Semantic.DeepEqual(plc1.Spec, plc2.Spec)
-	return annotationMatch && specMatch
-}
-
 // IsPbForPoicy compares group and kind with policy group and kind for given pb
 func IsPbForPoicy(pb *policiesv1.PlacementBinding) bool {
 	subjects := pb.Subjects
@@ -56,6 +94,81 @@ func IsPbForPoicy(pb *policiesv1.PlacementBinding) bool {
 	return found
 }
 
+// IsPolicySubject returns true if the PlacementBinding subject refers to policies, either by name
+// or by label selector
+func IsPolicySubject(subject policiesv1.Subject) bool {
+	return subject.APIGroup == policiesv1.SchemeGroupVersion.Group && subject.Kind == policiesv1.Kind
+}
+
+// SubjectMatchesPolicy returns true if the PlacementBinding subject binds the given root policy,
+// either by name or, when the subject has no name, by its label selector. Replicated policies and
+// invalid label selectors never match.
+func SubjectMatchesPolicy(subject policiesv1.Subject, plc client.Object) bool {
+	if !IsPolicySubject(subject) {
+		return false
+	}
+
+	if subject.Name != "" {
+		return subject.Name == plc.GetName()
+	}
+
+	if subject.Selector == nil {
+		return false
+	}
+
+	if _, replicated := plc.GetLabels()[RootPolicyLabel]; replicated {
+		return false
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(subject.Selector)
+	if err != nil {
+		return false
+	}
+
+	return selector.Matches(labels.Set(plc.GetLabels()))
+}
+
+// PoliciesForSubject returns the names of the root policies in the namespace that the
+// PlacementBinding subject binds. A subject with a name is returned as is without checking that
+// the policy exists.
+func PoliciesForSubject(
+	ctx context.Context, c client.Client, namespace string, subject policiesv1.Subject,
+) ([]string, error) {
+	if !IsPolicySubject(subject) {
+		return nil, nil
+	}
+
+	if subject.Name != "" {
+		return []string{subject.Name}, nil
+	}
+
+	if subject.Selector == nil {
+		return nil, nil
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(subject.Selector)
+	if err != nil {
+		return nil, err
+	}
+
+	plcList := &policiesv1.PolicyList{}
+
+	err = c.List(ctx, plcList, client.InNamespace(namespace), client.MatchingLabelsSelector{Selector: selector})
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+
+	for i := range plcList.Items {
+		if SubjectMatchesPolicy(subject, &plcList.Items[i]) {
+			names = append(names, plcList.Items[i].GetName())
+		}
+	}
+
+	return names, nil
+}
+
 // FindNonCompliantClustersForPolicy returns cluster in noncompliant status with given policy
 func FindNonCompliantClustersForPolicy(plc *policiesv1.Policy) []string {
 	clusterList := []string{}
@@ -0,0 +1,84 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package common
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+)
+
+func TestPoliciesForSubject(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := policiesv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to build the scheme: %v", err)
+	}
+
+	policy := func(name string, namespace string, labels map[string]string) *policiesv1.Policy {
+		return &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels}}
+	}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		policy("payments-1", "policies", map[string]string{"team": "payments"}),
+		policy("payments-2", "policies", map[string]string{"team": "payments"}),
+		policy("billing", "policies", map[string]string{"team": "billing"}),
+		policy("payments-other", "other", map[string]string{"team": "payments"}),
+		policy("policies.payments-1", "cluster1", map[string]string{
+			"team": "payments", RootPolicyLabel: "policies.payments-1",
+		}),
+	).Build()
+
+	selector := &metav1.LabelSelector{MatchLabels: map[string]string{"team": "payments"}}
+	subject := func(name string, selector *metav1.LabelSelector) policiesv1.Subject {
+		return policiesv1.Subject{
+			APIGroup: policiesv1.SchemeGroupVersion.Group, Kind: policiesv1.Kind, Name: name, Selector: selector,
+		}
+	}
+
+	tests := []struct {
+		name      string
+		namespace string
+		subject   policiesv1.Subject
+		expected  []string
+	}{
+		{"by name", "policies", subject("billing", nil), []string{"billing"}},
+		{"name takes precedence", "policies", subject("billing", selector), []string{"billing"}},
+		{"by selector", "policies", subject("", selector), []string{"payments-1", "payments-2"}},
+		{"replicated policies are ignored", "cluster1", subject("", selector), []string{}},
+		{"no name or selector", "policies", subject("", nil), nil},
+		{"not a policy", "policies", policiesv1.Subject{Kind: "Other", Selector: selector}, nil},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			names, err := PoliciesForSubject(context.TODO(), c, test.namespace, test.subject)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(names, test.expected) {
+				t.Fatalf("Expected the policies %v, got %v", test.expected, names)
+			}
+		})
+	}
+
+	invalid := subject("", &metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "team", Operator: "Invalid"}},
+	})
+
+	if _, err := PoliciesForSubject(context.TODO(), c, "policies", invalid); err == nil {
+		t.Fatal("Expected an error for the invalid label selector")
+	}
+
+	if SubjectMatchesPolicy(invalid, policy("payments-1", "policies", map[string]string{"team": "payments"})) {
+		t.Fatal("Expected the invalid label selector to not match any policy")
+	}
+}
@@ -0,0 +1,21 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package common
+
+// The Secret in each cluster namespace with the AES keys encrypting the values that the hub
+// templates of the replicated policies resolve from Secrets
+const (
+	EncryptionKeySecret = "policy-encryption-key"
+	// EncryptionKeyField is the field of the Secret with the current 256-bit key
+	EncryptionKeyField = "key"
+	// PreviousEncryptionKeyField is the field of the Secret with the key before the last rotation
+	PreviousEncryptionKeyField = "previousKey"
+	// LastRotatedAnnotation is when the key of the Secret was last rotated, in the RFC 3339 format
+	LastRotatedAnnotation = APIGroup + "/last-rotated"
+)
+
+// EncryptedPrefix is the prefix of the encrypted values. It is followed by the base64 encoding of
+// the random initialization vector of the value, its AES-CBC ciphertext with the PKCS #7 padding,
+// and the HMAC-SHA256 of the initialization vector and the ciphertext. The AES and HMAC keys are
+// the HMAC-SHA256 of "encryption" and "authentication" with the key of the cluster namespace.
+const EncryptedPrefix = "$ocm_encrypted:"
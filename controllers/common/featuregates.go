@@ -0,0 +1,141 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package common
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// Feature is the name of an experimental capability that can be toggled with a feature gate
+type Feature string
+
+const (
+	// PlacementRuleMigration enables migrating PlacementRule based PlacementBindings to Placements
+	PlacementRuleMigration Feature = "PlacementRuleMigration"
+	// RolloutStrategies enables propagating policies in waves based on the placement decisions
+	RolloutStrategies Feature = "RolloutStrategies"
+	// EncryptedHubTemplates enables the encryption of values resolved from hub templates
+	EncryptedHubTemplates Feature = "EncryptedHubTemplates"
+	// HostedClusterNamespaces enables replicating policies to the cluster namespace set on the
+	// ManagedCluster of a hosted control plane instead of the namespace named after the cluster
+	HostedClusterNamespaces Feature = "HostedClusterNamespaces"
+	// IncrementalDecisions enables only handling the clusters added to or removed from a placement
+	// decision instead of reconciling all their replicated policies again
+	IncrementalDecisions Feature = "IncrementalDecisions"
+	// Sharding enables partitioning root policies across multiple active propagator replicas
+	Sharding Feature = "Sharding"
+)
+
+// defaultFeatureGates are the known feature gates and whether they are enabled by default
+var defaultFeatureGates = map[Feature]bool{
+	PlacementRuleMigration:  false,
+	RolloutStrategies:       false,
+	EncryptedHubTemplates:   false,
+	HostedClusterNamespaces: false,
+	IncrementalDecisions:    false,
+	Sharding:                false,
+}
+
+var featureGatesLock sync.RWMutex
+var featureGates = copyFeatureGates(defaultFeatureGates)
+
+var featureGateGauge = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "policy_propagator_feature_enabled",
+		Help: "Whether the named feature gate is enabled. 1 == Enabled. 0 == Disabled",
+	},
+	[]string{"feature"},
+)
+
+func init() {
+	metrics.Registry.MustRegister(featureGateGauge)
+	reportFeatureGates()
+}
+
+// SetFeatureGates parses a comma separated list of <feature>=<bool> pairs and overrides the
+// default feature gates. Unknown features and invalid values result in an error and no feature
+// gates are changed.
+func SetFeatureGates(value string) error {
+	gates := copyFeatureGates(defaultFeatureGates)
+
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		keyValue := strings.SplitN(pair, "=", 2)
+		if len(keyValue) != 2 {
+			return fmt.Errorf("the feature gate %s must be in the format <feature>=<bool>", pair)
+		}
+
+		feature := Feature(strings.TrimSpace(keyValue[0]))
+		if _, known := defaultFeatureGates[feature]; !known {
+			return fmt.Errorf("the feature gate %s is not known", feature)
+		}
+
+		enabled, err := strconv.ParseBool(strings.TrimSpace(keyValue[1]))
+		if err != nil {
+			return fmt.Errorf("the feature gate %s has an invalid value: %w", feature, err)
+		}
+
+		gates[feature] = enabled
+	}
+
+	featureGatesLock.Lock()
+	featureGates = gates
+	featureGatesLock.Unlock()
+
+	reportFeatureGates()
+
+	return nil
+}
+
+// FeatureEnabled returns whether the feature gate is enabled
+func FeatureEnabled(feature Feature) bool {
+	featureGatesLock.RLock()
+	defer featureGatesLock.RUnlock()
+
+	return featureGates[feature]
+}
+
+// KnownFeatures returns the names of all the feature gates and their default value, sorted by name
+func KnownFeatures() []string {
+	known := make([]string, 0, len(defaultFeatureGates))
+	for feature, enabled := range defaultFeatureGates {
+		known = append(known, fmt.Sprintf("%s=%t", feature, enabled))
+	}
+
+	sort.Strings(known)
+
+	return known
+}
+
+func reportFeatureGates() {
+	featureGatesLock.RLock()
+	defer featureGatesLock.RUnlock()
+
+	for feature, enabled := range featureGates {
+		if enabled {
+			featureGateGauge.WithLabelValues(string(feature)).Set(1)
+		} else {
+			featureGateGauge.WithLabelValues(string(feature)).Set(0)
+		}
+	}
+}
+
+func copyFeatureGates(gates map[Feature]bool) map[Feature]bool {
+	gatesCopy := make(map[Feature]bool, len(gates))
+	for feature, enabled := range gates {
+		gatesCopy[feature] = enabled
+	}
+
+	return gatesCopy
+}
@@ -0,0 +1,66 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package common
+
+import (
+	"testing"
+)
+
+// The feature gates of the tests, since the features declare their gates alongside their
+// implementation
+const (
+	testFeature      Feature = "TestFeature"
+	testOtherFeature Feature = "TestOtherFeature"
+)
+
+func TestSetFeatureGates(t *testing.T) {
+	defaultFeatureGates[testFeature] = false
+	defaultFeatureGates[testOtherFeature] = false
+
+	t.Cleanup(func() {
+		delete(defaultFeatureGates, testFeature)
+		delete(defaultFeatureGates, testOtherFeature)
+
+		_ = SetFeatureGates("")
+	})
+
+	tests := []struct {
+		value     string
+		expectErr bool
+		expected  map[Feature]bool
+	}{
+		{"", false, map[Feature]bool{testFeature: false, testOtherFeature: false}},
+		{"TestFeature=true", false, map[Feature]bool{testFeature: true, testOtherFeature: false}},
+		{" TestFeature=true , TestOtherFeature=1 ", false, map[Feature]bool{testFeature: true, testOtherFeature: true}},
+		{"TestFeature", true, nil},
+		{"TestFeature=maybe", true, nil},
+		{"NotAFeature=true", true, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			defer func() {
+				_ = SetFeatureGates("")
+			}()
+
+			err := SetFeatureGates(test.value)
+			if test.expectErr {
+				if err == nil {
+					t.Fatalf("Expected an error for %q", test.value)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Unexpected error for %q: %v", test.value, err)
+			}
+
+			for feature, enabled := range test.expected {
+				if FeatureEnabled(feature) != enabled {
+					t.Fatalf("Expected %s=%t, got %t", feature, enabled, FeatureEnabled(feature))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package common
+
+// The labels and annotations GitOps tools such as Argo CD set on the objects they manage to track
+// which application owns them
+const (
+	ArgoCDTrackingIDAnnotation string = "argocd.argoproj.io/tracking-id"
+	ArgoCDInstanceLabel        string = "app.kubernetes.io/instance"
+)
+
+// IsGitOpsTrackingKey returns whether the label or annotation key is one set by the GitOps tools to
+// track the objects they manage. These keys are never copied from the root policies to the
+// replicated policies, otherwise the GitOps tools would consider the replicated policies as part of
+// their application and prune them, and they are never considered a change of the replicated
+// policies. Both keys are checked on the labels and the annotations since Argo CD can be configured
+// to track the objects with either.
+func IsGitOpsTrackingKey(key string) bool {
+	return key == ArgoCDTrackingIDAnnotation || key == ArgoCDInstanceLabel
+}
+
+// WithoutGitOpsTrackingKeys returns the labels or annotations without the GitOps tracking keys. The
+// map is returned as is when it has none of them, so it must not be modified.
+func WithoutGitOpsTrackingKeys(metadata map[string]string) map[string]string {
+	found := false
+
+	for key := range metadata {
+		if IsGitOpsTrackingKey(key) {
+			found = true
+
+			break
+		}
+	}
+
+	if !found {
+		return metadata
+	}
+
+	filtered := make(map[string]string, len(metadata))
+
+	for key, value := range metadata {
+		if !IsGitOpsTrackingKey(key) {
+			filtered[key] = value
+		}
+	}
+
+	return filtered
+}
@@ -0,0 +1,38 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package common
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWithoutGitOpsTrackingKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata map[string]string
+		expected map[string]string
+	}{
+		{name: "nil"},
+		{name: "untracked", metadata: map[string]string{"team": "a"}, expected: map[string]string{"team": "a"}},
+		{
+			name: "tracked",
+			metadata: map[string]string{
+				ArgoCDTrackingIDAnnotation: "app:policy.open-cluster-management.io/Policy:policies/policy",
+				ArgoCDInstanceLabel:        "app",
+				"team":                     "a",
+			},
+			expected: map[string]string{"team": "a"},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			if filtered := WithoutGitOpsTrackingKeys(test.metadata); !reflect.DeepEqual(filtered, test.expected) {
+				t.Fatalf("expected %v, got %v", test.expected, filtered)
+			}
+		})
+	}
+}
@@ -4,6 +4,8 @@
 package common
 
 import (
+	"time"
+
 	"k8s.io/client-go/util/workqueue"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/event"
@@ -18,6 +20,9 @@ var _ handler.EventHandler = &EnqueueRequestsFromMapFunc{}
 type EnqueueRequestsFromMapFunc struct {
 	// Mapper transforms the argument into a slice of keys to be reconciled
 	ToRequests handler.MapFunc
+	// Delay postpones the requests so that the events of the same object within it are coalesced
+	// into a single reconcile. The requests are added right away when it is 0.
+	Delay time.Duration
 }
 
 // Create implements EventHandler
@@ -43,7 +48,11 @@ func (e *EnqueueRequestsFromMapFunc) Generic(evt event.GenericEvent, q workqueue
 
 func (e *EnqueueRequestsFromMapFunc) mapAndEnqueue(q workqueue.RateLimitingInterface, object client.Object) {
 	for _, req := range e.ToRequests(object) {
-		q.Add(req)
+		if e.Delay > 0 {
+			q.AddAfter(req, e.Delay)
+		} else {
+			q.Add(req)
+		}
 	}
 }
 
@@ -0,0 +1,50 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package common
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+)
+
+func TestEnqueueRequestsFromMapFuncDelay(t *testing.T) {
+	q := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	defer q.ShutDown()
+
+	handler := &EnqueueRequestsFromMapFunc{
+		ToRequests: func(client.Object) []reconcile.Request {
+			return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: "policies", Name: "policy"}}}
+		},
+		Delay: 100 * time.Millisecond,
+	}
+
+	for _, cluster := range []string{"cluster1", "cluster2", "cluster1"} {
+		plc := &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{Namespace: cluster, Name: "policies.policy"}}
+		handler.Update(event.UpdateEvent{ObjectOld: plc, ObjectNew: plc}, q)
+	}
+
+	if q.Len() != 0 {
+		t.Fatalf("Expected no request before the delay, got %d", q.Len())
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for q.Len() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Give the other requests a chance to be added if they weren't coalesced
+	time.Sleep(200 * time.Millisecond)
+
+	if q.Len() != 1 {
+		t.Fatalf("Expected the requests to be coalesced into a single request, got %d", q.Len())
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package common
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+)
+
+// healthCheckTimeout is how long a health check waits before failing, so that a probe never hangs
+// on an unreachable dependency
+const healthCheckTimeout = 5 * time.Second
+
+// CacheSyncCheck returns a health check that fails until the informers of the cache are started
+// and synced
+func CacheSyncCheck(informers cache.Informers) healthz.Checker {
+	return func(req *http.Request) error {
+		ctx, cancel := context.WithTimeout(req.Context(), healthCheckTimeout)
+		defer cancel()
+
+		if !informers.WaitForCacheSync(ctx) {
+			return errors.New("the informer caches are not synced")
+		}
+
+		return nil
+	}
+}
+
+// PingCheck returns a health check that fails when the ping function returns an error, such as
+// when a database can't be reached
+func PingCheck(ping func(ctx context.Context) error) healthz.Checker {
+	return func(req *http.Request) error {
+		ctx, cancel := context.WithTimeout(req.Context(), healthCheckTimeout)
+		defer cancel()
+
+		return ping(ctx)
+	}
+}
+
+// APIServerPing returns a ping function that fails when the Kubernetes API server can't be reached
+// with the client, such as the client of the hub template lookups
+func APIServerPing(kubeClient kubernetes.Interface) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		return kubeClient.Discovery().RESTClient().Get().AbsPath("/readyz").Do(ctx).Error()
+	}
+}
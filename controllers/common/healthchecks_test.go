@@ -0,0 +1,76 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package common
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/cache/informertest"
+)
+
+func TestCacheSyncCheck(t *testing.T) {
+	synced := false
+	check := CacheSyncCheck(&informertest.FakeInformers{Synced: &synced})
+	req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+
+	if err := check(req); err == nil {
+		t.Fatal("expected the check to fail before the caches are synced")
+	}
+
+	synced = true
+
+	if err := check(req); err != nil {
+		t.Fatalf("expected the check to pass once the caches are synced, got %v", err)
+	}
+}
+
+func TestPingCheck(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+
+	check := PingCheck(func(ctx context.Context) error {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Fatal("expected the ping to have a deadline")
+		}
+
+		return errors.New("connection refused")
+	})
+
+	if err := check(req); err == nil || err.Error() != "connection refused" {
+		t.Fatalf("expected the ping error, got %v", err)
+	}
+}
+
+func TestAPIServerPing(t *testing.T) {
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/readyz" {
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	kubeClient, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatalf("failed to create the client: %v", err)
+	}
+
+	ping := APIServerPing(kubeClient)
+
+	if err := ping(context.TODO()); err != nil {
+		t.Fatalf("expected the API server to be reachable, got %v", err)
+	}
+
+	status = http.StatusServiceUnavailable
+
+	if err := ping(context.TODO()); err == nil {
+		t.Fatal("expected the ping to fail when the API server isn't ready")
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package common
+
+import (
+	"sync"
+
+	"github.com/go-logr/logr"
+	clusterv1alpha1 "github.com/open-cluster-management/api/cluster/v1alpha1"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/discovery"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+)
+
+// PlacementAPI is an API the placementRef of a PlacementBinding may refer to
+type PlacementAPI string
+
+const (
+	// PlacementRuleAPI is the PlacementRule API of the application lifecycle
+	PlacementRuleAPI PlacementAPI = "PlacementRule"
+	// ClusterPlacementAPI is the Placement and PlacementDecision API of the cluster lifecycle
+	ClusterPlacementAPI PlacementAPI = "Placement"
+)
+
+// PlacementAPIs are the placement APIs the policies may be placed with
+var PlacementAPIs = []PlacementAPI{PlacementRuleAPI, ClusterPlacementAPI}
+
+// PlacementRefAPI returns the placement API of the placementRef, or false if the placementRef isn't
+// supported
+func PlacementRefAPI(placementRef policiesv1.Subject) (PlacementAPI, bool) {
+	switch {
+	case placementRef.APIGroup == appsv1.SchemeGroupVersion.Group && placementRef.Kind == "PlacementRule":
+		return PlacementRuleAPI, true
+	case placementRef.APIGroup == clusterv1alpha1.SchemeGroupVersion.Group && placementRef.Kind == "Placement":
+		return ClusterPlacementAPI, true
+	}
+
+	return "", false
+}
+
+// PlacementAvailability records which of the placement APIs are installed on the hub, so that the
+// controllers only watch the installed ones and watch the others once they are installed. A nil
+// PlacementAvailability has all the APIs available. It is safe for concurrent use.
+type PlacementAvailability struct {
+	lock        sync.Mutex
+	unavailable map[PlacementAPI]bool
+	listeners   []func(api PlacementAPI)
+}
+
+// DetectPlacementAvailability returns the availability of the placement APIs served by the hub of
+// the discovery client
+func DetectPlacementAvailability(discoveryClient discovery.DiscoveryInterface) (*PlacementAvailability, error) {
+	availability := &PlacementAvailability{unavailable: map[PlacementAPI]bool{}}
+
+	resources := map[PlacementAPI][]string{
+		PlacementRuleAPI:    {appsv1.SchemeGroupVersion.String(), "placementrules"},
+		ClusterPlacementAPI: {clusterv1alpha1.GroupVersion.String(), "placements", "placementdecisions"},
+	}
+
+	for api, groupVersionResources := range resources {
+		served, err := discoveryClient.ServerResourcesForGroupVersion(groupVersionResources[0])
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return nil, err
+		}
+
+		names := map[string]bool{}
+
+		if served != nil {
+			for _, resource := range served.APIResources {
+				names[resource.Name] = true
+			}
+		}
+
+		for _, name := range groupVersionResources[1:] {
+			if !names[name] {
+				availability.unavailable[api] = true
+			}
+		}
+	}
+
+	return availability, nil
+}
+
+// Available returns whether the placement API is installed
+func (a *PlacementAvailability) Available(api PlacementAPI) bool {
+	if a == nil {
+		return true
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	return !a.unavailable[api]
+}
+
+// SetAvailable records whether the placement API is installed. The listeners are called when an
+// unavailable API becomes available.
+func (a *PlacementAvailability) SetAvailable(api PlacementAPI, available bool) {
+	if a == nil {
+		return
+	}
+
+	a.lock.Lock()
+
+	wasAvailable := !a.unavailable[api]
+
+	if available {
+		delete(a.unavailable, api)
+	} else {
+		if a.unavailable == nil {
+			a.unavailable = map[PlacementAPI]bool{}
+		}
+
+		a.unavailable[api] = true
+	}
+
+	listeners := a.listeners
+
+	a.lock.Unlock()
+
+	if available && !wasAvailable {
+		for _, listener := range listeners {
+			listener(api)
+		}
+	}
+}
+
+// OnAvailable registers a function called when an unavailable placement API becomes available
+func (a *PlacementAvailability) OnAvailable(listener func(api PlacementAPI)) {
+	if a == nil {
+		return
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	a.listeners = append(a.listeners, listener)
+}
+
+// PlacementWatch adds the watches of a placement API to a controller
+type PlacementWatch func(c controller.Controller) error
+
+// WatchWhenAvailable adds the watches of the installed placement APIs to the controller, and the
+// watches of the other placement APIs once they are installed, since watching an API that isn't
+// installed fails the controller
+func (a *PlacementAvailability) WatchWhenAvailable(
+	c controller.Controller, watches map[PlacementAPI]PlacementWatch, log logr.Logger,
+) error {
+	var lock sync.Mutex
+
+	watched := map[PlacementAPI]bool{}
+
+	watch := func(api PlacementAPI) error {
+		lock.Lock()
+		defer lock.Unlock()
+
+		if watched[api] || watches[api] == nil {
+			return nil
+		}
+
+		if err := watches[api](c); err != nil {
+			return err
+		}
+
+		watched[api] = true
+
+		return nil
+	}
+
+	for _, api := range PlacementAPIs {
+		if !a.Available(api) {
+			log.Info("The placement API is not installed, it will be watched once it is installed...", "API", api)
+
+			continue
+		}
+
+		if err := watch(api); err != nil {
+			return err
+		}
+	}
+
+	a.OnAvailable(func(api PlacementAPI) {
+		log.Info("The placement API was installed, watching it...", "API", api)
+
+		if err := watch(api); err != nil {
+			log.Error(err, "Failed to watch the installed placement API...", "API", api)
+		}
+	})
+
+	return nil
+}
@@ -0,0 +1,122 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package common
+
+import (
+	"testing"
+
+	clusterv1alpha1 "github.com/open-cluster-management/api/cluster/v1alpha1"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+)
+
+// notFoundDiscovery returns a not found error for the group versions that aren't served, like the
+// API server
+type notFoundDiscovery struct {
+	*fake.FakeDiscovery
+}
+
+func (d notFoundDiscovery) ServerResourcesForGroupVersion(groupVersion string) (*metav1.APIResourceList, error) {
+	for _, resourceList := range d.Resources {
+		if resourceList.GroupVersion == groupVersion {
+			return resourceList, nil
+		}
+	}
+
+	return nil, k8serrors.NewNotFound(schema.GroupResource{}, groupVersion)
+}
+
+func TestDetectPlacementAvailability(t *testing.T) {
+	discoveryClient := notFoundDiscovery{&fake.FakeDiscovery{Fake: &clienttesting.Fake{}}}
+	discoveryClient.Resources = []*metav1.APIResourceList{{
+		GroupVersion: clusterv1alpha1.GroupVersion.String(),
+		APIResources: []metav1.APIResource{{Name: "placements"}, {Name: "placementdecisions"}},
+	}}
+
+	availability, err := DetectPlacementAvailability(discoveryClient)
+	if err != nil {
+		t.Fatalf("failed to detect the placement APIs: %v", err)
+	}
+
+	if availability.Available(PlacementRuleAPI) || !availability.Available(ClusterPlacementAPI) {
+		t.Fatal("expected only the Placement API to be available")
+	}
+
+	// The Placement API needs the PlacementDecisions too
+	discoveryClient.Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: clusterv1alpha1.GroupVersion.String(),
+			APIResources: []metav1.APIResource{{Name: "placements"}},
+		},
+		{
+			GroupVersion: appsv1.SchemeGroupVersion.String(),
+			APIResources: []metav1.APIResource{{Name: "placementrules"}},
+		},
+	}
+
+	availability, err = DetectPlacementAvailability(discoveryClient)
+	if err != nil {
+		t.Fatalf("failed to detect the placement APIs: %v", err)
+	}
+
+	if !availability.Available(PlacementRuleAPI) || availability.Available(ClusterPlacementAPI) {
+		t.Fatal("expected only the PlacementRule API to be available")
+	}
+}
+
+func TestPlacementAvailabilityListeners(t *testing.T) {
+	availability := &PlacementAvailability{}
+	installed := []PlacementAPI{}
+
+	availability.OnAvailable(func(api PlacementAPI) {
+		installed = append(installed, api)
+	})
+
+	availability.SetAvailable(PlacementRuleAPI, true)
+	availability.SetAvailable(ClusterPlacementAPI, false)
+
+	if len(installed) != 0 || availability.Available(ClusterPlacementAPI) {
+		t.Fatalf("expected no listener calls for the APIs that didn't become available, got %v", installed)
+	}
+
+	availability.SetAvailable(ClusterPlacementAPI, true)
+	availability.SetAvailable(ClusterPlacementAPI, true)
+
+	if len(installed) != 1 || installed[0] != ClusterPlacementAPI {
+		t.Fatalf("expected one listener call for the installed Placement API, got %v", installed)
+	}
+
+	// A nil PlacementAvailability has all the APIs available
+	var unknown *PlacementAvailability
+	unknown.SetAvailable(PlacementRuleAPI, false)
+
+	if !unknown.Available(PlacementRuleAPI) {
+		t.Fatal("expected the APIs to be available without a PlacementAvailability")
+	}
+}
+
+func placementRef(group string, kind string) policiesv1.Subject {
+	return policiesv1.Subject{APIGroup: group, Kind: kind, Name: "placement"}
+}
+
+func TestPlacementRefAPI(t *testing.T) {
+	api, ok := PlacementRefAPI(placementRef(appsv1.SchemeGroupVersion.Group, "PlacementRule"))
+	if !ok || api != PlacementRuleAPI {
+		t.Fatalf("expected the PlacementRule API, got %q", api)
+	}
+
+	api, ok = PlacementRefAPI(placementRef(clusterv1alpha1.GroupVersion.Group, "Placement"))
+	if !ok || api != ClusterPlacementAPI {
+		t.Fatalf("expected the Placement API, got %q", api)
+	}
+
+	if _, ok := PlacementRefAPI(placementRef(appsv1.SchemeGroupVersion.Group, "Placement")); ok {
+		t.Fatal("expected the Placement kind of the apps group not to be supported")
+	}
+}
@@ -0,0 +1,725 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package complianceeventsapi
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ErrNotFound is returned when a compliance event, or the parent policy or the policy it references
+// by ID, doesn't exist
+var ErrNotFound = errors.New("not found")
+
+// The schema of the compliance events database. The rows of the clusters, parent policies, and
+// policies are shared by the compliance events, so they are unique.
+const schema = `
+CREATE TABLE IF NOT EXISTS clusters(
+	id serial PRIMARY KEY,
+	name TEXT NOT NULL,
+	cluster_id TEXT NOT NULL DEFAULT '',
+	UNIQUE (name, cluster_id)
+);
+
+CREATE TABLE IF NOT EXISTS parent_policies(
+	id serial PRIMARY KEY,
+	name TEXT NOT NULL,
+	namespace TEXT NOT NULL,
+	categories TEXT[] NOT NULL DEFAULT '{}',
+	controls TEXT[] NOT NULL DEFAULT '{}',
+	standards TEXT[] NOT NULL DEFAULT '{}',
+	UNIQUE (name, namespace, categories, controls, standards)
+);
+
+CREATE TABLE IF NOT EXISTS policies(
+	id serial PRIMARY KEY,
+	kind TEXT NOT NULL,
+	api_group TEXT NOT NULL,
+	name TEXT NOT NULL,
+	namespace TEXT NOT NULL DEFAULT '',
+	spec JSONB NOT NULL,
+	spec_hash TEXT NOT NULL,
+	severity TEXT NOT NULL DEFAULT '',
+	UNIQUE (kind, api_group, name, namespace, spec_hash, severity)
+);
+
+CREATE TABLE IF NOT EXISTS compliance_events(
+	id serial PRIMARY KEY,
+	cluster_id INT NOT NULL REFERENCES clusters(id),
+	policy_id INT NOT NULL REFERENCES policies(id),
+	parent_policy_id INT REFERENCES parent_policies(id),
+	compliance TEXT NOT NULL,
+	message TEXT NOT NULL,
+	timestamp TIMESTAMPTZ NOT NULL,
+	reported_by TEXT NOT NULL DEFAULT '',
+	UNIQUE (cluster_id, policy_id, parent_policy_id, compliance, message, timestamp)
+);
+
+CREATE INDEX IF NOT EXISTS compliance_events_timestamp_idx ON compliance_events (timestamp);
+
+CREATE TABLE IF NOT EXISTS template_lookups(
+	id serial PRIMARY KEY,
+	cluster_id INT NOT NULL REFERENCES clusters(id),
+	parent_policy_id INT NOT NULL REFERENCES parent_policies(id),
+	lookups JSONB NOT NULL,
+	lookups_hash TEXT NOT NULL,
+	timestamp TIMESTAMPTZ NOT NULL,
+	UNIQUE (cluster_id, parent_policy_id, lookups_hash, timestamp)
+);
+
+CREATE INDEX IF NOT EXISTS template_lookups_timestamp_idx ON template_lookups (timestamp);
+`
+
+// The columns of the compliance events returned by the queries, in the order scanned by scanEvent
+const eventsSelect = `SELECT ce.id, c.name, c.cluster_id,
+pp.id, pp.name, pp.namespace, pp.categories, pp.controls, pp.standards,
+p.id, p.kind, p.api_group, p.name, p.namespace, p.spec, p.severity,
+ce.compliance, ce.message, ce.timestamp, ce.reported_by
+FROM compliance_events ce
+JOIN clusters c ON ce.cluster_id = c.id
+JOIN policies p ON ce.policy_id = p.id
+LEFT JOIN parent_policies pp ON ce.parent_policy_id = pp.id`
+
+// The columns of the template lookups returned by the queries, in the order scanned by
+// scanTemplateLookups
+const lookupsSelect = `SELECT tl.id, c.name, c.cluster_id,
+pp.id, pp.name, pp.namespace, pp.categories, pp.controls, pp.standards,
+tl.lookups, tl.timestamp
+FROM template_lookups tl
+JOIN clusters c ON tl.cluster_id = c.id
+JOIN parent_policies pp ON tl.parent_policy_id = pp.id`
+
+// The number of compliance events returned per page when it's not set in the query, and the
+// maximum that can be requested
+const (
+	perPageDefault = 20
+	perPageMax     = 100
+)
+
+// EventFilters are the filters of the compliance events queries. Multiple values of the same
+// filter match any of them.
+type EventFilters struct {
+	ClusterNames           []string
+	PolicyNames            []string
+	ParentPolicyNames      []string
+	ParentPolicyNamespaces []string
+	Compliance             []string
+	TimestampAfter         time.Time
+	TimestampBefore        time.Time
+	// Page starts at 1
+	Page    int
+	PerPage int
+}
+
+// ComplianceDB records and queries the compliance events in a PostgreSQL database
+type ComplianceDB struct {
+	db *sql.DB
+	// The IDs of the rows shared by the compliance events, keyed by their unique columns, so they
+	// are only looked up once
+	clusterIDs      sync.Map
+	parentPolicyIDs sync.Map
+	policyIDs       sync.Map
+}
+
+// OpenComplianceDB returns the ComplianceDB of the PostgreSQL connection URL. The connection is
+// only established when the database is first used.
+func OpenComplianceDB(connectionURL string) (*ComplianceDB, error) {
+	db, err := sql.Open("postgres", connectionURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ComplianceDB{db: db}, nil
+}
+
+// Migrate creates the tables of the compliance events that don't exist
+func (c *ComplianceDB) Migrate(ctx context.Context) error {
+	_, err := c.db.ExecContext(ctx, schema)
+
+	return err
+}
+
+// Ping returns an error if the database can't be reached
+func (c *ComplianceDB) Ping(ctx context.Context) error {
+	return c.db.PingContext(ctx)
+}
+
+// Close closes the connections to the database
+func (c *ComplianceDB) Close() error {
+	return c.db.Close()
+}
+
+// getOrCreate returns the ID of the row inserted by the insert query, or of the existing row
+// returned by the select query when it already exists. The IDs are cached by the arguments of the
+// select query, which are the unique columns of the row.
+func (c *ComplianceDB) getOrCreate(
+	ctx context.Context, cache *sync.Map, insert string, insertArgs []interface{}, query string,
+	queryArgs []interface{},
+) (int32, error) {
+	cacheKey, err := json.Marshal(queryArgs)
+	if err != nil {
+		return 0, err
+	}
+
+	if id, ok := cache.Load(string(cacheKey)); ok {
+		return id.(int32), nil
+	}
+
+	var id int32
+
+	err = c.db.QueryRowContext(ctx, insert+" ON CONFLICT DO NOTHING RETURNING id", insertArgs...).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = c.db.QueryRowContext(ctx, query, queryArgs...).Scan(&id)
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	cache.Store(string(cacheKey), id)
+
+	return id, nil
+}
+
+// GetOrCreateCluster returns the ID of the cluster, and creates it if it doesn't exist
+func (c *ComplianceDB) GetOrCreateCluster(ctx context.Context, cluster *Cluster) (int32, error) {
+	args := []interface{}{cluster.Name, cluster.ClusterID}
+
+	return c.getOrCreate(ctx, &c.clusterIDs,
+		"INSERT INTO clusters (name, cluster_id) VALUES ($1, $2)", args,
+		"SELECT id FROM clusters WHERE name = $1 AND cluster_id = $2", args,
+	)
+}
+
+// GetOrCreateParentPolicy returns the ID of the parent policy, and creates it if it doesn't exist
+func (c *ComplianceDB) GetOrCreateParentPolicy(ctx context.Context, parent *ParentPolicy) (int32, error) {
+	args := []interface{}{
+		parent.Name, parent.Namespace,
+		pq.Array(nonNil(parent.Categories)), pq.Array(nonNil(parent.Controls)), pq.Array(nonNil(parent.Standards)),
+	}
+
+	return c.getOrCreate(ctx, &c.parentPolicyIDs,
+		"INSERT INTO parent_policies (name, namespace, categories, controls, standards) VALUES ($1, $2, $3, $4, $5)",
+		args,
+		"SELECT id FROM parent_policies WHERE name = $1 AND namespace = $2 AND categories = $3 AND "+
+			"controls = $4 AND standards = $5",
+		args,
+	)
+}
+
+// GetOrCreatePolicy returns the ID of the policy, and creates it if it doesn't exist. The policies
+// with the same spec share the same ID.
+func (c *ComplianceDB) GetOrCreatePolicy(ctx context.Context, policy *Policy) (int32, error) {
+	specHash, err := policy.SpecHash()
+	if err != nil {
+		return 0, err
+	}
+
+	spec, err := json.Marshal(policy.Spec)
+	if err != nil {
+		return 0, err
+	}
+
+	// The spec is identified by its hash
+	args := []interface{}{policy.Kind, policy.APIGroup, policy.Name, policy.Namespace, specHash, policy.Severity}
+
+	return c.getOrCreate(ctx, &c.policyIDs,
+		"INSERT INTO policies (kind, api_group, name, namespace, spec_hash, severity, spec) "+
+			"VALUES ($1, $2, $3, $4, $5, $6, $7)",
+		append(args, string(spec)),
+		"SELECT id FROM policies WHERE kind = $1 AND api_group = $2 AND name = $3 AND namespace = $4 AND "+
+			"spec_hash = $5 AND severity = $6",
+		args,
+	)
+}
+
+// RecordEvent inserts the compliance event and sets its ID. The parent policy and the policy are
+// created if they are fully specified, or must exist if they are referenced by their ID. Recording
+// the same compliance event again is not an error.
+func (c *ComplianceDB) RecordEvent(ctx context.Context, event *ComplianceEvent) error {
+	var err error
+
+	event.Cluster.KeyID, err = c.GetOrCreateCluster(ctx, &event.Cluster)
+	if err != nil {
+		return err
+	}
+
+	var parentPolicyID sql.NullInt32
+
+	if event.ParentPolicy != nil {
+		if event.ParentPolicy.KeyID == 0 {
+			event.ParentPolicy.KeyID, err = c.GetOrCreateParentPolicy(ctx, event.ParentPolicy)
+			if err != nil {
+				return err
+			}
+		} else if err := c.checkExists(ctx, "parent_policies", event.ParentPolicy.KeyID); err != nil {
+			return fmt.Errorf("parentPolicy.id: %w", err)
+		}
+
+		parentPolicyID = sql.NullInt32{Int32: event.ParentPolicy.KeyID, Valid: true}
+	}
+
+	if event.Policy.KeyID == 0 {
+		event.Policy.KeyID, err = c.GetOrCreatePolicy(ctx, &event.Policy)
+		if err != nil {
+			return err
+		}
+	} else if err := c.checkExists(ctx, "policies", event.Policy.KeyID); err != nil {
+		return fmt.Errorf("policy.id: %w", err)
+	}
+
+	args := []interface{}{
+		event.Cluster.KeyID, event.Policy.KeyID, parentPolicyID, event.Event.Compliance, event.Event.Message,
+		event.Event.Timestamp, event.Event.ReportedBy,
+	}
+
+	err = c.db.QueryRowContext(ctx,
+		"INSERT INTO compliance_events "+
+			"(cluster_id, policy_id, parent_policy_id, compliance, message, timestamp, reported_by) "+
+			"VALUES ($1, $2, $3, $4, $5, $6, $7) ON CONFLICT DO NOTHING RETURNING id",
+		args...,
+	).Scan(&event.EventID)
+	if errors.Is(err, sql.ErrNoRows) {
+		// The compliance event was already recorded
+		err = c.db.QueryRowContext(ctx,
+			"SELECT id FROM compliance_events WHERE cluster_id = $1 AND policy_id = $2 AND "+
+				"parent_policy_id IS NOT DISTINCT FROM $3 AND compliance = $4 AND message = $5 AND timestamp = $6 AND "+
+				"reported_by = $7",
+			args...,
+		).Scan(&event.EventID)
+	}
+
+	return err
+}
+
+// checkExists returns ErrNotFound if the table has no row with the ID
+func (c *ComplianceDB) checkExists(ctx context.Context, table string, id int32) error {
+	var exists bool
+
+	// The table is one of the constants of RecordEvent and RecordTemplateLookups
+	err := c.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM "+table+" WHERE id = $1)", id).Scan(&exists)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// GetEvent returns the compliance event with the ID, or ErrNotFound if it doesn't exist
+func (c *ComplianceDB) GetEvent(ctx context.Context, id int32) (*ComplianceEvent, error) {
+	event, err := scanEvent(c.db.QueryRowContext(ctx, eventsSelect+" WHERE ce.id = $1", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+
+	return event, err
+}
+
+// QueryEvents returns the page of the compliance events matching the filters, from the most
+// recent, and the total number of compliance events matching the filters
+func (c *ComplianceDB) QueryEvents(ctx context.Context, filters EventFilters) ([]ComplianceEvent, int, error) {
+	where, args := filterClause(filters)
+
+	var total int
+
+	err := c.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM compliance_events ce "+
+			"JOIN clusters c ON ce.cluster_id = c.id "+
+			"JOIN policies p ON ce.policy_id = p.id "+
+			"LEFT JOIN parent_policies pp ON ce.parent_policy_id = pp.id"+where,
+		args...,
+	).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	page, perPage := pagination(filters)
+	args = append(args, perPage, (page-1)*perPage)
+
+	rows, err := c.db.QueryContext(ctx,
+		fmt.Sprintf("%s%s ORDER BY ce.timestamp DESC, ce.id DESC LIMIT $%d OFFSET $%d",
+			eventsSelect, where, len(args)-1, len(args)),
+		args...,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	events := []ComplianceEvent{}
+
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		events = append(events, *event)
+	}
+
+	return events, total, rows.Err()
+}
+
+// StreamEvents calls fn with every compliance event matching the filters, from the most recent,
+// without loading them all in memory. The pagination of the filters is ignored. It stops at the
+// first error returned by fn.
+func (c *ComplianceDB) StreamEvents(
+	ctx context.Context, filters EventFilters, fn func(event *ComplianceEvent) error,
+) error {
+	where, args := filterClause(filters)
+
+	rows, err := c.db.QueryContext(ctx, eventsSelect+where+" ORDER BY ce.timestamp DESC, ce.id DESC", args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// pruneStatement is a DELETE statement of PruneEvents and its arguments
+type pruneStatement struct {
+	query string
+	args  []interface{}
+}
+
+// pruneStatements returns the statements deleting the compliance events outside of the retention
+func pruneStatements(retention Retention, now time.Time) []pruneStatement {
+	statements := []pruneStatement{}
+
+	if retention.MaxAge > 0 {
+		statements = append(statements, pruneStatement{
+			query: "DELETE FROM compliance_events WHERE timestamp < $1",
+			args:  []interface{}{now.Add(-retention.MaxAge)},
+		})
+	}
+
+	if retention.MaxEventsPerPolicy > 0 {
+		// The parent policies and the policies are partitioned by their name since their rows change
+		// with their annotations and specs
+		statements = append(statements, pruneStatement{
+			query: "DELETE FROM compliance_events WHERE id IN (" +
+				"SELECT id FROM (SELECT ce.id, ROW_NUMBER() OVER (" +
+				"PARTITION BY ce.cluster_id, pp.name, pp.namespace, p.kind, p.api_group, p.name, p.namespace " +
+				"ORDER BY ce.timestamp DESC, ce.id DESC) AS position " +
+				"FROM compliance_events ce " +
+				"JOIN policies p ON ce.policy_id = p.id " +
+				"LEFT JOIN parent_policies pp ON ce.parent_policy_id = pp.id" +
+				") ranked WHERE position > $1)",
+			args: []interface{}{retention.MaxEventsPerPolicy},
+		})
+	}
+
+	return statements
+}
+
+// PruneEvents deletes the compliance events older than the maximum age of the retention at the
+// given time, and the oldest compliance events above its maximum per policy. It returns the number
+// of deleted compliance events.
+func (c *ComplianceDB) PruneEvents(ctx context.Context, retention Retention, now time.Time) (int64, error) {
+	var pruned int64
+
+	for _, statement := range pruneStatements(retention, now) {
+		result, err := c.db.ExecContext(ctx, statement.query, statement.args...)
+		if err != nil {
+			return pruned, err
+		}
+
+		deleted, err := result.RowsAffected()
+		if err != nil {
+			return pruned, err
+		}
+
+		pruned += deleted
+	}
+
+	return pruned, nil
+}
+
+// columnFilter matches the rows whose column has any of the values
+type columnFilter struct {
+	column string
+	values []string
+}
+
+// RecordTemplateLookups inserts the objects looked up by the hub templates of the parent policy
+// when its replicated policy was rendered for the cluster, unless they are the same as the last
+// ones recorded, so that every row is when the policy started being rendered from them. The parent
+// policy is created if it is fully specified, or must exist if it is referenced by its ID.
+func (c *ComplianceDB) RecordTemplateLookups(ctx context.Context, lookups *TemplateLookups) error {
+	var err error
+
+	lookups.Cluster.KeyID, err = c.GetOrCreateCluster(ctx, &lookups.Cluster)
+	if err != nil {
+		return err
+	}
+
+	if lookups.ParentPolicy.KeyID == 0 {
+		lookups.ParentPolicy.KeyID, err = c.GetOrCreateParentPolicy(ctx, &lookups.ParentPolicy)
+		if err != nil {
+			return err
+		}
+	} else if err := c.checkExists(ctx, "parent_policies", lookups.ParentPolicy.KeyID); err != nil {
+		return fmt.Errorf("parentPolicy.id: %w", err)
+	}
+
+	lookupsHash, lookupsJSON, err := lookups.LookupsHash()
+	if err != nil {
+		return err
+	}
+
+	var lastHash string
+
+	err = c.db.QueryRowContext(ctx,
+		"SELECT lookups_hash FROM template_lookups WHERE cluster_id = $1 AND parent_policy_id = $2 "+
+			"ORDER BY timestamp DESC, id DESC LIMIT 1",
+		lookups.Cluster.KeyID, lookups.ParentPolicy.KeyID,
+	).Scan(&lastHash)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	if lastHash == lookupsHash {
+		return nil
+	}
+
+	err = c.db.QueryRowContext(ctx,
+		"INSERT INTO template_lookups (cluster_id, parent_policy_id, lookups, lookups_hash, timestamp) "+
+			"VALUES ($1, $2, $3, $4, $5) ON CONFLICT DO NOTHING RETURNING id",
+		lookups.Cluster.KeyID, lookups.ParentPolicy.KeyID, string(lookupsJSON), lookupsHash, lookups.Timestamp,
+	).Scan(&lookups.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		// The template lookups were already recorded
+		return nil
+	}
+
+	return err
+}
+
+// QueryTemplateLookups returns the page of the template lookups matching the filters, from the most
+// recent, and the total number of template lookups matching the filters
+func (c *ComplianceDB) QueryTemplateLookups(
+	ctx context.Context, filters EventFilters,
+) ([]TemplateLookups, int, error) {
+	where, args := lookupsFilterClause(filters)
+
+	var total int
+
+	err := c.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM template_lookups tl "+
+			"JOIN clusters c ON tl.cluster_id = c.id "+
+			"JOIN parent_policies pp ON tl.parent_policy_id = pp.id"+where,
+		args...,
+	).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	page, perPage := pagination(filters)
+	args = append(args, perPage, (page-1)*perPage)
+
+	rows, err := c.db.QueryContext(ctx,
+		fmt.Sprintf("%s%s ORDER BY tl.timestamp DESC, tl.id DESC LIMIT $%d OFFSET $%d",
+			lookupsSelect, where, len(args)-1, len(args)),
+		args...,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	allLookups := []TemplateLookups{}
+
+	for rows.Next() {
+		lookups, err := scanTemplateLookups(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		allLookups = append(allLookups, *lookups)
+	}
+
+	return allLookups, total, rows.Err()
+}
+
+// filterClause returns the WHERE clause of the filters, or an empty string if there are none, and
+// its arguments
+func filterClause(filters EventFilters) (string, []interface{}) {
+	return whereClause(
+		[]columnFilter{
+			{"c.name", filters.ClusterNames},
+			{"p.name", filters.PolicyNames},
+			{"pp.name", filters.ParentPolicyNames},
+			{"pp.namespace", filters.ParentPolicyNamespaces},
+			{"ce.compliance", filters.Compliance},
+		},
+		"ce.timestamp", filters,
+	)
+}
+
+// lookupsFilterClause returns the WHERE clause of the filters of the template lookups queries, or
+// an empty string if there are none, and its arguments. The template lookups have no policy or
+// compliance, so those filters are ignored.
+func lookupsFilterClause(filters EventFilters) (string, []interface{}) {
+	return whereClause(
+		[]columnFilter{
+			{"c.name", filters.ClusterNames},
+			{"pp.name", filters.ParentPolicyNames},
+			{"pp.namespace", filters.ParentPolicyNamespaces},
+		},
+		"tl.timestamp", filters,
+	)
+}
+
+// whereClause returns the WHERE clause of the column filters and of the timestamp filters on the
+// timestamp column, or an empty string if there are none, and its arguments
+func whereClause(columnFilters []columnFilter, timestampColumn string, filters EventFilters) (string, []interface{}) {
+	conditions := []string{}
+	args := []interface{}{}
+
+	addCondition := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	for _, filter := range columnFilters {
+		if len(filter.values) != 0 {
+			addCondition(filter.column+" = ANY($%d)", pq.Array(filter.values))
+		}
+	}
+
+	if !filters.TimestampAfter.IsZero() {
+		addCondition(timestampColumn+" > $%d", filters.TimestampAfter)
+	}
+
+	if !filters.TimestampBefore.IsZero() {
+		addCondition(timestampColumn+" < $%d", filters.TimestampBefore)
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// pagination returns the page and the number of compliance events per page of the filters, with
+// the defaults applied
+func pagination(filters EventFilters) (page int, perPage int) {
+	page = filters.Page
+	if page < 1 {
+		page = 1
+	}
+
+	perPage = filters.PerPage
+	if perPage < 1 {
+		perPage = perPageDefault
+	} else if perPage > perPageMax {
+		perPage = perPageMax
+	}
+
+	return page, perPage
+}
+
+// rowScanner is implemented by sql.Row and sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanEvent returns the compliance event of a row of the eventsSelect query
+func scanEvent(row rowScanner) (*ComplianceEvent, error) {
+	event := &ComplianceEvent{}
+
+	var (
+		parentID                        sql.NullInt32
+		parentName, parentNamespace     sql.NullString
+		categories, controls, standards []string
+		spec                            []byte
+	)
+
+	err := row.Scan(
+		&event.EventID, &event.Cluster.Name, &event.Cluster.ClusterID,
+		&parentID, &parentName, &parentNamespace,
+		pq.Array(&categories), pq.Array(&controls), pq.Array(&standards),
+		&event.Policy.KeyID, &event.Policy.Kind, &event.Policy.APIGroup, &event.Policy.Name,
+		&event.Policy.Namespace, &spec, &event.Policy.Severity,
+		&event.Event.Compliance, &event.Event.Message, &event.Event.Timestamp, &event.Event.ReportedBy,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if parentID.Valid {
+		event.ParentPolicy = &ParentPolicy{
+			KeyID:      parentID.Int32,
+			Name:       parentName.String,
+			Namespace:  parentNamespace.String,
+			Categories: categories,
+			Controls:   controls,
+			Standards:  standards,
+		}
+	}
+
+	err = json.Unmarshal(spec, &event.Policy.Spec)
+	if err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+// scanTemplateLookups returns the template lookups of a row of the lookupsSelect query
+func scanTemplateLookups(row rowScanner) (*TemplateLookups, error) {
+	lookups := &TemplateLookups{}
+
+	var lookupsJSON []byte
+
+	err := row.Scan(
+		&lookups.ID, &lookups.Cluster.Name, &lookups.Cluster.ClusterID,
+		&lookups.ParentPolicy.KeyID, &lookups.ParentPolicy.Name, &lookups.ParentPolicy.Namespace,
+		pq.Array(&lookups.ParentPolicy.Categories), pq.Array(&lookups.ParentPolicy.Controls),
+		pq.Array(&lookups.ParentPolicy.Standards), &lookupsJSON, &lookups.Timestamp,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(lookupsJSON, &lookups.Lookups)
+	if err != nil {
+		return nil, err
+	}
+
+	return lookups, nil
+}
+
+// nonNil returns an empty slice instead of nil, since a nil array is NULL in the database
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+
+	return values
+}
@@ -0,0 +1,90 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package complianceeventsapi
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFilterClause(t *testing.T) {
+	where, args := filterClause(EventFilters{})
+	if where != "" || len(args) != 0 {
+		t.Fatalf("expected no WHERE clause without filters, got %q", where)
+	}
+
+	where, args = filterClause(EventFilters{
+		ClusterNames:           []string{"cluster1", "cluster2"},
+		ParentPolicyNamespaces: []string{"policies"},
+		TimestampAfter:         time.Date(2021, 8, 1, 0, 0, 0, 0, time.UTC),
+	})
+
+	expected := " WHERE c.name = ANY($1) AND pp.namespace = ANY($2) AND ce.timestamp > $3"
+	if where != expected {
+		t.Fatalf("expected the WHERE clause %q, got %q", expected, where)
+	}
+
+	if len(args) != 3 {
+		t.Fatalf("expected 3 arguments, got %d", len(args))
+	}
+}
+
+func TestLookupsFilterClause(t *testing.T) {
+	where, args := lookupsFilterClause(EventFilters{
+		ClusterNames:      []string{"cluster1"},
+		PolicyNames:       []string{"ignored"},
+		ParentPolicyNames: []string{"policy"},
+		TimestampBefore:   time.Date(2021, 8, 1, 0, 0, 0, 0, time.UTC),
+	})
+
+	expected := " WHERE c.name = ANY($1) AND pp.name = ANY($2) AND tl.timestamp < $3"
+	if where != expected {
+		t.Fatalf("expected the WHERE clause %q, got %q", expected, where)
+	}
+
+	if len(args) != 3 {
+		t.Fatalf("expected 3 arguments, got %d", len(args))
+	}
+}
+
+func TestPagination(t *testing.T) {
+	tests := []struct {
+		filters         EventFilters
+		expectedPage    int
+		expectedPerPage int
+	}{
+		{EventFilters{}, 1, perPageDefault},
+		{EventFilters{Page: 3, PerPage: 50}, 3, 50},
+		{EventFilters{PerPage: 1000}, 1, perPageMax},
+	}
+
+	for _, test := range tests {
+		page, perPage := pagination(test.filters)
+		if page != test.expectedPage || perPage != test.expectedPerPage {
+			t.Fatalf("expected page %d of %d, got page %d of %d",
+				test.expectedPage, test.expectedPerPage, page, perPage)
+		}
+	}
+}
+
+func TestPruneStatements(t *testing.T) {
+	now := time.Date(2021, 8, 1, 0, 0, 0, 0, time.UTC)
+
+	if statements := pruneStatements(Retention{}, now); len(statements) != 0 {
+		t.Fatalf("expected no statements without a retention, got %v", statements)
+	}
+
+	statements := pruneStatements(Retention{MaxAge: 24 * time.Hour, MaxEventsPerPolicy: 10}, now)
+	if len(statements) != 2 {
+		t.Fatalf("expected a statement for the age and one for the count, got %v", statements)
+	}
+
+	if cutoff := statements[0].args[0]; cutoff != now.Add(-24*time.Hour) {
+		t.Fatalf("expected the compliance events before %v to be deleted, got %v", now.Add(-24*time.Hour), cutoff)
+	}
+
+	if !strings.Contains(statements[1].query, "WHERE position > $1") || statements[1].args[0] != 10 {
+		t.Fatalf("expected the compliance events above the 10 most recent to be deleted, got %v", statements[1])
+	}
+}
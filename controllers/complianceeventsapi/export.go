@@ -0,0 +1,181 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package complianceeventsapi
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The formats of the compliance events exports, set with the format query parameter
+const (
+	formatCSV    = "csv"
+	formatNDJSON = "ndjson"
+)
+
+// How many compliance events are written between the flushes of the export response
+const exportFlushInterval = 100
+
+// The columns of the CSV exports
+var csvHeader = []string{
+	"id", "cluster.name", "cluster.clusterId",
+	"parentPolicy.id", "parentPolicy.name", "parentPolicy.namespace",
+	"parentPolicy.categories", "parentPolicy.controls", "parentPolicy.standards",
+	"policy.id", "policy.apiGroup", "policy.kind", "policy.name", "policy.namespace", "policy.severity",
+	"policy.spec", "event.compliance", "event.message", "event.timestamp", "event.reportedBy",
+}
+
+// eventWriter writes the compliance events of an export
+type eventWriter interface {
+	Write(event *ComplianceEvent) error
+	Flush() error
+}
+
+type csvEventWriter struct {
+	writer *csv.Writer
+}
+
+func (w *csvEventWriter) Write(event *ComplianceEvent) error {
+	return w.writer.Write(csvRecord(event))
+}
+
+func (w *csvEventWriter) Flush() error {
+	w.writer.Flush()
+
+	return w.writer.Error()
+}
+
+type ndjsonEventWriter struct {
+	encoder *json.Encoder
+}
+
+func (w *ndjsonEventWriter) Write(event *ComplianceEvent) error {
+	// The encoder ends every compliance event with a newline
+	return w.encoder.Encode(event)
+}
+
+func (w *ndjsonEventWriter) Flush() error {
+	return nil
+}
+
+// exportEvents streams all the compliance events matching the filters of the query, in the CSV
+// format by default or in the NDJSON format when the format query parameter is ndjson. Unlike the
+// queries, the exports are not paginated.
+func (s *ComplianceAPIServer) exportEvents(w http.ResponseWriter, r *http.Request) {
+	filters, err := parseFilters(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = formatCSV
+	}
+
+	if format != formatCSV && format != formatNDJSON {
+		writeError(w, http.StatusBadRequest, "format must be one of csv, ndjson")
+
+		return
+	}
+
+	if !s.authorize(w, r, "list", "", "") {
+		return
+	}
+
+	flusher, _ := w.(http.Flusher)
+
+	var writer eventWriter
+
+	if format == formatCSV {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", `attachment; filename="compliance-events.csv"`)
+
+		csvWriter := csv.NewWriter(w)
+		writer = &csvEventWriter{writer: csvWriter}
+
+		// The header is written even when no compliance events match
+		if err := csvWriter.Write(csvHeader); err != nil {
+			log.Error(err, "Failed to write the compliance events export...")
+
+			return
+		}
+	} else {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		writer = &ndjsonEventWriter{encoder: json.NewEncoder(w)}
+	}
+
+	written := 0
+
+	err = s.Store.StreamEvents(r.Context(), filters, func(event *ComplianceEvent) error {
+		if err := writer.Write(event); err != nil {
+			return err
+		}
+
+		written++
+		if written%exportFlushInterval == 0 {
+			if err := writer.Flush(); err != nil {
+				return err
+			}
+
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		// The status code was already sent, so the export is truncated
+		log.Error(err, "Failed to export the compliance events...", "Written", written)
+
+		return
+	}
+
+	if err := writer.Flush(); err != nil {
+		log.Error(err, "Failed to write the compliance events export...")
+	}
+}
+
+// csvRecord returns the columns of the compliance event in the order of csvHeader
+func csvRecord(event *ComplianceEvent) []string {
+	record := make([]string, 0, len(csvHeader))
+	record = append(record, formatID(event.EventID), event.Cluster.Name, event.Cluster.ClusterID)
+
+	if event.ParentPolicy != nil {
+		record = append(record,
+			formatID(event.ParentPolicy.KeyID), event.ParentPolicy.Name, event.ParentPolicy.Namespace,
+			strings.Join(event.ParentPolicy.Categories, ", "), strings.Join(event.ParentPolicy.Controls, ", "),
+			strings.Join(event.ParentPolicy.Standards, ", "),
+		)
+	} else {
+		record = append(record, "", "", "", "", "", "")
+	}
+
+	spec, err := json.Marshal(event.Policy.Spec)
+	if err != nil || event.Policy.Spec == nil {
+		spec = nil
+	}
+
+	record = append(record,
+		formatID(event.Policy.KeyID), event.Policy.APIGroup, event.Policy.Kind, event.Policy.Name,
+		event.Policy.Namespace, event.Policy.Severity, string(spec),
+		event.Event.Compliance, event.Event.Message, event.Event.Timestamp.Format(time.RFC3339),
+		event.Event.ReportedBy,
+	)
+
+	return record
+}
+
+func formatID(id int32) string {
+	if id == 0 {
+		return ""
+	}
+
+	return strconv.Itoa(int(id))
+}
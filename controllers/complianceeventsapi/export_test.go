@@ -0,0 +1,109 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package complianceeventsapi
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestExportEvents(t *testing.T) {
+	store := &fakeStore{}
+
+	for i := 0; i < 3; i++ {
+		event := newTestEvent()
+		if i == 2 {
+			event.ParentPolicy = nil
+		}
+
+		if err := store.RecordEvent(context.TODO(), event); err != nil {
+			t.Fatalf("failed to record the compliance event: %v", err)
+		}
+	}
+
+	handler := (&ComplianceAPIServer{Store: store, Authorizer: fakeAuthorizer{"admin/list/": true}}).Handler()
+
+	export := func(query string, token string) *httptest.ResponseRecorder {
+		t.Helper()
+
+		req := httptest.NewRequest(http.MethodGet, ReportsPath+query, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+
+		return recorder
+	}
+
+	recorder := export("?cluster.name=cluster1", "admin")
+	if recorder.Code != http.StatusOK || recorder.Header().Get("Content-Type") != "text/csv" {
+		t.Fatalf("expected a CSV export, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	records, err := csv.NewReader(recorder.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to read the CSV export: %v", err)
+	}
+
+	if len(records) != 4 || strings.Join(records[0], ",") != strings.Join(csvHeader, ",") {
+		t.Fatalf("expected the header and 3 compliance events, got %v", records)
+	}
+
+	expected := []string{
+		"1", "cluster1", "", "", "policy", "policies", "", "", "", "", "policy.open-cluster-management.io",
+		"ConfigurationPolicy", "case", "", "", `{"remediationAction":"inform"}`, "NonCompliant",
+		"namespaces [test] not found", "2021-08-01T00:00:00Z", "",
+	}
+	if strings.Join(records[1], "|") != strings.Join(expected, "|") {
+		t.Fatalf("expected the record %v, got %v", expected, records[1])
+	}
+
+	if records[3][4] != "" {
+		t.Fatalf("expected no parent policy, got %v", records[3])
+	}
+
+	if len(store.filters.ClusterNames) != 1 {
+		t.Fatalf("expected the filters to be applied, got %+v", store.filters)
+	}
+
+	recorder = export("?format=ndjson", "admin")
+	if recorder.Code != http.StatusOK || recorder.Header().Get("Content-Type") != "application/x-ndjson" {
+		t.Fatalf("expected an NDJSON export, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	lines := 0
+
+	scanner := bufio.NewScanner(recorder.Body)
+	for scanner.Scan() {
+		event := &ComplianceEvent{}
+		if err := json.Unmarshal(scanner.Bytes(), event); err != nil {
+			t.Fatalf("failed to unmarshal the line %q: %v", scanner.Text(), err)
+		}
+
+		lines++
+	}
+
+	if lines != 3 {
+		t.Fatalf("expected 3 compliance events, got %d", lines)
+	}
+
+	for query, expected := range map[string]int{
+		"?format=xml":                    http.StatusBadRequest,
+		"?event.compliance=Unknown":      http.StatusBadRequest,
+		"?format=csv&cluster.name=other": http.StatusOK,
+	} {
+		if recorder := export(query, "admin"); recorder.Code != expected {
+			t.Fatalf("%s: expected the status code %d, got %d", query, expected, recorder.Code)
+		}
+	}
+
+	if recorder := export("", "cluster1"); recorder.Code != http.StatusForbidden {
+		t.Fatalf("expected the export to be forbidden, got %d", recorder.Code)
+	}
+}
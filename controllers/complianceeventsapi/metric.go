@@ -0,0 +1,29 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package complianceeventsapi
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	prunedEvents = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "compliance_events_pruned_total",
+		Help: "The number of compliance events deleted for being outside of the retention.",
+	})
+	pruneDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name: "compliance_events_prune_duration_seconds",
+		Help: "Time the compliance events database takes to be pruned.",
+	})
+	pruneFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "compliance_events_prune_failures_total",
+		Help: "The number of times the compliance events database failed to be pruned.",
+	})
+)
+
+func init() {
+	metrics.Registry.MustRegister(prunedEvents)
+	metrics.Registry.MustRegister(pruneDuration)
+	metrics.Registry.MustRegister(pruneFailures)
+}
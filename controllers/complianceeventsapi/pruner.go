@@ -0,0 +1,92 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package complianceeventsapi
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// Retention is how many compliance events are kept in the compliance events database. The zero
+// values keep all of them.
+type Retention struct {
+	// MaxAge is how long the compliance events are kept after their timestamp
+	MaxAge time.Duration
+	// MaxEventsPerPolicy is how many of the most recent compliance events are kept for each policy
+	// template of each parent policy on each cluster. The policy templates are identified by their
+	// kind, name, and namespace, so the compliance events of all their specs count together.
+	MaxEventsPerPolicy int
+}
+
+// Enabled returns true if the retention prunes any compliance event
+func (r Retention) Enabled() bool {
+	return r.MaxAge > 0 || r.MaxEventsPerPolicy > 0
+}
+
+// EventsPruner deletes the compliance events outside of the retention, see ComplianceDB
+type EventsPruner interface {
+	PruneEvents(ctx context.Context, retention Retention, now time.Time) (int64, error)
+}
+
+// blank assignment to verify that CompliancePruner is a manager.Runnable
+var _ manager.Runnable = &CompliancePruner{}
+
+// CompliancePruner periodically deletes the compliance events outside of the retention so that the
+// compliance events database doesn't grow unbounded
+type CompliancePruner struct {
+	Store     EventsPruner
+	Retention Retention
+	// Interval is how often the compliance events are pruned. They are only pruned when the
+	// propagator starts when it is not set.
+	Interval time.Duration
+}
+
+// NeedLeaderElection makes only the leader prune the compliance events
+func (p *CompliancePruner) NeedLeaderElection() bool {
+	return true
+}
+
+// Start prunes the compliance events once, and then at every interval until the context is
+// canceled if the interval is set. A failed prune, such as before the tables are created by the
+// compliance events API, is retried at the next interval.
+func (p *CompliancePruner) Start(ctx context.Context) error {
+	if !p.Retention.Enabled() {
+		return nil
+	}
+
+	if p.Interval <= 0 {
+		p.prune(ctx)
+
+		return nil
+	}
+
+	wait.UntilWithContext(ctx, p.prune, p.Interval)
+
+	return nil
+}
+
+// prune deletes the compliance events outside of the retention and records the run in the metrics
+func (p *CompliancePruner) prune(ctx context.Context) {
+	start := time.Now()
+
+	pruned, err := p.Store.PruneEvents(ctx, p.Retention, start)
+
+	pruneDuration.Observe(time.Since(start).Seconds())
+
+	if err != nil {
+		pruneFailures.Inc()
+		log.Error(err, "Failed to prune the compliance events, retrying at the next interval...",
+			"Interval", p.Interval.String())
+
+		return
+	}
+
+	prunedEvents.Add(float64(pruned))
+
+	if pruned != 0 {
+		log.Info("Pruned the compliance events outside of the retention...", "Count", pruned)
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package complianceeventsapi
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// fakePruner records the prune calls and returns the configured result
+type fakePruner struct {
+	calls     int
+	retention Retention
+	pruned    int64
+	err       error
+}
+
+func (p *fakePruner) PruneEvents(ctx context.Context, retention Retention, now time.Time) (int64, error) {
+	p.calls++
+	p.retention = retention
+
+	return p.pruned, p.err
+}
+
+func TestCompliancePrunerStart(t *testing.T) {
+	store := &fakePruner{}
+
+	// Without a retention, the compliance events are never pruned
+	if err := (&CompliancePruner{Store: store}).Start(context.TODO()); err != nil {
+		t.Fatalf("Start returned an error: %v", err)
+	}
+
+	if store.calls != 0 {
+		t.Fatalf("expected no prune without a retention, got %d", store.calls)
+	}
+
+	retention := Retention{MaxAge: time.Hour, MaxEventsPerPolicy: 10}
+	store.pruned = 3
+	prunedBefore := testutil.ToFloat64(prunedEvents)
+
+	// Without an interval, the compliance events are pruned once
+	if err := (&CompliancePruner{Store: store, Retention: retention}).Start(context.TODO()); err != nil {
+		t.Fatalf("Start returned an error: %v", err)
+	}
+
+	if store.calls != 1 || store.retention != retention {
+		t.Fatalf("expected a single prune with the retention %v, got %d with %v", retention, store.calls, store.retention)
+	}
+
+	if pruned := testutil.ToFloat64(prunedEvents) - prunedBefore; pruned != 3 {
+		t.Fatalf("expected the pruned compliance events to be counted, got %v", pruned)
+	}
+}
+
+func TestCompliancePrunerInterval(t *testing.T) {
+	store := &fakePruner{}
+	pruner := &CompliancePruner{Store: store, Retention: Retention{MaxAge: time.Hour}, Interval: 10 * time.Millisecond}
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 200*time.Millisecond)
+	defer cancel()
+
+	if err := pruner.Start(ctx); err != nil {
+		t.Fatalf("Start returned an error: %v", err)
+	}
+
+	if store.calls < 2 {
+		t.Fatalf("expected the compliance events to be pruned at every interval, got %d prunes", store.calls)
+	}
+}
+
+func TestCompliancePrunerFailure(t *testing.T) {
+	store := &fakePruner{pruned: 5, err: errors.New("relation \"compliance_events\" does not exist")}
+	pruner := &CompliancePruner{Store: store, Retention: Retention{MaxEventsPerPolicy: 1}}
+
+	prunedBefore := testutil.ToFloat64(prunedEvents)
+	failuresBefore := testutil.ToFloat64(pruneFailures)
+
+	pruner.prune(context.TODO())
+
+	if failures := testutil.ToFloat64(pruneFailures) - failuresBefore; failures != 1 {
+		t.Fatalf("expected the failed prune to be counted, got %v", failures)
+	}
+
+	if pruned := testutil.ToFloat64(prunedEvents) - prunedBefore; pruned != 0 {
+		t.Fatalf("expected the failed prune to not count pruned compliance events, got %v", pruned)
+	}
+}
@@ -0,0 +1,497 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package complianceeventsapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+)
+
+// DBURLEnvName is the environment variable with the PostgreSQL connection URL of the compliance
+// events database. The compliance events API is only served when it is set.
+const DBURLEnvName = "COMPLIANCE_EVENTS_DB_URL"
+
+// EventsPath is the path of the compliance events API
+const EventsPath = "/api/v1/compliance-events"
+
+// ReportsPath is the path of the compliance events exports, see exportEvents
+const ReportsPath = "/api/v1/reports/compliance-events"
+
+// TemplateLookupsPath is the path of the objects looked up by the hub templates of the root
+// policies when their replicated policies were rendered, see getTemplateLookups
+const TemplateLookupsPath = "/api/v1/template-lookups"
+
+// How long to wait between the attempts to create the tables of the compliance events database
+const migrateRetryDelay = 10 * time.Second
+
+var log = logf.Log.WithName("compliance-events-api")
+
+// ComplianceStore records and queries the compliance events, see ComplianceDB
+type ComplianceStore interface {
+	Migrate(ctx context.Context) error
+	RecordEvent(ctx context.Context, event *ComplianceEvent) error
+	GetEvent(ctx context.Context, id int32) (*ComplianceEvent, error)
+	QueryEvents(ctx context.Context, filters EventFilters) ([]ComplianceEvent, int, error)
+	StreamEvents(ctx context.Context, filters EventFilters, fn func(event *ComplianceEvent) error) error
+	QueryTemplateLookups(ctx context.Context, filters EventFilters) ([]TemplateLookups, int, error)
+}
+
+// Authorizer authorizes the bearer tokens of the compliance events API requests
+type Authorizer interface {
+	// Authorize returns true if the token is allowed to perform the verb on the policies, or their
+	// status when subresource is set, in the namespace. An empty namespace means all namespaces.
+	Authorize(ctx context.Context, token string, verb string, subresource string, namespace string) (bool, error)
+}
+
+//+kubebuilder:rbac:groups=authentication.k8s.io,resources=tokenreviews,verbs=create
+//+kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
+
+// KubeAuthorizer authorizes the bearer tokens of the Kubernetes users with a TokenReview and a
+// SubjectAccessReview
+type KubeAuthorizer struct {
+	Client kubernetes.Interface
+}
+
+// Authorize returns true if the Kubernetes user of the token is allowed to perform the verb on the
+// policies in the namespace
+func (a *KubeAuthorizer) Authorize(
+	ctx context.Context, token string, verb string, subresource string, namespace string,
+) (bool, error) {
+	review, err := a.Client.AuthenticationV1().TokenReviews().Create(ctx, &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: token},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return false, err
+	}
+
+	if !review.Status.Authenticated {
+		return false, nil
+	}
+
+	extra := map[string]authorizationv1.ExtraValue{}
+	for key, value := range review.Status.User.Extra {
+		extra[key] = authorizationv1.ExtraValue(value)
+	}
+
+	access, err := a.Client.AuthorizationV1().SubjectAccessReviews().Create(ctx, &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace:   namespace,
+				Verb:        verb,
+				Group:       common.APIGroup,
+				Resource:    "policies",
+				Subresource: subresource,
+			},
+			User:   review.Status.User.Username,
+			Groups: review.Status.User.Groups,
+			Extra:  extra,
+			UID:    review.Status.User.UID,
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return false, err
+	}
+
+	return access.Status.Allowed, nil
+}
+
+// blank assignment to verify that ComplianceAPIServer is a manager.Runnable
+var _ manager.Runnable = &ComplianceAPIServer{}
+
+// ComplianceAPIServer serves the compliance events API. The managed clusters record the compliance
+// events with POST requests, which requires being allowed to patch the status of the policies in
+// the cluster namespace. Querying the compliance events requires being allowed to list the
+// policies in all namespaces.
+type ComplianceAPIServer struct {
+	// Addr is the address the API binds to
+	Addr string
+	// CertFile and KeyFile are the TLS certificate of the API. The API is served over HTTP when
+	// they are not set.
+	CertFile   string
+	KeyFile    string
+	Store      ComplianceStore
+	Authorizer Authorizer
+}
+
+// NeedLeaderElection returns false so that every replica serves the API
+func (s *ComplianceAPIServer) NeedLeaderElection() bool {
+	return false
+}
+
+// Start creates the tables of the compliance events database and serves the API until the context
+// is done
+func (s *ComplianceAPIServer) Start(ctx context.Context) error {
+	for {
+		err := s.Store.Migrate(ctx)
+		if err == nil {
+			break
+		}
+
+		log.Error(err, "Failed to create the compliance events database tables, retrying...",
+			"RetryAfter", migrateRetryDelay.String())
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(migrateRetryDelay):
+		}
+	}
+
+	server := &http.Server{Addr: s.Addr, Handler: s.Handler()}
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Error(err, "Failed to shut down the compliance events API...")
+		}
+	}()
+
+	log.Info("Serving the compliance events API...", "Addr", s.Addr)
+
+	var err error
+	if s.CertFile != "" && s.KeyFile != "" {
+		err = server.ListenAndServeTLS(s.CertFile, s.KeyFile)
+	} else {
+		err = server.ListenAndServe()
+	}
+
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+
+	return err
+}
+
+// Handler returns the handler of the compliance events API
+func (s *ComplianceAPIServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc(EventsPath, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			s.getEvents(w, r)
+		case http.MethodPost:
+			s.postEvent(w, r)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "the method is not allowed")
+		}
+	})
+
+	mux.HandleFunc(EventsPath+"/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, "the method is not allowed")
+
+			return
+		}
+
+		s.getEvent(w, r)
+	})
+
+	mux.HandleFunc(ReportsPath, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, "the method is not allowed")
+
+			return
+		}
+
+		s.exportEvents(w, r)
+	})
+
+	mux.HandleFunc(TemplateLookupsPath, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, "the method is not allowed")
+
+			return
+		}
+
+		s.getTemplateLookups(w, r)
+	})
+
+	return mux
+}
+
+// authorize writes the error response and returns false if the request is not authorized
+func (s *ComplianceAPIServer) authorize(
+	w http.ResponseWriter, r *http.Request, verb string, subresource string, namespace string,
+) bool {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" || token == r.Header.Get("Authorization") {
+		writeError(w, http.StatusUnauthorized, "a bearer token is required")
+
+		return false
+	}
+
+	allowed, err := s.Authorizer.Authorize(r.Context(), token, verb, subresource, namespace)
+	if err != nil {
+		log.Error(err, "Failed to authorize the compliance events API request...")
+		writeError(w, http.StatusInternalServerError, "failed to authorize the request")
+
+		return false
+	}
+
+	if !allowed {
+		writeError(w, http.StatusForbidden, "the request is not allowed")
+
+		return false
+	}
+
+	return true
+}
+
+// postEvent records the compliance event in the request body
+func (s *ComplianceAPIServer) postEvent(w http.ResponseWriter, r *http.Request) {
+	event := &ComplianceEvent{}
+
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(event); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid compliance event: "+err.Error())
+
+		return
+	}
+
+	if err := event.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid compliance event: "+err.Error())
+
+		return
+	}
+
+	// The managed clusters can only record the compliance events of their cluster namespace
+	if !s.authorize(w, r, "patch", "status", event.Cluster.Name) {
+		return
+	}
+
+	err := s.Store.RecordEvent(r.Context(), event)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			writeError(w, http.StatusBadRequest, "invalid compliance event: "+err.Error())
+
+			return
+		}
+
+		log.Error(err, "Failed to record the compliance event...")
+		writeError(w, http.StatusInternalServerError, "failed to record the compliance event")
+
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, event)
+}
+
+// getEvent returns the compliance event with the ID in the path
+func (s *ComplianceAPIServer) getEvent(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, EventsPath+"/"), 10, 32)
+	if err != nil || id < 1 {
+		writeError(w, http.StatusBadRequest, "the compliance event ID must be a positive integer")
+
+		return
+	}
+
+	if !s.authorize(w, r, "list", "", "") {
+		return
+	}
+
+	event, err := s.Store.GetEvent(r.Context(), int32(id))
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			writeError(w, http.StatusNotFound, "the compliance event was not found")
+
+			return
+		}
+
+		log.Error(err, "Failed to get the compliance event...", "ID", id)
+		writeError(w, http.StatusInternalServerError, "failed to get the compliance event")
+
+		return
+	}
+
+	writeJSON(w, http.StatusOK, event)
+}
+
+// eventsResponse is the response of the compliance events queries
+type eventsResponse struct {
+	Data     []ComplianceEvent `json:"data"`
+	Metadata eventsMetadata    `json:"metadata"`
+}
+
+type eventsMetadata struct {
+	Page    int `json:"page"`
+	Pages   int `json:"pages"`
+	PerPage int `json:"perPage"`
+	Total   int `json:"total"`
+}
+
+// getEvents returns the page of the compliance events matching the filters of the query
+func (s *ComplianceAPIServer) getEvents(w http.ResponseWriter, r *http.Request) {
+	filters, err := parseFilters(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	if !s.authorize(w, r, "list", "", "") {
+		return
+	}
+
+	events, total, err := s.Store.QueryEvents(r.Context(), filters)
+	if err != nil {
+		log.Error(err, "Failed to query the compliance events...")
+		writeError(w, http.StatusInternalServerError, "failed to query the compliance events")
+
+		return
+	}
+
+	page, perPage := pagination(filters)
+
+	writeJSON(w, http.StatusOK, eventsResponse{
+		Data: events,
+		Metadata: eventsMetadata{
+			Page:    page,
+			Pages:   (total + perPage - 1) / perPage,
+			PerPage: perPage,
+			Total:   total,
+		},
+	})
+}
+
+// lookupsResponse is the response of the template lookups queries
+type lookupsResponse struct {
+	Data     []TemplateLookups `json:"data"`
+	Metadata eventsMetadata    `json:"metadata"`
+}
+
+// getTemplateLookups returns the page of the objects looked up by the hub templates of the root
+// policies matching the filters of the query, so that auditors can tell which versions of the hub
+// objects the policies on a cluster were rendered from. It supports the filters of the compliance
+// events queries except for policy.name and event.compliance.
+func (s *ComplianceAPIServer) getTemplateLookups(w http.ResponseWriter, r *http.Request) {
+	filters, err := parseFilters(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	if len(filters.PolicyNames) != 0 || len(filters.Compliance) != 0 {
+		writeError(w, http.StatusBadRequest, "the template lookups can't be filtered by policy.name or event.compliance")
+
+		return
+	}
+
+	if !s.authorize(w, r, "list", "", "") {
+		return
+	}
+
+	lookups, total, err := s.Store.QueryTemplateLookups(r.Context(), filters)
+	if err != nil {
+		log.Error(err, "Failed to query the template lookups...")
+		writeError(w, http.StatusInternalServerError, "failed to query the template lookups")
+
+		return
+	}
+
+	page, perPage := pagination(filters)
+
+	writeJSON(w, http.StatusOK, lookupsResponse{
+		Data: lookups,
+		Metadata: eventsMetadata{
+			Page:    page,
+			Pages:   (total + perPage - 1) / perPage,
+			PerPage: perPage,
+			Total:   total,
+		},
+	})
+}
+
+// parseFilters returns the filters of the query parameters of the request. The filters with
+// multiple values are comma separated.
+func parseFilters(r *http.Request) (EventFilters, error) {
+	query := r.URL.Query()
+	filters := EventFilters{
+		ClusterNames:           splitQuery(query.Get("cluster.name")),
+		PolicyNames:            splitQuery(query.Get("policy.name")),
+		ParentPolicyNames:      splitQuery(query.Get("parentPolicy.name")),
+		ParentPolicyNamespaces: splitQuery(query.Get("parentPolicy.namespace")),
+		Compliance:             splitQuery(query.Get("event.compliance")),
+	}
+
+	for _, compliance := range filters.Compliance {
+		if !validCompliance[compliance] {
+			return filters, fmt.Errorf("event.compliance must be one of %s", strings.Join(complianceStates(), ", "))
+		}
+	}
+
+	for param, timestamp := range map[string]*time.Time{
+		"event.timestampAfter":  &filters.TimestampAfter,
+		"event.timestampBefore": &filters.TimestampBefore,
+	} {
+		if value := query.Get(param); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return filters, fmt.Errorf("%s must be an RFC 3339 timestamp", param)
+			}
+
+			*timestamp = parsed
+		}
+	}
+
+	for param, number := range map[string]*int{"page": &filters.Page, "perPage": &filters.PerPage} {
+		if value := query.Get(param); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 1 {
+				return filters, fmt.Errorf("%s must be a positive integer", param)
+			}
+
+			*number = parsed
+		}
+	}
+
+	if filters.PerPage > perPageMax {
+		return filters, fmt.Errorf("perPage must not be greater than %d", perPageMax)
+	}
+
+	return filters, nil
+}
+
+// splitQuery returns the comma separated values of a query parameter, or nil if it is empty
+func splitQuery(value string) []string {
+	if value == "" {
+		return nil
+	}
+
+	return splitAnnotation(value)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Error(err, "Failed to write the compliance events API response...")
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"message": message})
+}
@@ -0,0 +1,228 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package complianceeventsapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// fakeStore keeps the compliance events in memory
+type fakeStore struct {
+	events  []ComplianceEvent
+	lookups []TemplateLookups
+	filters EventFilters
+}
+
+func (s *fakeStore) Migrate(ctx context.Context) error {
+	return nil
+}
+
+func (s *fakeStore) RecordEvent(ctx context.Context, event *ComplianceEvent) error {
+	if event.Policy.KeyID > 100 {
+		return ErrNotFound
+	}
+
+	event.EventID = int32(len(s.events) + 1)
+	s.events = append(s.events, *event)
+
+	return nil
+}
+
+func (s *fakeStore) GetEvent(ctx context.Context, id int32) (*ComplianceEvent, error) {
+	if int(id) > len(s.events) {
+		return nil, ErrNotFound
+	}
+
+	return &s.events[id-1], nil
+}
+
+func (s *fakeStore) QueryEvents(ctx context.Context, filters EventFilters) ([]ComplianceEvent, int, error) {
+	s.filters = filters
+
+	return s.events, len(s.events), nil
+}
+
+func (s *fakeStore) StreamEvents(
+	ctx context.Context, filters EventFilters, fn func(event *ComplianceEvent) error,
+) error {
+	s.filters = filters
+
+	for i := range s.events {
+		if err := fn(&s.events[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *fakeStore) QueryTemplateLookups(ctx context.Context, filters EventFilters) ([]TemplateLookups, int, error) {
+	s.filters = filters
+
+	return s.lookups, len(s.lookups), nil
+}
+
+// fakeAuthorizer allows the tokens to perform the verbs in the namespaces, in the format of
+// <token>/<verb>/<namespace>
+type fakeAuthorizer map[string]bool
+
+func (a fakeAuthorizer) Authorize(
+	ctx context.Context, token string, verb string, subresource string, namespace string,
+) (bool, error) {
+	return a[token+"/"+verb+"/"+namespace], nil
+}
+
+func TestComplianceAPI(t *testing.T) {
+	store := &fakeStore{}
+	server := &ComplianceAPIServer{
+		Store:      store,
+		Authorizer: fakeAuthorizer{"cluster1/patch/cluster1": true, "admin/list/": true},
+	}
+	handler := server.Handler()
+
+	request := func(method string, target string, token string, body interface{}) *httptest.ResponseRecorder {
+		t.Helper()
+
+		raw, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("failed to marshal the request body: %v", err)
+		}
+
+		req := httptest.NewRequest(method, target, bytes.NewReader(raw))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+
+		return recorder
+	}
+
+	tests := []struct {
+		name     string
+		method   string
+		target   string
+		token    string
+		body     interface{}
+		expected int
+	}{
+		{"record", http.MethodPost, EventsPath, "cluster1", newTestEvent(), http.StatusCreated},
+		{"record without a token", http.MethodPost, EventsPath, "", newTestEvent(), http.StatusUnauthorized},
+		{"record for another cluster", http.MethodPost, EventsPath, "cluster2", newTestEvent(), http.StatusForbidden},
+		{"record an invalid event", http.MethodPost, EventsPath, "cluster1", map[string]string{}, http.StatusBadRequest},
+		{
+			"record an unknown field", http.MethodPost, EventsPath, "cluster1",
+			map[string]string{"unknown": "field"}, http.StatusBadRequest,
+		},
+		{
+			"record an unknown policy", http.MethodPost, EventsPath, "cluster1",
+			func() *ComplianceEvent {
+				event := newTestEvent()
+				event.Policy = Policy{KeyID: 1000}
+
+				return event
+			}(),
+			http.StatusBadRequest,
+		},
+		{"get", http.MethodGet, EventsPath + "/1", "admin", nil, http.StatusOK},
+		{"get a missing event", http.MethodGet, EventsPath + "/2", "admin", nil, http.StatusNotFound},
+		{"get an invalid ID", http.MethodGet, EventsPath + "/abc", "admin", nil, http.StatusBadRequest},
+		{"get as a cluster", http.MethodGet, EventsPath + "/1", "cluster1", nil, http.StatusForbidden},
+		{"query the template lookups", http.MethodGet, TemplateLookupsPath, "admin", nil, http.StatusOK},
+		{
+			"query the template lookups as a cluster", http.MethodGet, TemplateLookupsPath, "cluster1", nil,
+			http.StatusForbidden,
+		},
+		{
+			"query the template lookups by compliance", http.MethodGet,
+			TemplateLookupsPath + "?event.compliance=Compliant", "admin", nil, http.StatusBadRequest,
+		},
+		{
+			"post the template lookups", http.MethodPost, TemplateLookupsPath, "admin", nil,
+			http.StatusMethodNotAllowed,
+		},
+		{"query", http.MethodGet, EventsPath + "?cluster.name=cluster1,cluster2", "admin", nil, http.StatusOK},
+		{
+			"query an invalid timestamp", http.MethodGet, EventsPath + "?event.timestampAfter=yesterday", "admin",
+			nil, http.StatusBadRequest,
+		},
+		{"query too many", http.MethodGet, EventsPath + "?perPage=1000", "admin", nil, http.StatusBadRequest},
+		{"delete", http.MethodDelete, EventsPath, "admin", nil, http.StatusMethodNotAllowed},
+	}
+
+	for _, test := range tests {
+		recorder := request(test.method, test.target, test.token, test.body)
+		if recorder.Code != test.expected {
+			t.Fatalf("%s: expected the status code %d, got %d: %s",
+				test.name, test.expected, recorder.Code, recorder.Body.String())
+		}
+	}
+
+	if len(store.filters.ClusterNames) != 2 || store.filters.ClusterNames[1] != "cluster2" {
+		t.Fatalf("expected the cluster name filters, got %v", store.filters.ClusterNames)
+	}
+
+	response := eventsResponse{}
+
+	recorder := request(http.MethodGet, EventsPath+"?perPage=10", "admin", nil)
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to unmarshal the response: %v", err)
+	}
+
+	expected := eventsMetadata{Page: 1, Pages: 1, PerPage: 10, Total: 1}
+	if response.Metadata != expected || len(response.Data) != 1 {
+		t.Fatalf("expected the metadata %+v and one event, got %+v", expected, response)
+	}
+
+	if response.Data[0].Event.Message != newTestEvent().Event.Message {
+		t.Fatalf("unexpected compliance event %+v", response.Data[0])
+	}
+}
+
+func TestTemplateLookupsAPI(t *testing.T) {
+	store := &fakeStore{lookups: []TemplateLookups{{
+		ID:           1,
+		Cluster:      Cluster{Name: "cluster1"},
+		ParentPolicy: ParentPolicy{KeyID: 1, Name: "policy", Namespace: "policies"},
+		Lookups: []TemplateLookup{
+			{Version: "v1", Resource: "configmaps", Namespace: "policies", Name: "config", Hash: "abc"},
+		},
+	}}}
+	server := &ComplianceAPIServer{Store: store, Authorizer: fakeAuthorizer{"admin/list/": true}}
+
+	req := httptest.NewRequest(
+		http.MethodGet, TemplateLookupsPath+"?cluster.name=cluster1&parentPolicy.namespace=policies", nil,
+	)
+	req.Header.Set("Authorization", "Bearer admin")
+
+	recorder := httptest.NewRecorder()
+	server.Handler().ServeHTTP(recorder, req)
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected the status code %d, got %d: %s", http.StatusOK, recorder.Code, recorder.Body.String())
+	}
+
+	if len(store.filters.ClusterNames) != 1 || len(store.filters.ParentPolicyNamespaces) != 1 {
+		t.Fatalf("expected the cluster name and parent policy namespace filters, got %+v", store.filters)
+	}
+
+	response := lookupsResponse{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to unmarshal the response: %v", err)
+	}
+
+	expected := eventsMetadata{Page: 1, Pages: 1, PerPage: perPageDefault, Total: 1}
+	if response.Metadata != expected || len(response.Data) != 1 || len(response.Data[0].Lookups) != 1 {
+		t.Fatalf("expected the metadata %+v and one template lookup, got %+v", expected, response)
+	}
+
+	if response.Data[0].Lookups[0] != store.lookups[0].Lookups[0] {
+		t.Fatalf("unexpected template lookup %+v", response.Data[0].Lookups[0])
+	}
+}
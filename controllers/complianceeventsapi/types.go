@@ -0,0 +1,236 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package complianceeventsapi
+
+import (
+	"crypto/sha1" // #nosec G505 -- the hash only identifies the policy specs
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+)
+
+// The compliance states that can be recorded in addition to the ones of the policies
+const (
+	Pending  = "Pending"
+	Disabled = "Disabled"
+)
+
+var validCompliance = map[string]bool{
+	string(policiesv1.Compliant):    true,
+	string(policiesv1.NonCompliant): true,
+	Pending:                         true,
+	Disabled:                        true,
+}
+
+// Cluster is the managed cluster a compliance event was reported on
+type Cluster struct {
+	KeyID     int32  `json:"-"`
+	Name      string `json:"name"`
+	ClusterID string `json:"clusterId,omitempty"`
+}
+
+// ParentPolicy is the root policy that replicated the policy a compliance event was reported on
+type ParentPolicy struct {
+	KeyID      int32    `json:"id,omitempty"`
+	Name       string   `json:"name,omitempty"`
+	Namespace  string   `json:"namespace,omitempty"`
+	Categories []string `json:"categories,omitempty"`
+	Controls   []string `json:"controls,omitempty"`
+	Standards  []string `json:"standards,omitempty"`
+}
+
+// Policy is the policy template a compliance event was reported on
+type Policy struct {
+	KeyID     int32                  `json:"id,omitempty"`
+	Kind      string                 `json:"kind,omitempty"`
+	APIGroup  string                 `json:"apiGroup,omitempty"`
+	Name      string                 `json:"name,omitempty"`
+	Namespace string                 `json:"namespace,omitempty"`
+	Spec      map[string]interface{} `json:"spec,omitempty"`
+	Severity  string                 `json:"severity,omitempty"`
+}
+
+// Event is the compliance state transition of a compliance event
+type Event struct {
+	Compliance string    `json:"compliance"`
+	Message    string    `json:"message"`
+	Timestamp  time.Time `json:"timestamp"`
+	ReportedBy string    `json:"reportedBy,omitempty"`
+}
+
+// ComplianceEvent is a compliance state transition of a policy template on a managed cluster
+type ComplianceEvent struct {
+	EventID      int32         `json:"id,omitempty"`
+	Cluster      Cluster       `json:"cluster"`
+	ParentPolicy *ParentPolicy `json:"parentPolicy,omitempty"`
+	Policy       Policy        `json:"policy"`
+	Event        Event         `json:"event"`
+}
+
+// TemplateLookup is an object looked up by the hub templates of a root policy when its replicated
+// policy was rendered. The content of the object is identified by its hash, except for the Secrets
+// whose values could be guessed from it, which are only identified by their resource version.
+type TemplateLookup struct {
+	Group     string `json:"group,omitempty"`
+	Version   string `json:"version"`
+	Resource  string `json:"resource"`
+	Namespace string `json:"namespace,omitempty"`
+	// Name is empty when the objects were listed
+	Name            string `json:"name,omitempty"`
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+	Hash            string `json:"hash,omitempty"`
+}
+
+// TemplateLookups are the objects looked up by the hub templates of a root policy when its
+// replicated policy was rendered for a cluster, so that the compliance events can be traced back
+// to the versions of the objects the policy was rendered from
+type TemplateLookups struct {
+	ID           int32            `json:"id,omitempty"`
+	Cluster      Cluster          `json:"cluster"`
+	ParentPolicy ParentPolicy     `json:"parentPolicy"`
+	Lookups      []TemplateLookup `json:"lookups"`
+	// Timestamp is when the replicated policy started being rendered from the objects
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// LookupsHash returns the hash identifying the looked up objects
+func (l *TemplateLookups) LookupsHash() (string, []byte, error) {
+	lookups, err := json.Marshal(l.Lookups)
+	if err != nil {
+		return "", nil, err
+	}
+
+	sum := sha1.Sum(lookups) // #nosec G401 -- the hash only identifies the looked up objects
+
+	return hex.EncodeToString(sum[:]), lookups, nil
+}
+
+// Validate returns an error describing every invalid field of the compliance event. The parent
+// policy and the policy can either be referenced by their ID or be fully specified.
+func (ce *ComplianceEvent) Validate() error {
+	errs := []string{}
+
+	if ce.Cluster.Name == "" {
+		errs = append(errs, "cluster.name is required")
+	}
+
+	if ce.ParentPolicy != nil && ce.ParentPolicy.KeyID == 0 {
+		if ce.ParentPolicy.Name == "" {
+			errs = append(errs, "parentPolicy.name is required")
+		}
+
+		if ce.ParentPolicy.Namespace == "" {
+			errs = append(errs, "parentPolicy.namespace is required")
+		}
+	}
+
+	if ce.Policy.KeyID == 0 {
+		for field, value := range map[string]string{
+			"policy.kind": ce.Policy.Kind, "policy.apiGroup": ce.Policy.APIGroup, "policy.name": ce.Policy.Name,
+		} {
+			if value == "" {
+				errs = append(errs, field+" is required")
+			}
+		}
+
+		if ce.Policy.Spec == nil {
+			errs = append(errs, "policy.spec is required")
+		}
+	}
+
+	if !validCompliance[ce.Event.Compliance] {
+		errs = append(errs, fmt.Sprintf("event.compliance must be one of %s", strings.Join(complianceStates(), ", ")))
+	}
+
+	if ce.Event.Timestamp.IsZero() {
+		errs = append(errs, "event.timestamp is required")
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	// The map iteration order is random
+	sort.Strings(errs)
+
+	return errors.New(strings.Join(errs, ", "))
+}
+
+// SpecHash returns the hash identifying the spec of the policy
+func (p *Policy) SpecHash() (string, error) {
+	// The keys of the maps are sorted when marshaled
+	spec, err := json.Marshal(p.Spec)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha1.Sum(spec) // #nosec G401 -- the hash only identifies the policy specs
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// ParentPolicyFromPolicy returns the parent policy of the compliance events of the replicated
+// policies of the root policy
+func ParentPolicyFromPolicy(root *policiesv1.Policy) *ParentPolicy {
+	annotations := root.GetAnnotations()
+
+	return &ParentPolicy{
+		Name:       root.GetName(),
+		Namespace:  root.GetNamespace(),
+		Categories: splitAnnotation(annotations[common.APIGroup+"/categories"]),
+		Controls:   splitAnnotation(annotations[common.APIGroup+"/controls"]),
+		Standards:  splitAnnotation(annotations[common.APIGroup+"/standards"]),
+	}
+}
+
+// PolicyFromTemplate returns the policy of the compliance events of the policy template
+func PolicyFromTemplate(template *unstructured.Unstructured) *Policy {
+	policy := &Policy{
+		Kind:      template.GetKind(),
+		APIGroup:  template.GroupVersionKind().Group,
+		Name:      template.GetName(),
+		Namespace: template.GetNamespace(),
+		Spec:      map[string]interface{}{},
+	}
+
+	if spec, ok := template.Object["spec"].(map[string]interface{}); ok {
+		policy.Spec = spec
+		policy.Severity, _ = spec["severity"].(string)
+	}
+
+	return policy
+}
+
+// splitAnnotation splits a comma separated annotation value and trims the whitespace of each entry
+func splitAnnotation(value string) []string {
+	result := []string{}
+
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry != "" {
+			result = append(result, entry)
+		}
+	}
+
+	return result
+}
+
+func complianceStates() []string {
+	states := make([]string, 0, len(validCompliance))
+	for state := range validCompliance {
+		states = append(states, state)
+	}
+
+	sort.Strings(states)
+
+	return states
+}
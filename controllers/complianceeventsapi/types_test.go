@@ -0,0 +1,139 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package complianceeventsapi
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+)
+
+func newTestEvent() *ComplianceEvent {
+	return &ComplianceEvent{
+		Cluster:      Cluster{Name: "cluster1"},
+		ParentPolicy: &ParentPolicy{Name: "policy", Namespace: "policies"},
+		Policy: Policy{
+			Kind: "ConfigurationPolicy", APIGroup: "policy.open-cluster-management.io", Name: "case",
+			Spec: map[string]interface{}{"remediationAction": "inform"},
+		},
+		Event: Event{
+			Compliance: "NonCompliant",
+			Message:    "namespaces [test] not found",
+			Timestamp:  time.Date(2021, 8, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		update   func(*ComplianceEvent)
+		expected string
+	}{
+		{name: "valid", update: func(*ComplianceEvent) {}},
+		{
+			name: "references by ID",
+			update: func(ce *ComplianceEvent) {
+				ce.ParentPolicy = &ParentPolicy{KeyID: 1}
+				ce.Policy = Policy{KeyID: 2}
+			},
+		},
+		{name: "no parent policy", update: func(ce *ComplianceEvent) { ce.ParentPolicy = nil }},
+		{
+			name:     "missing fields",
+			update:   func(ce *ComplianceEvent) { *ce = ComplianceEvent{ParentPolicy: &ParentPolicy{}} },
+			expected: "cluster.name is required, event.compliance must be one of",
+		},
+		{
+			name:     "invalid compliance",
+			update:   func(ce *ComplianceEvent) { ce.Event.Compliance = "Unknown" },
+			expected: "event.compliance must be one of Compliant, Disabled, NonCompliant, Pending",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			event := newTestEvent()
+			test.update(event)
+
+			err := event.Validate()
+			if test.expected == "" {
+				if err != nil {
+					t.Fatalf("expected the compliance event to be valid, got %v", err)
+				}
+
+				return
+			}
+
+			if err == nil || !strings.HasPrefix(err.Error(), test.expected) {
+				t.Fatalf("expected the error %q, got %v", test.expected, err)
+			}
+		})
+	}
+
+	event := &ComplianceEvent{ParentPolicy: &ParentPolicy{}}
+
+	for _, field := range []string{"parentPolicy.name", "policy.kind", "policy.spec", "event.timestamp"} {
+		if !strings.Contains(event.Validate().Error(), field+" is required") {
+			t.Fatalf("expected %s to be required, got %v", field, event.Validate())
+		}
+	}
+}
+
+func TestPolicyFromTemplate(t *testing.T) {
+	template := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "policy.open-cluster-management.io/v1",
+		"kind":       "ConfigurationPolicy",
+		"metadata":   map[string]interface{}{"name": "case"},
+		"spec":       map[string]interface{}{"severity": "high", "remediationAction": "inform"},
+	}}
+
+	policy := PolicyFromTemplate(template)
+
+	if policy.APIGroup != "policy.open-cluster-management.io" || policy.Kind != "ConfigurationPolicy" ||
+		policy.Name != "case" || policy.Severity != "high" {
+		t.Fatalf("unexpected policy %+v", policy)
+	}
+
+	hash, err := policy.SpecHash()
+	if err != nil {
+		t.Fatalf("failed to hash the spec: %v", err)
+	}
+
+	// The hash doesn't depend on the order of the keys
+	other := &Policy{Spec: map[string]interface{}{"remediationAction": "inform", "severity": "high"}}
+	if otherHash, _ := other.SpecHash(); otherHash != hash {
+		t.Fatalf("expected the same spec hash, got %s and %s", hash, otherHash)
+	}
+}
+
+func TestParentPolicyFromPolicy(t *testing.T) {
+	root := &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{
+		Name: "policy", Namespace: "policies",
+		Annotations: map[string]string{
+			"policy.open-cluster-management.io/standards": "NIST SP 800-53, NIST CSF",
+			"policy.open-cluster-management.io/controls":  "CM-2 Baseline Configuration",
+		},
+	}}
+
+	parent := ParentPolicyFromPolicy(root)
+
+	if parent.Name != "policy" || parent.Namespace != "policies" {
+		t.Fatalf("unexpected parent policy %+v", parent)
+	}
+
+	if len(parent.Standards) != 2 || parent.Standards[1] != "NIST CSF" {
+		t.Fatalf("unexpected standards %v", parent.Standards)
+	}
+
+	if len(parent.Categories) != 0 || len(parent.Controls) != 1 {
+		t.Fatalf("unexpected categories %v or controls %v", parent.Categories, parent.Controls)
+	}
+}
@@ -0,0 +1,252 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package compliancereport
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	policyv1beta1 "github.com/open-cluster-management/governance-policy-propagator/api/v1beta1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+)
+
+const ControllerName string = "policy-compliance-report"
+
+// ReportName is the name of the PolicyComplianceReport written in each namespace with root policies
+const ReportName string = "compliance-report"
+
+const (
+	standardsAnnotation  = "policy.open-cluster-management.io/standards"
+	categoriesAnnotation = "policy.open-cluster-management.io/categories"
+)
+
+var log = logf.Log.WithName(ControllerName)
+
+//+kubebuilder:rbac:groups=policy.open-cluster-management.io,resources=policycompliancereports,verbs=get;list;watch;create;update;delete
+//+kubebuilder:rbac:groups=policy.open-cluster-management.io,resources=policies,verbs=get;list;watch
+
+// SetupWithManager sets up the controller with the Manager. A report is requested when a root
+// policy is created or deleted, and is then rewritten every Interval.
+func (r *PolicyComplianceReportReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(ControllerName).
+		For(
+			&policyv1beta1.PolicyComplianceReport{},
+			builder.WithPredicates(predicate.Funcs{
+				CreateFunc: func(e event.CreateEvent) bool { return false },
+				UpdateFunc: func(e event.UpdateEvent) bool { return false },
+			})).
+		Watches(
+			&source.Kind{Type: &policiesv1.Policy{}},
+			handler.EnqueueRequestsFromMapFunc(policyMapper),
+			builder.WithPredicates(predicate.Funcs{
+				UpdateFunc: func(e event.UpdateEvent) bool { return false },
+			})).
+		Complete(r)
+}
+
+// policyMapper returns a reconcile request for the report in the namespace of a root policy
+func policyMapper(object client.Object) []reconcile.Request {
+	if _, ok := object.GetLabels()[common.RootPolicyLabel]; ok {
+		return nil
+	}
+
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{
+		Namespace: object.GetNamespace(),
+		Name:      ReportName,
+	}}}
+}
+
+// blank assignment to verify that PolicyComplianceReportReconciler implements reconcile.Reconciler
+var _ reconcile.Reconciler = &PolicyComplianceReportReconciler{}
+
+// PolicyComplianceReportReconciler periodically writes a PolicyComplianceReport summarizing the
+// compliance of the root policies in a namespace
+type PolicyComplianceReportReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	// Interval is how often the report is rewritten
+	Interval time.Duration
+}
+
+// Reconcile writes the PolicyComplianceReport of the namespace and requeues after the interval.
+// If there are no longer root policies in the namespace, the report is deleted.
+func (r *PolicyComplianceReportReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+	reqLogger.Info("Reconciling the compliance report...")
+
+	if request.Name != ReportName {
+		return reconcile.Result{}, nil
+	}
+
+	policyList := &policiesv1.PolicyList{}
+	err := r.List(ctx, policyList, client.InNamespace(request.Namespace))
+	if err != nil {
+		reqLogger.Error(err, "Failed to list the policies...")
+		return reconcile.Result{}, err
+	}
+
+	rootPolicies := make([]policiesv1.Policy, 0, len(policyList.Items))
+	for _, policy := range policyList.Items {
+		if _, ok := policy.GetLabels()[common.RootPolicyLabel]; !ok {
+			rootPolicies = append(rootPolicies, policy)
+		}
+	}
+
+	report := &policyv1beta1.PolicyComplianceReport{}
+	err = r.Get(ctx, request.NamespacedName, report)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			reqLogger.Error(err, "Failed to get the compliance report...")
+			return reconcile.Result{}, err
+		}
+
+		if len(rootPolicies) == 0 {
+			return reconcile.Result{}, nil
+		}
+
+		report = buildReport(request.Namespace, rootPolicies)
+		reqLogger.Info("Creating the compliance report...")
+
+		err = r.Create(ctx, report)
+		if err != nil {
+			reqLogger.Error(err, "Failed to create the compliance report...")
+			return reconcile.Result{}, err
+		}
+
+		return reconcile.Result{RequeueAfter: r.Interval}, nil
+	}
+
+	if len(rootPolicies) == 0 {
+		reqLogger.Info("Deleting the compliance report since there are no root policies in the namespace...")
+
+		return reconcile.Result{}, client.IgnoreNotFound(r.Delete(ctx, report))
+	}
+
+	desired := buildReport(request.Namespace, rootPolicies)
+	report.GeneratedAt = desired.GeneratedAt
+	report.Summary = desired.Summary
+	report.Policies = desired.Policies
+	report.Clusters = desired.Clusters
+	report.Standards = desired.Standards
+	report.Categories = desired.Categories
+
+	err = r.Update(ctx, report)
+	if err != nil {
+		reqLogger.Error(err, "Failed to update the compliance report...")
+		return reconcile.Result{}, err
+	}
+
+	return reconcile.Result{RequeueAfter: r.Interval}, nil
+}
+
+// buildReport summarizes the compliance of the input root policies by policy, cluster, standard,
+// and category.
+func buildReport(namespace string, rootPolicies []policiesv1.Policy) *policyv1beta1.PolicyComplianceReport {
+	report := &policyv1beta1.PolicyComplianceReport{
+		ObjectMeta:  metav1.ObjectMeta{Name: ReportName, Namespace: namespace},
+		GeneratedAt: metav1.Now(),
+	}
+
+	clusters := map[string]*policyv1beta1.ComplianceSummary{}
+	standards := map[string]*policyv1beta1.ComplianceSummary{}
+	categories := map[string]*policyv1beta1.ComplianceSummary{}
+
+	for _, policy := range rootPolicies {
+		if policy.Spec.Disabled {
+			continue
+		}
+
+		policySummary := policyv1beta1.PolicyComplianceSummary{
+			Name:            policy.GetName(),
+			ComplianceState: string(policy.Status.ComplianceState),
+		}
+
+		for _, cpcs := range policy.Status.Status {
+			count(&policySummary.ComplianceSummary, cpcs.ComplianceState)
+			count(groupSummary(clusters, cpcs.ClusterName), cpcs.ComplianceState)
+		}
+
+		report.Policies = append(report.Policies, policySummary)
+		count(&report.Summary, policy.Status.ComplianceState)
+
+		for _, standard := range splitAnnotation(policy.GetAnnotations()[standardsAnnotation]) {
+			count(groupSummary(standards, standard), policy.Status.ComplianceState)
+		}
+
+		for _, category := range splitAnnotation(policy.GetAnnotations()[categoriesAnnotation]) {
+			count(groupSummary(categories, category), policy.Status.ComplianceState)
+		}
+	}
+
+	report.Clusters = sortedGroups(clusters)
+	report.Standards = sortedGroups(standards)
+	report.Categories = sortedGroups(categories)
+
+	return report
+}
+
+func count(summary *policyv1beta1.ComplianceSummary, state policiesv1.ComplianceState) {
+	switch state {
+	case policiesv1.Compliant:
+		summary.Compliant++
+	case policiesv1.NonCompliant:
+		summary.NonCompliant++
+	default:
+		summary.Unknown++
+	}
+}
+
+func groupSummary(
+	groups map[string]*policyv1beta1.ComplianceSummary, name string,
+) *policyv1beta1.ComplianceSummary {
+	if _, ok := groups[name]; !ok {
+		groups[name] = &policyv1beta1.ComplianceSummary{}
+	}
+
+	return groups[name]
+}
+
+func sortedGroups(groups map[string]*policyv1beta1.ComplianceSummary) []policyv1beta1.GroupComplianceSummary {
+	result := make([]policyv1beta1.GroupComplianceSummary, 0, len(groups))
+	for name, summary := range groups {
+		result = append(result, policyv1beta1.GroupComplianceSummary{Name: name, ComplianceSummary: *summary})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result
+}
+
+// splitAnnotation splits a comma separated annotation value and trims the whitespace of each entry
+func splitAnnotation(value string) []string {
+	var result []string
+
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry != "" {
+			result = append(result, entry)
+		}
+	}
+
+	return result
+}
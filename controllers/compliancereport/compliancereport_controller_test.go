@@ -0,0 +1,77 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package compliancereport
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	policyv1beta1 "github.com/open-cluster-management/governance-policy-propagator/api/v1beta1"
+)
+
+func TestBuildReport(t *testing.T) {
+	policies := []policiesv1.Policy{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "policy-1",
+				Annotations: map[string]string{
+					standardsAnnotation:  "NIST SP 800-53, PCI",
+					categoriesAnnotation: "CM Configuration Management",
+				},
+			},
+			Status: policiesv1.PolicyStatus{
+				ComplianceState: policiesv1.NonCompliant,
+				Status: []*policiesv1.CompliancePerClusterStatus{
+					{ClusterName: "cluster1", ComplianceState: policiesv1.Compliant},
+					{ClusterName: "cluster2", ComplianceState: policiesv1.NonCompliant},
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "policy-2",
+				Annotations: map[string]string{standardsAnnotation: "NIST SP 800-53"},
+			},
+			Status: policiesv1.PolicyStatus{
+				ComplianceState: policiesv1.Compliant,
+				Status: []*policiesv1.CompliancePerClusterStatus{
+					{ClusterName: "cluster1", ComplianceState: policiesv1.Compliant},
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "policy-3"},
+			Spec:       policiesv1.PolicySpec{Disabled: true},
+		},
+	}
+
+	report := buildReport("policies", policies)
+
+	expectedSummary := policyv1beta1.ComplianceSummary{Compliant: 1, NonCompliant: 1}
+	if report.Summary != expectedSummary {
+		t.Fatalf("Expected the summary %v, got %v", expectedSummary, report.Summary)
+	}
+
+	if len(report.Policies) != 2 || report.Policies[0].NonCompliant != 1 || report.Policies[0].Compliant != 1 {
+		t.Fatalf("Unexpected policy summaries: %v", report.Policies)
+	}
+
+	expectedClusters := []policyv1beta1.GroupComplianceSummary{
+		{Name: "cluster1", ComplianceSummary: policyv1beta1.ComplianceSummary{Compliant: 2}},
+		{Name: "cluster2", ComplianceSummary: policyv1beta1.ComplianceSummary{NonCompliant: 1}},
+	}
+	if len(report.Clusters) != 2 || report.Clusters[0] != expectedClusters[0] || report.Clusters[1] != expectedClusters[1] {
+		t.Fatalf("Expected the cluster summaries %v, got %v", expectedClusters, report.Clusters)
+	}
+
+	if len(report.Standards) != 2 || report.Standards[0].Name != "NIST SP 800-53" ||
+		report.Standards[0].ComplianceSummary != expectedSummary {
+		t.Fatalf("Unexpected standard summaries: %v", report.Standards)
+	}
+
+	if len(report.Categories) != 1 || report.Categories[0].NonCompliant != 1 {
+		t.Fatalf("Unexpected category summaries: %v", report.Categories)
+	}
+}
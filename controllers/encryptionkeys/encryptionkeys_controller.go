@@ -0,0 +1,196 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package encryptionkeys
+
+import (
+	"context"
+	"crypto/rand"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/client-go/kubernetes"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	clusterv1 "github.com/open-cluster-management/api/cluster/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+)
+
+const ControllerName string = "policy-encryption-keys"
+
+var log = logf.Log.WithName(ControllerName)
+
+// keySize is the size in bytes of the AES-256 keys
+const keySize = 32
+
+//+kubebuilder:rbac:groups=cluster.open-cluster-management.io,resources=managedclusters,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *EncryptionKeysReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(ControllerName).
+		For(&clusterv1.ManagedCluster{}, builder.WithPredicates(predicate.Funcs{
+			// Only the creation and deletion of the clusters matter
+			UpdateFunc: func(e event.UpdateEvent) bool { return false },
+		})).
+		// Generate the key again if it is deleted or modified
+		Watches(
+			&source.Kind{Type: &corev1.Secret{}},
+			handler.EnqueueRequestsFromMapFunc(secretMapper),
+			builder.OnlyMetadata,
+			builder.WithPredicates(predicate.NewPredicateFuncs(func(object client.Object) bool {
+				return object.GetName() == common.EncryptionKeySecret
+			}))).
+		Complete(r)
+}
+
+// secretMapper returns a reconcile request for the managed cluster of the encryption key Secret,
+// which has the name of the cluster namespace
+func secretMapper(object client.Object) []reconcile.Request {
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: object.GetNamespace()}}}
+}
+
+// blank assignment to verify that EncryptionKeysReconciler implements reconcile.Reconciler
+var _ reconcile.Reconciler = &EncryptionKeysReconciler{}
+
+// EncryptionKeysReconciler generates and rotates the AES key encrypting the values resolved from
+// Secrets by the hub templates of the policies replicated to each managed cluster
+type EncryptionKeysReconciler struct {
+	client.Client
+	// KubeClient reads and writes the key Secrets so that the Secrets are not all cached
+	KubeClient kubernetes.Interface
+	Scheme     *runtime.Scheme
+	// KeyRotation is how often the keys are rotated
+	KeyRotation time.Duration
+	Clock       clock.Clock
+}
+
+// Reconcile creates the policy-encryption-key Secret in the namespace of the managed cluster if it
+// doesn't exist, and rotates its key when it is older than KeyRotation. The previous key is kept in
+// the Secret so that the policies encrypted with it can still be decrypted until they are updated.
+func (r *EncryptionKeysReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	reqLogger := log.WithValues("ManagedCluster", request.Name)
+
+	cluster := &clusterv1.ManagedCluster{}
+
+	err := r.Get(ctx, types.NamespacedName{Name: request.Name}, cluster)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			reqLogger.Info("ManagedCluster not found, may have been deleted, doing nothing...")
+
+			return reconcile.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		return reconcile.Result{}, err
+	}
+
+	if cluster.GetDeletionTimestamp() != nil {
+		return reconcile.Result{}, nil
+	}
+
+	secrets := r.KubeClient.CoreV1().Secrets(cluster.GetName())
+
+	secret, err := secrets.Get(ctx, common.EncryptionKeySecret, metav1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return reconcile.Result{}, err
+		}
+
+		key, err := generateKey()
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      common.EncryptionKeySecret,
+				Namespace: cluster.GetName(),
+				Annotations: map[string]string{
+					common.LastRotatedAnnotation: r.Clock.Now().UTC().Format(time.RFC3339),
+				},
+			},
+			Data: map[string][]byte{common.EncryptionKeyField: key},
+		}
+
+		reqLogger.Info("Generating the encryption key...")
+
+		_, err = secrets.Create(ctx, secret, metav1.CreateOptions{})
+		if err != nil {
+			// The namespace of the cluster may not be created yet
+			reqLogger.Error(err, "Failed to create the encryption key...")
+
+			return reconcile.Result{}, err
+		}
+
+		return reconcile.Result{RequeueAfter: r.KeyRotation}, nil
+	}
+
+	lastRotated, err := time.Parse(time.RFC3339, secret.GetAnnotations()[common.LastRotatedAnnotation])
+	if err != nil {
+		// Rotate keys of an unknown age
+		lastRotated = time.Time{}
+	}
+
+	nextRotation := lastRotated.Add(r.KeyRotation)
+	if len(secret.Data[common.EncryptionKeyField]) == keySize && r.Clock.Now().Before(nextRotation) {
+		return reconcile.Result{RequeueAfter: nextRotation.Sub(r.Clock.Now())}, nil
+	}
+
+	key, err := generateKey()
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+
+	if len(secret.Data[common.EncryptionKeyField]) == keySize {
+		secret.Data[common.PreviousEncryptionKeyField] = secret.Data[common.EncryptionKeyField]
+	}
+
+	secret.Data[common.EncryptionKeyField] = key
+
+	annotations := secret.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+
+	annotations[common.LastRotatedAnnotation] = r.Clock.Now().UTC().Format(time.RFC3339)
+	secret.SetAnnotations(annotations)
+
+	reqLogger.Info("Rotating the encryption key...")
+
+	_, err = secrets.Update(ctx, secret, metav1.UpdateOptions{})
+	if err != nil {
+		reqLogger.Error(err, "Failed to rotate the encryption key...")
+
+		return reconcile.Result{}, err
+	}
+
+	return reconcile.Result{RequeueAfter: r.KeyRotation}, nil
+}
+
+func generateKey() ([]byte, error) {
+	key := make([]byte, keySize)
+
+	_, err := rand.Read(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
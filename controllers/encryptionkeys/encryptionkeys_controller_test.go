@@ -0,0 +1,128 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package encryptionkeys
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	clusterv1 "github.com/open-cluster-management/api/cluster/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+)
+
+func newTestReconciler(t *testing.T, now time.Time) (*EncryptionKeysReconciler, *kubefake.Clientset) {
+	t.Helper()
+
+	scheme := runtime.NewScheme()
+	if err := clusterv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to build the scheme: %v", err)
+	}
+
+	cluster := &clusterv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster1"}}
+	kubeClient := kubefake.NewSimpleClientset()
+
+	return &EncryptionKeysReconciler{
+		Client:      fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster).Build(),
+		KubeClient:  kubeClient,
+		Scheme:      scheme,
+		KeyRotation: 30 * 24 * time.Hour,
+		Clock:       clock.NewFakeClock(now),
+	}, kubeClient
+}
+
+func reconcileCluster(t *testing.T, r *EncryptionKeysReconciler) (ctrl.Result, *corev1.Secret) {
+	t.Helper()
+
+	result, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "cluster1"}})
+	if err != nil {
+		t.Fatalf("Reconcile returned an error: %v", err)
+	}
+
+	secret, err := r.KubeClient.CoreV1().Secrets("cluster1").Get(
+		context.TODO(), common.EncryptionKeySecret, metav1.GetOptions{},
+	)
+	if err != nil {
+		t.Fatalf("failed to get the encryption key: %v", err)
+	}
+
+	return result, secret
+}
+
+func TestReconcileGeneratesKey(t *testing.T) {
+	now := time.Date(2021, 8, 1, 0, 0, 0, 0, time.UTC)
+	r, _ := newTestReconciler(t, now)
+
+	result, secret := reconcileCluster(t, r)
+
+	if len(secret.Data[common.EncryptionKeyField]) != keySize {
+		t.Fatalf("expected a %d byte key, got %d bytes", keySize, len(secret.Data[common.EncryptionKeyField]))
+	}
+
+	if secret.GetAnnotations()[common.LastRotatedAnnotation] != now.Format(time.RFC3339) {
+		t.Fatalf("expected the last-rotated annotation, got %v", secret.GetAnnotations())
+	}
+
+	if result.RequeueAfter != r.KeyRotation {
+		t.Fatalf("expected a requeue at the next rotation, got %v", result.RequeueAfter)
+	}
+
+	// The key is kept until the next rotation
+	r.Clock = clock.NewFakeClock(now.Add(24 * time.Hour))
+
+	result, unchanged := reconcileCluster(t, r)
+
+	if !bytes.Equal(unchanged.Data[common.EncryptionKeyField], secret.Data[common.EncryptionKeyField]) {
+		t.Fatal("expected the key not to be rotated before the rotation interval")
+	}
+
+	if result.RequeueAfter != r.KeyRotation-24*time.Hour {
+		t.Fatalf("expected a requeue at the next rotation, got %v", result.RequeueAfter)
+	}
+}
+
+func TestReconcileRotatesKey(t *testing.T) {
+	now := time.Date(2021, 8, 1, 0, 0, 0, 0, time.UTC)
+	r, _ := newTestReconciler(t, now)
+
+	_, secret := reconcileCluster(t, r)
+
+	r.Clock = clock.NewFakeClock(now.Add(r.KeyRotation))
+
+	_, rotated := reconcileCluster(t, r)
+
+	if bytes.Equal(rotated.Data[common.EncryptionKeyField], secret.Data[common.EncryptionKeyField]) {
+		t.Fatal("expected the key to be rotated")
+	}
+
+	if !bytes.Equal(rotated.Data[common.PreviousEncryptionKeyField], secret.Data[common.EncryptionKeyField]) {
+		t.Fatal("expected the previous key to be kept")
+	}
+
+	if rotated.GetAnnotations()[common.LastRotatedAnnotation] != now.Add(r.KeyRotation).Format(time.RFC3339) {
+		t.Fatalf("expected the last-rotated annotation to be updated, got %v", rotated.GetAnnotations())
+	}
+}
+
+func TestReconcileDeletedCluster(t *testing.T) {
+	r, kubeClient := newTestReconciler(t, time.Now())
+
+	_, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "cluster2"}})
+	if err != nil {
+		t.Fatalf("Reconcile returned an error: %v", err)
+	}
+
+	if len(kubeClient.Actions()) != 0 {
+		t.Fatalf("expected no key to be generated for a deleted cluster, got %v", kubeClient.Actions())
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package placementapis
+
+import (
+	"context"
+	"sync"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensionsclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	apiextensionsinformers "k8s.io/apiextensions-apiserver/pkg/client/informers/externalversions"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/tools/cache"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+)
+
+const ControllerName string = "placement-api-watcher"
+
+var log = logf.Log.WithName(ControllerName)
+
+// placementCRDs are the CRDs that must be established for their placement API to be installed
+var placementCRDs = map[string]common.PlacementAPI{
+	"placementrules.apps.open-cluster-management.io":        common.PlacementRuleAPI,
+	"placements.cluster.open-cluster-management.io":         common.ClusterPlacementAPI,
+	"placementdecisions.cluster.open-cluster-management.io": common.ClusterPlacementAPI,
+}
+
+//+kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get;list;watch
+
+// CRDWatcher records a placement API as installed once its CRDs are established, and as not
+// installed once one of them is deleted, so that the controllers watch the placement APIs
+// installed after the propagator without a restart. Since the watches can't be removed from the
+// controllers, the watches of a deleted placement API resume when it is installed again.
+type CRDWatcher struct {
+	Client       apiextensionsclientset.Interface
+	Availability *common.PlacementAvailability
+
+	lock      sync.Mutex
+	informers map[string]cache.SharedIndexInformer
+	synced    bool
+}
+
+// Start watches the placement CRDs until the context is done
+func (w *CRDWatcher) Start(ctx context.Context) error {
+	w.lock.Lock()
+	w.informers = make(map[string]cache.SharedIndexInformer, len(placementCRDs))
+	w.lock.Unlock()
+
+	synced := []cache.InformerSynced{}
+
+	for name := range placementCRDs {
+		name := name
+
+		// Only the placement CRDs are watched, rather than caching all the CRDs
+		factory := apiextensionsinformers.NewSharedInformerFactoryWithOptions(
+			w.Client, 0,
+			apiextensionsinformers.WithTweakListOptions(func(options *metav1.ListOptions) {
+				options.FieldSelector = fields.OneTermEqualSelector("metadata.name", name).String()
+			}),
+		)
+
+		informer := factory.Apiextensions().V1().CustomResourceDefinitions().Informer()
+		informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc:    func(interface{}) { w.update(placementCRDs[name]) },
+			UpdateFunc: func(interface{}, interface{}) { w.update(placementCRDs[name]) },
+			DeleteFunc: func(interface{}) { w.update(placementCRDs[name]) },
+		})
+
+		w.lock.Lock()
+		w.informers[name] = informer
+		w.lock.Unlock()
+
+		synced = append(synced, informer.HasSynced)
+
+		factory.Start(ctx.Done())
+	}
+
+	if !cache.WaitForCacheSync(ctx.Done(), synced...) {
+		return nil
+	}
+
+	// The APIs are only updated once all the CRDs are listed, so that an API with several CRDs
+	// isn't briefly recorded as not installed
+	w.lock.Lock()
+	w.synced = true
+	w.lock.Unlock()
+
+	for _, api := range common.PlacementAPIs {
+		w.update(api)
+	}
+
+	<-ctx.Done()
+
+	return nil
+}
+
+// NeedLeaderElection makes every replica watch the placement CRDs, since all of them watch the
+// placement APIs
+func (w *CRDWatcher) NeedLeaderElection() bool {
+	return false
+}
+
+// update records whether all the CRDs of the placement API are established
+func (w *CRDWatcher) update(api common.PlacementAPI) {
+	w.lock.Lock()
+
+	if !w.synced {
+		w.lock.Unlock()
+
+		return
+	}
+
+	installed := true
+
+	for name, crdAPI := range placementCRDs {
+		if crdAPI != api {
+			continue
+		}
+
+		obj, exists, err := w.informers[name].GetStore().GetByKey(name)
+		if err != nil || !exists {
+			installed = false
+
+			continue
+		}
+
+		crd, ok := obj.(*apiextensionsv1.CustomResourceDefinition)
+		if !ok || !established(crd) {
+			installed = false
+		}
+	}
+
+	w.lock.Unlock()
+
+	if installed != w.Availability.Available(api) {
+		log.Info("The installation of the placement API changed...", "API", api, "Installed", installed)
+	}
+
+	w.Availability.SetAvailable(api, installed)
+}
+
+// established returns whether the CRD is established and not being deleted
+func established(crd *apiextensionsv1.CustomResourceDefinition) bool {
+	if crd.GetDeletionTimestamp() != nil {
+		return false
+	}
+
+	for _, condition := range crd.Status.Conditions {
+		if condition.Type == apiextensionsv1.Established {
+			return condition.Status == apiextensionsv1.ConditionTrue
+		}
+	}
+
+	return false
+}
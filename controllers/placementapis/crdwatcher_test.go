@@ -0,0 +1,104 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package placementapis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+)
+
+func newCRD(name string, established bool) *apiextensionsv1.CustomResourceDefinition {
+	status := apiextensionsv1.ConditionFalse
+	if established {
+		status = apiextensionsv1.ConditionTrue
+	}
+
+	return &apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: apiextensionsv1.CustomResourceDefinitionStatus{
+			Conditions: []apiextensionsv1.CustomResourceDefinitionCondition{
+				{Type: apiextensionsv1.Established, Status: status},
+			},
+		},
+	}
+}
+
+func waitForAvailability(
+	t *testing.T, availability *common.PlacementAvailability, api common.PlacementAPI, expected bool,
+) {
+	t.Helper()
+
+	for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(10 * time.Millisecond) {
+		if availability.Available(api) == expected {
+			return
+		}
+	}
+
+	t.Fatalf("expected the availability of the %s API to be %v", api, expected)
+}
+
+func TestCRDWatcher(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		newCRD("placementrules.apps.open-cluster-management.io", true),
+		newCRD("placements.cluster.open-cluster-management.io", true),
+	)
+
+	availability := &common.PlacementAvailability{}
+	installed := make(chan common.PlacementAPI, 2)
+
+	availability.OnAvailable(func(api common.PlacementAPI) {
+		installed <- api
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		_ = (&CRDWatcher{Client: client, Availability: availability}).Start(ctx)
+	}()
+
+	// The Placement API isn't installed until its PlacementDecision CRD is established
+	waitForAvailability(t, availability, common.ClusterPlacementAPI, false)
+	waitForAvailability(t, availability, common.PlacementRuleAPI, true)
+
+	crds := client.ApiextensionsV1().CustomResourceDefinitions()
+	decisionsCRD := "placementdecisions.cluster.open-cluster-management.io"
+
+	_, err := crds.Create(ctx, newCRD(decisionsCRD, false), metav1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("failed to create the CRD: %v", err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	waitForAvailability(t, availability, common.ClusterPlacementAPI, false)
+
+	_, err = crds.Update(ctx, newCRD(decisionsCRD, true), metav1.UpdateOptions{})
+	if err != nil {
+		t.Fatalf("failed to update the CRD: %v", err)
+	}
+
+	waitForAvailability(t, availability, common.ClusterPlacementAPI, true)
+
+	select {
+	case api := <-installed:
+		if api != common.ClusterPlacementAPI {
+			t.Fatalf("expected the Placement API to be reported as installed, got %s", api)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the Placement API to be reported as installed")
+	}
+
+	err = crds.Delete(ctx, "placementrules.apps.open-cluster-management.io", metav1.DeleteOptions{})
+	if err != nil {
+		t.Fatalf("failed to delete the CRD: %v", err)
+	}
+
+	waitForAvailability(t, availability, common.PlacementRuleAPI, false)
+}
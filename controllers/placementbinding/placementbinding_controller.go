@@ -0,0 +1,318 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package placementbinding
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	clusterv1alpha1 "github.com/open-cluster-management/api/cluster/v1alpha1"
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+const ControllerName string = "placement-binding-status"
+
+var log = logf.Log.WithName(ControllerName)
+
+//+kubebuilder:rbac:groups=policy.open-cluster-management.io,resources=placementbindings,verbs=get;list;watch
+//+kubebuilder:rbac:groups=policy.open-cluster-management.io,resources=placementbindings/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=policy.open-cluster-management.io,resources=policies,verbs=get;list;watch
+//+kubebuilder:rbac:groups=cluster.open-cluster-management.io,resources=placements;placementdecisions,verbs=get;list;watch
+//+kubebuilder:rbac:groups=apps.open-cluster-management.io,resources=placementrules,verbs=get;list;watch
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *PlacementBindingReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	c, err := ctrl.NewControllerManagedBy(mgr).
+		Named(ControllerName).
+		For(
+			&policiesv1.PlacementBinding{},
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(
+			&source.Kind{Type: &policiesv1.Policy{}},
+			handler.EnqueueRequestsFromMapFunc(policyMapper(mgr.GetClient())),
+			builder.WithPredicates(policyPredicateFuncs)).
+		Build(r)
+	if err != nil {
+		return err
+	}
+
+	// The placement APIs are only watched once they are installed
+	return r.PlacementAvailability.WatchWhenAvailable(c, map[common.PlacementAPI]common.PlacementWatch{
+		common.PlacementRuleAPI: func(c controller.Controller) error {
+			return c.Watch(
+				&source.Kind{Type: &appsv1.PlacementRule{}},
+				handler.EnqueueRequestsFromMapFunc(placementMapper(mgr.GetClient())))
+		},
+		common.ClusterPlacementAPI: func(c controller.Controller) error {
+			err := c.Watch(
+				&source.Kind{Type: &clusterv1alpha1.Placement{}},
+				handler.EnqueueRequestsFromMapFunc(placementMapper(mgr.GetClient())),
+				existencePredicateFuncs)
+			if err != nil {
+				return err
+			}
+
+			return c.Watch(
+				&source.Kind{Type: &clusterv1alpha1.PlacementDecision{}},
+				handler.EnqueueRequestsFromMapFunc(placementDecisionMapper(mgr.GetClient())))
+		},
+	}, log)
+}
+
+// blank assignment to verify that PlacementBindingReconciler implements reconcile.Reconciler
+var _ reconcile.Reconciler = &PlacementBindingReconciler{}
+
+// PlacementBindingReconciler reconciles the status of a PlacementBinding object
+type PlacementBindingReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	// PlacementAvailability records which of the placement APIs are installed. When unset, all of
+	// them must be installed.
+	PlacementAvailability *common.PlacementAvailability
+}
+
+// Reconcile validates that the PlacementBinding's placementRef resolves and that its subjects
+// exist, and records the result in the BindingValid status condition along with the policies it
+// binds and the number of placement decisions.
+func (r *PlacementBindingReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+	reqLogger.Info("Reconciling PlacementBinding status...")
+
+	pb := &policiesv1.PlacementBinding{}
+	err := r.Get(ctx, request.NamespacedName, pb)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			reqLogger.Info("PlacementBinding not found, may have been deleted, doing nothing...")
+			return reconcile.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		return reconcile.Result{}, err
+	}
+
+	decisions, placementErr, err := r.resolvePlacement(ctx, pb)
+	if err != nil {
+		reqLogger.Error(err, "Failed to resolve the placementRef...")
+		return reconcile.Result{}, err
+	}
+
+	bound, missing, unsupported, err := r.resolveSubjects(ctx, pb)
+	if err != nil {
+		reqLogger.Error(err, "Failed to resolve the subjects...")
+		return reconcile.Result{}, err
+	}
+
+	original := pb.DeepCopy()
+	condition := bindingValidCondition(pb, placementErr, missing, unsupported)
+	meta.SetStatusCondition(&pb.Status.Conditions, condition)
+	pb.Status.BoundObjects = bound
+	pb.Status.DecisionCount = decisions
+
+	err = r.Status().Patch(ctx, pb, client.MergeFrom(original))
+	if err != nil {
+		reqLogger.Error(err, "Failed to update the PlacementBinding status...")
+		return reconcile.Result{}, err
+	}
+
+	reqLogger.Info("PlacementBinding status reconciled", "BindingValid", condition.Status, "Reason", condition.Reason)
+	return reconcile.Result{}, nil
+}
+
+// placementError describes why a placementRef does not resolve
+type placementError struct {
+	reason  string
+	message string
+}
+
+// resolvePlacement returns the number of cluster decisions of the PlacementBinding's
+// placementRef. If the placementRef does not resolve, a placementError describing why is
+// returned instead. An error is only returned when the placementRef could not be checked.
+func (r *PlacementBindingReconciler) resolvePlacement(
+	ctx context.Context, pb *policiesv1.PlacementBinding,
+) (int, *placementError, error) {
+	api, ok := common.PlacementRefAPI(pb.PlacementRef)
+	if !ok {
+		return 0, &placementError{
+			reason: "InvalidPlacementRef",
+			message: fmt.Sprintf(
+				"The placementRef kind %s in the API group %s is not supported",
+				pb.PlacementRef.Kind, pb.PlacementRef.APIGroup,
+			),
+		}, nil
+	}
+
+	key := types.NamespacedName{Namespace: pb.GetNamespace(), Name: pb.PlacementRef.Name}
+
+	var decisions int
+	var err error
+
+	if api == common.PlacementRuleAPI {
+		decisions, err = r.placementRuleDecisions(ctx, key)
+	} else {
+		decisions, err = r.placementDecisions(ctx, key)
+	}
+
+	switch {
+	case errors.IsNotFound(err):
+		return 0, &placementError{
+			reason:  "PlacementNotFound",
+			message: fmt.Sprintf("The %s %s was not found", pb.PlacementRef.Kind, pb.PlacementRef.Name),
+		}, nil
+	case meta.IsNoMatchError(err):
+		r.PlacementAvailability.SetAvailable(api, false)
+
+		return 0, &placementError{
+			reason:  "PlacementAPINotInstalled",
+			message: fmt.Sprintf("The %s API is not installed on the hub", pb.PlacementRef.Kind),
+		}, nil
+	case err != nil:
+		return 0, nil, err
+	}
+
+	r.PlacementAvailability.SetAvailable(api, true)
+
+	return decisions, nil, nil
+}
+
+// placementRuleDecisions returns the number of cluster decisions of the PlacementRule
+func (r *PlacementBindingReconciler) placementRuleDecisions(
+	ctx context.Context, key types.NamespacedName,
+) (int, error) {
+	plr := &appsv1.PlacementRule{}
+
+	err := r.Get(ctx, key, plr)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(plr.Status.Decisions), nil
+}
+
+// placementDecisions returns the number of cluster decisions of the PlacementDecisions of the
+// Placement
+func (r *PlacementBindingReconciler) placementDecisions(ctx context.Context, key types.NamespacedName) (int, error) {
+	err := r.Get(ctx, key, &clusterv1alpha1.Placement{})
+	if err != nil {
+		return 0, err
+	}
+
+	pldList := &clusterv1alpha1.PlacementDecisionList{}
+	lopts := &client.ListOptions{Namespace: key.Namespace}
+	opts := client.MatchingLabels{"cluster.open-cluster-management.io/placement": key.Name}
+	opts.ApplyToList(lopts)
+
+	err = r.List(ctx, pldList, lopts)
+	if err != nil {
+		return 0, err
+	}
+
+	decisions := 0
+	for _, pld := range pldList.Items {
+		decisions += len(pld.Status.Decisions)
+	}
+
+	return decisions, nil
+}
+
+// resolveSubjects sorts the PlacementBinding's subjects into the ones that are bound, the policies
+// that don't exist, and the subjects that are not supported. A subject with a label selector is
+// expanded into the policies it currently selects, which may be none.
+func (r *PlacementBindingReconciler) resolveSubjects(
+	ctx context.Context, pb *policiesv1.PlacementBinding,
+) (bound []policiesv1.Subject, missing []string, unsupported []string, err error) {
+	for _, subject := range pb.Subjects {
+		if !common.IsPolicySubject(subject) {
+			unsupported = append(unsupported, subject.Kind+"/"+subject.Name)
+
+			continue
+		}
+
+		if subject.Name == "" {
+			if _, selectorErr := metav1.LabelSelectorAsSelector(subject.Selector); selectorErr != nil {
+				unsupported = append(unsupported, subject.Kind+" with the invalid selector: "+selectorErr.Error())
+
+				continue
+			}
+
+			var policyNames []string
+
+			policyNames, err = common.PoliciesForSubject(ctx, r.Client, pb.GetNamespace(), subject)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+
+			for _, policyName := range policyNames {
+				bound = append(bound, policiesv1.Subject{
+					APIGroup: subject.APIGroup, Kind: subject.Kind, Name: policyName,
+				})
+			}
+
+			continue
+		}
+
+		err = r.Get(ctx, types.NamespacedName{Namespace: pb.GetNamespace(), Name: subject.Name}, &policiesv1.Policy{})
+		if err != nil {
+			if !errors.IsNotFound(err) {
+				return nil, nil, nil, err
+			}
+
+			missing = append(missing, subject.Name)
+
+			continue
+		}
+
+		bound = append(bound, subject)
+	}
+
+	return bound, missing, unsupported, nil
+}
+
+// bindingValidCondition returns the BindingValid condition based on the resolved placementRef
+// and subjects of the PlacementBinding.
+func bindingValidCondition(
+	pb *policiesv1.PlacementBinding, placementErr *placementError, missing []string, unsupported []string,
+) metav1.Condition {
+	condition := metav1.Condition{
+		Type:               policiesv1.BindingValid,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: pb.GetGeneration(),
+	}
+
+	switch {
+	case placementErr != nil:
+		condition.Reason = placementErr.reason
+		condition.Message = placementErr.message
+	case len(pb.Subjects) == 0:
+		condition.Reason = "NoSubjects"
+		condition.Message = "The PlacementBinding does not have any subjects"
+	case len(unsupported) > 0:
+		condition.Reason = "UnsupportedSubject"
+		condition.Message = "The following subjects are not supported: " + strings.Join(unsupported, ", ")
+	case len(missing) > 0:
+		condition.Reason = "SubjectNotFound"
+		condition.Message = "The following policies were not found: " + strings.Join(missing, ", ")
+	default:
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "Valid"
+		condition.Message = "The placementRef resolves and all subjects exist"
+	}
+
+	return condition
+}
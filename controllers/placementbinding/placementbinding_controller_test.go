@@ -0,0 +1,178 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package placementbinding
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	clusterv1alpha1 "github.com/open-cluster-management/api/cluster/v1alpha1"
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+func TestReconcileStatus(t *testing.T) {
+	scheme := runtime.NewScheme()
+	for _, addToScheme := range []func(*runtime.Scheme) error{
+		policiesv1.AddToScheme, appsv1.AddToScheme, clusterv1alpha1.AddToScheme,
+	} {
+		if err := addToScheme(scheme); err != nil {
+			t.Fatalf("failed to build the scheme: %v", err)
+		}
+	}
+
+	objects := []client.Object{
+		&appsv1.PlacementRule{
+			ObjectMeta: metav1.ObjectMeta{Name: "plr", Namespace: "policies"},
+			Status: appsv1.PlacementRuleStatus{
+				Decisions: []appsv1.PlacementDecision{{ClusterName: "c1"}, {ClusterName: "c2"}},
+			},
+		},
+		&clusterv1alpha1.Placement{ObjectMeta: metav1.ObjectMeta{Name: "placement", Namespace: "policies"}},
+		&clusterv1alpha1.PlacementDecision{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "placement-decision-1",
+				Namespace: "policies",
+				Labels:    map[string]string{"cluster.open-cluster-management.io/placement": "placement"},
+			},
+			Status: clusterv1alpha1.PlacementDecisionStatus{
+				Decisions: []clusterv1alpha1.ClusterDecision{{ClusterName: "c1"}},
+			},
+		},
+		&policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{Name: "policy", Namespace: "policies"}},
+	}
+
+	plrRef := policiesv1.Subject{APIGroup: appsv1.SchemeGroupVersion.Group, Kind: "PlacementRule", Name: "plr"}
+	placementRef := policiesv1.Subject{
+		APIGroup: clusterv1alpha1.SchemeGroupVersion.Group, Kind: "Placement", Name: "placement",
+	}
+	policySubject := policiesv1.Subject{APIGroup: policiesv1.SchemeGroupVersion.Group, Kind: policiesv1.Kind}
+
+	tests := []struct {
+		name           string
+		placementRef   policiesv1.Subject
+		subjectNames   []string
+		expectedStatus metav1.ConditionStatus
+		expectedReason string
+		expectedBound  int
+		expectedCount  int
+	}{
+		{"valid PlacementRule", plrRef, []string{"policy"}, metav1.ConditionTrue, "Valid", 1, 2},
+		{"valid Placement", placementRef, []string{"policy"}, metav1.ConditionTrue, "Valid", 1, 1},
+		{"invalid kind", policiesv1.Subject{Kind: "Other", Name: "plr"}, []string{"policy"},
+			metav1.ConditionFalse, "InvalidPlacementRef", 1, 0},
+		{"missing placement", policiesv1.Subject{
+			APIGroup: appsv1.SchemeGroupVersion.Group, Kind: "PlacementRule", Name: "missing",
+		}, []string{"policy"}, metav1.ConditionFalse, "PlacementNotFound", 1, 0},
+		{"no subjects", plrRef, nil, metav1.ConditionFalse, "NoSubjects", 0, 2},
+		{"missing policy", plrRef, []string{"policy", "missing"}, metav1.ConditionFalse, "SubjectNotFound", 1, 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pb := &policiesv1.PlacementBinding{
+				ObjectMeta:   metav1.ObjectMeta{Name: "pb", Namespace: "policies"},
+				PlacementRef: test.placementRef,
+			}
+			for _, name := range test.subjectNames {
+				subject := policySubject
+				subject.Name = name
+				pb.Subjects = append(pb.Subjects, subject)
+			}
+
+			r := &PlacementBindingReconciler{
+				Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(append(objects, pb)...).Build(),
+				Scheme: scheme,
+			}
+			key := types.NamespacedName{Namespace: "policies", Name: "pb"}
+
+			_, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: key})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if err := r.Get(context.TODO(), key, pb); err != nil {
+				t.Fatalf("Failed to get the PlacementBinding: %v", err)
+			}
+
+			condition := meta.FindStatusCondition(pb.Status.Conditions, policiesv1.BindingValid)
+			if condition == nil {
+				t.Fatal("Expected the BindingValid condition to be set")
+			}
+
+			if condition.Status != test.expectedStatus || condition.Reason != test.expectedReason {
+				t.Fatalf(
+					"Expected status=%s reason=%s, got status=%s reason=%s",
+					test.expectedStatus, test.expectedReason, condition.Status, condition.Reason,
+				)
+			}
+
+			if len(pb.Status.BoundObjects) != test.expectedBound || pb.Status.DecisionCount != test.expectedCount {
+				t.Fatalf(
+					"Expected %d bound objects and %d decisions, got %d and %d",
+					test.expectedBound, test.expectedCount, len(pb.Status.BoundObjects), pb.Status.DecisionCount,
+				)
+			}
+		})
+	}
+}
+
+// noPlacementClient fails like the cached client when the Placement API isn't installed
+type noPlacementClient struct {
+	client.Client
+}
+
+func (c noPlacementClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	if _, ok := obj.(*clusterv1alpha1.Placement); ok {
+		return &meta.NoKindMatchError{GroupKind: clusterv1alpha1.GroupVersion.WithKind("Placement").GroupKind()}
+	}
+
+	return c.Client.Get(ctx, key, obj)
+}
+
+func TestReconcileStatusAPINotInstalled(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := policiesv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to build the scheme: %v", err)
+	}
+
+	pb := &policiesv1.PlacementBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "pb", Namespace: "policies"},
+		PlacementRef: policiesv1.Subject{
+			APIGroup: clusterv1alpha1.SchemeGroupVersion.Group, Kind: "Placement", Name: "placement",
+		},
+	}
+
+	r := &PlacementBindingReconciler{
+		Client:                noPlacementClient{fake.NewClientBuilder().WithScheme(scheme).WithObjects(pb).Build()},
+		Scheme:                scheme,
+		PlacementAvailability: &common.PlacementAvailability{},
+	}
+	key := types.NamespacedName{Namespace: "policies", Name: "pb"}
+
+	if _, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if err := r.Get(context.TODO(), key, pb); err != nil {
+		t.Fatalf("Failed to get the PlacementBinding: %v", err)
+	}
+
+	condition := meta.FindStatusCondition(pb.Status.Conditions, policiesv1.BindingValid)
+	if condition == nil || condition.Reason != "PlacementAPINotInstalled" {
+		t.Fatalf("Expected the PlacementAPINotInstalled reason, got %v", condition)
+	}
+
+	if r.PlacementAvailability.Available(common.ClusterPlacementAPI) {
+		t.Fatal("Expected the Placement API to be recorded as not installed")
+	}
+}
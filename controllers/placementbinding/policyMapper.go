@@ -0,0 +1,61 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package placementbinding
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+)
+
+// existencePredicateFuncs only lets through the events that change whether an object exists,
+// since that is all the binding validation depends on
+var existencePredicateFuncs = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		return false
+	},
+}
+
+// policyPredicateFuncs also lets through the policy label changes, since they change which
+// policies the subjects with a label selector bind
+var policyPredicateFuncs = predicate.Or(existencePredicateFuncs, predicate.LabelChangedPredicate{})
+
+// policyMapper returns a reconcile request for every PlacementBinding in the policy's namespace
+// that has the policy as a subject, either by name or by label selector. Replicated policies are
+// ignored.
+func policyMapper(c client.Client) handler.MapFunc {
+	return func(object client.Object) []reconcile.Request {
+		if _, ok := object.GetLabels()[common.RootPolicyLabel]; ok {
+			return nil
+		}
+
+		pbList := &policiesv1.PlacementBindingList{}
+		err := c.List(context.TODO(), pbList, &client.ListOptions{Namespace: object.GetNamespace()})
+		if err != nil {
+			return nil
+		}
+
+		var result []reconcile.Request
+		for _, pb := range pbList.Items {
+			for _, subject := range pb.Subjects {
+				if common.SubjectMatchesPolicy(subject, object) {
+					result = append(result, reconcile.Request{NamespacedName: types.NamespacedName{
+						Name:      pb.GetName(),
+						Namespace: pb.GetNamespace(),
+					}})
+
+					break
+				}
+			}
+		}
+		return result
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package placementmigration
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+)
+
+// placementMapper returns a reconcile request for every PlacementBinding in the namespace of the
+// PlacementRule or Placement that references an object with the same name. Since the generated
+// Placement has the same name as its PlacementRule, this covers both sides of a migration.
+func placementMapper(c client.Client) handler.MapFunc {
+	return func(object client.Object) []reconcile.Request {
+		return placementBindingRequests(c, object.GetNamespace(), object.GetName())
+	}
+}
+
+// placementDecisionMapper returns a reconcile request for every PlacementBinding in the namespace
+// of the PlacementDecision that references an object with the name of its Placement
+func placementDecisionMapper(c client.Client) handler.MapFunc {
+	return func(object client.Object) []reconcile.Request {
+		placementName := object.GetLabels()["cluster.open-cluster-management.io/placement"]
+		if placementName == "" {
+			return nil
+		}
+
+		return placementBindingRequests(c, object.GetNamespace(), placementName)
+	}
+}
+
+func placementBindingRequests(c client.Client, namespace string, placementName string) []reconcile.Request {
+	pbList := &policiesv1.PlacementBindingList{}
+	lopts := &client.ListOptions{Namespace: namespace}
+	opts := client.MatchingFields{"placementRef.name": placementName}
+	opts.ApplyToList(lopts)
+	err := c.List(context.TODO(), pbList, lopts)
+	if err != nil {
+		return nil
+	}
+
+	result := make([]reconcile.Request, 0, len(pbList.Items))
+	for _, pb := range pbList.Items {
+		result = append(result, reconcile.Request{NamespacedName: types.NamespacedName{
+			Name:      pb.GetName(),
+			Namespace: pb.GetNamespace(),
+		}})
+	}
+	return result
+}
@@ -0,0 +1,338 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package placementmigration
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	clusterv1alpha1 "github.com/open-cluster-management/api/cluster/v1alpha1"
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+const ControllerName string = "placement-migration"
+
+var log = logf.Log.WithName(ControllerName)
+
+const (
+	// The annotation on the generated Placement and the migrated PlacementBinding with the name of
+	// the PlacementRule they replace
+	migratedFromAnnotation = "policy.open-cluster-management.io/migrated-from-placementrule"
+	// The annotation on the PlacementBinding listing why it could not be migrated
+	migrationReportAnnotation = "policy.open-cluster-management.io/placement-migration-report"
+)
+
+//+kubebuilder:rbac:groups=policy.open-cluster-management.io,resources=placementbindings,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=apps.open-cluster-management.io,resources=placementrules,verbs=get;list;watch
+//+kubebuilder:rbac:groups=cluster.open-cluster-management.io,resources=placements,verbs=get;list;watch;create;update
+//+kubebuilder:rbac:groups=cluster.open-cluster-management.io,resources=placementdecisions,verbs=get;list;watch
+//+kubebuilder:rbac:groups=cluster.open-cluster-management.io,resources=managedclustersetbindings,verbs=get;list;watch;create
+//+kubebuilder:rbac:groups=cluster.open-cluster-management.io,resources=managedclustersets/bind,verbs=create
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *PlacementMigrationReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(ControllerName).
+		For(
+			&policiesv1.PlacementBinding{},
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(
+			&source.Kind{Type: &appsv1.PlacementRule{}},
+			handler.EnqueueRequestsFromMapFunc(placementMapper(mgr.GetClient()))).
+		Watches(
+			&source.Kind{Type: &clusterv1alpha1.Placement{}},
+			handler.EnqueueRequestsFromMapFunc(placementMapper(mgr.GetClient()))).
+		Watches(
+			&source.Kind{Type: &clusterv1alpha1.PlacementDecision{}},
+			handler.EnqueueRequestsFromMapFunc(placementDecisionMapper(mgr.GetClient()))).
+		Complete(r)
+}
+
+// blank assignment to verify that PlacementMigrationReconciler implements reconcile.Reconciler
+var _ reconcile.Reconciler = &PlacementMigrationReconciler{}
+
+// PlacementMigrationReconciler migrates PlacementBindings from a PlacementRule to an equivalent
+// Placement
+type PlacementMigrationReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	// ClusterSets are the ManagedClusterSets the generated Placements select from. A
+	// ManagedClusterSetBinding is created for each in the namespace of the Placement.
+	ClusterSets []string
+}
+
+// Reconcile generates a Placement equivalent to the PlacementRule of the PlacementBinding and,
+// once the Placement selects the same clusters as the PlacementRule, switches the
+// PlacementBinding's placementRef to it in a single update. Anything preventing the migration is
+// listed in the policy.open-cluster-management.io/placement-migration-report annotation of the
+// PlacementBinding.
+func (r *PlacementMigrationReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+
+	pb := &policiesv1.PlacementBinding{}
+	err := r.Get(ctx, request.NamespacedName, pb)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			reqLogger.Info("PlacementBinding not found, may have been deleted, doing nothing...")
+			return reconcile.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		return reconcile.Result{}, err
+	}
+
+	if pb.PlacementRef.APIGroup != appsv1.SchemeGroupVersion.Group || pb.PlacementRef.Kind != "PlacementRule" {
+		return reconcile.Result{}, nil
+	}
+
+	reqLogger.Info("Migrating the PlacementBinding to a Placement...", "PlacementRule", pb.PlacementRef.Name)
+
+	plr := &appsv1.PlacementRule{}
+	err = r.Get(ctx, types.NamespacedName{Namespace: pb.GetNamespace(), Name: pb.PlacementRef.Name}, plr)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, r.report(ctx, pb, []string{
+				fmt.Sprintf("placementRef: the PlacementRule %s was not found", pb.PlacementRef.Name),
+			})
+		}
+
+		return reconcile.Result{}, err
+	}
+
+	spec, untranslatable := translatePlacementRule(plr, r.ClusterSets)
+	if len(untranslatable) > 0 {
+		return reconcile.Result{}, r.report(ctx, pb, untranslatable)
+	}
+
+	for _, clusterSet := range r.ClusterSets {
+		err = r.ensureClusterSetBinding(ctx, pb.GetNamespace(), clusterSet)
+		if err != nil {
+			reqLogger.Error(err, "Failed to bind the ManagedClusterSet...", "ManagedClusterSet", clusterSet)
+			return reconcile.Result{}, err
+		}
+	}
+
+	placement, problem, err := r.ensurePlacement(ctx, plr, spec)
+	if err != nil {
+		reqLogger.Error(err, "Failed to create or update the Placement...")
+		return reconcile.Result{}, err
+	}
+
+	if problem == "" {
+		problem, err = r.compareDecisions(ctx, plr, placement)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	if problem != "" {
+		return reconcile.Result{}, r.report(ctx, pb, []string{problem})
+	}
+
+	// Switching the placementRef along with the annotations in a single update makes the migration
+	// atomic. If the PlacementBinding changed in the meantime, the update fails with a conflict and
+	// the request is retried.
+	annotations := pb.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+
+	delete(annotations, migrationReportAnnotation)
+	annotations[migratedFromAnnotation] = plr.GetName()
+	pb.SetAnnotations(annotations)
+	pb.PlacementRef = policiesv1.Subject{
+		APIGroup: clusterv1alpha1.SchemeGroupVersion.Group,
+		Kind:     "Placement",
+		Name:     placement.GetName(),
+	}
+
+	err = r.Update(ctx, pb)
+	if err != nil {
+		reqLogger.Error(err, "Failed to switch the placementRef to the Placement...")
+		return reconcile.Result{}, err
+	}
+
+	r.Recorder.Event(pb, "Normal", "PlacementMigration",
+		fmt.Sprintf("The PlacementBinding was migrated from the PlacementRule %s to the Placement %s",
+			plr.GetName(), placement.GetName()))
+	reqLogger.Info("PlacementBinding migrated to a Placement", "Placement", placement.GetName())
+
+	return reconcile.Result{}, nil
+}
+
+// report sets the migration report annotation on the PlacementBinding and records a warning event
+// when the report changes
+func (r *PlacementMigrationReconciler) report(
+	ctx context.Context, pb *policiesv1.PlacementBinding, problems []string,
+) error {
+	report := strings.Join(problems, "\n")
+	if pb.GetAnnotations()[migrationReportAnnotation] == report {
+		return nil
+	}
+
+	log.Info("The PlacementBinding can't be migrated to a Placement...",
+		"Namespace", pb.GetNamespace(), "Name", pb.GetName(), "Report", report)
+
+	original := pb.DeepCopy()
+	annotations := pb.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+
+	annotations[migrationReportAnnotation] = report
+	pb.SetAnnotations(annotations)
+
+	err := r.Patch(ctx, pb, client.MergeFrom(original))
+	if err != nil {
+		return err
+	}
+
+	r.Recorder.Event(pb, "Warning", "PlacementMigration",
+		"The PlacementBinding can't be migrated to a Placement: "+strings.Join(problems, "; "))
+
+	return nil
+}
+
+// ensureClusterSetBinding creates the ManagedClusterSetBinding for the cluster set in the
+// namespace if it doesn't exist
+func (r *PlacementMigrationReconciler) ensureClusterSetBinding(
+	ctx context.Context, namespace string, clusterSet string,
+) error {
+	binding := &clusterv1alpha1.ManagedClusterSetBinding{}
+
+	err := r.Get(ctx, types.NamespacedName{Namespace: namespace, Name: clusterSet}, binding)
+	if err == nil || !errors.IsNotFound(err) {
+		return err
+	}
+
+	binding = &clusterv1alpha1.ManagedClusterSetBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: clusterSet, Namespace: namespace},
+		Spec:       clusterv1alpha1.ManagedClusterSetBindingSpec{ClusterSet: clusterSet},
+	}
+
+	err = r.Create(ctx, binding)
+	if errors.IsAlreadyExists(err) {
+		return nil
+	}
+
+	return err
+}
+
+// ensurePlacement creates or updates the Placement with the same name as the PlacementRule. If a
+// Placement with that name exists that wasn't generated from the PlacementRule, it is left alone
+// and the problem is returned.
+func (r *PlacementMigrationReconciler) ensurePlacement(
+	ctx context.Context, plr *appsv1.PlacementRule, spec clusterv1alpha1.PlacementSpec,
+) (*clusterv1alpha1.Placement, string, error) {
+	placement := &clusterv1alpha1.Placement{}
+
+	err := r.Get(ctx, types.NamespacedName{Namespace: plr.GetNamespace(), Name: plr.GetName()}, placement)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return nil, "", err
+		}
+
+		placement = &clusterv1alpha1.Placement{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        plr.GetName(),
+				Namespace:   plr.GetNamespace(),
+				Annotations: map[string]string{migratedFromAnnotation: plr.GetName()},
+			},
+			Spec: spec,
+		}
+
+		return placement, "", r.Create(ctx, placement)
+	}
+
+	if placement.GetAnnotations()[migratedFromAnnotation] != plr.GetName() {
+		return nil, fmt.Sprintf(
+			"placement: the Placement %s already exists and was not generated from the PlacementRule",
+			placement.GetName(),
+		), nil
+	}
+
+	if !equality.Semantic.DeepEqual(placement.Spec, spec) {
+		placement.Spec = spec
+
+		err = r.Update(ctx, placement)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	return placement, "", nil
+}
+
+// compareDecisions returns a problem if the Placement hasn't been scheduled yet or if it doesn't
+// select the same clusters as the PlacementRule, since switching the PlacementBinding would then
+// remove the policies from some clusters.
+func (r *PlacementMigrationReconciler) compareDecisions(
+	ctx context.Context, plr *appsv1.PlacementRule, placement *clusterv1alpha1.Placement,
+) (string, error) {
+	if meta.FindStatusCondition(placement.Status.Conditions, clusterv1alpha1.PlacementConditionSatisfied) == nil {
+		return fmt.Sprintf("decisions: waiting for the Placement %s to be scheduled", placement.GetName()), nil
+	}
+
+	pldList := &clusterv1alpha1.PlacementDecisionList{}
+	lopts := &client.ListOptions{Namespace: placement.GetNamespace()}
+	opts := client.MatchingLabels{"cluster.open-cluster-management.io/placement": placement.GetName()}
+	opts.ApplyToList(lopts)
+
+	err := r.List(ctx, pldList, lopts)
+	if err != nil {
+		return "", err
+	}
+
+	selected := map[string]bool{}
+	for _, pld := range pldList.Items {
+		for _, decision := range pld.Status.Decisions {
+			selected[decision.ClusterName] = true
+		}
+	}
+
+	missing := []string{}
+	for _, decision := range plr.Status.Decisions {
+		if !selected[decision.ClusterName] {
+			missing = append(missing, decision.ClusterName)
+		}
+
+		delete(selected, decision.ClusterName)
+	}
+
+	extra := make([]string, 0, len(selected))
+	for cluster := range selected {
+		extra = append(extra, cluster)
+	}
+
+	if len(missing) == 0 && len(extra) == 0 {
+		return "", nil
+	}
+
+	sort.Strings(missing)
+	sort.Strings(extra)
+
+	return fmt.Sprintf(
+		"decisions: the Placement %s doesn't select the same clusters as the PlacementRule "+
+			"(only selected by the PlacementRule: %v, only selected by the Placement: %v)",
+		placement.GetName(), missing, extra,
+	), nil
+}
@@ -0,0 +1,226 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package placementmigration
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	clusterv1alpha1 "github.com/open-cluster-management/api/cluster/v1alpha1"
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+func TestTranslatePlacementRule(t *testing.T) {
+	replicas := int32(2)
+
+	tests := []struct {
+		name           string
+		spec           appsv1.PlacementRuleSpec
+		expected       clusterv1alpha1.PlacementSpec
+		untranslatable int
+	}{
+		{
+			name:     "empty",
+			expected: clusterv1alpha1.PlacementSpec{ClusterSets: []string{"set"}},
+		},
+		{
+			name: "selector, clusters, and replicas",
+			spec: appsv1.PlacementRuleSpec{
+				ClusterReplicas: &replicas,
+				GenericPlacementFields: appsv1.GenericPlacementFields{
+					Clusters:        []appsv1.GenericClusterReference{{Name: "c1"}, {Name: "c2"}},
+					ClusterSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}},
+				},
+				ClusterConditions: []appsv1.ClusterConditionFilter{
+					{Type: "ManagedClusterConditionAvailable", Status: metav1.ConditionTrue},
+				},
+			},
+			expected: clusterv1alpha1.PlacementSpec{
+				ClusterSets:      []string{"set"},
+				NumberOfClusters: &replicas,
+				Predicates: []clusterv1alpha1.ClusterPredicate{{
+					RequiredClusterSelector: clusterv1alpha1.ClusterSelector{
+						LabelSelector: metav1.LabelSelector{
+							MatchLabels: map[string]string{"env": "prod"},
+							MatchExpressions: []metav1.LabelSelectorRequirement{
+								{Key: "name", Operator: metav1.LabelSelectorOpIn, Values: []string{"c1", "c2"}},
+							},
+						},
+					},
+				}},
+			},
+		},
+		{
+			name: "untranslatable",
+			spec: appsv1.PlacementRuleSpec{
+				SchedulerName: "custom",
+				ClusterConditions: []appsv1.ClusterConditionFilter{
+					{Type: "ManagedClusterConditionAvailable", Status: metav1.ConditionFalse},
+				},
+				ResourceHint: &appsv1.ResourceHint{Type: appsv1.ResourceTypeCPU},
+			},
+			untranslatable: 3,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			spec, untranslatable := translatePlacementRule(&appsv1.PlacementRule{Spec: test.spec}, []string{"set"})
+
+			if len(untranslatable) != test.untranslatable {
+				t.Fatalf("expected %d untranslatable fields, got %v", test.untranslatable, untranslatable)
+			}
+
+			if test.untranslatable == 0 && !reflect.DeepEqual(spec, test.expected) {
+				t.Fatalf("expected the Placement spec %+v, got %+v", test.expected, spec)
+			}
+		})
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	scheme := runtime.NewScheme()
+	for _, addToScheme := range []func(*runtime.Scheme) error{
+		policiesv1.AddToScheme, appsv1.AddToScheme, clusterv1alpha1.AddToScheme,
+	} {
+		if err := addToScheme(scheme); err != nil {
+			t.Fatalf("failed to build the scheme: %v", err)
+		}
+	}
+
+	pb := &policiesv1.PlacementBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "pb", Namespace: "policies"},
+		PlacementRef: policiesv1.Subject{
+			APIGroup: appsv1.SchemeGroupVersion.Group, Kind: "PlacementRule", Name: "plr",
+		},
+	}
+	plr := &appsv1.PlacementRule{
+		ObjectMeta: metav1.ObjectMeta{Name: "plr", Namespace: "policies"},
+		Status: appsv1.PlacementRuleStatus{
+			Decisions: []appsv1.PlacementDecision{{ClusterName: "c1", ClusterNamespace: "c1"}},
+		},
+	}
+	scheduled := &clusterv1alpha1.Placement{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "plr",
+			Namespace:   "policies",
+			Annotations: map[string]string{migratedFromAnnotation: "plr"},
+		},
+		Spec: clusterv1alpha1.PlacementSpec{ClusterSets: []string{"set"}},
+		Status: clusterv1alpha1.PlacementStatus{
+			Conditions: []metav1.Condition{
+				{Type: clusterv1alpha1.PlacementConditionSatisfied, Status: metav1.ConditionTrue},
+			},
+		},
+	}
+	decision := func(clusters ...string) *clusterv1alpha1.PlacementDecision {
+		pld := &clusterv1alpha1.PlacementDecision{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "plr-decision-1",
+				Namespace: "policies",
+				Labels:    map[string]string{"cluster.open-cluster-management.io/placement": "plr"},
+			},
+		}
+		for _, cluster := range clusters {
+			pld.Status.Decisions = append(pld.Status.Decisions, clusterv1alpha1.ClusterDecision{ClusterName: cluster})
+		}
+
+		return pld
+	}
+
+	tests := []struct {
+		name           string
+		objects        []client.Object
+		expectMigrated bool
+		expectReport   string
+	}{
+		{
+			name:         "not scheduled yet",
+			objects:      []client.Object{plr},
+			expectReport: "waiting for the Placement plr to be scheduled",
+		},
+		{
+			name:         "different decisions",
+			objects:      []client.Object{plr, scheduled, decision("c2")},
+			expectReport: "only selected by the PlacementRule: [c1], only selected by the Placement: [c2]",
+		},
+		{
+			name: "existing Placement",
+			objects: []client.Object{plr, &clusterv1alpha1.Placement{
+				ObjectMeta: metav1.ObjectMeta{Name: "plr", Namespace: "policies"},
+			}},
+			expectReport: "the Placement plr already exists",
+		},
+		{
+			name:         "missing PlacementRule",
+			expectReport: "the PlacementRule plr was not found",
+		},
+		{
+			name:           "migrated",
+			objects:        []client.Object{plr, scheduled, decision("c1")},
+			expectMigrated: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			objects := append([]client.Object{pb.DeepCopy()}, test.objects...)
+			r := &PlacementMigrationReconciler{
+				Client:      fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(),
+				Scheme:      scheme,
+				Recorder:    record.NewFakeRecorder(10),
+				ClusterSets: []string{"set"},
+			}
+
+			_, err := r.Reconcile(context.TODO(), ctrl.Request{
+				NamespacedName: types.NamespacedName{Namespace: "policies", Name: "pb"},
+			})
+			if err != nil {
+				t.Fatalf("Reconcile returned an error: %v", err)
+			}
+
+			updated := &policiesv1.PlacementBinding{}
+
+			err = r.Get(context.TODO(), types.NamespacedName{Namespace: "policies", Name: "pb"}, updated)
+			if err != nil {
+				t.Fatalf("failed to get the PlacementBinding: %v", err)
+			}
+
+			migrated := updated.PlacementRef.Kind == "Placement" &&
+				updated.PlacementRef.APIGroup == clusterv1alpha1.SchemeGroupVersion.Group &&
+				updated.PlacementRef.Name == "plr"
+			if migrated != test.expectMigrated {
+				t.Fatalf("expected migrated=%t, got the placementRef %+v", test.expectMigrated, updated.PlacementRef)
+			}
+
+			report := updated.GetAnnotations()[migrationReportAnnotation]
+			if test.expectReport == "" && report != "" || !strings.Contains(report, test.expectReport) {
+				t.Fatalf("expected the report to contain %q, got %q", test.expectReport, report)
+			}
+
+			if test.expectMigrated {
+				binding := &clusterv1alpha1.ManagedClusterSetBinding{}
+
+				err = r.Get(context.TODO(), types.NamespacedName{Namespace: "policies", Name: "set"}, binding)
+				if err != nil {
+					t.Fatalf("failed to get the ManagedClusterSetBinding: %v", err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package placementmigration
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	clusterv1 "github.com/open-cluster-management/api/cluster/v1"
+	clusterv1alpha1 "github.com/open-cluster-management/api/cluster/v1alpha1"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+// The label every ManagedCluster has with its name
+const clusterNameLabel = "name"
+
+// translatePlacementRule returns the spec of a Placement that selects the same clusters as the
+// PlacementRule, limited to the given cluster sets. Anything in the PlacementRule that a Placement
+// can't express is returned as untranslatable, in which case the Placement spec must not be used.
+func translatePlacementRule(plr *appsv1.PlacementRule, clusterSets []string) (
+	spec clusterv1alpha1.PlacementSpec, untranslatable []string,
+) {
+	spec.ClusterSets = clusterSets
+	spec.NumberOfClusters = plr.Spec.ClusterReplicas
+
+	selector := metav1.LabelSelector{}
+	if plr.Spec.ClusterSelector != nil {
+		plr.Spec.ClusterSelector.DeepCopyInto(&selector)
+	}
+
+	// The PlacementRule only selects the listed clusters that also match its cluster selector, so
+	// the list becomes an additional requirement of the label selector
+	if len(plr.Spec.Clusters) > 0 {
+		names := make([]string, 0, len(plr.Spec.Clusters))
+		for _, cluster := range plr.Spec.Clusters {
+			names = append(names, cluster.Name)
+		}
+
+		selector.MatchExpressions = append(selector.MatchExpressions, metav1.LabelSelectorRequirement{
+			Key:      clusterNameLabel,
+			Operator: metav1.LabelSelectorOpIn,
+			Values:   names,
+		})
+	}
+
+	if len(selector.MatchLabels) > 0 || len(selector.MatchExpressions) > 0 {
+		spec.Predicates = []clusterv1alpha1.ClusterPredicate{
+			{RequiredClusterSelector: clusterv1alpha1.ClusterSelector{LabelSelector: selector}},
+		}
+	}
+
+	// A Placement only selects available clusters, which matches the PlacementRule default
+	for _, condition := range plr.Spec.ClusterConditions {
+		if condition.Type == clusterv1.ManagedClusterConditionAvailable &&
+			condition.Status == metav1.ConditionTrue {
+			continue
+		}
+
+		untranslatable = append(untranslatable, fmt.Sprintf(
+			"clusterConditions: the %s=%s condition filter has no Placement equivalent",
+			condition.Type, condition.Status,
+		))
+	}
+
+	if plr.Spec.ResourceHint != nil && plr.Spec.ResourceHint.Type != appsv1.ResourceTypeNone {
+		untranslatable = append(untranslatable, fmt.Sprintf(
+			"resourceHint: selecting clusters by %s has no Placement equivalent", plr.Spec.ResourceHint.Type,
+		))
+	}
+
+	switch plr.Spec.SchedulerName {
+	case "", appsv1.SchedulerNameDefault, appsv1.SchedulerNameMCM:
+	default:
+		untranslatable = append(untranslatable, fmt.Sprintf(
+			"schedulerName: the %s scheduler has no Placement equivalent", plr.Spec.SchedulerName,
+		))
+	}
+
+	if len(plr.Spec.Policies) > 0 {
+		untranslatable = append(untranslatable, fmt.Sprintf(
+			"policies: filtering by %s has no Placement equivalent", objectReferences(plr.Spec.Policies),
+		))
+	}
+
+	return spec, untranslatable
+}
+
+func objectReferences(refs []corev1.ObjectReference) []string {
+	names := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		names = append(names, ref.Kind+"/"+ref.Name)
+	}
+
+	return names
+}
@@ -0,0 +1,204 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package policymetrics
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// The labels of the policy_governance_info metric that can be dropped to bound its cardinality.
+// The type label is always set.
+const (
+	PolicyLabel           = "policy"
+	PolicyNamespaceLabel  = "policy_namespace"
+	ClusterNamespaceLabel = "cluster_namespace"
+)
+
+// DetailLabels are the labels of the policy_governance_info metric that are set by default
+var DetailLabels = []string{PolicyLabel, PolicyNamespaceLabel, ClusterNamespaceLabel}
+
+// ParseMetricLabels parses a comma separated list of the labels of the policy_governance_info
+// metric to set. An empty list returns all the labels.
+func ParseMetricLabels(value string) ([]string, error) {
+	if strings.TrimSpace(value) == "" {
+		return DetailLabels, nil
+	}
+
+	labels := []string{}
+
+	for _, label := range strings.Split(value, ",") {
+		label = strings.TrimSpace(label)
+		if label == "" {
+			continue
+		}
+
+		if !isDetailLabel(label) {
+			return nil, fmt.Errorf(
+				"the metric label %s is not one of %s", label, strings.Join(DetailLabels, ", "),
+			)
+		}
+
+		labels = append(labels, label)
+	}
+
+	return labels, nil
+}
+
+func isDetailLabel(label string) bool {
+	for _, detailLabel := range DetailLabels {
+		if label == detailLabel {
+			return true
+		}
+	}
+
+	return false
+}
+
+// reduceLabels returns a copy of the labels with the value of the detail labels that are not
+// enabled cleared, which Prometheus treats as the label not being set
+func reduceLabels(labels prometheus.Labels, enabled []string) prometheus.Labels {
+	reduced := prometheus.Labels{}
+
+	for name, value := range labels {
+		if isDetailLabel(name) && !contains(enabled, name) {
+			value = ""
+		}
+
+		reduced[name] = value
+	}
+
+	return reduced
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// labelsKey returns a key that identifies the label values of the policy_governance_info metric
+func labelsKey(labels prometheus.Labels) string {
+	return strings.Join([]string{
+		labels["type"], labels[PolicyNamespaceLabel], labels[PolicyLabel], labels[ClusterNamespaceLabel],
+	}, "/")
+}
+
+type statusEntry struct {
+	series prometheus.Labels
+	value  float64
+}
+
+// statusAggregator sets the policy_governance_info series from the status of each policy. When
+// labels are dropped, the policies sharing a series are aggregated and the series is the number
+// of those policies that are NonCompliant, which is the same as the status with all the labels.
+type statusAggregator struct {
+	lock    sync.Mutex
+	gauge   *prometheus.GaugeVec
+	entries map[string]statusEntry
+	// members are the keys of the entries aggregated in each series
+	members map[string]map[string]bool
+}
+
+func newStatusAggregator(gauge *prometheus.GaugeVec) *statusAggregator {
+	return &statusAggregator{
+		gauge: gauge, entries: map[string]statusEntry{}, members: map[string]map[string]bool{},
+	}
+}
+
+// set records the status of the policy identified by the full labels in the series with the
+// reduced labels
+func (a *statusAggregator) set(full prometheus.Labels, series prometheus.Labels, value float64) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	key := labelsKey(full)
+	seriesKey := labelsKey(series)
+
+	// The series changes if the enabled labels changed since the last time the policy was set
+	if previous, ok := a.entries[key]; ok && labelsKey(previous.series) != seriesKey {
+		delete(a.entries, key)
+		delete(a.members[labelsKey(previous.series)], key)
+		a.refresh(previous.series)
+	}
+
+	if a.members[seriesKey] == nil {
+		a.members[seriesKey] = map[string]bool{}
+	}
+
+	a.entries[key] = statusEntry{series: series, value: value}
+	a.members[seriesKey][key] = true
+	a.refresh(series)
+}
+
+// get returns the recorded status of the policy identified by the full labels
+func (a *statusAggregator) get(full prometheus.Labels) (float64, bool) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	entry, ok := a.entries[labelsKey(full)]
+
+	return entry.value, ok
+}
+
+// delete removes the status of the policy identified by the full labels and returns whether it
+// was recorded
+func (a *statusAggregator) delete(full prometheus.Labels) bool {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	key := labelsKey(full)
+
+	entry, ok := a.entries[key]
+	if !ok {
+		return false
+	}
+
+	delete(a.entries, key)
+	delete(a.members[labelsKey(entry.series)], key)
+	a.refresh(entry.series)
+
+	return true
+}
+
+// refresh sets the series to the sum of the statuses aggregated in it, or deletes it if there are
+// none left. The pending statuses (NaN) are not counted, and the series is NaN if all the statuses
+// aggregated in it are pending. The lock must be held.
+func (a *statusAggregator) refresh(series prometheus.Labels) {
+	seriesKey := labelsKey(series)
+
+	if len(a.members[seriesKey]) == 0 {
+		delete(a.members, seriesKey)
+		a.gauge.Delete(series)
+
+		return
+	}
+
+	sum := 0.0
+	pending := 0
+
+	for key := range a.members[seriesKey] {
+		value := a.entries[key].value
+		if math.IsNaN(value) {
+			pending++
+
+			continue
+		}
+
+		sum += value
+	}
+
+	if pending == len(a.members[seriesKey]) {
+		sum = math.NaN()
+	}
+
+	a.gauge.With(series).Set(sum)
+}
@@ -0,0 +1,97 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package policymetrics
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestParseMetricLabels(t *testing.T) {
+	labels, err := ParseMetricLabels(" policy_namespace, policy ")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(labels, []string{PolicyNamespaceLabel, PolicyLabel}) {
+		t.Fatalf("Unexpected labels: %v", labels)
+	}
+
+	if labels, _ := ParseMetricLabels(""); !reflect.DeepEqual(labels, DetailLabels) {
+		t.Fatalf("Expected all the labels by default, got %v", labels)
+	}
+
+	if _, err := ParseMetricLabels("policy,cluster"); err == nil {
+		t.Fatal("Expected an error for an unknown label")
+	}
+}
+
+func TestStatusAggregator(t *testing.T) {
+	gauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{Name: "test_policy_governance_info"},
+		[]string{"type", PolicyLabel, PolicyNamespaceLabel, ClusterNamespaceLabel},
+	)
+	aggregator := newStatusAggregator(gauge)
+	enabled := []string{PolicyLabel, PolicyNamespaceLabel}
+
+	propagated := func(clusterNamespace string) prometheus.Labels {
+		return prometheus.Labels{
+			"type": "propagated", PolicyLabel: "policy", PolicyNamespaceLabel: "policies",
+			ClusterNamespaceLabel: clusterNamespace,
+		}
+	}
+	series := reduceLabels(propagated("cluster1"), enabled)
+
+	if series[ClusterNamespaceLabel] != "" || series[PolicyLabel] != "policy" {
+		t.Fatalf("Expected only the cluster namespace to be dropped, got %v", series)
+	}
+
+	aggregator.set(propagated("cluster1"), series, 1)
+	aggregator.set(propagated("cluster2"), reduceLabels(propagated("cluster2"), enabled), 1)
+	aggregator.set(propagated("cluster3"), reduceLabels(propagated("cluster3"), enabled), 0)
+
+	if count := testutil.CollectAndCount(gauge); count != 1 {
+		t.Fatalf("Expected the clusters to be aggregated in 1 series, got %d", count)
+	}
+
+	if value := testutil.ToFloat64(gauge.With(series)); value != 2 {
+		t.Fatalf("Expected 2 NonCompliant clusters, got %v", value)
+	}
+
+	aggregator.set(propagated("cluster1"), series, 0)
+
+	if value := testutil.ToFloat64(gauge.With(series)); value != 1 {
+		t.Fatalf("Expected 1 NonCompliant cluster after the update, got %v", value)
+	}
+
+	aggregator.set(propagated("cluster2"), series, math.NaN())
+
+	if value := testutil.ToFloat64(gauge.With(series)); value != 0 {
+		t.Fatalf("Expected the pending cluster not to be counted, got %v", value)
+	}
+
+	aggregator.set(propagated("cluster3"), series, math.NaN())
+	aggregator.set(propagated("cluster1"), series, math.NaN())
+
+	if value := testutil.ToFloat64(gauge.With(series)); !math.IsNaN(value) {
+		t.Fatalf("Expected NaN when all the clusters are pending, got %v", value)
+	}
+
+	for _, clusterNamespace := range []string{"cluster1", "cluster2", "cluster3"} {
+		if !aggregator.delete(propagated(clusterNamespace)) {
+			t.Fatalf("Expected the status of %s to be deleted", clusterNamespace)
+		}
+	}
+
+	if count := testutil.CollectAndCount(gauge); count != 0 {
+		t.Fatalf("Expected the series to be deleted with its last policy, got %d series", count)
+	}
+
+	if aggregator.delete(propagated("cluster1")) {
+		t.Fatal("Expected nothing to delete for an unknown policy")
+	}
+}
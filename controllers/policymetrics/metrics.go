@@ -12,7 +12,9 @@ var (
 	policyStatusGauge = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "policy_governance_info",
-			Help: "The compliance status of the named policy. 0 == Compliant. 1 == NonCompliant",
+			Help: "The compliance status of the named policy. 0 == Compliant. 1 == NonCompliant. " +
+				"NaN == Pending for root policies. When labels are dropped, the number of NonCompliant " +
+				"policies that share the labels.",
 		},
 		[]string{
 			"type",              // "root" or "propagated"
@@ -21,6 +23,7 @@ var (
 			"cluster_namespace", // The namespace where the policy was propagated
 		},
 	)
+	policyStatuses = newStatusAggregator(policyStatusGauge)
 )
 
 func init() {
@@ -5,6 +5,7 @@ package policymetrics
 
 import (
 	"context"
+	"math"
 	"strings"
 
 	clusterv1 "github.com/open-cluster-management/api/cluster/v1"
@@ -39,11 +40,24 @@ var _ reconcile.Reconciler = &MetricReconciler{}
 type MetricReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+	// MetricLabels are the detail labels set on the policy_governance_info metric. The policies
+	// that only differ by the other labels are aggregated. When empty, all the labels are set.
+	MetricLabels []string
+}
+
+// metricLabels returns the detail labels set on the policy_governance_info metric
+func (r *MetricReconciler) metricLabels() []string {
+	if len(r.MetricLabels) == 0 {
+		return DetailLabels
+	}
+
+	return r.MetricLabels
 }
 
 //+kubebuilder:rbac:groups=policy.open-cluster-management.io,resources=policies,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=policy.open-cluster-management.io,resources=policies/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=policy.open-cluster-management.io,resources=policies/finalizers,verbs=update
+//+kubebuilder:rbac:groups=monitoring.coreos.com,resources=prometheusrules,verbs=get;list;watch;create;update;delete
 
 // Reconcile reads the state of the cluster for the Policy object and ensures that the exported
 // policy metrics are accurate, updating them as necessary.
@@ -90,7 +104,7 @@ func (r *MetricReconciler) Reconcile(ctx context.Context, request ctrl.Request)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			// Try to delete the gauge, but don't get hung up on errors. Log whether it was deleted.
-			statusGaugeDeleted := policyStatusGauge.Delete(promLabels)
+			statusGaugeDeleted := policyStatuses.delete(promLabels)
 			reqLogger.Info("Policy not found - must have been deleted.",
 				"status-gauge-deleted", statusGaugeDeleted)
 			return reconcile.Result{}, nil
@@ -99,26 +113,37 @@ func (r *MetricReconciler) Reconcile(ctx context.Context, request ctrl.Request)
 		return reconcile.Result{}, err
 	}
 
+	if promLabels["type"] == "root" {
+		err = r.reconcilePrometheusRule(ctx, pol, r.metricLabels())
+		if err != nil {
+			reqLogger.Error(err, "Failed to reconcile the PrometheusRule")
+			return reconcile.Result{}, err
+		}
+	}
+
 	reqLogger.Info("Got active state", "pol.Spec.Disabled", pol.Spec.Disabled)
 	if pol.Spec.Disabled {
 		// The policy is no longer active, so delete its metric
-		statusGaugeDeleted := policyStatusGauge.Delete(promLabels)
+		statusGaugeDeleted := policyStatuses.delete(promLabels)
 		reqLogger.Info("Metric removed for non-active policy",
 			"status-gauge-deleted", statusGaugeDeleted)
 		return reconcile.Result{}, nil
 	}
 
 	reqLogger.Info("Got ComplianceState", "pol.Status.ComplianceState", pol.Status.ComplianceState)
-	statusMetric, err := policyStatusGauge.GetMetricWith(promLabels)
-	if err != nil {
-		reqLogger.Error(err, "Failed to get status metric from GaugeVec")
-		return reconcile.Result{}, err
-	}
+	// An unknown compliance state keeps the previous value, or 0 if there is none. A root policy
+	// without a compliance state is pending, since the propagator aggregates the status of all its
+	// clusters, so it is NaN.
+	value, _ := policyStatuses.get(promLabels)
 	if pol.Status.ComplianceState == policiesv1.Compliant {
-		statusMetric.Set(0)
+		value = 0
 	} else if pol.Status.ComplianceState == policiesv1.NonCompliant {
-		statusMetric.Set(1)
+		value = 1
+	} else if promLabels["type"] == "root" {
+		value = math.NaN()
 	}
 
+	policyStatuses.set(promLabels, reduceLabels(promLabels, r.metricLabels()), value)
+
 	return reconcile.Result{}, nil
 }
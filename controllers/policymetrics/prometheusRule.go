@@ -0,0 +1,181 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package policymetrics
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+)
+
+const (
+	// PrometheusRuleAnnotation opts a root policy in to having a PrometheusRule generated for it
+	PrometheusRuleAnnotation = "policy.open-cluster-management.io/prometheus-rule"
+	// PrometheusRuleThresholdAnnotation is the number of noncompliant clusters above which the
+	// alert fires. It defaults to 0.
+	PrometheusRuleThresholdAnnotation = "policy.open-cluster-management.io/prometheus-rule-threshold"
+	// PrometheusRuleSeverityAnnotation is the severity label of the alert. It defaults to warning.
+	PrometheusRuleSeverityAnnotation = "policy.open-cluster-management.io/prometheus-rule-severity"
+	// PrometheusRuleRunbookAnnotation is the runbook URL to set on the alert
+	PrometheusRuleRunbookAnnotation = "policy.open-cluster-management.io/prometheus-rule-runbook"
+)
+
+var prometheusRuleGVK = schema.GroupVersionKind{
+	Group:   "monitoring.coreos.com",
+	Version: "v1",
+	Kind:    "PrometheusRule",
+}
+
+// reconcilePrometheusRule creates, updates, or deletes the PrometheusRule of the root policy based
+// on its annotations. The PrometheusRule is owned by the policy so that it is garbage collected
+// with it. Nothing is done if the PrometheusRule CRD is not installed. Since the alert selects the
+// policy by its labels on the policy_governance_info metric, the PrometheusRule is not generated
+// when these labels are dropped.
+func (r *MetricReconciler) reconcilePrometheusRule(
+	ctx context.Context, pol *policiesv1.Policy, metricLabels []string,
+) error {
+	reqLogger := log.WithValues("Request.Namespace", pol.GetNamespace(), "Request.Name", pol.GetName())
+
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(prometheusRuleGVK)
+
+	err := r.Get(ctx, types.NamespacedName{Namespace: pol.GetNamespace(), Name: pol.GetName()}, existing)
+	if err != nil {
+		if meta.IsNoMatchError(err) {
+			reqLogger.V(1).Info("The PrometheusRule CRD is not installed, skipping the PrometheusRule...")
+
+			return nil
+		}
+
+		if !errors.IsNotFound(err) {
+			return err
+		}
+
+		existing = nil
+	}
+
+	labelsSet := contains(metricLabels, PolicyLabel) && contains(metricLabels, PolicyNamespaceLabel)
+	if !labelsSet && pol.GetAnnotations()[PrometheusRuleAnnotation] == "true" {
+		reqLogger.Info(
+			"The policy and policy_namespace metric labels are dropped, so the PrometheusRule can't be generated...",
+		)
+	}
+
+	if pol.GetAnnotations()[PrometheusRuleAnnotation] != "true" || !labelsSet {
+		if existing == nil || !metav1.IsControlledBy(existing, pol) {
+			return nil
+		}
+
+		reqLogger.Info("Deleting the PrometheusRule since the policy is no longer annotated...")
+
+		return client.IgnoreNotFound(r.Delete(ctx, existing))
+	}
+
+	desired, err := buildPrometheusRule(pol)
+	if err != nil {
+		return err
+	}
+
+	if existing == nil {
+		err = controllerutil.SetControllerReference(pol, desired, r.Scheme)
+		if err != nil {
+			return err
+		}
+
+		reqLogger.Info("Creating the PrometheusRule...")
+
+		return r.Create(ctx, desired)
+	}
+
+	if !metav1.IsControlledBy(existing, pol) {
+		return fmt.Errorf(
+			"the PrometheusRule %s/%s already exists and is not owned by the policy",
+			pol.GetNamespace(), pol.GetName(),
+		)
+	}
+
+	if equality.Semantic.DeepEqual(existing.Object["spec"], desired.Object["spec"]) {
+		return nil
+	}
+
+	existing.Object["spec"] = desired.Object["spec"]
+
+	reqLogger.Info("Updating the PrometheusRule...")
+
+	return r.Update(ctx, existing)
+}
+
+// buildPrometheusRule returns the PrometheusRule alerting when the number of clusters where the
+// root policy is noncompliant exceeds the threshold in its annotations.
+func buildPrometheusRule(pol *policiesv1.Policy) (*unstructured.Unstructured, error) {
+	annotations := pol.GetAnnotations()
+
+	threshold := 0
+	if value, ok := annotations[PrometheusRuleThresholdAnnotation]; ok {
+		var err error
+
+		threshold, err = strconv.Atoi(value)
+		if err != nil || threshold < 0 {
+			return nil, fmt.Errorf(
+				"the %s annotation must be a non-negative integer, got %s", PrometheusRuleThresholdAnnotation, value,
+			)
+		}
+	}
+
+	severity := annotations[PrometheusRuleSeverityAnnotation]
+	if severity == "" {
+		severity = "warning"
+	}
+
+	alertAnnotations := map[string]interface{}{
+		"summary": fmt.Sprintf("The policy %s/%s is noncompliant", pol.GetNamespace(), pol.GetName()),
+		"description": fmt.Sprintf(
+			"The policy %s/%s is noncompliant on more than %d clusters", pol.GetNamespace(), pol.GetName(), threshold,
+		),
+	}
+	if runbook := annotations[PrometheusRuleRunbookAnnotation]; runbook != "" {
+		alertAnnotations["runbook_url"] = runbook
+	}
+
+	rule := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"groups": []interface{}{
+				map[string]interface{}{
+					"name": "policy-" + pol.GetName(),
+					"rules": []interface{}{
+						map[string]interface{}{
+							"alert": "PolicyNoncompliant",
+							"expr": fmt.Sprintf(
+								`sum(policy_governance_info{type="propagated",policy="%s",policy_namespace="%s"}) > %d`,
+								pol.GetName(), pol.GetNamespace(), threshold,
+							),
+							"labels": map[string]interface{}{
+								"severity":         severity,
+								"policy":           pol.GetName(),
+								"policy_namespace": pol.GetNamespace(),
+							},
+							"annotations": alertAnnotations,
+						},
+					},
+				},
+			},
+		},
+	}}
+	rule.SetGroupVersionKind(prometheusRuleGVK)
+	rule.SetNamespace(pol.GetNamespace())
+	rule.SetName(pol.GetName())
+
+	return rule, nil
+}
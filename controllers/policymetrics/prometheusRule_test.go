@@ -0,0 +1,108 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package policymetrics
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+)
+
+func TestBuildPrometheusRule(t *testing.T) {
+	pol := &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{
+		Name:      "policy",
+		Namespace: "policies",
+		Annotations: map[string]string{
+			PrometheusRuleThresholdAnnotation: "3",
+			PrometheusRuleSeverityAnnotation:  "critical",
+			PrometheusRuleRunbookAnnotation:   "https://example.com/runbook",
+		},
+	}}
+
+	rule, err := buildPrometheusRule(pol)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	rules, _, _ := unstructured.NestedSlice(rule.Object, "spec", "groups")
+	alert := rules[0].(map[string]interface{})["rules"].([]interface{})[0].(map[string]interface{})
+
+	expectedExpr := `sum(policy_governance_info{type="propagated",policy="policy",policy_namespace="policies"}) > 3`
+	if alert["expr"] != expectedExpr {
+		t.Fatalf("Expected the expression %s, got %s", expectedExpr, alert["expr"])
+	}
+
+	if severity, _, _ := unstructured.NestedString(alert, "labels", "severity"); severity != "critical" {
+		t.Fatalf("Expected the severity critical, got %s", severity)
+	}
+
+	if runbook, _, _ := unstructured.NestedString(alert, "annotations", "runbook_url"); runbook == "" {
+		t.Fatal("Expected the runbook_url annotation to be set")
+	}
+
+	pol.Annotations[PrometheusRuleThresholdAnnotation] = "-1"
+
+	if _, err := buildPrometheusRule(pol); err == nil {
+		t.Fatal("Expected an error for a negative threshold")
+	}
+}
+
+func TestReconcilePrometheusRule(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := policiesv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to build the scheme: %v", err)
+	}
+
+	pol := &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{
+		Name:        "policy",
+		Namespace:   "policies",
+		UID:         "1234",
+		Annotations: map[string]string{PrometheusRuleAnnotation: "true"},
+	}}
+	r := &MetricReconciler{Client: fake.NewClientBuilder().WithScheme(scheme).Build(), Scheme: scheme}
+	key := types.NamespacedName{Namespace: "policies", Name: "policy"}
+
+	if err := r.reconcilePrometheusRule(context.TODO(), pol, DetailLabels); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	rule := &unstructured.Unstructured{}
+	rule.SetGroupVersionKind(prometheusRuleGVK)
+
+	if err := r.Get(context.TODO(), key, rule); err != nil {
+		t.Fatalf("Expected the PrometheusRule to be created: %v", err)
+	}
+
+	if !metav1.IsControlledBy(rule, pol) {
+		t.Fatal("Expected the PrometheusRule to be owned by the policy")
+	}
+
+	if err := r.reconcilePrometheusRule(context.TODO(), pol, []string{PolicyNamespaceLabel}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if err := r.Get(context.TODO(), key, rule); err == nil {
+		t.Fatal("Expected the PrometheusRule to be deleted when the policy metric label is dropped")
+	}
+
+	if err := r.reconcilePrometheusRule(context.TODO(), pol, DetailLabels); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	pol.Annotations = nil
+
+	if err := r.reconcilePrometheusRule(context.TODO(), pol, DetailLabels); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if err := r.Get(context.TODO(), key, rule); err == nil {
+		t.Fatal("Expected the PrometheusRule to be deleted")
+	}
+}
@@ -0,0 +1,281 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package policywebhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+)
+
+// MutatePath is the path the PolicyDefaulter is served on by the webhook server
+const MutatePath = "/mutate-policy-open-cluster-management-io-v1-policy"
+
+var log = logf.Log.WithName("policy-webhook")
+
+// The annotations listing the standards, categories, and controls of a policy, which are comma
+// separated lists
+var listAnnotations = []string{
+	common.APIGroup + "/standards",
+	common.APIGroup + "/categories",
+	common.APIGroup + "/controls",
+}
+
+// The labels set by the propagator on the replicated policies, which make the propagator treat a
+// root policy as a replicated policy
+var reservedLabels = []string{
+	common.ClusterNameLabel,
+	common.ClusterNamespaceLabel,
+	common.RootPolicyLabel,
+}
+
+//+kubebuilder:webhook:path=/mutate-policy-open-cluster-management-io-v1-policy,mutating=true,failurePolicy=ignore,sideEffects=None,groups=policy.open-cluster-management.io,resources=policies,verbs=create;update,versions=v1,name=mutate.policy.open-cluster-management.io,admissionReviewVersions=v1
+
+// blank assignment to verify that PolicyDefaulter implements admission.Handler
+var _ admission.Handler = &PolicyDefaulter{}
+
+// PolicyDefaulter is a mutating webhook that defaults and normalizes the root policies created
+// by users. The replicated policies are managed by the propagator, so they are left as is.
+type PolicyDefaulter struct {
+	Client  client.Client
+	decoder *admission.Decoder
+}
+
+// InjectDecoder sets the decoder of the admission requests, see admission.DecoderInjector
+func (d *PolicyDefaulter) InjectDecoder(decoder *admission.Decoder) error {
+	d.decoder = decoder
+
+	return nil
+}
+
+// Handle defaults the remediationAction of the policy templates, normalizes the list
+// annotations, removes the reserved labels, and records the template user of the root policy in
+// the request
+func (d *PolicyDefaulter) Handle(ctx context.Context, req admission.Request) admission.Response {
+	policy := &policiesv1.Policy{}
+
+	err := d.decoder.Decode(req, policy)
+	if err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	replicated, err := d.isReplicated(ctx, req, policy)
+	if err != nil {
+		log.Error(err, "Failed to determine if the policy is a replicated policy...",
+			"Namespace", req.Namespace, "Name", req.Name)
+
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	if replicated {
+		return admission.Allowed("replicated policies are managed by the propagator")
+	}
+
+	err = defaultPolicy(policy)
+	if err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	err = d.recordTemplateUser(req, policy)
+	if err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	marshaled, err := json.Marshal(policy)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	return admission.PatchResponseFromRaw(req.Object.Raw, marshaled)
+}
+
+// isReplicated returns true if the policy is a replicated policy of the propagator. The
+// replicated policies are named after the root policy in their label, and either the root policy
+// exists or the policy already was a replicated policy before the update.
+func (d *PolicyDefaulter) isReplicated(
+	ctx context.Context, req admission.Request, policy *policiesv1.Policy,
+) (bool, error) {
+	rootName := policy.GetLabels()[common.RootPolicyLabel]
+	if rootName == "" || rootName != policy.GetName() {
+		return false, nil
+	}
+
+	if req.Operation == admissionv1.Update {
+		oldPolicy := &policiesv1.Policy{}
+
+		err := d.decoder.DecodeRaw(req.OldObject, oldPolicy)
+		if err != nil {
+			return false, err
+		}
+
+		if oldPolicy.GetLabels()[common.RootPolicyLabel] == rootName {
+			return true, nil
+		}
+	}
+
+	// The namespace can't have a dot, so the root policy name is after the first one
+	rootKey := strings.SplitN(rootName, ".", 2)
+	if len(rootKey) != 2 {
+		return false, nil
+	}
+
+	err := d.Client.Get(ctx, types.NamespacedName{Namespace: rootKey[0], Name: rootKey[1]}, &policiesv1.Policy{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+// recordTemplateUser sets the template-user annotation of the root policy to the user of the
+// request when its spec changes, and keeps the previous value otherwise. The annotation can't be set
+// to another user, and the metadata updates of the propagator don't replace it.
+func (d *PolicyDefaulter) recordTemplateUser(req admission.Request, policy *policiesv1.Policy) error {
+	user := req.UserInfo.Username
+
+	if req.Operation == admissionv1.Update {
+		oldPolicy := &policiesv1.Policy{}
+
+		err := d.decoder.DecodeRaw(req.OldObject, oldPolicy)
+		if err != nil {
+			return err
+		}
+
+		if equality.Semantic.DeepEqual(oldPolicy.Spec, policy.Spec) {
+			user = oldPolicy.GetAnnotations()[common.TemplateUserAnnotation]
+		}
+	}
+
+	annotations := policy.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+
+	if user == "" {
+		delete(annotations, common.TemplateUserAnnotation)
+	} else {
+		annotations[common.TemplateUserAnnotation] = user
+	}
+
+	policy.SetAnnotations(annotations)
+
+	return nil
+}
+
+// defaultPolicy removes the reserved labels, normalizes the list annotations, and defaults the
+// remediationAction of the policy templates of the root policy
+func defaultPolicy(policy *policiesv1.Policy) error {
+	removeReservedLabels(policy)
+	normalizeListAnnotations(policy)
+
+	return defaultTemplateRemediationAction(policy)
+}
+
+// removeReservedLabels removes the labels of the replicated policies from the root policy
+func removeReservedLabels(policy *policiesv1.Policy) {
+	labels := policy.GetLabels()
+	if labels == nil {
+		return
+	}
+
+	for _, label := range reservedLabels {
+		delete(labels, label)
+	}
+
+	policy.SetLabels(labels)
+}
+
+// normalizeListAnnotations rewrites the standards, categories, and controls annotations as comma
+// separated lists without whitespace around the entries, empty entries, or duplicates
+func normalizeListAnnotations(policy *policiesv1.Policy) {
+	annotations := policy.GetAnnotations()
+	if annotations == nil {
+		return
+	}
+
+	for _, annotation := range listAnnotations {
+		value, ok := annotations[annotation]
+		if !ok {
+			continue
+		}
+
+		entries := []string{}
+		seen := map[string]bool{}
+
+		for _, entry := range strings.Split(value, ",") {
+			entry = strings.TrimSpace(entry)
+			if entry == "" || seen[entry] {
+				continue
+			}
+
+			seen[entry] = true
+			entries = append(entries, entry)
+		}
+
+		annotations[annotation] = strings.Join(entries, ", ")
+	}
+
+	policy.SetAnnotations(annotations)
+}
+
+// defaultTemplateRemediationAction sets the remediationAction of the policy templates of the
+// policy framework lacking one to the remediationAction of the policy, or inform if it has none.
+// The other policy templates, such as Gatekeeper constraints, don't have a remediationAction.
+func defaultTemplateRemediationAction(policy *policiesv1.Policy) error {
+	remediationAction := strings.ToLower(string(policy.Spec.RemediationAction))
+	if remediationAction == "" {
+		remediationAction = strings.ToLower(string(policiesv1.Inform))
+	}
+
+	for _, template := range policy.Spec.PolicyTemplates {
+		if template == nil || len(template.ObjectDefinition.Raw) == 0 {
+			continue
+		}
+
+		object := map[string]interface{}{}
+
+		err := json.Unmarshal(template.ObjectDefinition.Raw, &object)
+		if err != nil {
+			return err
+		}
+
+		apiVersion, _ := object["apiVersion"].(string)
+		if !strings.HasPrefix(apiVersion, common.APIGroup+"/") {
+			continue
+		}
+
+		spec, ok := object["spec"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		if action, _ := spec["remediationAction"].(string); action != "" {
+			continue
+		}
+
+		spec["remediationAction"] = remediationAction
+
+		template.ObjectDefinition.Raw, err = json.Marshal(object)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
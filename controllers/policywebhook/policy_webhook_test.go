@@ -0,0 +1,231 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package policywebhook
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+)
+
+func newTestDefaulter(t *testing.T, objects ...*policiesv1.Policy) *PolicyDefaulter {
+	t.Helper()
+
+	scheme := runtime.NewScheme()
+	if err := policiesv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to build the scheme: %v", err)
+	}
+
+	builder := fake.NewClientBuilder().WithScheme(scheme)
+	for _, obj := range objects {
+		builder = builder.WithObjects(obj)
+	}
+
+	decoder, err := admission.NewDecoder(scheme)
+	if err != nil {
+		t.Fatalf("failed to build the decoder: %v", err)
+	}
+
+	d := &PolicyDefaulter{Client: builder.Build()}
+	if err := d.InjectDecoder(decoder); err != nil {
+		t.Fatalf("failed to inject the decoder: %v", err)
+	}
+
+	return d
+}
+
+func newRequest(t *testing.T, operation admissionv1.Operation, policy *policiesv1.Policy, old *policiesv1.Policy) admission.Request {
+	t.Helper()
+
+	req := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+		Operation: operation, Namespace: policy.GetNamespace(), Name: policy.GetName(),
+	}}
+
+	raw, err := json.Marshal(policy)
+	if err != nil {
+		t.Fatalf("failed to marshal the policy: %v", err)
+	}
+
+	req.Object.Raw = raw
+
+	if old != nil {
+		req.OldObject.Raw, err = json.Marshal(old)
+		if err != nil {
+			t.Fatalf("failed to marshal the old policy: %v", err)
+		}
+	}
+
+	return req
+}
+
+func newTestPolicy(namespace string, name string, labels map[string]string) *policiesv1.Policy {
+	return &policiesv1.Policy{
+		TypeMeta: metav1.TypeMeta{APIVersion: policiesv1.SchemeGroupVersion.String(), Kind: policiesv1.Kind},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name, Namespace: namespace, Labels: labels,
+			Annotations: map[string]string{
+				common.APIGroup + "/standards":  " NIST SP 800-53,,NIST SP 800-53 ",
+				common.APIGroup + "/categories": "CM Configuration Management",
+			},
+		},
+		Spec: policiesv1.PolicySpec{
+			RemediationAction: policiesv1.Enforce,
+			PolicyTemplates: []*policiesv1.PolicyTemplate{
+				{ObjectDefinition: runtime.RawExtension{Raw: []byte(
+					`{"apiVersion":"policy.open-cluster-management.io/v1","kind":"ConfigurationPolicy",` +
+						`"metadata":{"name":"case"},"spec":{"severity":"low"}}`,
+				)}},
+				{ObjectDefinition: runtime.RawExtension{Raw: []byte(
+					`{"apiVersion":"policy.open-cluster-management.io/v1","kind":"ConfigurationPolicy",` +
+						`"metadata":{"name":"inform"},"spec":{"remediationAction":"inform"}}`,
+				)}},
+				{ObjectDefinition: runtime.RawExtension{Raw: []byte(
+					`{"apiVersion":"constraints.gatekeeper.sh/v1beta1","kind":"K8sRequiredLabels",` +
+						`"metadata":{"name":"labels"},"spec":{}}`,
+				)}},
+			},
+		},
+	}
+}
+
+func TestDefaultPolicy(t *testing.T) {
+	policy := newTestPolicy("policies", "policy", map[string]string{
+		common.RootPolicyLabel:  "policies.policy",
+		common.ClusterNameLabel: "cluster1",
+		"app":                   "test",
+	})
+
+	if err := defaultPolicy(policy); err != nil {
+		t.Fatalf("defaultPolicy returned an error: %v", err)
+	}
+
+	if len(policy.GetLabels()) != 1 || policy.GetLabels()["app"] != "test" {
+		t.Fatalf("expected the reserved labels to be removed, got %v", policy.GetLabels())
+	}
+
+	if standards := policy.GetAnnotations()[common.APIGroup+"/standards"]; standards != "NIST SP 800-53" {
+		t.Fatalf("expected the standards to be normalized, got %q", standards)
+	}
+
+	expectedActions := []string{"enforce", "inform", ""}
+
+	for i, template := range policy.Spec.PolicyTemplates {
+		object := struct {
+			Spec map[string]interface{} `json:"spec"`
+		}{}
+		if err := json.Unmarshal(template.ObjectDefinition.Raw, &object); err != nil {
+			t.Fatalf("failed to unmarshal the policy template: %v", err)
+		}
+
+		action, _ := object.Spec["remediationAction"].(string)
+		if action != expectedActions[i] {
+			t.Fatalf("expected the remediationAction %q of template %d, got %q", expectedActions[i], i, action)
+		}
+	}
+}
+
+func TestHandleRootPolicy(t *testing.T) {
+	d := newTestDefaulter(t)
+	req := newRequest(t, admissionv1.Create, newTestPolicy("policies", "policy", nil), nil)
+
+	resp := d.Handle(context.TODO(), req)
+	if !resp.Allowed || len(resp.Patches) == 0 {
+		t.Fatalf("expected the root policy to be patched, got %v", resp)
+	}
+}
+
+func TestHandleReplicatedPolicy(t *testing.T) {
+	root := newTestPolicy("policies", "policy", nil)
+	labels := map[string]string{
+		common.RootPolicyLabel:       "policies.policy",
+		common.ClusterNameLabel:      "cluster1",
+		common.ClusterNamespaceLabel: "cluster1",
+	}
+
+	tests := []struct {
+		name      string
+		operation admissionv1.Operation
+		objects   []*policiesv1.Policy
+		old       *policiesv1.Policy
+	}{
+		{name: "create with the root policy", operation: admissionv1.Create, objects: []*policiesv1.Policy{root}},
+		{
+			name: "update without the root policy", operation: admissionv1.Update,
+			old: newTestPolicy("cluster1", "policies.policy", labels),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			d := newTestDefaulter(t, test.objects...)
+			req := newRequest(t, test.operation, newTestPolicy("cluster1", "policies.policy", labels), test.old)
+
+			resp := d.Handle(context.TODO(), req)
+			if !resp.Allowed || len(resp.Patches) != 0 {
+				t.Fatalf("expected the replicated policy to be allowed as is, got %v", resp)
+			}
+		})
+	}
+
+	// Without the root policy, the policy was created by a user
+	d := newTestDefaulter(t)
+	req := newRequest(t, admissionv1.Create, newTestPolicy("cluster1", "policies.policy", labels), nil)
+
+	resp := d.Handle(context.TODO(), req)
+	for _, patch := range resp.Patches {
+		if patch.Path == "/metadata/labels" && patch.Operation == "remove" {
+			return
+		}
+	}
+
+	t.Fatalf("expected the reserved labels to be removed, got %v", resp.Patches)
+}
+
+func TestRecordTemplateUser(t *testing.T) {
+	d := newTestDefaulter(t)
+
+	old := newTestPolicy("policies", "policy", nil)
+	old.Annotations[common.TemplateUserAnnotation] = "alice"
+
+	changed := newTestPolicy("policies", "policy", nil)
+	changed.Spec.Disabled = true
+
+	tests := []struct {
+		name      string
+		operation admissionv1.Operation
+		policy    *policiesv1.Policy
+		old       *policiesv1.Policy
+		expected  string
+	}{
+		{name: "create", operation: admissionv1.Create, policy: newTestPolicy("policies", "policy", nil), expected: "bob"},
+		{name: "metadata update", operation: admissionv1.Update, policy: old.DeepCopy(), old: old, expected: "alice"},
+		{name: "spec update", operation: admissionv1.Update, policy: changed, old: old, expected: "bob"},
+	}
+
+	for _, test := range tests {
+		policy := test.policy.DeepCopy()
+		policy.Annotations[common.TemplateUserAnnotation] = "admin"
+
+		req := newRequest(t, test.operation, policy, test.old)
+		req.UserInfo.Username = "bob"
+
+		if err := d.recordTemplateUser(req, policy); err != nil {
+			t.Fatalf("%s: recordTemplateUser returned an error: %v", test.name, err)
+		}
+
+		if user := policy.GetAnnotations()[common.TemplateUserAnnotation]; user != test.expected {
+			t.Fatalf("%s: expected the template user %q, got %q", test.name, test.expected, user)
+		}
+	}
+}
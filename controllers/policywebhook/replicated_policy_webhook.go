@@ -0,0 +1,140 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package policywebhook
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+)
+
+// ValidatePath is the path the ReplicatedPolicyValidator is served on by the webhook server
+const ValidatePath = "/validate-policy-open-cluster-management-io-v1-policy"
+
+// The users of the Kubernetes controllers deleting the replicated policies with their cluster
+// namespace, which must not be blocked from finalizing the namespace
+var systemUsers = []string{
+	"system:kube-controller-manager",
+	"system:serviceaccount:kube-system:namespace-controller",
+	"system:serviceaccount:kube-system:generic-garbage-collector",
+}
+
+//+kubebuilder:webhook:path=/validate-policy-open-cluster-management-io-v1-policy,mutating=false,failurePolicy=ignore,sideEffects=None,groups=policy.open-cluster-management.io,resources=policies,verbs=create;update;delete,versions=v1,name=validate.policy.open-cluster-management.io,admissionReviewVersions=v1
+
+// blank assignment to verify that ReplicatedPolicyValidator implements admission.Handler
+var _ admission.Handler = &ReplicatedPolicyValidator{}
+
+// ReplicatedPolicyValidator is a validating webhook that denies the users from creating, updating,
+// or deleting the replicated policies in the cluster namespaces, since the propagator would
+// otherwise replicate the root policy over the changes or not notice them at all.
+type ReplicatedPolicyValidator struct {
+	// PropagatorUser is the user of the propagator service account, which is the only one allowed to
+	// modify the replicated policies
+	PropagatorUser string
+	decoder        *admission.Decoder
+}
+
+// InjectDecoder sets the decoder of the admission requests, see admission.DecoderInjector
+func (v *ReplicatedPolicyValidator) InjectDecoder(decoder *admission.Decoder) error {
+	v.decoder = decoder
+
+	return nil
+}
+
+// PropagatorUser returns the user of the service account of the propagator in its namespace
+func PropagatorUser(namespace string, serviceAccount string) string {
+	return fmt.Sprintf("system:serviceaccount:%s:%s", namespace, serviceAccount)
+}
+
+// Handle denies the request when the policy before or after it has the root policy label, unless
+// it comes from the propagator or the Kubernetes controllers deleting the cluster namespace
+func (v *ReplicatedPolicyValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	replicated, err := v.isReplicated(req)
+	if err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	if !replicated {
+		return admission.Allowed("")
+	}
+
+	user := req.UserInfo.Username
+	if user == v.PropagatorUser {
+		return admission.Allowed("")
+	}
+
+	if req.Operation == admissionv1.Delete {
+		for _, systemUser := range systemUsers {
+			if user == systemUser {
+				return admission.Allowed("")
+			}
+		}
+	}
+
+	log.Info("Denied the modification of a replicated policy...",
+		"Namespace", req.Namespace, "Name", req.Name, "Operation", req.Operation, "User", user)
+
+	return admission.Denied(fmt.Sprintf(
+		"replicated policies are managed by the propagator, modify the root policy %s instead",
+		v.rootName(req),
+	))
+}
+
+// isReplicated returns true if the policy before or after the request has the root policy label
+func (v *ReplicatedPolicyValidator) isReplicated(req admission.Request) (bool, error) {
+	for _, policy := range v.requestPolicies(req) {
+		if policy == nil {
+			return false, fmt.Errorf("failed to decode the policy of the request")
+		}
+
+		if policy.GetLabels()[common.RootPolicyLabel] != "" {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// rootName returns the name of the root policy in the format of <namespace>.<name> from the policy
+// of the request
+func (v *ReplicatedPolicyValidator) rootName(req admission.Request) string {
+	for _, policy := range v.requestPolicies(req) {
+		if policy != nil && policy.GetLabels()[common.RootPolicyLabel] != "" {
+			return policy.GetLabels()[common.RootPolicyLabel]
+		}
+	}
+
+	return ""
+}
+
+// requestPolicies returns the policies before and after the request, depending on the operation.
+// A policy that can't be decoded is nil.
+func (v *ReplicatedPolicyValidator) requestPolicies(req admission.Request) []*policiesv1.Policy {
+	policies := []*policiesv1.Policy{}
+
+	if req.Operation != admissionv1.Delete {
+		policy := &policiesv1.Policy{}
+		if err := v.decoder.DecodeRaw(req.Object, policy); err != nil {
+			policy = nil
+		}
+
+		policies = append(policies, policy)
+	}
+
+	if req.Operation == admissionv1.Update || req.Operation == admissionv1.Delete {
+		policy := &policiesv1.Policy{}
+		if err := v.decoder.DecodeRaw(req.OldObject, policy); err != nil {
+			policy = nil
+		}
+
+		policies = append(policies, policy)
+	}
+
+	return policies
+}
@@ -0,0 +1,132 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package policywebhook
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+)
+
+const testPropagatorUser = "system:serviceaccount:open-cluster-management:governance-policy-propagator"
+
+func newTestValidator(t *testing.T) *ReplicatedPolicyValidator {
+	t.Helper()
+
+	scheme := runtime.NewScheme()
+	if err := policiesv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to build the scheme: %v", err)
+	}
+
+	decoder, err := admission.NewDecoder(scheme)
+	if err != nil {
+		t.Fatalf("failed to build the decoder: %v", err)
+	}
+
+	v := &ReplicatedPolicyValidator{PropagatorUser: testPropagatorUser}
+	if err := v.InjectDecoder(decoder); err != nil {
+		t.Fatalf("failed to inject the decoder: %v", err)
+	}
+
+	return v
+}
+
+func TestPropagatorUser(t *testing.T) {
+	user := PropagatorUser("open-cluster-management", "governance-policy-propagator")
+	if user != testPropagatorUser {
+		t.Fatalf("expected the service account user %q, got %q", testPropagatorUser, user)
+	}
+}
+
+func TestValidateReplicatedPolicy(t *testing.T) {
+	labels := map[string]string{
+		common.RootPolicyLabel:       "policies.policy",
+		common.ClusterNameLabel:      "cluster1",
+		common.ClusterNamespaceLabel: "cluster1",
+	}
+	replicated := newTestPolicy("cluster1", "policies.policy", labels)
+	unlabeled := newTestPolicy("cluster1", "policies.policy", nil)
+	root := newTestPolicy("policies", "policy", nil)
+
+	tests := []struct {
+		name      string
+		operation admissionv1.Operation
+		policy    *policiesv1.Policy
+		old       *policiesv1.Policy
+		user      string
+		allowed   bool
+	}{
+		{name: "root policy", operation: admissionv1.Create, policy: root, user: "alice", allowed: true},
+		{
+			name: "root policy deleted", operation: admissionv1.Delete, old: root, user: "alice",
+			allowed: true,
+		},
+		{name: "created by a user", operation: admissionv1.Create, policy: replicated, user: "alice"},
+		{
+			name: "updated by a user", operation: admissionv1.Update, policy: replicated, old: replicated,
+			user: "alice",
+		},
+		{
+			name: "label removed by a user", operation: admissionv1.Update, policy: unlabeled, old: replicated,
+			user: "alice",
+		},
+		{name: "deleted by a user", operation: admissionv1.Delete, old: replicated, user: "alice"},
+		{
+			name: "updated by the propagator", operation: admissionv1.Update, policy: replicated,
+			old: replicated, user: testPropagatorUser, allowed: true,
+		},
+		{
+			name: "deleted by the propagator", operation: admissionv1.Delete, old: replicated,
+			user: testPropagatorUser, allowed: true,
+		},
+		{
+			name: "deleted with the namespace", operation: admissionv1.Delete, old: replicated,
+			user: "system:serviceaccount:kube-system:namespace-controller", allowed: true,
+		},
+		{
+			name: "updated by the namespace controller", operation: admissionv1.Update, policy: replicated,
+			old: replicated, user: "system:serviceaccount:kube-system:namespace-controller",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			v := newTestValidator(t)
+
+			req := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+				Operation: test.operation, Namespace: "cluster1", Name: "policies.policy",
+			}}
+			req.UserInfo.Username = test.user
+
+			var err error
+
+			if test.policy != nil {
+				req.Object.Raw, err = json.Marshal(test.policy)
+				if err != nil {
+					t.Fatalf("failed to marshal the policy: %v", err)
+				}
+			}
+
+			if test.old != nil {
+				req.OldObject.Raw, err = json.Marshal(test.old)
+				if err != nil {
+					t.Fatalf("failed to marshal the old policy: %v", err)
+				}
+			}
+
+			resp := v.Handle(context.TODO(), req)
+			if resp.Allowed != test.allowed {
+				t.Fatalf("expected the request to be allowed=%v, got %v", test.allowed, resp.Result)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+// The URL of an external HTTP service to consult before a replicated policy is created or updated.
+// When unset, no admission hook is called.
+const admissionHookURLEnvName = "CONTROLLER_CONFIG_ADMISSION_HOOK_URL"
+
+// The configuration in seconds to wait for a response from the admission hook.
+const admissionHookTimeoutEnvName = "CONTROLLER_CONFIG_ADMISSION_HOOK_TIMEOUT"
+const admissionHookTimeoutDefault = 10
+
+// admissionHookRequest is the payload sent to the admission hook for every replicated policy that
+// is about to be created or updated.
+type admissionHookRequest struct {
+	// Operation is either "create" or "update"
+	Operation        string             `json:"operation"`
+	RootPolicy       string             `json:"rootPolicy"`
+	ClusterName      string             `json:"clusterName"`
+	ClusterNamespace string             `json:"clusterNamespace"`
+	Policy           *policiesv1.Policy `json:"policy"`
+}
+
+// admissionHookResponse is the payload expected back from the admission hook. When Policy is set,
+// its labels, annotations, and spec replace the ones on the replicated policy.
+type admissionHookResponse struct {
+	Allowed bool               `json:"allowed"`
+	Message string             `json:"message,omitempty"`
+	Policy  *policiesv1.Policy `json:"policy,omitempty"`
+}
+
+// admissionHookClient calls an external HTTP service to approve, deny, or mutate replicated
+// policies before they are written to a cluster namespace.
+type admissionHookClient struct {
+	url        string
+	httpClient *http.Client
+}
+
+// propagationDeniedError is returned when the admission hook denies a replicated policy. It is
+// not retried since the hook is expected to give the same answer for the same input.
+type propagationDeniedError struct {
+	message string
+}
+
+func (e *propagationDeniedError) Error() string {
+	return "propagation denied by the admission hook: " + e.message
+}
+
+func newAdmissionHookClient(url string, timeout time.Duration) *admissionHookClient {
+	if url == "" {
+		return nil
+	}
+
+	return &admissionHookClient{url: url, httpClient: &http.Client{Timeout: timeout}}
+}
+
+// admit sends the replicated policy to the admission hook. If the hook denies it, a
+// *propagationDeniedError is returned. If the hook mutates it, replicatedPlc is updated in place
+// while keeping the labels the propagator relies on to track replicated policies.
+func (c *admissionHookClient) admit(
+	ctx context.Context,
+	operation string,
+	replicatedPlc *policiesv1.Policy,
+	decision appsv1.PlacementDecision,
+	rootPlc *policiesv1.Policy,
+) error {
+	if c == nil {
+		return nil
+	}
+
+	body, err := json.Marshal(admissionHookRequest{
+		Operation:        operation,
+		RootPolicy:       common.FullNameForPolicy(rootPlc),
+		ClusterName:      decision.ClusterName,
+		ClusterNamespace: decision.ClusterNamespace,
+		Policy:           replicatedPlc,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call the admission hook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read the admission hook response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("the admission hook returned the unexpected status code %d", resp.StatusCode)
+	}
+
+	admission := admissionHookResponse{}
+
+	err = json.Unmarshal(respBody, &admission)
+	if err != nil {
+		return fmt.Errorf("failed to parse the admission hook response: %w", err)
+	}
+
+	if !admission.Allowed {
+		return &propagationDeniedError{message: admission.Message}
+	}
+
+	if admission.Policy != nil {
+		labels := admission.Policy.GetLabels()
+		if labels == nil {
+			labels = map[string]string{}
+		}
+
+		for _, key := range []string{common.ClusterNameLabel, common.ClusterNamespaceLabel, common.RootPolicyLabel} {
+			if value, ok := replicatedPlc.GetLabels()[key]; ok {
+				labels[key] = value
+			}
+		}
+
+		replicatedPlc.SetLabels(labels)
+		replicatedPlc.SetAnnotations(admission.Policy.GetAnnotations())
+		replicatedPlc.Spec = admission.Policy.Spec
+	}
+
+	return nil
+}
@@ -0,0 +1,77 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAdmissionHookAdmit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		admissionReq := admissionHookRequest{}
+		// The test can't fail from the handler goroutine, so the error is returned to the hook client
+		if err := json.NewDecoder(req.Body).Decode(&admissionReq); err != nil {
+			http.Error(w, "failed to decode the admission request: "+err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+
+		resp := admissionHookResponse{Allowed: admissionReq.ClusterName != "denied"}
+		if resp.Allowed {
+			resp.Policy = admissionReq.Policy
+			resp.Policy.SetLabels(map[string]string{common.RootPolicyLabel: "tampered"})
+			resp.Policy.SetAnnotations(map[string]string{"change-record": "CHG0001"})
+		} else {
+			resp.Message = "change freeze"
+		}
+
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	hook := newAdmissionHookClient(server.URL, 5*time.Second)
+	rootPlc := &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{Name: "policy", Namespace: "policies"}}
+
+	replicatedPlc := rootPlc.DeepCopy()
+	replicatedPlc.SetLabels(map[string]string{common.RootPolicyLabel: "policies.policy"})
+
+	err := hook.admit(context.TODO(), "create", replicatedPlc, appsv1.PlacementDecision{ClusterName: "managed1"}, rootPlc)
+	if err != nil {
+		t.Fatalf("Expected the policy to be admitted, got: %v", err)
+	}
+
+	if replicatedPlc.GetAnnotations()["change-record"] != "CHG0001" {
+		t.Fatalf("Expected the mutated annotation to be set, got: %v", replicatedPlc.GetAnnotations())
+	}
+
+	if replicatedPlc.GetLabels()[common.RootPolicyLabel] != "policies.policy" {
+		t.Fatalf("Expected the root policy label to be preserved, got: %v", replicatedPlc.GetLabels())
+	}
+
+	err = hook.admit(context.TODO(), "create", rootPlc.DeepCopy(), appsv1.PlacementDecision{ClusterName: "denied"}, rootPlc)
+
+	deniedErr := &propagationDeniedError{}
+	if !errors.As(err, &deniedErr) || deniedErr.message != "change freeze" {
+		t.Fatalf("Expected a denial with the message from the hook, got: %v", err)
+	}
+}
+
+func TestAdmissionHookDisabled(t *testing.T) {
+	var hook *admissionHookClient = newAdmissionHookClient("", time.Second)
+
+	err := hook.admit(context.TODO(), "create", &policiesv1.Policy{}, appsv1.PlacementDecision{}, &policiesv1.Policy{})
+	if err != nil {
+		t.Fatalf("Expected no error when the admission hook is not configured, got: %v", err)
+	}
+}
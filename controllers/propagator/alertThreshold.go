@@ -0,0 +1,74 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+)
+
+// setAlertThresholdCondition sets the AlertThresholdExceeded condition on the root policy based
+// on its alertThreshold and the noncompliant clusters in its status. If the policy has no
+// alertThreshold, the condition is removed. It returns true when the threshold has just been
+// exceeded so that the caller can emit an event only on the transition.
+func setAlertThresholdCondition(instance *policiesv1.Policy) bool {
+	if instance.Spec.AlertThreshold == nil {
+		meta.RemoveStatusCondition(&instance.Status.Conditions, policiesv1.AlertThresholdExceeded)
+
+		return false
+	}
+
+	total := 0
+	noncompliant := 0
+
+	for _, cpcs := range instance.Status.Status {
+		// The incompatible clusters don't have the policy
+		if cpcs.Reason == reasonClusterIncompatible {
+			continue
+		}
+
+		total++
+
+		if cpcs.ComplianceState == policiesv1.NonCompliant {
+			noncompliant++
+		}
+	}
+
+	condition := metav1.Condition{
+		Type:               policiesv1.AlertThresholdExceeded,
+		ObservedGeneration: instance.GetGeneration(),
+	}
+
+	threshold, err := intstr.GetScaledValueFromIntOrPercent(instance.Spec.AlertThreshold, total, true)
+	if err != nil {
+		condition.Status = metav1.ConditionUnknown
+		condition.Reason = "InvalidAlertThreshold"
+		condition.Message = err.Error()
+		meta.SetStatusCondition(&instance.Status.Conditions, condition)
+
+		return false
+	}
+
+	wasExceeded := meta.IsStatusConditionTrue(instance.Status.Conditions, policiesv1.AlertThresholdExceeded)
+
+	condition.Message = fmt.Sprintf(
+		"%d of %d clusters are noncompliant and the alert threshold is %d", noncompliant, total, threshold,
+	)
+
+	if noncompliant > threshold {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "ThresholdExceeded"
+	} else {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "WithinThreshold"
+	}
+
+	meta.SetStatusCondition(&instance.Status.Conditions, condition)
+
+	return condition.Status == metav1.ConditionTrue && !wasExceeded
+}
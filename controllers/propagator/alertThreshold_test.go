@@ -0,0 +1,70 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+)
+
+func TestSetAlertThresholdCondition(t *testing.T) {
+	status := []*policiesv1.CompliancePerClusterStatus{
+		{ClusterName: "c1", ComplianceState: policiesv1.NonCompliant},
+		{ClusterName: "c2", ComplianceState: policiesv1.NonCompliant},
+		{ClusterName: "c3", ComplianceState: policiesv1.Compliant},
+		{ClusterName: "c4", ComplianceState: policiesv1.Compliant},
+	}
+
+	tests := []struct {
+		name            string
+		threshold       intstr.IntOrString
+		expectedStatus  metav1.ConditionStatus
+		expectedChanged bool
+	}{
+		{"count exceeded", intstr.FromInt(1), metav1.ConditionTrue, true},
+		{"count not exceeded", intstr.FromInt(2), metav1.ConditionFalse, false},
+		{"percentage exceeded", intstr.FromString("25%"), metav1.ConditionTrue, true},
+		{"percentage not exceeded", intstr.FromString("50%"), metav1.ConditionFalse, false},
+		{"invalid", intstr.FromString("half"), metav1.ConditionUnknown, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			threshold := test.threshold
+			policy := &policiesv1.Policy{
+				Spec:   policiesv1.PolicySpec{AlertThreshold: &threshold},
+				Status: policiesv1.PolicyStatus{Status: status},
+			}
+
+			changed := setAlertThresholdCondition(policy)
+			if changed != test.expectedChanged {
+				t.Fatalf("Expected the transition to be %v, got %v", test.expectedChanged, changed)
+			}
+
+			condition := meta.FindStatusCondition(policy.Status.Conditions, policiesv1.AlertThresholdExceeded)
+			if condition == nil || condition.Status != test.expectedStatus {
+				t.Fatalf("Expected the condition status %s, got %v", test.expectedStatus, condition)
+			}
+
+			// Evaluating again must not report another transition
+			if setAlertThresholdCondition(policy) {
+				t.Fatal("Expected no transition when the condition is already set")
+			}
+		})
+	}
+
+	policy := &policiesv1.Policy{Status: policiesv1.PolicyStatus{
+		Status:     status,
+		Conditions: []metav1.Condition{{Type: policiesv1.AlertThresholdExceeded, Status: metav1.ConditionTrue}},
+	}}
+	setAlertThresholdCondition(policy)
+
+	if len(policy.Status.Conditions) != 0 {
+		t.Fatal("Expected the condition to be removed when the alertThreshold is unset")
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+// bindsPolicy returns whether one of the subjects of the PlacementBinding matches the policy
+func bindsPolicy(pb policiesv1.PlacementBinding, instance *policiesv1.Policy) bool {
+	for _, subject := range pb.Subjects {
+		if common.SubjectMatchesPolicy(subject, instance) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// uniqueDecisions returns the placement decisions without the clusters selected more than once
+func uniqueDecisions(decisions []appsv1.PlacementDecision) []appsv1.PlacementDecision {
+	seen := map[string]bool{}
+	unique := make([]appsv1.PlacementDecision, 0, len(decisions))
+
+	for _, decision := range decisions {
+		if seen[decision.ClusterName] {
+			continue
+		}
+
+		seen[decision.ClusterName] = true
+		unique = append(unique, decision)
+	}
+
+	return unique
+}
+
+// restrictDecisionGroups removes the clusters that aren't selected from the decision groups of the
+// placements of the restricted bindings, since the restricted bindings don't apply to them
+func restrictDecisionGroups(placements []*policiesv1.Placement, selected []appsv1.PlacementDecision) {
+	selectedClusters := map[string]bool{}
+	for _, decision := range selected {
+		selectedClusters[decision.ClusterName] = true
+	}
+
+	for _, placement := range placements {
+		for i := range placement.DecisionGroups {
+			var clusters []string
+
+			for _, cluster := range placement.DecisionGroups[i].Clusters {
+				if selectedClusters[cluster] {
+					clusters = append(clusters, cluster)
+				}
+			}
+
+			placement.DecisionGroups[i].Clusters = clusters
+		}
+	}
+}
+
+// bindingRemediationAction returns the remediation action set by the bindingPolicyOverrides of
+// the PlacementBinding binding the policy, or by the bindingOverrides of its subjects matching the
+// policy, or an empty string when there is none
+func bindingRemediationAction(
+	pb policiesv1.PlacementBinding, instance *policiesv1.Policy,
+) policiesv1.RemediationAction {
+	if pb.BindingPolicyOverrides != nil && bindsPolicy(pb, instance) &&
+		strings.EqualFold(pb.BindingPolicyOverrides.RemediationAction, string(policiesv1.Enforce)) {
+		return policiesv1.Enforce
+	}
+
+	for _, subject := range pb.Subjects {
+		if subject.BindingOverrides == nil || !common.SubjectMatchesPolicy(subject, instance) {
+			continue
+		}
+
+		if strings.EqualFold(subject.BindingOverrides.RemediationAction, string(policiesv1.Enforce)) {
+			return policiesv1.Enforce
+		}
+	}
+
+	return ""
+}
+
+// remediationOverride returns the remediation action set on the replicated policy of the cluster
+// by the bindingOverrides of the PlacementBindings selecting it, or an empty string when there is
+// none
+func (r *PolicyReconciler) remediationOverride(
+	ctx context.Context, instance *policiesv1.Policy, clusterName string,
+) (policiesv1.RemediationAction, error) {
+	overrides, err := r.remediationOverrides(ctx, instance)
+	if err != nil {
+		return "", err
+	}
+
+	return overrides[clusterName], nil
+}
+
+// remediationOverrides returns the remediation actions set on the replicated policies by the
+// bindingOverrides of the PlacementBindings of the policy, by cluster name. The placement decisions
+// are only fetched for the PlacementBindings with overrides.
+func (r *PolicyReconciler) remediationOverrides(
+	ctx context.Context, instance *policiesv1.Policy,
+) (map[string]policiesv1.RemediationAction, error) {
+	pbList := &policiesv1.PlacementBindingList{}
+
+	err := r.List(ctx, pbList, client.InNamespace(instance.GetNamespace()))
+	if err != nil {
+		return nil, err
+	}
+
+	overrides := map[string]policiesv1.RemediationAction{}
+
+	for _, pb := range pbList.Items {
+		remediationAction := bindingRemediationAction(pb, instance)
+		if remediationAction == "" {
+			continue
+		}
+
+		decisions, _, err := r.getPlacementDecisions(ctx, pb, instance)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, decision := range decisions {
+			if _, ok := overrides[decision.ClusterName]; !ok {
+				overrides[decision.ClusterName] = remediationAction
+			}
+		}
+	}
+
+	return overrides, nil
+}
@@ -0,0 +1,215 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+func newTestPlacementRule(name string, clusters ...string) *appsv1.PlacementRule {
+	plr := &appsv1.PlacementRule{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "policies"}}
+
+	for _, cluster := range clusters {
+		plr.Status.Decisions = append(
+			plr.Status.Decisions, appsv1.PlacementDecision{ClusterName: cluster, ClusterNamespace: cluster},
+		)
+	}
+
+	return plr
+}
+
+func newTestPlacementBinding(name string, plr string, subjects ...policiesv1.Subject) *policiesv1.PlacementBinding {
+	return &policiesv1.PlacementBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "policies"},
+		PlacementRef: policiesv1.Subject{
+			APIGroup: appsv1.SchemeGroupVersion.Group, Kind: "PlacementRule", Name: plr,
+		},
+		Subjects: subjects,
+	}
+}
+
+func TestHandleRootPolicyMultipleBindings(t *testing.T) {
+	root := newTestPolicy("default")
+	root.SetLabels(map[string]string{"team": "a"})
+	root.Spec.RemediationAction = policiesv1.Inform
+
+	// Both subjects of the first binding match the policy
+	pb1 := newTestPlacementBinding("pb1", "plr1",
+		policiesv1.Subject{APIGroup: policiesv1.SchemeGroupVersion.Group, Kind: policiesv1.Kind, Name: "policy"},
+		policiesv1.Subject{
+			APIGroup: policiesv1.SchemeGroupVersion.Group, Kind: policiesv1.Kind,
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+		},
+	)
+	pb2 := newTestPlacementBinding("pb2", "plr2", policiesv1.Subject{
+		APIGroup: policiesv1.SchemeGroupVersion.Group, Kind: policiesv1.Kind, Name: "policy",
+		BindingOverrides: &policiesv1.BindingOverrides{RemediationAction: "enforce"},
+	})
+
+	r := newTestReconciler(t, &stubResolver{}, root, pb1, pb2,
+		newTestPlacementRule("plr1", "cluster1", "cluster2"), newTestPlacementRule("plr2", "cluster2", "cluster3"))
+
+	if err := r.handleRootPolicy(context.TODO(), root, nil); err != nil {
+		t.Fatalf("handleRootPolicy returned an error: %v", err)
+	}
+
+	expected := map[string]policiesv1.RemediationAction{
+		"cluster1": policiesv1.Inform,
+		"cluster2": policiesv1.Enforce,
+		"cluster3": policiesv1.Enforce,
+	}
+
+	for cluster, remediationAction := range expected {
+		replicatedPlc := &policiesv1.Policy{}
+
+		err := r.Get(context.TODO(), types.NamespacedName{Namespace: cluster, Name: "policies.policy"}, replicatedPlc)
+		if err != nil {
+			t.Fatalf("failed to get the replicated policy of %s: %v", cluster, err)
+		}
+
+		if replicatedPlc.Spec.RemediationAction != remediationAction {
+			t.Fatalf("expected %s to be %s, got %s", cluster, remediationAction, replicatedPlc.Spec.RemediationAction)
+		}
+	}
+
+	updated := &policiesv1.Policy{}
+	if err := r.Get(context.TODO(), types.NamespacedName{Namespace: "policies", Name: "policy"}, updated); err != nil {
+		t.Fatalf("failed to get the root policy: %v", err)
+	}
+
+	if len(updated.Status.Placement) != 2 {
+		t.Fatalf("expected a placement per binding, got %d", len(updated.Status.Placement))
+	}
+
+	if len(updated.Status.Status) != 3 {
+		t.Fatalf("expected every cluster to be listed once, got %d", len(updated.Status.Status))
+	}
+}
+
+func TestUniqueDecisions(t *testing.T) {
+	decisions := uniqueDecisions([]appsv1.PlacementDecision{
+		{ClusterName: "cluster1", ClusterNamespace: "cluster1"},
+		{ClusterName: "cluster2", ClusterNamespace: "cluster2"},
+		{ClusterName: "cluster1", ClusterNamespace: "cluster1"},
+	})
+
+	if len(decisions) != 2 || decisions[0].ClusterName != "cluster1" || decisions[1].ClusterName != "cluster2" {
+		t.Fatalf("expected the clusters to be listed once in order, got %v", decisions)
+	}
+}
+
+func TestHandleRootPolicyRestrictedBinding(t *testing.T) {
+	root := newTestPolicy("default")
+	root.Spec.RemediationAction = policiesv1.Inform
+
+	pb1 := newTestPlacementBinding("pb1", "plr1",
+		policiesv1.Subject{APIGroup: policiesv1.SchemeGroupVersion.Group, Kind: policiesv1.Kind, Name: "policy"},
+	)
+	// The restricted binding only enforces the policy on the clusters selected by the first binding
+	pb2 := newTestPlacementBinding("pb2", "plr2", policiesv1.Subject{
+		APIGroup: policiesv1.SchemeGroupVersion.Group, Kind: policiesv1.Kind, Name: "policy",
+		BindingOverrides: &policiesv1.BindingOverrides{RemediationAction: "enforce"},
+	})
+	pb2.SubFilter = policiesv1.Restricted
+
+	r := newTestReconciler(t, &stubResolver{}, root, pb1, pb2,
+		newTestPlacementRule("plr1", "cluster1", "cluster2"), newTestPlacementRule("plr2", "cluster2", "cluster3"))
+
+	if err := r.handleRootPolicy(context.TODO(), root, nil); err != nil {
+		t.Fatalf("handleRootPolicy returned an error: %v", err)
+	}
+
+	expected := map[string]policiesv1.RemediationAction{
+		"cluster1": policiesv1.Inform,
+		"cluster2": policiesv1.Enforce,
+	}
+
+	for cluster, remediationAction := range expected {
+		replicatedPlc := &policiesv1.Policy{}
+
+		err := r.Get(context.TODO(), types.NamespacedName{Namespace: cluster, Name: "policies.policy"}, replicatedPlc)
+		if err != nil {
+			t.Fatalf("failed to get the replicated policy of %s: %v", cluster, err)
+		}
+
+		if replicatedPlc.Spec.RemediationAction != remediationAction {
+			t.Fatalf("expected %s to be %s, got %s", cluster, remediationAction, replicatedPlc.Spec.RemediationAction)
+		}
+	}
+
+	err := r.Get(context.TODO(), types.NamespacedName{Namespace: "cluster3", Name: "policies.policy"}, &policiesv1.Policy{})
+	if !k8serrors.IsNotFound(err) {
+		t.Fatalf("expected the restricted binding not to add cluster3, got %v", err)
+	}
+}
+
+func TestRestrictDecisionGroups(t *testing.T) {
+	placement := &policiesv1.Placement{
+		DecisionGroups: []policiesv1.DecisionGroup{
+			{Name: "canary", Clusters: []string{"cluster1", "cluster3"}},
+			{Name: "rest", Clusters: []string{"cluster4"}},
+		},
+	}
+
+	restrictDecisionGroups([]*policiesv1.Placement{placement}, []appsv1.PlacementDecision{
+		{ClusterName: "cluster1", ClusterNamespace: "cluster1"},
+		{ClusterName: "cluster2", ClusterNamespace: "cluster2"},
+	})
+
+	if !reflect.DeepEqual(placement.DecisionGroups[0].Clusters, []string{"cluster1"}) ||
+		placement.DecisionGroups[1].Clusters != nil {
+		t.Fatalf("expected only the selected clusters in the decision groups, got %v", placement.DecisionGroups)
+	}
+}
+
+func TestBindingPolicyOverrides(t *testing.T) {
+	root := newTestPolicy("default")
+	root.Spec.RemediationAction = policiesv1.Inform
+
+	pb1 := newTestPlacementBinding("pb1", "plr1",
+		policiesv1.Subject{APIGroup: policiesv1.SchemeGroupVersion.Group, Kind: policiesv1.Kind, Name: "policy"},
+	)
+	pb2 := newTestPlacementBinding("pb2", "plr2",
+		policiesv1.Subject{APIGroup: policiesv1.SchemeGroupVersion.Group, Kind: policiesv1.Kind, Name: "policy"},
+	)
+	pb2.BindingPolicyOverrides = &policiesv1.BindingOverrides{RemediationAction: "Enforce"}
+	// The overrides of a binding of other policies don't apply
+	pb3 := newTestPlacementBinding("pb3", "plr1",
+		policiesv1.Subject{APIGroup: policiesv1.SchemeGroupVersion.Group, Kind: policiesv1.Kind, Name: "other"},
+	)
+	pb3.BindingPolicyOverrides = &policiesv1.BindingOverrides{RemediationAction: "enforce"}
+
+	r := newTestReconciler(t, &stubResolver{}, root, pb1, pb2, pb3,
+		newTestPlacementRule("plr1", "cluster1"), newTestPlacementRule("plr2", "cluster2"))
+
+	if err := r.handleRootPolicy(context.TODO(), root, nil); err != nil {
+		t.Fatalf("handleRootPolicy returned an error: %v", err)
+	}
+
+	expected := map[string]policiesv1.RemediationAction{
+		"cluster1": policiesv1.Inform,
+		"cluster2": policiesv1.Enforce,
+	}
+
+	for cluster, remediationAction := range expected {
+		replicatedPlc := &policiesv1.Policy{}
+
+		err := r.Get(context.TODO(), types.NamespacedName{Namespace: cluster, Name: "policies.policy"}, replicatedPlc)
+		if err != nil {
+			t.Fatalf("failed to get the replicated policy of %s: %v", cluster, err)
+		}
+
+		if replicatedPlc.Spec.RemediationAction != remediationAction {
+			t.Fatalf("expected %s to be %s, got %s", cluster, remediationAction, replicatedPlc.Spec.RemediationAction)
+		}
+	}
+}
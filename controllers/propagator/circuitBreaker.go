@@ -0,0 +1,110 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"sync"
+	"time"
+)
+
+// The configuration of the number of consecutive failed replications to a cluster namespace
+// before its circuit is opened and replication to it is paused.
+const circuitBreakerThresholdEnvName = "CONTROLLER_CONFIG_CIRCUIT_BREAKER_THRESHOLD"
+const circuitBreakerThresholdDefault = 3
+
+// The configuration in seconds of the first cool-down of an open circuit. The cool-down doubles
+// every time the circuit opens again, up to circuitBreakerCooldownMax.
+const circuitBreakerCooldownEnvName = "CONTROLLER_CONFIG_CIRCUIT_BREAKER_COOLDOWN"
+const circuitBreakerCooldownDefault = 30
+
+const circuitBreakerCooldownMax = 10 * time.Minute
+
+// circuitState is the replication state of a cluster namespace that recently failed
+type circuitState struct {
+	// failures is the number of consecutive failed replications
+	failures int
+	// opened is the number of consecutive times the circuit was opened, used for the cool-down
+	opened    int
+	openUntil time.Time
+}
+
+// circuitBreaker tracks the failed replications per cluster namespace across all the root
+// policies. After threshold consecutive failures, the circuit of the cluster namespace is opened
+// and the replications to it are skipped until the cool-down elapses. Then a single attempt is
+// let through, and a failure opens the circuit again with twice the cool-down.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	lock      sync.Mutex
+	circuits  map[string]*circuitState
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown, circuits: map[string]*circuitState{}}
+}
+
+// openUntil returns when the circuit of the cluster namespace closes again, or the zero time if the
+// circuit is not open at the given time
+func (c *circuitBreaker) openUntil(namespace string, now time.Time) time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	state, ok := c.circuits[namespace]
+	if !ok || !now.Before(state.openUntil) {
+		return time.Time{}
+	}
+
+	return state.openUntil
+}
+
+// halfOpen returns whether the circuit of the cluster namespace was opened before and its cool-down
+// elapsed, in which case a single replication attempt should be made
+func (c *circuitBreaker) halfOpen(namespace string) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	state, ok := c.circuits[namespace]
+
+	return ok && state.opened > 0
+}
+
+// recordFailure records a failed replication to the cluster namespace and returns when the circuit
+// closes again if this failure opened it
+func (c *circuitBreaker) recordFailure(namespace string, now time.Time) (openUntil time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	state, ok := c.circuits[namespace]
+	if !ok {
+		state = &circuitState{}
+		c.circuits[namespace] = state
+	}
+
+	state.failures++
+	if state.failures < c.threshold {
+		return time.Time{}
+	}
+
+	cooldown := c.cooldown << state.opened
+	if cooldown > circuitBreakerCooldownMax || cooldown <= 0 {
+		cooldown = circuitBreakerCooldownMax
+	}
+
+	// Don't let a misconfigured cool-down longer than the maximum be shortened
+	if c.cooldown > cooldown {
+		cooldown = c.cooldown
+	}
+
+	state.opened++
+	state.openUntil = now.Add(cooldown)
+
+	return state.openUntil
+}
+
+// recordSuccess closes the circuit of the cluster namespace and resets its failures
+func (c *circuitBreaker) recordSuccess(namespace string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	delete(c.circuits, namespace)
+}
@@ -0,0 +1,153 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	breaker := newCircuitBreaker(2, time.Minute)
+	now := time.Now()
+
+	if openUntil := breaker.recordFailure("cluster1", now); !openUntil.IsZero() {
+		t.Fatalf("expected the circuit to stay closed below the threshold, got open until %v", openUntil)
+	}
+
+	if openUntil := breaker.recordFailure("cluster1", now); !openUntil.Equal(now.Add(time.Minute)) {
+		t.Fatalf("expected the circuit to open for a minute, got open until %v", openUntil)
+	}
+
+	if breaker.openUntil("cluster1", now.Add(30*time.Second)).IsZero() {
+		t.Fatal("expected the circuit to be open during the cool-down")
+	}
+
+	if !breaker.openUntil("cluster2", now).IsZero() {
+		t.Fatal("expected the circuit of another cluster namespace to be closed")
+	}
+
+	later := now.Add(time.Minute)
+	if !breaker.openUntil("cluster1", later).IsZero() || !breaker.halfOpen("cluster1") {
+		t.Fatal("expected the circuit to be half-open after the cool-down")
+	}
+
+	if openUntil := breaker.recordFailure("cluster1", later); !openUntil.Equal(later.Add(2 * time.Minute)) {
+		t.Fatalf("expected the cool-down to double, got open until %v", openUntil)
+	}
+
+	for i := 0; i < 10; i++ {
+		breaker.recordFailure("cluster1", later)
+	}
+
+	if openUntil := breaker.recordFailure("cluster1", later); !openUntil.Equal(later.Add(circuitBreakerCooldownMax)) {
+		t.Fatalf("expected the cool-down to be capped, got open until %v", openUntil)
+	}
+
+	breaker.recordSuccess("cluster1")
+
+	if !breaker.openUntil("cluster1", later).IsZero() || breaker.halfOpen("cluster1") {
+		t.Fatal("expected the circuit to be closed after a success")
+	}
+}
+
+// failingNamespaceClient fails the patches of the objects in a namespace and counts the attempts
+type failingNamespaceClient struct {
+	client.Client
+	namespace string
+	attempts  int
+}
+
+func (c *failingNamespaceClient) Patch(
+	ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption,
+) error {
+	if obj.GetNamespace() == c.namespace {
+		c.attempts++
+
+		return errors.New("exceeded quota")
+	}
+
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func TestHandleRootPolicyCircuitOpen(t *testing.T) {
+	root := newTestPolicy("default")
+	plr := &appsv1.PlacementRule{
+		ObjectMeta: metav1.ObjectMeta{Name: "plr", Namespace: "policies"},
+		Status: appsv1.PlacementRuleStatus{
+			Decisions: []appsv1.PlacementDecision{
+				{ClusterName: "cluster1", ClusterNamespace: "cluster1"},
+				{ClusterName: "broken", ClusterNamespace: "broken"},
+			},
+		},
+	}
+	pb := &policiesv1.PlacementBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "pb", Namespace: "policies"},
+		PlacementRef: policiesv1.Subject{
+			APIGroup: appsv1.SchemeGroupVersion.Group, Kind: "PlacementRule", Name: "plr",
+		},
+		Subjects: []policiesv1.Subject{
+			{APIGroup: policiesv1.SchemeGroupVersion.Group, Kind: policiesv1.Kind, Name: "policy"},
+		},
+	}
+
+	r := newTestReconciler(t, &stubResolver{}, root, plr, pb)
+	failingClient := &failingNamespaceClient{Client: r.Client, namespace: "broken"}
+	r.Client = failingClient
+	r.clusterCircuits = newCircuitBreaker(1, time.Minute)
+
+	brokenStatus := func() *policiesv1.CompliancePerClusterStatus {
+		updatedRoot := &policiesv1.Policy{}
+
+		err := r.Get(context.TODO(), types.NamespacedName{Namespace: "policies", Name: "policy"}, updatedRoot)
+		if err != nil {
+			t.Fatalf("failed to get the root policy: %v", err)
+		}
+
+		for _, cpcs := range updatedRoot.Status.Status {
+			if cpcs.ClusterNamespace == "broken" {
+				return cpcs
+			}
+		}
+
+		t.Fatalf("expected the broken cluster in the root policy status, got %v", updatedRoot.Status.Status)
+
+		return nil
+	}
+
+	if err := r.handleRootPolicy(context.TODO(), root, nil); err != nil {
+		t.Fatalf("handleRootPolicy returned an error: %v", err)
+	}
+
+	if reason := brokenStatus().Reason; reason != reasonReplicationFailed {
+		t.Fatalf("expected the reason %s, got %s", reasonReplicationFailed, reason)
+	}
+
+	attempts := failingClient.attempts
+
+	if err := r.handleRootPolicy(context.TODO(), root, nil); err != nil {
+		t.Fatalf("handleRootPolicy returned an error: %v", err)
+	}
+
+	if cpcs := brokenStatus(); cpcs.Reason != reasonCircuitOpen || cpcs.Message == "" {
+		t.Fatalf("expected the reason %s with a message, got %+v", reasonCircuitOpen, cpcs)
+	}
+
+	if failingClient.attempts != attempts {
+		t.Fatalf("expected no replication attempts while the circuit is open, got %d", failingClient.attempts-attempts)
+	}
+
+	err := r.Get(context.TODO(), types.NamespacedName{Namespace: "cluster1", Name: "policies.policy"}, &policiesv1.Policy{})
+	if err != nil {
+		t.Fatalf("failed to get the replicated policy on the healthy cluster: %v", err)
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+
+	clusterv1 "github.com/open-cluster-management/api/cluster/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+)
+
+// clusterUnavailable returns why the policy with skipUnavailableClusters is not replicated to the
+// cluster, or nil if the cluster is available. The policy is replicated again by the ManagedCluster
+// watch once the cluster is available, and a missing ManagedCluster is left to the other checks.
+func (r *PolicyReconciler) clusterUnavailable(
+	ctx context.Context, instance *policiesv1.Policy, clusterName string,
+) (*replicationFailure, error) {
+	if !instance.Spec.SkipUnavailableClusters {
+		return nil, nil
+	}
+
+	cluster := &clusterv1.ManagedCluster{}
+
+	err := r.Get(ctx, types.NamespacedName{Name: clusterName}, cluster)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	if clusterAvailable(cluster) {
+		return nil, nil
+	}
+
+	return &replicationFailure{
+		reason: reasonClusterUnavailable,
+		message: "The cluster is unavailable, the replication is paused until the ManagedCluster " +
+			clusterName + " is available again",
+	}, nil
+}
+
+// clusterAvailable returns whether the ManagedCluster doesn't report that it is unavailable. The
+// clusters that didn't report their availability yet, such as the ones just imported, are
+// considered available.
+func clusterAvailable(cluster *clusterv1.ManagedCluster) bool {
+	condition := meta.FindStatusCondition(cluster.Status.Conditions, clusterv1.ManagedClusterConditionAvailable)
+
+	return condition == nil || condition.Status == metav1.ConditionTrue
+}
@@ -0,0 +1,124 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"testing"
+
+	clusterv1 "github.com/open-cluster-management/api/cluster/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+)
+
+func newTestAvailableCluster(name string, available metav1.ConditionStatus) *clusterv1.ManagedCluster {
+	cluster := newTestManagedCluster(name, "v1.21.3")
+	cluster.Status.Conditions = []metav1.Condition{{
+		Type: clusterv1.ManagedClusterConditionAvailable, Status: available, Reason: "Test",
+	}}
+
+	return cluster
+}
+
+func TestClusterUnavailable(t *testing.T) {
+	r := newTestReconciler(
+		t, &stubResolver{},
+		newTestManagedCluster("unknown", "v1.21.3"),
+		newTestAvailableCluster("available", metav1.ConditionTrue),
+		newTestAvailableCluster("offline", metav1.ConditionFalse),
+		newTestAvailableCluster("lost", metav1.ConditionUnknown),
+	)
+
+	root := newTestPolicy("default")
+
+	failure, err := r.clusterUnavailable(context.TODO(), root, "offline")
+	if err != nil || failure != nil {
+		t.Fatalf("expected the availability to be ignored without skipUnavailableClusters, got %v, %v", failure, err)
+	}
+
+	root.Spec.SkipUnavailableClusters = true
+
+	tests := map[string]bool{"unknown": false, "available": false, "offline": true, "lost": true, "missing": false}
+
+	for cluster, expected := range tests {
+		failure, err := r.clusterUnavailable(context.TODO(), root, cluster)
+		if err != nil {
+			t.Fatalf("clusterUnavailable returned an error: %v", err)
+		}
+
+		if (failure != nil) != expected {
+			t.Fatalf("expected the cluster %s to be unavailable %t, got %v", cluster, expected, failure)
+		}
+
+		if failure != nil && (failure.reason != reasonClusterUnavailable || failure.isFailure()) {
+			t.Fatalf("expected the ClusterUnavailable reason that is not a failure, got %v", failure)
+		}
+	}
+}
+
+func TestHandleRootPolicyUnavailableCluster(t *testing.T) {
+	root := newTestPolicy("default")
+	root.Spec.SkipUnavailableClusters = true
+
+	plr := newTestPlacementRule("plr", "cluster1", "cluster2")
+	pb := newTestPlacementBinding("pb", "plr", policiesv1.Subject{
+		APIGroup: policiesv1.SchemeGroupVersion.Group, Kind: policiesv1.Kind, Name: "policy",
+	})
+	offline := newTestAvailableCluster("cluster2", metav1.ConditionFalse)
+
+	r := newTestReconciler(
+		t, &stubResolver{}, root, plr, pb, offline,
+		newTestAvailableCluster("cluster1", metav1.ConditionTrue),
+		newTestReplicatedPolicy(root, "cluster2", policiesv1.NonCompliant),
+	)
+
+	clusterStatus := func(cluster string) *policiesv1.CompliancePerClusterStatus {
+		t.Helper()
+
+		if err := r.handleRootPolicy(context.TODO(), root, nil); err != nil {
+			t.Fatalf("handleRootPolicy returned an error: %v", err)
+		}
+
+		if err := r.Get(context.TODO(), types.NamespacedName{Namespace: "policies", Name: "policy"}, root); err != nil {
+			t.Fatalf("failed to get the root policy: %v", err)
+		}
+
+		for _, cpcs := range root.Status.Status {
+			if cpcs.ClusterName == cluster {
+				return cpcs
+			}
+		}
+
+		t.Fatalf("expected the status of the cluster %s, got %+v", cluster, root.Status.Status)
+
+		return nil
+	}
+
+	cpcs := clusterStatus("cluster2")
+	if cpcs.ComplianceState != policiesv1.Pending || cpcs.Reason != reasonClusterUnavailable {
+		t.Fatalf("expected the unavailable cluster to be Pending, got %+v", cpcs)
+	}
+
+	if cpcs := clusterStatus("cluster1"); cpcs.ComplianceState != "" || cpcs.Reason != "" {
+		t.Fatalf("expected the available cluster to be replicated to, got %+v", cpcs)
+	}
+
+	// The existing replicated policy is kept while the cluster is unavailable
+	replicated := &policiesv1.Policy{}
+
+	err := r.Get(context.TODO(), types.NamespacedName{Namespace: "cluster2", Name: "policies.policy"}, replicated)
+	if err != nil {
+		t.Fatalf("expected the replicated policy on the unavailable cluster to be kept, got the error: %v", err)
+	}
+
+	offline.Status.Conditions[0].Status = metav1.ConditionTrue
+	if err := r.Update(context.TODO(), offline); err != nil {
+		t.Fatalf("failed to update the ManagedCluster: %v", err)
+	}
+
+	if cpcs := clusterStatus("cluster2"); cpcs.ComplianceState != policiesv1.NonCompliant || cpcs.Reason != "" {
+		t.Fatalf("expected the cluster to report its compliance once it is available, got %+v", cpcs)
+	}
+}
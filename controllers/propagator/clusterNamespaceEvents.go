@@ -0,0 +1,31 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// The configuration to also record the lifecycle events of the replicated policies in their
+// cluster namespaces, for the users that can't read the namespaces of the root policies
+const clusterNamespaceEventsEnvName = "CONTROLLER_CONFIG_CLUSTER_NAMESPACE_EVENTS"
+
+// The actions on the replicated policies recorded in the cluster namespaces
+const (
+	replicatedPolicyCreated = "created"
+	replicatedPolicyUpdated = "updated"
+	replicatedPolicyDeleted = "deleted"
+)
+
+// recordClusterNamespaceEvent records the action on the replicated policy in its cluster namespace
+// when enabled. rootName is the namespace/name of the root policy.
+func (r *PolicyReconciler) recordClusterNamespaceEvent(replicatedPlc client.Object, rootName string, action string) {
+	if !r.clusterNamespaceEvents {
+		return
+	}
+
+	r.Recorder.Event(replicatedPlc, "Normal", "PolicyPropagation",
+		fmt.Sprintf("The replicated policy was %s by the propagation of the root policy %s", action, rootName))
+}
@@ -0,0 +1,61 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"testing"
+
+	policyv1beta1 "github.com/open-cluster-management/governance-policy-propagator/api/v1beta1"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+func TestClusterNamespaceEvents(t *testing.T) {
+	decision := appsv1.PlacementDecision{ClusterName: "cluster1", ClusterNamespace: "cluster1"}
+
+	for _, enabled := range []bool{false, true} {
+		root := newTestPolicy("default")
+		r := newTestReconciler(t, &stubResolver{}, root)
+		r.clusterNamespaceEvents = enabled
+
+		if _, err := r.handleDecision(context.TODO(), root, decision, policyv1beta1.PropagationConfigSpec{}); err != nil {
+			t.Fatalf("handleDecision returned an error: %v", err)
+		}
+
+		if err := r.cleanUpPolicy(context.TODO(), root); err != nil {
+			t.Fatalf("cleanUpPolicy returned an error: %v", err)
+		}
+
+		expected := []string{
+			"Normal PolicyPropagation Policy policies/policy was propagated to cluster cluster1/cluster1",
+		}
+		if enabled {
+			expected = []string{
+				"Normal PolicyPropagation The replicated policy was created by the propagation of the root " +
+					"policy policies/policy",
+				expected[0],
+				"Normal PolicyPropagation The replicated policy was deleted by the propagation of the root " +
+					"policy policies/policy",
+			}
+		}
+
+		actual := events(r)
+		if len(actual) != len(expected) {
+			t.Fatalf("expected the events %v, got %v", expected, actual)
+		}
+
+		for _, event := range expected {
+			found := false
+
+			for _, actualEvent := range actual {
+				if actualEvent == event {
+					found = true
+				}
+			}
+
+			if !found {
+				t.Fatalf("expected the event %q, got %v", event, actual)
+			}
+		}
+	}
+}
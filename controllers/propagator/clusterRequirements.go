@@ -0,0 +1,101 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"fmt"
+
+	clusterv1 "github.com/open-cluster-management/api/cluster/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	utilversion "k8s.io/apimachinery/pkg/util/version"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+)
+
+// clusterIncompatibility returns why the managed cluster doesn't meet the policy's cluster
+// requirements, or an empty string if it does
+func (r *PolicyReconciler) clusterIncompatibility(
+	ctx context.Context, requirements *policiesv1.ClusterRequirements, clusterName string,
+) (string, error) {
+	if requirements == nil {
+		return "", nil
+	}
+
+	cluster := &clusterv1.ManagedCluster{}
+
+	err := r.Get(ctx, types.NamespacedName{Name: clusterName}, cluster)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return fmt.Sprintf("The ManagedCluster %s was not found to check the cluster requirements", clusterName),
+				nil
+		}
+
+		return "", err
+	}
+
+	return unmetClusterRequirement(requirements, cluster), nil
+}
+
+// unmetClusterRequirement returns a message describing the first cluster requirement that the
+// ManagedCluster doesn't meet, or an empty string if all of them are met
+func unmetClusterRequirement(requirements *policiesv1.ClusterRequirements, cluster *clusterv1.ManagedCluster) string {
+	if requirements.MinKubernetesVersion != "" || requirements.MaxKubernetesVersion != "" {
+		kubeVersion := cluster.Status.Version.Kubernetes
+
+		clusterVersion, err := utilversion.ParseGeneric(kubeVersion)
+		if err != nil {
+			return fmt.Sprintf("The Kubernetes version %q of the cluster is unknown", kubeVersion)
+		}
+
+		if requirements.MinKubernetesVersion != "" {
+			minVersion, err := utilversion.ParseGeneric(requirements.MinKubernetesVersion)
+			if err != nil {
+				return fmt.Sprintf("The minimum Kubernetes version %q is invalid", requirements.MinKubernetesVersion)
+			}
+
+			if !clusterVersion.AtLeast(minVersion) {
+				return fmt.Sprintf(
+					"The Kubernetes version %s of the cluster is older than the minimum version %s",
+					kubeVersion, requirements.MinKubernetesVersion,
+				)
+			}
+		}
+
+		if requirements.MaxKubernetesVersion != "" {
+			maxVersion, err := utilversion.ParseGeneric(requirements.MaxKubernetesVersion)
+			if err != nil {
+				return fmt.Sprintf("The maximum Kubernetes version %q is invalid", requirements.MaxKubernetesVersion)
+			}
+
+			if !clusterVersion.LessThan(maxVersion) {
+				return fmt.Sprintf(
+					"The Kubernetes version %s of the cluster is not older than the maximum version %s",
+					kubeVersion, requirements.MaxKubernetesVersion,
+				)
+			}
+		}
+	}
+
+	claims := map[string]string{}
+	for _, claim := range cluster.Status.ClusterClaims {
+		claims[claim.Name] = claim.Value
+	}
+
+	for _, required := range requirements.ClusterClaims {
+		value, ok := claims[required.Name]
+		if !ok {
+			return fmt.Sprintf("The cluster does not have the required ClusterClaim %s", required.Name)
+		}
+
+		if required.Value != "" && value != required.Value {
+			return fmt.Sprintf(
+				"The ClusterClaim %s of the cluster is %q instead of the required %q", required.Name, value,
+				required.Value,
+			)
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,137 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	clusterv1 "github.com/open-cluster-management/api/cluster/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+func newTestManagedCluster(name string, kubeVersion string, claims ...clusterv1.ManagedClusterClaim) *clusterv1.ManagedCluster {
+	return &clusterv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: clusterv1.ManagedClusterStatus{
+			Version:       clusterv1.ManagedClusterVersion{Kubernetes: kubeVersion},
+			ClusterClaims: claims,
+		},
+	}
+}
+
+func TestUnmetClusterRequirement(t *testing.T) {
+	cluster := newTestManagedCluster("cluster1", "v1.21.3+k3s1",
+		clusterv1.ManagedClusterClaim{Name: "platform.open-cluster-management.io", Value: "AWS"},
+		clusterv1.ManagedClusterClaim{Name: "gatekeeper.crds"},
+	)
+
+	tests := []struct {
+		name         string
+		requirements policiesv1.ClusterRequirements
+		expected     string
+	}{
+		{"no requirements", policiesv1.ClusterRequirements{}, ""},
+		{"version in range", policiesv1.ClusterRequirements{
+			MinKubernetesVersion: "v1.20", MaxKubernetesVersion: "1.22",
+		}, ""},
+		{"version too old", policiesv1.ClusterRequirements{MinKubernetesVersion: "v1.22"}, "older than the minimum"},
+		{"version too new", policiesv1.ClusterRequirements{MaxKubernetesVersion: "v1.21"}, "not older than the maximum"},
+		{"claims met", policiesv1.ClusterRequirements{ClusterClaims: []policiesv1.ClusterClaimRequirement{
+			{Name: "platform.open-cluster-management.io", Value: "AWS"}, {Name: "gatekeeper.crds"},
+		}}, ""},
+		{"claim missing", policiesv1.ClusterRequirements{ClusterClaims: []policiesv1.ClusterClaimRequirement{
+			{Name: "kyverno.crds"},
+		}}, "does not have the required ClusterClaim kyverno.crds"},
+		{"claim value mismatch", policiesv1.ClusterRequirements{ClusterClaims: []policiesv1.ClusterClaimRequirement{
+			{Name: "platform.open-cluster-management.io", Value: "GCP"},
+		}}, `is "AWS" instead of the required "GCP"`},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			message := unmetClusterRequirement(&test.requirements, cluster)
+			if test.expected == "" && message != "" {
+				t.Fatalf("expected the requirements to be met, got: %s", message)
+			}
+
+			if !strings.Contains(message, test.expected) {
+				t.Fatalf("expected the message to contain %q, got %q", test.expected, message)
+			}
+		})
+	}
+
+	unknown := newTestManagedCluster("cluster2", "")
+	if message := unmetClusterRequirement(
+		&policiesv1.ClusterRequirements{MinKubernetesVersion: "v1.20"}, unknown,
+	); !strings.Contains(message, "unknown") {
+		t.Fatalf("expected the unknown version to not meet the requirements, got %q", message)
+	}
+}
+
+func TestHandleRootPolicyClusterIncompatible(t *testing.T) {
+	root := newTestPolicy("default")
+	root.Spec.ClusterRequirements = &policiesv1.ClusterRequirements{MinKubernetesVersion: "v1.20"}
+
+	plr := &appsv1.PlacementRule{
+		ObjectMeta: metav1.ObjectMeta{Name: "plr", Namespace: "policies"},
+		Status: appsv1.PlacementRuleStatus{
+			Decisions: []appsv1.PlacementDecision{
+				{ClusterName: "cluster1", ClusterNamespace: "cluster1"},
+				{ClusterName: "old", ClusterNamespace: "old"},
+			},
+		},
+	}
+	pb := &policiesv1.PlacementBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "pb", Namespace: "policies"},
+		PlacementRef: policiesv1.Subject{
+			APIGroup: appsv1.SchemeGroupVersion.Group, Kind: "PlacementRule", Name: "plr",
+		},
+		Subjects: []policiesv1.Subject{
+			{APIGroup: policiesv1.SchemeGroupVersion.Group, Kind: policiesv1.Kind, Name: "policy"},
+		},
+	}
+
+	r := newTestReconciler(
+		t, &stubResolver{}, root, plr, pb,
+		newTestManagedCluster("cluster1", "v1.21.3"), newTestManagedCluster("old", "v1.18.0"),
+	)
+
+	if err := r.handleRootPolicy(context.TODO(), root, nil); err != nil {
+		t.Fatalf("handleRootPolicy returned an error: %v", err)
+	}
+
+	err := r.Get(context.TODO(), types.NamespacedName{Namespace: "old", Name: "policies.policy"}, &policiesv1.Policy{})
+	if !k8serrors.IsNotFound(err) {
+		t.Fatalf("expected no replicated policy on the incompatible cluster, got the error: %v", err)
+	}
+
+	updatedRoot := &policiesv1.Policy{}
+
+	err = r.Get(context.TODO(), types.NamespacedName{Namespace: "policies", Name: "policy"}, updatedRoot)
+	if err != nil {
+		t.Fatalf("failed to get the root policy: %v", err)
+	}
+
+	for _, cpcs := range updatedRoot.Status.Status {
+		if cpcs.ClusterName != "old" {
+			continue
+		}
+
+		if cpcs.Reason != reasonClusterIncompatible || cpcs.ComplianceState != "" || cpcs.Message == "" {
+			t.Fatalf("expected the old cluster to be incompatible without a compliance state, got %+v", cpcs)
+		}
+
+		return
+	}
+
+	t.Fatalf("expected the incompatible cluster in the root policy status, got %v", updatedRoot.Status.Status)
+}
@@ -0,0 +1,48 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+
+	clusterv1 "github.com/open-cluster-management/api/cluster/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+)
+
+// policyClusterSelector returns the selector of the policy's cluster selector, or nil if the
+// policy doesn't restrict the clusters selected by its placements
+func policyClusterSelector(instance *policiesv1.Policy) (labels.Selector, error) {
+	if instance.Spec.ClusterSelector == nil {
+		return nil, nil
+	}
+
+	return metav1.LabelSelectorAsSelector(instance.Spec.ClusterSelector)
+}
+
+// clusterSelected returns whether the labels of the managed cluster match the selector. A
+// ManagedCluster that doesn't exist has no labels to match.
+func (r *PolicyReconciler) clusterSelected(
+	ctx context.Context, selector labels.Selector, clusterName string,
+) (bool, error) {
+	if selector == nil {
+		return true, nil
+	}
+
+	cluster := &clusterv1.ManagedCluster{}
+
+	err := r.Get(ctx, types.NamespacedName{Name: clusterName}, cluster)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return selector.Matches(labels.Set(cluster.GetLabels())), nil
+}
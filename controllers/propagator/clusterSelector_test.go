@@ -0,0 +1,106 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"testing"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+func TestHandleRootPolicyClusterSelector(t *testing.T) {
+	root := newTestPolicy("default")
+	root.Spec.ClusterSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}}
+
+	plr := &appsv1.PlacementRule{
+		ObjectMeta: metav1.ObjectMeta{Name: "plr", Namespace: "policies"},
+		Status: appsv1.PlacementRuleStatus{
+			Decisions: []appsv1.PlacementDecision{
+				{ClusterName: "prod", ClusterNamespace: "prod"},
+				{ClusterName: "dev", ClusterNamespace: "dev"},
+				{ClusterName: "missing", ClusterNamespace: "missing"},
+			},
+		},
+	}
+	pb := &policiesv1.PlacementBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "pb", Namespace: "policies"},
+		PlacementRef: policiesv1.Subject{
+			APIGroup: appsv1.SchemeGroupVersion.Group, Kind: "PlacementRule", Name: "plr",
+		},
+		Subjects: []policiesv1.Subject{
+			{APIGroup: policiesv1.SchemeGroupVersion.Group, Kind: policiesv1.Kind, Name: "policy"},
+		},
+	}
+
+	prod := newTestManagedCluster("prod", "v1.21.3")
+	prod.SetLabels(map[string]string{"env": "prod"})
+
+	dev := newTestManagedCluster("dev", "v1.21.3")
+	dev.SetLabels(map[string]string{"env": "dev"})
+
+	// The replicated policy of a cluster that no longer matches is cleaned up as an orphan
+	orphan := newTestReplicatedPolicy(root, "dev", policiesv1.Compliant)
+
+	r := newTestReconciler(t, &stubResolver{}, root, plr, pb, prod, dev, orphan)
+
+	if err := r.handleRootPolicy(context.TODO(), root, nil); err != nil {
+		t.Fatalf("handleRootPolicy returned an error: %v", err)
+	}
+
+	err := r.Get(context.TODO(), types.NamespacedName{Namespace: "prod", Name: "policies.policy"}, &policiesv1.Policy{})
+	if err != nil {
+		t.Fatalf("expected a replicated policy on the selected cluster, got the error: %v", err)
+	}
+
+	for _, cluster := range []string{"dev", "missing"} {
+		err := r.Get(
+			context.TODO(), types.NamespacedName{Namespace: cluster, Name: "policies.policy"}, &policiesv1.Policy{},
+		)
+		if !k8serrors.IsNotFound(err) {
+			t.Fatalf("expected no replicated policy on the cluster %s, got the error: %v", cluster, err)
+		}
+	}
+
+	updatedRoot := &policiesv1.Policy{}
+
+	err = r.Get(context.TODO(), types.NamespacedName{Namespace: "policies", Name: "policy"}, updatedRoot)
+	if err != nil {
+		t.Fatalf("failed to get the root policy: %v", err)
+	}
+
+	for _, cpcs := range updatedRoot.Status.Status {
+		if cpcs.ClusterName == "missing" {
+			t.Fatalf("expected the unselected cluster not to be in the root policy status, got %+v", cpcs)
+		}
+	}
+}
+
+func TestHandleRootPolicyInvalidClusterSelector(t *testing.T) {
+	root := newTestPolicy("default")
+	root.Spec.ClusterSelector = &metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "env", Operator: "Unknown"}},
+	}
+
+	r := newTestReconciler(t, &stubResolver{}, root)
+
+	if err := r.handleRootPolicy(context.TODO(), root, nil); err == nil {
+		t.Fatal("expected an error for the invalid cluster selector")
+	}
+
+	updated := &policiesv1.Policy{}
+	if err := r.Get(context.TODO(), types.NamespacedName{Namespace: "policies", Name: "policy"}, updated); err != nil {
+		t.Fatalf("failed to get the root policy: %v", err)
+	}
+
+	condition := meta.FindStatusCondition(updated.Status.Conditions, policiesv1.PlacementResolvedCondition)
+	if condition == nil || condition.Status != metav1.ConditionFalse || condition.Reason != "InvalidClusterSelector" {
+		t.Fatalf("expected the PlacementResolved condition to be False, got %v", condition)
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	clusterv1alpha1 "github.com/open-cluster-management/api/cluster/v1alpha1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// reasonClusterSetsNotBound is the reason of the PlacementResolved condition of the root policy when
+// the ManagedClusterSets of some of its Placements are not bound to its namespace
+const reasonClusterSetsNotBound = "ClusterSetsNotBound"
+
+// unboundClusterSetsMessage returns why the Placement can't select the clusters of some of its
+// ManagedClusterSets since they are not bound to its namespace by a ManagedClusterSetBinding, or an
+// empty string when they all are. A Placement without ManagedClusterSets selects the clusters of
+// all of the ones bound to its namespace, so it only needs one of them to be bound. The failures
+// are only logged since the placement decisions are valid either way.
+func (r *PolicyReconciler) unboundClusterSetsMessage(ctx context.Context, namespace string, name string) string {
+	pl := &clusterv1alpha1.Placement{}
+
+	err := r.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, pl)
+	if err != nil {
+		if !k8serrors.IsNotFound(err) {
+			log.Error(err, "Failed to get the Placement to check its ManagedClusterSetBindings...",
+				"Namespace", namespace, "Name", name)
+		}
+
+		return ""
+	}
+
+	bindings := &clusterv1alpha1.ManagedClusterSetBindingList{}
+
+	err = r.List(ctx, bindings, client.InNamespace(namespace))
+	if err != nil {
+		log.Error(err, "Failed to list the ManagedClusterSetBindings of the namespace...", "Namespace", namespace)
+
+		return ""
+	}
+
+	bound := map[string]bool{}
+	for _, binding := range bindings.Items {
+		bound[binding.Spec.ClusterSet] = true
+	}
+
+	if len(pl.Spec.ClusterSets) == 0 {
+		if len(bound) != 0 {
+			return ""
+		}
+
+		return fmt.Sprintf(
+			"The Placement %s selects no clusters since no ManagedClusterSet is bound to the namespace %s by a "+
+				"ManagedClusterSetBinding", name, namespace,
+		)
+	}
+
+	unbound := []string{}
+
+	for _, clusterSet := range pl.Spec.ClusterSets {
+		if !bound[clusterSet] {
+			unbound = append(unbound, clusterSet)
+		}
+	}
+
+	if len(unbound) == 0 {
+		return ""
+	}
+
+	sort.Strings(unbound)
+
+	return fmt.Sprintf(
+		"The Placement %s doesn't select the clusters of the ManagedClusterSets %s since they are not bound to the "+
+			"namespace %s by a ManagedClusterSetBinding", name, strings.Join(unbound, ", "), namespace,
+	)
+}
@@ -0,0 +1,103 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	clusterv1alpha1 "github.com/open-cluster-management/api/cluster/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+)
+
+func newTestClusterSetBinding(clusterSet string) *clusterv1alpha1.ManagedClusterSetBinding {
+	return &clusterv1alpha1.ManagedClusterSetBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: clusterSet, Namespace: "policies"},
+		Spec:       clusterv1alpha1.ManagedClusterSetBindingSpec{ClusterSet: clusterSet},
+	}
+}
+
+func TestUnboundClusterSetsMessage(t *testing.T) {
+	tests := []struct {
+		name        string
+		clusterSets []string
+		bindings    []string
+		expected    string
+	}{
+		{name: "all bound", clusterSets: []string{"set1", "set2"}, bindings: []string{"set1", "set2"}},
+		{
+			name: "some unbound", clusterSets: []string{"set3", "set1", "set2"}, bindings: []string{"set1"},
+			expected: "The Placement placement doesn't select the clusters of the ManagedClusterSets set2, set3 " +
+				"since they are not bound to the namespace policies by a ManagedClusterSetBinding",
+		},
+		{name: "bound namespace", bindings: []string{"set1"}},
+		{
+			name: "unbound namespace",
+			expected: "The Placement placement selects no clusters since no ManagedClusterSet is bound to the " +
+				"namespace policies by a ManagedClusterSetBinding",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			objects := []client.Object{&clusterv1alpha1.Placement{
+				ObjectMeta: metav1.ObjectMeta{Name: "placement", Namespace: "policies"},
+				Spec:       clusterv1alpha1.PlacementSpec{ClusterSets: test.clusterSets},
+			}}
+
+			for _, clusterSet := range test.bindings {
+				objects = append(objects, newTestClusterSetBinding(clusterSet))
+			}
+
+			r := newTestReconciler(t, &stubResolver{}, objects...)
+
+			if message := r.unboundClusterSetsMessage(context.TODO(), "policies", "placement"); message != test.expected {
+				t.Fatalf("expected the message %q, got %q", test.expected, message)
+			}
+		})
+	}
+}
+
+func TestHandleRootPolicyUnboundClusterSets(t *testing.T) {
+	root := newTestPolicy("default")
+	placement := &clusterv1alpha1.Placement{
+		ObjectMeta: metav1.ObjectMeta{Name: "placement", Namespace: "policies"},
+		Spec:       clusterv1alpha1.PlacementSpec{ClusterSets: []string{"set1", "set2"}},
+	}
+	pb := newTestPlacementBinding("pb", "placement", policiesv1.Subject{
+		APIGroup: policiesv1.SchemeGroupVersion.Group, Kind: policiesv1.Kind, Name: "policy",
+	})
+	pb.PlacementRef = policiesv1.Subject{
+		APIGroup: clusterv1alpha1.GroupVersion.Group, Kind: "Placement", Name: "placement",
+	}
+
+	r := newTestReconciler(t, &stubResolver{}, root, placement, pb, newTestClusterSetBinding("set1"))
+
+	err := r.handleRootPolicy(context.TODO(), root, nil)
+	if err != nil {
+		t.Fatalf("handleRootPolicy returned an error: %v", err)
+	}
+
+	if len(root.Status.Placement) != 1 || !strings.Contains(root.Status.Placement[0].Message, "ManagedClusterSets set2 ") {
+		t.Fatalf("expected the unbound ManagedClusterSet in the placement status, got %+v", root.Status.Placement)
+	}
+
+	condition := meta.FindStatusCondition(root.Status.Conditions, policiesv1.PlacementResolvedCondition)
+	if condition == nil || condition.Status != metav1.ConditionFalse || condition.Reason != reasonClusterSetsNotBound {
+		t.Fatalf("expected the PlacementResolved condition to be False, got %v", condition)
+	}
+
+	expected := "Warning PolicyPropagation " + root.Status.Placement[0].Message
+
+	recorded := events(r)
+	if len(recorded) != 1 || !strings.HasPrefix(recorded[0], expected) {
+		t.Fatalf("expected a Warning event for the unbound ManagedClusterSet, got %v", recorded)
+	}
+}
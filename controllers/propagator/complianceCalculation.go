@@ -0,0 +1,147 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+)
+
+// templateInfo is what the compliance calculation needs to know about a policy template
+type templateInfo struct {
+	ignorePending bool
+	lowSeverity   bool
+}
+
+// templateInfos returns the information of the policy templates of the policy by name, and their
+// names in the order of the policy templates
+func templateInfos(plc *policiesv1.Policy) (map[string]templateInfo, []string) {
+	infos := map[string]templateInfo{}
+	names := []string{}
+
+	for _, template := range plc.Spec.PolicyTemplates {
+		tmpl := &unstructured.Unstructured{}
+		if err := tmpl.UnmarshalJSON(template.ObjectDefinition.Raw); err != nil {
+			continue
+		}
+
+		if _, ok := infos[tmpl.GetName()]; !ok {
+			names = append(names, tmpl.GetName())
+		}
+
+		severity, _, _ := unstructured.NestedString(tmpl.Object, "spec", "severity")
+
+		infos[tmpl.GetName()] = templateInfo{
+			ignorePending: template.IgnorePending,
+			lowSeverity:   strings.EqualFold(severity, "low"),
+		}
+	}
+
+	return infos, names
+}
+
+// replicatedCompliance returns the compliance of the replicated policy, leaving out the Pending
+// policy templates that ignore pending, and with the weighted compliance calculation, the
+// NonCompliant policy templates of low severity
+func replicatedCompliance(replicatedPlc *policiesv1.Policy) policiesv1.ComplianceState {
+	compliance := replicatedPlc.Status.ComplianceState
+	weighted := replicatedPlc.Spec.ComplianceCalculation == policiesv1.ComplianceWeighted
+
+	if compliance != policiesv1.Pending && !(weighted && compliance == policiesv1.NonCompliant) {
+		return compliance
+	}
+
+	infos, _ := templateInfos(replicatedPlc)
+	result := policiesv1.Compliant
+	counted := 0
+
+	for _, details := range replicatedPlc.Status.Details {
+		info := infos[details.TemplateMeta.GetName()]
+		state := details.ComplianceState
+
+		if state == policiesv1.Pending && info.ignorePending {
+			continue
+		}
+
+		if weighted && state == policiesv1.NonCompliant && info.lowSeverity {
+			state = policiesv1.Compliant
+		}
+
+		counted++
+
+		switch state {
+		case policiesv1.NonCompliant:
+			return policiesv1.NonCompliant
+		case policiesv1.Pending:
+			result = policiesv1.Pending
+		case policiesv1.Compliant:
+		default:
+			return compliance
+		}
+	}
+
+	if counted == 0 {
+		return compliance
+	}
+
+	return result
+}
+
+// summarizeTemplateDetails returns the compliance of each policy template of the root policy
+// across the replicated policies, or nil if the root policy has no compliance calculation. A
+// policy template is NonCompliant if it is NonCompliant on any cluster, and with the weighted
+// compliance calculation, the policy templates of low severity are NonCompliant(Low) instead.
+func summarizeTemplateDetails(
+	instance *policiesv1.Policy, replicatedPlcs []policiesv1.Policy,
+) []*policiesv1.DetailsPerTemplate {
+	if instance.Spec.ComplianceCalculation == "" {
+		return nil
+	}
+
+	infos, names := templateInfos(instance)
+	nonCompliant := map[string]bool{}
+	pending := map[string]bool{}
+	compliant := map[string]int{}
+
+	for _, rPlc := range replicatedPlcs {
+		for _, details := range rPlc.Status.Details {
+			switch details.ComplianceState {
+			case policiesv1.NonCompliant:
+				nonCompliant[details.TemplateMeta.GetName()] = true
+			case policiesv1.Pending:
+				pending[details.TemplateMeta.GetName()] = true
+			case policiesv1.Compliant:
+				compliant[details.TemplateMeta.GetName()]++
+			}
+		}
+	}
+
+	summary := make([]*policiesv1.DetailsPerTemplate, 0, len(names))
+
+	for _, name := range names {
+		var state policiesv1.ComplianceState
+
+		switch {
+		case nonCompliant[name] && instance.Spec.ComplianceCalculation == policiesv1.ComplianceWeighted &&
+			infos[name].lowSeverity:
+			state = policiesv1.NonCompliantLow
+		case nonCompliant[name]:
+			state = policiesv1.NonCompliant
+		case pending[name]:
+			state = policiesv1.Pending
+		case len(replicatedPlcs) > 0 && compliant[name] == len(replicatedPlcs):
+			state = policiesv1.Compliant
+		}
+
+		summary = append(summary, &policiesv1.DetailsPerTemplate{
+			TemplateMeta:    metav1.ObjectMeta{Name: name},
+			ComplianceState: state,
+		})
+	}
+
+	return summary
+}
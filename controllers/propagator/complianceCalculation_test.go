@@ -0,0 +1,155 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+)
+
+const lowSeverityPolicy = `{"apiVersion":"policy.open-cluster-management.io/v1","kind":"ConfigurationPolicy",` +
+	`"metadata":{"name":"other"},"spec":{"severity":"low"}}`
+
+// newTestWeightedPolicy returns a policy with the case policy template and the other policy
+// template of low severity
+func newTestWeightedPolicy(calculation string) *policiesv1.Policy {
+	plc := newTestPolicy("default")
+	plc.Spec.ComplianceCalculation = calculation
+	plc.Spec.PolicyTemplates = append(plc.Spec.PolicyTemplates, &policiesv1.PolicyTemplate{
+		ObjectDefinition: runtime.RawExtension{Raw: []byte(lowSeverityPolicy)},
+	})
+
+	return plc
+}
+
+func testDetails(states ...policiesv1.ComplianceState) []*policiesv1.DetailsPerTemplate {
+	result := []*policiesv1.DetailsPerTemplate{}
+	for i, state := range states {
+		result = append(result, &policiesv1.DetailsPerTemplate{
+			TemplateMeta:    metav1.ObjectMeta{Name: []string{"case", "other"}[i]},
+			ComplianceState: state,
+		})
+	}
+
+	return result
+}
+
+func TestReplicatedCompliance(t *testing.T) {
+	tests := []struct {
+		name          string
+		calculation   string
+		ignorePending bool
+		compliance    policiesv1.ComplianceState
+		details       []*policiesv1.DetailsPerTemplate
+		expected      policiesv1.ComplianceState
+	}{
+		{
+			"not pending", "", true, policiesv1.NonCompliant, testDetails(policiesv1.NonCompliant),
+			policiesv1.NonCompliant,
+		},
+		{
+			"pending", "", false, policiesv1.Pending, testDetails(policiesv1.Pending, policiesv1.Compliant),
+			policiesv1.Pending,
+		},
+		{
+			"ignored pending", "", true, policiesv1.Pending, testDetails(policiesv1.Pending, policiesv1.Compliant),
+			policiesv1.Compliant,
+		},
+		{
+			"other pending", "", true, policiesv1.Pending, testDetails(policiesv1.Compliant, policiesv1.Pending),
+			policiesv1.Pending,
+		},
+		{"only ignored pending", "", true, policiesv1.Pending, testDetails(policiesv1.Pending), policiesv1.Pending},
+		{
+			"worst case low severity", policiesv1.ComplianceWorstCase, false, policiesv1.NonCompliant,
+			testDetails(policiesv1.Compliant, policiesv1.NonCompliant), policiesv1.NonCompliant,
+		},
+		{
+			"weighted low severity", policiesv1.ComplianceWeighted, false, policiesv1.NonCompliant,
+			testDetails(policiesv1.Compliant, policiesv1.NonCompliant), policiesv1.Compliant,
+		},
+		{
+			"weighted other severity", policiesv1.ComplianceWeighted, false, policiesv1.NonCompliant,
+			testDetails(policiesv1.NonCompliant, policiesv1.NonCompliant), policiesv1.NonCompliant,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			replicated := newTestWeightedPolicy(test.calculation)
+			replicated.Spec.PolicyTemplates[0].IgnorePending = test.ignorePending
+			replicated.Status = policiesv1.PolicyStatus{ComplianceState: test.compliance, Details: test.details}
+
+			if actual := replicatedCompliance(replicated); actual != test.expected {
+				t.Fatalf("expected %q, got %q", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestSummarizeTemplateDetails(t *testing.T) {
+	replicated := func(states ...policiesv1.ComplianceState) policiesv1.Policy {
+		return policiesv1.Policy{Status: policiesv1.PolicyStatus{Details: testDetails(states...)}}
+	}
+
+	tests := []struct {
+		name        string
+		calculation string
+		replicated  []policiesv1.Policy
+		expected    []policiesv1.ComplianceState
+	}{
+		{"no calculation", "", []policiesv1.Policy{replicated(policiesv1.Compliant, policiesv1.Compliant)}, nil},
+		{
+			"worst case", policiesv1.ComplianceWorstCase,
+			[]policiesv1.Policy{
+				replicated(policiesv1.Compliant, policiesv1.NonCompliant),
+				replicated(policiesv1.Compliant, policiesv1.Compliant),
+			},
+			[]policiesv1.ComplianceState{policiesv1.Compliant, policiesv1.NonCompliant},
+		},
+		{
+			"weighted", policiesv1.ComplianceWeighted,
+			[]policiesv1.Policy{
+				replicated(policiesv1.Pending, policiesv1.NonCompliant),
+				replicated(policiesv1.Compliant, policiesv1.Compliant),
+			},
+			[]policiesv1.ComplianceState{policiesv1.Pending, policiesv1.NonCompliantLow},
+		},
+		{
+			"unknown", policiesv1.ComplianceWeighted,
+			[]policiesv1.Policy{replicated(policiesv1.Compliant), replicated()},
+			[]policiesv1.ComplianceState{"", ""},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			summary := summarizeTemplateDetails(newTestWeightedPolicy(test.calculation), test.replicated)
+			if test.expected == nil {
+				if summary != nil {
+					t.Fatalf("expected no summary, got %v", summary)
+				}
+
+				return
+			}
+
+			if len(summary) != len(test.expected) {
+				t.Fatalf("expected %d policy templates, got %d", len(test.expected), len(summary))
+			}
+
+			for i, state := range test.expected {
+				if summary[i].TemplateMeta.Name != []string{"case", "other"}[i] || summary[i].ComplianceState != state {
+					t.Fatalf("expected the policy template %d to be %q, got %+v", i, state, summary[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/complianceeventsapi"
+)
+
+// ComplianceDBIDs returns the IDs of the root policies and the policy templates in the compliance
+// events database, and records the objects looked up by their hub templates, see
+// complianceeventsapi.ComplianceDB
+type ComplianceDBIDs interface {
+	GetOrCreateParentPolicy(ctx context.Context, parent *complianceeventsapi.ParentPolicy) (int32, error)
+	GetOrCreatePolicy(ctx context.Context, policy *complianceeventsapi.Policy) (int32, error)
+	RecordTemplateLookups(ctx context.Context, lookups *complianceeventsapi.TemplateLookups) error
+}
+
+// stampComplianceDBIDs sets the ID of the root policy in the compliance events database on the
+// replicated policy, and the IDs of the policy templates on them, so that the managed clusters
+// can reference them when they record compliance events. When an ID can't be retrieved, the one
+// of the existing replicated policy is kept so that the replicated policies aren't updated while
+// the database is unavailable. existingPlc is nil when the replicated policy doesn't exist yet.
+func (r *PolicyReconciler) stampComplianceDBIDs(
+	ctx context.Context, replicatedPlc *policiesv1.Policy, existingPlc *policiesv1.Policy, rootPlc *policiesv1.Policy,
+) {
+	if r.complianceDB == nil {
+		return
+	}
+
+	reqLogger := log.WithValues("Policy-Namespace", rootPlc.GetNamespace(), "Policy-Name", rootPlc.GetName())
+
+	existingIDs := map[string]string{}
+	existingParentID := ""
+
+	if existingPlc != nil {
+		existingParentID = existingPlc.GetAnnotations()[common.ParentPolicyIDAnnotation]
+
+		for _, template := range existingPlc.Spec.PolicyTemplates {
+			tmpl := &unstructured.Unstructured{}
+			if err := tmpl.UnmarshalJSON(template.ObjectDefinition.Raw); err != nil {
+				continue
+			}
+
+			existingIDs[tmpl.GetKind()+"/"+tmpl.GetName()] = tmpl.GetAnnotations()[common.PolicyIDAnnotation]
+		}
+	}
+
+	// The annotations may be shared with the root policy
+	annotations := map[string]string{}
+	for key, value := range replicatedPlc.GetAnnotations() {
+		annotations[key] = value
+	}
+
+	parentID, err := r.complianceDB.GetOrCreateParentPolicy(ctx, complianceeventsapi.ParentPolicyFromPolicy(rootPlc))
+	if err != nil {
+		reqLogger.Error(err, "Failed to get the compliance events database ID of the root policy...")
+
+		annotations[common.ParentPolicyIDAnnotation] = existingParentID
+	} else {
+		annotations[common.ParentPolicyIDAnnotation] = strconv.Itoa(int(parentID))
+	}
+
+	if annotations[common.ParentPolicyIDAnnotation] == "" {
+		delete(annotations, common.ParentPolicyIDAnnotation)
+	}
+
+	replicatedPlc.SetAnnotations(annotations)
+
+	// The policy templates may be shared with the root policy
+	templates := make([]*policiesv1.PolicyTemplate, 0, len(replicatedPlc.Spec.PolicyTemplates))
+
+	for _, template := range replicatedPlc.Spec.PolicyTemplates {
+		template = template.DeepCopy()
+		templates = append(templates, template)
+
+		tmpl := &unstructured.Unstructured{}
+		if err := tmpl.UnmarshalJSON(template.ObjectDefinition.Raw); err != nil {
+			continue
+		}
+
+		tmplAnnotations := tmpl.GetAnnotations()
+		if tmplAnnotations == nil {
+			tmplAnnotations = map[string]string{}
+		}
+
+		// The ID is not part of the policy template spec, so it doesn't affect itself
+		id, err := r.complianceDB.GetOrCreatePolicy(ctx, complianceeventsapi.PolicyFromTemplate(tmpl))
+		if err != nil {
+			reqLogger.Error(err, "Failed to get the compliance events database ID of the policy template...",
+				"Kind", tmpl.GetKind(), "Name", tmpl.GetName())
+
+			tmplAnnotations[common.PolicyIDAnnotation] = existingIDs[tmpl.GetKind()+"/"+tmpl.GetName()]
+		} else {
+			tmplAnnotations[common.PolicyIDAnnotation] = strconv.Itoa(int(id))
+		}
+
+		if tmplAnnotations[common.PolicyIDAnnotation] == "" {
+			delete(tmplAnnotations, common.PolicyIDAnnotation)
+		}
+
+		tmpl.SetAnnotations(tmplAnnotations)
+
+		raw, err := json.Marshal(tmpl.Object)
+		if err != nil {
+			continue
+		}
+
+		template.ObjectDefinition.Raw = raw
+	}
+
+	replicatedPlc.Spec.PolicyTemplates = templates
+}
@@ -0,0 +1,97 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/complianceeventsapi"
+)
+
+// fakeComplianceDB returns the IDs of the names, or an error when err is set, and keeps the recorded
+// template lookups
+type fakeComplianceDB struct {
+	ids     map[string]int32
+	err     error
+	lookups []complianceeventsapi.TemplateLookups
+}
+
+func (db *fakeComplianceDB) GetOrCreateParentPolicy(
+	ctx context.Context, parent *complianceeventsapi.ParentPolicy,
+) (int32, error) {
+	return db.ids[parent.Name], db.err
+}
+
+func (db *fakeComplianceDB) GetOrCreatePolicy(ctx context.Context, policy *complianceeventsapi.Policy) (int32, error) {
+	return db.ids[policy.Name], db.err
+}
+
+func (db *fakeComplianceDB) RecordTemplateLookups(
+	ctx context.Context, lookups *complianceeventsapi.TemplateLookups,
+) error {
+	if db.err == nil {
+		db.lookups = append(db.lookups, *lookups)
+	}
+
+	return db.err
+}
+
+func TestStampComplianceDBIDs(t *testing.T) {
+	root := newTestPolicy("default")
+	rootRaw := string(root.Spec.PolicyTemplates[0].ObjectDefinition.Raw)
+	db := &fakeComplianceDB{ids: map[string]int32{"policy": 3, "case": 5}}
+
+	r := newTestReconciler(t, &stubResolver{})
+	r.complianceDB = db
+
+	templateID := func(plc *unstructured.Unstructured) string {
+		return plc.GetAnnotations()[common.PolicyIDAnnotation]
+	}
+
+	replicatedPlc := root.DeepCopy()
+	// The spec is shared with the root policy when it has no hub templates
+	replicatedPlc.Spec = root.Spec
+	r.stampComplianceDBIDs(context.TODO(), replicatedPlc, nil, root)
+
+	if replicatedPlc.GetAnnotations()[common.ParentPolicyIDAnnotation] != "3" {
+		t.Fatalf("expected the parent policy ID 3, got %v", replicatedPlc.GetAnnotations())
+	}
+
+	tmpl := &unstructured.Unstructured{}
+	if err := tmpl.UnmarshalJSON(replicatedPlc.Spec.PolicyTemplates[0].ObjectDefinition.Raw); err != nil {
+		t.Fatalf("failed to unmarshal the policy template: %v", err)
+	}
+
+	if templateID(tmpl) != "5" {
+		t.Fatalf("expected the policy template ID 5, got %v", tmpl.GetAnnotations())
+	}
+
+	if string(root.Spec.PolicyTemplates[0].ObjectDefinition.Raw) != rootRaw || len(root.GetAnnotations()) != 0 {
+		t.Fatal("expected the root policy to be unchanged")
+	}
+
+	// The IDs of the existing replicated policy are kept when the database is unavailable
+	db.err = errors.New("connection refused")
+
+	desiredPlc := root.DeepCopy()
+	r.stampComplianceDBIDs(context.TODO(), desiredPlc, replicatedPlc, root)
+
+	if !replicatedPolicyApplied(desiredPlc, replicatedPlc) {
+		t.Fatal("expected the IDs of the existing replicated policy to be kept")
+	}
+
+	// No IDs are set when the database was never available
+	desiredPlc = root.DeepCopy()
+	r.stampComplianceDBIDs(context.TODO(), desiredPlc, nil, root)
+
+	if len(desiredPlc.GetAnnotations()) != 0 ||
+		strings.Contains(string(desiredPlc.Spec.PolicyTemplates[0].ObjectDefinition.Raw), common.PolicyIDAnnotation) {
+		t.Fatal("expected no IDs to be set")
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+)
+
+// complianceHistoryLimit is the number of compliance transitions kept for each cluster in the
+// status of the root policies
+const complianceHistoryLimit = 10
+
+// setComplianceHistory sets the compliance history and the last transition time of the clusters in
+// the status from the previous status of the root policy. A transition is added to the front of the
+// history of the cluster when its compliance differs from the previous one, dropping the oldest
+// transitions past the limit. The history is kept in the root policy status on the hub since the
+// status of the replicated policies is replaced by the status sync of the managed clusters.
+func setComplianceHistory(
+	instance *policiesv1.Policy, status []*policiesv1.CompliancePerClusterStatus, now time.Time,
+) {
+	previous := map[string][]policiesv1.ComplianceTransition{}
+
+	for _, cpcs := range instance.Status.Status {
+		previous[cpcs.ClusterNamespace+"/"+cpcs.ClusterName] = cpcs.ComplianceHistory
+	}
+
+	for _, cpcs := range status {
+		history := make([]policiesv1.ComplianceTransition, 0, complianceHistoryLimit)
+
+		compliance := cpcs.ComplianceState
+		previousHistory := previous[cpcs.ClusterNamespace+"/"+cpcs.ClusterName]
+
+		if compliance != "" && (len(previousHistory) == 0 || previousHistory[0].ComplianceState != compliance) {
+			history = append(history, policiesv1.ComplianceTransition{
+				ComplianceState: compliance, LastTransitionTime: metav1.NewTime(now),
+			})
+		}
+
+		for _, transition := range previousHistory {
+			if len(history) == complianceHistoryLimit {
+				break
+			}
+
+			history = append(history, *transition.DeepCopy())
+		}
+
+		cpcs.ComplianceHistory = nil
+		cpcs.LastTransitionTime = nil
+
+		if len(history) != 0 {
+			cpcs.ComplianceHistory = history
+			cpcs.LastTransitionTime = history[0].LastTransitionTime.DeepCopy()
+		}
+	}
+}
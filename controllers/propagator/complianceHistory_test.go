@@ -0,0 +1,159 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+)
+
+func TestSetComplianceHistory(t *testing.T) {
+	start := time.Date(2021, time.June, 2, 10, 0, 0, 0, time.UTC)
+	instance := newTestPolicy("default")
+
+	// setStatus sets the compliance of the clusters in the root policy status and returns the
+	// status of cluster1
+	setStatus := func(now time.Time, compliance policiesv1.ComplianceState) *policiesv1.CompliancePerClusterStatus {
+		t.Helper()
+
+		status := []*policiesv1.CompliancePerClusterStatus{
+			{ClusterName: "cluster1", ClusterNamespace: "cluster1", ComplianceState: compliance},
+			{ClusterName: "cluster2", ClusterNamespace: "cluster2"},
+		}
+
+		setComplianceHistory(instance, status, now)
+		instance.Status.Status = status
+
+		if status[1].ComplianceHistory != nil || status[1].LastTransitionTime != nil {
+			t.Fatalf("expected no history without a compliance, got %v", status[1].ComplianceHistory)
+		}
+
+		return status[0]
+	}
+
+	if cpcs := setStatus(start, ""); cpcs.ComplianceHistory != nil {
+		t.Fatalf("expected no transition without a compliance, got %v", cpcs.ComplianceHistory)
+	}
+
+	for i := 0; i < complianceHistoryLimit+2; i++ {
+		compliance := policiesv1.Compliant
+		if i%2 == 1 {
+			compliance = policiesv1.NonCompliant
+		}
+
+		now := start.Add(time.Duration(i) * time.Minute)
+
+		if cpcs := setStatus(now, compliance); cpcs.LastTransitionTime == nil || !cpcs.LastTransitionTime.Time.Equal(now) {
+			t.Fatalf("expected transition %d to be recorded, got %v", i, cpcs.ComplianceHistory)
+		}
+	}
+
+	cpcs := setStatus(start.Add(time.Hour), policiesv1.NonCompliant)
+
+	history := cpcs.ComplianceHistory
+	if len(history) != complianceHistoryLimit {
+		t.Fatalf("expected the history to be limited to %d transitions, got %d", complianceHistoryLimit, len(history))
+	}
+
+	lastTransition := start.Add(11 * time.Minute)
+	if history[0].ComplianceState != policiesv1.NonCompliant || !cpcs.LastTransitionTime.Time.Equal(lastTransition) {
+		t.Fatalf("expected no transition when the compliance didn't change, got %v", history[0])
+	}
+
+	if !history[complianceHistoryLimit-1].LastTransitionTime.Time.Equal(start.Add(2 * time.Minute)) {
+		t.Fatalf("expected the oldest transitions to be dropped, got %v", history[complianceHistoryLimit-1])
+	}
+
+	// The compliance reported again after none was reported isn't a transition
+	setStatus(start.Add(2*time.Hour), "")
+
+	cpcs = setStatus(start.Add(3*time.Hour), policiesv1.NonCompliant)
+	if !cpcs.LastTransitionTime.Time.Equal(lastTransition) {
+		t.Fatalf("expected the history to be kept without a compliance, got %v", cpcs.ComplianceHistory)
+	}
+}
+
+func TestRootPolicyStatusReconcileComplianceHistory(t *testing.T) {
+	transitioned := metav1.NewTime(time.Date(2021, time.June, 2, 10, 0, 0, 0, time.UTC))
+
+	root := newTestPolicy("default")
+	root.Status.Status = []*policiesv1.CompliancePerClusterStatus{{
+		ClusterName: "cluster1", ClusterNamespace: "cluster1", ComplianceState: policiesv1.NonCompliant,
+		LastTransitionTime: &transitioned,
+		ComplianceHistory: []policiesv1.ComplianceTransition{
+			{ComplianceState: policiesv1.NonCompliant, LastTransitionTime: transitioned},
+		},
+	}}
+
+	propagator := newTestReconciler(t, &stubResolver{}, root,
+		newTestReplicatedPolicy(root, "cluster1", policiesv1.NonCompliant),
+		newTestReplicatedPolicy(root, "cluster2", policiesv1.Compliant))
+	r := &RootPolicyStatusReconciler{
+		Client: propagator.Client, Scheme: propagator.Scheme, Recorder: record.NewFakeRecorder(10),
+	}
+
+	rootKey := types.NamespacedName{Namespace: root.GetNamespace(), Name: root.GetName()}
+
+	reconcileStatus := func() []*policiesv1.CompliancePerClusterStatus {
+		t.Helper()
+
+		if _, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: rootKey}); err != nil {
+			t.Fatalf("Reconcile returned an error: %v", err)
+		}
+
+		updated := &policiesv1.Policy{}
+		if err := r.Get(context.TODO(), rootKey, updated); err != nil {
+			t.Fatalf("failed to get the root policy: %v", err)
+		}
+
+		if len(updated.Status.Status) != 2 {
+			t.Fatalf("expected the status of both clusters, got %v", updated.Status.Status)
+		}
+
+		return updated.Status.Status
+	}
+
+	status := reconcileStatus()
+
+	if last := status[0].LastTransitionTime; last == nil || !last.Equal(&transitioned) ||
+		len(status[0].ComplianceHistory) != 1 {
+		t.Fatalf("expected cluster1 to keep its compliance history, got %v", status[0].ComplianceHistory)
+	}
+
+	if status[1].LastTransitionTime == nil || len(status[1].ComplianceHistory) != 1 {
+		t.Fatalf("expected the compliance transition of cluster2 to be recorded, got %v", status[1].ComplianceHistory)
+	}
+
+	// The status of the replicated policy is replaced by the status sync of the managed cluster
+	replicated := &policiesv1.Policy{}
+
+	err := r.Get(context.TODO(), types.NamespacedName{Namespace: "cluster1", Name: "policies.policy"}, replicated)
+	if err != nil {
+		t.Fatalf("failed to get the replicated policy: %v", err)
+	}
+
+	replicated.Status = policiesv1.PolicyStatus{ComplianceState: policiesv1.Compliant}
+	if err := r.Status().Update(context.TODO(), replicated); err != nil {
+		t.Fatalf("failed to update the replicated policy status: %v", err)
+	}
+
+	status = reconcileStatus()
+
+	history := status[0].ComplianceHistory
+	if len(history) != 2 || history[0].ComplianceState != policiesv1.Compliant ||
+		!history[1].LastTransitionTime.Equal(&transitioned) {
+		t.Fatalf("expected the compliance transition of cluster1 to be added to its history, got %v", history)
+	}
+
+	if !status[0].LastTransitionTime.Equal(&history[0].LastTransitionTime) {
+		t.Fatalf("expected the last transition time of the latest transition, got %v", status[0].LastTransitionTime)
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+)
+
+// The annotations of the root policy listing its standards, categories, and controls, which are
+// comma separated lists
+const (
+	standardsAnnotation  = common.APIGroup + "/standards"
+	categoriesAnnotation = common.APIGroup + "/categories"
+	controlsAnnotation   = common.APIGroup + "/controls"
+)
+
+// summarizeControls returns the number of compliant clusters and the number of clusters of the root
+// policy for each control of its controls annotation, or nil if it has none. Like the compliance of
+// the root policy, the incompatible clusters are not counted since they don't have the policy.
+func summarizeControls(
+	instance *policiesv1.Policy, status []*policiesv1.CompliancePerClusterStatus,
+) map[string]policiesv1.ControlSummary {
+	controls := splitList(instance.GetAnnotations()[controlsAnnotation])
+	if len(controls) == 0 {
+		return nil
+	}
+
+	compliant := 0
+	total := 0
+
+	for _, cpcs := range status {
+		if cpcs.Reason == reasonClusterIncompatible {
+			continue
+		}
+
+		total++
+
+		if cpcs.ComplianceState == policiesv1.Compliant {
+			compliant++
+		}
+	}
+
+	summary := make(map[string]policiesv1.ControlSummary, len(controls))
+
+	for _, control := range controls {
+		summary[control] = policiesv1.ControlSummary{
+			Standards:  nonEmptyList(instance.GetAnnotations()[standardsAnnotation]),
+			Categories: nonEmptyList(instance.GetAnnotations()[categoriesAnnotation]),
+			Compliant:  compliant,
+			Total:      total,
+		}
+	}
+
+	return summary
+}
+
+// nonEmptyList returns the non-empty entries of the comma separated list, or nil if there are none
+func nonEmptyList(value string) []string {
+	entries := splitList(value)
+	if len(entries) == 0 {
+		return nil
+	}
+
+	return entries
+}
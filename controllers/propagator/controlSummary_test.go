@@ -0,0 +1,93 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+)
+
+func TestSummarizeControls(t *testing.T) {
+	status := []*policiesv1.CompliancePerClusterStatus{
+		{ClusterName: "cluster1", ComplianceState: policiesv1.Compliant},
+		{ClusterName: "cluster2", ComplianceState: policiesv1.NonCompliant},
+		{ClusterName: "cluster3", ComplianceState: policiesv1.Pending},
+		{ClusterName: "cluster4", Reason: reasonClusterIncompatible},
+	}
+
+	root := newTestPolicy("default")
+
+	if summary := summarizeControls(root, status); summary != nil {
+		t.Fatalf("expected no summary without controls, got %v", summary)
+	}
+
+	root.SetAnnotations(map[string]string{
+		standardsAnnotation: "NIST SP 800-53",
+		controlsAnnotation:  "CM-2 Baseline Configuration, ,AC-3 Access Enforcement",
+	})
+
+	expected := map[string]policiesv1.ControlSummary{
+		"CM-2 Baseline Configuration": {Standards: []string{"NIST SP 800-53"}, Compliant: 1, Total: 3},
+		"AC-3 Access Enforcement":     {Standards: []string{"NIST SP 800-53"}, Compliant: 1, Total: 3},
+	}
+
+	if summary := summarizeControls(root, status); !reflect.DeepEqual(summary, expected) {
+		t.Fatalf("expected the summary %v, got %v", expected, summary)
+	}
+}
+
+func TestRootPolicyStatusReconcileControls(t *testing.T) {
+	root := newTestPolicy("default")
+	root.SetAnnotations(map[string]string{
+		standardsAnnotation:  "NIST SP 800-53",
+		categoriesAnnotation: "CM Configuration Management",
+		controlsAnnotation:   "CM-2 Baseline Configuration",
+	})
+	root.Status = policiesv1.PolicyStatus{
+		Status: []*policiesv1.CompliancePerClusterStatus{
+			{ClusterName: "cluster1", ClusterNamespace: "cluster1"},
+			{ClusterName: "cluster2", ClusterNamespace: "cluster2"},
+		},
+	}
+
+	propagator := newTestReconciler(t, &stubResolver{},
+		root,
+		newTestReplicatedPolicy(root, "cluster1", policiesv1.Compliant),
+		newTestReplicatedPolicy(root, "cluster2", policiesv1.NonCompliant),
+	)
+	r := &RootPolicyStatusReconciler{
+		Client: propagator.Client, Scheme: propagator.Scheme, Recorder: record.NewFakeRecorder(10),
+	}
+
+	_, err := r.Reconcile(context.TODO(), ctrl.Request{
+		NamespacedName: types.NamespacedName{Namespace: root.GetNamespace(), Name: root.GetName()},
+	})
+	if err != nil {
+		t.Fatalf("Reconcile returned an error: %v", err)
+	}
+
+	updated := &policiesv1.Policy{}
+	if err := r.Get(context.TODO(), types.NamespacedName{Namespace: "policies", Name: "policy"}, updated); err != nil {
+		t.Fatalf("failed to get the root policy: %v", err)
+	}
+
+	expected := map[string]policiesv1.ControlSummary{
+		"CM-2 Baseline Configuration": {
+			Standards:  []string{"NIST SP 800-53"},
+			Categories: []string{"CM Configuration Management"},
+			Compliant:  1,
+			Total:      2,
+		},
+	}
+
+	if !reflect.DeepEqual(updated.Status.SummaryByControl, expected) {
+		t.Fatalf("expected the summary by control %v, got %v", expected, updated.Status.SummaryByControl)
+	}
+}
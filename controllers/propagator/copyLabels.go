@@ -0,0 +1,83 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"path"
+	"strings"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+)
+
+// copyLabelsAnnotation on a root policy is the comma separated list of the glob patterns of the keys
+// of its labels and annotations that are copied to its replicated policies, in addition to the
+// ones of the CopyLabels option. It is not copied itself.
+const copyLabelsAnnotation = common.APIGroup + "/copy-labels"
+
+// copyLabelPatterns returns the glob patterns of the keys of the labels and annotations of the root
+// policy copied to its replicated policies, or nil when they are all copied since neither the
+// CopyLabels option nor the copy-labels annotation of the root policy is set
+func (r *PolicyReconciler) copyLabelPatterns(instance *policiesv1.Policy) []string {
+	value, annotated := instance.GetAnnotations()[copyLabelsAnnotation]
+	if r.copyLabels == nil && !annotated {
+		return nil
+	}
+
+	patterns := append([]string{}, r.copyLabels...)
+
+	return append(patterns, splitList(value)...)
+}
+
+// filterCopiedMetadata removes the labels and annotations of the replicated policy whose keys don't
+// match the patterns. The keys in the domain of the policy framework are always kept since the
+// propagator and the managed clusters rely on them. A nil patterns keeps everything.
+func filterCopiedMetadata(replicatedPlc *policiesv1.Policy, patterns []string) {
+	if patterns == nil {
+		return
+	}
+
+	replicatedPlc.SetLabels(filterMetadataKeys(replicatedPlc.GetLabels(), patterns))
+	replicatedPlc.SetAnnotations(filterMetadataKeys(replicatedPlc.GetAnnotations(), patterns))
+}
+
+// filterMetadataKeys returns the labels or annotations whose keys match the patterns or are in the
+// domain of the policy framework, or nil if there are none
+func filterMetadataKeys(metadata map[string]string, patterns []string) map[string]string {
+	var filtered map[string]string
+
+	for key, value := range metadata {
+		if !policyFrameworkKey(key) && !keyMatches(key, patterns) {
+			continue
+		}
+
+		if filtered == nil {
+			filtered = map[string]string{}
+		}
+
+		filtered[key] = value
+	}
+
+	return filtered
+}
+
+// policyFrameworkKey returns whether the label or annotation key is prefixed by the API group of
+// the policies or one of its subdomains
+func policyFrameworkKey(key string) bool {
+	prefix := strings.SplitN(key, "/", 2)
+	if len(prefix) != 2 {
+		return false
+	}
+
+	return prefix[0] == common.APIGroup || strings.HasSuffix(prefix[0], "."+common.APIGroup)
+}
+
+func keyMatches(key string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, key); matched {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,134 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+)
+
+func TestCopyLabelPatterns(t *testing.T) {
+	tests := []struct {
+		name       string
+		copyLabels []string
+		annotation *string
+		expected   []string
+	}{
+		{name: "unset"},
+		{name: "option", copyLabels: []string{"team"}, expected: []string{"team"}},
+		{
+			name: "annotation", annotation: stringPtr("env, team.example.com/*"),
+			expected: []string{"env", "team.example.com/*"},
+		},
+		{
+			name: "option and annotation", copyLabels: []string{"team"}, annotation: stringPtr("env"),
+			expected: []string{"team", "env"},
+		},
+		{name: "empty annotation", annotation: stringPtr(""), expected: []string{}},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			r := newTestReconciler(t, &stubResolver{})
+			r.copyLabels = test.copyLabels
+
+			root := newTestPolicy("default")
+			if test.annotation != nil {
+				root.SetAnnotations(map[string]string{copyLabelsAnnotation: *test.annotation})
+			}
+
+			if patterns := r.copyLabelPatterns(root); !reflect.DeepEqual(patterns, test.expected) {
+				t.Fatalf("expected the patterns %#v, got %#v", test.expected, patterns)
+			}
+		})
+	}
+}
+
+func stringPtr(value string) *string {
+	return &value
+}
+
+func TestFilterCopiedMetadata(t *testing.T) {
+	replicated := newTestPolicy("default")
+	replicated.SetLabels(map[string]string{
+		"team": "a", "other": "b", common.RootPolicyLabel: "policies.policy",
+	})
+	replicated.SetAnnotations(map[string]string{
+		"team.example.com/owner":                           "c",
+		"example.com/owner":                                "d",
+		"policy.open-cluster-management.io/standards":      "NIST",
+		"propagator.policy.open-cluster-management.io/key": "e",
+	})
+
+	filterCopiedMetadata(replicated, []string{"team", "team.example.com/*"})
+
+	expectedLabels := map[string]string{"team": "a", common.RootPolicyLabel: "policies.policy"}
+	if !reflect.DeepEqual(replicated.GetLabels(), expectedLabels) {
+		t.Fatalf("expected the labels %v, got %v", expectedLabels, replicated.GetLabels())
+	}
+
+	expectedAnnotations := map[string]string{
+		"team.example.com/owner":                           "c",
+		"policy.open-cluster-management.io/standards":      "NIST",
+		"propagator.policy.open-cluster-management.io/key": "e",
+	}
+	if !reflect.DeepEqual(replicated.GetAnnotations(), expectedAnnotations) {
+		t.Fatalf("expected the annotations %v, got %v", expectedAnnotations, replicated.GetAnnotations())
+	}
+
+	// Nothing is filtered without patterns
+	unfiltered := newTestPolicy("default")
+	unfiltered.SetLabels(map[string]string{"other": "b"})
+	filterCopiedMetadata(unfiltered, nil)
+
+	if unfiltered.GetLabels()["other"] != "b" {
+		t.Fatal("expected all the labels to be copied without patterns")
+	}
+}
+
+func TestHandleRootPolicyCopyLabels(t *testing.T) {
+	root := newTestPolicy("default")
+	root.SetLabels(map[string]string{"team": "a", "other": "b"})
+	root.SetAnnotations(map[string]string{copyLabelsAnnotation: "team", "owner": "c"})
+
+	plr := newTestPlacementRule("plr", "cluster1")
+	pb := newTestPlacementBinding("pb", "plr", policiesv1.Subject{
+		APIGroup: policiesv1.SchemeGroupVersion.Group, Kind: policiesv1.Kind, Name: "policy",
+	})
+
+	r := newTestReconciler(t, &stubResolver{}, root, plr, pb)
+
+	err := r.handleRootPolicy(context.TODO(), root, nil)
+	if err != nil {
+		t.Fatalf("handleRootPolicy returned an error: %v", err)
+	}
+
+	replicated := &policiesv1.Policy{}
+
+	err = r.Get(context.TODO(), types.NamespacedName{Namespace: "cluster1", Name: "policies.policy"}, replicated)
+	if err != nil {
+		t.Fatalf("expected the policy to be replicated: %v", err)
+	}
+
+	if replicated.GetLabels()["team"] != "a" || replicated.GetLabels()[common.ClusterNameLabel] != "cluster1" {
+		t.Fatalf("expected the allowed and the propagator labels to be copied, got %v", replicated.GetLabels())
+	}
+
+	if _, ok := replicated.GetLabels()["other"]; ok {
+		t.Fatalf("expected the other labels to not be copied, got %v", replicated.GetLabels())
+	}
+
+	for _, key := range []string{"owner", copyLabelsAnnotation} {
+		if _, ok := replicated.GetAnnotations()[key]; ok {
+			t.Fatalf("expected the %s annotation to not be copied, got %v", key, replicated.GetAnnotations())
+		}
+	}
+}
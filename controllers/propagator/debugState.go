@@ -0,0 +1,281 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+)
+
+// DebugPath is the path of the endpoint dumping the propagation state of the root policies. The
+// callers must be allowed to get this non-resource URL.
+const DebugPath = "/debug/propagation"
+
+//+kubebuilder:rbac:groups=authentication.k8s.io,resources=tokenreviews,verbs=create
+//+kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
+
+// clusterPropagationState is the outcome of the last replication to a cluster
+type clusterPropagationState struct {
+	LastAttempt time.Time `json:"lastAttempt"`
+	LastError   string    `json:"lastError,omitempty"`
+}
+
+// rootPropagationState is what the propagator last resolved for a root policy
+type rootPropagationState struct {
+	// Bindings are the placement bindings of the root policy with their placement
+	Bindings []string `json:"bindings"`
+	// Decisions are the clusters selected by the placements in the format of <namespace>/<name>
+	Decisions []string                           `json:"decisions"`
+	Clusters  map[string]clusterPropagationState `json:"clusters"`
+	// Reconciling is whether the root policy is currently being reconciled
+	Reconciling bool `json:"reconciling"`
+	// RequeueAt is when the root policy is reconciled again after the last reconcile failed
+	RequeueAt *time.Time `json:"requeueAt,omitempty"`
+}
+
+// propagationState records the propagation state of every root policy for the debug endpoint
+type propagationState struct {
+	lock  sync.RWMutex
+	roots map[string]*rootPropagationState
+}
+
+func newPropagationState() *propagationState {
+	return &propagationState{roots: map[string]*rootPropagationState{}}
+}
+
+// root returns the state of the root policy, creating it if needed. The lock must be held.
+func (s *propagationState) root(rootKey string) *rootPropagationState {
+	state, ok := s.roots[rootKey]
+	if !ok {
+		state = &rootPropagationState{Clusters: map[string]clusterPropagationState{}}
+		s.roots[rootKey] = state
+	}
+
+	return state
+}
+
+// startReconcile marks the root policy as being reconciled
+func (s *propagationState) startReconcile(rootKey string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	state := s.root(rootKey)
+	state.Reconciling = true
+	state.RequeueAt = nil
+}
+
+// finishReconcile marks the root policy as reconciled. A non-zero requeueAt is when it is
+// reconciled again.
+func (s *propagationState) finishReconcile(rootKey string, requeueAt time.Time) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	state := s.root(rootKey)
+	state.Reconciling = false
+
+	if !requeueAt.IsZero() {
+		state.RequeueAt = &requeueAt
+	}
+}
+
+// setResolved records the placements and decisions of the root policy and forgets the clusters
+// that are no longer selected
+func (s *propagationState) setResolved(
+	rootKey string, placements []*policiesv1.Placement, allDecisions map[string]bool,
+) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	state := s.root(rootKey)
+	state.Bindings = []string{}
+
+	for _, p := range placements {
+		placement := p.Placement
+		if placement == "" {
+			placement = p.PlacementRule
+		}
+
+		state.Bindings = append(state.Bindings, p.PlacementBinding+" -> "+placement)
+	}
+
+	state.Decisions = make([]string, 0, len(allDecisions))
+	for decision := range allDecisions {
+		state.Decisions = append(state.Decisions, decision)
+	}
+
+	sort.Strings(state.Decisions)
+
+	for cluster := range state.Clusters {
+		if !allDecisions[cluster] {
+			delete(state.Clusters, cluster)
+		}
+	}
+}
+
+// recordAttempt records the outcome of a replication of the root policy to the cluster
+func (s *propagationState) recordAttempt(rootKey string, cluster string, now time.Time, err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	clusterState := clusterPropagationState{LastAttempt: now}
+	if err != nil {
+		clusterState.LastError = err.Error()
+	}
+
+	s.root(rootKey).Clusters[cluster] = clusterState
+}
+
+// clusterDecision returns the cluster selected by the last resolved decisions of the root policy in
+// the cluster namespace. The last return value is false when the decisions of the root policy were
+// not resolved yet.
+func (s *propagationState) clusterDecision(rootKey string, clusterNamespace string) (string, bool, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	state, ok := s.roots[rootKey]
+	if !ok || state.Decisions == nil {
+		return "", false, false
+	}
+
+	for _, decision := range state.Decisions {
+		// The decisions are in the format of <namespace>/<name>
+		if strings.HasPrefix(decision, clusterNamespace+"/") {
+			return strings.TrimPrefix(decision, clusterNamespace+"/"), true, true
+		}
+	}
+
+	return "", false, true
+}
+
+// reconciling returns whether the root policy is currently being reconciled
+func (s *propagationState) reconciling(rootKey string) bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	state, ok := s.roots[rootKey]
+
+	return ok && state.Reconciling
+}
+
+// delete forgets the root policy
+func (s *propagationState) delete(rootKey string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.roots, rootKey)
+}
+
+// snapshot returns a copy of the state of all the root policies
+func (s *propagationState) snapshot() map[string]rootPropagationState {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	result := make(map[string]rootPropagationState, len(s.roots))
+
+	for rootKey, state := range s.roots {
+		stateCopy := *state
+		stateCopy.Bindings = append([]string{}, state.Bindings...)
+		stateCopy.Decisions = append([]string{}, state.Decisions...)
+		stateCopy.Clusters = make(map[string]clusterPropagationState, len(state.Clusters))
+
+		for cluster, clusterState := range state.Clusters {
+			stateCopy.Clusters[cluster] = clusterState
+		}
+
+		result[rootKey] = stateCopy
+	}
+
+	return result
+}
+
+// DebugHandler returns the handler of the debug endpoint. The callers are authenticated with their
+// bearer token and must be allowed to get the DebugPath non-resource URL.
+func (r *PolicyReconciler) DebugHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if status := r.authorizeDebugRequest(req); status != http.StatusOK {
+			http.Error(w, http.StatusText(status), status)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+
+		if err := encoder.Encode(r.propagationState.snapshot()); err != nil {
+			log.Error(err, "Failed to write the propagation state...")
+		}
+	})
+}
+
+// authorizeDebugRequest returns the HTTP status of the authentication and authorization of the
+// request to the debug endpoint
+func (r *PolicyReconciler) authorizeDebugRequest(req *http.Request) int {
+	token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if token == "" || token == req.Header.Get("Authorization") {
+		return http.StatusUnauthorized
+	}
+
+	if r.kubeClient == nil {
+		return http.StatusForbidden
+	}
+
+	tokenReview, err := (*r.kubeClient).AuthenticationV1().TokenReviews().Create(
+		req.Context(),
+		&authenticationv1.TokenReview{Spec: authenticationv1.TokenReviewSpec{Token: token}},
+		metav1.CreateOptions{},
+	)
+	if err != nil {
+		log.Error(err, "Failed to review the token of the debug request...")
+
+		return http.StatusInternalServerError
+	}
+
+	if !tokenReview.Status.Authenticated {
+		return http.StatusUnauthorized
+	}
+
+	user := tokenReview.Status.User
+	extra := map[string]authorizationv1.ExtraValue{}
+
+	for key, value := range user.Extra {
+		extra[key] = authorizationv1.ExtraValue(value)
+	}
+
+	review, err := (*r.kubeClient).AuthorizationV1().SubjectAccessReviews().Create(
+		req.Context(),
+		&authorizationv1.SubjectAccessReview{Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   user.Username,
+			Groups: user.Groups,
+			UID:    user.UID,
+			Extra:  extra,
+			NonResourceAttributes: &authorizationv1.NonResourceAttributes{
+				Path: DebugPath,
+				Verb: "get",
+			},
+		}},
+		metav1.CreateOptions{},
+	)
+	if err != nil {
+		log.Error(err, "Failed to review the access of the debug request...")
+
+		return http.StatusInternalServerError
+	}
+
+	if !review.Status.Allowed {
+		return http.StatusForbidden
+	}
+
+	return http.StatusOK
+}
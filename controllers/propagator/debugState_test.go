@@ -0,0 +1,118 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+)
+
+func TestPropagationState(t *testing.T) {
+	state := newPropagationState()
+	now := time.Now()
+
+	state.startReconcile("policies/policy")
+	state.recordAttempt("policies/policy", "cluster1/cluster1", now, nil)
+	state.recordAttempt("policies/policy", "cluster2/cluster2", now, errors.New("boom"))
+	state.setResolved(
+		"policies/policy",
+		[]*policiesv1.Placement{{PlacementBinding: "pb", PlacementRule: "plr"}},
+		map[string]bool{"cluster2/cluster2": true},
+	)
+	state.finishReconcile("policies/policy", now.Add(time.Minute))
+
+	root := state.snapshot()["policies/policy"]
+
+	if root.Reconciling || root.RequeueAt == nil {
+		t.Fatalf("expected the root policy to be requeued after its reconcile, got %+v", root)
+	}
+
+	if len(root.Bindings) != 1 || root.Bindings[0] != "pb -> plr" {
+		t.Fatalf("expected the binding to the placement rule, got %v", root.Bindings)
+	}
+
+	if len(root.Clusters) != 1 || root.Clusters["cluster2/cluster2"].LastError != "boom" {
+		t.Fatalf("expected only the selected cluster with its error, got %v", root.Clusters)
+	}
+
+	state.delete("policies/policy")
+
+	if len(state.snapshot()) != 0 {
+		t.Fatal("expected the deleted root policy to be forgotten")
+	}
+}
+
+func TestDebugHandler(t *testing.T) {
+	r := newTestReconciler(t, &stubResolver{})
+	r.propagationState.startReconcile("policies/policy")
+
+	clientset := kubefake.NewSimpleClientset()
+	clientset.PrependReactor("create", "tokenreviews",
+		func(action clienttesting.Action) (bool, runtime.Object, error) {
+			review := action.(clienttesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+			review.Status.Authenticated = review.Spec.Token != "invalid"
+			review.Status.User.Username = review.Spec.Token
+
+			return true, review, nil
+		})
+	clientset.PrependReactor("create", "subjectaccessreviews",
+		func(action clienttesting.Action) (bool, runtime.Object, error) {
+			review := action.(clienttesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+			review.Status.Allowed = review.Spec.User == "admin" && review.Spec.NonResourceAttributes.Path == DebugPath
+
+			return true, review, nil
+		})
+
+	var kubeClient kubernetes.Interface = clientset
+	r.kubeClient = &kubeClient
+
+	tests := []struct {
+		authorization string
+		expected      int
+	}{
+		{"", http.StatusUnauthorized},
+		{"Bearer invalid", http.StatusUnauthorized},
+		{"Bearer user", http.StatusForbidden},
+		{"Bearer admin", http.StatusOK},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, DebugPath, nil)
+		if test.authorization != "" {
+			req.Header.Set("Authorization", test.authorization)
+		}
+
+		recorder := httptest.NewRecorder()
+		r.DebugHandler().ServeHTTP(recorder, req)
+
+		if recorder.Code != test.expected {
+			t.Fatalf("expected the status %d for %q, got %d", test.expected, test.authorization, recorder.Code)
+		}
+
+		if recorder.Code != http.StatusOK {
+			continue
+		}
+
+		state := map[string]rootPropagationState{}
+		if err := json.Unmarshal(recorder.Body.Bytes(), &state); err != nil {
+			t.Fatalf("failed to parse the propagation state: %v", err)
+		}
+
+		if !state["policies/policy"].Reconciling {
+			t.Fatalf("expected the root policy being reconciled, got %v", state)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"sync"
+
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+// The configuration of the maximum number of replicated policies of a root policy that are created
+// or updated at the same time.
+const decisionConcurrencyEnvName = "CONTROLLER_CONFIG_DECISION_CONCURRENCY"
+const decisionConcurrencyDefault = 10
+
+// forEachDecision calls handle for every placement decision with at most decisionConcurrency calls
+// running at the same time, and returns when all the calls returned
+func (r *PolicyReconciler) forEachDecision(
+	decisions []appsv1.PlacementDecision, handle func(decision appsv1.PlacementDecision),
+) {
+	workers := r.decisionConcurrency
+	if workers <= 0 {
+		workers = 1
+	}
+
+	if workers > len(decisions) {
+		workers = len(decisions)
+	}
+
+	queue := make(chan appsv1.PlacementDecision)
+
+	var wg sync.WaitGroup
+
+	wg.Add(workers)
+
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+
+			for decision := range queue {
+				handle(decision)
+			}
+		}()
+	}
+
+	for _, decision := range decisions {
+		queue <- decision
+	}
+
+	close(queue)
+	wg.Wait()
+}
@@ -0,0 +1,65 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+func TestForEachDecision(t *testing.T) {
+	r := &PolicyReconciler{decisionConcurrency: 3}
+
+	decisions := make([]appsv1.PlacementDecision, 10)
+	for i := range decisions {
+		decisions[i] = appsv1.PlacementDecision{ClusterName: string(rune('a' + i))}
+	}
+
+	var running, maxRunning int32
+
+	var lock sync.Mutex
+
+	handled := map[string]bool{}
+
+	r.forEachDecision(decisions, func(decision appsv1.PlacementDecision) {
+		current := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+
+		for {
+			previous := atomic.LoadInt32(&maxRunning)
+			if current <= previous || atomic.CompareAndSwapInt32(&maxRunning, previous, current) {
+				break
+			}
+		}
+
+		time.Sleep(10 * time.Millisecond)
+
+		lock.Lock()
+		handled[decision.ClusterName] = true
+		lock.Unlock()
+	})
+
+	if len(handled) != len(decisions) {
+		t.Fatalf("expected all the %d decisions to be handled, got %d", len(decisions), len(handled))
+	}
+
+	if maxRunning > 3 {
+		t.Fatalf("expected at most 3 decisions to be handled at the same time, got %d", maxRunning)
+	}
+
+	if maxRunning < 2 {
+		t.Fatalf("expected the decisions to be handled concurrently, got %d at most", maxRunning)
+	}
+}
+
+func TestForEachDecisionEmpty(t *testing.T) {
+	r := &PolicyReconciler{decisionConcurrency: 3}
+
+	r.forEachDecision(nil, func(decision appsv1.PlacementDecision) {
+		t.Fatal("expected no decision to be handled")
+	})
+}
@@ -0,0 +1,279 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	clusterv1alpha1 "github.com/open-cluster-management/api/cluster/v1alpha1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+)
+
+// decisionDiff is what changed for a queued root policy
+type decisionDiff struct {
+	// changed are the names of the clusters added to or removed from the placement decisions of
+	// the root policy
+	changed map[string]bool
+	// full is whether something else changed, so all the replicated policies must be reconciled
+	full bool
+}
+
+// decisionDiffs holds the clusters added to or removed from the placement decisions of the queued
+// root policies
+// when the IncrementalDecisions feature is enabled. A root policy without a diff is reconciled
+// fully.
+type decisionDiffs struct {
+	lock  sync.Mutex
+	diffs map[types.NamespacedName]*decisionDiff
+}
+
+func newDecisionDiffs() *decisionDiffs {
+	return &decisionDiffs{diffs: map[types.NamespacedName]*decisionDiff{}}
+}
+
+// diff returns the diff of the root policy, creating it if needed. The lock must be held.
+func (d *decisionDiffs) diff(root types.NamespacedName) *decisionDiff {
+	diff, ok := d.diffs[root]
+	if !ok {
+		diff = &decisionDiff{changed: map[string]bool{}}
+		d.diffs[root] = diff
+	}
+
+	return diff
+}
+
+// record adds the clusters added to or removed from the placement decisions to the diff of the root
+// policy
+func (d *decisionDiffs) record(root types.NamespacedName, changed []string) {
+	if !common.FeatureEnabled(common.IncrementalDecisions) {
+		return
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	diff := d.diff(root)
+
+	for _, cluster := range changed {
+		diff.changed[cluster] = true
+	}
+}
+
+// markFull makes the next reconcile of the root policy reconcile all its replicated policies
+func (d *decisionDiffs) markFull(root types.NamespacedName) {
+	if !common.FeatureEnabled(common.IncrementalDecisions) {
+		return
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.diff(root).full = true
+}
+
+// take returns the clusters added to or removed from the placement decisions of the root policy and
+// forgets them.
+// It returns nil when all the replicated policies must be reconciled.
+func (d *decisionDiffs) take(root types.NamespacedName) map[string]bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	diff, ok := d.diffs[root]
+	if !ok {
+		return nil
+	}
+
+	delete(d.diffs, root)
+
+	if diff.full || !common.FeatureEnabled(common.IncrementalDecisions) {
+		return nil
+	}
+
+	return diff.changed
+}
+
+// forget deletes the diff of the deleted root policy
+func (d *decisionDiffs) forget(root types.NamespacedName) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	delete(d.diffs, root)
+}
+
+// keepClusterStatus keeps the replication failure or the hub template error of the cluster from
+// the status of the root policy, since the policy isn't replicated to the cluster again
+func keepClusterStatus(
+	instance *policiesv1.Policy, key string, failedClusters map[string]replicationFailure,
+	templateErrors map[string]string,
+) {
+	for _, cpcs := range instance.Status.Status {
+		if cpcs.ClusterNamespace+"/"+cpcs.ClusterName != key {
+			continue
+		}
+
+		if cpcs.Reason != "" {
+			failedClusters[key] = replicationFailure{reason: cpcs.Reason, message: cpcs.Message}
+		} else if strings.HasPrefix(cpcs.Message, templateErrorPrefix) {
+			templateErrors[key] = strings.TrimPrefix(cpcs.Message, templateErrorPrefix)
+		}
+
+		return
+	}
+}
+
+// decisionClusters returns the names of the clusters of the PlacementDecision
+func decisionClusters(object client.Object) []string {
+	decision, ok := object.(*clusterv1alpha1.PlacementDecision)
+	if !ok {
+		return nil
+	}
+
+	clusters := make([]string, 0, len(decision.Status.Decisions))
+	for _, cluster := range decision.Status.Decisions {
+		clusters = append(clusters, cluster.ClusterName)
+	}
+
+	return clusters
+}
+
+// placementDecisionHandler enqueues the root policies bound to the Placement of the
+// PlacementDecision with the clusters added to or removed from it, so that only their replicated
+// policies are created, updated, or deleted. A removed cluster may still be selected by another
+// PlacementBinding without its bindingOverrides. The updates that don't change the decisions or
+// the labels enqueue nothing.
+type placementDecisionHandler struct {
+	toRequests handler.MapFunc
+	diffs      *decisionDiffs
+}
+
+var _ handler.EventHandler = &placementDecisionHandler{}
+
+// Create implements EventHandler
+func (h *placementDecisionHandler) Create(evt event.CreateEvent, q workqueue.RateLimitingInterface) {
+	h.enqueue(q, evt.Object, decisionClusters(evt.Object), false)
+}
+
+// Update implements EventHandler
+func (h *placementDecisionHandler) Update(evt event.UpdateEvent, q workqueue.RateLimitingInterface) {
+	oldClusters := decisionClusters(evt.ObjectOld)
+	newClusters := decisionClusters(evt.ObjectNew)
+	// The labels of the PlacementDecisions set the decision groups of the clusters
+	labelsChanged := !reflect.DeepEqual(evt.ObjectOld.GetLabels(), evt.ObjectNew.GetLabels())
+
+	if !labelsChanged && reflect.DeepEqual(oldClusters, newClusters) {
+		return
+	}
+
+	previous := map[string]bool{}
+	for _, cluster := range oldClusters {
+		previous[cluster] = true
+	}
+
+	current := map[string]bool{}
+	changed := []string{}
+
+	for _, cluster := range newClusters {
+		current[cluster] = true
+
+		if !previous[cluster] {
+			changed = append(changed, cluster)
+		}
+	}
+
+	for _, cluster := range oldClusters {
+		if !current[cluster] {
+			changed = append(changed, cluster)
+		}
+	}
+
+	h.enqueue(q, evt.ObjectNew, changed, labelsChanged)
+}
+
+// Delete implements EventHandler
+func (h *placementDecisionHandler) Delete(evt event.DeleteEvent, q workqueue.RateLimitingInterface) {
+	h.enqueue(q, evt.Object, decisionClusters(evt.Object), false)
+}
+
+// Generic implements EventHandler
+func (h *placementDecisionHandler) Generic(evt event.GenericEvent, q workqueue.RateLimitingInterface) {
+	h.enqueue(q, evt.Object, nil, true)
+}
+
+func (h *placementDecisionHandler) enqueue(
+	q workqueue.RateLimitingInterface, object client.Object, changed []string, full bool,
+) {
+	for _, req := range h.toRequests(object) {
+		if full {
+			h.diffs.markFull(req.NamespacedName)
+		} else {
+			h.diffs.record(req.NamespacedName, changed)
+		}
+
+		q.Add(req)
+	}
+}
+
+// fullReconcileHandler makes the root policies enqueued by the handler reconcile all their
+// replicated policies, since the change isn't limited to the clusters of their placement decisions
+type fullReconcileHandler struct {
+	handler.EventHandler
+	diffs *decisionDiffs
+}
+
+// Create implements EventHandler
+func (h *fullReconcileHandler) Create(evt event.CreateEvent, q workqueue.RateLimitingInterface) {
+	h.EventHandler.Create(evt, &fullReconcileQueue{RateLimitingInterface: q, diffs: h.diffs})
+}
+
+// Update implements EventHandler
+func (h *fullReconcileHandler) Update(evt event.UpdateEvent, q workqueue.RateLimitingInterface) {
+	h.EventHandler.Update(evt, &fullReconcileQueue{RateLimitingInterface: q, diffs: h.diffs})
+}
+
+// Delete implements EventHandler
+func (h *fullReconcileHandler) Delete(evt event.DeleteEvent, q workqueue.RateLimitingInterface) {
+	h.EventHandler.Delete(evt, &fullReconcileQueue{RateLimitingInterface: q, diffs: h.diffs})
+}
+
+// Generic implements EventHandler
+func (h *fullReconcileHandler) Generic(evt event.GenericEvent, q workqueue.RateLimitingInterface) {
+	h.EventHandler.Generic(evt, &fullReconcileQueue{RateLimitingInterface: q, diffs: h.diffs})
+}
+
+// fullReconcileQueue marks the root policies added to the queue for a full reconcile
+type fullReconcileQueue struct {
+	workqueue.RateLimitingInterface
+	diffs *decisionDiffs
+}
+
+func (q *fullReconcileQueue) markFull(item interface{}) {
+	if req, ok := item.(reconcile.Request); ok {
+		q.diffs.markFull(req.NamespacedName)
+	}
+}
+
+func (q *fullReconcileQueue) Add(item interface{}) {
+	q.markFull(item)
+	q.RateLimitingInterface.Add(item)
+}
+
+func (q *fullReconcileQueue) AddAfter(item interface{}, duration time.Duration) {
+	q.markFull(item)
+	q.RateLimitingInterface.AddAfter(item, duration)
+}
+
+func (q *fullReconcileQueue) AddRateLimited(item interface{}) {
+	q.markFull(item)
+	q.RateLimitingInterface.AddRateLimited(item)
+}
@@ -0,0 +1,218 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	clusterv1alpha1 "github.com/open-cluster-management/api/cluster/v1alpha1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+func newTestPlacementDecision(clusters ...string) *clusterv1alpha1.PlacementDecision {
+	decision := &clusterv1alpha1.PlacementDecision{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "placement-decision-1",
+			Namespace: "policies",
+			Labels:    map[string]string{"cluster.open-cluster-management.io/placement": "placement"},
+		},
+	}
+
+	for _, cluster := range clusters {
+		decision.Status.Decisions = append(
+			decision.Status.Decisions, clusterv1alpha1.ClusterDecision{ClusterName: cluster},
+		)
+	}
+
+	return decision
+}
+
+func TestPlacementDecisionHandler(t *testing.T) {
+	if err := common.SetFeatureGates("IncrementalDecisions=true"); err != nil {
+		t.Fatalf("failed to enable the feature gate: %v", err)
+	}
+	defer func() { _ = common.SetFeatureGates("") }()
+
+	root := types.NamespacedName{Namespace: "policies", Name: "policy"}
+	diffs := newDecisionDiffs()
+	h := &placementDecisionHandler{
+		toRequests: func(client.Object) []reconcile.Request {
+			return []reconcile.Request{{NamespacedName: root}}
+		},
+		diffs: diffs,
+	}
+
+	q := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	defer q.ShutDown()
+
+	h.Update(event.UpdateEvent{
+		ObjectOld: newTestPlacementDecision("cluster1"),
+		ObjectNew: newTestPlacementDecision("cluster1"),
+	}, q)
+
+	if q.Len() != 0 {
+		t.Fatalf("expected no request for unchanged decisions, got %d", q.Len())
+	}
+
+	h.Update(event.UpdateEvent{
+		ObjectOld: newTestPlacementDecision("cluster1", "cluster2"),
+		ObjectNew: newTestPlacementDecision("cluster1", "cluster3"),
+	}, q)
+
+	if q.Len() != 1 {
+		t.Fatalf("expected the root policy to be enqueued, got %d requests", q.Len())
+	}
+
+	if changed := diffs.take(root); !reflect.DeepEqual(changed, map[string]bool{"cluster2": true, "cluster3": true}) {
+		t.Fatalf("expected only cluster2 and cluster3 to change, got %v", changed)
+	}
+
+	if changed := diffs.take(root); changed != nil {
+		t.Fatalf("expected a full reconcile once the diff is taken, got %v", changed)
+	}
+
+	h.Delete(event.DeleteEvent{Object: newTestPlacementDecision("cluster1")}, q)
+
+	if changed := diffs.take(root); !reflect.DeepEqual(changed, map[string]bool{"cluster1": true}) {
+		t.Fatalf("expected the clusters of the deleted PlacementDecision to change, got %v", changed)
+	}
+
+	relabeled := newTestPlacementDecision("cluster1", "cluster2")
+	relabeled.Labels[decisionGroupNameLabel] = "canary"
+
+	h.Update(event.UpdateEvent{ObjectOld: newTestPlacementDecision("cluster1"), ObjectNew: relabeled}, q)
+
+	if changed := diffs.take(root); changed != nil {
+		t.Fatalf("expected a full reconcile when the decision groups change, got %v", changed)
+	}
+}
+
+func TestFullReconcileHandler(t *testing.T) {
+	if err := common.SetFeatureGates("IncrementalDecisions=true"); err != nil {
+		t.Fatalf("failed to enable the feature gate: %v", err)
+	}
+	defer func() { _ = common.SetFeatureGates("") }()
+
+	root := types.NamespacedName{Namespace: "policies", Name: "policy"}
+	diffs := newDecisionDiffs()
+	diffs.record(root, []string{"cluster1"})
+
+	h := &fullReconcileHandler{
+		EventHandler: &handler.EnqueueRequestForObject{},
+		diffs:        diffs,
+	}
+
+	q := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	defer q.ShutDown()
+
+	h.Generic(event.GenericEvent{Object: newTestPolicy("default")}, q)
+
+	if q.Len() != 1 {
+		t.Fatalf("expected the root policy to be enqueued, got %d requests", q.Len())
+	}
+
+	if changed := diffs.take(root); changed != nil {
+		t.Fatalf("expected a full reconcile after another change, got %v", changed)
+	}
+}
+
+func TestDecisionDiffsDisabled(t *testing.T) {
+	root := types.NamespacedName{Namespace: "policies", Name: "policy"}
+	diffs := newDecisionDiffs()
+	diffs.record(root, []string{"cluster1"})
+
+	if changed := diffs.take(root); changed != nil {
+		t.Fatalf("expected a full reconcile without the feature gate, got %v", changed)
+	}
+}
+
+func TestHandleRootPolicyChangedClusters(t *testing.T) {
+	root := newTestPolicy("default")
+	root.Status.Status = []*policiesv1.CompliancePerClusterStatus{
+		{ClusterName: "cluster1", ClusterNamespace: "cluster1", ComplianceState: policiesv1.Compliant},
+		{ClusterName: "cluster3", ClusterNamespace: "cluster3", ComplianceState: policiesv1.Compliant},
+		{
+			ClusterName: "cluster4", ClusterNamespace: "cluster4", ComplianceState: policiesv1.NonCompliant,
+			Reason: reasonReplicationFailed,
+		},
+	}
+
+	// The outdated replicated policy must not be updated since its cluster didn't change
+	outdated := newTestReplicatedPolicy(newTestPolicy("outdated"), "cluster1", policiesv1.Compliant)
+	orphan := newTestReplicatedPolicy(root, "cluster3", policiesv1.Compliant)
+
+	plr := &appsv1.PlacementRule{
+		ObjectMeta: metav1.ObjectMeta{Name: "plr", Namespace: "policies"},
+		Status: appsv1.PlacementRuleStatus{
+			Decisions: []appsv1.PlacementDecision{
+				{ClusterName: "cluster1", ClusterNamespace: "cluster1"},
+				{ClusterName: "cluster2", ClusterNamespace: "cluster2"},
+				{ClusterName: "cluster4", ClusterNamespace: "cluster4"},
+			},
+		},
+	}
+	pb := &policiesv1.PlacementBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "pb", Namespace: "policies"},
+		PlacementRef: policiesv1.Subject{
+			APIGroup: appsv1.SchemeGroupVersion.Group, Kind: "PlacementRule", Name: "plr",
+		},
+		Subjects: []policiesv1.Subject{
+			{APIGroup: policiesv1.SchemeGroupVersion.Group, Kind: policiesv1.Kind, Name: "policy"},
+		},
+	}
+
+	r := newTestReconciler(t, &stubResolver{}, root, outdated, orphan, plr, pb)
+
+	err := r.handleRootPolicy(context.TODO(), root, map[string]bool{"cluster2": true})
+	if err != nil {
+		t.Fatalf("handleRootPolicy returned an error: %v", err)
+	}
+
+	replicatedPlc := &policiesv1.Policy{}
+
+	err = r.Get(context.TODO(), types.NamespacedName{Namespace: "cluster2", Name: "policies.policy"}, replicatedPlc)
+	if err != nil {
+		t.Fatalf("expected the policy to be replicated to the added cluster: %v", err)
+	}
+
+	err = r.Get(context.TODO(), types.NamespacedName{Namespace: "cluster1", Name: "policies.policy"}, replicatedPlc)
+	if err != nil {
+		t.Fatalf("failed to get the existing replicated policy: %v", err)
+	}
+
+	if !reflect.DeepEqual(replicatedPlc.Spec, outdated.Spec) {
+		t.Fatal("expected the existing replicated policy to be left as is")
+	}
+
+	err = r.Get(context.TODO(), types.NamespacedName{Namespace: "cluster3", Name: "policies.policy"}, replicatedPlc)
+	if !k8serrors.IsNotFound(err) {
+		t.Fatalf("expected the replicated policy of the removed cluster to be deleted, got %v", err)
+	}
+
+	updated := &policiesv1.Policy{}
+	if err := r.Get(context.TODO(), types.NamespacedName{Namespace: "policies", Name: "policy"}, updated); err != nil {
+		t.Fatalf("failed to get the root policy: %v", err)
+	}
+
+	reasons := map[string]string{}
+	for _, cpcs := range updated.Status.Status {
+		reasons[cpcs.ClusterName] = cpcs.Reason
+	}
+
+	if reason, ok := reasons["cluster4"]; !ok || reason != reasonReplicationFailed {
+		t.Fatalf("expected the failed cluster to keep its status, got %v", updated.Status.Status)
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"sort"
+	"strconv"
+
+	clusterv1alpha1 "github.com/open-cluster-management/api/cluster/v1alpha1"
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+)
+
+// The labels the Placement controller sets on the PlacementDecisions of a Placement with decision
+// groups
+const (
+	decisionGroupNameLabel  = "cluster.open-cluster-management.io/decision-group-name"
+	decisionGroupIndexLabel = "cluster.open-cluster-management.io/decision-group-index"
+)
+
+// getDecisionGroups returns the decision groups of the PlacementDecisions in the order of their
+// index. PlacementDecisions without a decision group name are not part of any group.
+func getDecisionGroups(decisions []clusterv1alpha1.PlacementDecision) []policiesv1.DecisionGroup {
+	groups := []policiesv1.DecisionGroup{}
+	indexes := map[string]int{}
+	positions := map[string]int{}
+
+	for _, pld := range decisions {
+		name := pld.GetLabels()[decisionGroupNameLabel]
+		if name == "" {
+			continue
+		}
+
+		position, ok := positions[name]
+		if !ok {
+			index, err := strconv.Atoi(pld.GetLabels()[decisionGroupIndexLabel])
+			if err != nil {
+				index = len(decisions)
+			}
+
+			position = len(groups)
+			positions[name] = position
+			indexes[name] = index
+			groups = append(groups, policiesv1.DecisionGroup{Name: name})
+		}
+
+		for _, decision := range pld.Status.Decisions {
+			groups[position].Clusters = append(groups[position].Clusters, decision.ClusterName)
+		}
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		return indexes[groups[i].Name] < indexes[groups[j].Name]
+	})
+
+	for _, group := range groups {
+		sort.Strings(group.Clusters)
+	}
+
+	return groups
+}
+
+// aggregateCompliance returns NonCompliant if any cluster is NonCompliant, Pending if any cluster
+// is Pending, Compliant if all the clusters are Compliant, and an empty compliance state otherwise
+func aggregateCompliance(status []*policiesv1.CompliancePerClusterStatus) policiesv1.ComplianceState {
+	isCompliant := true
+	isPending := false
+	counted := 0
+
+	for _, cpcs := range status {
+		// The incompatible clusters don't have the policy, so they don't affect the compliance
+		if cpcs.Reason == reasonClusterIncompatible {
+			continue
+		}
+
+		counted++
+
+		if cpcs.ComplianceState == policiesv1.NonCompliant {
+			return policiesv1.NonCompliant
+		} else if cpcs.ComplianceState == policiesv1.Pending {
+			isPending = true
+		} else if cpcs.ComplianceState == "" {
+			isCompliant = false
+		}
+	}
+
+	if isPending {
+		return policiesv1.Pending
+	}
+
+	// set to compliant only when all status are compliant
+	if counted > 0 && isCompliant {
+		return policiesv1.Compliant
+	}
+
+	return ""
+}
+
+// groupStatusByDecisionGroup sets the decision group of each per-cluster status entry, orders the
+// entries by decision group the same way the Placement does, and sets the compliance of each
+// decision group. Entries of clusters that are not in a decision group come last.
+func groupStatusByDecisionGroup(placements []*policiesv1.Placement, status []*policiesv1.CompliancePerClusterStatus) {
+	clusterGroups := map[string]string{}
+	groupOrder := map[string]int{}
+
+	for _, placement := range placements {
+		for _, group := range placement.DecisionGroups {
+			if _, ok := groupOrder[group.Name]; !ok {
+				groupOrder[group.Name] = len(groupOrder)
+			}
+
+			for _, cluster := range group.Clusters {
+				if _, ok := clusterGroups[cluster]; !ok {
+					clusterGroups[cluster] = group.Name
+				}
+			}
+		}
+	}
+
+	if len(groupOrder) == 0 {
+		return
+	}
+
+	clusterStatus := map[string]*policiesv1.CompliancePerClusterStatus{}
+
+	for _, cpcs := range status {
+		cpcs.DecisionGroup = clusterGroups[cpcs.ClusterName]
+		clusterStatus[cpcs.ClusterName] = cpcs
+	}
+
+	sort.SliceStable(status, func(i, j int) bool {
+		iOrder, iGrouped := groupOrder[status[i].DecisionGroup]
+		jOrder, jGrouped := groupOrder[status[j].DecisionGroup]
+
+		if iGrouped != jGrouped {
+			return iGrouped
+		}
+
+		return iOrder < jOrder
+	})
+
+	for _, placement := range placements {
+		for i, group := range placement.DecisionGroups {
+			groupStatus := make([]*policiesv1.CompliancePerClusterStatus, 0, len(group.Clusters))
+
+			for _, cluster := range group.Clusters {
+				if cpcs, ok := clusterStatus[cluster]; ok {
+					groupStatus = append(groupStatus, cpcs)
+				}
+			}
+
+			placement.DecisionGroups[i].ComplianceState = aggregateCompliance(groupStatus)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	clusterv1alpha1 "github.com/open-cluster-management/api/cluster/v1alpha1"
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+)
+
+func TestDecisionGroups(t *testing.T) {
+	decision := func(group string, index string, clusters ...string) clusterv1alpha1.PlacementDecision {
+		pld := clusterv1alpha1.PlacementDecision{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{}}}
+		if group != "" {
+			pld.Labels[decisionGroupNameLabel] = group
+			pld.Labels[decisionGroupIndexLabel] = index
+		}
+
+		for _, cluster := range clusters {
+			pld.Status.Decisions = append(pld.Status.Decisions, clusterv1alpha1.ClusterDecision{ClusterName: cluster})
+		}
+
+		return pld
+	}
+
+	groups := getDecisionGroups([]clusterv1alpha1.PlacementDecision{
+		decision("prod", "1", "c4", "c3"),
+		decision("canary", "0", "c2"),
+		decision("prod", "1", "c1"),
+		decision("", "", "c5"),
+	})
+
+	expectedGroups := []policiesv1.DecisionGroup{
+		{Name: "canary", Clusters: []string{"c2"}},
+		{Name: "prod", Clusters: []string{"c1", "c3", "c4"}},
+	}
+	if !reflect.DeepEqual(groups, expectedGroups) {
+		t.Fatalf("expected the decision groups %v, got %v", expectedGroups, groups)
+	}
+
+	placements := []*policiesv1.Placement{{PlacementBinding: "pb", Placement: "pl", DecisionGroups: groups}}
+	status := []*policiesv1.CompliancePerClusterStatus{
+		{ClusterName: "c1", ComplianceState: policiesv1.Compliant},
+		{ClusterName: "c2", ComplianceState: policiesv1.Compliant},
+		{ClusterName: "c3", ComplianceState: policiesv1.NonCompliant},
+		{ClusterName: "c4", ComplianceState: policiesv1.Compliant},
+		{ClusterName: "c5"},
+	}
+
+	groupStatusByDecisionGroup(placements, status)
+
+	order := []string{}
+	for _, cpcs := range status {
+		order = append(order, cpcs.DecisionGroup+"/"+cpcs.ClusterName)
+	}
+
+	expectedOrder := []string{"canary/c2", "prod/c1", "prod/c3", "prod/c4", "/c5"}
+	if !reflect.DeepEqual(order, expectedOrder) {
+		t.Fatalf("expected the status order %v, got %v", expectedOrder, order)
+	}
+
+	if placements[0].DecisionGroups[0].ComplianceState != policiesv1.Compliant {
+		t.Fatalf("expected the canary group to be Compliant, got %s", placements[0].DecisionGroups[0].ComplianceState)
+	}
+
+	if placements[0].DecisionGroups[1].ComplianceState != policiesv1.NonCompliant {
+		t.Fatalf("expected the prod group to be NonCompliant, got %s", placements[0].DecisionGroups[1].ComplianceState)
+	}
+}
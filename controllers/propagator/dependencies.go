@@ -0,0 +1,228 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+)
+
+// policyDependency returns the namespaced name of the root policy of the dependency, or false if
+// the dependency is not a Policy, in which case it is checked on the managed cluster
+func policyDependency(instance *policiesv1.Policy, dep policiesv1.PolicyDependency) (types.NamespacedName, bool) {
+	if dep.Kind != policiesv1.Kind {
+		return types.NamespacedName{}, false
+	}
+
+	if dep.APIVersion != "" {
+		gv, err := schema.ParseGroupVersion(dep.APIVersion)
+		if err != nil || gv.Group != policiesv1.GroupVersion.Group {
+			return types.NamespacedName{}, false
+		}
+	}
+
+	namespace := dep.Namespace
+	if namespace == "" {
+		namespace = instance.GetNamespace()
+	}
+
+	return types.NamespacedName{Namespace: namespace, Name: dep.Name}, true
+}
+
+// unmetDependencies returns a message listing the Policy dependencies of the policy that don't
+// have the required compliance state on the cluster, or an empty string if all of them are met. A
+// dependency that isn't replicated to the cluster is Pending.
+func (r *PolicyReconciler) unmetDependencies(
+	ctx context.Context, instance *policiesv1.Policy, clusterNamespace string,
+) (string, error) {
+	unmet := []string{}
+
+	for _, dep := range instance.Spec.Dependencies {
+		root, ok := policyDependency(instance, dep)
+		if !ok {
+			continue
+		}
+
+		required := dep.Compliance
+		if required == "" {
+			required = policiesv1.Compliant
+		}
+
+		replicated := &policiesv1.Policy{}
+
+		err := r.Get(ctx, types.NamespacedName{
+			Namespace: clusterNamespace, Name: root.Namespace + "." + root.Name,
+		}, replicated)
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return "", err
+		}
+
+		compliance := policiesv1.Pending
+		if err == nil && replicatedCompliance(replicated) != "" {
+			compliance = replicatedCompliance(replicated)
+		}
+
+		if compliance != required {
+			unmet = append(unmet, fmt.Sprintf("%s is %s instead of %s", root.String(), compliance, required))
+		}
+	}
+
+	if len(unmet) == 0 {
+		return "", nil
+	}
+
+	return "The policy is waiting for its dependencies: " + strings.Join(unmet, ", "), nil
+}
+
+// dependenciesPending returns why the policy is not replicated to the cluster yet, or nil if its
+// dependencies are met. Once the policy is replicated to the cluster, the replicated policy is
+// kept up to date and the policy framework on the managed cluster handles the dependencies.
+func (r *PolicyReconciler) dependenciesPending(
+	ctx context.Context, instance *policiesv1.Policy, clusterNamespace string,
+) (*replicationFailure, error) {
+	if len(instance.Spec.Dependencies) == 0 {
+		return nil, nil
+	}
+
+	err := r.Get(ctx, types.NamespacedName{
+		Namespace: clusterNamespace, Name: common.FullNameForPolicy(instance),
+	}, &policiesv1.Policy{})
+	if err == nil {
+		return nil, nil
+	}
+
+	if !k8serrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	message, err := r.unmetDependencies(ctx, instance, clusterNamespace)
+	if err != nil || message == "" {
+		return nil, err
+	}
+
+	return &replicationFailure{reason: reasonDependenciesPending, message: message}, nil
+}
+
+// stampDependencies sets the dependencies of the root policy and the extra dependencies of each
+// policy template on the policy templates of the replicated policy for the policy framework on
+// the managed cluster
+func stampDependencies(replicatedPlc *policiesv1.Policy, rootPlc *policiesv1.Policy) error {
+	// The policy templates may be shared with the root policy
+	templates := make([]*policiesv1.PolicyTemplate, 0, len(replicatedPlc.Spec.PolicyTemplates))
+
+	for _, template := range replicatedPlc.Spec.PolicyTemplates {
+		template = template.DeepCopy()
+		templates = append(templates, template)
+
+		deps := make([]policiesv1.PolicyDependency, 0, len(rootPlc.Spec.Dependencies)+len(template.ExtraDependencies))
+		deps = append(deps, rootPlc.Spec.Dependencies...)
+		deps = append(deps, template.ExtraDependencies...)
+
+		if len(deps) == 0 {
+			continue
+		}
+
+		tmpl := &unstructured.Unstructured{}
+		if err := tmpl.UnmarshalJSON(template.ObjectDefinition.Raw); err != nil {
+			continue
+		}
+
+		encoded, err := json.Marshal(deps)
+		if err != nil {
+			return err
+		}
+
+		annotations := tmpl.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+
+		annotations[common.DependenciesAnnotation] = string(encoded)
+		tmpl.SetAnnotations(annotations)
+
+		raw, err := json.Marshal(tmpl.Object)
+		if err != nil {
+			return err
+		}
+
+		template.ObjectDefinition.Raw = raw
+	}
+
+	replicatedPlc.Spec.PolicyTemplates = templates
+
+	return nil
+}
+
+// dependencyPredicateFuncs only lets through the root policy status updates, since the policies
+// depending on the root policy may now be replicated to more clusters
+var dependencyPredicateFuncs = predicate.Funcs{
+	CreateFunc: func(e event.CreateEvent) bool { return false },
+	DeleteFunc: func(e event.DeleteEvent) bool { return false },
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		if isReplicatedPolicy(e.ObjectNew) {
+			return false
+		}
+
+		plcOld, oldOk := e.ObjectOld.(*policiesv1.Policy)
+		plcNew, newOk := e.ObjectNew.(*policiesv1.Policy)
+
+		return oldOk && newOk && !equality.Semantic.DeepEqual(plcOld.Status.Status, plcNew.Status.Status)
+	},
+	GenericFunc: func(e event.GenericEvent) bool { return false },
+}
+
+// dependencyMapper returns a reconcile request for every root policy depending on the root policy
+func dependencyMapper(c client.Client) handler.MapFunc {
+	return func(object client.Object) []reconcile.Request {
+		plcList := &policiesv1.PolicyList{}
+
+		err := c.List(context.TODO(), plcList)
+		if err != nil {
+			log.Error(err, "Failed to list the policies depending on the policy...",
+				"Namespace", object.GetNamespace(), "Name", object.GetName())
+
+			return nil
+		}
+
+		dependency := types.NamespacedName{Namespace: object.GetNamespace(), Name: object.GetName()}
+
+		var result []reconcile.Request
+
+		for i := range plcList.Items {
+			plc := &plcList.Items[i]
+			if isReplicatedPolicy(plc) {
+				continue
+			}
+
+			for _, dep := range plc.Spec.Dependencies {
+				if root, ok := policyDependency(plc, dep); ok && root == dependency {
+					log.Info("Found reconciliation request from the policy dependency...",
+						"Namespace", plc.GetNamespace(), "Policy-Name", plc.GetName())
+					result = append(result, reconcile.Request{NamespacedName: types.NamespacedName{
+						Namespace: plc.GetNamespace(), Name: plc.GetName(),
+					}})
+
+					break
+				}
+			}
+		}
+
+		return result
+	}
+}
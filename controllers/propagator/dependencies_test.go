@@ -0,0 +1,217 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+func newTestDependentPolicy(deps ...policiesv1.PolicyDependency) (*policiesv1.Policy, *policiesv1.Policy) {
+	root := newTestPolicy("default")
+	root.Spec.Dependencies = deps
+
+	dependency := newTestPolicy("default")
+	dependency.SetName("dep")
+
+	return root, dependency
+}
+
+func TestHandleRootPolicyDependencies(t *testing.T) {
+	root, dependency := newTestDependentPolicy(policiesv1.PolicyDependency{
+		TypeMeta: metav1.TypeMeta{APIVersion: policiesv1.SchemeGroupVersion.String(), Kind: policiesv1.Kind},
+		Name:     "dep",
+	})
+
+	plr := &appsv1.PlacementRule{
+		ObjectMeta: metav1.ObjectMeta{Name: "plr", Namespace: "policies"},
+		Status: appsv1.PlacementRuleStatus{
+			Decisions: []appsv1.PlacementDecision{
+				{ClusterName: "cluster1", ClusterNamespace: "cluster1"},
+				{ClusterName: "cluster2", ClusterNamespace: "cluster2"},
+			},
+		},
+	}
+	pb := &policiesv1.PlacementBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "pb", Namespace: "policies"},
+		PlacementRef: policiesv1.Subject{
+			APIGroup: appsv1.SchemeGroupVersion.Group, Kind: "PlacementRule", Name: "plr",
+		},
+		Subjects: []policiesv1.Subject{
+			{APIGroup: policiesv1.SchemeGroupVersion.Group, Kind: policiesv1.Kind, Name: "policy"},
+		},
+	}
+
+	r := newTestReconciler(
+		t, &stubResolver{}, root, dependency, plr, pb,
+		newTestReplicatedPolicy(dependency, "cluster1", policiesv1.Compliant),
+		newTestReplicatedPolicy(dependency, "cluster2", policiesv1.NonCompliant),
+	)
+
+	if err := r.handleRootPolicy(context.TODO(), root, nil); err != nil {
+		t.Fatalf("handleRootPolicy returned an error: %v", err)
+	}
+
+	replicated := &policiesv1.Policy{}
+
+	err := r.Get(context.TODO(), types.NamespacedName{Namespace: "cluster1", Name: "policies.policy"}, replicated)
+	if err != nil {
+		t.Fatalf("expected a replicated policy on the cluster with the dependency met, got the error: %v", err)
+	}
+
+	tmpl := &unstructured.Unstructured{}
+	if err := tmpl.UnmarshalJSON(replicated.Spec.PolicyTemplates[0].ObjectDefinition.Raw); err != nil {
+		t.Fatalf("failed to unmarshal the policy template: %v", err)
+	}
+
+	if !strings.Contains(tmpl.GetAnnotations()[common.DependenciesAnnotation], `"name":"dep"`) {
+		t.Fatalf("expected the dependencies on the policy template, got %v", tmpl.GetAnnotations())
+	}
+
+	err = r.Get(context.TODO(), types.NamespacedName{Namespace: "cluster2", Name: "policies.policy"}, replicated)
+	if !k8serrors.IsNotFound(err) {
+		t.Fatalf("expected no replicated policy on the cluster with the dependency unmet, got the error: %v", err)
+	}
+
+	updatedRoot := &policiesv1.Policy{}
+
+	err = r.Get(context.TODO(), types.NamespacedName{Namespace: "policies", Name: "policy"}, updatedRoot)
+	if err != nil {
+		t.Fatalf("failed to get the root policy: %v", err)
+	}
+
+	if updatedRoot.Status.ComplianceState != policiesv1.Pending {
+		t.Fatalf("expected the root policy to be Pending, got %q", updatedRoot.Status.ComplianceState)
+	}
+
+	for _, cpcs := range updatedRoot.Status.Status {
+		if cpcs.ClusterName != "cluster2" {
+			continue
+		}
+
+		if cpcs.Reason != reasonDependenciesPending || cpcs.ComplianceState != policiesv1.Pending ||
+			!strings.Contains(cpcs.Message, "policies/dep is NonCompliant instead of Compliant") {
+			t.Fatalf("expected cluster2 to be waiting for its dependencies, got %+v", cpcs)
+		}
+
+		return
+	}
+
+	t.Fatalf("expected the pending cluster in the root policy status, got %v", updatedRoot.Status.Status)
+}
+
+func TestUnmetDependencies(t *testing.T) {
+	tests := []struct {
+		name     string
+		dep      policiesv1.PolicyDependency
+		expected string
+	}{
+		{"met", policiesv1.PolicyDependency{
+			TypeMeta: metav1.TypeMeta{Kind: policiesv1.Kind}, Name: "dep",
+		}, ""},
+		{"required noncompliant", policiesv1.PolicyDependency{
+			TypeMeta: metav1.TypeMeta{Kind: policiesv1.Kind}, Name: "dep", Compliance: policiesv1.NonCompliant,
+		}, "policies/dep is Compliant instead of NonCompliant"},
+		{"not replicated", policiesv1.PolicyDependency{
+			TypeMeta: metav1.TypeMeta{Kind: policiesv1.Kind}, Name: "dep", Namespace: "other",
+		}, "other/dep is Pending instead of Compliant"},
+		{"checked on the managed cluster", policiesv1.PolicyDependency{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "policy.open-cluster-management.io/v1", Kind: "ConfigurationPolicy",
+			},
+			Name: "case",
+		}, ""},
+		{"other group", policiesv1.PolicyDependency{
+			TypeMeta: metav1.TypeMeta{APIVersion: "example.com/v1", Kind: policiesv1.Kind}, Name: "dep",
+			Namespace: "other",
+		}, ""},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			root, dependency := newTestDependentPolicy(test.dep)
+			r := newTestReconciler(
+				t, &stubResolver{}, root, newTestReplicatedPolicy(dependency, "cluster1", policiesv1.Compliant),
+			)
+
+			message, err := r.unmetDependencies(context.TODO(), root, "cluster1")
+			if err != nil {
+				t.Fatalf("unmetDependencies returned an error: %v", err)
+			}
+
+			if test.expected == "" && message != "" {
+				t.Fatalf("expected the dependencies to be met, got: %s", message)
+			}
+
+			if !strings.Contains(message, test.expected) {
+				t.Fatalf("expected the message to contain %q, got %q", test.expected, message)
+			}
+		})
+	}
+}
+
+func TestDependencyMapper(t *testing.T) {
+	root, dependency := newTestDependentPolicy(policiesv1.PolicyDependency{
+		TypeMeta: metav1.TypeMeta{Kind: policiesv1.Kind}, Name: "dep",
+	})
+
+	unrelated := newTestPolicy("default")
+	unrelated.SetName("unrelated")
+
+	r := newTestReconciler(
+		t, &stubResolver{}, root, dependency, unrelated,
+		newTestReplicatedPolicy(root, "cluster1", policiesv1.Compliant),
+	)
+
+	requests := dependencyMapper(r.Client)(dependency)
+	if len(requests) != 1 || requests[0].Name != "policy" || requests[0].Namespace != "policies" {
+		t.Fatalf("expected only the dependent root policy to be reconciled, got %v", requests)
+	}
+}
+
+func TestStampDependenciesExtraDependencies(t *testing.T) {
+	root := newTestPolicy("default")
+	root.Spec.PolicyTemplates[0].ExtraDependencies = []policiesv1.PolicyDependency{{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "policy.open-cluster-management.io/v1", Kind: "ConfigurationPolicy",
+		},
+		Name: "other",
+	}}
+	original := string(root.Spec.PolicyTemplates[0].ObjectDefinition.Raw)
+
+	replicated := root.DeepCopy()
+	replicated.Spec.PolicyTemplates = root.Spec.PolicyTemplates
+
+	if err := stampDependencies(replicated, root); err != nil {
+		t.Fatalf("stampDependencies returned an error: %v", err)
+	}
+
+	if string(root.Spec.PolicyTemplates[0].ObjectDefinition.Raw) != original {
+		t.Fatal("expected the policy templates of the root policy not to be modified")
+	}
+
+	if len(replicated.Spec.PolicyTemplates[0].ExtraDependencies) != 1 {
+		t.Fatal("expected the extra dependencies to be replicated")
+	}
+
+	tmpl := &unstructured.Unstructured{}
+	if err := tmpl.UnmarshalJSON(replicated.Spec.PolicyTemplates[0].ObjectDefinition.Raw); err != nil {
+		t.Fatalf("failed to unmarshal the policy template: %v", err)
+	}
+
+	if !strings.Contains(tmpl.GetAnnotations()[common.DependenciesAnnotation], `"name":"other"`) {
+		t.Fatalf("expected the extra dependencies on the policy template, got %v", tmpl.GetAnnotations())
+	}
+}
@@ -0,0 +1,236 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/uninstall"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+// The configuration in seconds of how often the replicated policies are checked for changes made
+// outside of the propagator. Set it to 0 to disable the drift detection.
+const driftDetectionIntervalEnvName = "CONTROLLER_CONFIG_DRIFT_DETECTION_INTERVAL"
+const driftDetectionIntervalDefault = 600
+
+// specHashAnnotation on a replicated policy is the hash of the spec it was replicated with, so
+// that a changed spec is detected without resolving the hub templates of the root policy again
+const specHashAnnotation = "policy.open-cluster-management.io/spec-hash"
+
+// specHash returns the hash of the normalized JSON of the policy spec. The policy templates are
+// normalized since the API server may not return their raw JSON as it was sent.
+func specHash(spec policiesv1.PolicySpec) (string, error) {
+	specJSON, err := json.Marshal(spec)
+	if err != nil {
+		return "", err
+	}
+
+	var normalized interface{}
+
+	err = json.Unmarshal(specJSON, &normalized)
+	if err != nil {
+		return "", err
+	}
+
+	specJSON, err = json.Marshal(normalized)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(specJSON)
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// stampSpecHash sets the spec hash annotation on the replicated policy. The annotations are
+// copied since they may be shared with the root policy.
+func stampSpecHash(replicatedPlc *policiesv1.Policy) error {
+	hash, err := specHash(replicatedPlc.Spec)
+	if err != nil {
+		return err
+	}
+
+	annotations := map[string]string{}
+	for key, value := range replicatedPlc.GetAnnotations() {
+		annotations[key] = value
+	}
+
+	annotations[specHashAnnotation] = hash
+	replicatedPlc.SetAnnotations(annotations)
+
+	return nil
+}
+
+// specDrifted returns whether the spec of the replicated policy no longer matches the spec hash it
+// was replicated with. Replicated policies without the annotation are not considered drifted.
+func specDrifted(replicatedPlc *policiesv1.Policy) (bool, string, error) {
+	expected, ok := replicatedPlc.GetAnnotations()[specHashAnnotation]
+	if !ok {
+		return false, "", nil
+	}
+
+	actual, err := specHash(replicatedPlc.Spec)
+	if err != nil {
+		return false, "", err
+	}
+
+	return actual != expected, actual, nil
+}
+
+// driftDetector periodically restores the replicated policies that were modified in the cluster
+// namespaces, even when their root policies don't change
+type driftDetector struct {
+	reconciler *PolicyReconciler
+	interval   time.Duration
+}
+
+// Start checks the replicated policies every interval until the context is done
+func (d *driftDetector) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, d.reconciler.correctDrift, d.interval)
+
+	return nil
+}
+
+// NeedLeaderElection makes only the leader correct the drift
+func (d *driftDetector) NeedLeaderElection() bool {
+	return true
+}
+
+// correctDrift replicates the root policy again to every cluster namespace with a replicated
+// policy whose spec no longer matches its spec hash
+func (r *PolicyReconciler) correctDrift(ctx context.Context) {
+	replicatedPlcList := &policiesv1.PolicyList{}
+
+	err := r.List(ctx, replicatedPlcList, client.HasLabels{common.RootPolicyLabel})
+	if err != nil {
+		log.Error(err, "Failed to list the replicated policies to detect drift...")
+
+		return
+	}
+
+	for i := range replicatedPlcList.Items {
+		replicatedPlc := &replicatedPlcList.Items[i]
+
+		if !r.shard.ownsReplicated(replicatedPlc) {
+			continue
+		}
+
+		drifted, actualHash, err := specDrifted(replicatedPlc)
+		if err != nil {
+			log.Error(err, "Failed to hash the replicated policy...",
+				"Namespace", replicatedPlc.GetNamespace(), "Name", replicatedPlc.GetName())
+
+			continue
+		}
+
+		if !drifted {
+			continue
+		}
+
+		err = r.repairDrift(ctx, replicatedPlc, actualHash)
+		if err != nil {
+			log.Error(err, "Failed to correct the drift of the replicated policy...",
+				"Namespace", replicatedPlc.GetNamespace(), "Name", replicatedPlc.GetName())
+		}
+	}
+}
+
+// repairDrift replicates the root policy of the drifted replicated policy again to its cluster
+// namespace
+func (r *PolicyReconciler) repairDrift(ctx context.Context, replicatedPlc *policiesv1.Policy, actualHash string) error {
+	if uninstall.Uninstalling() {
+		return nil
+	}
+
+	rootName := strings.SplitN(replicatedPlc.GetLabels()[common.RootPolicyLabel], ".", 2)
+	if len(rootName) != 2 {
+		return fmt.Errorf("the root policy label %s is invalid", replicatedPlc.GetLabels()[common.RootPolicyLabel])
+	}
+
+	rootPlc := &policiesv1.Policy{}
+
+	err := r.Get(ctx, types.NamespacedName{Namespace: rootName[0], Name: rootName[1]}, rootPlc)
+	if err != nil {
+		// The replicated policy is cleaned up when the root policy deletion is reconciled
+		if k8serrors.IsNotFound(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	// The replicated policy is cleaned up when the disabled root policy is reconciled
+	if rootPlc.Spec.Disabled {
+		return nil
+	}
+
+	// The replicated policies of the root policies in dry run or paused are left as is
+	if isDryRun(rootPlc) || isPropagationPaused(rootPlc) {
+		return nil
+	}
+
+	cfg, err := r.getPropagationConfig(ctx, rootPlc.GetNamespace())
+	if err != nil {
+		return err
+	}
+
+	decision := appsv1.PlacementDecision{
+		ClusterName:      replicatedPlc.GetLabels()[common.ClusterNameLabel],
+		ClusterNamespace: replicatedPlc.GetNamespace(),
+	}
+
+	log.Info("Correcting the drift of the replicated policy...",
+		"Namespace", replicatedPlc.GetNamespace(), "Name", replicatedPlc.GetName())
+
+	_, err = r.handleDecision(ctx, rootPlc, decision, cfg)
+	if err != nil {
+		// The root policy is reconciled again when its maintenance window opens
+		pendingErr := &updatePendingError{}
+		if errors.As(err, &pendingErr) {
+			return nil
+		}
+
+		return err
+	}
+
+	r.Recorder.Event(rootPlc, "Normal", "DriftCorrected",
+		fmt.Sprintf("The replicated policy %s/%s was modified in the cluster namespace and was restored "+
+			"(expected spec hash %s, found %s)", replicatedPlc.GetNamespace(), replicatedPlc.GetName(),
+			replicatedPlc.GetAnnotations()[specHashAnnotation], actualHash))
+
+	return nil
+}
+
+// driftedReplicatedPolicy returns the replicated policy and its actual spec hash when its spec was
+// modified in the cluster namespace, or nil otherwise
+func (r *PolicyReconciler) driftedReplicatedPolicy(
+	ctx context.Context, key types.NamespacedName,
+) (*policiesv1.Policy, string) {
+	replicatedPlc := &policiesv1.Policy{}
+
+	if err := r.Get(ctx, key, replicatedPlc); err != nil {
+		return nil, ""
+	}
+
+	drifted, actualHash, err := specDrifted(replicatedPlc)
+	if err != nil || !drifted {
+		return nil, ""
+	}
+
+	return replicatedPlc, actualHash
+}
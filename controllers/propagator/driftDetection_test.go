@@ -0,0 +1,85 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	policyv1beta1 "github.com/open-cluster-management/governance-policy-propagator/api/v1beta1"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+func TestSpecHashNormalized(t *testing.T) {
+	plc := newTestPolicy("default")
+
+	reformatted := plc.DeepCopy()
+	reformatted.Spec.PolicyTemplates[0].ObjectDefinition = runtime.RawExtension{
+		Raw: []byte(" " + strings.Replace(configPolicy, "%s", "default", 1) + "\n"),
+	}
+
+	expected, err := specHash(plc.Spec)
+	if err != nil {
+		t.Fatalf("failed to hash the spec: %v", err)
+	}
+
+	actual, err := specHash(reformatted.Spec)
+	if err != nil {
+		t.Fatalf("failed to hash the spec: %v", err)
+	}
+
+	if expected != actual {
+		t.Fatal("expected the spec hash to ignore the formatting of the policy templates")
+	}
+}
+
+func TestCorrectDrift(t *testing.T) {
+	decision := appsv1.PlacementDecision{ClusterName: "cluster1", ClusterNamespace: "cluster1"}
+	root := newTestPolicy("default")
+	root.Spec.RemediationAction = policiesv1.Inform
+
+	r := newTestReconciler(t, &stubResolver{}, root)
+
+	if _, err := r.handleDecision(context.TODO(), root, decision, policyv1beta1.PropagationConfigSpec{}); err != nil {
+		t.Fatalf("handleDecision returned an error: %v", err)
+	}
+
+	replicatedPlc := &policiesv1.Policy{}
+	key := types.NamespacedName{Namespace: "cluster1", Name: "policies.policy"}
+
+	if err := r.Get(context.TODO(), key, replicatedPlc); err != nil {
+		t.Fatalf("failed to get the replicated policy: %v", err)
+	}
+
+	if drifted, _, err := specDrifted(replicatedPlc); err != nil || drifted {
+		t.Fatalf("expected the replicated policy to not be drifted, got %v (error: %v)", drifted, err)
+	}
+
+	replicatedPlc.Spec.RemediationAction = policiesv1.Enforce
+	if err := r.Update(context.TODO(), replicatedPlc); err != nil {
+		t.Fatalf("failed to modify the replicated policy: %v", err)
+	}
+
+	r.correctDrift(context.TODO())
+
+	if err := r.Get(context.TODO(), key, replicatedPlc); err != nil {
+		t.Fatalf("failed to get the replicated policy: %v", err)
+	}
+
+	if replicatedPlc.Spec.RemediationAction != policiesv1.Inform {
+		t.Fatalf("expected the drift to be corrected, got %s", replicatedPlc.Spec.RemediationAction)
+	}
+
+	for _, event := range events(r) {
+		if strings.HasPrefix(event, "Normal DriftCorrected The replicated policy cluster1/policies.policy") {
+			return
+		}
+	}
+
+	t.Fatal("expected a DriftCorrected event")
+}
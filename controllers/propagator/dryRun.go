@@ -0,0 +1,106 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/util/jsonmergepatch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+// dryRunAnnotation on a root policy makes the propagator resolve its placement decisions and hub
+// templates without creating, updating, or deleting its replicated policies. The clusters it would
+// be replicated to are set in the root policy status, and the changes are recorded as events.
+const dryRunAnnotation = "policy.open-cluster-management.io/dry-run"
+
+// dryRunDiffLimit is the maximum length of the diff in the dry run events
+const dryRunDiffLimit = 1024
+
+// isDryRun returns whether the root policy has the dry run annotation set to true
+func isDryRun(instance client.Object) bool {
+	dryRun, err := strconv.ParseBool(instance.GetAnnotations()[dryRunAnnotation])
+
+	return err == nil && dryRun
+}
+
+// dryRunResult is returned by handleDecision instead of writing the replicated policy of a root
+// policy in dry run. It is not retried since nothing failed.
+type dryRunResult struct {
+	// operation is what would be done to the replicated policy, either created or updated
+	operation string
+	// diff is the JSON merge patch from the existing replicated policy to the desired one
+	diff string
+}
+
+func (e *dryRunResult) Error() string {
+	return fmt.Sprintf("dry run: the replicated policy would be %s", e.operation)
+}
+
+// previewReplicatedPolicy returns the dry run result of writing the desired replicated policy. The
+// existing replicated policy is nil when it would be created.
+func previewReplicatedPolicy(existingPlc *policiesv1.Policy, desiredPlc *policiesv1.Policy) error {
+	result := &dryRunResult{operation: "created"}
+	original := []byte("{}")
+
+	if existingPlc != nil {
+		result.operation = "updated"
+
+		var err error
+
+		original, err = previewJSON(existingPlc)
+		if err != nil {
+			return err
+		}
+	}
+
+	modified, err := previewJSON(desiredPlc)
+	if err != nil {
+		return err
+	}
+
+	diff, err := jsonmergepatch.CreateThreeWayJSONMergePatch(original, modified, original)
+	if err != nil {
+		return err
+	}
+
+	result.diff = string(diff)
+
+	return result
+}
+
+// previewJSON returns the JSON of the fields of the replicated policy set by the propagator
+func previewJSON(plc *policiesv1.Policy) ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels":      plc.GetLabels(),
+			"annotations": plc.GetAnnotations(),
+		},
+		"spec": plc.Spec,
+	})
+}
+
+// recordDryRun records the change the root policy in dry run would make to the replicated policy
+// of the cluster and returns the status of the cluster
+func (r *PolicyReconciler) recordDryRun(
+	instance *policiesv1.Policy, decision appsv1.PlacementDecision, result *dryRunResult,
+) *replicationFailure {
+	diff := result.diff
+	if len(diff) > dryRunDiffLimit {
+		diff = diff[:dryRunDiffLimit] + "..."
+	}
+
+	r.Recorder.Event(instance, "Normal", "PolicyPropagation",
+		fmt.Sprintf("Policy %s/%s would be %s for cluster %s/%s (dry run): %s", instance.GetNamespace(),
+			instance.GetName(), result.operation, decision.ClusterNamespace, decision.ClusterName, diff))
+
+	return &replicationFailure{
+		reason:  reasonDryRun,
+		message: fmt.Sprintf("The replicated policy would be %s on the cluster (dry run)", result.operation),
+	}
+}
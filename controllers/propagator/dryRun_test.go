@@ -0,0 +1,125 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+func TestHandleRootPolicyDryRun(t *testing.T) {
+	root := newTestPolicy("default")
+	root.SetAnnotations(map[string]string{dryRunAnnotation: "true"})
+	root.Spec.RemediationAction = policiesv1.Inform
+
+	existing := newTestReplicatedPolicy(root, "cluster1", policiesv1.Compliant)
+	existing.Spec.RemediationAction = policiesv1.Enforce
+
+	plr := &appsv1.PlacementRule{
+		ObjectMeta: metav1.ObjectMeta{Name: "plr", Namespace: "policies"},
+		Status: appsv1.PlacementRuleStatus{
+			Decisions: []appsv1.PlacementDecision{
+				{ClusterName: "cluster1", ClusterNamespace: "cluster1"},
+				{ClusterName: "cluster2", ClusterNamespace: "cluster2"},
+			},
+		},
+	}
+	pb := &policiesv1.PlacementBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "pb", Namespace: "policies"},
+		PlacementRef: policiesv1.Subject{
+			APIGroup: appsv1.SchemeGroupVersion.Group, Kind: "PlacementRule", Name: "plr",
+		},
+		Subjects: []policiesv1.Subject{
+			{APIGroup: policiesv1.SchemeGroupVersion.Group, Kind: policiesv1.Kind, Name: "policy"},
+		},
+	}
+
+	r := newTestReconciler(t, &stubResolver{}, root, existing, plr, pb)
+
+	if err := r.handleRootPolicy(context.TODO(), root, nil); err != nil {
+		t.Fatalf("handleRootPolicy returned an error: %v", err)
+	}
+
+	replicated := &policiesv1.Policy{}
+
+	err := r.Get(context.TODO(), types.NamespacedName{Namespace: "cluster1", Name: "policies.policy"}, replicated)
+	if err != nil {
+		t.Fatalf("failed to get the existing replicated policy: %v", err)
+	}
+
+	if replicated.Spec.RemediationAction != policiesv1.Enforce {
+		t.Fatalf("expected the existing replicated policy not to be updated, got %q", replicated.Spec.RemediationAction)
+	}
+
+	err = r.Get(context.TODO(), types.NamespacedName{Namespace: "cluster2", Name: "policies.policy"}, replicated)
+	if !k8serrors.IsNotFound(err) {
+		t.Fatalf("expected no replicated policy to be created, got the error: %v", err)
+	}
+
+	updatedRoot := &policiesv1.Policy{}
+
+	err = r.Get(context.TODO(), types.NamespacedName{Namespace: "policies", Name: "policy"}, updatedRoot)
+	if err != nil {
+		t.Fatalf("failed to get the root policy: %v", err)
+	}
+
+	if len(updatedRoot.Status.Status) != 2 {
+		t.Fatalf("expected the would-be clusters in the root policy status, got %v", updatedRoot.Status.Status)
+	}
+
+	for _, cpcs := range updatedRoot.Status.Status {
+		if cpcs.Reason != reasonDryRun {
+			t.Fatalf("expected the %s cluster to have the dry run reason, got %+v", cpcs.ClusterName, cpcs)
+		}
+	}
+
+	created := false
+	updated := false
+
+	recorded := events(r)
+
+	for _, event := range recorded {
+		if strings.Contains(event, "would be created for cluster cluster2/cluster2 (dry run)") {
+			created = true
+		}
+
+		if strings.Contains(event, "would be updated for cluster cluster1/cluster1 (dry run)") &&
+			strings.Contains(event, `"remediationAction":"Inform"`) {
+			updated = true
+		}
+	}
+
+	if !created || !updated {
+		t.Fatalf("expected the dry run events for both clusters, got %v", recorded)
+	}
+}
+
+func TestIsDryRun(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected bool
+	}{
+		{"true", true},
+		{"True", true},
+		{"false", false},
+		{"", false},
+		{"yes", false},
+	}
+
+	for _, test := range tests {
+		root := newTestPolicy("default")
+		root.SetAnnotations(map[string]string{dryRunAnnotation: test.value})
+
+		if isDryRun(root) != test.expected {
+			t.Fatalf("expected isDryRun=%v for %q", test.expected, test.value)
+		}
+	}
+}
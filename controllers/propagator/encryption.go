@@ -0,0 +1,735 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/complianceeventsapi"
+)
+
+// errEncryptionKeyNotReady is returned when the encryption key of the cluster namespace is not
+// generated yet
+var errEncryptionKeyNotReady = errors.New("the encryption key of the cluster namespace is not ready")
+
+// errSecretValueModified is returned when the hub templates modify a value resolved from a Secret,
+// which can then no longer be encrypted
+var errSecretValueModified = errors.New(
+	"the values resolved from Secrets can only be decoded with base64dec or passed to protect",
+)
+
+// The functions of the hub templates that resolve values from Secrets or encrypt values
+var encryptedFunctions = []string{"fromSecret", "lookup", "protect"}
+
+// policyUsesSecrets returns whether one of the policy templates calls a function that may resolve
+// values from Secrets, or the protect function
+func policyUsesSecrets(plc *policiesv1.Policy) bool {
+	for _, policyT := range plc.Spec.PolicyTemplates {
+		for _, function := range encryptedFunctions {
+			if bytes.Contains(policyT.ObjectDefinition.Raw, []byte(function)) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// policyHasEncryptedValues returns whether one of the policy templates of the replicated policy has
+// encrypted values
+func policyHasEncryptedValues(plc *policiesv1.Policy) bool {
+	for _, policyT := range plc.Spec.PolicyTemplates {
+		if bytes.Contains(policyT.ObjectDefinition.Raw, []byte(common.EncryptedPrefix)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getEncryptionKey returns the AES key encrypting the values resolved from Secrets for the cluster
+// namespace. The Secrets are read directly from the API server so that they are not all cached.
+func (r *PolicyReconciler) getEncryptionKey(ctx context.Context, clusterNamespace string) ([]byte, error) {
+	if r.kubeClient == nil {
+		return nil, errEncryptionKeyNotReady
+	}
+
+	secret, err := (*r.kubeClient).CoreV1().Secrets(clusterNamespace).Get(
+		ctx, common.EncryptionKeySecret, metav1.GetOptions{},
+	)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil, errEncryptionKeyNotReady
+		}
+
+		return nil, err
+	}
+
+	key := secret.Data[common.EncryptionKeyField]
+	if len(key) != 32 {
+		return nil, errEncryptionKeyNotReady
+	}
+
+	return key, nil
+}
+
+// encryptedValuePattern matches the encrypted values in the policy templates. The base64 encoded
+// value may be followed by other base64 characters of the string it is in.
+var encryptedValuePattern = regexp.MustCompile(regexp.QuoteMeta(common.EncryptedPrefix) + `[A-Za-z0-9+/]+={0,2}`)
+
+// existingEncryptedValues returns the encrypted values of the existing replicated policy by their
+// decrypted value, so that they are reused and don't change on every reconcile. The values that
+// can't be decrypted with the key, such as the ones encrypted with the previous key, are skipped.
+func (r *PolicyReconciler) existingEncryptedValues(
+	ctx context.Context, namespace string, name string, key []byte,
+) (map[string][]string, error) {
+	existing := &policiesv1.Policy{}
+
+	err := r.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, existing)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return map[string][]string{}, nil
+		}
+
+		return nil, err
+	}
+
+	values := map[string][]string{}
+
+	for _, policyT := range existing.Spec.PolicyTemplates {
+		for _, match := range encryptedValuePattern.FindAllString(string(policyT.ObjectDefinition.Raw), -1) {
+			encoded := strings.TrimPrefix(match, common.EncryptedPrefix)
+
+			// The longest base64 encoded value that is authenticated is the encrypted value
+			for length := len(encoded) - len(encoded)%4; length > 0; length -= 4 {
+				encrypted := common.EncryptedPrefix + encoded[:length]
+
+				plaintext, err := decryptValue(key, encrypted)
+				if err == nil {
+					values[string(plaintext)] = append(values[string(plaintext)], encrypted)
+
+					break
+				}
+			}
+		}
+	}
+
+	return values, nil
+}
+
+// keyFingerprint identifies the key without revealing it
+func keyFingerprint(key []byte) string {
+	sum := sha256.Sum256(key)
+
+	return hex.EncodeToString(sum[:8])
+}
+
+// deriveKeys returns the AES key encrypting the values and the HMAC-SHA256 key authenticating them,
+// which are derived from the key of the cluster namespace
+func deriveKeys(key []byte) (encryptionKey []byte, authenticationKey []byte) {
+	derive := func(purpose string) []byte {
+		mac := hmac.New(sha256.New, key)
+		_, _ = mac.Write([]byte(purpose))
+
+		return mac.Sum(nil)
+	}
+
+	return derive("encryption"), derive("authentication")
+}
+
+// encryptValue returns the value encrypted with AES-CBC, a random initialization vector, and PKCS #7
+// padding, and authenticated with HMAC-SHA256, see common.EncryptedPrefix
+func encryptValue(key []byte, value []byte) (string, error) {
+	encryptionKey, authenticationKey := deriveKeys(key)
+
+	block, err := aes.NewCipher(encryptionKey)
+	if err != nil {
+		return "", err
+	}
+
+	padding := aes.BlockSize - len(value)%aes.BlockSize
+	plaintext := append(append([]byte{}, value...), bytes.Repeat([]byte{byte(padding)}, padding)...)
+
+	// The initialization vector is followed by the ciphertext
+	encrypted := make([]byte, aes.BlockSize+len(plaintext))
+	iv := encrypted[:aes.BlockSize]
+
+	_, err = rand.Read(iv)
+	if err != nil {
+		return "", err
+	}
+
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(encrypted[aes.BlockSize:], plaintext)
+
+	mac := hmac.New(sha256.New, authenticationKey)
+	_, _ = mac.Write(encrypted)
+
+	return common.EncryptedPrefix + base64.StdEncoding.EncodeToString(mac.Sum(encrypted)), nil
+}
+
+// decryptValue returns the value encrypted by encryptValue, after verifying that it wasn't modified
+func decryptValue(key []byte, encrypted string) ([]byte, error) {
+	if !strings.HasPrefix(encrypted, common.EncryptedPrefix) {
+		return nil, fmt.Errorf("the value is not prefixed with %s", common.EncryptedPrefix)
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(encrypted, common.EncryptedPrefix))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(decoded) < 2*aes.BlockSize+sha256.Size {
+		return nil, errors.New("the encrypted value is too short")
+	}
+
+	iv := decoded[:aes.BlockSize]
+	ciphertext := decoded[aes.BlockSize : len(decoded)-sha256.Size]
+	encryptionKey, authenticationKey := deriveKeys(key)
+
+	mac := hmac.New(sha256.New, authenticationKey)
+	_, _ = mac.Write(decoded[:len(decoded)-sha256.Size])
+
+	if !hmac.Equal(mac.Sum(nil), decoded[len(decoded)-sha256.Size:]) {
+		return nil, errors.New("the encrypted value failed the authentication")
+	}
+
+	if len(ciphertext)%aes.BlockSize != 0 {
+		return nil, errors.New("the ciphertext is not a multiple of the block size")
+	}
+
+	block, err := aes.NewCipher(encryptionKey)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plaintext, ciphertext)
+
+	padding := int(plaintext[len(plaintext)-1])
+	if padding == 0 || padding > aes.BlockSize ||
+		!bytes.Equal(plaintext[len(plaintext)-padding:], bytes.Repeat([]byte{byte(padding)}, padding)) {
+		return nil, errors.New("the padding of the value is invalid")
+	}
+
+	return plaintext[:len(plaintext)-padding], nil
+}
+
+// newEncryptingResolver returns a template resolver with the options, whose values resolved from
+// Secrets with the fromSecret and lookup functions, and passed to the protect function, are
+// encrypted with the key. The existing encrypted values of the replicated policy, by their
+// decrypted value, are reused.
+func (r *PolicyReconciler) newEncryptingResolver(
+	opts TemplateResolverOptions, key []byte, existing map[string][]string,
+) (TemplateResolver, error) {
+	placeholders, err := newSecretPlaceholders()
+	if err != nil {
+		return nil, err
+	}
+
+	resolver, err := r.buildTemplateResolver(
+		opts,
+		func(kubeClient kubernetes.Interface) kubernetes.Interface {
+			return &encryptingClient{Interface: kubeClient, placeholders: placeholders}
+		},
+		placeholders.wrap,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	encrypting := &encryptingResolver{
+		resolver:     resolver,
+		placeholders: placeholders,
+		key:          key,
+		existing:     existing,
+		startDelim:   r.templateCfg.StartDelim,
+		stopDelim:    r.templateCfg.StopDelim,
+	}
+
+	if tracker, ok := resolver.(referenceTrackingResolver); ok {
+		return &trackingEncryptingResolver{encryptingResolver: encrypting, tracker: tracker}, nil
+	}
+
+	return encrypting, nil
+}
+
+// failingResolver fails to resolve any template with the error
+type failingResolver struct {
+	err error
+}
+
+func (f failingResolver) ResolveTemplate(tmplJSON []byte, context interface{}) ([]byte, error) {
+	return nil, f.err
+}
+
+// protectPattern matches the protect function in a template action
+var protectPattern = regexp.MustCompile(`(^|[\s(|])protect([\s)]|$)`)
+
+// encryptingResolver resolves the hub templates with the values of the Secrets replaced with
+// placeholders, and then replaces the placeholders, and the values passed to the protect function,
+// with their encrypted values. The template functions of the resolver can't be extended, so the
+// protect function is replaced with a printf call wrapping the value in markers.
+type encryptingResolver struct {
+	resolver     TemplateResolver
+	placeholders *secretPlaceholders
+	key          []byte
+	// existing are the encrypted values of the existing replicated policy by their decrypted value
+	existing   map[string][]string
+	startDelim string
+	stopDelim  string
+}
+
+func (e *encryptingResolver) ResolveTemplate(tmplJSON []byte, context interface{}) ([]byte, error) {
+	resolved, err := e.resolver.ResolveTemplate(e.replaceProtect(tmplJSON), context)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(resolved))
+	decoder.UseNumber()
+
+	var obj interface{}
+
+	err = decoder.Decode(&obj)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, err = e.encryptObject(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	if bytes.Contains(encrypted, []byte(e.placeholders.prefix)) ||
+		bytes.Contains(encrypted, []byte(e.placeholders.encodedPrefix())) {
+		return nil, errSecretValueModified
+	}
+
+	return encrypted, nil
+}
+
+// The markers wrapping the values passed to the protect function in the resolved templates
+func (e *encryptingResolver) protectStart() string {
+	return e.placeholders.prefix + "protectstart"
+}
+
+func (e *encryptingResolver) protectEnd() string {
+	return e.placeholders.prefix + "protectend"
+}
+
+// replaceProtect replaces the protect function in the actions of the raw JSON templates with a
+// printf call wrapping the value in the protect markers
+func (e *encryptingResolver) replaceProtect(tmplJSON []byte) []byte {
+	// The quotes are escaped since the actions are in JSON strings
+	call := `printf \"` + e.protectStart() + "%v" + e.protectEnd() + `\"`
+	tmpl := string(tmplJSON)
+	result := strings.Builder{}
+
+	for {
+		start := strings.Index(tmpl, e.startDelim)
+		if start == -1 {
+			break
+		}
+
+		end := strings.Index(tmpl[start:], e.stopDelim)
+		if end == -1 {
+			break
+		}
+
+		end += start + len(e.stopDelim)
+
+		result.WriteString(tmpl[:start])
+		result.WriteString(protectPattern.ReplaceAllString(tmpl[start:end], "${1}"+call+"${2}"))
+
+		tmpl = tmpl[end:]
+	}
+
+	result.WriteString(tmpl)
+
+	return []byte(result.String())
+}
+
+// encryptObject encrypts the values in the strings of the resolved template. The keys of the maps
+// are handled in the order they are marshaled in, which is the order the existing encrypted values
+// are reused in.
+func (e *encryptingResolver) encryptObject(obj interface{}) (interface{}, error) {
+	switch typed := obj.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(typed))
+		for key := range typed {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			encrypted, err := e.encryptObject(typed[key])
+			if err != nil {
+				return nil, err
+			}
+
+			typed[key] = encrypted
+		}
+	case []interface{}:
+		for i, value := range typed {
+			encrypted, err := e.encryptObject(value)
+			if err != nil {
+				return nil, err
+			}
+
+			typed[i] = encrypted
+		}
+	case string:
+		return e.encryptString(typed)
+	}
+
+	return obj, nil
+}
+
+// encryptString encrypts the values passed to the protect function, with the values of the Secrets
+// they contain, and then the other values of the Secrets
+func (e *encryptingResolver) encryptString(value string) (string, error) {
+	for {
+		start := strings.Index(value, e.protectStart())
+		if start == -1 {
+			break
+		}
+
+		end := strings.Index(value[start:], e.protectEnd())
+		if end == -1 {
+			break
+		}
+
+		end += start
+
+		protected := value[start+len(e.protectStart()) : end]
+
+		plaintext, err := e.placeholders.replace(protected, func(secretValue []byte) (string, error) {
+			return string(secretValue), nil
+		})
+		if err != nil {
+			return "", err
+		}
+
+		encrypted, err := e.encrypt([]byte(plaintext))
+		if err != nil {
+			return "", err
+		}
+
+		value = value[:start] + encrypted + value[end+len(e.protectEnd()):]
+	}
+
+	return e.placeholders.replace(value, e.encrypt)
+}
+
+// encrypt returns an existing encrypted value of the plaintext so that the replicated policy is not
+// updated on every reconcile, or else the plaintext encrypted with a new initialization vector.
+// Each existing value is only reused once so that the equal values aren't revealed.
+func (e *encryptingResolver) encrypt(plaintext []byte) (string, error) {
+	if reused := e.existing[string(plaintext)]; len(reused) != 0 {
+		e.existing[string(plaintext)] = reused[1:]
+
+		return reused[0], nil
+	}
+
+	return encryptValue(e.key, plaintext)
+}
+
+// trackingEncryptingResolver is an encryptingResolver reporting the objects looked up by the
+// tracking resolver it wraps
+type trackingEncryptingResolver struct {
+	*encryptingResolver
+	tracker referenceTrackingResolver
+}
+
+func (t *trackingEncryptingResolver) references() []templateReference {
+	return t.tracker.references()
+}
+
+func (t *trackingEncryptingResolver) lookups() []complianceeventsapi.TemplateLookup {
+	return t.tracker.lookups()
+}
+
+// secretPlaceholders replaces the values of the Secrets looked up by the hub templates with random
+// placeholders, so that the values are only in the resolved templates once they are encrypted
+type secretPlaceholders struct {
+	// prefix is the random prefix of the placeholders
+	prefix string
+
+	lock   sync.Mutex
+	values map[string][]byte
+}
+
+func newSecretPlaceholders() (*secretPlaceholders, error) {
+	random := make([]byte, 15)
+
+	_, err := rand.Read(random)
+	if err != nil {
+		return nil, err
+	}
+
+	return &secretPlaceholders{prefix: "ocmsecret" + hex.EncodeToString(random), values: map[string][]byte{}}, nil
+}
+
+// placeholder returns a new placeholder for the value
+func (p *secretPlaceholders) placeholder(value []byte) []byte {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	placeholder := fmt.Sprintf("%sv%dv", p.prefix, len(p.values))
+	p.values[placeholder] = value
+
+	return []byte(placeholder)
+}
+
+// replace replaces the placeholders in the string, and their base64 encoding returned by the
+// fromSecret function, with the replacement of the value and of its base64 encoding
+func (p *secretPlaceholders) replace(value string, replacement func([]byte) (string, error)) (string, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if !strings.Contains(value, p.prefix) && !strings.Contains(value, p.encodedPrefix()) {
+		return value, nil
+	}
+
+	placeholders := make([]string, 0, len(p.values))
+	for placeholder := range p.values {
+		placeholders = append(placeholders, placeholder)
+	}
+
+	// The longer placeholders are replaced first so that they don't contain a replaced one
+	sort.Slice(placeholders, func(i, j int) bool { return len(placeholders[i]) > len(placeholders[j]) })
+
+	for _, placeholder := range placeholders {
+		secretValue := p.values[placeholder]
+		encodedPlaceholder := base64.StdEncoding.EncodeToString([]byte(placeholder))
+
+		if strings.Contains(value, encodedPlaceholder) {
+			replaced, err := replacement([]byte(base64.StdEncoding.EncodeToString(secretValue)))
+			if err != nil {
+				return "", err
+			}
+
+			value = strings.ReplaceAll(value, encodedPlaceholder, replaced)
+		}
+
+		if strings.Contains(value, placeholder) {
+			replaced, err := replacement(secretValue)
+			if err != nil {
+				return "", err
+			}
+
+			value = strings.ReplaceAll(value, placeholder, replaced)
+		}
+	}
+
+	return value, nil
+}
+
+// encodedPrefix returns the start of the base64 encoding of the placeholders, which is the same
+// for all of them since the prefix is 39 bytes long, a multiple of 3
+func (p *secretPlaceholders) encodedPrefix() string {
+	return base64.StdEncoding.EncodeToString([]byte(p.prefix))
+}
+
+// placeholderSecret replaces the values of the Secret returned by the API server, and its last
+// applied configuration which has them too, with placeholders
+func (p *secretPlaceholders) placeholderSecret(secret map[string]interface{}) {
+	if data, ok := secret["data"].(map[string]interface{}); ok {
+		for key, value := range data {
+			encoded, _ := value.(string)
+
+			decoded, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				decoded = []byte(encoded)
+			}
+
+			data[key] = base64.StdEncoding.EncodeToString(p.placeholder(decoded))
+		}
+	}
+
+	metadata, _ := secret["metadata"].(map[string]interface{})
+	annotations, _ := metadata["annotations"].(map[string]interface{})
+
+	if applied, ok := annotations[corev1.LastAppliedConfigAnnotation].(string); ok {
+		annotations[corev1.LastAppliedConfigAnnotation] = string(p.placeholder([]byte(applied)))
+	}
+}
+
+// wrap returns a transport replacing the values of the Secrets returned by the API server with
+// placeholders, which are the Secrets looked up with the lookup function
+func (p *secretPlaceholders) wrap(next http.RoundTripper) http.RoundTripper {
+	return &placeholderTransport{placeholders: p, next: next}
+}
+
+type placeholderTransport struct {
+	placeholders *secretPlaceholders
+	next         http.RoundTripper
+}
+
+func (t *placeholderTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+
+	ref, ok := parseTemplateReference(req.URL.Path)
+	if !ok || !isSecretReference(ref) {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if err != nil {
+		return nil, err
+	}
+
+	// The Secrets that can't be parsed are not returned, since their values would not be encrypted
+	obj := map[string]interface{}{}
+
+	err = json.Unmarshal(body, &obj)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the Secrets returned by the API server: %w", err)
+	}
+
+	if items, ok := obj["items"].([]interface{}); ok {
+		for _, item := range items {
+			if secret, ok := item.(map[string]interface{}); ok {
+				t.placeholders.placeholderSecret(secret)
+			}
+		}
+	} else {
+		t.placeholders.placeholderSecret(obj)
+	}
+
+	body, err = json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	resp.Header.Del("Content-Length")
+
+	return resp, nil
+}
+
+// encryptingClient is a Kubernetes client returning the values of the Secrets replaced with
+// placeholders, which are the Secrets looked up with the fromSecret function
+type encryptingClient struct {
+	kubernetes.Interface
+	placeholders *secretPlaceholders
+}
+
+func (c *encryptingClient) CoreV1() corev1client.CoreV1Interface {
+	return &encryptingCoreV1{CoreV1Interface: c.Interface.CoreV1(), placeholders: c.placeholders}
+}
+
+type encryptingCoreV1 struct {
+	corev1client.CoreV1Interface
+	placeholders *secretPlaceholders
+}
+
+func (c *encryptingCoreV1) Secrets(namespace string) corev1client.SecretInterface {
+	return &encryptingSecrets{SecretInterface: c.CoreV1Interface.Secrets(namespace), placeholders: c.placeholders}
+}
+
+type encryptingSecrets struct {
+	corev1client.SecretInterface
+	placeholders *secretPlaceholders
+}
+
+func (s *encryptingSecrets) Get(ctx context.Context, name string, opts metav1.GetOptions) (*corev1.Secret, error) {
+	secret, err := s.SecretInterface.Get(ctx, name, opts)
+	if err != nil {
+		return secret, err
+	}
+
+	placeholders := secret.DeepCopy()
+
+	for field, value := range secret.Data {
+		placeholders.Data[field] = s.placeholders.placeholder(value)
+	}
+
+	return placeholders, nil
+}
+
+// encryptionKeyPredicateFuncs only lets through the encryption key Secrets
+var encryptionKeyPredicateFuncs = predicate.NewPredicateFuncs(func(object client.Object) bool {
+	return object.GetName() == common.EncryptionKeySecret
+})
+
+// encryptionKeyMapper returns a reconcile request for every root policy replicated to the cluster
+// namespace of the encryption key so that the values are encrypted with the rotated key
+func encryptionKeyMapper(c client.Client) handler.MapFunc {
+	return func(object client.Object) []reconcile.Request {
+		plcList := &policiesv1.PolicyList{}
+
+		err := c.List(
+			context.TODO(), plcList, client.InNamespace(object.GetNamespace()), client.HasLabels{common.RootPolicyLabel},
+		)
+		if err != nil {
+			log.Error(err, "Failed to list the replicated policies...", "Namespace", object.GetNamespace())
+
+			return nil
+		}
+
+		var result []reconcile.Request
+
+		for _, plc := range plcList.Items {
+			if !policyHasEncryptedValues(&plc) {
+				continue
+			}
+
+			// The root policy label is in the format of <namespace>.<name>
+			rootName := strings.SplitN(plc.GetLabels()[common.RootPolicyLabel], ".", 2)
+			if len(rootName) != 2 {
+				continue
+			}
+
+			log.Info("Found reconciliation request from the encryption key...",
+				"Namespace", rootName[0], "Policy-Name", rootName[1])
+			result = append(result, reconcile.Request{NamespacedName: client.ObjectKey{
+				Namespace: rootName[0], Name: rootName[1],
+			}})
+		}
+
+		return result
+	}
+}
@@ -0,0 +1,288 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"crypto/aes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	policyv1beta1 "github.com/open-cluster-management/governance-policy-propagator/api/v1beta1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+func TestEncryptValue(t *testing.T) {
+	key := []byte(strings.Repeat("k", 32))
+
+	for _, value := range []string{"", "password", strings.Repeat("x", 16)} {
+		encrypted, err := encryptValue(key, []byte(value))
+		if err != nil {
+			t.Fatalf("encryptValue returned an error: %v", err)
+		}
+
+		if !strings.HasPrefix(encrypted, common.EncryptedPrefix) {
+			t.Fatalf("expected the %s prefix, got %s", common.EncryptedPrefix, encrypted)
+		}
+
+		decrypted, err := decryptValue(key, encrypted)
+		if err != nil {
+			t.Fatalf("decryptValue returned an error: %v", err)
+		}
+
+		if string(decrypted) != value {
+			t.Fatalf("expected %q, got %q", value, decrypted)
+		}
+
+		// Each value is encrypted with a new initialization vector
+		reencrypted, err := encryptValue(key, []byte(value))
+		if err != nil {
+			t.Fatalf("encryptValue returned an error: %v", err)
+		}
+
+		if reencrypted == encrypted {
+			t.Fatalf("expected the value %q to be encrypted differently every time", value)
+		}
+	}
+}
+
+func TestDecryptValueAuthentication(t *testing.T) {
+	key := []byte(strings.Repeat("k", 32))
+
+	encrypted, err := encryptValue(key, []byte("password"))
+	if err != nil {
+		t.Fatalf("encryptValue returned an error: %v", err)
+	}
+
+	for name, offset := range map[string]int{"initialization vector": 0, "ciphertext": aes.BlockSize} {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(encrypted, common.EncryptedPrefix))
+		if err != nil {
+			t.Fatalf("expected a base64 encoded value, got %s", encrypted)
+		}
+
+		decoded[offset] ^= 1
+		tampered := common.EncryptedPrefix + base64.StdEncoding.EncodeToString(decoded)
+
+		if _, err := decryptValue(key, tampered); err == nil {
+			t.Fatalf("expected the modified %s to fail the authentication", name)
+		}
+	}
+
+	if _, err := decryptValue([]byte(strings.Repeat("l", 32)), encrypted); err == nil {
+		t.Fatal("expected another key to fail the authentication")
+	}
+}
+
+// newTestEncryptionReconciler returns a reconciler with the EncryptedHubTemplates feature enabled,
+// the Secret of the policies namespace, and the encryption key of the cluster1 namespace. The
+// lookup function gets the Secret from a test API server.
+func newTestEncryptionReconciler(t *testing.T, key []byte, objects ...runtime.Object) *PolicyReconciler {
+	t.Helper()
+
+	if err := common.SetFeatureGates("EncryptedHubTemplates=true"); err != nil {
+		t.Fatalf("failed to enable the feature gate: %v", err)
+	}
+
+	t.Cleanup(func() { _ = common.SetFeatureGates("") })
+
+	secret := &corev1.Secret{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: metav1.ObjectMeta{Name: "creds", Namespace: "policies"},
+		Data:       map[string][]byte{"password": []byte("p@ssw0rd")},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/api/v1/namespaces/policies/secrets/creds" {
+			http.NotFound(w, req)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(secret)
+	}))
+	t.Cleanup(server.Close)
+
+	if key != nil {
+		objects = append(objects, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: common.EncryptionKeySecret, Namespace: "cluster1"},
+			Data:       map[string][]byte{common.EncryptionKeyField: key},
+		})
+	}
+
+	r := newTestReconciler(t, &stubResolver{})
+
+	var kubeClient kubernetes.Interface = kubefake.NewSimpleClientset(append(objects, secret)...)
+	r.kubeClient = &kubeClient
+	r.kubeConfig = &rest.Config{Host: server.URL}
+	r.templateCfg.KubeAPIResourceList = []*metav1.APIResourceList{{
+		GroupVersion: "v1",
+		APIResources: []metav1.APIResource{{Name: "secrets", Kind: "Secret", Namespaced: true}},
+	}}
+
+	return r
+}
+
+// resolvedSpec returns the spec of the resolved policy template of the replicated policy
+func resolvedSpec(t *testing.T, replicated *policiesv1.Policy) map[string]string {
+	t.Helper()
+
+	resolved := struct {
+		Spec map[string]string `json:"spec"`
+	}{}
+
+	if err := json.Unmarshal(replicated.Spec.PolicyTemplates[0].ObjectDefinition.Raw, &resolved); err != nil {
+		t.Fatalf("failed to unmarshal the resolved template: %v", err)
+	}
+
+	return resolved.Spec
+}
+
+func TestProcessTemplatesEncryption(t *testing.T) {
+	key := []byte(strings.Repeat("k", 32))
+	decision := appsv1.PlacementDecision{ClusterName: "cluster1", ClusterNamespace: "cluster1"}
+	root := newTestPolicy(`{{hub fromSecret \"policies\" \"creds\" \"password\" hub}}`)
+
+	// Without an encryption key for the cluster, the templates fail to resolve
+	r := newTestEncryptionReconciler(t, nil)
+	replicated := root.DeepCopy()
+
+	err := r.processTemplates(context.TODO(), replicated, decision, root, policyv1beta1.PropagationConfigSpec{})
+	if err == nil {
+		t.Fatal("expected an error without an encryption key")
+	}
+
+	if !strings.Contains(string(replicated.Spec.PolicyTemplates[0].ObjectDefinition.Raw), "hub-templates-error") {
+		t.Fatalf("expected the hub-templates-error annotation, got %s", replicated.Spec.PolicyTemplates[0].ObjectDefinition.Raw)
+	}
+
+	r = newTestEncryptionReconciler(t, key)
+	replicated = root.DeepCopy()
+
+	err = r.processTemplates(context.TODO(), replicated, decision, root, policyv1beta1.PropagationConfigSpec{})
+	if err != nil {
+		t.Fatalf("processTemplates returned an error: %v", err)
+	}
+
+	// fromSecret returns the encrypted base64 encoded value of the Secret
+	decrypted, err := decryptValue(key, resolvedSpec(t, replicated)["namespace"])
+	if err != nil {
+		t.Fatalf("decryptValue returned an error: %v", err)
+	}
+
+	if string(decrypted) != base64.StdEncoding.EncodeToString([]byte("p@ssw0rd")) {
+		t.Fatalf("expected the base64 encoded Secret value to be decrypted, got %q", decrypted)
+	}
+}
+
+func TestProcessTemplatesEncryptionRoundTrip(t *testing.T) {
+	key := []byte(strings.Repeat("k", 32))
+	decision := appsv1.PlacementDecision{ClusterName: "cluster1", ClusterNamespace: "cluster1"}
+
+	root := newTestPolicy("default")
+	root.Spec.PolicyTemplates[0].ObjectDefinition.Raw = []byte(`{` +
+		`"apiVersion":"policy.open-cluster-management.io/v1","kind":"ConfigurationPolicy",` +
+		`"metadata":{"name":"case"},"spec":{` +
+		`"fromSecret":"{{hub fromSecret \"policies\" \"creds\" \"password\" hub}}",` +
+		`"decoded":"{{hub fromSecret \"policies\" \"creds\" \"password\" | base64dec hub}}",` +
+		`"lookup":"{{hub (lookup \"v1\" \"Secret\" \"policies\" \"creds\").data.password hub}}",` +
+		`"protect":"{{hub protect \"plain\" hub}}",` +
+		`"protectSecret":"{{hub fromSecret \"policies\" \"creds\" \"password\" | base64dec | ` +
+		`printf \"user:%s\" | protect hub}}",` +
+		// The encrypted value of 40 bytes has no base64 padding before the suffix
+		`"suffixed":"{{hub protect \"` + strings.Repeat("s", 40) + `\" hub}}Zm9v",` +
+		`"cluster":"{{hub .ManagedClusterName hub}}"}}`)
+
+	r := newTestEncryptionReconciler(t, key)
+	replicated := root.DeepCopy()
+
+	err := r.processTemplates(context.TODO(), replicated, decision, root, policyv1beta1.PropagationConfigSpec{})
+	if err != nil {
+		t.Fatalf("processTemplates returned an error: %v", err)
+	}
+
+	rendered := string(replicated.Spec.PolicyTemplates[0].ObjectDefinition.Raw)
+	if strings.Contains(rendered, "p@ssw0rd") || strings.Contains(rendered, "cEBzc3cwcmQ=") {
+		t.Fatalf("expected the Secret value not to be readable, got %s", rendered)
+	}
+
+	spec := resolvedSpec(t, replicated)
+
+	expected := map[string]string{
+		"fromSecret":    "cEBzc3cwcmQ=",
+		"decoded":       "p@ssw0rd",
+		"lookup":        "cEBzc3cwcmQ=",
+		"protect":       "plain",
+		"protectSecret": "user:p@ssw0rd",
+	}
+
+	for field, value := range expected {
+		decrypted, err := decryptValue(key, spec[field])
+		if err != nil {
+			t.Fatalf("failed to decrypt the %s field %q: %v", field, spec[field], err)
+		}
+
+		if string(decrypted) != value {
+			t.Fatalf("expected the %s field to be decrypted to %q, got %q", field, value, decrypted)
+		}
+	}
+
+	if spec["cluster"] != "cluster1" {
+		t.Fatalf("expected the values not resolved from Secrets to not be encrypted, got %q", spec["cluster"])
+	}
+
+	if !strings.HasSuffix(spec["suffixed"], "Zm9v") {
+		t.Fatalf("expected the text after the encrypted value to be kept, got %q", spec["suffixed"])
+	}
+
+	if spec["fromSecret"] == spec["lookup"] {
+		t.Fatalf("expected the equal values to be encrypted differently, got %q", spec["lookup"])
+	}
+
+	// The encrypted values of the existing replicated policy are kept
+	existing := replicated.DeepCopy()
+	existing.SetName(common.FullNameForPolicy(root))
+	existing.SetNamespace("cluster1")
+
+	if err := r.Create(context.TODO(), existing); err != nil {
+		t.Fatalf("failed to create the replicated policy: %v", err)
+	}
+
+	r.templateCache = newTemplateCache()
+	replicated = root.DeepCopy()
+
+	err = r.processTemplates(context.TODO(), replicated, decision, root, policyv1beta1.PropagationConfigSpec{})
+	if err != nil {
+		t.Fatalf("processTemplates returned an error: %v", err)
+	}
+
+	if updated := string(replicated.Spec.PolicyTemplates[0].ObjectDefinition.Raw); updated != rendered {
+		t.Fatalf("expected the encrypted values to not change, got %s", updated)
+	}
+}
+
+func TestProcessTemplatesEncryptionModifiedSecret(t *testing.T) {
+	decision := appsv1.PlacementDecision{ClusterName: "cluster1", ClusterNamespace: "cluster1"}
+	// Truncating the value resolved from the Secret keeps only part of it, which can't be encrypted
+	root := newTestPolicy(`{{hub fromSecret \"policies\" \"creds\" \"password\" | base64dec | printf \"%.41s\" hub}}`)
+
+	r := newTestEncryptionReconciler(t, []byte(strings.Repeat("k", 32)))
+
+	err := r.processTemplates(context.TODO(), root.DeepCopy(), decision, root, policyv1beta1.PropagationConfigSpec{})
+	if err == nil || !strings.Contains(err.Error(), errSecretValueModified.Error()) {
+		t.Fatalf("expected the modified Secret value to fail the templates, got %v", err)
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+)
+
+// enforcementLockAnnotation on a namespace forces all the policies replicated from the root
+// policies in it to inform, regardless of their remediationAction
+const enforcementLockAnnotation = "policy.open-cluster-management.io/enforcement-lock"
+
+// enforcementLocked returns whether the namespace has the enforcement lock annotation set to true
+func (r *PolicyReconciler) enforcementLocked(ctx context.Context, namespace string) (bool, error) {
+	ns := &corev1.Namespace{}
+
+	err := r.Get(ctx, types.NamespacedName{Name: namespace}, ns)
+	if err != nil {
+		// The namespace may already be deleted along with the root policy
+		return false, client.IgnoreNotFound(err)
+	}
+
+	return isEnforcementLocked(ns), nil
+}
+
+func isEnforcementLocked(ns client.Object) bool {
+	locked, err := strconv.ParseBool(ns.GetAnnotations()[enforcementLockAnnotation])
+
+	return err == nil && locked
+}
+
+// applyEnforcementLock forces the replicated policy to inform if the namespace of the root policy
+// is locked. The remediationAction of the policy overrides the one of its policy templates on the
+// managed cluster.
+func (r *PolicyReconciler) applyEnforcementLock(
+	ctx context.Context, replicatedPlc *policiesv1.Policy, rootPlc *policiesv1.Policy,
+) error {
+	locked, err := r.enforcementLocked(ctx, rootPlc.GetNamespace())
+	if err != nil {
+		return err
+	}
+
+	if locked {
+		replicatedPlc.Spec.RemediationAction = policiesv1.Inform
+	}
+
+	return nil
+}
+
+// enforcementLockPredicateFuncs only lets through the namespace updates that change the
+// enforcement lock
+var enforcementLockPredicateFuncs = predicate.Funcs{
+	CreateFunc: func(e event.CreateEvent) bool { return false },
+	DeleteFunc: func(e event.DeleteEvent) bool { return false },
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		return isEnforcementLocked(e.ObjectOld) != isEnforcementLocked(e.ObjectNew)
+	},
+}
+
+// namespaceMapper returns a reconcile request for every root policy in the namespace
+func namespaceMapper(c client.Client) handler.MapFunc {
+	return func(object client.Object) []reconcile.Request {
+		return rootPolicyRequests(c, object.GetName())
+	}
+}
+
+// rootPolicyRequests returns a reconcile request for every root policy in the namespace
+func rootPolicyRequests(c client.Client, namespace string) []reconcile.Request {
+	plcList := &policiesv1.PolicyList{}
+
+	err := c.List(context.TODO(), plcList, client.InNamespace(namespace))
+	if err != nil {
+		log.Error(err, "Failed to list the policies in the namespace...", "Namespace", namespace)
+
+		return nil
+	}
+
+	var result []reconcile.Request
+
+	for _, plc := range plcList.Items {
+		if _, replicated := plc.GetLabels()[common.RootPolicyLabel]; replicated {
+			continue
+		}
+
+		log.Info("Found reconciliation request from the policy namespace...",
+			"Namespace", plc.GetNamespace(), "Policy-Name", plc.GetName())
+		result = append(result, reconcile.Request{NamespacedName: types.NamespacedName{
+			Name:      plc.GetName(),
+			Namespace: plc.GetNamespace(),
+		}})
+	}
+
+	return result
+}
@@ -0,0 +1,88 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	policyv1beta1 "github.com/open-cluster-management/governance-policy-propagator/api/v1beta1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+func TestEnforcementLock(t *testing.T) {
+	decision := appsv1.PlacementDecision{ClusterName: "cluster1", ClusterNamespace: "cluster1"}
+
+	root := newTestPolicy("default")
+	root.Spec.RemediationAction = policiesv1.Enforce
+
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:        "policies",
+		Annotations: map[string]string{enforcementLockAnnotation: "true"},
+	}}
+
+	r := newTestReconciler(t, &stubResolver{}, root, ns)
+
+	if _, err := r.handleDecision(context.TODO(), root, decision, policyv1beta1.PropagationConfigSpec{}); err != nil {
+		t.Fatalf("handleDecision returned an error: %v", err)
+	}
+
+	replicatedPlc := &policiesv1.Policy{}
+	key := types.NamespacedName{Namespace: "cluster1", Name: "policies.policy"}
+
+	if err := r.Get(context.TODO(), key, replicatedPlc); err != nil {
+		t.Fatalf("failed to get the replicated policy: %v", err)
+	}
+
+	if replicatedPlc.Spec.RemediationAction != policiesv1.Inform {
+		t.Fatalf("expected the locked namespace to force inform, got %s", replicatedPlc.Spec.RemediationAction)
+	}
+
+	if root.Spec.RemediationAction != policiesv1.Enforce {
+		t.Fatal("expected the root policy to be left as is")
+	}
+
+	unlocked := ns.DeepCopy()
+	unlocked.Annotations = nil
+
+	if !enforcementLockPredicateFuncs.Update(event.UpdateEvent{ObjectOld: ns, ObjectNew: unlocked}) {
+		t.Fatal("expected the removal of the enforcement lock to be let through")
+	}
+
+	if err := r.Update(context.TODO(), unlocked); err != nil {
+		t.Fatalf("failed to unlock the namespace: %v", err)
+	}
+
+	// A replicated policy in the namespace must not be mapped as a root policy
+	replicatedInRoot := newTestPolicy("default")
+	replicatedInRoot.SetName("other.replicated")
+	replicatedInRoot.SetLabels(map[string]string{common.RootPolicyLabel: "other.replicated"})
+
+	if err := r.Create(context.TODO(), replicatedInRoot); err != nil {
+		t.Fatalf("failed to create the replicated policy: %v", err)
+	}
+
+	requests := namespaceMapper(r.Client)(unlocked)
+	if len(requests) != 1 || requests[0].Name != "policy" {
+		t.Fatalf("expected a reconcile request for the root policy only, got %v", requests)
+	}
+
+	if _, err := r.handleDecision(context.TODO(), root, decision, policyv1beta1.PropagationConfigSpec{}); err != nil {
+		t.Fatalf("handleDecision returned an error: %v", err)
+	}
+
+	if err := r.Get(context.TODO(), key, replicatedPlc); err != nil {
+		t.Fatalf("failed to get the replicated policy: %v", err)
+	}
+
+	if replicatedPlc.Spec.RemediationAction != policiesv1.Enforce {
+		t.Fatalf("expected enforce after the lock was removed, got %s", replicatedPlc.Spec.RemediationAction)
+	}
+}
@@ -0,0 +1,234 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+)
+
+// The configuration in seconds of how often the per-cluster PolicyPropagation events of the root
+// policies are aggregated into one event. Set it to 0 to record an event for every cluster.
+const eventAggregationIntervalEnvName = "CONTROLLER_CONFIG_EVENT_AGGREGATION_INTERVAL"
+const eventAggregationIntervalDefault = 30
+
+// maxAggregatedClusters is the number of clusters named in an aggregated event for every failure
+const maxAggregatedClusters = 10
+
+// The outcomes of the replications aggregated in the events
+const (
+	clusterPropagated    = "propagated"
+	clusterUpdated       = "updated"
+	clusterFailed        = "failed"
+	clusterTemplateError = "templateError"
+)
+
+// eventAggregator aggregates the per-cluster PolicyPropagation events of the root policies and
+// records one event for every root policy at every interval, so that a change to a policy
+// replicated to thousands of clusters doesn't record thousands of events. The Warning events that
+// are not per cluster are only recorded once per interval for every root policy and message.
+type eventAggregator struct {
+	recorder record.EventRecorder
+	interval time.Duration
+
+	lock sync.Mutex
+	// pending are the aggregated events of the root policies in the format of <namespace>/<name>
+	pending map[string]*aggregatedEvents
+}
+
+// aggregatedEvents are the events of a root policy aggregated during the interval
+type aggregatedEvents struct {
+	// instance only has the metadata of the root policy needed to record the event
+	instance *policiesv1.Policy
+	// clusters are the names of the clusters for every outcome
+	clusters map[string][]string
+	// warnings are the Warning messages recorded during the interval, with the number of times
+	// they were repeated and not recorded
+	warnings map[string]int
+}
+
+func newEventAggregator(recorder record.EventRecorder, interval time.Duration) *eventAggregator {
+	return &eventAggregator{
+		recorder: recorder,
+		interval: interval,
+		pending:  map[string]*aggregatedEvents{},
+	}
+}
+
+// events returns the aggregated events of the root policy. The lock must be held.
+func (a *eventAggregator) events(instance *policiesv1.Policy) *aggregatedEvents {
+	key := instance.GetNamespace() + "/" + instance.GetName()
+
+	events, ok := a.pending[key]
+	if !ok {
+		events = &aggregatedEvents{
+			instance: &policiesv1.Policy{
+				TypeMeta: instance.TypeMeta,
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            instance.GetName(),
+					Namespace:       instance.GetNamespace(),
+					UID:             instance.GetUID(),
+					ResourceVersion: instance.GetResourceVersion(),
+				},
+			},
+			clusters: map[string][]string{},
+			warnings: map[string]int{},
+		}
+		a.pending[key] = events
+	}
+
+	return events
+}
+
+// clusterEvent adds the outcome of the replication of the root policy to the cluster to the next
+// aggregated event of the root policy
+func (a *eventAggregator) clusterEvent(instance *policiesv1.Policy, outcome string, clusterName string) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	events := a.events(instance)
+	events.clusters[outcome] = append(events.clusters[outcome], clusterName)
+}
+
+// warning returns whether the Warning message of the root policy should be recorded, which is only
+// the case the first time it's seen during the interval. The repeated messages are counted in the
+// aggregated event instead.
+func (a *eventAggregator) warning(instance *policiesv1.Policy, message string) bool {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	events := a.events(instance)
+
+	repeated, seen := events.warnings[message]
+	if seen {
+		events.warnings[message] = repeated + 1
+	} else {
+		events.warnings[message] = 0
+	}
+
+	return !seen
+}
+
+// flush records the aggregated events of the root policies and starts a new interval
+func (a *eventAggregator) flush() {
+	a.lock.Lock()
+	pending := a.pending
+	a.pending = map[string]*aggregatedEvents{}
+	a.lock.Unlock()
+
+	for _, events := range pending {
+		eventType, message := events.message()
+		if message == "" {
+			continue
+		}
+
+		a.recorder.Event(events.instance, eventType, "PolicyPropagation", message)
+	}
+}
+
+// message returns the type and the message of the aggregated event. The message is empty when
+// there is nothing to record.
+func (e *aggregatedEvents) message() (string, string) {
+	parts := []string{}
+
+	if clusters := e.clusters[clusterPropagated]; len(clusters) > 0 {
+		parts = append(parts, "was propagated to "+clusterCount(len(clusters)))
+	}
+
+	if clusters := e.clusters[clusterUpdated]; len(clusters) > 0 {
+		parts = append(parts, "was updated for "+clusterCount(len(clusters)))
+	}
+
+	if clusters := e.clusters[clusterFailed]; len(clusters) > 0 {
+		parts = append(parts, "failed to be propagated to "+clusterCount(len(clusters))+": "+clusterNames(clusters))
+	}
+
+	if clusters := e.clusters[clusterTemplateError]; len(clusters) > 0 {
+		parts = append(parts,
+			"failed to resolve its hub templates for "+clusterCount(len(clusters))+": "+clusterNames(clusters))
+	}
+
+	repeated := 0
+	for _, count := range e.warnings {
+		repeated += count
+	}
+
+	if len(parts) == 0 && repeated == 0 {
+		return "", ""
+	}
+
+	eventType := "Normal"
+	if len(e.clusters[clusterFailed]) > 0 || len(e.clusters[clusterTemplateError]) > 0 {
+		eventType = "Warning"
+	}
+
+	message := fmt.Sprintf("Policy %s/%s", e.instance.GetNamespace(), e.instance.GetName())
+
+	if len(parts) > 0 {
+		message += " " + strings.Join(parts, ", ")
+	}
+
+	if repeated > 0 {
+		message += fmt.Sprintf(" (%d repeated warnings were not recorded)", repeated)
+	}
+
+	return eventType, message
+}
+
+// clusterCount returns the number of clusters with their unit
+func clusterCount(count int) string {
+	if count == 1 {
+		return "1 cluster"
+	}
+
+	return fmt.Sprintf("%d clusters", count)
+}
+
+// clusterNames returns the sorted names of the clusters, with at most maxAggregatedClusters of them
+// named
+func clusterNames(clusters []string) string {
+	sorted := append([]string{}, clusters...)
+	sort.Strings(sorted)
+
+	if len(sorted) <= maxAggregatedClusters {
+		return strings.Join(sorted, ", ")
+	}
+
+	return fmt.Sprintf(
+		"%s and %d more", strings.Join(sorted[:maxAggregatedClusters], ", "), len(sorted)-maxAggregatedClusters,
+	)
+}
+
+// Start records the aggregated events at every interval until the context is canceled, and then
+// records the remaining ones
+func (a *eventAggregator) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, func(context.Context) { a.flush() }, a.interval)
+
+	a.flush()
+
+	return nil
+}
+
+// recordClusterEvent records the event of the replication of the root policy to the cluster, or
+// adds its outcome to the aggregated event of the root policy when the events are aggregated
+func (r *PolicyReconciler) recordClusterEvent(
+	instance *policiesv1.Policy, eventType string, outcome string, clusterName string, message string,
+) {
+	if r.eventAggregator == nil {
+		r.Recorder.Event(instance, eventType, "PolicyPropagation", message)
+
+		return
+	}
+
+	r.eventAggregator.clusterEvent(instance, outcome, clusterName)
+}
@@ -0,0 +1,90 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/tools/record"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+)
+
+func TestEventAggregator(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	aggregator := newEventAggregator(recorder, time.Minute)
+	root := newTestPolicy("default")
+
+	for i := 1; i <= 842; i++ {
+		aggregator.clusterEvent(root, clusterPropagated, fmt.Sprintf("cluster%03d", i))
+	}
+
+	for _, cluster := range []string{"c3", "c1", "c2"} {
+		aggregator.clusterEvent(root, clusterFailed, cluster)
+	}
+
+	if !aggregator.warning(root, "Could not list the replicated policies") {
+		t.Fatal("expected the first warning to be recorded")
+	}
+
+	if aggregator.warning(root, "Could not list the replicated policies") {
+		t.Fatal("expected the repeated warning not to be recorded")
+	}
+
+	aggregator.flush()
+	// Nothing is recorded when nothing happened during the interval
+	aggregator.flush()
+	close(recorder.Events)
+
+	recorded := []string{}
+	for event := range recorder.Events {
+		recorded = append(recorded, event)
+	}
+
+	expected := "Warning PolicyPropagation Policy policies/policy was propagated to 842 clusters, failed to be " +
+		"propagated to 3 clusters: c1, c2, c3 (1 repeated warnings were not recorded)"
+	if len(recorded) != 1 || recorded[0] != expected {
+		t.Fatalf("expected the event %q, got %v", expected, recorded)
+	}
+}
+
+func TestClusterNames(t *testing.T) {
+	clusters := []string{}
+	for i := 12; i > 0; i-- {
+		clusters = append(clusters, fmt.Sprintf("cluster%02d", i))
+	}
+
+	expected := "cluster01, cluster02, cluster03, cluster04, cluster05, cluster06, cluster07, cluster08, " +
+		"cluster09, cluster10 and 2 more"
+	if actual := clusterNames(clusters); actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+}
+
+func TestHandleRootPolicyAggregatedEvents(t *testing.T) {
+	root := newTestPolicy("default")
+	pb := newTestPlacementBinding("pb", "plr", policiesv1.Subject{
+		APIGroup: policiesv1.SchemeGroupVersion.Group, Kind: policiesv1.Kind, Name: "policy",
+	})
+
+	r := newTestReconciler(t, &stubResolver{}, root, pb,
+		newTestPlacementRule("plr", "cluster1", "cluster2", "cluster3"))
+	r.eventAggregator = newEventAggregator(r.Recorder, time.Minute)
+
+	if err := r.handleRootPolicy(context.TODO(), root, nil); err != nil {
+		t.Fatalf("handleRootPolicy returned an error: %v", err)
+	}
+
+	r.eventAggregator.flush()
+
+	// The PlacementRule deprecation warning is recorded first
+	recorded := events(r)
+
+	expected := "Normal PolicyPropagation Policy policies/policy was propagated to 3 clusters"
+	if len(recorded) != 2 || recorded[1] != expected {
+		t.Fatalf("expected the event %q, got %v", expected, recorded)
+	}
+}
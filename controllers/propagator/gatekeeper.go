@@ -0,0 +1,165 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+)
+
+// The API groups of the Gatekeeper ConstraintTemplates and of the Constraints, whose kinds are
+// defined by the ConstraintTemplates
+const (
+	gatekeeperTemplatesGroup   = "templates.gatekeeper.sh"
+	gatekeeperConstraintsGroup = "constraints.gatekeeper.sh"
+)
+
+// The enforcement actions of the Gatekeeper Constraints
+var gatekeeperEnforcementActions = []string{"deny", "dryrun", "warn"}
+
+// policyTemplateObject is the part of the policy templates that is validated before they are
+// replicated
+type policyTemplateObject struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Metadata   struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Spec map[string]interface{} `json:"spec"`
+}
+
+// group returns the API group of the object
+func (o *policyTemplateObject) group() string {
+	return strings.SplitN(o.APIVersion, "/", 2)[0]
+}
+
+// isGatekeeperObject returns whether the policy template is a Gatekeeper ConstraintTemplate or
+// Constraint, which may have hub templates like the ConfigurationPolicies
+func isGatekeeperObject(policyT *policiesv1.PolicyTemplate) bool {
+	object := &policyTemplateObject{}
+	if err := json.Unmarshal(policyT.ObjectDefinition.Raw, object); err != nil {
+		return false
+	}
+
+	switch object.group() {
+	case gatekeeperTemplatesGroup:
+		return object.Kind == "ConstraintTemplate"
+	case gatekeeperConstraintsGroup:
+		return object.Kind != ""
+	}
+
+	return false
+}
+
+// validateGatekeeperTemplates returns an error if a Gatekeeper ConstraintTemplate or Constraint in
+// the policy templates of the root policy is not structurally sound, since Gatekeeper would reject
+// it on every managed cluster. The values with hub templates aren't validated until they are
+// resolved.
+func validateGatekeeperTemplates(instance *policiesv1.Policy, startDelim string) error {
+	for i, policyT := range instance.Spec.PolicyTemplates {
+		if policyT == nil || !isGatekeeperObject(policyT) {
+			continue
+		}
+
+		object := &policyTemplateObject{}
+		if err := json.Unmarshal(policyT.ObjectDefinition.Raw, object); err != nil {
+			return err
+		}
+
+		var err error
+
+		if object.group() == gatekeeperTemplatesGroup {
+			err = validateConstraintTemplate(object, startDelim)
+		} else {
+			err = validateConstraint(object, startDelim)
+		}
+
+		if err != nil {
+			return fmt.Errorf("policy template %d: the %s %s is invalid: %w", i, object.Kind, object.Metadata.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// validateConstraintTemplate returns an error if the ConstraintTemplate doesn't define the kind of
+// its Constraints after its name, or has no target with Rego
+func validateConstraintTemplate(object *policyTemplateObject, startDelim string) error {
+	kind, _, err := unstructured.NestedString(object.Spec, "crd", "spec", "names", "kind")
+	if err != nil {
+		return err
+	}
+
+	if kind == "" {
+		return fmt.Errorf("spec.crd.spec.names.kind must be set")
+	}
+
+	if !strings.Contains(kind+object.Metadata.Name, startDelim) && object.Metadata.Name != strings.ToLower(kind) {
+		return fmt.Errorf("the name must be the lowercase of the kind %s", kind)
+	}
+
+	targets, ok := object.Spec["targets"].([]interface{})
+	if !ok || len(targets) == 0 {
+		return fmt.Errorf("spec.targets must have at least one target")
+	}
+
+	for i, target := range targets {
+		targetMap, ok := target.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("spec.targets[%d] must be an object", i)
+		}
+
+		if name, _ := targetMap["target"].(string); name == "" {
+			return fmt.Errorf("spec.targets[%d].target must be set", i)
+		}
+
+		rego, _ := targetMap["rego"].(string)
+		code, _ := targetMap["code"].([]interface{})
+
+		if rego == "" && len(code) == 0 {
+			return fmt.Errorf("spec.targets[%d] must have rego or code", i)
+		}
+	}
+
+	return nil
+}
+
+// validateConstraint returns an error if the match, parameters, or enforcementAction of the
+// Constraint are invalid
+func validateConstraint(object *policyTemplateObject, startDelim string) error {
+	if object.Metadata.Name == "" {
+		return fmt.Errorf("metadata.name must be set")
+	}
+
+	for _, field := range []string{"match", "parameters"} {
+		if value, ok := object.Spec[field]; ok && value != nil {
+			if _, ok := value.(map[string]interface{}); !ok {
+				return fmt.Errorf("spec.%s must be an object", field)
+			}
+		}
+	}
+
+	action, found, err := unstructured.NestedString(object.Spec, "enforcementAction")
+	if err != nil {
+		return err
+	}
+
+	if !found || strings.Contains(action, startDelim) {
+		return nil
+	}
+
+	for _, validAction := range gatekeeperEnforcementActions {
+		if action == validAction {
+			return nil
+		}
+	}
+
+	return fmt.Errorf(
+		"spec.enforcementAction must be one of %s, got %q", strings.Join(gatekeeperEnforcementActions, ", "), action,
+	)
+}
@@ -0,0 +1,170 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	policyv1beta1 "github.com/open-cluster-management/governance-policy-propagator/api/v1beta1"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+const constraintTemplate = `{"apiVersion":"templates.gatekeeper.sh/v1beta1","kind":"ConstraintTemplate",` +
+	`"metadata":{"name":"k8srequiredlabels"},"spec":{"crd":{"spec":{"names":{"kind":"K8sRequiredLabels"}}},` +
+	`"targets":[{"target":"admission.k8s.gatekeeper.sh","rego":"package k8srequiredlabels"}]}}`
+
+const constraint = `{"apiVersion":"constraints.gatekeeper.sh/v1beta1","kind":"K8sRequiredLabels",` +
+	`"metadata":{"name":"ns-must-have-owner"},"spec":{"enforcementAction":"dryrun",` +
+	`"match":{"kinds":[{"apiGroups":[""],"kinds":["Namespace"]}]},"parameters":{"labels":["owner"]}}}`
+
+func newGatekeeperPolicy(objectDefinitions ...string) *policiesv1.Policy {
+	root := newTestPolicy("default")
+	root.Spec.PolicyTemplates = nil
+
+	for _, objectDefinition := range objectDefinitions {
+		root.Spec.PolicyTemplates = append(root.Spec.PolicyTemplates, &policiesv1.PolicyTemplate{
+			ObjectDefinition: runtime.RawExtension{Raw: []byte(objectDefinition)},
+		})
+	}
+
+	return root
+}
+
+func TestIsGatekeeperObject(t *testing.T) {
+	tests := []struct {
+		objectDefinition string
+		expected         bool
+	}{
+		{objectDefinition: constraintTemplate, expected: true},
+		{objectDefinition: constraint, expected: true},
+		{objectDefinition: configPolicy},
+		{objectDefinition: `{"apiVersion":"templates.gatekeeper.sh/v1beta1","kind":"Other"}`},
+		{objectDefinition: `{"apiVersion":"v1","kind":"ConfigMap"}`},
+	}
+
+	for _, test := range tests {
+		policyT := &policiesv1.PolicyTemplate{ObjectDefinition: runtime.RawExtension{Raw: []byte(test.objectDefinition)}}
+
+		if actual := isGatekeeperObject(policyT); actual != test.expected {
+			t.Fatalf("expected isGatekeeperObject to be %v for %s", test.expected, test.objectDefinition)
+		}
+	}
+}
+
+func TestValidateGatekeeperTemplates(t *testing.T) {
+	tests := []struct {
+		name             string
+		objectDefinition string
+		expectedErr      string
+	}{
+		{name: "valid template", objectDefinition: constraintTemplate},
+		{name: "valid constraint", objectDefinition: constraint},
+		{name: "not Gatekeeper", objectDefinition: configPolicy},
+		{
+			name:             "template name",
+			objectDefinition: strings.Replace(constraintTemplate, `"name":"k8srequiredlabels"`, `"name":"labels"`, 1),
+			expectedErr:      "the name must be the lowercase of the kind K8sRequiredLabels",
+		},
+		{
+			name: "template name with a hub template",
+			objectDefinition: strings.Replace(
+				constraintTemplate, `"name":"k8srequiredlabels"`, `"name":"{{hub .ManagedClusterName hub}}"`, 1,
+			),
+		},
+		{
+			name:             "template without kind",
+			objectDefinition: strings.Replace(constraintTemplate, `"kind":"K8sRequiredLabels"`, `"plural":"labels"`, 1),
+			expectedErr:      "spec.crd.spec.names.kind must be set",
+		},
+		{
+			name:             "template without targets",
+			objectDefinition: strings.Replace(constraintTemplate, `"rego":"package k8srequiredlabels"`, `"libs":[]`, 1),
+			expectedErr:      "spec.targets[0] must have rego or code",
+		},
+		{
+			name:             "constraint enforcementAction",
+			objectDefinition: strings.Replace(constraint, `"dryrun"`, `"enforce"`, 1),
+			expectedErr:      `spec.enforcementAction must be one of deny, dryrun, warn, got "enforce"`,
+		},
+		{
+			name:             "constraint enforcementAction with a hub template",
+			objectDefinition: strings.Replace(constraint, `"dryrun"`, `"{{hub .ManagedClusterName hub}}"`, 1),
+		},
+		{
+			name:             "constraint match",
+			objectDefinition: strings.Replace(constraint, `"match":{`, `"match":"all","other":{`, 1),
+			expectedErr:      "spec.match must be an object",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			err := validateGatekeeperTemplates(newGatekeeperPolicy(test.objectDefinition), "{{hub")
+
+			if test.expectedErr == "" {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), test.expectedErr) {
+				t.Fatalf("expected the error %q, got %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestProcessTemplatesGatekeeper(t *testing.T) {
+	decision := appsv1.PlacementDecision{ClusterName: "cluster1", ClusterNamespace: "cluster1"}
+	resolved := strings.Replace(constraint, `"dryrun"`, `"deny"`, 1)
+
+	root := newGatekeeperPolicy(strings.Replace(constraint, `"dryrun"`, `"{{hub .ManagedClusterName hub}}"`, 1))
+	r := newTestReconciler(t, &stubResolver{result: []byte(resolved)}, root)
+
+	replicated := root.DeepCopy()
+
+	err := r.processTemplates(context.TODO(), replicated, decision, root, policyv1beta1.PropagationConfigSpec{})
+	if err != nil {
+		t.Fatalf("expected the hub templates of the Constraint to be resolved, got %v", err)
+	}
+
+	if actual := string(replicated.Spec.PolicyTemplates[0].ObjectDefinition.Raw); actual != resolved {
+		t.Fatalf("expected the resolved Constraint, got %s", actual)
+	}
+
+	// The other policy templates still can't have hub templates
+	root = newGatekeeperPolicy(`{"apiVersion":"v1","kind":"ConfigMap","data":{"a":"{{hub .ManagedClusterName hub}}"}}`)
+	replicated = root.DeepCopy()
+	r = newTestReconciler(t, &stubResolver{result: []byte(resolved)}, root)
+
+	err = r.processTemplates(context.TODO(), replicated, decision, root, policyv1beta1.PropagationConfigSpec{})
+	if err == nil {
+		t.Fatal("expected the hub templates of a ConfigMap to be rejected")
+	}
+}
+
+func TestHandleRootPolicyInvalidGatekeeper(t *testing.T) {
+	root := newGatekeeperPolicy(strings.Replace(constraint, `"dryrun"`, `"enforce"`, 1))
+	r := newTestReconciler(t, &stubResolver{}, root)
+
+	if err := r.handleRootPolicy(context.TODO(), root, nil); err == nil {
+		t.Fatal("expected the invalid Constraint not to be replicated")
+	}
+
+	for _, event := range events(r) {
+		if strings.Contains(event, "Invalid Gatekeeper object in the policy templates") {
+			return
+		}
+	}
+
+	t.Fatal("expected a warning event for the invalid Constraint")
+}
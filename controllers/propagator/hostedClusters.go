@@ -0,0 +1,40 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+
+	clusterv1 "github.com/open-cluster-management/api/cluster/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+// hostedClusterDecision returns the decision with the cluster namespace set on the ManagedCluster of
+// a hosted control plane when the HostedClusterNamespaces feature is enabled. The decision is
+// returned as is otherwise, or when the ManagedCluster doesn't exist.
+func (r *PolicyReconciler) hostedClusterDecision(
+	ctx context.Context, decision appsv1.PlacementDecision,
+) (appsv1.PlacementDecision, error) {
+	if !common.FeatureEnabled(common.HostedClusterNamespaces) {
+		return decision, nil
+	}
+
+	cluster := &clusterv1.ManagedCluster{}
+
+	err := r.Get(ctx, types.NamespacedName{Name: decision.ClusterName}, cluster)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return decision, nil
+		}
+
+		return decision, err
+	}
+
+	decision.ClusterNamespace = common.ClusterNamespace(cluster)
+
+	return decision, nil
+}
@@ -0,0 +1,80 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"testing"
+
+	clusterv1 "github.com/open-cluster-management/api/cluster/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+func TestHostedClusterNamespaces(t *testing.T) {
+	if err := common.SetFeatureGates("HostedClusterNamespaces=true"); err != nil {
+		t.Fatalf("failed to enable the feature gate: %v", err)
+	}
+
+	defer func() {
+		_ = common.SetFeatureGates("")
+	}()
+
+	root := newTestPolicy("default")
+	cluster := &clusterv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{
+		Name:        "hosted",
+		Annotations: map[string]string{common.KlusterletNamespaceAnnotation: "klusterlet-hosted"},
+	}}
+	plr := &appsv1.PlacementRule{
+		ObjectMeta: metav1.ObjectMeta{Name: "plr", Namespace: "policies"},
+		Status: appsv1.PlacementRuleStatus{
+			Decisions: []appsv1.PlacementDecision{{ClusterName: "hosted", ClusterNamespace: "hosted"}},
+		},
+	}
+	pb := &policiesv1.PlacementBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "pb", Namespace: "policies"},
+		PlacementRef: policiesv1.Subject{
+			APIGroup: appsv1.SchemeGroupVersion.Group, Kind: "PlacementRule", Name: "plr",
+		},
+		Subjects: []policiesv1.Subject{
+			{APIGroup: policiesv1.SchemeGroupVersion.Group, Kind: policiesv1.Kind, Name: "policy"},
+		},
+	}
+
+	if !common.IsInClusterNamespace("klusterlet-hosted", []clusterv1.ManagedCluster{*cluster}) {
+		t.Fatal("expected the klusterlet namespace to be a cluster namespace")
+	}
+
+	r := newTestReconciler(t, &stubResolver{}, root, cluster, plr, pb)
+
+	_, err := r.Reconcile(context.TODO(), ctrl.Request{
+		NamespacedName: types.NamespacedName{Namespace: "policies", Name: "policy"},
+	})
+	if err != nil {
+		t.Fatalf("Reconcile returned an error: %v", err)
+	}
+
+	replicated := &policiesv1.Policy{}
+
+	err = r.Get(context.TODO(), types.NamespacedName{Namespace: "klusterlet-hosted", Name: "policies.policy"}, replicated)
+	if err != nil {
+		t.Fatalf("expected the policy to be replicated to the klusterlet namespace, got the error: %v", err)
+	}
+
+	if replicated.GetLabels()[common.ClusterNameLabel] != "hosted" ||
+		replicated.GetLabels()[common.ClusterNamespaceLabel] != "klusterlet-hosted" {
+		t.Fatalf("expected the replicated policy to be labeled with the hosted cluster, got %v",
+			replicated.GetLabels())
+	}
+
+	err = r.Get(context.TODO(), types.NamespacedName{Namespace: "hosted", Name: "policies.policy"}, replicated)
+	if !k8serrors.IsNotFound(err) {
+		t.Fatalf("expected no policy to be replicated to the namespace named after the cluster, got: %v", err)
+	}
+}
@@ -0,0 +1,231 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+)
+
+// cronSchedule is a parsed cron expression. Each field is the set of the allowed values.
+type cronSchedule struct {
+	minutes     map[int]bool
+	hours       map[int]bool
+	daysOfMonth map[int]bool
+	months      map[int]bool
+	daysOfWeek  map[int]bool
+	// anyDayOfMonth and anyDayOfWeek are whether the day fields are unrestricted. When only one of
+	// them is restricted, the other is ignored. When both are, either one may match.
+	anyDayOfMonth bool
+	anyDayOfWeek  bool
+}
+
+// parseCronSchedule parses a cron expression in the format of
+// "minute hour day-of-month month day-of-week". Each field is a comma separated list of *, a
+// value, or a range, optionally with a step such as */15 or 1-5/2. Sunday is 0 or 7.
+func parseCronSchedule(expression string) (*cronSchedule, error) {
+	fields := strings.Fields(expression)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("the cron expression %q must have 5 fields", expression)
+	}
+
+	bounds := [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+	sets := [5]map[int]bool{}
+
+	for i, field := range fields {
+		set, err := parseCronField(field, bounds[i][0], bounds[i][1])
+		if err != nil {
+			return nil, fmt.Errorf("the cron expression %q is invalid: %w", expression, err)
+		}
+
+		sets[i] = set
+	}
+
+	// Sunday is both 0 and 7
+	if sets[4][7] {
+		sets[4][0] = true
+	}
+
+	return &cronSchedule{
+		minutes:       sets[0],
+		hours:         sets[1],
+		daysOfMonth:   sets[2],
+		months:        sets[3],
+		daysOfWeek:    sets[4],
+		anyDayOfMonth: strings.HasPrefix(fields[2], "*"),
+		anyDayOfWeek:  strings.HasPrefix(fields[4], "*"),
+	}, nil
+}
+
+// parseCronField returns the set of the values allowed by the cron field
+func parseCronField(field string, minValue int, maxValue int) (map[int]bool, error) {
+	set := map[int]bool{}
+
+	for _, part := range strings.Split(field, ",") {
+		valueRange := part
+		step := 1
+
+		if i := strings.Index(part, "/"); i != -1 {
+			var err error
+
+			step, err = strconv.Atoi(part[i+1:])
+			if err != nil || step <= 0 {
+				return nil, fmt.Errorf("the step of %q is invalid", part)
+			}
+
+			valueRange = part[:i]
+		}
+
+		start, end := minValue, maxValue
+
+		if valueRange != "*" {
+			bounds := strings.SplitN(valueRange, "-", 2)
+
+			var err error
+
+			start, err = strconv.Atoi(bounds[0])
+			if err != nil {
+				return nil, fmt.Errorf("the value %q is invalid", part)
+			}
+
+			end = start
+			if len(bounds) == 2 {
+				end, err = strconv.Atoi(bounds[1])
+				if err != nil {
+					return nil, fmt.Errorf("the value %q is invalid", part)
+				}
+			} else if step != 1 {
+				// A single value with a step, such as 5/15, runs until the maximum value
+				end = maxValue
+			}
+		}
+
+		if start < minValue || end > maxValue || start > end {
+			return nil, fmt.Errorf("the value %q is out of the range %d-%d", part, minValue, maxValue)
+		}
+
+		for value := start; value <= end; value += step {
+			set[value] = true
+		}
+	}
+
+	return set, nil
+}
+
+// dayMatches returns whether the schedule allows the day of the time
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	dayOfMonth := s.daysOfMonth[t.Day()]
+	dayOfWeek := s.daysOfWeek[int(t.Weekday())]
+
+	switch {
+	case s.anyDayOfMonth && s.anyDayOfWeek:
+		return true
+	case s.anyDayOfMonth:
+		return dayOfWeek
+	case s.anyDayOfWeek:
+		return dayOfMonth
+	default:
+		return dayOfMonth || dayOfWeek
+	}
+}
+
+// next returns the first time after t that the schedule allows, or the zero time if there is none
+// in the next five years, such as for February 30
+func (s *cronSchedule) next(t time.Time) time.Time {
+	t = t.UTC().Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if !s.months[int(t.Month())] {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+
+			continue
+		}
+
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+
+			continue
+		}
+
+		if !s.hours[t.Hour()] {
+			t = t.Truncate(time.Hour).Add(time.Hour)
+
+			continue
+		}
+
+		if !s.minutes[t.Minute()] {
+			t = t.Add(time.Minute)
+
+			continue
+		}
+
+		return t
+	}
+
+	return time.Time{}
+}
+
+// maintenanceWindowOpens returns the zero time if the maintenance window of the root policy is open
+// at now, or when it opens next otherwise. A root policy without a maintenance window is always
+// open.
+func maintenanceWindowOpens(instance *policiesv1.Policy, now time.Time) (time.Time, error) {
+	window := instance.Spec.MaintenanceWindow
+	if window == nil {
+		return time.Time{}, nil
+	}
+
+	schedule, err := parseCronSchedule(window.Schedule)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	// The window is open if it opened within its duration before now
+	opened := schedule.next(now.Add(-window.Duration.Duration - time.Minute))
+	if !opened.IsZero() && !opened.After(now) && now.Before(opened.Add(window.Duration.Duration)) {
+		return time.Time{}, nil
+	}
+
+	opens := schedule.next(now)
+	if opens.IsZero() {
+		return time.Time{}, fmt.Errorf("the maintenance window schedule %q never opens", window.Schedule)
+	}
+
+	return opens, nil
+}
+
+// updatePendingError is returned by handleDecision instead of updating the replicated policy when
+// the maintenance window of the root policy is closed. It is not retried.
+type updatePendingError struct {
+	opens time.Time
+}
+
+func (e *updatePendingError) Error() string {
+	return fmt.Sprintf(
+		"the replicated policy update is pending until the maintenance window opens at %s",
+		e.opens.UTC().Format(time.RFC3339),
+	)
+}
+
+// pendingUpdateRequeue returns how long to wait before the root policy is reconciled again to push
+// the updates pending on its maintenance window, or 0 if there are none
+func pendingUpdateRequeue(instance *policiesv1.Policy, now time.Time) time.Duration {
+	for _, cpcs := range instance.Status.Status {
+		if cpcs.Reason != reasonPendingUpdate {
+			continue
+		}
+
+		opens, err := maintenanceWindowOpens(instance, now)
+		if err != nil || opens.IsZero() {
+			return 0
+		}
+
+		return opens.Sub(now)
+	}
+
+	return 0
+}
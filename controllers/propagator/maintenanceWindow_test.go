@@ -0,0 +1,153 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	policyv1beta1 "github.com/open-cluster-management/governance-policy-propagator/api/v1beta1"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+func TestCronScheduleNext(t *testing.T) {
+	// A Wednesday
+	from := time.Date(2021, time.June, 2, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		schedule string
+		expected time.Time
+	}{
+		{"* * * * *", time.Date(2021, time.June, 2, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2021, time.June, 2, 10, 45, 0, 0, time.UTC)},
+		{"0 2 * * *", time.Date(2021, time.June, 3, 2, 0, 0, 0, time.UTC)},
+		{"0 2 * * 6", time.Date(2021, time.June, 5, 2, 0, 0, 0, time.UTC)},
+		{"0 2 * * 7", time.Date(2021, time.June, 6, 2, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2021, time.July, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 15 * 1", time.Date(2021, time.June, 7, 0, 0, 0, 0, time.UTC)},
+		{"30 9-17/4 * 1,12 *", time.Date(2021, time.December, 1, 9, 30, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+
+	for _, test := range tests {
+		schedule, err := parseCronSchedule(test.schedule)
+		if err != nil {
+			t.Fatalf("failed to parse %q: %v", test.schedule, err)
+		}
+
+		if next := schedule.next(from); !next.Equal(test.expected) {
+			t.Fatalf("expected %q to be next at %v, got %v", test.schedule, test.expected, next)
+		}
+	}
+}
+
+func TestParseCronScheduleInvalid(t *testing.T) {
+	for _, schedule := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		if _, err := parseCronSchedule(schedule); err == nil {
+			t.Fatalf("expected an error for %q", schedule)
+		}
+	}
+}
+
+func TestMaintenanceWindowOpens(t *testing.T) {
+	root := newTestPolicy("default")
+	root.Spec.MaintenanceWindow = &policiesv1.MaintenanceWindow{
+		Schedule: "0 2 * * *", Duration: metav1.Duration{Duration: 2 * time.Hour},
+	}
+
+	tests := []struct {
+		now      time.Time
+		expected time.Time
+	}{
+		{time.Date(2021, time.June, 2, 2, 0, 0, 0, time.UTC), time.Time{}},
+		{time.Date(2021, time.June, 2, 3, 59, 0, 0, time.UTC), time.Time{}},
+		{time.Date(2021, time.June, 2, 4, 0, 0, 0, time.UTC), time.Date(2021, time.June, 3, 2, 0, 0, 0, time.UTC)},
+		{time.Date(2021, time.June, 2, 1, 59, 0, 0, time.UTC), time.Date(2021, time.June, 2, 2, 0, 0, 0, time.UTC)},
+	}
+
+	for _, test := range tests {
+		opens, err := maintenanceWindowOpens(root, test.now)
+		if err != nil {
+			t.Fatalf("maintenanceWindowOpens returned an error: %v", err)
+		}
+
+		if !opens.Equal(test.expected) {
+			t.Fatalf("expected the window to open at %v at %v, got %v", test.expected, test.now, opens)
+		}
+	}
+}
+
+func TestHandleDecisionMaintenanceWindow(t *testing.T) {
+	decision := appsv1.PlacementDecision{ClusterName: "cluster1", ClusterNamespace: "cluster1"}
+	root := newTestPolicy("default")
+	root.Spec.RemediationAction = policiesv1.Inform
+	root.Spec.MaintenanceWindow = &policiesv1.MaintenanceWindow{
+		Schedule: "0 2 * * *", Duration: metav1.Duration{Duration: time.Hour},
+	}
+
+	existing := newTestReplicatedPolicy(root, "cluster1", policiesv1.Compliant)
+	existing.Spec.RemediationAction = policiesv1.Enforce
+
+	r := newTestReconciler(t, &stubResolver{}, root, existing)
+	fakeClock := clock.NewFakeClock(time.Date(2021, time.June, 2, 10, 0, 0, 0, time.UTC))
+	r.clock = fakeClock
+
+	_, err := r.handleDecision(context.TODO(), root, decision, policyv1beta1.PropagationConfigSpec{})
+
+	pendingErr := &updatePendingError{}
+	if !errors.As(err, &pendingErr) {
+		t.Fatalf("expected the update to be pending, got the error: %v", err)
+	}
+
+	if !pendingErr.opens.Equal(time.Date(2021, time.June, 3, 2, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected the update to be pending until the next window, got %v", pendingErr.opens)
+	}
+
+	replicated := &policiesv1.Policy{}
+	key := types.NamespacedName{Namespace: "cluster1", Name: "policies.policy"}
+
+	if err := r.Get(context.TODO(), key, replicated); err != nil {
+		t.Fatalf("failed to get the replicated policy: %v", err)
+	}
+
+	if replicated.Spec.RemediationAction != policiesv1.Enforce {
+		t.Fatalf("expected the replicated policy not to be updated, got %q", replicated.Spec.RemediationAction)
+	}
+
+	fakeClock.SetTime(time.Date(2021, time.June, 3, 2, 30, 0, 0, time.UTC))
+
+	if _, err := r.handleDecision(context.TODO(), root, decision, policyv1beta1.PropagationConfigSpec{}); err != nil {
+		t.Fatalf("handleDecision returned an error in the window: %v", err)
+	}
+
+	if err := r.Get(context.TODO(), key, replicated); err != nil {
+		t.Fatalf("failed to get the replicated policy: %v", err)
+	}
+
+	if replicated.Spec.RemediationAction != policiesv1.Inform {
+		t.Fatalf("expected the replicated policy to be updated in the window, got %q",
+			replicated.Spec.RemediationAction)
+	}
+}
+
+func TestReplicatedStatusPendingUpdate(t *testing.T) {
+	root := newTestPolicy("default")
+	replicated := newTestReplicatedPolicy(root, "cluster1", policiesv1.NonCompliant)
+
+	status := replicatedStatus([]*policiesv1.CompliancePerClusterStatus{{
+		ClusterName: "cluster1", ClusterNamespace: "cluster1", ComplianceState: policiesv1.Compliant,
+		Reason: reasonPendingUpdate, Message: "pending",
+	}}, []policiesv1.Policy{*replicated})
+
+	if len(status) != 1 || status[0].ComplianceState != policiesv1.NonCompliant ||
+		status[0].Reason != reasonPendingUpdate || status[0].Message != "pending" {
+		t.Fatalf("expected the pending cluster to report its compliance, got %+v", status[0])
+	}
+}
@@ -4,17 +4,118 @@
 package propagator
 
 import (
+	"strings"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
 )
 
 var (
-	roothandlerMeasure = prometheus.NewHistogram(prometheus.HistogramOpts{
-		Name: "ocm_handle_root_policy_duration_seconds",
-		Help: "Time the handleRootPolicy function takes to complete.",
+	roothandlerMeasure = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name: "ocm_handle_root_policy_duration_seconds",
+			Help: "Time the handleRootPolicy function takes to complete.",
+		},
+		[]string{
+			"outcome",   // One of the outcome constants below
+			"templates", // "true" if the policy has hub templates, otherwise "false"
+		},
+	)
+	roothandlerTimeouts = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "ocm_handle_root_policy_timeouts_total",
+		Help: "The number of times the handleRootPolicy function was canceled for exceeding the reconcile timeout.",
+	})
+	placementKindGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "ocm_policies_by_placement_kind",
+			Help: "The number of root policies bound through each placement kind. PlacementRule is deprecated.",
+		},
+		[]string{
+			"placement_kind", // "PlacementRule" or "Placement"
+		},
+	)
+	replicatedPolicyGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "policy_governance_replicated_policy_total",
+			Help: "The number of clusters each root policy is replicated to.",
+		},
+		[]string{
+			"policy",
+			"policy_namespace",
+		},
+	)
+	propagationFailuresCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "policy_propagation_failures_total",
+			Help: "The number of times a root policy failed to be replicated to a cluster.",
+		},
+		[]string{
+			"cluster", // The name of the managed cluster
+			"reason",  // The reason of the replication failure, such as "ReplicationFailed"
+		},
+	)
+	templateResolutionDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name: "policy_template_resolution_duration_seconds",
+		Help: "Time the hub templates of a policy template take to be resolved for a cluster.",
+	})
+	templateResolverFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "policy_template_resolver_failures_total",
+		Help: "The number of times the template resolver could not be created to resolve the hub templates.",
 	})
+	propagationLatencyHistogram = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name: "policy_propagation_latency_seconds",
+		Help: "Time from the generation change of a root policy to the update of its replicated policy in a " +
+			"cluster namespace, for a sample of the clusters.",
+		Buckets: []float64{1, 5, 10, 30, 60, 120, 300, 600, 1800},
+	})
+)
+
+// The outcomes of handleRootPolicy used to label roothandlerMeasure
+const (
+	outcomeSuccess        = "success"
+	outcomePlacementError = "placement-error"
+	outcomeTemplateError  = "template-error"
+	outcomeStatusConflict = "status-conflict"
+	outcomePartialFailure = "partial-failure"
+	// outcomeError covers any other error, such as a canceled reconcile or a failed clean up
+	outcomeError = "error"
 )
 
 func init() {
 	metrics.Registry.MustRegister(roothandlerMeasure)
+	metrics.Registry.MustRegister(roothandlerTimeouts)
+	metrics.Registry.MustRegister(placementKindGauge)
+	metrics.Registry.MustRegister(replicatedPolicyGauge)
+	metrics.Registry.MustRegister(propagationFailuresCounter)
+	metrics.Registry.MustRegister(templateResolutionDuration)
+	metrics.Registry.MustRegister(templateResolverFailures)
+	metrics.Registry.MustRegister(propagationLatencyHistogram)
+}
+
+// reportReplicationMetrics sets the number of clusters the root policy is replicated to and counts
+// the replication failures. The clusters skipped on purpose, such as the incompatible clusters,
+// are not counted as failures.
+func reportReplicationMetrics(
+	instance *policiesv1.Policy, allDecisions map[string]bool, failedClusters map[string]replicationFailure,
+) {
+	replicated := 0
+
+	for key := range allDecisions {
+		if _, failed := failedClusters[key]; !failed {
+			replicated++
+		}
+	}
+
+	replicatedPolicyGauge.WithLabelValues(instance.GetName(), instance.GetNamespace()).Set(float64(replicated))
+
+	for key, failure := range failedClusters {
+		if !failure.isFailure() {
+			continue
+		}
+
+		// The string split is safe since the namespace and name must be DNS compliant names
+		propagationFailuresCounter.WithLabelValues(strings.Split(key, "/")[1], failure.reason).Inc()
+	}
 }
@@ -0,0 +1,57 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+)
+
+func TestReportReplicationMetrics(t *testing.T) {
+	instance := &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{Name: "metrics", Namespace: "policies"}}
+
+	failedBefore := testutil.ToFloat64(
+		propagationFailuresCounter.WithLabelValues("metrics-cluster2", reasonReplicationFailed),
+	)
+	incompatibleBefore := testutil.ToFloat64(
+		propagationFailuresCounter.WithLabelValues("metrics-cluster3", reasonClusterIncompatible),
+	)
+
+	allDecisions := map[string]bool{
+		"metrics-cluster1/metrics-cluster1": true,
+		"metrics-cluster2/metrics-cluster2": true,
+	}
+	failedClusters := map[string]replicationFailure{
+		"metrics-cluster2/metrics-cluster2": {reason: reasonReplicationFailed},
+		"metrics-cluster3/metrics-cluster3": {reason: reasonClusterIncompatible},
+	}
+
+	reportReplicationMetrics(instance, allDecisions, failedClusters)
+
+	if actual := testutil.ToFloat64(replicatedPolicyGauge.WithLabelValues("metrics", "policies")); actual != 1 {
+		t.Fatalf("expected the policy to be replicated to 1 cluster, got %v", actual)
+	}
+
+	failed := testutil.ToFloat64(propagationFailuresCounter.WithLabelValues("metrics-cluster2", reasonReplicationFailed))
+	if failed-failedBefore != 1 {
+		t.Fatalf("expected 1 replication failure for metrics-cluster2, got %v", failed-failedBefore)
+	}
+
+	incompatible := testutil.ToFloat64(
+		propagationFailuresCounter.WithLabelValues("metrics-cluster3", reasonClusterIncompatible),
+	)
+	if incompatible != incompatibleBefore {
+		t.Fatalf("expected the incompatible cluster not to be counted as a failure, got %v", incompatible)
+	}
+
+	// A disabled policy has no decisions
+	reportReplicationMetrics(instance, map[string]bool{}, map[string]replicationFailure{})
+
+	if actual := testutil.ToFloat64(replicatedPolicyGauge.WithLabelValues("metrics", "policies")); actual != 0 {
+		t.Fatalf("expected the disabled policy to be replicated to no clusters, got %v", actual)
+	}
+}
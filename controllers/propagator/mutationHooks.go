@@ -0,0 +1,181 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+// A comma separated list of executables to run as mutation hooks, in order, after the hub
+// templates of a replicated policy are resolved.
+const mutationHookCommandsEnvName = "CONTROLLER_CONFIG_MUTATION_HOOK_COMMANDS"
+
+// The amount of time an exec based mutation hook has to complete.
+const mutationHookExecTimeout = 30 * time.Second
+
+// MutationHook mutates a replicated policy for a single placement decision. Hooks run after hub
+// templates are resolved and before the replicated policy is compared to the existing one in the
+// cluster namespace, so they must be deterministic to avoid updating the replicated policy on
+// every reconcile.
+type MutationHook interface {
+	// Name identifies the hook in logs and errors
+	Name() string
+	// Mutate modifies replicatedPlc in place. rootPlc must not be modified. The context is canceled
+	// when the reconcile of the root policy is.
+	Mutate(
+		ctx context.Context,
+		replicatedPlc *policiesv1.Policy,
+		decision appsv1.PlacementDecision,
+		rootPlc *policiesv1.Policy,
+	) error
+}
+
+// newExecMutationHooks returns an exec based mutation hook for each command in the comma
+// separated input.
+func newExecMutationHooks(commands string) []MutationHook {
+	hooks := []MutationHook{}
+
+	for _, command := range strings.Split(commands, ",") {
+		command = strings.TrimSpace(command)
+		if command == "" {
+			continue
+		}
+
+		log.Info("Using the exec based mutation hook", "Command", command)
+		hooks = append(hooks, &execMutationHook{command: command, timeout: mutationHookExecTimeout})
+	}
+
+	return hooks
+}
+
+// applyMutationHooks runs the mutation hooks in order on the replicated policy, and stops at the
+// first one that fails. The labels the propagator relies on to track replicated policies are
+// restored after each hook.
+func applyMutationHooks(
+	ctx context.Context,
+	hooks []MutationHook,
+	replicatedPlc *policiesv1.Policy,
+	decision appsv1.PlacementDecision,
+	rootPlc *policiesv1.Policy,
+) error {
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	trackingLabels := map[string]string{}
+
+	for _, key := range []string{common.ClusterNameLabel, common.ClusterNamespaceLabel, common.RootPolicyLabel} {
+		if value, ok := replicatedPlc.GetLabels()[key]; ok {
+			trackingLabels[key] = value
+		}
+	}
+
+	for _, hook := range hooks {
+		err := hook.Mutate(ctx, replicatedPlc, decision, rootPlc)
+		if err != nil {
+			return fmt.Errorf("the mutation hook %s failed: %w", hook.Name(), err)
+		}
+
+		labels := replicatedPlc.GetLabels()
+		if labels == nil {
+			labels = map[string]string{}
+		}
+
+		for key, value := range trackingLabels {
+			labels[key] = value
+		}
+
+		replicatedPlc.SetLabels(labels)
+	}
+
+	return nil
+}
+
+// execMutationHook runs an executable that receives an execMutationHookInput as JSON on stdin
+// and must write a JSON merge patch of the replicated policy to stdout, such as the whole mutated
+// policy or only the metadata it changes. It is killed after the timeout.
+type execMutationHook struct {
+	command string
+	timeout time.Duration
+}
+
+type execMutationHookInput struct {
+	RootPolicy       string             `json:"rootPolicy"`
+	ClusterName      string             `json:"clusterName"`
+	ClusterNamespace string             `json:"clusterNamespace"`
+	Policy           *policiesv1.Policy `json:"policy"`
+}
+
+func (h *execMutationHook) Name() string {
+	return h.command
+}
+
+func (h *execMutationHook) Mutate(
+	ctx context.Context,
+	replicatedPlc *policiesv1.Policy,
+	decision appsv1.PlacementDecision,
+	rootPlc *policiesv1.Policy,
+) error {
+	input, err := json.Marshal(execMutationHookInput{
+		RootPolicy:       common.FullNameForPolicy(rootPlc),
+		ClusterName:      decision.ClusterName,
+		ClusterNamespace: decision.ClusterNamespace,
+		Policy:           replicatedPlc,
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	// #nosec G204 -- the command is configured by the hub administrator
+	cmd := exec.CommandContext(ctx, h.command)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	err = cmd.Run()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	original, err := json.Marshal(replicatedPlc)
+	if err != nil {
+		return err
+	}
+
+	// The fields missing from the output are kept, so that the spec isn't removed when only the
+	// metadata is mutated
+	patched, err := jsonpatch.MergePatch(original, stdout.Bytes())
+	if err != nil {
+		return fmt.Errorf("failed to parse the mutated policy: %w", err)
+	}
+
+	mutatedPlc := &policiesv1.Policy{}
+
+	err = json.Unmarshal(patched, mutatedPlc)
+	if err != nil {
+		return fmt.Errorf("failed to parse the mutated policy: %w", err)
+	}
+
+	replicatedPlc.SetLabels(mutatedPlc.GetLabels())
+	replicatedPlc.SetAnnotations(mutatedPlc.GetAnnotations())
+	replicatedPlc.Spec = mutatedPlc.Spec
+
+	return nil
+}
@@ -0,0 +1,189 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+)
+
+type testMutationHook struct {
+	name   string
+	mutate func(replicatedPlc *policiesv1.Policy) error
+}
+
+func (h *testMutationHook) Name() string {
+	return h.name
+}
+
+func (h *testMutationHook) Mutate(
+	_ context.Context, replicatedPlc *policiesv1.Policy, _ appsv1.PlacementDecision, _ *policiesv1.Policy,
+) error {
+	return h.mutate(replicatedPlc)
+}
+
+// appendingHook appends its name to the order annotation of the replicated policy
+func appendingHook(name string) *testMutationHook {
+	return &testMutationHook{name: name, mutate: func(replicatedPlc *policiesv1.Policy) error {
+		order := replicatedPlc.GetAnnotations()["order"]
+		replicatedPlc.SetAnnotations(map[string]string{"order": order + name})
+		// The tracking labels are restored after each hook
+		replicatedPlc.SetLabels(map[string]string{common.RootPolicyLabel: "tampered"})
+
+		return nil
+	}}
+}
+
+// writeHookScript writes an executable shell script for the exec based mutation hook
+func writeHookScript(t *testing.T, script string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "hook.sh")
+
+	// #nosec G306 -- the script must be executable
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script+"\n"), 0o700); err != nil {
+		t.Fatalf("failed to write the hook script: %v", err)
+	}
+
+	return path
+}
+
+func TestApplyMutationHooksOrder(t *testing.T) {
+	root := newTestPolicy("default")
+	replicated := newTestReplicatedPolicy(root, "cluster1", "")
+
+	hooks := []MutationHook{appendingHook("a"), appendingHook("b"), appendingHook("c")}
+
+	err := applyMutationHooks(context.TODO(), hooks, replicated, appsv1.PlacementDecision{}, root)
+	if err != nil {
+		t.Fatalf("applyMutationHooks returned an error: %v", err)
+	}
+
+	if order := replicated.GetAnnotations()["order"]; order != "abc" {
+		t.Fatalf("expected the hooks to run in order, got %s", order)
+	}
+
+	expected := newTestReplicatedPolicy(root, "cluster1", "").GetLabels()
+	if !reflect.DeepEqual(replicated.GetLabels(), expected) {
+		t.Fatalf("expected the tracking labels %v to be restored, got %v", expected, replicated.GetLabels())
+	}
+}
+
+func TestApplyMutationHooksError(t *testing.T) {
+	root := newTestPolicy("default")
+	replicated := newTestReplicatedPolicy(root, "cluster1", "")
+	hookErr := errors.New("no change record")
+
+	hooks := []MutationHook{
+		appendingHook("a"),
+		&testMutationHook{name: "failing", mutate: func(*policiesv1.Policy) error { return hookErr }},
+		appendingHook("c"),
+	}
+
+	err := applyMutationHooks(context.TODO(), hooks, replicated, appsv1.PlacementDecision{}, root)
+	if !errors.Is(err, hookErr) || !strings.Contains(err.Error(), "failing") {
+		t.Fatalf("expected the error of the failing hook, got %v", err)
+	}
+
+	if order := replicated.GetAnnotations()["order"]; order != "a" {
+		t.Fatalf("expected the hooks after the failing one to not run, got %s", order)
+	}
+}
+
+func TestExecMutationHook(t *testing.T) {
+	root := newTestPolicy("default")
+	decision := appsv1.PlacementDecision{ClusterName: "cluster1", ClusterNamespace: "cluster1"}
+
+	tests := map[string]struct {
+		script string
+		err    string
+		// mutate applies the expected mutation to the replicated policy
+		mutate func(replicatedPlc *policiesv1.Policy)
+	}{
+		"mutated": {
+			script: `cat > /dev/null; echo '{"metadata": {"annotations": {"change-record": "CHG0001"}}}'`,
+			mutate: func(replicatedPlc *policiesv1.Policy) {
+				replicatedPlc.SetAnnotations(map[string]string{"change-record": "CHG0001"})
+			},
+		},
+		"mutated spec": {
+			script: `cat > /dev/null; echo '{"spec": {"remediationAction": "Enforce"}}'`,
+			mutate: func(replicatedPlc *policiesv1.Policy) {
+				replicatedPlc.Spec.RemediationAction = policiesv1.Enforce
+			},
+		},
+		"non-zero exit": {
+			script: `cat > /dev/null; echo "no change record" >&2; exit 3`,
+			err:    "exit status 3: no change record",
+		},
+		"timeout": {
+			script: "exec sleep 10",
+			err:    "signal: killed",
+		},
+		"invalid output": {
+			script: "cat > /dev/null; echo invalid",
+			err:    "failed to parse the mutated policy",
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+
+		t.Run(name, func(t *testing.T) {
+			hook := &execMutationHook{command: writeHookScript(t, test.script), timeout: 200 * time.Millisecond}
+			replicated := newTestReplicatedPolicy(root, "cluster1", "")
+
+			start := time.Now()
+			err := hook.Mutate(context.TODO(), replicated, decision, root)
+
+			if time.Since(start) > 5*time.Second {
+				t.Fatalf("expected the hook to be killed after its timeout, it took %v", time.Since(start))
+			}
+
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("expected the error %q, got %v", test.err, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("the hook returned an error: %v", err)
+			}
+
+			// The fields missing from the output of the hook are kept
+			expected := newTestReplicatedPolicy(root, "cluster1", "")
+			test.mutate(expected)
+
+			if !reflect.DeepEqual(replicated, expected) {
+				t.Fatalf("expected the mutated policy %v, got %v", expected, replicated)
+			}
+		})
+	}
+}
+
+func TestExecMutationHookCanceled(t *testing.T) {
+	root := newTestPolicy("default")
+	hook := &execMutationHook{command: writeHookScript(t, "exec sleep 10"), timeout: time.Minute}
+
+	// The hook is killed when the reconcile is canceled, even before its own timeout
+	ctx, cancel := context.WithTimeout(context.TODO(), 200*time.Millisecond)
+	defer cancel()
+
+	err := hook.Mutate(ctx, newTestReplicatedPolicy(root, "cluster1", ""), appsv1.PlacementDecision{}, root)
+	if err == nil || !strings.Contains(err.Error(), "signal: killed") {
+		t.Fatalf("expected the hook to be killed, got %v", err)
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"fmt"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+)
+
+// nameCollisionError is returned when the replicated policy of the root policy would overwrite a
+// policy in the cluster namespace with the same name that belongs to another root policy, or to
+// no root policy at all. It is not retried since only deleting the other policy resolves it.
+type nameCollisionError struct {
+	namespace string
+	name      string
+	// owner is the root policy the existing policy belongs to in the format of <namespace>.<name>.
+	// It is empty when the existing policy is not a replicated policy.
+	owner string
+}
+
+func (e *nameCollisionError) Error() string {
+	if e.owner == "" {
+		return fmt.Sprintf("the policy %s/%s already exists and is not a replicated policy", e.namespace, e.name)
+	}
+
+	return fmt.Sprintf(
+		"the policy %s/%s already exists and is replicated from the root policy %s", e.namespace, e.name, e.owner,
+	)
+}
+
+// nameCollision returns the error of the name collision when the existing policy in the cluster
+// namespace doesn't belong to the root policy. The replicated policy names are in the format of
+// <root namespace>.<root name>, so they are only unique as long as the root policy names are.
+func nameCollision(existingPlc *policiesv1.Policy, instance *policiesv1.Policy) *nameCollisionError {
+	if existingPlc == nil {
+		return nil
+	}
+
+	owner := existingPlc.GetLabels()[common.RootPolicyLabel]
+	if owner == common.FullNameForPolicy(instance) {
+		return nil
+	}
+
+	return &nameCollisionError{namespace: existingPlc.GetNamespace(), name: existingPlc.GetName(), owner: owner}
+}
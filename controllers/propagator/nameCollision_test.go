@@ -0,0 +1,94 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+)
+
+func TestHandleRootPolicyNameCollision(t *testing.T) {
+	root := newTestPolicy("default")
+	plr := newTestPlacementRule("plr", "cluster1")
+	pb := newTestPlacementBinding("pb", "plr", policiesv1.Subject{
+		APIGroup: policiesv1.SchemeGroupVersion.Group, Kind: policiesv1.Kind, Name: "policy",
+	})
+
+	// The policy in the cluster namespace has the name of the replicated policy but belongs to
+	// another root policy
+	other := newTestPolicy("other")
+	other.SetName("policies.policy")
+	other.SetNamespace("cluster1")
+	other.SetLabels(map[string]string{common.RootPolicyLabel: "policies.other"})
+
+	r := newTestReconciler(t, &stubResolver{}, root, plr, pb, other)
+
+	err := r.handleRootPolicy(context.TODO(), root, nil)
+	if err != nil {
+		t.Fatalf("handleRootPolicy returned an error: %v", err)
+	}
+
+	existing := &policiesv1.Policy{}
+
+	err = r.Get(context.TODO(), types.NamespacedName{Namespace: "cluster1", Name: "policies.policy"}, existing)
+	if err != nil {
+		t.Fatalf("failed to get the other policy: %v", err)
+	}
+
+	if !reflect.DeepEqual(existing.Spec, other.Spec) || existing.GetLabels()[common.RootPolicyLabel] != "policies.other" {
+		t.Fatal("expected the policy of the other root policy to be left as is")
+	}
+
+	if len(root.Status.Status) != 1 || root.Status.Status[0].Reason != reasonNameCollision ||
+		root.Status.Status[0].ComplianceState != policiesv1.NonCompliant {
+		t.Fatalf("expected the cluster to be NonCompliant with the NameCollision reason, got %+v", root.Status.Status)
+	}
+
+	if !strings.Contains(root.Status.Status[0].Message, "replicated from the root policy policies.other") {
+		t.Fatalf("expected the message to name the other root policy, got %q", root.Status.Status[0].Message)
+	}
+
+	collided := false
+
+	for _, event := range events(r) {
+		if strings.HasPrefix(event, "Warning PolicyPropagation Policy policies/policy was not propagated to cluster "+
+			"cluster1/cluster1 since the policy cluster1/policies.policy already exists") {
+			collided = true
+		}
+	}
+
+	if !collided {
+		t.Fatal("expected a Warning event for the name collision")
+	}
+}
+
+func TestCleanUpOrphanedNameCollision(t *testing.T) {
+	root := newTestPolicy("default")
+	root.Status.Status = []*policiesv1.CompliancePerClusterStatus{{
+		ClusterName: "cluster1", ClusterNamespace: "cluster1", ComplianceState: policiesv1.NonCompliant,
+		Reason: reasonNameCollision,
+	}}
+
+	other := newTestPolicy("other")
+	other.SetName("policies.policy")
+	other.SetNamespace("cluster1")
+
+	r := newTestReconciler(t, &stubResolver{}, root, other)
+
+	err := r.cleanUpOrphanedRplPolicies(context.TODO(), root, map[string]bool{})
+	if err != nil {
+		t.Fatalf("cleanUpOrphanedRplPolicies returned an error: %v", err)
+	}
+
+	err = r.Get(context.TODO(), types.NamespacedName{Namespace: "cluster1", Name: "policies.policy"}, other)
+	if err != nil {
+		t.Fatalf("expected the policy the replicated policy collided with to not be deleted: %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"path"
+	"strings"
+)
+
+// A comma separated list of namespaces that replicated policies must never be created in. Each
+// entry may be a glob pattern such as kube-* or openshift-*.
+const namespaceDenylistEnvName = "CONTROLLER_CONFIG_NAMESPACE_DENYLIST"
+
+// parseNamespaceDenylist returns the valid patterns in the comma separated list. Invalid patterns
+// are logged and ignored.
+func parseNamespaceDenylist(value string) []string {
+	patterns := []string{}
+
+	for _, pattern := range strings.Split(value, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+
+		if _, err := path.Match(pattern, ""); err != nil {
+			log.Info(
+				"Ignoring the invalid namespace denylist pattern...", "Pattern", pattern, "Error", err.Error(),
+			)
+
+			continue
+		}
+
+		patterns = append(patterns, pattern)
+	}
+
+	return patterns
+}
+
+// namespaceDenied returns whether the namespace matches a pattern in the namespace denylist
+func (r *PolicyReconciler) namespaceDenied(namespace string) bool {
+	for _, pattern := range r.namespaceDenylist {
+		// The patterns were validated when parsed, so an error can't be returned
+		if matched, _ := path.Match(pattern, namespace); matched {
+			return true
+		}
+	}
+
+	return false
+}
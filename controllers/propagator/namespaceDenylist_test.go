@@ -0,0 +1,84 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+func TestParseNamespaceDenylist(t *testing.T) {
+	patterns := parseNamespaceDenylist(" kube-*, openshift-* ,,[invalid,default")
+	expected := []string{"kube-*", "openshift-*", "default"}
+
+	if !reflect.DeepEqual(patterns, expected) {
+		t.Fatalf("expected the patterns %v, got %v", expected, patterns)
+	}
+}
+
+func TestNamespaceDenylistReplication(t *testing.T) {
+	root := newTestPolicy("default")
+	plr := &appsv1.PlacementRule{
+		ObjectMeta: metav1.ObjectMeta{Name: "plr", Namespace: "policies"},
+		Status: appsv1.PlacementRuleStatus{
+			Decisions: []appsv1.PlacementDecision{
+				{ClusterName: "cluster1", ClusterNamespace: "cluster1"},
+				{ClusterName: "system", ClusterNamespace: "kube-system"},
+			},
+		},
+	}
+	pb := &policiesv1.PlacementBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "pb", Namespace: "policies"},
+		PlacementRef: policiesv1.Subject{
+			APIGroup: appsv1.SchemeGroupVersion.Group, Kind: "PlacementRule", Name: "plr",
+		},
+		Subjects: []policiesv1.Subject{
+			{APIGroup: policiesv1.SchemeGroupVersion.Group, Kind: policiesv1.Kind, Name: "policy"},
+		},
+	}
+
+	r := newTestReconciler(t, &stubResolver{}, root, plr, pb)
+	r.namespaceDenylist = []string{"kube-*"}
+
+	err := r.handleRootPolicy(context.TODO(), root, nil)
+	if err != nil {
+		t.Fatalf("handleRootPolicy returned an error: %v", err)
+	}
+
+	err = r.Get(context.TODO(), types.NamespacedName{Namespace: "cluster1", Name: "policies.policy"}, &policiesv1.Policy{})
+	if err != nil {
+		t.Fatalf("failed to get the replicated policy in the allowed namespace: %v", err)
+	}
+
+	err = r.Get(context.TODO(), types.NamespacedName{Namespace: "kube-system", Name: "policies.policy"}, &policiesv1.Policy{})
+	if !k8serrors.IsNotFound(err) {
+		t.Fatalf("expected no replicated policy in the denied namespace, got the error: %v", err)
+	}
+
+	updatedRoot := &policiesv1.Policy{}
+
+	err = r.Get(context.TODO(), types.NamespacedName{Namespace: "policies", Name: "policy"}, updatedRoot)
+	if err != nil {
+		t.Fatalf("failed to get the root policy: %v", err)
+	}
+
+	for _, cpcs := range updatedRoot.Status.Status {
+		if cpcs.ClusterNamespace == "kube-system" {
+			if cpcs.ComplianceState != policiesv1.NonCompliant || cpcs.Message == "" {
+				t.Fatalf("expected the denied cluster to be NonCompliant with a message, got %+v", cpcs)
+			}
+
+			return
+		}
+	}
+
+	t.Fatalf("expected the denied cluster in the root policy status, got %v", updatedRoot.Status.Status)
+}
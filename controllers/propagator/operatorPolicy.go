@@ -0,0 +1,167 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+)
+
+// The string fields of the subscription of an OperatorPolicy
+var operatorSubscriptionFields = []string{
+	"name", "namespace", "channel", "source", "sourceNamespace", "startingCSV",
+}
+
+// The install plan approvals of the subscription of an OperatorPolicy
+var operatorInstallPlanApprovals = []string{"Automatic", "Manual"}
+
+// installedCSVRegexp finds the ClusterServiceVersion in the compliance messages of the
+// OperatorPolicies, such as "ClusterServiceVersion (quay-operator.v3.8.1) - install strategy
+// completed with no errors"
+var installedCSVRegexp = regexp.MustCompile(`ClusterServiceVersion \(([^)\s]+)\)`)
+
+// isOperatorPolicy returns whether the policy template is an OperatorPolicy of the policy
+// framework, which may have hub templates like the ConfigurationPolicies
+func isOperatorPolicy(policyT *policiesv1.PolicyTemplate) bool {
+	object := &policyTemplateObject{}
+	if err := json.Unmarshal(policyT.ObjectDefinition.Raw, object); err != nil {
+		return false
+	}
+
+	return object.Kind == "OperatorPolicy" && strings.HasPrefix(object.APIVersion, common.APIGroup+"/")
+}
+
+// validateOperatorPolicies returns an error if the subscription of an OperatorPolicy in the policy
+// templates of the root policy is invalid, since the OperatorPolicy would then fail on every
+// managed cluster. The values with hub templates aren't validated until they are resolved.
+func validateOperatorPolicies(instance *policiesv1.Policy, startDelim string) error {
+	for i, policyT := range instance.Spec.PolicyTemplates {
+		if policyT == nil || !isOperatorPolicy(policyT) {
+			continue
+		}
+
+		object := &policyTemplateObject{}
+		if err := json.Unmarshal(policyT.ObjectDefinition.Raw, object); err != nil {
+			return err
+		}
+
+		if err := validateOperatorSubscription(object.Spec, startDelim); err != nil {
+			return fmt.Errorf("policy template %d: the OperatorPolicy %s is invalid: %w", i, object.Metadata.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// validateOperatorSubscription returns an error if the subscription of the OperatorPolicy has no
+// package name, or has fields of the wrong type or an unknown install plan approval
+func validateOperatorSubscription(spec map[string]interface{}, startDelim string) error {
+	subscription, found, err := unstructured.NestedFieldNoCopy(spec, "subscription")
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		return fmt.Errorf("spec.subscription must be set")
+	}
+
+	subscriptionMap, ok := subscription.(map[string]interface{})
+	if !ok {
+		// The whole subscription may be resolved from a hub template
+		if value, ok := subscription.(string); ok && strings.Contains(value, startDelim) {
+			return nil
+		}
+
+		return fmt.Errorf("spec.subscription must be an object")
+	}
+
+	for _, field := range operatorSubscriptionFields {
+		if value, ok := subscriptionMap[field]; ok && value != nil {
+			if _, ok := value.(string); !ok {
+				return fmt.Errorf("spec.subscription.%s must be a string", field)
+			}
+		}
+	}
+
+	if name, _ := subscriptionMap["name"].(string); name == "" {
+		return fmt.Errorf("spec.subscription.name must be set")
+	}
+
+	approval, _ := subscriptionMap["installPlanApproval"].(string)
+	if approval == "" || strings.Contains(approval, startDelim) {
+		return nil
+	}
+
+	for _, validApproval := range operatorInstallPlanApprovals {
+		if approval == validApproval {
+			return nil
+		}
+	}
+
+	return fmt.Errorf(
+		"spec.subscription.installPlanApproval must be one of %s, got %q",
+		strings.Join(operatorInstallPlanApprovals, ", "), approval,
+	)
+}
+
+// setOperatorPolicyStatus sets the ClusterServiceVersions installed by the OperatorPolicy templates
+// of the replicated policies in the per-cluster status, from the latest compliance message of the
+// OperatorPolicies
+func setOperatorPolicyStatus(status []*policiesv1.CompliancePerClusterStatus, replicatedPlcs []policiesv1.Policy) {
+	operatorPolicies := map[string][]*policiesv1.OperatorPolicyStatus{}
+
+	for _, rPlc := range replicatedPlcs {
+		names := map[string]bool{}
+
+		for _, policyT := range rPlc.Spec.PolicyTemplates {
+			if policyT == nil || !isOperatorPolicy(policyT) {
+				continue
+			}
+
+			object := &policyTemplateObject{}
+			if err := json.Unmarshal(policyT.ObjectDefinition.Raw, object); err == nil {
+				names[object.Metadata.Name] = true
+			}
+		}
+
+		if len(names) == 0 {
+			continue
+		}
+
+		key := rPlc.GetLabels()[common.ClusterNamespaceLabel] + "/" + rPlc.GetLabels()[common.ClusterNameLabel]
+
+		for _, details := range rPlc.Status.Details {
+			if details == nil || !names[details.TemplateMeta.GetName()] {
+				continue
+			}
+
+			opStatus := &policiesv1.OperatorPolicyStatus{Name: details.TemplateMeta.GetName()}
+
+			// The latest compliance event is first in the history
+			if len(details.History) != 0 {
+				if match := installedCSVRegexp.FindStringSubmatch(details.History[0].Message); match != nil {
+					opStatus.InstalledCSV = match[1]
+				}
+			}
+
+			operatorPolicies[key] = append(operatorPolicies[key], opStatus)
+		}
+	}
+
+	for _, cpcs := range status {
+		if cpcs.Reason != "" && cpcs.Reason != reasonPendingUpdate {
+			cpcs.OperatorPolicies = nil
+
+			continue
+		}
+
+		cpcs.OperatorPolicies = operatorPolicies[cpcs.ClusterNamespace+"/"+cpcs.ClusterName]
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	policyv1beta1 "github.com/open-cluster-management/governance-policy-propagator/api/v1beta1"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+const operatorPolicy = `{"apiVersion":"policy.open-cluster-management.io/v1beta1","kind":"OperatorPolicy",` +
+	`"metadata":{"name":"install-quay"},"spec":{"remediationAction":"enforce","complianceType":"musthave",` +
+	`"subscription":{"name":"quay-operator","namespace":"quay","channel":"stable-3.8",` +
+	`"source":"redhat-operators","sourceNamespace":"openshift-marketplace","installPlanApproval":"Automatic"}}}`
+
+func TestValidateOperatorPolicies(t *testing.T) {
+	tests := []struct {
+		name             string
+		objectDefinition string
+		expectedErr      string
+	}{
+		{name: "valid", objectDefinition: operatorPolicy},
+		{name: "not an OperatorPolicy", objectDefinition: configPolicy},
+		{
+			name: "Gatekeeper kind",
+			objectDefinition: strings.Replace(
+				operatorPolicy, "policy.open-cluster-management.io/v1beta1", "constraints.gatekeeper.sh/v1beta1", 1,
+			),
+		},
+		{
+			name:             "without subscription",
+			objectDefinition: strings.Replace(operatorPolicy, `"subscription":`, `"other":`, 1),
+			expectedErr:      "spec.subscription must be set",
+		},
+		{
+			name:             "without name",
+			objectDefinition: strings.Replace(operatorPolicy, `"name":"quay-operator",`, "", 1),
+			expectedErr:      "spec.subscription.name must be set",
+		},
+		{
+			name:             "channel type",
+			objectDefinition: strings.Replace(operatorPolicy, `"stable-3.8"`, `3.8`, 1),
+			expectedErr:      "spec.subscription.channel must be a string",
+		},
+		{
+			name:             "install plan approval",
+			objectDefinition: strings.Replace(operatorPolicy, `"Automatic"`, `"Always"`, 1),
+			expectedErr:      `spec.subscription.installPlanApproval must be one of Automatic, Manual, got "Always"`,
+		},
+		{
+			name:             "install plan approval with a hub template",
+			objectDefinition: strings.Replace(operatorPolicy, `"Automatic"`, `"{{hub .ManagedClusterName hub}}"`, 1),
+		},
+		{
+			name: "subscription with a hub template",
+			objectDefinition: `{"apiVersion":"policy.open-cluster-management.io/v1beta1","kind":"OperatorPolicy",` +
+				`"metadata":{"name":"op"},"spec":{"subscription":"{{hub fromConfigMap \"\" \"subs\" \"quay\" hub}}"}}`,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			err := validateOperatorPolicies(newGatekeeperPolicy(test.objectDefinition), "{{hub")
+
+			if test.expectedErr == "" {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), test.expectedErr) {
+				t.Fatalf("expected the error %q, got %v", test.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestProcessTemplatesOperatorPolicy(t *testing.T) {
+	decision := appsv1.PlacementDecision{ClusterName: "cluster1", ClusterNamespace: "cluster1"}
+	resolved := strings.Replace(operatorPolicy, `"stable-3.8"`, `"stable-3.9"`, 1)
+
+	root := newGatekeeperPolicy(strings.Replace(operatorPolicy, `"stable-3.8"`, `"{{hub .ManagedClusterName hub}}"`, 1))
+	r := newTestReconciler(t, &stubResolver{result: []byte(resolved)}, root)
+
+	replicated := root.DeepCopy()
+
+	err := r.processTemplates(context.TODO(), replicated, decision, root, policyv1beta1.PropagationConfigSpec{})
+	if err != nil {
+		t.Fatalf("expected the hub templates of the OperatorPolicy to be resolved, got %v", err)
+	}
+
+	if actual := string(replicated.Spec.PolicyTemplates[0].ObjectDefinition.Raw); actual != resolved {
+		t.Fatalf("expected the resolved OperatorPolicy, got %s", actual)
+	}
+}
+
+func TestSetOperatorPolicyStatus(t *testing.T) {
+	root := newTestPolicy("default")
+	root.Spec.PolicyTemplates = append(root.Spec.PolicyTemplates, &policiesv1.PolicyTemplate{
+		ObjectDefinition: runtime.RawExtension{Raw: []byte(operatorPolicy)},
+	})
+
+	replicated1 := newTestReplicatedPolicy(root, "cluster1", policiesv1.Compliant)
+	replicated1.Status.Details = []*policiesv1.DetailsPerTemplate{
+		{TemplateMeta: metav1.ObjectMeta{Name: "case"}, ComplianceState: policiesv1.Compliant},
+		{
+			TemplateMeta:    metav1.ObjectMeta{Name: "install-quay"},
+			ComplianceState: policiesv1.Compliant,
+			History: []policiesv1.ComplianceHistory{
+				{Message: "Compliant; the ClusterServiceVersion (quay-operator.v3.8.1) - install strategy completed " +
+					"with no errors"},
+				{Message: "NonCompliant; the ClusterServiceVersion (quay-operator.v3.8.0) - install strategy failed"},
+			},
+		},
+	}
+
+	replicated2 := newTestReplicatedPolicy(root, "cluster2", policiesv1.NonCompliant)
+	replicated2.Status.Details = []*policiesv1.DetailsPerTemplate{
+		{
+			TemplateMeta:    metav1.ObjectMeta{Name: "install-quay"},
+			ComplianceState: policiesv1.NonCompliant,
+			History:         []policiesv1.ComplianceHistory{{Message: "NonCompliant; the Subscription is missing"}},
+		},
+	}
+
+	status := []*policiesv1.CompliancePerClusterStatus{
+		{ClusterName: "cluster1", ClusterNamespace: "cluster1"},
+		{ClusterName: "cluster2", ClusterNamespace: "cluster2"},
+		{ClusterName: "cluster3", ClusterNamespace: "cluster3", Reason: reasonReplicationFailed},
+	}
+
+	setOperatorPolicyStatus(status, []policiesv1.Policy{*replicated1, *replicated2})
+
+	expected := []*policiesv1.OperatorPolicyStatus{{Name: "install-quay", InstalledCSV: "quay-operator.v3.8.1"}}
+	if !reflect.DeepEqual(status[0].OperatorPolicies, expected) {
+		t.Fatalf("expected the installed CSV of cluster1 to be %v, got %v", expected, status[0].OperatorPolicies)
+	}
+
+	expected = []*policiesv1.OperatorPolicyStatus{{Name: "install-quay"}}
+	if !reflect.DeepEqual(status[1].OperatorPolicies, expected) {
+		t.Fatalf("expected no installed CSV on cluster2, got %v", status[1].OperatorPolicies)
+	}
+
+	if status[2].OperatorPolicies != nil {
+		t.Fatalf("expected no OperatorPolicies for the failed replication, got %v", status[2].OperatorPolicies)
+	}
+}
@@ -0,0 +1,493 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/transport"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	templates "github.com/open-cluster-management/go-template-utils/pkg/templates"
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+)
+
+const attemptsDefault = 3
+const attemptsEnvName = "CONTROLLER_CONFIG_RETRY_ATTEMPTS"
+
+// The configuration in minutes to requeue after if something failed after several
+// retries.
+const requeueErrorDelayEnvName = "CONTROLLER_CONFIG_REQUEUE_ERROR_DELAY"
+const requeueErrorDelayDefault = 5
+
+// The configuration in seconds that a root policy reconcile may take before it is canceled.
+const reconcileTimeoutEnvName = "CONTROLLER_CONFIG_RECONCILE_TIMEOUT"
+const reconcileTimeoutDefault = 300
+
+// The configuration of the number of root policies reconciled at the same time. The
+// --max-concurrent-reconciles flag takes precedence.
+const maxConcurrentReconcilesEnvName = "CONTROLLER_CONFIG_MAX_CONCURRENT_RECONCILES"
+const maxConcurrentReconcilesDefault = 1
+
+const retryDelayDefault = 2 * time.Second
+
+// TemplateResolver resolves the hub templates in the raw JSON of a policy template
+type TemplateResolver interface {
+	ResolveTemplate(tmplJSON []byte, context interface{}) ([]byte, error)
+}
+
+// TemplateResolverOptions configure the resolver of the hub templates of a root policy
+type TemplateResolverOptions struct {
+	// LookupNamespace is the namespace the lookups are restricted to
+	LookupNamespace string
+	// AdditionalLookupNamespaces are the patterns of the other namespaces the lookups are allowed in.
+	// The lookups must then set the namespace, and can't look up cluster scoped objects.
+	AdditionalLookupNamespaces []string
+	// DisabledFunctions are the template functions that fail to resolve
+	DisabledFunctions []string
+	// Impersonate is the user the lookups are made as. When it is empty, the lookups are made with
+	// the permissions of the propagator.
+	Impersonate string
+}
+
+// PolicyReconcilerOptions are the dependencies and configuration of a PolicyReconciler. Unset
+// options are replaced with their defaults by NewPolicyReconciler.
+type PolicyReconcilerOptions struct {
+	// KubeConfig and KubeClient are used by the default template resolver for its lookups
+	KubeConfig *rest.Config
+	KubeClient *kubernetes.Interface
+	// TemplateConfig configures the hub templates. The default uses the {{hub and hub}}
+	// delimiters and disables the fromSecret function. The fromSecret function is enabled with the
+	// EncryptedHubTemplates feature, which encrypts the values it resolves with the key of the
+	// cluster namespace.
+	TemplateConfig *templates.Config
+	// Clock is used to time the reconciliation of the root policies
+	Clock clock.Clock
+	// Recorder records the propagation events on the root policies
+	Recorder record.EventRecorder
+	// RetryAttempts is the number of attempts of each API request before giving up
+	RetryAttempts int
+	// RetryDelay is the initial delay between attempts of an API request
+	RetryDelay time.Duration
+	// RequeueErrorDelay is how long to wait before reconciling a root policy again after giving up
+	RequeueErrorDelay time.Duration
+	// RequeueErrorMaxDelay is the maximum delay the RequeueErrorDelay doubles up to when a root
+	// policy keeps failing. When it is not greater than the RequeueErrorDelay, the delay is fixed.
+	RequeueErrorMaxDelay time.Duration
+	// ReconcileTimeout is how long a root policy reconcile may take before it is canceled
+	ReconcileTimeout time.Duration
+	// AdmissionHookURL is the URL of the admission hook. When unset, no admission hook is called.
+	AdmissionHookURL string
+	// AdmissionHookTimeout is how long to wait for a response from the admission hook
+	AdmissionHookTimeout time.Duration
+	// MutationHooks mutate the replicated policies, in order, after their hub templates are resolved.
+	// The options from the environment have the exec based hooks of the
+	// CONTROLLER_CONFIG_MUTATION_HOOK_COMMANDS environment variable.
+	MutationHooks []MutationHook
+	// NamespaceDenylist are the glob patterns of the namespaces that replicated policies must never
+	// be created in, regardless of the placement decisions
+	NamespaceDenylist []string
+	// CircuitBreakerThreshold is the number of consecutive failed replications to a cluster
+	// namespace before the replications to it are paused
+	CircuitBreakerThreshold int
+	// CircuitBreakerCooldown is how long the replications to a failing cluster namespace are first
+	// paused. It doubles every time the replications fail again.
+	CircuitBreakerCooldown time.Duration
+	// ClusterNamespaceEvents also records the creation, update, and deletion of the replicated
+	// policies as events in their cluster namespaces
+	ClusterNamespaceEvents bool
+	// DriftDetectionInterval is how often the replicated policies modified in the cluster
+	// namespaces are restored. When unset, the drift is only corrected when the root policy is
+	// reconciled.
+	DriftDetectionInterval time.Duration
+	// EventAggregationInterval is how often the per-cluster PolicyPropagation events of the root
+	// policies are recorded as one event for every root policy. When unset, an event is recorded for
+	// every cluster.
+	EventAggregationInterval time.Duration
+	// OrphanGCInterval is how often the replicated policies whose root policy or placement decision
+	// no longer exists are deleted. When unset, they are only deleted when the propagator starts.
+	OrphanGCInterval time.Duration
+	// MaxConcurrentReconciles is the number of root policies reconciled at the same time
+	MaxConcurrentReconciles int
+	// DecisionConcurrency is the maximum number of replicated policies of a root policy that are
+	// created or updated at the same time
+	DecisionConcurrency int
+	// DisabledTemplateFunctions replaces the functions disabled by the TemplateConfig when set
+	DisabledTemplateFunctions []string
+	// TemplateFunctionsConfigMap is the ConfigMap restricting the hub template functions to the root
+	// policies of some namespaces. It is reloaded when it changes. When the name is unset, the
+	// functions disabled by the TemplateConfig apply to all the root policies.
+	TemplateFunctionsConfigMap types.NamespacedName
+	// TemplateImpersonation makes the lookups of the hub templates impersonate the
+	// templateServiceAccount of the PropagationConfig of the namespace, or the author of the root
+	// policy recorded in its template-user annotation, or else the default ServiceAccount of the
+	// namespace. Only the user name is impersonated, without the groups of the user.
+	TemplateImpersonation bool
+	// TrustTemplateUserAnnotation is whether the template-user annotation of the root policies is
+	// set by the mutating webhook. The annotation is ignored otherwise, since the authors could set
+	// it to any user. It is also ignored while the webhook isn't configured with the Fail failure
+	// policy for all the policies.
+	TrustTemplateUserAnnotation bool
+	// NewTemplateResolver returns the resolver for the hub templates of the root policies
+	NewTemplateResolver func(opts TemplateResolverOptions) (TemplateResolver, error)
+	// ComplianceDB returns the compliance events database IDs set on the replicated policies and
+	// their policy templates. When unset, the IDs are not set.
+	ComplianceDB ComplianceDBIDs
+	// DetailedRootStatus sets the compliance of the policy templates of the replicated policies in
+	// the per-cluster status of the root policies
+	DetailedRootStatus bool
+	// Shard is the partition of the root policies reconciled by the replica. Its count and index are
+	// ignored unless the Sharding feature is enabled.
+	Shard Shard
+	// LatencySamplePercent is the percentage of the clusters whose propagation latency is measured.
+	// The latency isn't measured when it is 0.
+	LatencySamplePercent int
+	// TemplateEligibleKinds are the kinds of the policy templates that may have hub templates, in
+	// addition to the Gatekeeper objects. The default is DefaultTemplateEligibleKinds.
+	TemplateEligibleKinds []string
+	// CopyLabels are the glob patterns of the keys of the labels and annotations of the root policies
+	// copied to their replicated policies, in addition to the ones of their copy-labels annotation.
+	// When it is nil and a root policy has no copy-labels annotation, all of them are copied. The
+	// keys of the policy framework are always copied.
+	CopyLabels []string
+	// PlacementAvailability records which of the placement APIs are installed on the hub, so that
+	// the ones that aren't are only watched once they are installed. When unset, all of them must
+	// be installed.
+	PlacementAvailability *common.PlacementAvailability
+}
+
+// PolicyReconcilerOptionsFromEnv returns the options configured through the CONTROLLER_CONFIG_*
+// environment variables
+func PolicyReconcilerOptionsFromEnv(kubeConfig *rest.Config, kubeClient *kubernetes.Interface) PolicyReconcilerOptions {
+	return PolicyReconcilerOptions{
+		KubeConfig:    kubeConfig,
+		KubeClient:    kubeClient,
+		RetryAttempts: getEnvVarPosInt(attemptsEnvName, attemptsDefault),
+		RequeueErrorDelay: time.Duration(
+			getEnvVarPosInt(requeueErrorDelayEnvName, requeueErrorDelayDefault),
+		) * time.Minute,
+		RequeueErrorMaxDelay: time.Duration(
+			getEnvVarPosInt(requeueErrorMaxDelayEnvName, requeueErrorMaxDelayDefault),
+		) * time.Minute,
+		ReconcileTimeout: time.Duration(
+			getEnvVarPosInt(reconcileTimeoutEnvName, reconcileTimeoutDefault),
+		) * time.Second,
+		AdmissionHookURL: os.Getenv(admissionHookURLEnvName),
+		AdmissionHookTimeout: time.Duration(
+			getEnvVarPosInt(admissionHookTimeoutEnvName, admissionHookTimeoutDefault),
+		) * time.Second,
+		MutationHooks:     newExecMutationHooks(os.Getenv(mutationHookCommandsEnvName)),
+		NamespaceDenylist: parseNamespaceDenylist(os.Getenv(namespaceDenylistEnvName)),
+		CircuitBreakerThreshold: getEnvVarPosInt(
+			circuitBreakerThresholdEnvName, circuitBreakerThresholdDefault,
+		),
+		CircuitBreakerCooldown: time.Duration(
+			getEnvVarPosInt(circuitBreakerCooldownEnvName, circuitBreakerCooldownDefault),
+		) * time.Second,
+		ClusterNamespaceEvents: getEnvVarBool(clusterNamespaceEventsEnvName, false),
+		DriftDetectionInterval: time.Duration(
+			getEnvVarNonNegInt(driftDetectionIntervalEnvName, driftDetectionIntervalDefault),
+		) * time.Second,
+		OrphanGCInterval: time.Duration(
+			getEnvVarNonNegInt(orphanGCIntervalEnvName, orphanGCIntervalDefault),
+		) * time.Second,
+		EventAggregationInterval: time.Duration(
+			getEnvVarNonNegInt(eventAggregationIntervalEnvName, eventAggregationIntervalDefault),
+		) * time.Second,
+		MaxConcurrentReconciles: getEnvVarPosInt(
+			maxConcurrentReconcilesEnvName, maxConcurrentReconcilesDefault,
+		),
+		DecisionConcurrency:   getEnvVarPosInt(decisionConcurrencyEnvName, decisionConcurrencyDefault),
+		TemplateImpersonation: getEnvVarBool(templateImpersonationEnvName, false),
+		LatencySamplePercent:  getEnvVarNonNegInt(latencySamplePercentEnvName, latencySamplePercentDefault),
+	}
+}
+
+func getEnvVarPosInt(name string, defaultValue int) int {
+	var envValue = os.Getenv(name)
+	if envValue == "" {
+		return defaultValue
+	}
+
+	envInt, err := strconv.Atoi(envValue)
+	if err == nil && envInt > 0 {
+		return envInt
+	}
+
+	log.Info(
+		fmt.Sprintf(
+			"The %s environment variable is invalid. Using default.", name,
+		),
+	)
+	return defaultValue
+}
+
+func getEnvVarNonNegInt(name string, defaultValue int) int {
+	envValue := os.Getenv(name)
+	if envValue == "" {
+		return defaultValue
+	}
+
+	envInt, err := strconv.Atoi(envValue)
+	if err == nil && envInt >= 0 {
+		return envInt
+	}
+
+	log.Info(
+		fmt.Sprintf(
+			"The %s environment variable is invalid. Using default.", name,
+		),
+	)
+
+	return defaultValue
+}
+
+func getEnvVarBool(name string, defaultValue bool) bool {
+	envValue := os.Getenv(name)
+	if envValue == "" {
+		return defaultValue
+	}
+
+	envBool, err := strconv.ParseBool(envValue)
+	if err == nil {
+		return envBool
+	}
+
+	log.Info(
+		fmt.Sprintf(
+			"The %s environment variable is invalid. Using default.", name,
+		),
+	)
+
+	return defaultValue
+}
+
+// defaultTemplateConfig returns the hub template configuration used when none is provided
+func defaultTemplateConfig() templates.Config {
+	// Adding four spaces to the indentation makes the usage of `indent N` be from the logical
+	// starting point of the resource object wrapped in the ConfigurationPolicy.
+	return templates.Config{
+		AdditionalIndentation: 8,
+		DisabledFunctions:     []string{"fromSecret"},
+		StartDelim:            "{{hub", StopDelim: "hub}}",
+	}
+}
+
+// NewPolicyReconciler returns a PolicyReconciler with the given options
+func NewPolicyReconciler(c client.Client, scheme *runtime.Scheme, opts PolicyReconcilerOptions) *PolicyReconciler {
+	if opts.TemplateConfig == nil {
+		templateCfg := defaultTemplateConfig()
+		opts.TemplateConfig = &templateCfg
+	}
+
+	if opts.Clock == nil {
+		opts.Clock = clock.RealClock{}
+	}
+
+	// A zero value for the attempts means to retry forever in the retry library
+	if opts.RetryAttempts <= 0 {
+		opts.RetryAttempts = attemptsDefault
+	}
+
+	if opts.RetryDelay <= 0 {
+		opts.RetryDelay = retryDelayDefault
+	}
+
+	if opts.RequeueErrorDelay <= 0 {
+		opts.RequeueErrorDelay = time.Duration(requeueErrorDelayDefault) * time.Minute
+	}
+
+	if opts.ReconcileTimeout <= 0 {
+		opts.ReconcileTimeout = time.Duration(reconcileTimeoutDefault) * time.Second
+	}
+
+	if opts.CircuitBreakerThreshold <= 0 {
+		opts.CircuitBreakerThreshold = circuitBreakerThresholdDefault
+	}
+
+	if opts.CircuitBreakerCooldown <= 0 {
+		opts.CircuitBreakerCooldown = time.Duration(circuitBreakerCooldownDefault) * time.Second
+	}
+
+	if opts.MaxConcurrentReconciles <= 0 {
+		opts.MaxConcurrentReconciles = maxConcurrentReconcilesDefault
+	}
+
+	if opts.DecisionConcurrency <= 0 {
+		opts.DecisionConcurrency = decisionConcurrencyDefault
+	}
+
+	if opts.AdmissionHookTimeout <= 0 {
+		opts.AdmissionHookTimeout = time.Duration(admissionHookTimeoutDefault) * time.Second
+	}
+
+	if opts.DisabledTemplateFunctions != nil {
+		templateCfg := *opts.TemplateConfig
+		templateCfg.DisabledFunctions = opts.DisabledTemplateFunctions
+		opts.TemplateConfig = &templateCfg
+	}
+
+	r := &PolicyReconciler{
+		Client:            c,
+		Scheme:            scheme,
+		Recorder:          opts.Recorder,
+		kubeConfig:        opts.KubeConfig,
+		kubeClient:        opts.KubeClient,
+		templateCfg:       *opts.TemplateConfig,
+		clock:             opts.Clock,
+		retryAttempts:     opts.RetryAttempts,
+		retryDelay:        opts.RetryDelay,
+		reconcileTimeout:  opts.ReconcileTimeout,
+		admissionHook:     newAdmissionHookClient(opts.AdmissionHookURL, opts.AdmissionHookTimeout),
+		mutationHooks:     opts.MutationHooks,
+		namespaceDenylist: opts.NamespaceDenylist,
+		clusterCircuits:   newCircuitBreaker(opts.CircuitBreakerThreshold, opts.CircuitBreakerCooldown),
+	}
+
+	r.clusterNamespaceEvents = opts.ClusterNamespaceEvents
+	r.driftDetectionInterval = opts.DriftDetectionInterval
+	r.orphanGCInterval = opts.OrphanGCInterval
+	r.requeueBackoff = newRequeueBackoff(opts.RequeueErrorDelay, opts.RequeueErrorMaxDelay)
+
+	if opts.EventAggregationInterval > 0 {
+		r.eventAggregator = newEventAggregator(opts.Recorder, opts.EventAggregationInterval)
+	}
+
+	r.propagationState = newPropagationState()
+	r.templateCache = newTemplateCache()
+	r.templateClients = newTemplateClients(opts.KubeConfig, r.lookupClient)
+	r.decisionDiffs = newDecisionDiffs()
+
+	if opts.LatencySamplePercent > 100 {
+		opts.LatencySamplePercent = 100
+	}
+
+	r.propagationLatency = newPropagationLatency(opts.LatencySamplePercent)
+
+	if opts.KubeConfig != nil {
+		metadataClient, err := metadata.NewForConfig(opts.KubeConfig)
+		if err != nil {
+			log.Error(err, "Failed to create the metadata client, the hub template objects will not be watched...")
+		} else {
+			r.templateWatcher = newTemplateWatcher(metadataClient, r.templateCache)
+		}
+	}
+	r.templateFunctions = newTemplateFunctions(r.templateCfg.DisabledFunctions)
+	if opts.TemplateFunctionsConfigMap.Name != "" && opts.KubeClient != nil {
+		r.templateFunctionsWatcher = &templateFunctionsWatcher{
+			kubeClient: *opts.KubeClient,
+			configMap:  opts.TemplateFunctionsConfigMap,
+			functions:  r.templateFunctions,
+			listRoots: func(ctx context.Context) ([]policiesv1.Policy, error) {
+				plcList := &policiesv1.PolicyList{}
+				err := c.List(ctx, plcList)
+
+				return plcList.Items, err
+			},
+			events: make(chan event.GenericEvent),
+		}
+	}
+
+	r.maxConcurrentReconciles = opts.MaxConcurrentReconciles
+	r.priorities = newPriorityQueue(r.maxConcurrentReconciles, r.priorityOf)
+	r.decisionConcurrency = opts.DecisionConcurrency
+
+	r.complianceDB = opts.ComplianceDB
+	r.detailedRootStatus = opts.DetailedRootStatus
+
+	if common.FeatureEnabled(common.Sharding) {
+		r.shard = opts.Shard
+	}
+
+	r.shard.Namespaces = opts.Shard.Namespaces
+
+	if opts.TemplateEligibleKinds == nil {
+		opts.TemplateEligibleKinds = DefaultTemplateEligibleKinds
+	}
+
+	r.templateEligibleKinds = newTemplateEligibleKinds(opts.TemplateEligibleKinds)
+	r.placementAvailability = opts.PlacementAvailability
+	r.placementKinds = newPlacementKindTracker()
+	r.copyLabels = opts.CopyLabels
+
+	r.templateImpersonation = opts.TemplateImpersonation
+	r.trustTemplateUserAnnotation = opts.TrustTemplateUserAnnotation
+
+	r.newTemplateResolver = opts.NewTemplateResolver
+	if r.newTemplateResolver == nil {
+		r.newTemplateResolver = r.defaultTemplateResolver
+	}
+
+	return r
+}
+
+// defaultTemplateResolver returns a resolver using the Kubernetes client and configuration of the
+// reconciler
+func (r *PolicyReconciler) defaultTemplateResolver(opts TemplateResolverOptions) (TemplateResolver, error) {
+	return r.buildTemplateResolver(opts, nil, nil)
+}
+
+// buildTemplateResolver returns a resolver with the template configuration and the options. The
+// optional wrapClient wraps the Kubernetes client used by the lookups, and the optional
+// wrapTransport wraps the transport of the dynamic client used by the lookup function.
+func (r *PolicyReconciler) buildTemplateResolver(
+	opts TemplateResolverOptions,
+	wrapClient func(kubernetes.Interface) kubernetes.Interface,
+	wrapTransport transport.WrapperFunc,
+) (TemplateResolver, error) {
+	cfg := r.templateCfg
+	cfg.LookupNamespace = opts.LookupNamespace
+	cfg.DisabledFunctions = opts.DisabledFunctions
+
+	kubeConfig := r.kubeConfig
+	kubeClient := r.kubeClient
+
+	if opts.Impersonate != "" || len(opts.AdditionalLookupNamespaces) != 0 {
+		var err error
+
+		kubeConfig, kubeClient, err = r.templateClients.get(opts)
+		if err != nil {
+			return nil, err
+		}
+
+		// The client restricts the lookups to the lookup namespace and the additional ones instead
+		if len(opts.AdditionalLookupNamespaces) != 0 {
+			cfg.LookupNamespace = ""
+		}
+	}
+
+	if wrapTransport != nil && kubeConfig != nil {
+		kubeConfig = rest.CopyConfig(kubeConfig)
+		kubeConfig.WrapTransport = transport.Wrappers(kubeConfig.WrapTransport, wrapTransport)
+	}
+
+	// Record the objects looked up by the templates to reconcile the policy again when they change
+	if r.templateWatcher != nil {
+		resolver, err := newTrackingResolver(kubeConfig, kubeClient, cfg, wrapClient)
+		if err != nil {
+			return nil, err
+		}
+
+		return resolver, nil
+	}
+
+	if wrapClient != nil && kubeClient != nil {
+		wrapped := wrapClient(*kubeClient)
+		kubeClient = &wrapped
+	}
+
+	return templates.NewResolver(kubeClient, kubeConfig, cfg)
+}
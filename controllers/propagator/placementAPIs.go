@@ -0,0 +1,34 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	clusterv1alpha1 "github.com/open-cluster-management/api/cluster/v1alpha1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+// placementWatches returns the watches of the placement APIs that reconcile the root policies
+// whose placement decisions change
+func (r *PolicyReconciler) placementWatches() map[common.PlacementAPI]common.PlacementWatch {
+	return map[common.PlacementAPI]common.PlacementWatch{
+		common.PlacementRuleAPI: func(c controller.Controller) error {
+			return c.Watch(
+				&source.Kind{Type: &appsv1.PlacementRule{}},
+				r.fullReconcile(handler.EnqueueRequestsFromMapFunc(placementRuleMapper(r.Client))),
+			)
+		},
+		common.ClusterPlacementAPI: func(c controller.Controller) error {
+			return c.Watch(
+				&source.Kind{Type: &clusterv1alpha1.PlacementDecision{}},
+				r.prioritized(
+					&placementDecisionHandler{toRequests: placementDecisionMapper(r.Client), diffs: r.decisionDiffs},
+				),
+			)
+		},
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+// noPlacementRuleClient fails like the cached client when the PlacementRule API isn't installed
+type noPlacementRuleClient struct {
+	client.Client
+}
+
+func (c noPlacementRuleClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	if _, ok := obj.(*appsv1.PlacementRule); ok {
+		return &meta.NoKindMatchError{GroupKind: appsv1.SchemeGroupVersion.WithKind("PlacementRule").GroupKind()}
+	}
+
+	return c.Client.Get(ctx, key, obj)
+}
+
+func TestGetPlacementDecisionsAPINotInstalled(t *testing.T) {
+	root := newTestPolicy("default")
+	plr := newTestPlacementRule("plr", "cluster1")
+	pb := newTestPlacementBinding("pb", "plr",
+		policiesv1.Subject{APIGroup: policiesv1.SchemeGroupVersion.Group, Kind: policiesv1.Kind, Name: "policy"},
+	)
+
+	r := newTestReconciler(t, &stubResolver{}, root, plr, pb)
+	r.placementAvailability = &common.PlacementAvailability{}
+
+	installed := []common.PlacementAPI{}
+	r.placementAvailability.OnAvailable(func(api common.PlacementAPI) {
+		installed = append(installed, api)
+	})
+
+	installedClient := r.Client
+	r.Client = noPlacementRuleClient{Client: installedClient}
+
+	decisions, placement, err := r.getPlacementDecisions(context.TODO(), *pb, root)
+	if err != nil {
+		t.Fatalf("expected no error when the PlacementRule API isn't installed, got %v", err)
+	}
+
+	if len(decisions) != 0 || placement == nil || placement.PlacementBinding != "pb" {
+		t.Fatalf("expected no decisions for the PlacementBinding, got %v and %v", decisions, placement)
+	}
+
+	if r.placementAvailability.Available(common.PlacementRuleAPI) {
+		t.Fatal("expected the PlacementRule API to be recorded as not installed")
+	}
+
+	// Once the API is installed, its decisions are used again and it is watched
+	r.Client = installedClient
+
+	decisions, _, err = r.getPlacementDecisions(context.TODO(), *pb, root)
+	if err != nil {
+		t.Fatalf("failed to get the placement decisions: %v", err)
+	}
+
+	if len(decisions) != 1 || decisions[0].ClusterName != "cluster1" {
+		t.Fatalf("expected the decision of cluster1, got %v", decisions)
+	}
+
+	if len(installed) != 1 || installed[0] != common.PlacementRuleAPI {
+		t.Fatalf("expected the PlacementRule API to be reported as installed, got %v", installed)
+	}
+}
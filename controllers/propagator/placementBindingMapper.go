@@ -4,7 +4,10 @@
 package propagator
 
 import (
+	"context"
+
 	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -17,11 +20,20 @@ func placementBindingMapper(c client.Client) handler.MapFunc {
 		var result []reconcile.Request
 		subjects := object.Subjects
 		for _, subject := range subjects {
-			if subject.APIGroup == policiesv1.SchemeGroupVersion.Group && subject.Kind == policiesv1.Kind {
+			// a subject with a label selector binds all the matching policies in the namespace
+			policyNames, err := common.PoliciesForSubject(context.TODO(), c, object.GetNamespace(), subject)
+			if err != nil {
+				log.Error(err, "Failed to get the policies selected by the placement binding...",
+					"Namespace", object.GetNamespace(), "Name", object.GetName())
+
+				continue
+			}
+
+			for _, policyName := range policyNames {
 				log.Info("Found reconciliation request from placement binding...",
-					"Namespace", object.GetNamespace(), "Name", object.GetName(), "Policy-Name", subject.Name)
+					"Namespace", object.GetNamespace(), "Name", object.GetName(), "Policy-Name", policyName)
 				request := reconcile.Request{NamespacedName: types.NamespacedName{
-					Name:      subject.Name,
+					Name:      policyName,
 					Namespace: object.GetNamespace(),
 				}}
 				result = append(result, request)
@@ -8,6 +8,7 @@ import (
 
 	clusterv1alpha1 "github.com/open-cluster-management/api/cluster/v1alpha1"
 	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -44,17 +45,21 @@ func placementDecisionMapper(c client.Client) handler.MapFunc {
 			// found matching placement rule in pb -- check if it is for policy
 			subjects := pb.Subjects
 			for _, subject := range subjects {
-				if subject.APIGroup != policiesv1.SchemeGroupVersion.Group || subject.Kind != policiesv1.Kind {
+				policyNames, err := common.PoliciesForSubject(context.TODO(), c, object.GetNamespace(), subject)
+				if err != nil {
 					continue
 				}
-				log.Info("Found reconciliation request from placement decision...", "Namespace", object.GetNamespace(),
-					"Name", object.GetName(), "Policy-Name", subject.Name)
-				// generate reconcile request for policy referenced by pb
-				request := reconcile.Request{NamespacedName: types.NamespacedName{
-					Name:      subject.Name,
-					Namespace: object.GetNamespace(),
-				}}
-				result = append(result, request)
+
+				for _, policyName := range policyNames {
+					log.Info("Found reconciliation request from placement decision...", "Namespace", object.GetNamespace(),
+						"Name", object.GetName(), "Policy-Name", policyName)
+					// generate reconcile request for policy referenced by pb
+					request := reconcile.Request{NamespacedName: types.NamespacedName{
+						Name:      policyName,
+						Namespace: object.GetNamespace(),
+					}}
+					result = append(result, request)
+				}
 			}
 		}
 		return result
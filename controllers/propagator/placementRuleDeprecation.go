@@ -0,0 +1,110 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"fmt"
+	"sync"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+)
+
+const (
+	placementRuleKind = "PlacementRule"
+	placementKind     = "Placement"
+)
+
+// placementKindTracker tracks the placement kind of the bindings of each root policy so that
+// placementKindGauge can report the number of policies per kind, and so that the deprecation of
+// PlacementRule is only reported once for each binding
+type placementKindTracker struct {
+	lock sync.Mutex
+	// policies are the placement kinds of the bindings of each root policy, by binding name
+	policies map[string]map[string]string
+}
+
+func newPlacementKindTracker() *placementKindTracker {
+	return &placementKindTracker{policies: map[string]map[string]string{}}
+}
+
+// set records the placement kinds of the bindings of the root policy and updates the gauge. A
+// policy bound through both kinds is counted for each. It returns the bindings that are new or
+// whose kind changed since the previous call.
+func (t *placementKindTracker) set(policyKey string, bindings map[string]string) map[string]bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	changed := map[string]bool{}
+
+	for binding, kind := range bindings {
+		if t.policies[policyKey][binding] != kind {
+			changed[binding] = true
+		}
+	}
+
+	if len(bindings) == 0 {
+		delete(t.policies, policyKey)
+	} else {
+		t.policies[policyKey] = bindings
+	}
+
+	t.updateGauge()
+
+	return changed
+}
+
+// delete forgets the root policy and updates the gauge
+func (t *placementKindTracker) delete(policyKey string) {
+	t.set(policyKey, nil)
+}
+
+func (t *placementKindTracker) updateGauge() {
+	counts := map[string]int{placementRuleKind: 0, placementKind: 0}
+
+	for _, bindings := range t.policies {
+		kinds := map[string]bool{}
+		for _, kind := range bindings {
+			kinds[kind] = true
+		}
+
+		for kind := range kinds {
+			counts[kind]++
+		}
+	}
+
+	for kind, count := range counts {
+		placementKindGauge.WithLabelValues(kind).Set(float64(count))
+	}
+}
+
+// reportPlacementKinds updates the placement kind gauge for the root policy and records a
+// warning event when a PlacementBinding first binds it through a PlacementRule, since
+// PlacementRule is deprecated in favor of Placement.
+func (r *PolicyReconciler) reportPlacementKinds(instance *policiesv1.Policy) {
+	bindings := map[string]string{}
+
+	for _, placement := range instance.Status.Placement {
+		if placement.PlacementRule != "" {
+			bindings[placement.PlacementBinding] = placementRuleKind
+		} else if placement.Placement != "" {
+			bindings[placement.PlacementBinding] = placementKind
+		}
+	}
+
+	changed := r.placementKinds.set(instance.GetNamespace()+"/"+instance.GetName(), bindings)
+
+	for _, placement := range instance.Status.Placement {
+		if placement.PlacementRule == "" || !changed[placement.PlacementBinding] {
+			continue
+		}
+
+		r.Recorder.Event(instance, "Warning", "PolicyPropagation",
+			fmt.Sprintf(
+				"Policy %s/%s is bound through the PlacementRule %s by the PlacementBinding %s. "+
+					"PlacementRule is deprecated, use a Placement instead.",
+				instance.GetNamespace(), instance.GetName(), placement.PlacementRule,
+				placement.PlacementBinding,
+			),
+		)
+	}
+}
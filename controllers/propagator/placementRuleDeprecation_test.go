@@ -0,0 +1,71 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+)
+
+func TestReportPlacementKinds(t *testing.T) {
+	policy := func(name string, placements ...*policiesv1.Placement) *policiesv1.Policy {
+		return &policiesv1.Policy{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "policies"},
+			Status:     policiesv1.PolicyStatus{Placement: placements},
+		}
+	}
+
+	recorder := record.NewFakeRecorder(10)
+	r := &PolicyReconciler{Recorder: recorder, placementKinds: newPlacementKindTracker()}
+
+	r.reportPlacementKinds(policy("legacy", &policiesv1.Placement{PlacementBinding: "pb", PlacementRule: "plr"}))
+	r.reportPlacementKinds(policy("migrated", &policiesv1.Placement{PlacementBinding: "pb2", Placement: "pl"}))
+	r.reportPlacementKinds(policy("both",
+		&policiesv1.Placement{PlacementBinding: "pb", PlacementRule: "plr"},
+		&policiesv1.Placement{PlacementBinding: "pb2", Placement: "pl"},
+	))
+	r.reportPlacementKinds(policy("unbound"))
+
+	expected := map[string]float64{placementRuleKind: 2, placementKind: 2}
+	for kind, count := range expected {
+		if actual := testutil.ToFloat64(placementKindGauge.WithLabelValues(kind)); actual != count {
+			t.Fatalf("expected %v policies bound through %s, got %v", count, kind, actual)
+		}
+	}
+
+	if len(recorder.Events) != 2 {
+		t.Fatalf("expected 2 deprecation events, got %d", len(recorder.Events))
+	}
+
+	event := <-recorder.Events
+	if !strings.Contains(event, "Warning") || !strings.Contains(event, "PlacementRule plr") {
+		t.Fatalf("unexpected deprecation event: %s", event)
+	}
+
+	// The deprecation is only reported again when a binding switches to a PlacementRule
+	r.reportPlacementKinds(policy("legacy", &policiesv1.Placement{PlacementBinding: "pb", PlacementRule: "plr"}))
+	r.reportPlacementKinds(policy("migrated", &policiesv1.Placement{PlacementBinding: "pb2", PlacementRule: "plr"}))
+
+	if len(recorder.Events) != 2 {
+		t.Fatalf("expected 1 more deprecation event after the kind changed, got %d", len(recorder.Events)-1)
+	}
+
+	<-recorder.Events
+
+	event = <-recorder.Events
+	if !strings.Contains(event, "Policy policies/migrated") {
+		t.Fatalf("expected the deprecation event of the changed binding, got: %s", event)
+	}
+
+	r.placementKinds.delete("policies/legacy")
+
+	if actual := testutil.ToFloat64(placementKindGauge.WithLabelValues(placementRuleKind)); actual != 2 {
+		t.Fatalf("expected 2 policies bound through a PlacementRule after the deletion, got %v", actual)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"context"
 
 	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
 	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -33,12 +34,17 @@ func placementRuleMapper(c client.Client) handler.MapFunc {
 				// check if it is for policy
 				subjects := pb.Subjects
 				for _, subject := range subjects {
-					if subject.APIGroup == policiesv1.SchemeGroupVersion.Group && subject.Kind == policiesv1.Kind {
+					policyNames, err := common.PoliciesForSubject(context.TODO(), c, object.GetNamespace(), subject)
+					if err != nil {
+						continue
+					}
+
+					for _, policyName := range policyNames {
 						log.Info("Found reconciliation request from placement rule...", "Namespace", object.GetNamespace(),
-							"Name", object.GetName(), "Policy-Name", subject.Name)
+							"Name", object.GetName(), "Policy-Name", policyName)
 						// generate reconcile request for policy referenced by pb
 						request := reconcile.Request{NamespacedName: types.NamespacedName{
-							Name:      subject.Name,
+							Name:      policyName,
 							Namespace: object.GetNamespace(),
 						}}
 						result = append(result, request)
@@ -0,0 +1,79 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"k8s.io/apimachinery/pkg/api/equality"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+)
+
+func isReplicatedPolicy(object client.Object) bool {
+	_, replicated := object.GetLabels()[common.RootPolicyLabel]
+
+	return replicated
+}
+
+// policyPredicateFuncs only lets through the root policy updates that require propagating the root
+// policy again. The replicated policies are handled by the ReplicatedPolicyReconciler, and the
+// compliance changes by the RootPolicyStatusReconciler, except during a rollout since the next
+// wave depends on the compliance of the current one, and for the policies removed from the
+// clusters that remained compliant.
+var policyPredicateFuncs = predicate.Funcs{
+	CreateFunc: func(e event.CreateEvent) bool { return !isReplicatedPolicy(e.Object) },
+	DeleteFunc: func(e event.DeleteEvent) bool { return !isReplicatedPolicy(e.Object) },
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		if isReplicatedPolicy(e.ObjectNew) {
+			return false
+		}
+
+		plcOld, oldOk := e.ObjectOld.(*policiesv1.Policy)
+		plcNew, newOk := e.ObjectNew.(*policiesv1.Policy)
+
+		if !oldOk || !newOk {
+			return true
+		}
+
+		if plcOld.GetGeneration() != plcNew.GetGeneration() ||
+			!equality.Semantic.DeepEqual(
+				common.WithoutGitOpsTrackingKeys(plcOld.GetLabels()), common.WithoutGitOpsTrackingKeys(plcNew.GetLabels()),
+			) ||
+			!equality.Semantic.DeepEqual(propagatedAnnotations(plcOld), propagatedAnnotations(plcNew)) ||
+			(plcOld.GetDeletionTimestamp() == nil) != (plcNew.GetDeletionTimestamp() == nil) {
+			return true
+		}
+
+		if equality.Semantic.DeepEqual(plcOld.Status.Status, plcNew.Status.Status) {
+			return false
+		}
+
+		// The removal of the policy from the clusters that remained compliant is scheduled when
+		// they become compliant
+		return plcNew.Spec.RemoveAfterCompliant != nil ||
+			(plcNew.Status.Rollout != nil && plcNew.Status.Rollout.State != policiesv1.RolloutCompleted)
+	},
+	GenericFunc: func(e event.GenericEvent) bool { return !isReplicatedPolicy(e.Object) },
+}
+
+// propagatedAnnotations returns the annotations of the root policy without the propagation hash,
+// since saving the hash doesn't need another reconcile, and without the GitOps tracking ones, since
+// they are not replicated
+func propagatedAnnotations(instance *policiesv1.Policy) map[string]string {
+	untracked := common.WithoutGitOpsTrackingKeys(instance.GetAnnotations())
+	if _, ok := untracked[propagationHashAnnotation]; !ok {
+		return untracked
+	}
+
+	annotations := map[string]string{}
+	for key, value := range untracked {
+		if key != propagationHashAnnotation {
+			annotations[key] = value
+		}
+	}
+
+	return annotations
+}
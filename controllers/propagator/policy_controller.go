@@ -8,23 +8,29 @@ import (
 	"fmt"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	clusterv1 "github.com/open-cluster-management/api/cluster/v1"
-	clusterv1alpha1 "github.com/open-cluster-management/api/cluster/v1alpha1"
+	templates "github.com/open-cluster-management/go-template-utils/pkg/templates"
 	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	policyv1beta1 "github.com/open-cluster-management/governance-policy-propagator/api/v1beta1"
 	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
-	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/uninstall"
 )
 
 const ControllerName string = "policy-propagator"
@@ -35,16 +41,41 @@ var log = logf.Log.WithName(ControllerName)
 //+kubebuilder:rbac:groups=policy.open-cluster-management.io,resources=policies/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=policy.open-cluster-management.io,resources=policies/finalizers,verbs=update
 //+kubebuilder:rbac:groups=policy.open-cluster-management.io,resources=placementbindings,verbs=get;list;watch;create;update;patch;delete
-//+kubebuilder:rbac:groups=cluster.open-cluster-management.io,resources=managedclusters;placementdecisions;placements,verbs=get;list;watch
+//+kubebuilder:rbac:groups=policy.open-cluster-management.io,resources=propagationconfigs,verbs=get;list;watch
+//+kubebuilder:rbac:groups=cluster.open-cluster-management.io,resources=managedclusters;managedclustersetbindings;placementdecisions;placements,verbs=get;list;watch
 //+kubebuilder:rbac:groups=apps.open-cluster-management.io,resources=placementrules,verbs=get;list;watch
 //+kubebuilder:rbac:groups=core,resources=events,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=serviceaccounts;users,verbs=impersonate
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *PolicyReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	err := mgr.Add(&orphanSweeper{reconciler: r, interval: r.orphanGCInterval})
+	if err != nil {
+		return err
+	}
+
+	if r.driftDetectionInterval > 0 {
+		err := mgr.Add(&driftDetector{reconciler: r, interval: r.driftDetectionInterval})
+		if err != nil {
+			return err
+		}
+	}
+
+	if r.eventAggregator != nil {
+		err := mgr.Add(r.eventAggregator)
+		if err != nil {
+			return err
+		}
+	}
+
+	full := r.fullReconcile
+
+	bldr := ctrl.NewControllerManagedBy(mgr).
 		Named(ControllerName).
+		WithOptions(controller.Options{MaxConcurrentReconciles: r.maxConcurrentReconciles}).
 		For(
 			&policiesv1.Policy{},
 			builder.WithPredicates(common.NeverEnqueue)).
@@ -53,28 +84,170 @@ func (r *PolicyReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		// particular way, so we will define that in a separate "Watches"
 		Watches(
 			&source.Kind{Type: &policiesv1.Policy{}},
-			&common.EnqueueRequestsFromMapFunc{ToRequests: policyMapper(mgr.GetClient())}).
+			full(&common.EnqueueRequestsFromMapFunc{ToRequests: policyMapper(mgr.GetClient())}),
+			builder.WithPredicates(policyPredicateFuncs)).
+		Watches(
+			&source.Kind{Type: &policiesv1.Policy{}},
+			full(handler.EnqueueRequestsFromMapFunc(dependencyMapper(mgr.GetClient()))),
+			builder.WithPredicates(dependencyPredicateFuncs)).
 		Watches(
 			&source.Kind{Type: &policiesv1.PlacementBinding{}},
-			handler.EnqueueRequestsFromMapFunc(placementBindingMapper(mgr.GetClient())),
+			full(handler.EnqueueRequestsFromMapFunc(placementBindingMapper(mgr.GetClient()))),
 			builder.WithPredicates(pbPredicateFuncs)).
 		Watches(
-			&source.Kind{Type: &appsv1.PlacementRule{}},
-			handler.EnqueueRequestsFromMapFunc(placementRuleMapper(mgr.GetClient()))).
+			&source.Kind{Type: &corev1.Namespace{}},
+			full(handler.EnqueueRequestsFromMapFunc(namespaceMapper(mgr.GetClient()))),
+			builder.WithPredicates(enforcementLockPredicateFuncs)).
 		Watches(
-			&source.Kind{Type: &clusterv1alpha1.PlacementDecision{}},
-			handler.EnqueueRequestsFromMapFunc(placementDecisionMapper(mgr.GetClient()))).
-		Complete(r)
+			&source.Kind{Type: &policyv1beta1.PropagationConfig{}},
+			full(handler.EnqueueRequestsFromMapFunc(propagationConfigMapper(mgr.GetClient())))).
+		Watches(
+			&source.Kind{Type: &clusterv1.ManagedCluster{}},
+			full(handler.EnqueueRequestsFromMapFunc(managedClusterMapper(mgr.GetClient()))),
+			builder.WithPredicates(managedClusterPredicateFuncs)).
+		Watches(
+			&source.Kind{Type: &corev1.ConfigMap{}},
+			full(handler.EnqueueRequestsFromMapFunc(templateSourceMapper(r.templateCache))),
+			builder.OnlyMetadata).
+		Watches(
+			&source.Kind{Type: &corev1.Secret{}},
+			full(handler.EnqueueRequestsFromMapFunc(templateSourceMapper(r.templateCache))),
+			builder.OnlyMetadata)
+
+	// Encrypt the values resolved from Secrets again when the encryption key is rotated
+	if common.FeatureEnabled(common.EncryptedHubTemplates) {
+		bldr = bldr.Watches(
+			&source.Kind{Type: &corev1.Secret{}},
+			full(handler.EnqueueRequestsFromMapFunc(encryptionKeyMapper(mgr.GetClient()))),
+			builder.OnlyMetadata,
+			builder.WithPredicates(encryptionKeyPredicateFuncs))
+	}
+
+	if r.templateWatcher != nil {
+		err := mgr.Add(r.templateWatcher)
+		if err != nil {
+			return err
+		}
+
+		bldr = bldr.Watches(
+			&source.Channel{Source: r.templateWatcher.events}, full(&handler.EnqueueRequestForObject{}),
+		)
+	}
+
+	if r.templateFunctionsWatcher != nil {
+		err := mgr.Add(r.templateFunctionsWatcher)
+		if err != nil {
+			return err
+		}
+
+		bldr = bldr.Watches(
+			&source.Channel{Source: r.templateFunctionsWatcher.events}, full(&handler.EnqueueRequestForObject{}),
+		)
+	}
+
+	c, err := bldr.Build(r)
+	if err != nil {
+		return err
+	}
+
+	// The placement APIs are only watched once they are installed
+	return r.placementAvailability.WatchWhenAvailable(c, r.placementWatches(), log)
+}
+
+// fullReconcile wraps the event handler so that its events reconcile all the replicated policies of
+// the root policies, since only the changes of the placement decisions may not. The root policies
+// are reconciled by priority.
+func (r *PolicyReconciler) fullReconcile(h handler.EventHandler) handler.EventHandler {
+	return r.prioritized(&fullReconcileHandler{EventHandler: h, diffs: r.decisionDiffs})
 }
 
 // blank assignment to verify that ReconcilePolicy implements reconcile.Reconciler
 var _ reconcile.Reconciler = &PolicyReconciler{}
 
-// PolicyReconciler reconciles a Policy object
+// PolicyReconciler reconciles a Policy object. It must be created with NewPolicyReconciler.
 type PolicyReconciler struct {
 	client.Client
-	Scheme   *runtime.Scheme
-	Recorder record.EventRecorder
+	Scheme              *runtime.Scheme
+	Recorder            record.EventRecorder
+	kubeConfig          *rest.Config
+	kubeClient          *kubernetes.Interface
+	templateCfg         templates.Config
+	clock               clock.Clock
+	retryAttempts       int
+	retryDelay          time.Duration
+	reconcileTimeout    time.Duration
+	admissionHook       *admissionHookClient
+	mutationHooks       []MutationHook
+	namespaceDenylist   []string
+	clusterCircuits     *circuitBreaker
+	newTemplateResolver func(opts TemplateResolverOptions) (TemplateResolver, error)
+
+	// clusterNamespaceEvents records the lifecycle events of the replicated policies in their
+	// cluster namespaces
+	clusterNamespaceEvents bool
+	// driftDetectionInterval is how often the modified replicated policies are restored. The drift
+	// detection is disabled when it is 0.
+	driftDetectionInterval time.Duration
+	// orphanGCInterval is how often the orphaned replicated policies are deleted. They are only
+	// deleted on start when it is 0.
+	orphanGCInterval time.Duration
+	// requeueBackoff is the delay to reconcile the root policies and the replicated policies again
+	// after they failed, which grows while they keep failing
+	requeueBackoff *requeueBackoff
+	// eventAggregator aggregates the per-cluster events of the root policies. It is nil when an
+	// event is recorded for every cluster.
+	eventAggregator *eventAggregator
+	// propagationState is the propagation state of the root policies served by the debug endpoint
+	propagationState *propagationState
+	// maxConcurrentReconciles is the number of root policies reconciled at the same time
+	maxConcurrentReconciles int
+	// decisionConcurrency is the maximum number of replicated policies of a root policy that are
+	// created or updated at the same time
+	decisionConcurrency int
+	// templateCache holds the resolved hub templates of the root policies for every cluster
+	templateCache *templateCache
+	// templateClients are the Kubernetes clients of the hub template lookups shared between the
+	// reconciles
+	templateClients *templateClients
+	// templateWatcher reconciles the root policies again when the objects looked up by their hub
+	// templates change. It is nil when the objects are not watched.
+	templateWatcher *templateWatcher
+	// templateFunctions are the hub template functions disabled for the root policies of every
+	// namespace
+	templateFunctions *templateFunctions
+	// templateFunctionsWatcher reloads the templateFunctions when their ConfigMap changes. It is nil
+	// when no ConfigMap is configured.
+	templateFunctionsWatcher *templateFunctionsWatcher
+	// templateEligibleKinds are the kinds of the policy templates that may have hub templates
+	templateEligibleKinds map[string]bool
+	// copyLabels are the patterns of the keys of the labels and annotations of the root policies
+	// copied to their replicated policies. It is nil when they are all copied.
+	copyLabels []string
+	// templateImpersonation makes the hub template lookups impersonate the user returned by
+	// templateUser
+	templateImpersonation bool
+	// trustTemplateUserAnnotation is whether the template-user annotation is set by the webhook
+	trustTemplateUserAnnotation bool
+	// complianceDB returns the compliance events database IDs of the replicated policies. It is nil
+	// when the compliance events API is disabled.
+	complianceDB ComplianceDBIDs
+	// detailedRootStatus sets the compliance of the policy templates in the per-cluster status
+	detailedRootStatus bool
+	// shard is the partition of the root policies reconciled by the replica. The zero value
+	// reconciles all of them.
+	shard Shard
+	// decisionDiffs are the clusters added to or removed from the placement decisions of the queued
+	// root policies
+	decisionDiffs *decisionDiffs
+	// priorities releases the queued root policies to the controller queue by priority
+	priorities *priorityQueue
+	// propagationLatency measures the time it takes for the root policy changes to be replicated
+	propagationLatency *propagationLatency
+	// placementAvailability records which of the placement APIs are installed. It is nil when all of
+	// them are assumed to be installed.
+	placementAvailability *common.PlacementAvailability
+	// placementKinds tracks the placement kinds the root policies are bound through
+	placementKinds *placementKindTracker
 }
 
 // Reconcile reads that state of the cluster for a Policy object and makes changes based on the state read
@@ -85,6 +258,14 @@ type PolicyReconciler struct {
 func (r *PolicyReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
 	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
 
+	// Release the next root policy by priority now that the controller queue has room for it
+	r.priorities.started(request.NamespacedName)
+
+	// The root policies of the other shards are reconciled by their replicas
+	if !r.shard.Owns(request.NamespacedName) {
+		return reconcile.Result{}, nil
+	}
+
 	reqLogger.Info("Reconciling Policy...")
 
 	// Fetch the Policy instance
@@ -122,7 +303,10 @@ func (r *PolicyReconciler) Reconcile(ctx context.Context, request ctrl.Request)
 						"Name", plc.GetName())
 					return reconcile.Result{}, err
 				}
+				// #nosec G601 -- no memory addresses are stored in collections
+				r.recordClusterNamespaceEvent(&plc, request.String(), replicatedPolicyDeleted)
 			}
+			r.forgetRootPolicy(request.NamespacedName)
 			reqLogger.Info("Policy clean up complete, reconciliation completed.")
 			return reconcile.Result{}, nil
 		}
@@ -138,20 +322,87 @@ func (r *PolicyReconciler) Reconcile(ctx context.Context, request ctrl.Request)
 	}
 
 	if !common.IsInClusterNamespace(request.Namespace, clusterList.Items) {
+		if instance.GetDeletionTimestamp() != nil {
+			return r.finalizeRootPolicy(ctx, instance)
+		}
+
+		// The uninstall controller removes the finalizers and the replicated policies
+		if uninstall.Uninstalling() {
+			reqLogger.Info("The propagator is being uninstalled, skipping the reconcile...")
+
+			return reconcile.Result{}, nil
+		}
+
+		err = r.addRootPolicyFinalizer(ctx, instance)
+		if err != nil {
+			reqLogger.Error(err, "Failed to add the finalizer to the root policy...")
+
+			return reconcile.Result{}, err
+		}
+
+		// The RootPolicyStatusReconciler keeps aggregating the compliance of the replicated policies
+		if isPropagationPaused(instance) {
+			reqLogger.Info("The propagation of the policy is paused, skipping the reconcile...")
+
+			return reconcile.Result{}, nil
+		}
+
 		// handleRootPolicy handles all retries and it will give up as appropriate. In that case
 		// requeue it to be reprocessed later.
-		err := r.handleRootPolicy(instance)
+		rootCtx, cancel := context.WithTimeout(ctx, r.reconcileTimeout)
+		defer cancel()
+
+		r.propagationState.startReconcile(request.String())
+
+		// Only the clusters added to or removed from the placement decisions are handled when
+		// nothing else changed since the last reconcile
+		changed := r.decisionDiffs.take(request.NamespacedName)
+		if changed != nil {
+			reqLogger.Info("Only handling the clusters whose placement decisions changed...",
+				"Clusters", len(changed))
+		}
+
+		err = r.handleRootPolicy(rootCtx, instance, changed)
 		if err != nil {
+			requeueDelay := r.requeueBackoff.next(request.String(), len(instance.Status.Status))
+
+			r.propagationState.finishReconcile(request.String(), r.clock.Now().Add(requeueDelay))
+			r.decisionDiffs.markFull(request.NamespacedName)
+
+			if rootCtx.Err() == context.DeadlineExceeded {
+				reqLogger.Info("Timed out handling the root policy...", "Timeout", r.reconcileTimeout.String())
+				roothandlerTimeouts.Inc()
+				r.recordWarning(instance, fmt.Sprintf("The reconcile timed out after %v", r.reconcileTimeout))
+			}
+
 			r.recordWarning(
 				instance,
-				fmt.Sprintf("Retrying the request in %d minutes", requeueErrorDelay),
+				fmt.Sprintf("Retrying the request in %v", requeueDelay),
 			)
-			duration := time.Duration(requeueErrorDelay) * time.Minute
 			// An error must not be returned for RequeueAfter to take effect. See:
 			// https://github.com/kubernetes-sigs/controller-runtime/blob/5de246bfbfd1a75f966b5662edcb9c7235244160/pkg/internal/controller/controller.go#L319-L322
-			return reconcile.Result{RequeueAfter: duration}, nil
+			return reconcile.Result{RequeueAfter: requeueDelay}, nil
+		}
+
+		r.requeueBackoff.reset(request.String())
+
+		// Push the updates pending on the maintenance window when it opens, and remove the policy
+		// from the clusters once they remained compliant for long enough
+		requeueAfter := pendingUpdateRequeue(instance, r.clock.Now())
+		if removeAfter := removeAfterCompliantRequeue(instance, r.clock.Now()); removeAfter > 0 &&
+			(requeueAfter == 0 || removeAfter < requeueAfter) {
+			requeueAfter = removeAfter
 		}
 
+		if requeueAfter > 0 {
+			r.propagationState.finishReconcile(request.String(), r.clock.Now().Add(requeueAfter))
+			r.decisionDiffs.markFull(request.NamespacedName)
+
+			return reconcile.Result{RequeueAfter: requeueAfter}, nil
+		}
+
+		r.propagationState.finishReconcile(request.String(), time.Time{})
+
 		return reconcile.Result{}, nil
 	}
 
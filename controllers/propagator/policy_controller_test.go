@@ -0,0 +1,406 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	clusterv1 "github.com/open-cluster-management/api/cluster/v1"
+	clusterv1alpha1 "github.com/open-cluster-management/api/cluster/v1alpha1"
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	policyv1beta1 "github.com/open-cluster-management/governance-policy-propagator/api/v1beta1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+// stubResolver replaces the hub templates with a fixed result or fails with a fixed error
+type stubResolver struct {
+	result []byte
+	err    error
+}
+
+func (s *stubResolver) ResolveTemplate(tmplJSON []byte, context interface{}) ([]byte, error) {
+	return s.result, s.err
+}
+
+const configPolicy = `{"apiVersion":"policy.open-cluster-management.io/v1","kind":"ConfigurationPolicy",` +
+	`"metadata":{"name":"case"},"spec":{"namespace":"%s"}}`
+
+func newTestReconciler(t *testing.T, resolver *stubResolver, objects ...client.Object) *PolicyReconciler {
+	t.Helper()
+
+	scheme := runtime.NewScheme()
+	for _, addToScheme := range []func(*runtime.Scheme) error{
+		policiesv1.AddToScheme, appsv1.AddToScheme, clusterv1alpha1.AddToScheme, clusterv1.AddToScheme,
+		corev1.AddToScheme, policyv1beta1.AddToScheme, admissionregistrationv1.AddToScheme,
+	} {
+		if err := addToScheme(scheme); err != nil {
+			t.Fatalf("failed to build the scheme: %v", err)
+		}
+	}
+
+	return NewPolicyReconciler(
+		&applyClient{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()},
+		scheme,
+		PolicyReconcilerOptions{
+			Clock:             clock.NewFakeClock(time.Now()),
+			Recorder:          record.NewFakeRecorder(10),
+			RetryAttempts:     1,
+			RetryDelay:        time.Millisecond,
+			RequeueErrorDelay: time.Minute,
+			NewTemplateResolver: func(TemplateResolverOptions) (TemplateResolver, error) {
+				return resolver, nil
+			},
+		},
+	)
+}
+
+func newTestPolicy(namespace string) *policiesv1.Policy {
+	return &policiesv1.Policy{
+		TypeMeta:   metav1.TypeMeta{APIVersion: policiesv1.SchemeGroupVersion.String(), Kind: policiesv1.Kind},
+		ObjectMeta: metav1.ObjectMeta{Name: "policy", Namespace: "policies"},
+		Spec: policiesv1.PolicySpec{
+			PolicyTemplates: []*policiesv1.PolicyTemplate{{
+				ObjectDefinition: runtime.RawExtension{Raw: []byte(strings.Replace(configPolicy, "%s", namespace, 1))},
+			}},
+		},
+	}
+}
+
+func events(r *PolicyReconciler) []string {
+	recorder := r.Recorder.(*record.FakeRecorder)
+	close(recorder.Events)
+
+	result := []string{}
+	for event := range recorder.Events {
+		result = append(result, event)
+	}
+
+	return result
+}
+
+func TestHandleDecision(t *testing.T) {
+	resolved := []byte(strings.Replace(configPolicy, "%s", "resolved", 1))
+	decision := appsv1.PlacementDecision{ClusterName: "cluster1", ClusterNamespace: "cluster1"}
+
+	tests := []struct {
+		name          string
+		root          *policiesv1.Policy
+		existing      *policiesv1.Policy
+		resolver      *stubResolver
+		expectedRaw   string
+		expectedEvent string
+	}{
+		{
+			name:          "create without templates",
+			root:          newTestPolicy("default"),
+			resolver:      &stubResolver{err: errors.New("unexpected call")},
+			expectedRaw:   strings.Replace(configPolicy, "%s", "default", 1),
+			expectedEvent: "Normal PolicyPropagation Policy policies/policy was propagated to cluster cluster1/cluster1",
+		},
+		{
+			name:          "create with templates",
+			root:          newTestPolicy(`{{hub .ManagedClusterName hub}}`),
+			resolver:      &stubResolver{result: resolved},
+			expectedRaw:   string(resolved),
+			expectedEvent: "Normal PolicyPropagation Policy policies/policy was propagated to cluster cluster1/cluster1",
+		},
+		{
+			name: "update with templates",
+			root: newTestPolicy(`{{hub .ManagedClusterName hub}}`),
+			existing: func() *policiesv1.Policy {
+				plc := newTestPolicy("outdated")
+				plc.SetName("policies.policy")
+				plc.SetNamespace("cluster1")
+				plc.SetLabels(map[string]string{common.RootPolicyLabel: "policies.policy"})
+
+				return plc
+			}(),
+			resolver:      &stubResolver{result: resolved},
+			expectedRaw:   string(resolved),
+			expectedEvent: "Normal PolicyPropagation Policy policies/policy was updated for cluster cluster1/cluster1",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			objects := []client.Object{test.root}
+			if test.existing != nil {
+				objects = append(objects, test.existing)
+			}
+
+			r := newTestReconciler(t, test.resolver, objects...)
+
+			templateErr, err := r.handleDecision(context.TODO(), test.root, decision, policyv1beta1.PropagationConfigSpec{})
+			if err != nil {
+				t.Fatalf("handleDecision returned an error: %v", err)
+			}
+
+			if templateErr != nil {
+				t.Fatalf("expected the hub templates to be resolved, got %v", templateErr)
+			}
+
+			replicatedPlc := &policiesv1.Policy{}
+
+			err = r.Get(context.TODO(), types.NamespacedName{Namespace: "cluster1", Name: "policies.policy"}, replicatedPlc)
+			if err != nil {
+				t.Fatalf("failed to get the replicated policy: %v", err)
+			}
+
+			raw := string(replicatedPlc.Spec.PolicyTemplates[0].ObjectDefinition.Raw)
+			if raw != test.expectedRaw {
+				t.Fatalf("expected the policy template %s, got %s", test.expectedRaw, raw)
+			}
+
+			recorded := events(r)
+			if len(recorded) != 1 || recorded[0] != test.expectedEvent {
+				t.Fatalf("expected the event %q, got %v", test.expectedEvent, recorded)
+			}
+		})
+	}
+}
+
+func TestHandleRootPolicy(t *testing.T) {
+	root := newTestPolicy("default")
+	plr := &appsv1.PlacementRule{
+		ObjectMeta: metav1.ObjectMeta{Name: "plr", Namespace: "policies"},
+		Status: appsv1.PlacementRuleStatus{
+			Decisions: []appsv1.PlacementDecision{{ClusterName: "cluster1", ClusterNamespace: "cluster1"}},
+		},
+	}
+	pb := &policiesv1.PlacementBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "pb", Namespace: "policies"},
+		PlacementRef: policiesv1.Subject{
+			APIGroup: appsv1.SchemeGroupVersion.Group, Kind: "PlacementRule", Name: "plr",
+		},
+		Subjects: []policiesv1.Subject{
+			{APIGroup: policiesv1.SchemeGroupVersion.Group, Kind: policiesv1.Kind, Name: "policy"},
+		},
+	}
+
+	r := newTestReconciler(t, &stubResolver{}, root, plr, pb)
+
+	err := r.handleRootPolicy(context.TODO(), root, nil)
+	if err != nil {
+		t.Fatalf("handleRootPolicy returned an error: %v", err)
+	}
+
+	replicatedPlc := &policiesv1.Policy{}
+
+	err = r.Get(context.TODO(), types.NamespacedName{Namespace: "cluster1", Name: "policies.policy"}, replicatedPlc)
+	if err != nil {
+		t.Fatalf("failed to get the replicated policy: %v", err)
+	}
+
+	updatedRoot := &policiesv1.Policy{}
+
+	err = r.Get(context.TODO(), types.NamespacedName{Namespace: "policies", Name: "policy"}, updatedRoot)
+	if err != nil {
+		t.Fatalf("failed to get the root policy: %v", err)
+	}
+
+	if len(updatedRoot.Status.Status) != 1 || updatedRoot.Status.Status[0].ClusterName != "cluster1" {
+		t.Fatalf("expected the root policy status to list cluster1, got %v", updatedRoot.Status.Status)
+	}
+
+	if len(updatedRoot.Status.Placement) != 1 || updatedRoot.Status.Placement[0].PlacementBinding != "pb" {
+		t.Fatalf("expected the root policy status to list the placement binding, got %v", updatedRoot.Status.Placement)
+	}
+}
+
+func TestHandleRootPolicyOutcome(t *testing.T) {
+	plr := &appsv1.PlacementRule{
+		ObjectMeta: metav1.ObjectMeta{Name: "plr", Namespace: "policies"},
+		Status: appsv1.PlacementRuleStatus{
+			Decisions: []appsv1.PlacementDecision{
+				{ClusterName: "cluster1", ClusterNamespace: "cluster1"},
+				{ClusterName: "system", ClusterNamespace: "kube-system"},
+			},
+		},
+	}
+	pb := &policiesv1.PlacementBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "pb", Namespace: "policies"},
+		PlacementRef: policiesv1.Subject{
+			APIGroup: appsv1.SchemeGroupVersion.Group, Kind: "PlacementRule", Name: "plr",
+		},
+		Subjects: []policiesv1.Subject{
+			{APIGroup: policiesv1.SchemeGroupVersion.Group, Kind: policiesv1.Kind, Name: "policy"},
+		},
+	}
+
+	tests := []struct {
+		name              string
+		root              *policiesv1.Policy
+		resolver          *stubResolver
+		denylist          []string
+		expectedOutcome   string
+		expectedTemplates string
+	}{
+		{
+			name:              "success",
+			root:              newTestPolicy("default"),
+			resolver:          &stubResolver{},
+			expectedOutcome:   outcomeSuccess,
+			expectedTemplates: "false",
+		},
+		{
+			name:              "template error",
+			root:              newTestPolicy(`{{hub .ManagedClusterName hub}}`),
+			resolver:          &stubResolver{err: errors.New("template failure")},
+			expectedOutcome:   outcomeTemplateError,
+			expectedTemplates: "true",
+		},
+		{
+			name:              "partial failure",
+			root:              newTestPolicy("default"),
+			resolver:          &stubResolver{},
+			denylist:          []string{"kube-*"},
+			expectedOutcome:   outcomePartialFailure,
+			expectedTemplates: "false",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			roothandlerMeasure.Reset()
+
+			r := newTestReconciler(t, test.resolver, test.root, plr.DeepCopy(), pb.DeepCopy())
+			r.Recorder = record.NewFakeRecorder(20)
+			r.namespaceDenylist = test.denylist
+
+			err := r.handleRootPolicy(context.TODO(), test.root, nil)
+			if err != nil {
+				t.Fatalf("handleRootPolicy returned an error: %v", err)
+			}
+
+			if series := testutil.CollectAndCount(roothandlerMeasure); series != 1 {
+				t.Fatalf("expected 1 observed outcome, got %d", series)
+			}
+
+			// Deleting the series only succeeds if it was observed with these labels
+			if !roothandlerMeasure.DeleteLabelValues(test.expectedOutcome, test.expectedTemplates) {
+				t.Fatalf(
+					"expected the outcome %s with templates=%s to be observed",
+					test.expectedOutcome, test.expectedTemplates,
+				)
+			}
+		})
+	}
+}
+
+// contextClient fails the requests whose context is done, like a real client does
+type contextClient struct {
+	client.Client
+}
+
+func (c *contextClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	return c.Client.List(ctx, list, opts...)
+}
+
+func TestReconcileTimeout(t *testing.T) {
+	root := newTestPolicy("default")
+	r := newTestReconciler(t, &stubResolver{}, root)
+	r.Client = &contextClient{r.Client}
+	r.reconcileTimeout = time.Nanosecond
+
+	before := testutil.ToFloat64(roothandlerTimeouts)
+
+	result, err := r.Reconcile(context.TODO(), ctrl.Request{
+		NamespacedName: types.NamespacedName{Namespace: "policies", Name: "policy"},
+	})
+	if err != nil {
+		t.Fatalf("Reconcile returned an error: %v", err)
+	}
+
+	if result.RequeueAfter != r.requeueBackoff.min {
+		t.Fatalf("expected a requeue after %v, got %v", r.requeueBackoff.min, result.RequeueAfter)
+	}
+
+	if timeouts := testutil.ToFloat64(roothandlerTimeouts) - before; timeouts != 1 {
+		t.Fatalf("expected 1 timed out reconcile, got %v", timeouts)
+	}
+
+	expected := "Warning PolicyPropagation Retrying the request in " + r.requeueBackoff.min.String() +
+		" for the policy policies/policy"
+
+	recorded := events(r)
+	if len(recorded) == 0 || recorded[len(recorded)-1] != expected {
+		t.Fatalf("expected the event %q, got %v", expected, recorded)
+	}
+}
+
+func TestHandleRootPolicySelectorSubject(t *testing.T) {
+	labeled := newTestPolicy("default")
+	labeled.SetLabels(map[string]string{"team": "payments"})
+
+	unlabeled := newTestPolicy("default")
+	unlabeled.SetName("unlabeled")
+
+	plr := &appsv1.PlacementRule{
+		ObjectMeta: metav1.ObjectMeta{Name: "plr", Namespace: "policies"},
+		Status: appsv1.PlacementRuleStatus{
+			Decisions: []appsv1.PlacementDecision{{ClusterName: "cluster1", ClusterNamespace: "cluster1"}},
+		},
+	}
+	pb := &policiesv1.PlacementBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "pb", Namespace: "policies"},
+		PlacementRef: policiesv1.Subject{
+			APIGroup: appsv1.SchemeGroupVersion.Group, Kind: "PlacementRule", Name: "plr",
+		},
+		Subjects: []policiesv1.Subject{{
+			APIGroup: policiesv1.SchemeGroupVersion.Group,
+			Kind:     policiesv1.Kind,
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "payments"}},
+		}},
+	}
+
+	r := newTestReconciler(t, &stubResolver{}, labeled, unlabeled, plr, pb)
+
+	requests := placementBindingMapper(r.Client)(pb)
+	if len(requests) != 1 || requests[0].Name != "policy" {
+		t.Fatalf("expected a reconcile request for the labeled policy only, got %v", requests)
+	}
+
+	for _, root := range []*policiesv1.Policy{labeled, unlabeled} {
+		if err := r.handleRootPolicy(context.TODO(), root, nil); err != nil {
+			t.Fatalf("handleRootPolicy returned an error for %s: %v", root.GetName(), err)
+		}
+	}
+
+	err := r.Get(context.TODO(), types.NamespacedName{Namespace: "cluster1", Name: "policies.policy"}, &policiesv1.Policy{})
+	if err != nil {
+		t.Fatalf("failed to get the replicated policy of the labeled policy: %v", err)
+	}
+
+	err = r.Get(
+		context.TODO(), types.NamespacedName{Namespace: "cluster1", Name: "policies.unlabeled"}, &policiesv1.Policy{},
+	)
+	if !k8serrors.IsNotFound(err) {
+		t.Fatalf("expected no replicated policy for the unlabeled policy, got the error: %v", err)
+	}
+}
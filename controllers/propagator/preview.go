@@ -0,0 +1,126 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+// PreviewDecisions returns the placement decisions of the clusters the root policy would be
+// replicated to, after the cluster selector, the namespace denylist, the cluster requirements, and
+// the fan-out limit of the PropagationConfig of the namespace are applied. The rollout waves are
+// ignored. Nothing is created or updated.
+func (r *PolicyReconciler) PreviewDecisions(
+	ctx context.Context, instance *policiesv1.Policy,
+) ([]appsv1.PlacementDecision, error) {
+	cfg, err := r.getPropagationConfig(ctx, instance.GetNamespace())
+	if err != nil {
+		return nil, err
+	}
+
+	clusterSelector, err := policyClusterSelector(instance)
+	if err != nil {
+		return nil, err
+	}
+
+	pbList := &policiesv1.PlacementBindingList{}
+
+	err = r.List(ctx, pbList, &client.ListOptions{Namespace: instance.GetNamespace()})
+	if err != nil {
+		return nil, err
+	}
+
+	_, selected, err := r.placementDecisions(ctx, instance, pbList)
+	if err != nil {
+		return nil, err
+	}
+
+	eligible := []appsv1.PlacementDecision{}
+
+	for _, decision := range selected {
+		decision, err := r.hostedClusterDecision(ctx, decision)
+		if err != nil {
+			return nil, err
+		}
+
+		matches, err := r.clusterSelected(ctx, clusterSelector, decision.ClusterName)
+		if err != nil {
+			return nil, err
+		}
+
+		if !matches || r.namespaceDenied(decision.ClusterNamespace) {
+			continue
+		}
+
+		incompatibility, err := r.clusterIncompatibility(ctx, instance.Spec.ClusterRequirements, decision.ClusterName)
+		if err != nil {
+			return nil, err
+		}
+
+		if incompatibility != "" {
+			continue
+		}
+
+		if cfg.MaxClusters > 0 && len(eligible) >= cfg.MaxClusters {
+			break
+		}
+
+		eligible = append(eligible, decision)
+	}
+
+	return eligible, nil
+}
+
+// PreviewTemplates returns the root policy with its hub templates resolved for the cluster of the
+// placement decision, as they are resolved before the replicated policy is built
+func (r *PolicyReconciler) PreviewTemplates(
+	ctx context.Context, instance *policiesv1.Policy, decision appsv1.PlacementDecision,
+) (*policiesv1.Policy, error) {
+	cfg, err := r.getPropagationConfig(ctx, instance.GetNamespace())
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := desiredReplicatedPolicy(instance, decision)
+
+	if r.policyHasTemplates(instance) {
+		err = r.processTemplates(ctx, resolved, decision, instance, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve the hub templates for the cluster %s: %w", decision.ClusterName, err)
+		}
+	}
+
+	return resolved, nil
+}
+
+// PreviewReplicatedPolicy returns the replicated policy the propagator would apply in the namespace
+// of the placement decision, including the PropagationConfig defaults, the bindingOverrides, the
+// mutation hooks, and the stamps of the propagator. Unlike the propagator, a failure to resolve the
+// hub templates is returned as an error. Nothing is created or updated.
+func (r *PolicyReconciler) PreviewReplicatedPolicy(
+	ctx context.Context, instance *policiesv1.Policy, decision appsv1.PlacementDecision,
+) (*policiesv1.Policy, error) {
+	cfg, err := r.getPropagationConfig(ctx, instance.GetNamespace())
+	if err != nil {
+		return nil, err
+	}
+
+	desiredPlc, templateErr, err := r.buildReplicatedPolicy(ctx, instance, decision, cfg, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if templateErr != nil {
+		return nil, fmt.Errorf(
+			"failed to resolve the hub templates for the cluster %s: %w", decision.ClusterName, templateErr,
+		)
+	}
+
+	return desiredPlc, nil
+}
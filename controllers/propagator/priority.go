@@ -0,0 +1,226 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+)
+
+// priorityAnnotation on a root policy is its propagation priority, high, default, or low. When many
+// root policies are waiting to be reconciled, such as after the propagator restarts, the ones with
+// a higher priority are reconciled first.
+const priorityAnnotation = common.APIGroup + "/priority"
+
+type propagationPriority int
+
+const (
+	priorityLow propagationPriority = iota
+	priorityDefault
+	priorityHigh
+)
+
+// policyPriority returns the propagation priority of the root policy. An invalid priority is the
+// default one.
+func policyPriority(instance *policiesv1.Policy) propagationPriority {
+	switch strings.ToLower(strings.TrimSpace(instance.GetAnnotations()[priorityAnnotation])) {
+	case "high":
+		return priorityHigh
+	case "low":
+		return priorityLow
+	default:
+		return priorityDefault
+	}
+}
+
+// priorityQueue holds the root policies enqueued by the watches of the PolicyReconciler and
+// releases them to the queue of the controller by priority, and in the order they were enqueued for
+// the same priority. Since the controller queue is first in first out, only as many root policies
+// as the number of concurrent reconciles are released to it at once, so that a root policy with a
+// higher priority enqueued later doesn't wait behind the ones already released.
+type priorityQueue struct {
+	// priorityOf returns the priority of the root policy
+	priorityOf func(name types.NamespacedName) propagationPriority
+	// maxReleased is the number of root policies released and not yet reconciled at once
+	maxReleased int
+
+	lock sync.Mutex
+	// queue is the queue of the controller, set when the first root policy is enqueued
+	queue workqueue.RateLimitingInterface
+	// pending are the root policies not released yet, by priority
+	pending [priorityHigh + 1][]types.NamespacedName
+	// pendingPriority is the priority of the pending root policies
+	pendingPriority map[types.NamespacedName]propagationPriority
+	// released are the root policies released and not yet reconciled
+	released map[types.NamespacedName]bool
+}
+
+func newPriorityQueue(maxReleased int, priorityOf func(name types.NamespacedName) propagationPriority) *priorityQueue {
+	if maxReleased < 1 {
+		maxReleased = 1
+	}
+
+	return &priorityQueue{
+		priorityOf:      priorityOf,
+		maxReleased:     maxReleased,
+		pendingPriority: map[types.NamespacedName]propagationPriority{},
+		released:        map[types.NamespacedName]bool{},
+	}
+}
+
+// priorityOf returns the priority of the root policy in the cache, or the default one when it
+// can't be retrieved, such as when it was deleted
+func (r *PolicyReconciler) priorityOf(name types.NamespacedName) propagationPriority {
+	instance := &policiesv1.Policy{}
+
+	if err := r.Get(context.TODO(), name, instance); err != nil {
+		return priorityDefault
+	}
+
+	return policyPriority(instance)
+}
+
+// add holds the root policy until it is released to the controller queue. A root policy that was
+// released and not yet reconciled is ignored since it is already in the controller queue.
+func (p *priorityQueue) add(queue workqueue.RateLimitingInterface, name types.NamespacedName) {
+	priority := p.priorityOf(name)
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.queue = queue
+
+	if p.released[name] {
+		return
+	}
+
+	if current, ok := p.pendingPriority[name]; ok {
+		if current >= priority {
+			return
+		}
+
+		p.removePending(name, current)
+	}
+
+	p.pending[priority] = append(p.pending[priority], name)
+	p.pendingPriority[name] = priority
+
+	p.release()
+}
+
+// started releases the next root policies when a root policy starts to be reconciled. A nil queue
+// ignores it.
+func (p *priorityQueue) started(name types.NamespacedName) {
+	if p == nil {
+		return
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	delete(p.released, name)
+	p.release()
+}
+
+// release adds the pending root policies with the highest priority to the controller queue until
+// it has as many as the number of concurrent reconciles. The lock must be held.
+func (p *priorityQueue) release() {
+	for priority := priorityHigh; priority >= priorityLow && len(p.released) < p.maxReleased; {
+		if len(p.pending[priority]) == 0 {
+			priority--
+
+			continue
+		}
+
+		name := p.pending[priority][0]
+		p.pending[priority] = p.pending[priority][1:]
+		delete(p.pendingPriority, name)
+
+		p.released[name] = true
+		p.queue.Add(reconcile.Request{NamespacedName: name})
+	}
+}
+
+// removePending removes the pending root policy from the pending ones of the priority. The lock
+// must be held.
+func (p *priorityQueue) removePending(name types.NamespacedName, priority propagationPriority) {
+	for i, pending := range p.pending[priority] {
+		if pending == name {
+			p.pending[priority] = append(p.pending[priority][:i], p.pending[priority][i+1:]...)
+
+			break
+		}
+	}
+
+	delete(p.pendingPriority, name)
+}
+
+// prioritized wraps the event handler so that the root policies it enqueues are released to the
+// controller queue by priority
+func (r *PolicyReconciler) prioritized(h handler.EventHandler) handler.EventHandler {
+	return &priorityHandler{EventHandler: h, priorities: r.priorities}
+}
+
+type priorityHandler struct {
+	handler.EventHandler
+	priorities *priorityQueue
+}
+
+// Create implements EventHandler
+func (h *priorityHandler) Create(evt event.CreateEvent, q workqueue.RateLimitingInterface) {
+	h.EventHandler.Create(evt, &priorityEnqueuer{RateLimitingInterface: q, priorities: h.priorities})
+}
+
+// Update implements EventHandler
+func (h *priorityHandler) Update(evt event.UpdateEvent, q workqueue.RateLimitingInterface) {
+	h.EventHandler.Update(evt, &priorityEnqueuer{RateLimitingInterface: q, priorities: h.priorities})
+}
+
+// Delete implements EventHandler
+func (h *priorityHandler) Delete(evt event.DeleteEvent, q workqueue.RateLimitingInterface) {
+	h.EventHandler.Delete(evt, &priorityEnqueuer{RateLimitingInterface: q, priorities: h.priorities})
+}
+
+// Generic implements EventHandler
+func (h *priorityHandler) Generic(evt event.GenericEvent, q workqueue.RateLimitingInterface) {
+	h.EventHandler.Generic(evt, &priorityEnqueuer{RateLimitingInterface: q, priorities: h.priorities})
+}
+
+// priorityEnqueuer holds the root policies added to the controller queue in the priority queue. The
+// delayed and rate limited additions are left to the controller queue.
+type priorityEnqueuer struct {
+	workqueue.RateLimitingInterface
+	priorities *priorityQueue
+}
+
+func (q *priorityEnqueuer) Add(item interface{}) {
+	req, ok := item.(reconcile.Request)
+	if !ok || q.priorities == nil {
+		q.RateLimitingInterface.Add(item)
+
+		return
+	}
+
+	q.priorities.add(q.RateLimitingInterface, req.NamespacedName)
+}
+
+func (q *priorityEnqueuer) AddAfter(item interface{}, duration time.Duration) {
+	if duration <= 0 {
+		q.Add(item)
+
+		return
+	}
+
+	q.RateLimitingInterface.AddAfter(item, duration)
+}
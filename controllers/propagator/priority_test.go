@@ -0,0 +1,171 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+)
+
+func TestPolicyPriority(t *testing.T) {
+	tests := map[string]propagationPriority{
+		"high":    priorityHigh,
+		" HIGH ":  priorityHigh,
+		"low":     priorityLow,
+		"default": priorityDefault,
+		"urgent":  priorityDefault,
+		"":        priorityDefault,
+	}
+
+	for value, expected := range tests {
+		root := newTestPolicy("default")
+		root.SetAnnotations(map[string]string{priorityAnnotation: value})
+
+		if priority := policyPriority(root); priority != expected {
+			t.Fatalf("expected the priority %d for %q, got %d", expected, value, priority)
+		}
+	}
+
+	if priority := policyPriority(newTestPolicy("default")); priority != priorityDefault {
+		t.Fatalf("expected the default priority without the annotation, got %d", priority)
+	}
+}
+
+func newPriorityTestPolicy(name, priority string) *policiesv1.Policy {
+	root := newTestPolicy("default")
+	root.SetName(name)
+
+	if priority != "" {
+		root.SetAnnotations(map[string]string{priorityAnnotation: priority})
+	}
+
+	return root
+}
+
+func TestPrioritizedHandler(t *testing.T) {
+	roots := []*policiesv1.Policy{
+		newPriorityTestPolicy("first", ""),
+		newPriorityTestPolicy("low", "low"),
+		newPriorityTestPolicy("default", ""),
+		newPriorityTestPolicy("high", "high"),
+	}
+
+	r := newTestReconciler(t, &stubResolver{}, roots[0], roots[1], roots[2], roots[3])
+	h := r.fullReconcile(&handler.EnqueueRequestForObject{})
+
+	q := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	defer q.ShutDown()
+
+	// Enqueue them all, the low priority one twice, and a deleted one which has the default priority
+	for _, root := range append(roots, roots[1], newPriorityTestPolicy("deleted", "high")) {
+		h.Generic(event.GenericEvent{Object: root}, q)
+	}
+
+	// Only as many root policies as the number of concurrent reconciles are in the controller queue
+	if q.Len() != 1 {
+		t.Fatalf("expected a single root policy in the controller queue, got %d", q.Len())
+	}
+
+	order := []string{}
+
+	for q.Len() > 0 {
+		item, _ := q.Get()
+		name := item.(reconcile.Request).NamespacedName
+
+		r.priorities.started(name)
+		q.Done(item)
+
+		order = append(order, name.Name)
+	}
+
+	expected := []string{"first", "high", "default", "deleted", "low"}
+	if len(order) != len(expected) {
+		t.Fatalf("expected the root policies %v to be released, got %v", expected, order)
+	}
+
+	for i := range expected {
+		if order[i] != expected[i] {
+			t.Fatalf("expected the root policies to be released in the order %v, got %v", expected, order)
+		}
+	}
+
+}
+
+func TestPriorityQueueUpgrade(t *testing.T) {
+	priorities := map[string]propagationPriority{"blocker": priorityDefault, "other": priorityDefault}
+	p := newPriorityQueue(0, func(name types.NamespacedName) propagationPriority {
+		return priorities[name.Name]
+	})
+
+	q := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	defer q.ShutDown()
+
+	blocker := types.NamespacedName{Namespace: "policies", Name: "blocker"}
+	other := types.NamespacedName{Namespace: "policies", Name: "other"}
+	upgraded := types.NamespacedName{Namespace: "policies", Name: "upgraded"}
+
+	p.add(q, blocker)
+	p.add(q, other)
+	priorities["upgraded"] = priorityLow
+	p.add(q, upgraded)
+
+	// The root policy is upgraded when its priority was changed while it was pending
+	priorities["upgraded"] = priorityHigh
+	p.add(q, upgraded)
+
+	// The released root policy is not added again before it is reconciled
+	p.add(q, blocker)
+
+	if q.Len() != 1 {
+		t.Fatalf("expected the released root policy to be in the controller queue once, got %d", q.Len())
+	}
+
+	p.started(blocker)
+
+	item, _ := q.Get()
+	if item.(reconcile.Request).NamespacedName != blocker {
+		t.Fatalf("expected the blocker root policy first, got %v", item)
+	}
+
+	q.Done(item)
+
+	item, _ = q.Get()
+	if item.(reconcile.Request).NamespacedName != upgraded {
+		t.Fatalf("expected the upgraded root policy to be released next, got %v", item)
+	}
+
+	q.Done(item)
+
+	if len(p.pending[priorityDefault]) != 1 || len(p.pending[priorityLow]) != 0 {
+		t.Fatalf("expected only the other root policy to be pending, got %v", p.pending)
+	}
+}
+
+func TestPriorityOf(t *testing.T) {
+	root := newPriorityTestPolicy("policy", "low")
+	r := newTestReconciler(t, &stubResolver{}, root)
+
+	name := types.NamespacedName{Namespace: "policies", Name: "policy"}
+
+	if priority := r.priorityOf(name); priority != priorityLow {
+		t.Fatalf("expected the priority of the root policy, got %d", priority)
+	}
+
+	root.SetAnnotations(map[string]string{priorityAnnotation: "high"})
+
+	if err := r.Update(context.TODO(), root); err != nil {
+		t.Fatalf("failed to update the root policy: %v", err)
+	}
+
+	if priority := r.priorityOf(name); priority != priorityHigh {
+		t.Fatalf("expected the updated priority of the root policy, got %d", priority)
+	}
+}
@@ -7,10 +7,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"os"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	retry "github.com/avast/retry-go/v3"
@@ -18,71 +18,89 @@ import (
 	clusterv1alpha1 "github.com/open-cluster-management/api/cluster/v1alpha1"
 	templates "github.com/open-cluster-management/go-template-utils/pkg/templates"
 	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	policyv1beta1 "github.com/open-cluster-management/governance-policy-propagator/api/v1beta1"
 	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
 	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/json"
-	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-const attemptsDefault = 3
-const attemptsEnvName = "CONTROLLER_CONFIG_RETRY_ATTEMPTS"
-
-// The configuration in minutes to requeue after if something failed after several
-// retries.
-const requeueErrorDelayEnvName = "CONTROLLER_CONFIG_REQUEUE_ERROR_DELAY"
-const requeueErrorDelayDefault = 5
-
-var attempts int
-var requeueErrorDelay int
-var kubeConfig *rest.Config
-var kubeClient *kubernetes.Interface
-var templateCfg templates.Config
-
-func Initialize(kubeconfig *rest.Config, kubeclient *kubernetes.Interface) {
-	kubeConfig = kubeconfig
-	kubeClient = kubeclient
-	// Adding four spaces to the indentation makes the usage of `indent N` be from the logical
-	// starting point of the resource object wrapped in the ConfigurationPolicy.
-	templateCfg = templates.Config{
-		AdditionalIndentation: 8,
-		DisabledFunctions:     []string{"fromSecret"},
-		StartDelim:            "{{hub", StopDelim: "hub}}",
-	}
-
-	attempts = getEnvVarPosInt(attemptsEnvName, attemptsDefault)
-	requeueErrorDelay = getEnvVarPosInt(requeueErrorDelayEnvName, requeueErrorDelayDefault)
+// The reasons set in the root policy status for the clusters the policy could not be replicated to
+const (
+	reasonNamespaceDenied   = "NamespaceDenied"
+	reasonPropagationDenied = "PropagationDenied"
+	reasonReplicationFailed = "ReplicationFailed"
+	reasonCircuitOpen       = "CircuitOpen"
+	// reasonFanOutLimitExceeded is set for the clusters past the maxClusters of the
+	// PropagationConfig of the namespace
+	reasonFanOutLimitExceeded = "FanOutLimitExceeded"
+	// reasonClusterIncompatible is not a failure. The cluster doesn't meet the policy's cluster
+	// requirements, so the policy is not replicated to it and it has no compliance state.
+	reasonClusterIncompatible = "ClusterIncompatible"
+	// reasonDependenciesPending is not a failure either. The policy is replicated to the cluster
+	// once its dependencies are met on it, and it is Pending until then.
+	reasonDependenciesPending = "DependenciesPending"
+	// reasonDryRun is not a failure either. The root policy is in dry run, so the replicated policy
+	// is not written to the cluster.
+	reasonDryRun = "DryRun"
+	// reasonPendingUpdate is not a failure either. The replicated policy is out of date until the
+	// maintenance window of the root policy opens, but it still reports its compliance.
+	reasonPendingUpdate = "PendingUpdate"
+	// reasonRemovedAfterCompliant is not a failure either. The cluster remained compliant for the
+	// removeAfterCompliant duration of the root policy, so the policy was removed from it.
+	reasonRemovedAfterCompliant = "RemovedAfterCompliant"
+	// reasonTemplateResolverFailed is set for the clusters whose hub templates could not be resolved
+	// since the template resolver could not be created. The policy is replicated with the error on
+	// its policy templates and the replication to the cluster is retried later.
+	reasonTemplateResolverFailed = "TemplateResolverFailed"
+	// reasonNameCollision is set for the clusters whose namespace already has a policy with the name
+	// of the replicated policy that belongs to another root policy. It is not overwritten.
+	reasonNameCollision = "NameCollision"
+	// reasonClusterUnavailable is not a failure. The ManagedCluster of the root policy with
+	// skipUnavailableClusters is not available, so the replication to it is paused and it is
+	// Pending until it is available again.
+	reasonClusterUnavailable = "ClusterUnavailable"
+)
+
+// replicationFailure is why a policy could not be replicated to a cluster, surfaced in the root
+// policy status
+type replicationFailure struct {
+	reason  string
+	message string
 }
 
-func getEnvVarPosInt(name string, defaultValue int) int {
-	var envValue = os.Getenv(name)
-	if envValue == "" {
-		return defaultValue
-	}
+// isFailure returns whether the policy could not be replicated to the cluster, as opposed to
+// being skipped on purpose
+func (f replicationFailure) isFailure() bool {
+	return f.reason != reasonClusterIncompatible && f.reason != reasonDependenciesPending &&
+		f.reason != reasonDryRun && f.reason != reasonPendingUpdate && f.reason != reasonRemovedAfterCompliant &&
+		f.reason != reasonClusterUnavailable
+}
 
-	envInt, err := strconv.Atoi(envValue)
-	if err == nil && envInt > 0 {
-		return envInt
+// hasReplicationFailures returns whether any of the clusters failed, ignoring the clusters that
+// were skipped on purpose
+func hasReplicationFailures(failedClusters map[string]replicationFailure) bool {
+	for _, failure := range failedClusters {
+		if failure.isFailure() {
+			return true
+		}
 	}
 
-	log.Info(
-		fmt.Sprintf(
-			"The %s environment variable is invalid. Using default.", name,
-		),
-	)
-	return defaultValue
+	return false
 }
 
 // The options to call retry.Do with
-func getRetryOptions(logger logr.Logger, retryMsg string) []retry.Option {
+func (r *PolicyReconciler) getRetryOptions(ctx context.Context, logger logr.Logger, retryMsg string) []retry.Option {
 	return []retry.Option{
-		retry.Attempts(uint(attempts)),
-		retry.Delay(2 * time.Second),
+		retry.Context(ctx),
+		retry.Attempts(uint(r.retryAttempts)),
+		retry.Delay(r.retryDelay),
 		retry.MaxDelay(10 * time.Second),
 		retry.OnRetry(func(n uint, err error) { logger.Info(retryMsg) }),
 		retry.LastErrorOnly(true),
@@ -90,13 +108,13 @@ func getRetryOptions(logger logr.Logger, retryMsg string) []retry.Option {
 }
 
 // cleanUpPolicy will delete all replicated policies associated with provided policy.
-func (r *PolicyReconciler) cleanUpPolicy(instance *policiesv1.Policy) error {
+func (r *PolicyReconciler) cleanUpPolicy(ctx context.Context, instance *policiesv1.Policy) error {
 	reqLogger := log.WithValues("Policy-Namespace", instance.GetNamespace(), "Policy-Name", instance.GetName())
 	successful := true
 	replicatedPlcList := &policiesv1.PolicyList{}
 
 	err := r.List(
-		context.TODO(), replicatedPlcList, client.MatchingLabels(common.LabelsForRootPolicy(instance)),
+		ctx, replicatedPlcList, client.MatchingLabels(common.LabelsForRootPolicy(instance)),
 	)
 	if err != nil {
 		reqLogger.Error(err, "Failed to list the replicated policies...")
@@ -104,13 +122,28 @@ func (r *PolicyReconciler) cleanUpPolicy(instance *policiesv1.Policy) error {
 	}
 
 	for _, plc := range replicatedPlcList.Items {
+		if r.namespaceTerminating(ctx, plc.GetNamespace()) {
+			key := types.NamespacedName{Namespace: plc.GetNamespace(), Name: plc.GetName()}
+			if r.pruneTerminatingReplicatedPolicy(ctx, key) {
+				// #nosec G601 -- no memory addresses are stored in collections
+				r.recordClusterNamespaceEvent(&plc, instance.GetNamespace()+"/"+instance.GetName(), replicatedPolicyDeleted)
+			}
+
+			continue
+		}
+
 		// #nosec G601 -- no memory addresses are stored in collections
-		err := r.Delete(context.TODO(), &plc)
+		err := r.Delete(ctx, &plc)
 		if err != nil && !k8serrors.IsNotFound(err) {
 			reqLogger.Error(err, "Failed to delete replicated policy...", "Namespace", plc.GetNamespace(),
 				"Name", plc.GetName())
 			successful = false
+
+			continue
 		}
+
+		// #nosec G601 -- no memory addresses are stored in collections
+		r.recordClusterNamespaceEvent(&plc, instance.GetNamespace()+"/"+instance.GetName(), replicatedPolicyDeleted)
 	}
 
 	if !successful {
@@ -121,80 +154,463 @@ func (r *PolicyReconciler) cleanUpPolicy(instance *policiesv1.Policy) error {
 }
 
 // handleDecisions will get all the placement decisions based on the input policy and placement
-// binding list and propagate the policy. It returns the following:
-// * placements - a slice of all the placement decisions discovered
-// * allDecisions - a set of all the placement decisions encountered in the format of
-//   <namespace>/<name>
-// * failedClusters - a set of all the clusters that encountered an error during propagation in the
-//   format of <namespace>/<name>
-// * allFailed - a bool that determines if all clusters encountered an error during propagation
+// binding list and propagate the policy. When changed isn't nil, the policy is only propagated to
+// the clusters in it. It returns the following:
+//   - placements - a slice of all the placement decisions discovered
+//   - allDecisions - a set of all the placement decisions encountered in the format of
+//     <namespace>/<name>
+//   - failedClusters - a map of all the clusters that encountered an error during propagation in the
+//     format of <namespace>/<name> to the reason and message to surface in the status
+//   - allFailed - a bool that determines if all clusters encountered an error during propagation
+//   - templateErrors - a map of the clusters whose hub templates failed to resolve in the format of
+//     <namespace>/<name> to the error
+//   - rollout - the progress of the rollout when the policy is rolled out progressively
 func (r *PolicyReconciler) handleDecisions(
-	instance *policiesv1.Policy, pbList *policiesv1.PlacementBindingList,
+	ctx context.Context, instance *policiesv1.Policy, pbList *policiesv1.PlacementBindingList,
+	cfg policyv1beta1.PropagationConfigSpec, clusterSelector labels.Selector, changed map[string]bool,
 ) (
-	placements []*policiesv1.Placement, allDecisions map[string]bool, failedClusters map[string]bool, allFailed bool,
+	placements []*policiesv1.Placement, allDecisions map[string]bool,
+	failedClusters map[string]replicationFailure, allFailed bool,
+	templateErrors map[string]string, rollout *policiesv1.RolloutStatus,
 ) {
 	reqLogger := log.WithValues("Policy-Namespace", instance.GetNamespace(), "Policy-Name", instance.GetName())
 	allDecisions = map[string]bool{}
-	failedClusters = map[string]bool{}
+	failedClusters = map[string]replicationFailure{}
+	templateErrors = map[string]string{}
 
-	for _, pb := range pbList.Items {
-		subjects := pb.Subjects
-		for _, subject := range subjects {
-			if !(subject.APIGroup == policiesv1.SchemeGroupVersion.Group &&
-				subject.Kind == policiesv1.Kind &&
-				subject.Name == instance.GetName()) {
+	placements, selected, err := r.placementDecisions(ctx, instance, pbList)
+	if err != nil {
+		reqLogger.Info("Giving up on getting the placement decisions...")
+		allFailed = true
+		return
+	}
 
-				continue
-			}
+	// The clusters the policy may be replicated to
+	eligible := []appsv1.PlacementDecision{}
+
+	for _, decision := range selected {
+		decision, err := r.hostedClusterDecision(ctx, decision)
+		key := fmt.Sprintf("%s/%s", decision.ClusterNamespace, decision.ClusterName)
 
-			var decisions []appsv1.PlacementDecision
-			var p *policiesv1.Placement
-			err := retry.Do(
-				func() error {
-					var err error
-					decisions, p, err = getPlacementDecisions(r.Client, pb, instance)
-					return err
-				},
-				getRetryOptions(reqLogger, "Retrying to get the placement decisions...")...,
+		if err != nil {
+			reqLogger.Error(err, "Failed to get the cluster namespace...", "Cluster", decision.ClusterName)
+			allDecisions[key] = true
+			failedClusters[key] = replicationFailure{reason: reasonReplicationFailed}
+
+			continue
+		}
+
+		// The clusters that don't match the policy's cluster selector are handled as if they weren't
+		// selected, so an existing replicated policy is cleaned up as an orphan
+		matches, err := r.clusterSelected(ctx, clusterSelector, decision.ClusterName)
+		if err != nil {
+			reqLogger.Error(err, "Failed to check the cluster selector...", "Cluster", decision.ClusterName)
+			allDecisions[key] = true
+			failedClusters[key] = replicationFailure{reason: reasonReplicationFailed}
+
+			continue
+		}
+
+		if !matches {
+			reqLogger.V(1).Info("The cluster doesn't match the cluster selector, skipping the replication...",
+				"Cluster", decision.ClusterName)
+
+			continue
+		}
+
+		// Don't add the decision to allDecisions so that an existing replicated policy in
+		// the namespace is cleaned up as an orphan
+		if r.namespaceDenied(decision.ClusterNamespace) {
+			msg := fmt.Sprintf(
+				"Policy %s/%s was not propagated to cluster %s/%s since the namespace is denied "+
+					"by the namespace denylist",
+				instance.GetNamespace(), instance.GetName(), decision.ClusterNamespace, decision.ClusterName,
 			)
+			reqLogger.Info("The cluster namespace is denied, skipping the replication...",
+				"Namespace", decision.ClusterNamespace)
+			r.recordClusterEvent(instance, "Warning", clusterFailed, decision.ClusterName, msg)
+			failedClusters[key] = replicationFailure{reason: reasonNamespaceDenied, message: msg}
 
-			if err != nil {
-				reqLogger.Info("Giving up on getting the placement decisions...")
-				allFailed = true
-				return
+			continue
+		}
+
+		// Like a denied namespace, an existing replicated policy on an incompatible cluster is
+		// cleaned up as an orphan
+		incompatibility, err := r.clusterIncompatibility(
+			ctx, instance.Spec.ClusterRequirements, decision.ClusterName,
+		)
+		if err != nil {
+			reqLogger.Error(err, "Failed to check the cluster requirements...", "Cluster", decision.ClusterName)
+			allDecisions[key] = true
+			failedClusters[key] = replicationFailure{reason: reasonReplicationFailed}
+
+			continue
+		}
+
+		if incompatibility != "" {
+			reqLogger.Info("The cluster doesn't meet the cluster requirements, skipping the replication...",
+				"Cluster", decision.ClusterName, "Reason", incompatibility)
+			failedClusters[key] = replicationFailure{
+				reason: reasonClusterIncompatible, message: incompatibility,
 			}
 
-			placements = append(placements, p)
-			if instance.Spec.Disabled {
-				// Only handle the first match in pb.spec.subjects
-				break
+			continue
+		}
+
+		// The existing replicated policy on an unavailable cluster is kept, but it is not updated
+		// until the cluster is available again
+		unavailable, err := r.clusterUnavailable(ctx, instance, decision.ClusterName)
+		if err != nil {
+			reqLogger.Error(err, "Failed to check the availability of the cluster...", "Cluster", decision.ClusterName)
+			allDecisions[key] = true
+			failedClusters[key] = replicationFailure{reason: reasonReplicationFailed}
+
+			continue
+		}
+
+		if unavailable != nil {
+			reqLogger.V(1).Info("The cluster is unavailable, skipping the replication...",
+				"Cluster", decision.ClusterName)
+			allDecisions[key] = true
+			failedClusters[key] = *unavailable
+
+			continue
+		}
+
+		// Like a denied namespace, the replicated policy of a cluster that remained compliant for
+		// long enough is cleaned up as an orphan
+		if removal := r.complianceRemoval(instance, key); removal != nil {
+			reqLogger.V(1).Info("The cluster remained compliant, skipping the replication...",
+				"Cluster", decision.ClusterName)
+			failedClusters[key] = *removal
+
+			continue
+		}
+
+		// Like a denied namespace, an existing replicated policy past the fan-out limit of the
+		// PropagationConfig is cleaned up as an orphan
+		if cfg.MaxClusters > 0 && !allDecisions[key] && len(allDecisions) >= cfg.MaxClusters {
+			reqLogger.Info("The policy reached the maximum number of clusters, skipping the replication...",
+				"Cluster", decision.ClusterName, "MaxClusters", cfg.MaxClusters)
+			failedClusters[key] = replicationFailure{
+				reason: reasonFanOutLimitExceeded,
+				message: fmt.Sprintf(
+					"The policy was not propagated since it reached the maximum of %d clusters set by "+
+						"the PropagationConfig of the namespace", cfg.MaxClusters,
+				),
 			}
-			// Only handle replicated policies when the policy is not disabled
-			// plr found, checking decision
-			for _, decision := range decisions {
-				key := fmt.Sprintf("%s/%s", decision.ClusterNamespace, decision.ClusterName)
-				allDecisions[key] = true
-				// create/update replicated policy for each decision
-				err := retry.Do(
-					func() error {
-						return r.handleDecision(instance, decision)
-					},
-					getRetryOptions(reqLogger, "Retrying to replicate the policy...")...,
-				)
 
-				if err != nil {
-					reqLogger.Info(
-						fmt.Sprintf(
-							"Giving up on replicating the policy %s/%s...",
-							decision.ClusterNamespace,
-							common.FullNameForPolicy(instance),
-						),
-					)
-					failedClusters[key] = true
-				}
+			continue
+		}
+
+		allDecisions[key] = true
+		eligible = append(eligible, decision)
+	}
+
+	var rolledOut map[string]bool
+
+	if common.FeatureEnabled(common.RolloutStrategies) {
+		rolloutStrategy := instance.Spec.RolloutStrategy
+		if rolloutStrategy == nil {
+			rolloutStrategy = cfg.RolloutStrategy
+		}
+
+		rolledOut, rollout = rolloutClusters(rolloutStrategy, placements, eligible, instance.Status.Status)
+	}
+
+	// The replication to all the clusters is skipped when the root policy, its PropagationConfig, the
+	// enforcement lock of its namespace, and its clusters and their remediation overrides didn't
+	// change since it was last replicated to all of them
+	hash, err := r.rootPropagationHash(ctx, instance, cfg, eligible)
+	if err != nil {
+		reqLogger.Error(err, "Failed to hash the policy, replicating it to all the clusters...")
+	}
+
+	skipped := changed == nil && r.replicationSkippable(instance, hash)
+	if skipped {
+		reqLogger.V(1).Info("The policy didn't change since it was replicated, skipping the replication...")
+	}
+
+	// The clusters the policy is replicated to in this reconcile
+	replicate := []appsv1.PlacementDecision{}
+
+	for _, decision := range eligible {
+		key := fmt.Sprintf("%s/%s", decision.ClusterNamespace, decision.ClusterName)
+
+		// The clusters of the next waves of the rollout keep their existing replicated policy
+		if rolledOut != nil && !rolledOut[key] {
+			continue
+		}
+
+		// The clusters whose placement decisions didn't change keep their existing replicated
+		// policy and status
+		if skipped || (changed != nil && !changed[decision.ClusterName]) {
+			keepClusterStatus(instance, key, failedClusters, templateErrors)
+
+			continue
+		}
+
+		replicate = append(replicate, decision)
+	}
+
+	// The results of the concurrent replications are collected with the lock
+	var lock sync.Mutex
+
+	r.forEachDecision(replicate, func(decision appsv1.PlacementDecision) {
+		failure, templateErr := r.replicateDecision(ctx, instance, decision, cfg)
+
+		lock.Lock()
+		defer lock.Unlock()
+
+		if templateErr != nil {
+			templateErrors[fmt.Sprintf("%s/%s", decision.ClusterNamespace, decision.ClusterName)] = templateErr.Error()
+		}
+
+		if failure != nil {
+			failedClusters[fmt.Sprintf("%s/%s", decision.ClusterNamespace, decision.ClusterName)] = *failure
+		}
+	})
+
+	// Report the failed clusters once instead of for every worker
+	failed := []string{}
+
+	for key, failure := range failedClusters {
+		if failure.isFailure() {
+			failed = append(failed, key)
+		}
+	}
+
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		reqLogger.Info("Failed to replicate the policy to some clusters...", "Clusters", failed)
+	}
+
+	// The hash is only saved once the policy was replicated to all of its clusters without errors
+	if hash != "" && changed == nil && replicationComplete(failedClusters, templateErrors, rollout) {
+		setPropagationHash(instance, hash)
+	}
+
+	return
+}
+
+// placementDecisions returns the placements of the PlacementBindings of the root policy, and the
+// decisions of the placements that aren't restricted, merged so that every cluster is selected once.
+// The decision groups of the restricted placements are restricted to the selected clusters. There
+// are no decisions when the root policy is disabled.
+func (r *PolicyReconciler) placementDecisions(
+	ctx context.Context, instance *policiesv1.Policy, pbList *policiesv1.PlacementBindingList,
+) ([]*policiesv1.Placement, []appsv1.PlacementDecision, error) {
+	reqLogger := log.WithValues("Policy-Namespace", instance.GetNamespace(), "Policy-Name", instance.GetName())
+	placements := []*policiesv1.Placement{}
+	// The decisions of all the placements of the policy
+	selected := []appsv1.PlacementDecision{}
+	// The placements of the restricted bindings, which only apply to the clusters selected by the
+	// other bindings
+	restricted := []*policiesv1.Placement{}
+
+	// The placement decisions of a PlacementBinding are only handled once, even when several of its
+	// subjects match the policy
+	for _, pb := range pbList.Items {
+		if !bindsPolicy(pb, instance) {
+			continue
+		}
+
+		var decisions []appsv1.PlacementDecision
+		var p *policiesv1.Placement
+		err := retry.Do(
+			func() error {
+				var err error
+				decisions, p, err = r.getPlacementDecisions(ctx, pb, instance)
+				return err
+			},
+			r.getRetryOptions(ctx, reqLogger, "Retrying to get the placement decisions...")...,
+		)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		placements = append(placements, p)
+
+		if p.Message != "" {
+			reqLogger.Info("The placement of the PlacementBinding is not fully resolved...",
+				"PlacementBinding", pb.GetName(), "Reason", p.Message)
+			r.recordWarning(instance, p.Message)
+		}
+
+		if pb.SubFilter == policiesv1.Restricted {
+			restricted = append(restricted, p)
+
+			continue
+		}
+
+		// Only handle replicated policies when the policy is not disabled
+		if !instance.Spec.Disabled {
+			selected = append(selected, decisions...)
+		}
+	}
+
+	// The decisions of the placements are merged, so that the policy is replicated once to every
+	// cluster selected by any of them
+	selected = uniqueDecisions(selected)
+	// The decisions of the restricted bindings are intersected with them
+	restrictDecisionGroups(restricted, selected)
+
+	return placements, selected, nil
+}
+
+// replicateDecision creates or updates the replicated policy for the placement decision. It
+// returns why the policy could not be replicated to the cluster, if it failed, and the error of
+// the hub templates that failed to resolve. It is safe to call concurrently.
+func (r *PolicyReconciler) replicateDecision(
+	ctx context.Context, instance *policiesv1.Policy, decision appsv1.PlacementDecision,
+	cfg policyv1beta1.PropagationConfigSpec,
+) (failure *replicationFailure, templateErr error) {
+	reqLogger := log.WithValues("Policy-Namespace", instance.GetNamespace(), "Policy-Name", instance.GetName())
+	key := fmt.Sprintf("%s/%s", decision.ClusterNamespace, decision.ClusterName)
+
+	failure, err := r.dependenciesPending(ctx, instance, decision.ClusterNamespace)
+	if err != nil {
+		reqLogger.Error(err, "Failed to check the dependencies of the policy...", "Cluster", decision.ClusterName)
+
+		return &replicationFailure{reason: reasonReplicationFailed}, nil
+	}
+
+	if failure != nil {
+		reqLogger.V(1).Info("The dependencies of the policy are not met, skipping the replication...",
+			"Cluster", decision.ClusterName, "Reason", failure.message)
+
+		return failure, nil
+	}
+
+	// Skip the clusters whose replications keep failing so that they don't use up the
+	// retries and delay the replication to the healthy clusters. The existing replicated
+	// policy is left as is.
+	openUntil := r.clusterCircuits.openUntil(decision.ClusterNamespace, r.clock.Now())
+	if !openUntil.IsZero() {
+		failure = &replicationFailure{
+			reason: reasonCircuitOpen,
+			message: fmt.Sprintf(
+				"The replication to the cluster namespace %s is paused until %s after repeated failures",
+				decision.ClusterNamespace, openUntil.UTC().Format(time.RFC3339),
+			),
+		}
+
+		return
+	}
+
+	retryOptions := r.getRetryOptions(ctx, reqLogger, "Retrying to replicate the policy...")
+	if r.clusterCircuits.halfOpen(decision.ClusterNamespace) {
+		// Only try once after the cool-down since the cluster namespace was failing
+		retryOptions = append(retryOptions, retry.Attempts(1))
+	}
+
+	// create/update replicated policy for each decision
+	err = retry.Do(
+		func() error {
+			var err error
+			templateErr, err = r.handleDecision(ctx, instance, decision, cfg)
+			deniedErr := &propagationDeniedError{}
+			dryRun := &dryRunResult{}
+			pendingErr := &updatePendingError{}
+			collisionErr := &nameCollisionError{}
+			if errors.As(err, &deniedErr) || errors.As(err, &dryRun) || errors.As(err, &pendingErr) ||
+				errors.As(err, &collisionErr) || isNamespaceTerminatingError(err) {
+				return retry.Unrecoverable(err)
+			}
+			return err
+		},
+		retryOptions...,
+	)
+
+	// Nothing was written to the cluster namespace
+	dryRun := &dryRunResult{}
+	if errors.As(err, &dryRun) {
+		return r.recordDryRun(instance, decision, dryRun), templateErr
+	}
+
+	pendingErr := &updatePendingError{}
+	if errors.As(err, &pendingErr) {
+		reqLogger.V(1).Info("The maintenance window of the policy is closed, skipping the update...",
+			"Cluster", decision.ClusterName, "Opens", pendingErr.opens)
+
+		return &replicationFailure{
+			reason: reasonPendingUpdate,
+			message: fmt.Sprintf("The replicated policy update is pending until the maintenance window opens at %s",
+				pendingErr.opens.UTC().Format(time.RFC3339)),
+		}, templateErr
+	}
+
+	r.propagationState.recordAttempt(
+		types.NamespacedName{Namespace: instance.GetNamespace(), Name: instance.GetName()}.String(),
+		key, r.clock.Now(), err,
+	)
+
+	// The policy of the other root policy is left as is, and it's not the cluster namespace's fault
+	collisionErr := &nameCollisionError{}
+	if errors.As(err, &collisionErr) {
+		reqLogger.Info("The replicated policy name collides with another policy, skipping the replication...",
+			"Namespace", collisionErr.namespace, "Name", collisionErr.name, "Owner", collisionErr.owner)
+		r.recordClusterEvent(instance, "Warning", clusterFailed, decision.ClusterName,
+			fmt.Sprintf("Policy %s/%s was not propagated to cluster %s/%s since %s",
+				instance.GetNamespace(), instance.GetName(), decision.ClusterNamespace,
+				decision.ClusterName, collisionErr.Error()))
+
+		return &replicationFailure{
+			reason: reasonNameCollision, message: "The replicated policy was not propagated since " + collisionErr.Error(),
+		}, nil
+	}
+
+	deniedErr := &propagationDeniedError{}
+	if errors.As(err, &deniedErr) {
+		reqLogger.Info(
+			fmt.Sprintf(
+				"The admission hook denied replicating the policy %s/%s...",
+				decision.ClusterNamespace,
+				common.FullNameForPolicy(instance),
+			),
+			"Reason", deniedErr.message,
+		)
+		r.recordClusterEvent(instance, "Warning", clusterFailed, decision.ClusterName,
+			fmt.Sprintf("Policy %s/%s was denied propagation to cluster %s/%s: %s",
+				instance.GetNamespace(), instance.GetName(), decision.ClusterNamespace,
+				decision.ClusterName, deniedErr.message))
+		failure = &replicationFailure{
+			reason: reasonPropagationDenied, message: deniedErr.Error(),
+		}
+	} else if err != nil {
+		reqLogger.Info(
+			fmt.Sprintf(
+				"Giving up on replicating the policy %s/%s...",
+				decision.ClusterNamespace,
+				common.FullNameForPolicy(instance),
+			),
+		)
+		failure = &replicationFailure{reason: reasonReplicationFailed}
+
+		// A canceled reconcile is not the cluster namespace's fault
+		if ctx.Err() != nil {
+			return
+		}
+
+		openUntil = r.clusterCircuits.recordFailure(decision.ClusterNamespace, r.clock.Now())
+		if !openUntil.IsZero() {
+			reqLogger.Info(
+				"Pausing the replication to the cluster namespace after repeated failures...",
+				"Namespace", decision.ClusterNamespace, "Until", openUntil,
+			)
+			r.Recorder.Event(instance, "Warning", "PolicyPropagation",
+				fmt.Sprintf("The replication to the cluster namespace %s is paused until %s after "+
+					"repeated failures", decision.ClusterNamespace, openUntil.UTC().Format(time.RFC3339)))
+		}
+	} else {
+		r.clusterCircuits.recordSuccess(decision.ClusterNamespace)
+
+		resolverErr := &templateResolverError{}
+		if errors.As(templateErr, &resolverErr) {
+			failure = &replicationFailure{
+				reason: reasonTemplateResolverFailed, message: templateErrorMessage(templateErr.Error()),
 			}
-			// Only handle the first match in pb.spec.subjects
-			break
 		}
 	}
 
@@ -204,7 +620,7 @@ func (r *PolicyReconciler) handleDecisions(
 // cleanUpOrphanedRplPolicies compares the status of the input policy against the input placement
 // decisions. If the cluster exists in the status but doesn't exist in the input placement
 // decisions, then it's considered stale and will be removed.
-func (r *PolicyReconciler) cleanUpOrphanedRplPolicies(instance *policiesv1.Policy, allDecisions map[string]bool) error {
+func (r *PolicyReconciler) cleanUpOrphanedRplPolicies(ctx context.Context, instance *policiesv1.Policy, allDecisions map[string]bool) error {
 	reqLogger := log.WithValues("Policy-Namespace", instance.GetNamespace(), "Policy-Name", instance.GetName())
 	successful := true
 	for _, cluster := range instance.Status.Status {
@@ -212,8 +628,35 @@ func (r *PolicyReconciler) cleanUpOrphanedRplPolicies(instance *policiesv1.Polic
 		if allDecisions[key] {
 			continue
 		}
+
+		// The policy with the name of the replicated policy belongs to another root policy
+		if cluster.Reason == reasonNameCollision {
+			continue
+		}
 		// not found in allDecisions, orphan, delete it
 		name := common.FullNameForPolicy(instance)
+		if isDryRun(instance) {
+			r.Recorder.Event(instance, "Normal", "PolicyPropagation",
+				fmt.Sprintf("Policy %s/%s would be removed from cluster %s/%s (dry run)", instance.GetNamespace(),
+					instance.GetName(), cluster.ClusterNamespace, cluster.ClusterName))
+
+			continue
+		}
+
+		// The replicated policy is deleted along with its terminating cluster namespace, so it is only
+		// deleted once
+		if r.namespaceTerminating(ctx, cluster.ClusterNamespace) {
+			key := types.NamespacedName{Namespace: cluster.ClusterNamespace, Name: name}
+			if r.pruneTerminatingReplicatedPolicy(ctx, key) {
+				r.recordClusterNamespaceEvent(
+					&policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: cluster.ClusterNamespace}},
+					instance.GetNamespace()+"/"+instance.GetName(), replicatedPolicyDeleted,
+				)
+			}
+
+			continue
+		}
+
 		reqLogger.Info(
 			fmt.Sprintf(
 				"Deleting orphaned replicated policy %s/%s",
@@ -221,26 +664,30 @@ func (r *PolicyReconciler) cleanUpOrphanedRplPolicies(instance *policiesv1.Polic
 				name,
 			),
 		)
+		orphan := &policiesv1.Policy{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       policiesv1.Kind,
+				APIVersion: policiesv1.SchemeGroupVersion.Group,
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: cluster.ClusterNamespace,
+			},
+		}
+		deleted := false
 		err := retry.Do(
 			func() error {
-				err := r.Delete(context.TODO(), &policiesv1.Policy{
-					TypeMeta: metav1.TypeMeta{
-						Kind:       policiesv1.Kind,
-						APIVersion: policiesv1.SchemeGroupVersion.Group,
-					},
-					ObjectMeta: metav1.ObjectMeta{
-						Name:      name,
-						Namespace: cluster.ClusterNamespace,
-					},
-				})
+				err := r.Delete(ctx, orphan)
 
 				if err != nil && k8serrors.IsNotFound(err) {
 					return nil
 				}
 
+				deleted = err == nil
+
 				return err
 			},
-			getRetryOptions(reqLogger, "Retrying to delete the orphaned replicated policy...")...,
+			r.getRetryOptions(ctx, reqLogger, "Retrying to delete the orphaned replicated policy...")...,
 		)
 
 		if err != nil {
@@ -253,6 +700,10 @@ func (r *PolicyReconciler) cleanUpOrphanedRplPolicies(instance *policiesv1.Polic
 					name,
 				),
 			)
+		} else if deleted {
+			r.recordClusterNamespaceEvent(
+				orphan, instance.GetNamespace()+"/"+instance.GetName(), replicatedPolicyDeleted,
+			)
 		}
 	}
 
@@ -270,10 +721,18 @@ func (r *PolicyReconciler) recordWarning(instance *policiesv1.Policy, msgPrefix
 		instance.GetNamespace(),
 		instance.GetName(),
 	)
+
+	// The same warnings are recorded once per interval when the events are aggregated
+	if r.eventAggregator != nil && !r.eventAggregator.warning(instance, msg) {
+		return
+	}
+
 	r.Recorder.Event(instance, "Warning", "PolicyPropagation", msg)
 }
 
-// handleRootPolicy will properly replicate or clean up when a root policy is updated.
+// handleRootPolicy will properly replicate or clean up when a root policy is updated. When changed
+// isn't nil, only the clusters in it are replicated to, and the other clusters keep their
+// replicated policy and status.
 //
 // Errors are logged in this method and a summary error is returned. This is because the method
 // handles retries and will only return after giving up.
@@ -281,23 +740,32 @@ func (r *PolicyReconciler) recordWarning(instance *policiesv1.Policy, msgPrefix
 // There are several retries within handleRootPolicy. This approach is taken over retrying the whole
 // method because it makes the retries more targeted and prevents race conditions, such as a
 // placement binding getting updated, from causing inconsistencies.
-func (r *PolicyReconciler) handleRootPolicy(instance *policiesv1.Policy) error {
-	entry_ts := time.Now()
+func (r *PolicyReconciler) handleRootPolicy(
+	ctx context.Context, instance *policiesv1.Policy, changed map[string]bool,
+) error {
+	entry_ts := r.clock.Now()
+	// outcome is overwritten on every early return so that the latency can be attributed to a cause
+	outcome := outcomeError
 	defer func() {
-		now := time.Now()
-		elapsed := now.Sub(entry_ts).Seconds()
-		roothandlerMeasure.Observe(elapsed)
+		elapsed := r.clock.Since(entry_ts).Seconds()
+		templates := strconv.FormatBool(r.policyHasTemplates(instance))
+		roothandlerMeasure.WithLabelValues(outcome, templates).Observe(elapsed)
 	}()
 
 	reqLogger := log.WithValues("Policy-Namespace", instance.GetNamespace(), "Policy-Name", instance.GetName())
 	originalInstance := instance.DeepCopy()
 
+	r.propagationLatency.seen(instance, r.clock.Now())
+
 	// Clean up the replicated policies if the policy is disabled
-	if instance.Spec.Disabled {
+	if instance.Spec.Disabled && isDryRun(instance) {
+		r.Recorder.Event(instance, "Normal", "PolicyPropagation",
+			fmt.Sprintf("Policy %s/%s would be disabled (dry run)", instance.GetNamespace(), instance.GetName()))
+	} else if instance.Spec.Disabled {
 		reqLogger.Info("Policy is disabled, doing clean up...")
 		err := retry.Do(
-			func() error { return r.cleanUpPolicy(instance) },
-			getRetryOptions(reqLogger, "Retrying the policy clean up...")...,
+			func() error { return r.cleanUpPolicy(ctx, instance) },
+			r.getRetryOptions(ctx, reqLogger, "Retrying the policy clean up...")...,
 		)
 
 		if err != nil {
@@ -315,41 +783,101 @@ func (r *PolicyReconciler) handleRootPolicy(instance *policiesv1.Policy) error {
 	err := retry.Do(
 		func() error {
 			return r.List(
-				context.TODO(), pbList, &client.ListOptions{Namespace: instance.GetNamespace()},
+				ctx, pbList, &client.ListOptions{Namespace: instance.GetNamespace()},
 			)
 		},
-		getRetryOptions(reqLogger, "Retrying to list the placement bindings...")...,
+		r.getRetryOptions(ctx, reqLogger, "Retrying to list the placement bindings...")...,
 	)
 
 	if err != nil {
 		reqLogger.Info("Giving up on listing the placement bindings...")
 		r.recordWarning(instance, "Could not list the placement bindings")
+		outcome = outcomePlacementError
+
+		return err
+	}
+
+	cfg, err := r.getPropagationConfig(ctx, instance.GetNamespace())
+	if err != nil {
+		reqLogger.Error(err, "Failed to get the PropagationConfig of the namespace...")
+		r.recordWarning(instance, "Could not get the PropagationConfig of the namespace")
+
+		return err
+	}
+
+	clusterSelector, err := policyClusterSelector(instance)
+	if err != nil {
+		reqLogger.Error(err, "Failed to parse the cluster selector of the policy...")
+		r.recordWarning(instance, "Could not parse the cluster selector")
+		r.setFailedCondition(ctx, instance, policiesv1.PlacementResolvedCondition, "InvalidClusterSelector",
+			"The cluster selector of the policy is invalid: "+err.Error())
+		outcome = outcomePlacementError
+
+		return err
+	}
+
+	if _, err := maintenanceWindowOpens(instance, r.clock.Now()); err != nil {
+		reqLogger.Error(err, "Failed to parse the maintenance window of the policy...")
+		r.recordWarning(instance, "Could not parse the maintenance window")
+		r.setFailedCondition(ctx, instance, policiesv1.PropagatedCondition, "InvalidMaintenanceWindow",
+			"The maintenance window of the policy is invalid: "+err.Error())
+
+		return err
+	}
+
+	if err := validateGatekeeperTemplates(instance, r.templateCfg.StartDelim); err != nil {
+		reqLogger.Error(err, "The Gatekeeper objects of the policy are invalid...")
+		r.recordWarning(instance, "Invalid Gatekeeper object in the policy templates")
+		r.setFailedCondition(ctx, instance, policiesv1.TemplatesResolvedCondition, "InvalidPolicyTemplates",
+			err.Error())
+
+		return err
+	}
+
+	if err := validateOperatorPolicies(instance, r.templateCfg.StartDelim); err != nil {
+		reqLogger.Error(err, "The OperatorPolicies of the policy are invalid...")
+		r.recordWarning(instance, "Invalid OperatorPolicy in the policy templates")
+		r.setFailedCondition(ctx, instance, policiesv1.TemplatesResolvedCondition, "InvalidPolicyTemplates",
+			err.Error())
+
 		return err
 	}
 
 	// allDecisions and failedClusters are sets in the format of <namespace>/<name>
-	placements, allDecisions, failedClusters, allFailed := r.handleDecisions(instance, pbList)
+	placements, allDecisions, failedClusters, allFailed, templateErrors, rollout := r.handleDecisions(
+		ctx, instance, pbList, cfg, clusterSelector, changed,
+	)
 	if allFailed {
 		reqLogger.Info("Failed to get any placement decisions. Giving up...")
 		msg := "Could not get the placement decisions"
 		r.recordWarning(instance, msg)
+		r.setFailedCondition(ctx, instance, policiesv1.PlacementResolvedCondition, "PlacementDecisionsFailed",
+			"The placement decisions of the placement bindings of the policy could not be retrieved")
+		outcome = outcomePlacementError
 		// Make the error start with a lower case for the linting check
 		return errors.New("c" + msg[1:])
 	}
 
+	r.propagationState.setResolved(
+		types.NamespacedName{Namespace: instance.GetNamespace(), Name: instance.GetName()}.String(),
+		placements, allDecisions,
+	)
+
 	status := []*policiesv1.CompliancePerClusterStatus{}
+	var details []*policiesv1.DetailsPerTemplate
+
 	if !instance.Spec.Disabled {
 		// Get all the replicated policies
 		replicatedPlcList := &policiesv1.PolicyList{}
 		err := retry.Do(
 			func() error {
 				return r.List(
-					context.TODO(),
+					ctx,
 					replicatedPlcList,
 					client.MatchingLabels(common.LabelsForRootPolicy(instance)),
 				)
 			},
-			getRetryOptions(reqLogger, "Retrying to list the replicated policies...")...,
+			r.getRetryOptions(ctx, reqLogger, "Retrying to list the replicated policies...")...,
 		)
 
 		if err != nil {
@@ -364,104 +892,186 @@ func (r *PolicyReconciler) handleRootPolicy(instance *policiesv1.Policy) error {
 			name := rPlc.GetLabels()[common.ClusterNameLabel]
 			key := fmt.Sprintf("%s/%s", namespace, name)
 
-			if failed := failedClusters[key]; failed {
+			// The replicated policy being deleted with its terminating cluster namespace is no longer
+			// reported once its cluster is no longer selected
+			if rPlc.GetDeletionTimestamp() != nil && !allDecisions[key] {
+				continue
+			}
+
+			failure, failed := failedClusters[key]
+			if failed && failure.reason != reasonPendingUpdate {
 				// Skip the replicated policies that failed to be properly replicated
 				// for now. This will be handled later.
 				continue
 			}
 
-			status = append(status, &policiesv1.CompliancePerClusterStatus{
-				ComplianceState:  rPlc.Status.ComplianceState,
+			// The hub template errors are only visible on the managed cluster otherwise
+			// #nosec G601 -- no memory addresses are stored in collections
+			cpcs := &policiesv1.CompliancePerClusterStatus{
+				ComplianceState:  replicatedCompliance(&rPlc),
 				ClusterName:      name,
 				ClusterNamespace: namespace,
-			})
+				Message:          templateErrorMessage(templateErrors[key]),
+			}
+
+			// The out of date replicated policy still reports its compliance
+			if failed {
+				cpcs.Message = failure.message
+				cpcs.Reason = failure.reason
+			}
+
+			status = append(status, cpcs)
 		}
 
 		// Add cluster statuses for the clusters that did not get their policies properly
 		// replicated. This is not done in the previous loop since some replicated polices may not
 		// have been created at all.
-		for clusterNsName := range failedClusters {
-			reqLogger.Info(
-				fmt.Sprintf(
-					"Setting the policy to noncompliant for %s since the replication failed...",
-					clusterNsName,
-				),
-			)
+		for clusterNsName, failure := range failedClusters {
+			if failure.reason == reasonPendingUpdate {
+				continue
+			}
+
+			complianceState := policiesv1.NonCompliant
+			if failure.reason == reasonClusterIncompatible || failure.reason == reasonDryRun {
+				complianceState = ""
+			} else if failure.reason == reasonDependenciesPending || failure.reason == reasonClusterUnavailable {
+				complianceState = policiesv1.Pending
+			} else if failure.reason == reasonRemovedAfterCompliant {
+				complianceState = policiesv1.Compliant
+			} else {
+				reqLogger.Info(
+					fmt.Sprintf(
+						"Setting the policy to noncompliant for %s since the replication failed...",
+						clusterNsName,
+					),
+				)
+			}
 			// The string split is safe since the namespace and name cannot have slashes in them
 			// since they must be DNS compliant names
 			clusterNsNameSl := strings.Split(clusterNsName, "/")
+
 			status = append(status, &policiesv1.CompliancePerClusterStatus{
-				ComplianceState:  policiesv1.NonCompliant,
+				ComplianceState:  complianceState,
 				ClusterName:      clusterNsNameSl[1],
 				ClusterNamespace: clusterNsNameSl[0],
+				Message:          failure.message,
+				Reason:           failure.reason,
 			})
 		}
 
 		sort.Slice(status, func(i, j int) bool {
 			return status[i].ClusterName < status[j].ClusterName
 		})
-	}
 
-	instance.Status.Status = status
-	//loop through status and set ComplianceState
-	instance.Status.ComplianceState = ""
-	isCompliant := true
-	for _, cpcs := range status {
-		if cpcs.ComplianceState == "NonCompliant" {
-			instance.Status.ComplianceState = policiesv1.NonCompliant
-			isCompliant = false
-			break
-		} else if cpcs.ComplianceState == "" {
-			isCompliant = false
-		}
-	}
-	// set to compliant only when all status are compliant
-	if len(status) > 0 && isCompliant {
-		instance.Status.ComplianceState = policiesv1.Compliant
+		if r.detailedRootStatus {
+			setTemplateStatus(status, replicatedPlcList.Items)
+		}
+
+		setOperatorPolicyStatus(status, replicatedPlcList.Items)
+
+		details = summarizeTemplateDetails(instance, replicatedPlcList.Items)
 	}
+
 	// looped through all pb, update status.placement
 	sort.Slice(placements, func(i, j int) bool {
 		return placements[i].PlacementBinding < placements[j].PlacementBinding
 	})
 
+	groupStatusByDecisionGroup(placements, status)
+	setCompliantSince(instance, status, r.clock.Now())
+	setComplianceHistory(instance, status, r.clock.Now())
+
+	instance.Status.Status = status
+	instance.Status.Details = details
+	instance.Status.SummaryByControl = summarizeControls(instance, status)
+	instance.Status.ComplianceState = aggregateCompliance(status)
+
 	instance.Status.Placement = placements
+	instance.Status.Rollout = rollout
+
+	if rollout != nil && rollout.State == policiesv1.RolloutPaused &&
+		(originalInstance.Status.Rollout == nil || originalInstance.Status.Rollout.State != policiesv1.RolloutPaused) {
+		r.Recorder.Event(instance, "Warning", "PolicyPropagation", rollout.Message)
+	}
+
+	setPropagationConditions(
+		instance, r.policyHasTemplates(instance), placements, allDecisions, failedClusters, templateErrors,
+	)
+	setCompliantCondition(instance)
+
+	thresholdExceeded := setAlertThresholdCondition(instance)
+
+	// The status patch returns the root policy without the annotation set since it was retrieved
+	hash := instance.GetAnnotations()[propagationHashAnnotation]
 
 	err = retry.Do(
 		func() error {
 			return r.Status().Patch(
-				context.TODO(), instance, client.MergeFrom(originalInstance),
+				ctx, instance, client.MergeFrom(originalInstance),
 			)
 		},
-		getRetryOptions(reqLogger, "Retrying to update the root policy status...")...,
+		r.getRetryOptions(ctx, reqLogger, "Retrying to update the root policy status...")...,
 	)
 
 	if err != nil {
 		reqLogger.Error(err, "Giving up on updating the root policy status...")
 		r.recordWarning(instance, "Failed to update the policy status")
+
+		if k8serrors.IsConflict(err) {
+			outcome = outcomeStatusConflict
+		}
+
 		return err
 	}
 
-	err = r.cleanUpOrphanedRplPolicies(instance, allDecisions)
+	err = r.patchPropagationHash(ctx, originalInstance, hash)
+	if err != nil {
+		// The policy is only replicated again to all of its clusters on the next reconcile
+		reqLogger.Error(err, "Failed to save the propagation hash of the root policy...")
+	}
+
+	if thresholdExceeded {
+		condition := meta.FindStatusCondition(instance.Status.Conditions, policiesv1.AlertThresholdExceeded)
+		r.recordWarning(instance, "The noncompliance alert threshold was exceeded: "+condition.Message)
+	}
+
+	r.reportPlacementKinds(instance)
+	reportReplicationMetrics(instance, allDecisions, failedClusters)
+
+	err = r.cleanUpOrphanedRplPolicies(ctx, instance, allDecisions)
 	if err != nil {
 		reqLogger.Error(err, "Giving up on deleting the orphaned replicated policies...")
 		r.recordWarning(instance, "Failed to delete orphaned replicated policies")
 		return err
 	}
 
+	switch {
+	case hasReplicationFailures(failedClusters):
+		outcome = outcomePartialFailure
+	case len(templateErrors) > 0:
+		outcome = outcomeTemplateError
+	default:
+		outcome = outcomeSuccess
+	}
+
 	reqLogger.Info("Reconciliation complete.")
 	return nil
 }
 
 // getApplicationPlacementDecisions return the placement decisions from an application
 // lifecycle placementrule
-func getApplicationPlacementDecisions(c client.Client, pb policiesv1.PlacementBinding, instance *policiesv1.Policy) ([]appsv1.PlacementDecision, *policiesv1.Placement, error) {
+func getApplicationPlacementDecisions(ctx context.Context, c client.Client, pb policiesv1.PlacementBinding, instance *policiesv1.Policy) ([]appsv1.PlacementDecision, *policiesv1.Placement, error) {
 	plr := &appsv1.PlacementRule{}
-	err := c.Get(context.TODO(), types.NamespacedName{Namespace: instance.GetNamespace(),
+	err := c.Get(ctx, types.NamespacedName{Namespace: instance.GetNamespace(),
 		Name: pb.PlacementRef.Name}, plr)
 	// no error when not found
 	if err != nil && !k8serrors.IsNotFound(err) {
-		log.Error(err, "Failed to get PlacementRule...", "Namespace", instance.GetNamespace(), "Name",
-			pb.PlacementRef.Name)
+		// The caller handles the placement API not being installed
+		if !meta.IsNoMatchError(err) {
+			log.Error(err, "Failed to get PlacementRule...", "Namespace", instance.GetNamespace(), "Name",
+				pb.PlacementRef.Name)
+		}
+
 		return nil, nil, err
 	}
 	// add the PlacementRule to placement, if not found there are no decisions
@@ -475,14 +1085,18 @@ func getApplicationPlacementDecisions(c client.Client, pb policiesv1.PlacementBi
 
 // getClusterPlacementDecisions return the placement decisions from cluster
 // placement decisions
-func getClusterPlacementDecisions(c client.Client, pb policiesv1.PlacementBinding, instance *policiesv1.Policy) ([]appsv1.PlacementDecision, *policiesv1.Placement, error) {
+func getClusterPlacementDecisions(ctx context.Context, c client.Client, pb policiesv1.PlacementBinding, instance *policiesv1.Policy) ([]appsv1.PlacementDecision, *policiesv1.Placement, error) {
 	pl := &clusterv1alpha1.Placement{}
-	err := c.Get(context.TODO(), types.NamespacedName{Namespace: instance.GetNamespace(),
+	err := c.Get(ctx, types.NamespacedName{Namespace: instance.GetNamespace(),
 		Name: pb.PlacementRef.Name}, pl)
 	// no error when not found
 	if err != nil && !k8serrors.IsNotFound(err) {
-		log.Error(err, "Failed to get Placement...", "Namespace", instance.GetNamespace(), "Name",
-			pb.PlacementRef.Name)
+		// The caller handles the placement API not being installed
+		if !meta.IsNoMatchError(err) {
+			log.Error(err, "Failed to get Placement...", "Namespace", instance.GetNamespace(), "Name",
+				pb.PlacementRef.Name)
+		}
+
 		return nil, nil, err
 	}
 	// add current Placement to placement, if not found no decisions will be found
@@ -495,13 +1109,14 @@ func getClusterPlacementDecisions(c client.Client, pb policiesv1.PlacementBindin
 
 	opts := client.MatchingLabels{"cluster.open-cluster-management.io/placement": pl.GetName()}
 	opts.ApplyToList(lopts)
-	err = c.List(context.TODO(), list, lopts)
+	err = c.List(ctx, list, lopts)
 	// do not error out if not found
 	if err != nil && !k8serrors.IsNotFound(err) {
 		log.Error(err, "Failed to get PlacementDecision...", "Namespace", instance.GetNamespace(), "Name",
 			pb.PlacementRef.Name)
 		return nil, nil, err
 	}
+	placement.DecisionGroups = getDecisionGroups(list.Items)
 	var decisions []appsv1.PlacementDecision
 	decisions = make([]appsv1.PlacementDecision, 0, len(list.Items))
 	for _, item := range list.Items {
@@ -516,122 +1131,224 @@ func getClusterPlacementDecisions(c client.Client, pb policiesv1.PlacementBindin
 	return decisions, placement, nil
 }
 
-// getPlacementDecisions gets the PlacementDecisions for a PlacementBinding
-func getPlacementDecisions(c client.Client, pb policiesv1.PlacementBinding,
+// getPlacementDecisions gets the PlacementDecisions for a PlacementBinding. A PlacementBinding
+// whose placement API isn't installed has no decisions, and the API is watched once it is installed.
+func (r *PolicyReconciler) getPlacementDecisions(ctx context.Context, pb policiesv1.PlacementBinding,
 	instance *policiesv1.Policy) ([]appsv1.PlacementDecision, *policiesv1.Placement, error) {
-	if pb.PlacementRef.APIGroup == appsv1.SchemeGroupVersion.Group &&
-		pb.PlacementRef.Kind == "PlacementRule" {
-		d, placement, err := getApplicationPlacementDecisions(c, pb, instance)
-		if err != nil {
-			return nil, nil, err
-		}
-		return d, placement, nil
-	} else if pb.PlacementRef.APIGroup == clusterv1alpha1.SchemeGroupVersion.Group &&
-		pb.PlacementRef.Kind == "Placement" {
-		d, placement, err := getClusterPlacementDecisions(c, pb, instance)
-		if err != nil {
-			return nil, nil, err
-		}
-		return d, placement, nil
+	api, ok := common.PlacementRefAPI(pb.PlacementRef)
+	if !ok {
+		return nil, nil, fmt.Errorf("Placement binding %s/%s reference is not valid", pb.Name, pb.Namespace)
+	}
+
+	var d []appsv1.PlacementDecision
+	var placement *policiesv1.Placement
+	var err error
+
+	if api == common.PlacementRuleAPI {
+		d, placement, err = getApplicationPlacementDecisions(ctx, r.Client, pb, instance)
+	} else {
+		d, placement, err = getClusterPlacementDecisions(ctx, r.Client, pb, instance)
+	}
+
+	if meta.IsNoMatchError(err) {
+		log.V(1).Info("The placement API is not installed, the PlacementBinding has no decisions...",
+			"Namespace", pb.GetNamespace(), "Name", pb.GetName(), "API", api)
+		r.placementAvailability.SetAvailable(api, false)
+
+		return nil, &policiesv1.Placement{PlacementBinding: pb.GetName()}, nil
 	}
-	return nil, nil, fmt.Errorf("Placement binding %s/%s reference is not valid", pb.Name, pb.Namespace)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r.placementAvailability.SetAvailable(api, true)
+
+	// The Placements whose ManagedClusterSets are not bound to the namespace silently select no
+	// clusters otherwise
+	if api == common.ClusterPlacementAPI && placement.Placement != "" {
+		placement.Message = r.unboundClusterSetsMessage(ctx, instance.GetNamespace(), placement.Placement)
+	}
+
+	return d, placement, nil
 }
 
-func (r *PolicyReconciler) handleDecision(instance *policiesv1.Policy, decision appsv1.PlacementDecision) error {
+// handleDecision creates or updates the replicated policy for the placement decision. Failing to
+// resolve the hub templates doesn't fail the replication, since the error is surfaced on the
+// managed cluster, so it is reported separately with templateErr. The PropagationConfig of the
+// namespace of the root policy sets the defaults of the replicated policy, and the bindingOverrides
+// of the PlacementBindings selecting the cluster override it.
+func (r *PolicyReconciler) handleDecision(
+	ctx context.Context, instance *policiesv1.Policy, decision appsv1.PlacementDecision,
+	cfg policyv1beta1.PropagationConfigSpec,
+) (templateErr error, err error) {
 	reqLogger := log.WithValues("Policy-Namespace", instance.GetNamespace(), "Policy-Name", instance.GetName())
-	// retrieve replicated policy in cluster namespace
-	replicatedPlc := &policiesv1.Policy{}
-	err := r.Get(context.TODO(), types.NamespacedName{Namespace: decision.ClusterNamespace,
-		Name: common.FullNameForPolicy(instance)}, replicatedPlc)
-	if err != nil {
-		if k8serrors.IsNotFound(err) {
-			// not replicated, need to create
-			replicatedPlc = instance.DeepCopy()
-			replicatedPlc.SetName(common.FullNameForPolicy(instance))
-			replicatedPlc.SetNamespace(decision.ClusterNamespace)
-			replicatedPlc.SetResourceVersion("")
-			replicatedPlc.SetFinalizers(nil)
-			labels := replicatedPlc.GetLabels()
-			if labels == nil {
-				labels = map[string]string{}
-			}
-			labels[common.ClusterNameLabel] = decision.ClusterName
-			labels[common.ClusterNamespaceLabel] = decision.ClusterNamespace
-			labels[common.RootPolicyLabel] = common.FullNameForPolicy(instance)
-			replicatedPlc.SetLabels(labels)
-
-			// Make sure the Owner Reference is cleared
-			replicatedPlc.SetOwnerReferences(nil)
-
-			//do a quick check for any template delims in the policy before putting it through
-			// template processor
-			if policyHasTemplates(instance) {
-				// resolve hubTemplate before replicating
-				// #nosec G104 -- any errors are logged and recorded in the processTemplates method,
-				// but the ignored status will be handled appropriately by the policy controllers on
-				// the managed cluster(s).
-				r.processTemplates(replicatedPlc, decision, instance)
-			}
 
-			reqLogger.Info("Creating replicated policy...", "Namespace", decision.ClusterNamespace,
-				"Name", common.FullNameForPolicy(instance))
-			err = r.Create(context.TODO(), replicatedPlc)
-			if err != nil {
-				reqLogger.Error(err, "Failed to create replicated policy...", "Namespace", decision.ClusterNamespace,
-					"Name", common.FullNameForPolicy(instance))
-				return err
-			}
-			r.Recorder.Event(instance, "Normal", "PolicyPropagation",
-				fmt.Sprintf("Policy %s/%s was propagated to cluster %s/%s", instance.GetNamespace(),
-					instance.GetName(), decision.ClusterNamespace, decision.ClusterName))
-			//exit after handling the create path, shouldnt be going to through the update path
-			return nil
-		} else {
+	// The existing replicated policy is only read from the cache to detect the changes, since the
+	// desired replicated policy is applied whole
+	existingPlc := &policiesv1.Policy{}
+	err = r.Get(ctx, types.NamespacedName{Namespace: decision.ClusterNamespace,
+		Name: common.FullNameForPolicy(instance)}, existingPlc)
+	if err != nil {
+		if !k8serrors.IsNotFound(err) {
 			// failed to get replicated object, requeue
 			reqLogger.Error(err, "Failed to get replicated policy...", "Namespace", decision.ClusterNamespace,
 				"Name", common.FullNameForPolicy(instance))
-			return err
+			return nil, err
 		}
 
+		existingPlc = nil
+	}
+
+	if collisionErr := nameCollision(existingPlc, instance); collisionErr != nil {
+		return nil, collisionErr
 	}
 
-	// replicated policy already created, need to compare and patch
-	comparePlc := instance
-	if policyHasTemplates(instance) {
-		//template delimis detected, build a temp holder policy with templates resolved
-		//before doing a compare with the replicated policy in the cluster namespaces
-		tempResolvedPlc := instance.DeepCopy()
-		//resolve hubTemplate before replicating
-		// #nosec G104 -- any errors are logged and recorded in the processTemplates method,
-		// but the ignored status will be handled appropriately by the policy controllers on
-		// the managed cluster(s).
-		r.processTemplates(tempResolvedPlc, decision, instance)
-		comparePlc = tempResolvedPlc
+	desiredPlc, templateErr, err := r.buildReplicatedPolicy(ctx, instance, decision, cfg, existingPlc)
+	if err != nil {
+		return templateErr, err
 	}
 
-	if !common.CompareSpecAndAnnotation(comparePlc, replicatedPlc) {
-		// update needed
-		reqLogger.Info("Root policy and Replicated policy mismatch, updating replicated policy...",
-			"Namespace", replicatedPlc.GetNamespace(), "Name", replicatedPlc.GetName())
-		replicatedPlc.SetAnnotations(comparePlc.GetAnnotations())
-		replicatedPlc.Spec = comparePlc.Spec
-		err = r.Update(context.TODO(), replicatedPlc)
+	operation := "create"
+
+	if existingPlc != nil {
+		if replicatedPolicyApplied(desiredPlc, existingPlc) {
+			return templateErr, nil
+		}
+
+		opens, err := maintenanceWindowOpens(instance, r.clock.Now())
 		if err != nil {
-			reqLogger.Error(err, "Failed to update replicated policy...",
-				"Namespace", replicatedPlc.GetNamespace(), "Name", replicatedPlc.GetName())
-			return err
+			reqLogger.Error(err, "Failed to check the maintenance window of the policy...")
+			return templateErr, err
 		}
-		r.Recorder.Event(instance, "Normal", "PolicyPropagation",
+
+		if !opens.IsZero() {
+			return templateErr, &updatePendingError{opens: opens}
+		}
+
+		operation = "update"
+	}
+
+	err = r.admissionHook.admit(ctx, operation, desiredPlc, decision, instance)
+	if err != nil {
+		reqLogger.Error(err, "The replicated policy was not admitted...", "Namespace", decision.ClusterNamespace,
+			"Name", common.FullNameForPolicy(instance), "Operation", operation)
+		return templateErr, err
+	}
+
+	// The hook may inject the same mutation every time, so check again if there is a change
+	if existingPlc != nil && replicatedPolicyApplied(desiredPlc, existingPlc) {
+		return templateErr, nil
+	}
+
+	if isDryRun(instance) {
+		return templateErr, previewReplicatedPolicy(existingPlc, desiredPlc)
+	}
+
+	reqLogger.Info("Applying replicated policy...", "Namespace", decision.ClusterNamespace,
+		"Name", common.FullNameForPolicy(instance), "Operation", operation)
+
+	// The propagator takes back the ownership of the fields modified by others, and leaves the
+	// other labels and annotations as is
+	err = r.Patch(ctx, desiredPlc, client.Apply, client.FieldOwner(fieldManager), client.ForceOwnership)
+	if err != nil {
+		reqLogger.Error(err, "Failed to apply replicated policy...", "Namespace", decision.ClusterNamespace,
+			"Name", common.FullNameForPolicy(instance))
+		return templateErr, err
+	}
+
+	r.propagationLatency.replicated(instance, decision.ClusterNamespace, r.clock.Now())
+	r.recordTemplateLookups(ctx, desiredPlc, decision)
+
+	if existingPlc == nil {
+		r.recordClusterEvent(instance, "Normal", clusterPropagated, decision.ClusterName,
+			fmt.Sprintf("Policy %s/%s was propagated to cluster %s/%s", instance.GetNamespace(),
+				instance.GetName(), decision.ClusterNamespace, decision.ClusterName))
+		r.recordClusterNamespaceEvent(
+			desiredPlc, instance.GetNamespace()+"/"+instance.GetName(), replicatedPolicyCreated,
+		)
+	} else {
+		r.recordClusterEvent(instance, "Normal", clusterUpdated, decision.ClusterName,
 			fmt.Sprintf("Policy %s/%s was updated for cluster %s/%s", instance.GetNamespace(),
 				instance.GetName(), decision.ClusterNamespace, decision.ClusterName))
+		r.recordClusterNamespaceEvent(
+			desiredPlc, instance.GetNamespace()+"/"+instance.GetName(), replicatedPolicyUpdated,
+		)
 	}
-	return nil
+
+	return templateErr, nil
+}
+
+// buildReplicatedPolicy returns the replicated policy of the root policy applied for the placement
+// decision, with its hub templates resolved, the PropagationConfig defaults and the bindingOverrides
+// applied, and the stamps of the propagator set. The existing replicated policy is nil when it
+// doesn't exist yet. Failing to resolve the hub templates is reported separately with templateErr.
+func (r *PolicyReconciler) buildReplicatedPolicy(
+	ctx context.Context, instance *policiesv1.Policy, decision appsv1.PlacementDecision,
+	cfg policyv1beta1.PropagationConfigSpec, existingPlc *policiesv1.Policy,
+) (desiredPlc *policiesv1.Policy, templateErr error, err error) {
+	reqLogger := log.WithValues("Policy-Namespace", instance.GetNamespace(), "Policy-Name", instance.GetName())
+
+	remediationOverride, err := r.remediationOverride(ctx, instance, decision.ClusterName)
+	if err != nil {
+		reqLogger.Error(err, "Failed to get the binding overrides of the cluster...", "Cluster", decision.ClusterName)
+		return nil, nil, err
+	}
+
+	desiredPlc = desiredReplicatedPolicy(instance, decision)
+	filterCopiedMetadata(desiredPlc, r.copyLabelPatterns(instance))
+
+	//do a quick check for any template delims in the policy before putting it through
+	// template processor
+	if r.policyHasTemplates(instance) {
+		// resolve hubTemplate before replicating
+		// any errors are logged and recorded in the processTemplates method, but the
+		// ignored status will be handled appropriately by the policy controllers on the
+		// managed cluster(s).
+		templateErr = r.processTemplates(ctx, desiredPlc, decision, instance, cfg)
+	}
+
+	applyPropagationConfig(cfg, desiredPlc)
+
+	if remediationOverride != "" {
+		desiredPlc.Spec.RemediationAction = remediationOverride
+	}
+
+	err = applyMutationHooks(ctx, r.mutationHooks, desiredPlc, decision, instance)
+	if err != nil {
+		reqLogger.Error(err, "Failed to mutate the replicated policy...", "Namespace", decision.ClusterNamespace,
+			"Name", common.FullNameForPolicy(instance))
+		return nil, templateErr, err
+	}
+
+	err = r.applyEnforcementLock(ctx, desiredPlc, instance)
+	if err != nil {
+		reqLogger.Error(err, "Failed to check the enforcement lock of the namespace...")
+		return nil, templateErr, err
+	}
+
+	err = stampDependencies(desiredPlc, instance)
+	if err != nil {
+		reqLogger.Error(err, "Failed to set the dependencies on the policy templates...")
+		return nil, templateErr, err
+	}
+
+	r.stampComplianceDBIDs(ctx, desiredPlc, existingPlc, instance)
+	r.stampVersions(desiredPlc, instance)
+
+	err = stampSpecHash(desiredPlc)
+	if err != nil {
+		reqLogger.Error(err, "Failed to hash the replicated policy spec...")
+		return nil, templateErr, err
+	}
+
+	return desiredPlc, templateErr, nil
 }
 
 // a helper to quickly check if there are any templates in any of the policy templates
-func policyHasTemplates(instance *policiesv1.Policy) bool {
+func (r *PolicyReconciler) policyHasTemplates(instance *policiesv1.Policy) bool {
 	for _, policyT := range instance.Spec.PolicyTemplates {
-		if templates.HasTemplate(policyT.ObjectDefinition.Raw, templateCfg.StartDelim) {
+		if templates.HasTemplate(policyT.ObjectDefinition.Raw, r.templateCfg.StartDelim) {
 			return true
 		}
 	}
@@ -643,7 +1360,10 @@ func policyHasTemplates(instance *policiesv1.Policy) bool {
 // templates and ensuring that the replicated-policies in cluster is updated only if there is a change.
 // this annotation is deleted from the replicated policies and not propagated to the cluster namespaces.
 
-func (r *PolicyReconciler) processTemplates(replicatedPlc *policiesv1.Policy, decision appsv1.PlacementDecision, rootPlc *policiesv1.Policy) error {
+func (r *PolicyReconciler) processTemplates(
+	ctx context.Context, replicatedPlc *policiesv1.Policy, decision appsv1.PlacementDecision, rootPlc *policiesv1.Policy,
+	cfg policyv1beta1.PropagationConfigSpec,
+) error {
 
 	reqLogger := log.WithValues("Policy-Namespace", rootPlc.GetNamespace(), "Policy-Name", rootPlc.GetName(), "Managed-Cluster", decision.ClusterName)
 	reqLogger.Info("Processing Templates..")
@@ -667,43 +1387,137 @@ func (r *PolicyReconciler) processTemplates(replicatedPlc *policiesv1.Policy, de
 		replicatedPlc.SetAnnotations(annotations)
 	}
 
-	templateCfg.LookupNamespace = rootPlc.GetNamespace()
-	tmplResolver, err := templates.NewResolver(kubeClient, kubeConfig, templateCfg)
+	tmplCtx, err := r.getTemplateContext(ctx, decision.ClusterName)
 	if err != nil {
-		reqLogger.Error(err, "Error instantiating template resolver")
-		panic(err)
+		reqLogger.Error(err, "Failed to get the managed cluster for the template context...")
+
+		return err
+	}
+
+	// The templates are only resolved again when the root policy or the managed cluster changes, or
+	// when a ConfigMap or Secret in the lookup namespace changes
+	cacheKey := templateCacheKey{
+		root:            rootPlc.GetNamespace() + "/" + rootPlc.GetName(),
+		rootUID:         rootPlc.GetUID(),
+		generation:      rootPlc.GetGeneration(),
+		triggerUpdate:   rootPlc.GetAnnotations()[triggerUpdateAnnotation],
+		lookupNamespace: templateLookupNamespace(cfg, rootPlc),
+		cluster:         decision.ClusterName,
+		context:         tmplCtx,
+	}
+
+	// The values resolved from Secrets are encrypted with the key of the cluster namespace so that
+	// they are not readable in the replicated policy
+	var encryptionKey []byte
+	var encryptionKeyErr error
+
+	encrypt := common.FeatureEnabled(common.EncryptedHubTemplates) && policyUsesSecrets(rootPlc)
+
+	if encrypt {
+		encryptionKey, encryptionKeyErr = r.getEncryptionKey(ctx, decision.ClusterNamespace)
+		if encryptionKeyErr == nil {
+			cacheKey.encryptionKey = keyFingerprint(encryptionKey)
+		} else {
+			reqLogger.Error(encryptionKeyErr, "Failed to get the encryption key of the cluster namespace...")
+		}
+	}
+
+	// The functions may be restricted to the root policies of some namespaces
+	disabledFunctions := r.templateFunctions.disabledFunctions(rootPlc.GetNamespace(), encrypt)
+	cacheKey.disabledFunctions = strings.Join(disabledFunctions, ",")
+	cacheKey.impersonate = r.templateUser(ctx, cfg, rootPlc)
+
+	resolverOpts := TemplateResolverOptions{
+		LookupNamespace:   cacheKey.lookupNamespace,
+		DisabledFunctions: disabledFunctions,
+		Impersonate:       cacheKey.impersonate,
+		AdditionalLookupNamespaces: r.templateFunctions.additionalLookupNamespaces(
+			rootPlc.GetNamespace(), rootPlc.GetName(),
+		),
+	}
+	cacheKey.additionalLookupNamespaces = strings.Join(resolverOpts.AdditionalLookupNamespaces, ",")
+
+	if cached, ok := r.templateCache.get(cacheKey); ok && encryptionKeyErr == nil {
+		reqLogger.Info("Using the cached resolved templates...")
+
+		for i, policyT := range replicatedPlc.Spec.PolicyTemplates {
+			if resolved, ok := cached.templates[i]; ok {
+				policyT.ObjectDefinition.Raw = append([]byte(nil), resolved...)
+			}
+		}
+
+		setTemplateLookups(replicatedPlc, cached.lookups)
+
+		return nil
+	}
+
+	var tmplResolver TemplateResolver
+
+	switch {
+	case encryptionKeyErr != nil:
+		// Fail the templates so that the error is reported on the managed cluster
+		tmplResolver = failingResolver{err: encryptionKeyErr}
+	case encrypt:
+		tmplResolver = r.instantiateResolver(ctx, reqLogger, func() (TemplateResolver, error) {
+			existing, err := r.existingEncryptedValues(
+				ctx, decision.ClusterNamespace, common.FullNameForPolicy(rootPlc), encryptionKey,
+			)
+			if err != nil {
+				return nil, err
+			}
+
+			return r.newEncryptingResolver(resolverOpts, encryptionKey, existing)
+		})
+	default:
+		tmplResolver = r.instantiateResolver(ctx, reqLogger, func() (TemplateResolver, error) {
+			return r.newTemplateResolver(resolverOpts)
+		})
+	}
+
+	// Watch the objects looked up by the templates, even when they failed to resolve since the
+	// objects may not exist yet, and record the versions of the objects on the replicated policy
+	tracker, tracking := tmplResolver.(referenceTrackingResolver)
+	if tracking {
+		defer func() {
+			r.templateWatcher.setReferences(cacheKey.root, decision.ClusterName, tracker.references())
+			setTemplateLookups(replicatedPlc, tracker.lookups())
+		}()
 	}
 
+	resolvedTemplates := map[int][]byte{}
+
 	//A policy can have multiple policy templates within it, iterate and process each
-	for _, policyT := range replicatedPlc.Spec.PolicyTemplates {
+	for i, policyT := range replicatedPlc.Spec.PolicyTemplates {
 
-		if !templates.HasTemplate(policyT.ObjectDefinition.Raw, templateCfg.StartDelim) {
+		if !templates.HasTemplate(policyT.ObjectDefinition.Raw, r.templateCfg.StartDelim) {
 			continue
 		}
 
-		if !isConfigurationPolicy(policyT) {
-			// has Templates but not a configuration policy
-			err = k8serrors.NewBadRequest("Templates are restricted to only Configuration Policies")
-			log.Error(err, "Not a Configuration Policy")
+		if !r.hubTemplatesAllowed(policyT) {
+			// has Templates but not of an eligible kind
+			err = k8serrors.NewBadRequest(
+				"Templates are restricted to the policy kinds " + r.templateEligibleKindsList() +
+					" and to Gatekeeper objects",
+			)
+			log.Error(err, "Not a policy kind eligible for templates")
 
 			r.Recorder.Event(rootPlc, "Warning", "PolicyPropagation",
-				fmt.Sprintf("Policy %s/%s has templates but it is not a ConfigurationPolicy.", rootPlc.GetName(), rootPlc.GetNamespace()))
+				fmt.Sprintf("Policy %s/%s has templates but it is not of a kind eligible for templates: %s.",
+					rootPlc.GetNamespace(), rootPlc.GetName(), r.templateEligibleKindsList()))
 
 			return err
 		}
 
 		reqLogger.Info("Found Object Definition with templates")
 
-		templateContext := struct {
-			ManagedClusterName string
-		}{
-			ManagedClusterName: decision.ClusterName,
-		}
-		resolveddata, tplErr := tmplResolver.ResolveTemplate(policyT.ObjectDefinition.Raw, templateContext)
+		resolveStart := r.clock.Now()
+		resolveddata, tplErr := tmplResolver.ResolveTemplate(policyT.ObjectDefinition.Raw, tmplCtx)
+		templateResolutionDuration.Observe(r.clock.Since(resolveStart).Seconds())
+
 		if tplErr != nil {
 			reqLogger.Error(tplErr, "Failed to resolve templates")
 
-			r.Recorder.Event(rootPlc, "Warning", "PolicyPropagation",
+			r.recordClusterEvent(rootPlc, "Warning", clusterTemplateError, decision.ClusterName,
 				fmt.Sprintf("Failed to resolve templates for cluster %s/%s: %s", decision.ClusterNamespace, decision.ClusterName, tplErr.Error()))
 			//Set an annotation on the policyTemplate(e.g. ConfigurationPolicy)  to the template processing error msg
 			//managed clusters will use this when creating a violation
@@ -732,16 +1546,16 @@ func (r *PolicyReconciler) processTemplates(replicatedPlc *policiesv1.Policy, de
 		}
 
 		policyT.ObjectDefinition.Raw = resolveddata
+		resolvedTemplates[i] = append([]byte(nil), resolveddata...)
 
 	}
-	return nil
-}
 
-func isConfigurationPolicy(policyT *policiesv1.PolicyTemplate) bool {
-	//check if it is a configuration policy first
+	cacheEntry := templateCacheEntry{templates: resolvedTemplates}
+	if tracking {
+		cacheEntry.lookups = tracker.lookups()
+	}
 
-	var jsonDef map[string]interface{}
-	_ = json.Unmarshal(policyT.ObjectDefinition.Raw, &jsonDef)
+	r.templateCache.set(cacheKey, cacheEntry)
 
-	return jsonDef != nil && jsonDef["kind"] == "ConfigurationPolicy"
+	return nil
 }
@@ -0,0 +1,111 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"path"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	policyv1beta1 "github.com/open-cluster-management/governance-policy-propagator/api/v1beta1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+)
+
+// getPropagationConfig returns the spec of the PropagationConfig of the namespace, or an empty spec
+// if there is none or the CRD is not installed
+func (r *PolicyReconciler) getPropagationConfig(
+	ctx context.Context, namespace string,
+) (policyv1beta1.PropagationConfigSpec, error) {
+	cfg := &policyv1beta1.PropagationConfig{}
+
+	err := r.Get(ctx, types.NamespacedName{Namespace: namespace, Name: policyv1beta1.PropagationConfigName}, cfg)
+	if err != nil {
+		if k8serrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			return policyv1beta1.PropagationConfigSpec{}, nil
+		}
+
+		return policyv1beta1.PropagationConfigSpec{}, err
+	}
+
+	return cfg.Spec, nil
+}
+
+// templateLookupNamespace returns the namespace the hub templates of the root policy can look up
+// objects in
+func templateLookupNamespace(cfg policyv1beta1.PropagationConfigSpec, rootPlc *policiesv1.Policy) string {
+	if cfg.TemplateLookupNamespace != "" {
+		return cfg.TemplateLookupNamespace
+	}
+
+	return rootPlc.GetNamespace()
+}
+
+// applyPropagationConfig removes the excluded labels and annotations copied from the root policy
+// and sets the default remediationAction on the replicated policy. The labels set by the
+// propagator are never removed.
+func applyPropagationConfig(cfg policyv1beta1.PropagationConfigSpec, replicatedPlc *policiesv1.Policy) {
+	if len(cfg.ExcludeLabels) > 0 {
+		replicatedPlc.SetLabels(excludeMetadata(replicatedPlc.GetLabels(), cfg.ExcludeLabels, map[string]bool{
+			common.ClusterNameLabel:      true,
+			common.ClusterNamespaceLabel: true,
+			common.RootPolicyLabel:       true,
+		}))
+	}
+
+	if len(cfg.ExcludeAnnotations) > 0 {
+		replicatedPlc.SetAnnotations(excludeMetadata(replicatedPlc.GetAnnotations(), cfg.ExcludeAnnotations, nil))
+	}
+
+	if replicatedPlc.Spec.RemediationAction == "" {
+		replicatedPlc.Spec.RemediationAction = cfg.DefaultRemediationAction
+	}
+}
+
+// excludeMetadata returns a copy of the labels or annotations without the keys matching one of
+// the glob patterns, except for the kept keys
+func excludeMetadata(values map[string]string, patterns []string, keep map[string]bool) map[string]string {
+	if values == nil {
+		return nil
+	}
+
+	result := make(map[string]string, len(values))
+
+	for key, value := range values {
+		if !keep[key] && matchesAny(key, patterns) {
+			continue
+		}
+
+		result[key] = value
+	}
+
+	return result
+}
+
+func matchesAny(value string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matched, err := path.Match(pattern, value); err == nil && matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// propagationConfigMapper returns a reconcile request for every root policy in the namespace of
+// the PropagationConfig
+func propagationConfigMapper(c client.Client) handler.MapFunc {
+	return func(object client.Object) []reconcile.Request {
+		if object.GetName() != policyv1beta1.PropagationConfigName {
+			return nil
+		}
+
+		return rootPolicyRequests(c, object.GetNamespace())
+	}
+}
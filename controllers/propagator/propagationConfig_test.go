@@ -0,0 +1,159 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"testing"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	policyv1beta1 "github.com/open-cluster-management/governance-policy-propagator/api/v1beta1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+func TestApplyPropagationConfig(t *testing.T) {
+	cfg := policyv1beta1.PropagationConfigSpec{
+		ExcludeLabels:            []string{"team.example.com/*", common.RootPolicyLabel},
+		ExcludeAnnotations:       []string{"kubectl.kubernetes.io/last-applied-configuration"},
+		DefaultRemediationAction: policiesv1.Inform,
+	}
+
+	plc := newTestPolicy("default")
+	plc.SetLabels(map[string]string{
+		"team.example.com/owner": "a",
+		"app":                    "b",
+		common.RootPolicyLabel:   "policies.policy",
+	})
+	plc.SetAnnotations(map[string]string{
+		"kubectl.kubernetes.io/last-applied-configuration": "{}",
+		"policy.open-cluster-management.io/standards":      "NIST SP 800-53",
+	})
+
+	applyPropagationConfig(cfg, plc)
+
+	expectedLabels := map[string]string{"app": "b", common.RootPolicyLabel: "policies.policy"}
+	if len(plc.GetLabels()) != len(expectedLabels) {
+		t.Fatalf("expected the labels %v, got %v", expectedLabels, plc.GetLabels())
+	}
+
+	for key, value := range expectedLabels {
+		if plc.GetLabels()[key] != value {
+			t.Fatalf("expected the labels %v, got %v", expectedLabels, plc.GetLabels())
+		}
+	}
+
+	if _, ok := plc.GetAnnotations()["kubectl.kubernetes.io/last-applied-configuration"]; ok {
+		t.Fatal("expected the excluded annotation to be removed")
+	}
+
+	if plc.GetAnnotations()["policy.open-cluster-management.io/standards"] == "" {
+		t.Fatal("expected the other annotations to be kept")
+	}
+
+	if plc.Spec.RemediationAction != policiesv1.Inform {
+		t.Fatalf("expected the default remediationAction, got %q", plc.Spec.RemediationAction)
+	}
+
+	plc.Spec.RemediationAction = policiesv1.Enforce
+	applyPropagationConfig(cfg, plc)
+
+	if plc.Spec.RemediationAction != policiesv1.Enforce {
+		t.Fatal("expected the remediationAction of the policy to override the default")
+	}
+}
+
+func TestHandleDecisionTemplateLookupNamespace(t *testing.T) {
+	decision := appsv1.PlacementDecision{ClusterName: "cluster1", ClusterNamespace: "cluster1"}
+	root := newTestPolicy(`{{hub .ManagedClusterName hub}}`)
+	resolver := &stubResolver{result: []byte(configPolicy)}
+
+	r := newTestReconciler(t, resolver, root)
+
+	lookupNamespace := ""
+	r.newTemplateResolver = func(opts TemplateResolverOptions) (TemplateResolver, error) {
+		lookupNamespace = opts.LookupNamespace
+
+		return resolver, nil
+	}
+
+	tests := []struct {
+		cfg      policyv1beta1.PropagationConfigSpec
+		expected string
+	}{
+		{policyv1beta1.PropagationConfigSpec{}, "policies"},
+		{policyv1beta1.PropagationConfigSpec{TemplateLookupNamespace: "shared"}, "shared"},
+	}
+
+	for _, test := range tests {
+		if _, err := r.handleDecision(context.TODO(), root, decision, test.cfg); err != nil {
+			t.Fatalf("handleDecision returned an error: %v", err)
+		}
+
+		if lookupNamespace != test.expected {
+			t.Fatalf("expected the templates to look up objects in %s, got %s", test.expected, lookupNamespace)
+		}
+	}
+}
+
+func TestHandleRootPolicyMaxClusters(t *testing.T) {
+	root := newTestPolicy("default")
+
+	cfg := &policyv1beta1.PropagationConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: policyv1beta1.PropagationConfigName, Namespace: "policies"},
+		Spec:       policyv1beta1.PropagationConfigSpec{MaxClusters: 1},
+	}
+	plr := &appsv1.PlacementRule{
+		ObjectMeta: metav1.ObjectMeta{Name: "plr", Namespace: "policies"},
+		Status: appsv1.PlacementRuleStatus{
+			Decisions: []appsv1.PlacementDecision{
+				{ClusterName: "cluster1", ClusterNamespace: "cluster1"},
+				{ClusterName: "cluster2", ClusterNamespace: "cluster2"},
+			},
+		},
+	}
+	pb := &policiesv1.PlacementBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "pb", Namespace: "policies"},
+		PlacementRef: policiesv1.Subject{
+			APIGroup: appsv1.SchemeGroupVersion.Group, Kind: "PlacementRule", Name: "plr",
+		},
+		Subjects: []policiesv1.Subject{
+			{APIGroup: policiesv1.SchemeGroupVersion.Group, Kind: policiesv1.Kind, Name: "policy"},
+		},
+	}
+
+	r := newTestReconciler(t, &stubResolver{}, root, cfg, plr, pb)
+
+	if err := r.handleRootPolicy(context.TODO(), root, nil); err != nil {
+		t.Fatalf("handleRootPolicy returned an error: %v", err)
+	}
+
+	err := r.Get(context.TODO(), types.NamespacedName{Namespace: "cluster1", Name: "policies.policy"}, &policiesv1.Policy{})
+	if err != nil {
+		t.Fatalf("expected the policy to be replicated to the first cluster: %v", err)
+	}
+
+	err = r.Get(context.TODO(), types.NamespacedName{Namespace: "cluster2", Name: "policies.policy"}, &policiesv1.Policy{})
+	if !k8serrors.IsNotFound(err) {
+		t.Fatalf("expected no replicated policy past the fan-out limit, got the error: %v", err)
+	}
+
+	updatedRoot := &policiesv1.Policy{}
+
+	err = r.Get(context.TODO(), types.NamespacedName{Namespace: "policies", Name: "policy"}, updatedRoot)
+	if err != nil {
+		t.Fatalf("failed to get the root policy: %v", err)
+	}
+
+	for _, cpcs := range updatedRoot.Status.Status {
+		if cpcs.ClusterName == "cluster2" && cpcs.Reason == reasonFanOutLimitExceeded {
+			return
+		}
+	}
+
+	t.Fatalf("expected the fan-out limit in the root policy status, got %v", updatedRoot.Status.Status)
+}
@@ -0,0 +1,185 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	policyv1beta1 "github.com/open-cluster-management/governance-policy-propagator/api/v1beta1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+	"github.com/open-cluster-management/governance-policy-propagator/version"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+// propagationHashAnnotation on a root policy is the hash of what its replicated policies were last
+// built from, so that the replication to all of its clusters is skipped when nothing changed. Unlike
+// the specHashAnnotation of the replicated policies, it covers the labels and annotations of the
+// root policy, the PropagationConfig and the enforcement lock of its namespace, the remediation
+// overrides of its PlacementBindings, and the clusters it is replicated to.
+const propagationHashAnnotation = "policy.open-cluster-management.io/propagation-hash"
+
+// propagationHash returns the hash of the spec, labels, and annotations of the root policy, the
+// PropagationConfig and the enforcement lock of its namespace, the placement decisions of the
+// clusters it is replicated to and their remediation overrides by cluster name, the patterns of the
+// labels and annotations copied to all the replicated policies, and the version of the propagator
+func propagationHash(
+	instance *policiesv1.Policy, cfg policyv1beta1.PropagationConfigSpec, decisions []appsv1.PlacementDecision,
+	copyLabels []string, locked bool, overrides map[string]policiesv1.RemediationAction,
+) (string, error) {
+	annotations := map[string]string{}
+
+	for key, value := range common.WithoutGitOpsTrackingKeys(instance.GetAnnotations()) {
+		if key != propagationHashAnnotation {
+			annotations[key] = value
+		}
+	}
+
+	clusters := make([]string, 0, len(decisions))
+	remediationOverrides := map[string]policiesv1.RemediationAction{}
+
+	for _, decision := range decisions {
+		clusters = append(clusters, decision.ClusterNamespace+"/"+decision.ClusterName)
+
+		if override := overrides[decision.ClusterName]; override != "" {
+			remediationOverrides[decision.ClusterName] = override
+		}
+	}
+
+	sort.Strings(clusters)
+
+	hashed, err := json.Marshal(struct {
+		Spec                 policiesv1.PolicySpec
+		Labels               map[string]string
+		Annotations          map[string]string
+		Config               policyv1beta1.PropagationConfigSpec
+		EnforcementLocked    bool
+		Clusters             []string
+		RemediationOverrides map[string]policiesv1.RemediationAction
+		CopyLabels           []string
+		Version              string
+	}{
+		instance.Spec, common.WithoutGitOpsTrackingKeys(instance.GetLabels()), annotations, cfg, locked, clusters,
+		remediationOverrides, copyLabels, version.Version,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256(hashed)
+
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// rootPropagationHash returns the propagationHash of the root policy with the enforcement lock of
+// its namespace and the remediation overrides of its PlacementBindings
+func (r *PolicyReconciler) rootPropagationHash(
+	ctx context.Context, instance *policiesv1.Policy, cfg policyv1beta1.PropagationConfigSpec,
+	decisions []appsv1.PlacementDecision,
+) (string, error) {
+	locked, err := r.enforcementLocked(ctx, instance.GetNamespace())
+	if err != nil {
+		return "", err
+	}
+
+	overrides, err := r.remediationOverrides(ctx, instance)
+	if err != nil {
+		return "", err
+	}
+
+	return propagationHash(instance, cfg, decisions, r.copyLabels, locked, overrides)
+}
+
+// replicationSkippable returns whether the root policy doesn't need to be replicated again since
+// its hash didn't change. The root policies whose replicated policies depend on more than the hash,
+// such as the objects looked up by their hub templates or the compliance of their dependencies, are
+// always replicated, as well as the ones that failed to be replicated to some of their clusters.
+func (r *PolicyReconciler) replicationSkippable(instance *policiesv1.Policy, hash string) bool {
+	if hash == "" || instance.GetAnnotations()[propagationHashAnnotation] != hash {
+		return false
+	}
+
+	if r.policyHasTemplates(instance) || len(instance.Spec.Dependencies) != 0 || len(r.mutationHooks) != 0 ||
+		isDryRun(instance) {
+		return false
+	}
+
+	if instance.Status.Rollout != nil && instance.Status.Rollout.State != policiesv1.RolloutCompleted {
+		return false
+	}
+
+	for _, cpcs := range instance.Status.Status {
+		if cpcs.Reason != "" && !decidedBeforeReplication(cpcs.Reason) {
+			return false
+		}
+
+		if strings.HasPrefix(cpcs.Message, templateErrorPrefix) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// decidedBeforeReplication returns whether the reason of the cluster is decided before the policy
+// is replicated to the clusters, so that it is set again when the replication is skipped
+func decidedBeforeReplication(reason string) bool {
+	switch reason {
+	case reasonNamespaceDenied, reasonClusterIncompatible, reasonRemovedAfterCompliant, reasonFanOutLimitExceeded,
+		reasonClusterUnavailable:
+		return true
+	default:
+		return false
+	}
+}
+
+// replicationComplete returns whether the policy was replicated to all of its clusters without
+// errors, so that its propagation hash can be saved
+func replicationComplete(
+	failedClusters map[string]replicationFailure, templateErrors map[string]string,
+	rollout *policiesv1.RolloutStatus,
+) bool {
+	for _, failure := range failedClusters {
+		if !decidedBeforeReplication(failure.reason) {
+			return false
+		}
+	}
+
+	return len(templateErrors) == 0 && (rollout == nil || rollout.State == policiesv1.RolloutCompleted)
+}
+
+// setPropagationHash sets the propagation hash annotation on the root policy. The annotations are
+// copied since they may be shared with the cached root policy.
+func setPropagationHash(instance *policiesv1.Policy, hash string) {
+	annotations := map[string]string{}
+	for key, value := range instance.GetAnnotations() {
+		annotations[key] = value
+	}
+
+	annotations[propagationHashAnnotation] = hash
+	instance.SetAnnotations(annotations)
+}
+
+// patchPropagationHash saves the propagation hash on the root policy when it changed since the root
+// policy was retrieved. Only the annotation is patched so that the other changes of the root policy
+// are kept.
+func (r *PolicyReconciler) patchPropagationHash(
+	ctx context.Context, original *policiesv1.Policy, hash string,
+) error {
+	if hash == "" || hash == original.GetAnnotations()[propagationHashAnnotation] {
+		return nil
+	}
+
+	patch := fmt.Sprintf(`{"metadata":{"annotations":{%q:%q}}}`, propagationHashAnnotation, hash)
+
+	return r.Patch(ctx, original.DeepCopy(), client.RawPatch(types.MergePatchType, []byte(patch)))
+}
@@ -0,0 +1,349 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	policyv1beta1 "github.com/open-cluster-management/governance-policy-propagator/api/v1beta1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+func TestPropagationHash(t *testing.T) {
+	decisions := []appsv1.PlacementDecision{
+		{ClusterName: "cluster1", ClusterNamespace: "cluster1"},
+		{ClusterName: "cluster2", ClusterNamespace: "cluster2"},
+	}
+
+	hashAll := func(instance *policiesv1.Policy, cfg policyv1beta1.PropagationConfigSpec,
+		decisions []appsv1.PlacementDecision, copyLabels []string, locked bool,
+		overrides map[string]policiesv1.RemediationAction,
+	) string {
+		t.Helper()
+
+		hash, err := propagationHash(instance, cfg, decisions, copyLabels, locked, overrides)
+		if err != nil {
+			t.Fatalf("failed to hash the policy: %v", err)
+		}
+
+		return hash
+	}
+
+	hash := func(instance *policiesv1.Policy, cfg policyv1beta1.PropagationConfigSpec,
+		decisions []appsv1.PlacementDecision,
+	) string {
+		t.Helper()
+
+		return hashAll(instance, cfg, decisions, nil, false, nil)
+	}
+
+	expected := hash(newTestPolicy("default"), policyv1beta1.PropagationConfigSpec{}, decisions)
+
+	reordered := []appsv1.PlacementDecision{decisions[1], decisions[0]}
+	if hash(newTestPolicy("default"), policyv1beta1.PropagationConfigSpec{}, reordered) != expected {
+		t.Fatal("expected the hash to not depend on the order of the decisions")
+	}
+
+	hashed := newTestPolicy("default")
+	setPropagationHash(hashed, expected)
+
+	if hash(hashed, policyv1beta1.PropagationConfigSpec{}, decisions) != expected {
+		t.Fatal("expected the hash to ignore the propagation hash annotation")
+	}
+
+	otherCluster := map[string]policiesv1.RemediationAction{"cluster3": policiesv1.Enforce}
+	if hashAll(newTestPolicy("default"), policyv1beta1.PropagationConfigSpec{}, decisions, nil, false,
+		otherCluster) != expected {
+		t.Fatal("expected the hash to ignore the overrides of the clusters the policy isn't replicated to")
+	}
+
+	labeled := newTestPolicy("default")
+	labeled.SetLabels(map[string]string{"team": "a"})
+
+	changes := map[string]string{
+		"spec":     hash(newTestPolicy("other"), policyv1beta1.PropagationConfigSpec{}, decisions),
+		"labels":   hash(labeled, policyv1beta1.PropagationConfigSpec{}, decisions),
+		"config":   hash(newTestPolicy("default"), policyv1beta1.PropagationConfigSpec{MaxClusters: 1}, decisions),
+		"clusters": hash(newTestPolicy("default"), policyv1beta1.PropagationConfigSpec{}, decisions[:1]),
+		"copied labels": hashAll(
+			newTestPolicy("default"), policyv1beta1.PropagationConfigSpec{}, decisions, []string{"team"}, false, nil,
+		),
+		"enforcement lock": hashAll(
+			newTestPolicy("default"), policyv1beta1.PropagationConfigSpec{}, decisions, nil, true, nil,
+		),
+		"remediation overrides": hashAll(
+			newTestPolicy("default"), policyv1beta1.PropagationConfigSpec{}, decisions, nil, false,
+			map[string]policiesv1.RemediationAction{"cluster1": policiesv1.Enforce},
+		),
+	}
+
+	for change, changed := range changes {
+		if changed == expected {
+			t.Errorf("expected the hash to change with the %s", change)
+		}
+	}
+}
+
+func TestHandleRootPolicySkipsReplication(t *testing.T) {
+	root := newTestPolicy("default")
+	plr := newTestPlacementRule("plr", "cluster1")
+	pb := newTestPlacementBinding("pb", "plr", policiesv1.Subject{
+		APIGroup: policiesv1.SchemeGroupVersion.Group, Kind: policiesv1.Kind, Name: "policy",
+	})
+
+	r := newTestReconciler(t, &stubResolver{}, root, plr, pb)
+	rootKey := types.NamespacedName{Namespace: "policies", Name: "policy"}
+	replicatedKey := types.NamespacedName{Namespace: "cluster1", Name: "policies.policy"}
+
+	reconcileRoot := func() {
+		t.Helper()
+
+		instance := &policiesv1.Policy{}
+		if err := r.Get(context.TODO(), rootKey, instance); err != nil {
+			t.Fatalf("failed to get the root policy: %v", err)
+		}
+
+		if err := r.handleRootPolicy(context.TODO(), instance, nil); err != nil {
+			t.Fatalf("handleRootPolicy returned an error: %v", err)
+		}
+	}
+
+	reconcileRoot()
+
+	instance := &policiesv1.Policy{}
+	if err := r.Get(context.TODO(), rootKey, instance); err != nil {
+		t.Fatalf("failed to get the root policy: %v", err)
+	}
+
+	if instance.GetAnnotations()[propagationHashAnnotation] == "" {
+		t.Fatal("expected the propagation hash to be saved on the root policy")
+	}
+
+	replicatedPlc := &policiesv1.Policy{}
+	if err := r.Get(context.TODO(), replicatedKey, replicatedPlc); err != nil {
+		t.Fatalf("expected the policy to be replicated: %v", err)
+	}
+
+	if _, ok := replicatedPlc.GetAnnotations()[propagationHashAnnotation]; ok {
+		t.Fatal("expected the propagation hash to not be replicated")
+	}
+
+	// The modified replicated policy is left as is since the root policy didn't change
+	replicatedPlc.Spec.Disabled = true
+	if err := r.Update(context.TODO(), replicatedPlc); err != nil {
+		t.Fatalf("failed to update the replicated policy: %v", err)
+	}
+
+	reconcileRoot()
+
+	if err := r.Get(context.TODO(), replicatedKey, replicatedPlc); err != nil {
+		t.Fatalf("failed to get the replicated policy: %v", err)
+	}
+
+	if !replicatedPlc.Spec.Disabled {
+		t.Fatal("expected the replication to be skipped when the root policy didn't change")
+	}
+
+	if err := r.Get(context.TODO(), rootKey, instance); err != nil {
+		t.Fatalf("failed to get the root policy: %v", err)
+	}
+
+	instance.SetLabels(map[string]string{"team": "a"})
+	if err := r.Update(context.TODO(), instance); err != nil {
+		t.Fatalf("failed to update the root policy: %v", err)
+	}
+
+	reconcileRoot()
+
+	if err := r.Get(context.TODO(), replicatedKey, replicatedPlc); err != nil {
+		t.Fatalf("failed to get the replicated policy: %v", err)
+	}
+
+	if replicatedPlc.Spec.Disabled || replicatedPlc.GetLabels()["team"] != "a" {
+		t.Fatal("expected the policy to be replicated again when the root policy changed")
+	}
+}
+
+func TestHandleRootPolicyReplicatesLockAndOverrides(t *testing.T) {
+	root := newTestPolicy("default")
+	root.Spec.RemediationAction = policiesv1.Enforce
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "policies"}}
+	plr := newTestPlacementRule("plr", "cluster1")
+	pb := newTestPlacementBinding("pb", "plr", policiesv1.Subject{
+		APIGroup: policiesv1.SchemeGroupVersion.Group, Kind: policiesv1.Kind, Name: "policy",
+	})
+
+	r := newTestReconciler(t, &stubResolver{}, root, ns, plr, pb)
+	rootKey := types.NamespacedName{Namespace: "policies", Name: "policy"}
+	replicatedKey := types.NamespacedName{Namespace: "cluster1", Name: "policies.policy"}
+
+	// reconcileRoot reconciles the root policy and returns the remediation action of its replicated
+	// policy
+	reconcileRoot := func() policiesv1.RemediationAction {
+		t.Helper()
+
+		instance := &policiesv1.Policy{}
+		if err := r.Get(context.TODO(), rootKey, instance); err != nil {
+			t.Fatalf("failed to get the root policy: %v", err)
+		}
+
+		if err := r.handleRootPolicy(context.TODO(), instance, nil); err != nil {
+			t.Fatalf("handleRootPolicy returned an error: %v", err)
+		}
+
+		replicatedPlc := &policiesv1.Policy{}
+		if err := r.Get(context.TODO(), replicatedKey, replicatedPlc); err != nil {
+			t.Fatalf("expected the policy to be replicated: %v", err)
+		}
+
+		return replicatedPlc.Spec.RemediationAction
+	}
+
+	if action := reconcileRoot(); action != policiesv1.Enforce {
+		t.Fatalf("expected the replicated policy to enforce, got %s", action)
+	}
+
+	ns.SetAnnotations(map[string]string{enforcementLockAnnotation: "true"})
+	if err := r.Update(context.TODO(), ns); err != nil {
+		t.Fatalf("failed to lock the namespace: %v", err)
+	}
+
+	if action := reconcileRoot(); action != policiesv1.Inform {
+		t.Fatalf("expected the replicated policy to inform when the namespace is locked, got %s", action)
+	}
+
+	ns.SetAnnotations(nil)
+	if err := r.Update(context.TODO(), ns); err != nil {
+		t.Fatalf("failed to unlock the namespace: %v", err)
+	}
+
+	if action := reconcileRoot(); action != policiesv1.Enforce {
+		t.Fatalf("expected the replicated policy to enforce when the namespace is unlocked, got %s", action)
+	}
+
+	instance := &policiesv1.Policy{}
+	if err := r.Get(context.TODO(), rootKey, instance); err != nil {
+		t.Fatalf("failed to get the root policy: %v", err)
+	}
+
+	instance.Spec.RemediationAction = policiesv1.Inform
+	if err := r.Update(context.TODO(), instance); err != nil {
+		t.Fatalf("failed to update the root policy: %v", err)
+	}
+
+	if action := reconcileRoot(); action != policiesv1.Inform {
+		t.Fatalf("expected the replicated policy to inform, got %s", action)
+	}
+
+	if err := r.Get(context.TODO(), types.NamespacedName{Namespace: "policies", Name: "pb"}, pb); err != nil {
+		t.Fatalf("failed to get the placement binding: %v", err)
+	}
+
+	pb.Subjects[0].BindingOverrides = &policiesv1.BindingOverrides{RemediationAction: "Enforce"}
+	if err := r.Update(context.TODO(), pb); err != nil {
+		t.Fatalf("failed to update the placement binding: %v", err)
+	}
+
+	if action := reconcileRoot(); action != policiesv1.Enforce {
+		t.Fatalf("expected the replicated policy to enforce with the binding override, got %s", action)
+	}
+}
+
+func TestReplicationSkippable(t *testing.T) {
+	r := newTestReconciler(t, &stubResolver{})
+
+	tests := []struct {
+		name     string
+		mutate   func(*policiesv1.Policy)
+		expected bool
+	}{
+		{name: "unchanged", mutate: func(*policiesv1.Policy) {}, expected: true},
+		{
+			name:   "other hash",
+			mutate: func(instance *policiesv1.Policy) { setPropagationHash(instance, "other") },
+		},
+		{
+			name: "dependencies",
+			mutate: func(instance *policiesv1.Policy) {
+				instance.Spec.Dependencies = []policiesv1.PolicyDependency{{Compliance: policiesv1.Compliant}}
+			},
+		},
+		{
+			name: "rollout in progress",
+			mutate: func(instance *policiesv1.Policy) {
+				instance.Status.Rollout = &policiesv1.RolloutStatus{State: policiesv1.RolloutProgressing}
+			},
+		},
+		{
+			name: "replication failure",
+			mutate: func(instance *policiesv1.Policy) {
+				instance.Status.Status[0].Reason = reasonReplicationFailed
+			},
+		},
+		{
+			name: "template error",
+			mutate: func(instance *policiesv1.Policy) {
+				instance.Status.Status[0].Message = templateErrorMessage("lookup failed")
+			},
+		},
+		{
+			name: "incompatible cluster",
+			mutate: func(instance *policiesv1.Policy) {
+				instance.Status.Status[0].Reason = reasonClusterIncompatible
+			},
+			expected: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			instance := newTestPolicy("default")
+			setPropagationHash(instance, "hash")
+			instance.Status.Status = []*policiesv1.CompliancePerClusterStatus{
+				{ClusterName: "cluster1", ClusterNamespace: "cluster1", ComplianceState: policiesv1.Compliant},
+			}
+
+			test.mutate(instance)
+
+			if skippable := r.replicationSkippable(instance, "hash"); skippable != test.expected {
+				t.Fatalf("expected skippable to be %v, got %v", test.expected, skippable)
+			}
+		})
+	}
+}
+
+func TestPolicyPredicateIgnoresPropagationHash(t *testing.T) {
+	plcOld := newTestPolicy("default")
+	plcOld.SetAnnotations(map[string]string{"owner": "a"})
+
+	plcNew := plcOld.DeepCopy()
+	setPropagationHash(plcNew, "hash")
+
+	if policyPredicateFuncs.Update(event.UpdateEvent{ObjectOld: plcOld, ObjectNew: plcNew}) {
+		t.Fatal("expected saving the propagation hash to not reconcile the root policy")
+	}
+
+	tracked := plcNew.DeepCopy()
+	tracked.SetLabels(map[string]string{common.ArgoCDInstanceLabel: "app"})
+	tracked.Annotations[common.ArgoCDTrackingIDAnnotation] = "app:policy/Policy:policies/policy"
+
+	if policyPredicateFuncs.Update(event.UpdateEvent{ObjectOld: plcNew, ObjectNew: tracked}) {
+		t.Fatal("expected the GitOps tracking changes to not reconcile the root policy")
+	}
+
+	plcNew.Annotations["owner"] = "b"
+
+	if !policyPredicateFuncs.Update(event.UpdateEvent{ObjectOld: plcOld, ObjectNew: plcNew}) {
+		t.Fatal("expected the other annotation changes to reconcile the root policy")
+	}
+}
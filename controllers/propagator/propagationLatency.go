@@ -0,0 +1,118 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+)
+
+// The configuration of the percentage of the clusters whose propagation latency is measured, from
+// 0 to 100
+const (
+	latencySamplePercentEnvName = "CONTROLLER_CONFIG_LATENCY_SAMPLE_PERCENT"
+	latencySamplePercentDefault = 10
+)
+
+// generationChange is when the propagator saw the generation of a root policy
+type generationChange struct {
+	generation int64
+	// changed is when the generation was seen. It is zero when it isn't known, such as for the
+	// generations that changed before the propagator started.
+	changed time.Time
+	// observed are the clusters whose latency was already measured for the generation
+	observed map[string]bool
+}
+
+// propagationLatency measures the time from the generation change of the root policies to the
+// update of their replicated policies, for a sample of the clusters
+type propagationLatency struct {
+	lock          sync.Mutex
+	samplePercent int
+	generations   map[types.NamespacedName]*generationChange
+}
+
+func newPropagationLatency(samplePercent int) *propagationLatency {
+	return &propagationLatency{
+		samplePercent: samplePercent,
+		generations:   map[types.NamespacedName]*generationChange{},
+	}
+}
+
+// sampled returns whether the latency of the cluster namespace is measured. The same clusters are
+// always sampled so that the latencies are comparable over time.
+func (p *propagationLatency) sampled(clusterNamespace string) bool {
+	if p.samplePercent <= 0 {
+		return false
+	}
+
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(clusterNamespace))
+
+	return int(hash.Sum32()%100) < p.samplePercent
+}
+
+// seen records when the generation of the root policy is first seen. A new root policy changed
+// when it was created, and the other generations seen first after the propagator started aren't
+// measured since when they changed is unknown.
+func (p *propagationLatency) seen(instance *policiesv1.Policy, now time.Time) {
+	if p.samplePercent <= 0 {
+		return
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	root := types.NamespacedName{Namespace: instance.GetNamespace(), Name: instance.GetName()}
+
+	previous, ok := p.generations[root]
+	if ok && previous.generation == instance.GetGeneration() {
+		return
+	}
+
+	change := &generationChange{generation: instance.GetGeneration(), observed: map[string]bool{}}
+
+	if ok {
+		change.changed = now
+	} else if instance.GetGeneration() == 1 {
+		change.changed = instance.GetCreationTimestamp().Time
+	}
+
+	p.generations[root] = change
+}
+
+// replicated measures the latency of the replicated policy of the sampled cluster namespace the
+// first time it is updated for the generation of the root policy
+func (p *propagationLatency) replicated(instance *policiesv1.Policy, clusterNamespace string, now time.Time) {
+	if !p.sampled(clusterNamespace) {
+		return
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	root := types.NamespacedName{Namespace: instance.GetNamespace(), Name: instance.GetName()}
+
+	change, ok := p.generations[root]
+	if !ok || change.generation != instance.GetGeneration() || change.changed.IsZero() ||
+		change.observed[clusterNamespace] {
+		return
+	}
+
+	change.observed[clusterNamespace] = true
+
+	propagationLatencyHistogram.Observe(now.Sub(change.changed).Seconds())
+}
+
+// forget deletes the generation of the deleted root policy
+func (p *propagationLatency) forget(root types.NamespacedName) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	delete(p.generations, root)
+}
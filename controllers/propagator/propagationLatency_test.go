@@ -0,0 +1,126 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+)
+
+// latencySamples returns the number of observations and their sum of the latency histogram
+func latencySamples(t *testing.T) (uint64, float64) {
+	t.Helper()
+
+	metric := &dto.Metric{}
+	if err := propagationLatencyHistogram.(prometheus.Metric).Write(metric); err != nil {
+		t.Fatalf("failed to read the latency histogram: %v", err)
+	}
+
+	return metric.GetHistogram().GetSampleCount(), metric.GetHistogram().GetSampleSum()
+}
+
+func TestPropagationLatencySampled(t *testing.T) {
+	if newPropagationLatency(0).sampled("cluster1") {
+		t.Fatal("expected no cluster to be sampled at 0 percent")
+	}
+
+	if !newPropagationLatency(100).sampled("cluster1") {
+		t.Fatal("expected every cluster to be sampled at 100 percent")
+	}
+
+	p := newPropagationLatency(10)
+	sampled := 0
+
+	for i := 0; i < 1000; i++ {
+		if p.sampled(fmt.Sprintf("cluster%d", i)) {
+			sampled++
+		}
+	}
+
+	if sampled < 50 || sampled > 150 {
+		t.Fatalf("expected about 10 percent of the clusters to be sampled, got %d of 1000", sampled)
+	}
+}
+
+func TestPropagationLatencyReplicated(t *testing.T) {
+	created := time.Date(2021, time.June, 1, 0, 0, 0, 0, time.UTC)
+	p := newPropagationLatency(100)
+
+	root := newTestPolicy("default")
+	root.Generation = 2
+
+	countBefore, _ := latencySamples(t)
+
+	// The generation changed before the propagator started, so when is unknown
+	p.seen(root, created.Add(time.Hour))
+	p.replicated(root, "cluster1", created.Add(time.Hour+time.Second))
+
+	if count, _ := latencySamples(t); count != countBefore {
+		t.Fatalf("expected no latency for an unknown generation change, got %d samples", count-countBefore)
+	}
+
+	root.Generation = 3
+	p.seen(root, created.Add(2*time.Hour))
+	p.replicated(root, "cluster1", created.Add(2*time.Hour+5*time.Second))
+	// An update of the same generation, such as for a hub template change, isn't measured again
+	p.replicated(root, "cluster1", created.Add(3*time.Hour))
+
+	count, sum := latencySamples(t)
+	if count != countBefore+1 {
+		t.Fatalf("expected one latency sample, got %d", count-countBefore)
+	}
+
+	p.forget(types.NamespacedName{Namespace: root.Namespace, Name: root.Name})
+
+	root2 := newTestPolicy("default")
+	root2.Name = "new-policy"
+	root2.Generation = 1
+	root2.CreationTimestamp = metav1.NewTime(created)
+
+	p.seen(root2, created.Add(10*time.Second))
+	p.replicated(root2, "cluster1", created.Add(30*time.Second))
+
+	newCount, newSum := latencySamples(t)
+	if newCount != count+1 || newSum-sum != 30 {
+		t.Fatalf("expected a latency of 30s since the creation, got %d samples of %vs", newCount-count, newSum-sum)
+	}
+}
+
+func TestHandleRootPolicyLatency(t *testing.T) {
+	now := time.Date(2021, time.June, 2, 10, 0, 0, 0, time.UTC)
+
+	root := newTestPolicy("default")
+	root.Generation = 1
+	root.CreationTimestamp = metav1.NewTime(now.Add(-20 * time.Second))
+
+	r := newTestReconciler(t, &stubResolver{}, root,
+		newTestPlacementRule("plr", "cluster1", "cluster2"),
+		newTestPlacementBinding("pb", "plr", policiesv1.Subject{
+			APIGroup: policiesv1.SchemeGroupVersion.Group, Kind: policiesv1.Kind, Name: "policy",
+		}),
+	)
+	r.clock = clock.NewFakeClock(now)
+	r.propagationLatency = newPropagationLatency(100)
+
+	countBefore, sumBefore := latencySamples(t)
+
+	if err := r.handleRootPolicy(context.TODO(), root, nil); err != nil {
+		t.Fatalf("handleRootPolicy returned an error: %v", err)
+	}
+
+	count, sum := latencySamples(t)
+	if count-countBefore != 2 || sum-sumBefore != 40 {
+		t.Fatalf("expected a latency of 20s for both clusters, got %d samples of %vs", count-countBefore,
+			sum-sumBefore)
+	}
+}
@@ -0,0 +1,23 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"strconv"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// propagationPausedAnnotation on a root policy freezes its replicated policies, such as during a
+// maintenance window. They are not created, updated, or deleted until the annotation is removed,
+// but the compliance of the existing ones is still aggregated in the root policy status. Deleting
+// the root policy still deletes them.
+const propagationPausedAnnotation = "policy.open-cluster-management.io/propagation-paused"
+
+// isPropagationPaused returns whether the root policy has the propagation paused annotation set to
+// true
+func isPropagationPaused(instance client.Object) bool {
+	paused, err := strconv.ParseBool(instance.GetAnnotations()[propagationPausedAnnotation])
+
+	return err == nil && paused
+}
@@ -0,0 +1,88 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"testing"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+func TestPropagationPaused(t *testing.T) {
+	root := newTestPolicy("default")
+	root.SetAnnotations(map[string]string{propagationPausedAnnotation: "true"})
+	root.Spec.RemediationAction = policiesv1.Inform
+
+	existing := newTestReplicatedPolicy(root, "cluster1", policiesv1.NonCompliant)
+	existing.Spec.RemediationAction = policiesv1.Enforce
+
+	plr := &appsv1.PlacementRule{
+		ObjectMeta: metav1.ObjectMeta{Name: "plr", Namespace: "policies"},
+		Status: appsv1.PlacementRuleStatus{
+			Decisions: []appsv1.PlacementDecision{{ClusterName: "cluster2", ClusterNamespace: "cluster2"}},
+		},
+	}
+	pb := &policiesv1.PlacementBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "pb", Namespace: "policies"},
+		PlacementRef: policiesv1.Subject{
+			APIGroup: appsv1.SchemeGroupVersion.Group, Kind: "PlacementRule", Name: "plr",
+		},
+		Subjects: []policiesv1.Subject{
+			{APIGroup: policiesv1.SchemeGroupVersion.Group, Kind: policiesv1.Kind, Name: "policy"},
+		},
+	}
+
+	r := newTestReconciler(t, &stubResolver{}, root, existing, plr, pb)
+	rootRequest := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "policies", Name: "policy"}}
+
+	if _, err := r.Reconcile(context.TODO(), rootRequest); err != nil {
+		t.Fatalf("Reconcile returned an error: %v", err)
+	}
+
+	r.propagationState.setResolved("policies/policy", nil, map[string]bool{"cluster2/cluster2": true})
+
+	_, err := NewReplicatedPolicyReconciler(r).Reconcile(context.TODO(), ctrl.Request{
+		NamespacedName: types.NamespacedName{Namespace: "cluster1", Name: "policies.policy"},
+	})
+	if err != nil {
+		t.Fatalf("Reconcile of the replicated policy returned an error: %v", err)
+	}
+
+	replicated := &policiesv1.Policy{}
+
+	err = r.Get(context.TODO(), types.NamespacedName{Namespace: "cluster1", Name: "policies.policy"}, replicated)
+	if err != nil {
+		t.Fatalf("expected the replicated policy to be kept while paused, got the error: %v", err)
+	}
+
+	if replicated.Spec.RemediationAction != policiesv1.Enforce {
+		t.Fatalf("expected the replicated policy not to be updated, got %q", replicated.Spec.RemediationAction)
+	}
+
+	err = r.Get(context.TODO(), types.NamespacedName{Namespace: "cluster2", Name: "policies.policy"}, replicated)
+	if !k8serrors.IsNotFound(err) {
+		t.Fatalf("expected no replicated policy to be created while paused, got the error: %v", err)
+	}
+
+	statusReconciler := &RootPolicyStatusReconciler{Client: r.Client, Scheme: r.Scheme, Recorder: r.Recorder}
+
+	if _, err := statusReconciler.Reconcile(context.TODO(), rootRequest); err != nil {
+		t.Fatalf("Reconcile of the root policy status returned an error: %v", err)
+	}
+
+	updatedRoot := &policiesv1.Policy{}
+	if err := r.Get(context.TODO(), rootRequest.NamespacedName, updatedRoot); err != nil {
+		t.Fatalf("failed to get the root policy: %v", err)
+	}
+
+	if updatedRoot.Status.ComplianceState != policiesv1.NonCompliant {
+		t.Fatalf("expected the compliance to be aggregated while paused, got %q", updatedRoot.Status.ComplianceState)
+	}
+}
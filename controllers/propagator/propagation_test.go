@@ -6,12 +6,13 @@ import (
 	"fmt"
 	"os"
 	"testing"
+	"time"
 
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 )
 
-func TestInitializeAttempts(t *testing.T) {
+func TestOptionsFromEnvAttempts(t *testing.T) {
 	tests := []struct {
 		envVarValue string
 		expected    int
@@ -27,8 +28,6 @@ func TestInitializeAttempts(t *testing.T) {
 			fmt.Sprintf(`%s="%s"`, attemptsEnvName, test.envVarValue),
 			func(t *testing.T) {
 				defer func() {
-					// Reset to the default values
-					attempts = 0
 					err := os.Unsetenv(attemptsEnvName)
 					if err != nil {
 						t.Fatalf("failed to unset the environment variable: %v", err)
@@ -40,25 +39,25 @@ func TestInitializeAttempts(t *testing.T) {
 					t.Fatalf("failed to set the environment variable: %v", err)
 				}
 				var k8sInterface kubernetes.Interface
-				Initialize(&rest.Config{}, &k8sInterface)
+				opts := PolicyReconcilerOptionsFromEnv(&rest.Config{}, &k8sInterface)
 
-				if attempts != test.expected {
-					t.Fatalf("Expected attempts=%d, got %d", test.expected, attempts)
+				if opts.RetryAttempts != test.expected {
+					t.Fatalf("Expected RetryAttempts=%d, got %d", test.expected, opts.RetryAttempts)
 				}
 			},
 		)
 	}
 }
 
-func TestInitializeRequeueErrorDelay(t *testing.T) {
+func TestOptionsFromEnvRequeueErrorDelay(t *testing.T) {
 	tests := []struct {
 		envVarValue string
-		expected    int
+		expected    time.Duration
 	}{
-		{"", requeueErrorDelayDefault},
-		{fmt.Sprint(requeueErrorDelayDefault + 2), requeueErrorDelayDefault + 2},
-		{"0", requeueErrorDelayDefault},
-		{"-3", requeueErrorDelayDefault},
+		{"", requeueErrorDelayDefault * time.Minute},
+		{fmt.Sprint(requeueErrorDelayDefault + 2), (requeueErrorDelayDefault + 2) * time.Minute},
+		{"0", requeueErrorDelayDefault * time.Minute},
+		{"-3", requeueErrorDelayDefault * time.Minute},
 	}
 
 	for _, test := range tests {
@@ -66,8 +65,6 @@ func TestInitializeRequeueErrorDelay(t *testing.T) {
 			fmt.Sprintf(`%s="%s"`, requeueErrorDelayEnvName, test.envVarValue),
 			func(t *testing.T) {
 				defer func() {
-					// Reset to the default values
-					requeueErrorDelay = 0
 					err := os.Unsetenv(requeueErrorDelayEnvName)
 					if err != nil {
 						t.Fatalf("failed to unset the environment variable: %v", err)
@@ -79,12 +76,69 @@ func TestInitializeRequeueErrorDelay(t *testing.T) {
 					t.Fatalf("failed to set the environment variable: %v", err)
 				}
 				var k8sInterface kubernetes.Interface
-				Initialize(&rest.Config{}, &k8sInterface)
+				opts := PolicyReconcilerOptionsFromEnv(&rest.Config{}, &k8sInterface)
 
-				if requeueErrorDelay != test.expected {
-					t.Fatalf("Expected requeueErrorDelay=%d, got %d", test.expected, attempts)
+				if opts.RequeueErrorDelay != test.expected {
+					t.Fatalf("Expected RequeueErrorDelay=%v, got %v", test.expected, opts.RequeueErrorDelay)
 				}
 			},
 		)
 	}
 }
+
+func TestOptionsFromEnvMaxConcurrentReconciles(t *testing.T) {
+	tests := []struct {
+		envVarValue string
+		expected    int
+	}{
+		{"", maxConcurrentReconcilesDefault},
+		{"8", 8},
+		{"0", maxConcurrentReconcilesDefault},
+		{"-3", maxConcurrentReconcilesDefault},
+	}
+
+	for _, test := range tests {
+		t.Run(
+			fmt.Sprintf(`%s="%s"`, maxConcurrentReconcilesEnvName, test.envVarValue),
+			func(t *testing.T) {
+				defer func() {
+					err := os.Unsetenv(maxConcurrentReconcilesEnvName)
+					if err != nil {
+						t.Fatalf("failed to unset the environment variable: %v", err)
+					}
+				}()
+
+				err := os.Setenv(maxConcurrentReconcilesEnvName, test.envVarValue)
+				if err != nil {
+					t.Fatalf("failed to set the environment variable: %v", err)
+				}
+				var k8sInterface kubernetes.Interface
+				opts := PolicyReconcilerOptionsFromEnv(&rest.Config{}, &k8sInterface)
+
+				if opts.MaxConcurrentReconciles != test.expected {
+					t.Fatalf("Expected MaxConcurrentReconciles=%d, got %d", test.expected, opts.MaxConcurrentReconciles)
+				}
+			},
+		)
+	}
+}
+
+func TestNewPolicyReconcilerIndependentConfig(t *testing.T) {
+	customCfg := defaultTemplateConfig()
+	customCfg.StartDelim = "{{custom"
+	customCfg.StopDelim = "custom}}"
+
+	r1 := NewPolicyReconciler(nil, nil, PolicyReconcilerOptions{RetryAttempts: 1})
+	r2 := NewPolicyReconciler(nil, nil, PolicyReconcilerOptions{RetryAttempts: 7, TemplateConfig: &customCfg})
+
+	if r1.retryAttempts != 1 || r2.retryAttempts != 7 {
+		t.Fatalf("Expected the attempts 1 and 7, got %d and %d", r1.retryAttempts, r2.retryAttempts)
+	}
+
+	if r1.templateCfg.StartDelim != "{{hub" || r2.templateCfg.StartDelim != "{{custom" {
+		t.Fatalf(
+			"Expected the delimiters {{hub and {{custom, got %s and %s",
+			r1.templateCfg.StartDelim, r2.templateCfg.StartDelim,
+		)
+	}
+}
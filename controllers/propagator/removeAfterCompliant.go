@@ -0,0 +1,113 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+)
+
+// setCompliantSince sets when the compliant clusters of the new per-cluster status became
+// compliant when the root policy has removeAfterCompliant. The clusters already compliant in the
+// current status of the root policy keep their time.
+func setCompliantSince(instance *policiesv1.Policy, status []*policiesv1.CompliancePerClusterStatus, now time.Time) {
+	if instance.Spec.RemoveAfterCompliant == nil {
+		return
+	}
+
+	previous := map[string]*metav1.Time{}
+
+	for _, cpcs := range instance.Status.Status {
+		if cpcs.ComplianceState == policiesv1.Compliant && cpcs.CompliantSince != nil {
+			previous[cpcs.ClusterNamespace+"/"+cpcs.ClusterName] = cpcs.CompliantSince
+		}
+	}
+
+	for _, cpcs := range status {
+		if cpcs.ComplianceState != policiesv1.Compliant {
+			cpcs.CompliantSince = nil
+
+			continue
+		}
+
+		if since, ok := previous[cpcs.ClusterNamespace+"/"+cpcs.ClusterName]; ok {
+			cpcs.CompliantSince = since.DeepCopy()
+		} else {
+			since := metav1.NewTime(now)
+			cpcs.CompliantSince = &since
+		}
+	}
+}
+
+// complianceRemoval returns why the policy is removed from the cluster in the format of
+// <namespace>/<name>, or nil when the cluster didn't remain compliant for the removeAfterCompliant
+// duration of the root policy. An event is recorded on the root policy when the policy is removed.
+func (r *PolicyReconciler) complianceRemoval(instance *policiesv1.Policy, key string) *replicationFailure {
+	removeAfter := instance.Spec.RemoveAfterCompliant
+	if removeAfter == nil {
+		return nil
+	}
+
+	for _, cpcs := range instance.Status.Status {
+		if cpcs.ClusterNamespace+"/"+cpcs.ClusterName != key {
+			continue
+		}
+
+		if cpcs.Reason == reasonRemovedAfterCompliant {
+			return &replicationFailure{reason: reasonRemovedAfterCompliant, message: cpcs.Message}
+		}
+
+		if cpcs.Reason != "" || cpcs.ComplianceState != policiesv1.Compliant || cpcs.CompliantSince == nil ||
+			r.clock.Now().Before(cpcs.CompliantSince.Add(removeAfter.Duration)) {
+			return nil
+		}
+
+		r.Recorder.Event(instance, "Normal", "PolicyPropagation",
+			fmt.Sprintf(
+				"Policy %s/%s was removed from cluster %s/%s after being compliant for %s",
+				instance.GetNamespace(), instance.GetName(), cpcs.ClusterNamespace, cpcs.ClusterName,
+				removeAfter.Duration,
+			),
+		)
+
+		return &replicationFailure{
+			reason:  reasonRemovedAfterCompliant,
+			message: fmt.Sprintf("The policy was removed after the cluster was compliant for %s", removeAfter.Duration),
+		}
+	}
+
+	return nil
+}
+
+// removeAfterCompliantRequeue returns how long to wait before the root policy is reconciled again to
+// remove it from the next cluster that remains compliant for its removeAfterCompliant duration, or
+// 0 if there is none
+func removeAfterCompliantRequeue(instance *policiesv1.Policy, now time.Time) time.Duration {
+	if instance.Spec.RemoveAfterCompliant == nil {
+		return 0
+	}
+
+	var requeueAfter time.Duration
+
+	for _, cpcs := range instance.Status.Status {
+		if cpcs.Reason != "" || cpcs.ComplianceState != policiesv1.Compliant || cpcs.CompliantSince == nil {
+			continue
+		}
+
+		remaining := cpcs.CompliantSince.Add(instance.Spec.RemoveAfterCompliant.Duration).Sub(now)
+		if remaining <= 0 {
+			// Past due, such as when it became compliant while the root policy was reconciled
+			remaining = time.Second
+		}
+
+		if requeueAfter == 0 || remaining < requeueAfter {
+			requeueAfter = remaining
+		}
+	}
+
+	return requeueAfter
+}
@@ -0,0 +1,150 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+)
+
+func TestSetCompliantSince(t *testing.T) {
+	before := metav1.NewTime(time.Date(2021, time.June, 1, 0, 0, 0, 0, time.UTC))
+	now := time.Date(2021, time.June, 2, 0, 0, 0, 0, time.UTC)
+
+	root := newTestPolicy("default")
+	root.Spec.RemoveAfterCompliant = &metav1.Duration{Duration: time.Hour}
+	root.Status.Status = []*policiesv1.CompliancePerClusterStatus{
+		{
+			ClusterName: "cluster1", ClusterNamespace: "cluster1", ComplianceState: policiesv1.Compliant,
+			CompliantSince: &before,
+		},
+		{ClusterName: "cluster2", ClusterNamespace: "cluster2", ComplianceState: policiesv1.NonCompliant},
+	}
+
+	status := []*policiesv1.CompliancePerClusterStatus{
+		{ClusterName: "cluster1", ClusterNamespace: "cluster1", ComplianceState: policiesv1.Compliant},
+		{ClusterName: "cluster2", ClusterNamespace: "cluster2", ComplianceState: policiesv1.Compliant},
+		{ClusterName: "cluster3", ClusterNamespace: "cluster3", ComplianceState: policiesv1.NonCompliant},
+	}
+
+	setCompliantSince(root, status, now)
+
+	if status[0].CompliantSince == nil || !status[0].CompliantSince.Equal(&before) {
+		t.Fatalf("expected the compliant cluster to keep its time, got %v", status[0].CompliantSince)
+	}
+
+	if status[1].CompliantSince == nil || !status[1].CompliantSince.Time.Equal(now) {
+		t.Fatalf("expected the newly compliant cluster to be compliant since now, got %v", status[1].CompliantSince)
+	}
+
+	if status[2].CompliantSince != nil {
+		t.Fatalf("expected the noncompliant cluster not to be compliant since, got %v", status[2].CompliantSince)
+	}
+
+	root.Spec.RemoveAfterCompliant = nil
+	status[2].ComplianceState = policiesv1.Compliant
+
+	setCompliantSince(root, status, now)
+
+	if status[2].CompliantSince != nil {
+		t.Fatal("expected the time not to be set without removeAfterCompliant")
+	}
+}
+
+func TestHandleRootPolicyRemoveAfterCompliant(t *testing.T) {
+	now := time.Date(2021, time.June, 2, 10, 0, 0, 0, time.UTC)
+	longAgo := metav1.NewTime(now.Add(-2 * time.Hour))
+	recently := metav1.NewTime(now.Add(-30 * time.Minute))
+
+	root := newTestPolicy("default")
+	root.Spec.RemoveAfterCompliant = &metav1.Duration{Duration: time.Hour}
+	root.Status.Status = []*policiesv1.CompliancePerClusterStatus{
+		{
+			ClusterName: "cluster1", ClusterNamespace: "cluster1", ComplianceState: policiesv1.Compliant,
+			CompliantSince: &longAgo,
+		},
+		{
+			ClusterName: "cluster2", ClusterNamespace: "cluster2", ComplianceState: policiesv1.Compliant,
+			CompliantSince: &recently,
+		},
+	}
+
+	r := newTestReconciler(t, &stubResolver{}, root,
+		newTestReplicatedPolicy(root, "cluster1", policiesv1.Compliant),
+		newTestReplicatedPolicy(root, "cluster2", policiesv1.Compliant),
+		newTestPlacementRule("plr", "cluster1", "cluster2"),
+		newTestPlacementBinding("pb", "plr", policiesv1.Subject{
+			APIGroup: policiesv1.SchemeGroupVersion.Group, Kind: policiesv1.Kind, Name: "policy",
+		}),
+	)
+	r.clock = clock.NewFakeClock(now)
+
+	if err := r.handleRootPolicy(context.TODO(), root, nil); err != nil {
+		t.Fatalf("handleRootPolicy returned an error: %v", err)
+	}
+
+	replicated := &policiesv1.Policy{}
+
+	err := r.Get(context.TODO(), types.NamespacedName{Namespace: "cluster1", Name: "policies.policy"}, replicated)
+	if !k8serrors.IsNotFound(err) {
+		t.Fatalf("expected the policy to be removed from the compliant cluster, got %v", err)
+	}
+
+	err = r.Get(context.TODO(), types.NamespacedName{Namespace: "cluster2", Name: "policies.policy"}, replicated)
+	if err != nil {
+		t.Fatalf("expected the recently compliant cluster to keep the policy: %v", err)
+	}
+
+	updated := &policiesv1.Policy{}
+	if err := r.Get(context.TODO(), types.NamespacedName{Namespace: "policies", Name: "policy"}, updated); err != nil {
+		t.Fatalf("failed to get the root policy: %v", err)
+	}
+
+	if len(updated.Status.Status) != 2 {
+		t.Fatalf("expected the status of both clusters, got %v", updated.Status.Status)
+	}
+
+	removed := updated.Status.Status[0]
+	if removed.Reason != reasonRemovedAfterCompliant || removed.ComplianceState != policiesv1.Compliant {
+		t.Fatalf("expected the removed cluster to stay compliant with the reason, got %v", removed)
+	}
+
+	if updated.Status.ComplianceState != policiesv1.Compliant {
+		t.Fatalf("expected the root policy to be compliant, got %q", updated.Status.ComplianceState)
+	}
+
+	if requeueAfter := removeAfterCompliantRequeue(updated, now); requeueAfter != 30*time.Minute {
+		t.Fatalf("expected to requeue when cluster2 is compliant for an hour, got %v", requeueAfter)
+	}
+
+	// The removed cluster doesn't get the policy again
+	if err := r.handleRootPolicy(context.TODO(), updated, nil); err != nil {
+		t.Fatalf("handleRootPolicy returned an error: %v", err)
+	}
+
+	err = r.Get(context.TODO(), types.NamespacedName{Namespace: "cluster1", Name: "policies.policy"}, replicated)
+	if !k8serrors.IsNotFound(err) {
+		t.Fatalf("expected the policy not to be replicated to the removed cluster again, got %v", err)
+	}
+
+	removals := 0
+
+	for _, event := range events(r) {
+		if strings.Contains(event, "was removed from cluster cluster1/cluster1") {
+			removals++
+		}
+	}
+
+	if removals != 1 {
+		t.Fatalf("expected one event for the removed cluster, got %d", removals)
+	}
+}
@@ -0,0 +1,308 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/uninstall"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+const ReplicatedPolicyControllerName string = "replicated-policy"
+
+// replicatedPolicyPredicateFuncs only lets through the replicated policies that were deleted or
+// modified by something other than their status
+var replicatedPolicyPredicateFuncs = predicate.Funcs{
+	CreateFunc: func(e event.CreateEvent) bool { return false },
+	DeleteFunc: func(e event.DeleteEvent) bool {
+		_, replicated := e.Object.GetLabels()[common.RootPolicyLabel]
+
+		return replicated
+	},
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		if _, replicated := e.ObjectNew.GetLabels()[common.RootPolicyLabel]; !replicated {
+			return false
+		}
+
+		// The GitOps tracking labels and annotations are never replicated, so a GitOps tool setting
+		// them is not a change to restore
+		return e.ObjectOld.GetGeneration() != e.ObjectNew.GetGeneration() ||
+			!equality.Semantic.DeepEqual(
+				common.WithoutGitOpsTrackingKeys(e.ObjectOld.GetLabels()),
+				common.WithoutGitOpsTrackingKeys(e.ObjectNew.GetLabels()),
+			) ||
+			!equality.Semantic.DeepEqual(
+				common.WithoutGitOpsTrackingKeys(e.ObjectOld.GetAnnotations()),
+				common.WithoutGitOpsTrackingKeys(e.ObjectNew.GetAnnotations()),
+			)
+	},
+	GenericFunc: func(e event.GenericEvent) bool { return false },
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ReplicatedPolicyReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	maxConcurrentReconciles := r.MaxConcurrentReconciles
+	if maxConcurrentReconciles <= 0 {
+		maxConcurrentReconciles = r.decisionConcurrency
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(ReplicatedPolicyControllerName).
+		WithOptions(controller.Options{MaxConcurrentReconciles: maxConcurrentReconciles}).
+		For(
+			&policiesv1.Policy{},
+			builder.WithPredicates(replicatedPolicyPredicateFuncs)).
+		Complete(r)
+}
+
+// blank assignment to verify that ReplicatedPolicyReconciler implements reconcile.Reconciler
+var _ reconcile.Reconciler = &ReplicatedPolicyReconciler{}
+
+// ReplicatedPolicyReconciler reconciles a single replicated policy in the format of
+// <cluster namespace>/<root namespace>.<root name> against the decisions last resolved by the
+// PolicyReconciler for its root policy. This restores a modified or deleted replicated policy
+// without replicating the root policy to all of its clusters again.
+type ReplicatedPolicyReconciler struct {
+	*PolicyReconciler
+	// MaxConcurrentReconciles is the number of replicated policies reconciled at the same time. It
+	// defaults to the decision concurrency of the PolicyReconciler.
+	MaxConcurrentReconciles int
+}
+
+// NewReplicatedPolicyReconciler returns a ReplicatedPolicyReconciler sharing the configuration and
+// the state of the PolicyReconciler
+func NewReplicatedPolicyReconciler(r *PolicyReconciler) *ReplicatedPolicyReconciler {
+	return &ReplicatedPolicyReconciler{PolicyReconciler: r}
+}
+
+// Reconcile creates, updates, or deletes the replicated policy based on its root policy
+func (r *ReplicatedPolicyReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+
+	// The uninstall controller deletes the replicated policies
+	if uninstall.Uninstalling() {
+		return reconcile.Result{}, nil
+	}
+
+	// The name of the replicated policy is in the format of <root namespace>.<root name>, and the
+	// namespace can't have a dot
+	rootName := strings.SplitN(request.Name, ".", 2)
+	if len(rootName) != 2 {
+		return reconcile.Result{}, nil
+	}
+
+	rootKey := types.NamespacedName{Namespace: rootName[0], Name: rootName[1]}
+
+	// The replicated policies of the root policies of the other shards are reconciled by their
+	// replicas
+	if !r.shard.Owns(rootKey) {
+		return reconcile.Result{}, nil
+	}
+
+	instance := &policiesv1.Policy{}
+
+	err := r.Get(ctx, rootKey, instance)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			reqLogger.Info("The root policy was not found, deleting the replicated policy...")
+
+			return reconcile.Result{}, r.deleteReplicatedPolicy(ctx, request.NamespacedName, rootKey.String())
+		}
+		// Error reading the object - requeue the request.
+		return reconcile.Result{}, err
+	}
+
+	// The root policy reconcile previews the propagation of the root policies in dry run, and the
+	// replicated policies of the paused root policies are frozen
+	if isDryRun(instance) || isPropagationPaused(instance) {
+		return reconcile.Result{}, nil
+	}
+
+	if instance.Spec.Disabled {
+		return reconcile.Result{}, r.deleteReplicatedPolicy(ctx, request.NamespacedName, rootKey.String())
+	}
+
+	// The root policy reconcile replicates the policy to the clusters of the current wave
+	if instance.Status.Rollout != nil && instance.Status.Rollout.State != policiesv1.RolloutCompleted {
+		return reconcile.Result{}, nil
+	}
+
+	clusterName, selected, resolved := r.propagationState.clusterDecision(rootKey.String(), request.Namespace)
+	if !resolved {
+		// The root policy reconcile resolves the decisions and replicates the policy
+		reqLogger.Info("The decisions of the root policy are not resolved yet, doing nothing...")
+
+		return reconcile.Result{}, nil
+	}
+
+	if !selected {
+		reqLogger.Info("The cluster is not selected by the root policy, deleting the replicated policy...")
+
+		return reconcile.Result{}, r.deleteReplicatedPolicy(ctx, request.NamespacedName, rootKey.String())
+	}
+
+	cfg, err := r.getPropagationConfig(ctx, instance.GetNamespace())
+	if err != nil {
+		reqLogger.Error(err, "Failed to get the PropagationConfig of the namespace...")
+
+		return reconcile.Result{}, err
+	}
+
+	// The root policy reconcile skips the unavailable clusters before replicating the policy, and
+	// it is reconciled again when the cluster is available
+	unavailable, err := r.clusterUnavailable(ctx, instance, clusterName)
+	if err != nil {
+		reqLogger.Error(err, "Failed to check the availability of the cluster...")
+
+		return reconcile.Result{}, err
+	}
+
+	if unavailable != nil {
+		reqLogger.Info("The cluster is unavailable, skipping the replication...")
+
+		return reconcile.Result{}, nil
+	}
+
+	decision := appsv1.PlacementDecision{ClusterName: clusterName, ClusterNamespace: request.Namespace}
+
+	// The spec of the replicated policy modified in the cluster namespace is restored by the
+	// replication
+	driftedPlc, actualHash := r.driftedReplicatedPolicy(ctx, request.NamespacedName)
+
+	failure, templateErr := r.replicateDecision(ctx, instance, decision, cfg)
+	if failure != nil && failure.reason == reasonDependenciesPending {
+		// The root policy is reconciled again when the compliance of its dependencies changes
+		reqLogger.Info("The dependencies of the policy are not met, skipping the replication...",
+			"Reason", failure.message)
+
+		return reconcile.Result{}, nil
+	}
+
+	if failure != nil && failure.reason == reasonNameCollision {
+		// The replicated policy is reconciled again when the policy it collides with is deleted
+		reqLogger.Info("The replicated policy name collides with another policy, skipping the replication...",
+			"Reason", failure.message)
+
+		return reconcile.Result{}, nil
+	}
+
+	if failure != nil && failure.reason == reasonPendingUpdate {
+		opens, err := maintenanceWindowOpens(instance, r.clock.Now())
+		if err != nil || opens.IsZero() {
+			return reconcile.Result{}, err
+		}
+
+		reqLogger.Info("The maintenance window of the policy is closed, updating it when it opens...",
+			"Opens", opens)
+
+		return reconcile.Result{RequeueAfter: opens.Sub(r.clock.Now())}, nil
+	}
+
+	if failure != nil && failure.reason == reasonTemplateResolverFailed {
+		// The replicated policy was updated with the error on its policy templates
+		propagationFailuresCounter.WithLabelValues(clusterName, failure.reason).Inc()
+
+		err = r.setTemplateResolverFailure(ctx, instance, request.Namespace, *failure)
+		if err != nil {
+			reqLogger.Error(err, "Failed to set the template resolver failure in the root policy status...")
+
+			return reconcile.Result{}, err
+		}
+
+		requeueDelay := r.requeueBackoff.next(request.String(), 1)
+
+		reqLogger.Info("Failed to create the template resolver, retrying later...",
+			"RequeueAfter", requeueDelay.String())
+
+		return reconcile.Result{RequeueAfter: requeueDelay}, nil
+	}
+
+	if failure != nil {
+		requeueDelay := r.requeueBackoff.next(request.String(), 1)
+
+		reqLogger.Info("Failed to replicate the policy, retrying later...", "Reason", failure.reason,
+			"RequeueAfter", requeueDelay.String())
+
+		if failure.isFailure() {
+			propagationFailuresCounter.WithLabelValues(clusterName, failure.reason).Inc()
+		}
+
+		// Only this cluster is retried instead of the whole root policy
+		return reconcile.Result{RequeueAfter: requeueDelay}, nil
+	}
+
+	r.requeueBackoff.reset(request.String())
+
+	if driftedPlc != nil {
+		reqLogger.Info("The replicated policy was modified in the cluster namespace and was restored...")
+
+		r.Recorder.Event(instance, "Normal", "PolicyDriftRepaired",
+			fmt.Sprintf("The replicated policy %s/%s was modified in the cluster namespace and was restored from "+
+				"the root policy (expected spec hash %s, found %s)", driftedPlc.GetNamespace(), driftedPlc.GetName(),
+				driftedPlc.GetAnnotations()[specHashAnnotation], actualHash))
+	}
+
+	err = r.setTemplateError(ctx, instance, request.Namespace, templateErr)
+	if err != nil {
+		reqLogger.Error(err, "Failed to set the hub template error in the root policy status...")
+
+		return reconcile.Result{}, err
+	}
+
+	return reconcile.Result{}, nil
+}
+
+// deleteReplicatedPolicy deletes the replicated policy if it exists
+func (r *ReplicatedPolicyReconciler) deleteReplicatedPolicy(
+	ctx context.Context, key types.NamespacedName, rootName string,
+) error {
+	replicatedPlc := &policiesv1.Policy{}
+
+	err := r.Get(ctx, key, replicatedPlc)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	// The policy with the name of the replicated policy belongs to another root policy
+	if replicatedPlc.GetLabels()[common.RootPolicyLabel] != key.Name {
+		return nil
+	}
+
+	if r.namespaceTerminating(ctx, key.Namespace) {
+		if r.pruneTerminatingReplicatedPolicy(ctx, key) {
+			r.recordClusterNamespaceEvent(replicatedPlc, rootName, replicatedPolicyDeleted)
+		}
+
+		return nil
+	}
+
+	err = r.Delete(ctx, replicatedPlc)
+	if err != nil && !errors.IsNotFound(err) {
+		log.Error(err, "Failed to delete the replicated policy...", "Namespace", key.Namespace, "Name", key.Name)
+
+		return err
+	}
+
+	r.recordClusterNamespaceEvent(replicatedPlc, rootName, replicatedPolicyDeleted)
+
+	return nil
+}
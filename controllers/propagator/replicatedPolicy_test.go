@@ -0,0 +1,129 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	policyv1beta1 "github.com/open-cluster-management/governance-policy-propagator/api/v1beta1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+func TestReplicatedPolicyReconcile(t *testing.T) {
+	root := newTestPolicy("default")
+	stale := newTestReplicatedPolicy(root, "cluster2", policiesv1.Compliant)
+
+	r := NewReplicatedPolicyReconciler(newTestReconciler(t, &stubResolver{}, root, stale))
+
+	reconcileReplicated := func(clusterNamespace string) {
+		t.Helper()
+
+		_, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{
+			Namespace: clusterNamespace, Name: common.FullNameForPolicy(root),
+		}})
+		if err != nil {
+			t.Fatalf("Reconcile returned an error: %v", err)
+		}
+	}
+
+	getReplicated := func(clusterNamespace string) (*policiesv1.Policy, error) {
+		replicated := &policiesv1.Policy{}
+		err := r.Get(context.TODO(), types.NamespacedName{
+			Namespace: clusterNamespace, Name: common.FullNameForPolicy(root),
+		}, replicated)
+
+		return replicated, err
+	}
+
+	// Nothing is done until the root policy reconcile resolves the decisions
+	reconcileReplicated("cluster1")
+	reconcileReplicated("cluster2")
+
+	if _, err := getReplicated("cluster2"); err != nil {
+		t.Fatalf("expected the replicated policy to be kept before the decisions are resolved: %v", err)
+	}
+
+	r.propagationState.setResolved("policies/policy", nil, map[string]bool{"cluster1/cluster1": true})
+
+	reconcileReplicated("cluster1")
+
+	replicated, err := getReplicated("cluster1")
+	if err != nil {
+		t.Fatalf("expected the replicated policy to be created: %v", err)
+	}
+
+	if replicated.GetLabels()[common.ClusterNameLabel] != "cluster1" {
+		t.Fatalf("expected the cluster name label, got %v", replicated.GetLabels())
+	}
+
+	// The cluster is no longer selected by the root policy
+	reconcileReplicated("cluster2")
+
+	if _, err := getReplicated("cluster2"); !errors.IsNotFound(err) {
+		t.Fatalf("expected the replicated policy of the unselected cluster to be deleted, got %v", err)
+	}
+
+	// The root policy is deleted
+	if err := r.Delete(context.TODO(), root); err != nil {
+		t.Fatalf("failed to delete the root policy: %v", err)
+	}
+
+	reconcileReplicated("cluster1")
+
+	if _, err := getReplicated("cluster1"); !errors.IsNotFound(err) {
+		t.Fatalf("expected the replicated policy of the deleted root policy to be deleted, got %v", err)
+	}
+}
+
+func TestReplicatedPolicyReconcileDrift(t *testing.T) {
+	decision := appsv1.PlacementDecision{ClusterName: "cluster1", ClusterNamespace: "cluster1"}
+	root := newTestPolicy("default")
+	root.Spec.RemediationAction = policiesv1.Inform
+
+	r := NewReplicatedPolicyReconciler(newTestReconciler(t, &stubResolver{}, root))
+	r.propagationState.setResolved("policies/policy", nil, map[string]bool{"cluster1/cluster1": true})
+
+	if _, err := r.handleDecision(context.TODO(), root, decision, policyv1beta1.PropagationConfigSpec{}); err != nil {
+		t.Fatalf("handleDecision returned an error: %v", err)
+	}
+
+	key := types.NamespacedName{Namespace: "cluster1", Name: common.FullNameForPolicy(root)}
+	replicated := &policiesv1.Policy{}
+
+	if err := r.Get(context.TODO(), key, replicated); err != nil {
+		t.Fatalf("failed to get the replicated policy: %v", err)
+	}
+
+	replicated.Spec.RemediationAction = policiesv1.Enforce
+	if err := r.Update(context.TODO(), replicated); err != nil {
+		t.Fatalf("failed to modify the replicated policy: %v", err)
+	}
+
+	if _, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatalf("Reconcile returned an error: %v", err)
+	}
+
+	if err := r.Get(context.TODO(), key, replicated); err != nil {
+		t.Fatalf("failed to get the replicated policy: %v", err)
+	}
+
+	if replicated.Spec.RemediationAction != policiesv1.Inform {
+		t.Fatalf("expected the drift to be repaired, got %s", replicated.Spec.RemediationAction)
+	}
+
+	for _, event := range events(r.PolicyReconciler) {
+		if strings.HasPrefix(event, "Normal PolicyDriftRepaired The replicated policy cluster1/policies.policy") {
+			return
+		}
+	}
+
+	t.Fatal("expected a PolicyDriftRepaired event")
+}
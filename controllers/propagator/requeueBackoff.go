@@ -0,0 +1,73 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"sync"
+	"time"
+)
+
+// The configuration in minutes of the maximum delay to requeue after when the retries keep
+// failing. The delay starts at the CONTROLLER_CONFIG_REQUEUE_ERROR_DELAY and doubles on every
+// failure up to this delay.
+const requeueErrorMaxDelayEnvName = "CONTROLLER_CONFIG_REQUEUE_ERROR_MAX_DELAY"
+const requeueErrorMaxDelayDefault = 60
+
+// backoffClustersPerStep is the number of clusters of a root policy adding the minimum delay to
+// the first delay of its backoff, so that the policies replicated to more clusters are retried
+// less often
+const backoffClustersPerStep = 1000
+
+// requeueBackoff is the exponential backoff of the requests that failed after several retries.
+// Every request has its own backoff, which is reset once the request succeeds.
+type requeueBackoff struct {
+	min time.Duration
+	max time.Duration
+
+	lock sync.Mutex
+	// failures are the number of consecutive failures of the requests
+	failures map[string]int
+}
+
+func newRequeueBackoff(min time.Duration, max time.Duration) *requeueBackoff {
+	if max < min {
+		max = min
+	}
+
+	return &requeueBackoff{min: min, max: max, failures: map[string]int{}}
+}
+
+// next records another failure of the request and returns the delay to requeue it after. The
+// delay starts at the minimum delay for every backoffClustersPerStep clusters the request
+// replicates to, and doubles on every consecutive failure up to the maximum delay.
+func (b *requeueBackoff) next(key string, clusters int) time.Duration {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	failures := b.failures[key]
+	b.failures[key] = failures + 1
+
+	delay := b.min * time.Duration(1+clusters/backoffClustersPerStep)
+
+	for i := 0; i < failures && delay < b.max; i++ {
+		delay *= 2
+	}
+
+	if delay > b.max {
+		return b.max
+	}
+
+	return delay
+}
+
+// reset resets the backoff of the request once it succeeded
+func (b *requeueBackoff) reset(key string) {
+	if b == nil {
+		return
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	delete(b.failures, key)
+}
@@ -0,0 +1,54 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRequeueBackoff(t *testing.T) {
+	backoff := newRequeueBackoff(5*time.Minute, time.Hour)
+
+	expected := []time.Duration{5 * time.Minute, 10 * time.Minute, 20 * time.Minute, 40 * time.Minute, time.Hour}
+	for _, delay := range expected {
+		if actual := backoff.next("policies/policy", 10); actual != delay {
+			t.Fatalf("expected a delay of %v, got %v", delay, actual)
+		}
+	}
+
+	// The other requests have their own backoff
+	if actual := backoff.next("policies/other", 10); actual != 5*time.Minute {
+		t.Fatalf("expected the other request to be requeued after the minimum delay, got %v", actual)
+	}
+
+	backoff.reset("policies/policy")
+
+	if actual := backoff.next("policies/policy", 10); actual != 5*time.Minute {
+		t.Fatalf("expected the backoff to be reset, got %v", actual)
+	}
+}
+
+func TestRequeueBackoffFleetSize(t *testing.T) {
+	backoff := newRequeueBackoff(5*time.Minute, time.Hour)
+
+	// The policies replicated to more clusters are retried less often
+	if actual := backoff.next("policies/policy", 2000); actual != 15*time.Minute {
+		t.Fatalf("expected a delay of 15m for 2000 clusters, got %v", actual)
+	}
+
+	if actual := backoff.next("policies/policy", 2000); actual != 30*time.Minute {
+		t.Fatalf("expected the delay to double, got %v", actual)
+	}
+}
+
+func TestRequeueBackoffFixed(t *testing.T) {
+	// The delay is fixed without a greater maximum delay
+	backoff := newRequeueBackoff(time.Minute, 0)
+
+	for i := 0; i < 3; i++ {
+		if actual := backoff.next("policies/policy", 5000); actual != time.Minute {
+			t.Fatalf("expected a fixed delay of 1m, got %v", actual)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"fmt"
+	"sort"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+// rolloutWave is a set of clusters in the format of <namespace>/<name> rolled out together
+type rolloutWave struct {
+	decisionGroup string
+	clusters      []string
+}
+
+// rolloutWaves splits the clusters into the waves of the rollout strategy. The clusters are
+// ordered by the decision groups of the placements, and the clusters without a decision group
+// come last.
+func rolloutWaves(
+	strategy policiesv1.RolloutStrategy, placements []*policiesv1.Placement, decisions []appsv1.PlacementDecision,
+) []rolloutWave {
+	groupOrder := map[string]int{}
+	clusterGroups := map[string]string{}
+
+	for _, placement := range placements {
+		for _, group := range placement.DecisionGroups {
+			if _, ok := groupOrder[group.Name]; !ok {
+				groupOrder[group.Name] = len(groupOrder)
+			}
+
+			for _, cluster := range group.Clusters {
+				if _, ok := clusterGroups[cluster]; !ok {
+					clusterGroups[cluster] = group.Name
+				}
+			}
+		}
+	}
+
+	sorted := make([]appsv1.PlacementDecision, len(decisions))
+	copy(sorted, decisions)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		iOrder, iGrouped := groupOrder[clusterGroups[sorted[i].ClusterName]]
+		jOrder, jGrouped := groupOrder[clusterGroups[sorted[j].ClusterName]]
+
+		if iGrouped != jGrouped {
+			return iGrouped
+		}
+
+		if iOrder != jOrder {
+			return iOrder < jOrder
+		}
+
+		return sorted[i].ClusterName < sorted[j].ClusterName
+	})
+
+	waves := []rolloutWave{}
+
+	for _, decision := range sorted {
+		key := fmt.Sprintf("%s/%s", decision.ClusterNamespace, decision.ClusterName)
+		group := clusterGroups[decision.ClusterName]
+
+		newWave := len(waves) == 0
+		if !newWave {
+			last := waves[len(waves)-1]
+
+			if strategy.Type == policiesv1.RolloutProgressivePerGroup {
+				newWave = last.decisionGroup != group
+			} else {
+				maxConcurrency := strategy.MaxConcurrency
+				if maxConcurrency <= 0 {
+					maxConcurrency = 1
+				}
+
+				newWave = len(last.clusters) >= maxConcurrency
+			}
+		}
+
+		if newWave {
+			wave := rolloutWave{}
+			if strategy.Type == policiesv1.RolloutProgressivePerGroup {
+				wave.decisionGroup = group
+			}
+
+			waves = append(waves, wave)
+		}
+
+		waves[len(waves)-1].clusters = append(waves[len(waves)-1].clusters, key)
+	}
+
+	return waves
+}
+
+// rolloutClusters returns the clusters in the format of <namespace>/<name> the policy is rolled
+// out to with the rollout strategy, and the progress of the rollout. The next wave is only rolled
+// out when all the clusters of the current wave are compliant, and the rollout is paused when one
+// of them is noncompliant. Nil is returned for both when all the clusters are rolled out at once.
+func rolloutClusters(
+	strategy *policiesv1.RolloutStrategy, placements []*policiesv1.Placement,
+	decisions []appsv1.PlacementDecision, status []*policiesv1.CompliancePerClusterStatus,
+) (map[string]bool, *policiesv1.RolloutStatus) {
+	if strategy == nil || strategy.Type == "" || strategy.Type == policiesv1.RolloutAll || len(decisions) == 0 {
+		return nil, nil
+	}
+
+	compliance := map[string]policiesv1.ComplianceState{}
+	for _, cpcs := range status {
+		compliance[fmt.Sprintf("%s/%s", cpcs.ClusterNamespace, cpcs.ClusterName)] = cpcs.ComplianceState
+	}
+
+	waves := rolloutWaves(*strategy, placements, decisions)
+	rolledOut := map[string]bool{}
+
+	for i, wave := range waves {
+		rollout := &policiesv1.RolloutStatus{Wave: i, DecisionGroup: wave.decisionGroup}
+		pending := []string{}
+
+		for _, cluster := range wave.clusters {
+			rolledOut[cluster] = true
+
+			switch compliance[cluster] {
+			case policiesv1.Compliant:
+			case policiesv1.NonCompliant:
+				rollout.State = policiesv1.RolloutPaused
+				rollout.Message = fmt.Sprintf("The rollout is paused since the cluster %s is noncompliant", cluster)
+			default:
+				pending = append(pending, cluster)
+			}
+		}
+
+		if rollout.State == policiesv1.RolloutPaused {
+			return rolledOut, rollout
+		}
+
+		if len(pending) > 0 {
+			rollout.State = policiesv1.RolloutProgressing
+			rollout.Message = fmt.Sprintf(
+				"Waiting for %d of the %d clusters of the wave to be compliant", len(pending), len(wave.clusters),
+			)
+
+			return rolledOut, rollout
+		}
+	}
+
+	last := waves[len(waves)-1]
+
+	return rolledOut, &policiesv1.RolloutStatus{
+		State:         policiesv1.RolloutCompleted,
+		Wave:          len(waves) - 1,
+		DecisionGroup: last.decisionGroup,
+		Message:       fmt.Sprintf("The policy is rolled out to all the %d clusters", len(decisions)),
+	}
+}
@@ -0,0 +1,204 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+func rolloutTestDecisions(clusters ...string) []appsv1.PlacementDecision {
+	decisions := make([]appsv1.PlacementDecision, 0, len(clusters))
+	for _, cluster := range clusters {
+		decisions = append(decisions, appsv1.PlacementDecision{ClusterName: cluster, ClusterNamespace: cluster})
+	}
+
+	return decisions
+}
+
+func rolloutTestStatus(states map[string]policiesv1.ComplianceState) []*policiesv1.CompliancePerClusterStatus {
+	status := []*policiesv1.CompliancePerClusterStatus{}
+	for cluster, state := range states {
+		status = append(status, &policiesv1.CompliancePerClusterStatus{
+			ClusterName: cluster, ClusterNamespace: cluster, ComplianceState: state,
+		})
+	}
+
+	return status
+}
+
+func TestRolloutClustersAll(t *testing.T) {
+	decisions := rolloutTestDecisions("cluster1", "cluster2")
+
+	for _, strategy := range []*policiesv1.RolloutStrategy{nil, {}, {Type: policiesv1.RolloutAll}} {
+		rolledOut, rollout := rolloutClusters(strategy, nil, decisions, nil)
+		if rolledOut != nil || rollout != nil {
+			t.Fatalf("expected all the clusters to be rolled out at once with %v", strategy)
+		}
+	}
+}
+
+func TestRolloutClustersProgressivePerGroup(t *testing.T) {
+	strategy := &policiesv1.RolloutStrategy{Type: policiesv1.RolloutProgressivePerGroup}
+	placements := []*policiesv1.Placement{{
+		DecisionGroups: []policiesv1.DecisionGroup{
+			{Name: "canary", Clusters: []string{"cluster3"}},
+			{Name: "prod", Clusters: []string{"cluster1", "cluster2"}},
+		},
+	}}
+	decisions := rolloutTestDecisions("cluster1", "cluster2", "cluster3", "cluster4")
+
+	tests := []struct {
+		name     string
+		states   map[string]policiesv1.ComplianceState
+		expected []string
+		state    string
+		wave     int
+		group    string
+	}{
+		{
+			"first wave", nil,
+			[]string{"cluster3/cluster3"}, policiesv1.RolloutProgressing, 0, "canary",
+		},
+		{
+			"paused", map[string]policiesv1.ComplianceState{"cluster3": policiesv1.NonCompliant},
+			[]string{"cluster3/cluster3"}, policiesv1.RolloutPaused, 0, "canary",
+		},
+		{
+			"second wave", map[string]policiesv1.ComplianceState{"cluster3": policiesv1.Compliant},
+			[]string{"cluster3/cluster3", "cluster1/cluster1", "cluster2/cluster2"}, policiesv1.RolloutProgressing, 1, "prod",
+		},
+		{
+			"ungrouped clusters last",
+			map[string]policiesv1.ComplianceState{
+				"cluster1": policiesv1.Compliant, "cluster2": policiesv1.Compliant, "cluster3": policiesv1.Compliant,
+			},
+			[]string{"cluster1/cluster1", "cluster2/cluster2", "cluster3/cluster3", "cluster4/cluster4"},
+			policiesv1.RolloutProgressing, 2, "",
+		},
+		{
+			"completed",
+			map[string]policiesv1.ComplianceState{
+				"cluster1": policiesv1.Compliant, "cluster2": policiesv1.Compliant,
+				"cluster3": policiesv1.Compliant, "cluster4": policiesv1.Compliant,
+			},
+			[]string{"cluster1/cluster1", "cluster2/cluster2", "cluster3/cluster3", "cluster4/cluster4"},
+			policiesv1.RolloutCompleted, 2, "",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			rolledOut, rollout := rolloutClusters(strategy, placements, decisions, rolloutTestStatus(test.states))
+
+			if len(rolledOut) != len(test.expected) {
+				t.Fatalf("expected the clusters %v to be rolled out, got %v", test.expected, rolledOut)
+			}
+
+			for _, cluster := range test.expected {
+				if !rolledOut[cluster] {
+					t.Fatalf("expected the clusters %v to be rolled out, got %v", test.expected, rolledOut)
+				}
+			}
+
+			if rollout.State != test.state || rollout.Wave != test.wave || rollout.DecisionGroup != test.group {
+				t.Fatalf("expected the rollout %s of the wave %d (%q), got %+v", test.state, test.wave, test.group, rollout)
+			}
+		})
+	}
+}
+
+func TestRolloutClustersProgressive(t *testing.T) {
+	strategy := &policiesv1.RolloutStrategy{Type: policiesv1.RolloutProgressive, MaxConcurrency: 2}
+	decisions := rolloutTestDecisions("cluster3", "cluster1", "cluster2")
+
+	rolledOut, rollout := rolloutClusters(strategy, nil, decisions, nil)
+	if len(rolledOut) != 2 || !rolledOut["cluster1/cluster1"] || !rolledOut["cluster2/cluster2"] {
+		t.Fatalf("expected the first two clusters to be rolled out, got %v", rolledOut)
+	}
+
+	if rollout.State != policiesv1.RolloutProgressing || rollout.Wave != 0 {
+		t.Fatalf("expected the first wave to be progressing, got %+v", rollout)
+	}
+
+	status := rolloutTestStatus(map[string]policiesv1.ComplianceState{
+		"cluster1": policiesv1.Compliant, "cluster2": policiesv1.NonCompliant,
+	})
+
+	rolledOut, rollout = rolloutClusters(strategy, nil, decisions, status)
+	if len(rolledOut) != 2 || rollout.State != policiesv1.RolloutPaused {
+		t.Fatalf("expected the rollout to pause on the noncompliant cluster, got %v %+v", rolledOut, rollout)
+	}
+
+	status = rolloutTestStatus(map[string]policiesv1.ComplianceState{
+		"cluster1": policiesv1.Compliant, "cluster2": policiesv1.Compliant,
+	})
+
+	rolledOut, rollout = rolloutClusters(strategy, nil, decisions, status)
+	if len(rolledOut) != 3 || rollout.Wave != 1 || rollout.DecisionGroup != "" {
+		t.Fatalf("expected the second wave to be rolled out, got %v %+v", rolledOut, rollout)
+	}
+}
+
+func TestHandleRootPolicyRolloutFeatureGate(t *testing.T) {
+	plr := &appsv1.PlacementRule{
+		ObjectMeta: metav1.ObjectMeta{Name: "plr", Namespace: "policies"},
+		Status: appsv1.PlacementRuleStatus{
+			Decisions: rolloutTestDecisions("cluster1", "cluster2"),
+		},
+	}
+	pb := &policiesv1.PlacementBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "pb", Namespace: "policies"},
+		PlacementRef: policiesv1.Subject{
+			APIGroup: appsv1.SchemeGroupVersion.Group, Kind: "PlacementRule", Name: "plr",
+		},
+		Subjects: []policiesv1.Subject{
+			{APIGroup: policiesv1.SchemeGroupVersion.Group, Kind: policiesv1.Kind, Name: "policy"},
+		},
+	}
+
+	tests := []struct {
+		featureGates string
+		replicated   int
+	}{
+		{"", 2},
+		{"RolloutStrategies=true", 1},
+	}
+
+	// The feature gates are global, so they are reset even when the test fails
+	t.Cleanup(func() { _ = common.SetFeatureGates("") })
+
+	for _, test := range tests {
+		if err := common.SetFeatureGates(test.featureGates); err != nil {
+			t.Fatalf("failed to set the feature gates: %v", err)
+		}
+
+		root := newTestPolicy("default")
+		root.Spec.RolloutStrategy = &policiesv1.RolloutStrategy{Type: policiesv1.RolloutProgressive}
+
+		r := newTestReconciler(t, &stubResolver{}, root, plr, pb)
+
+		if err := r.handleRootPolicy(context.TODO(), root, nil); err != nil {
+			t.Fatalf("handleRootPolicy returned an error: %v", err)
+		}
+
+		replicated := &policiesv1.PolicyList{}
+		if err := r.List(context.TODO(), replicated, client.HasLabels{common.RootPolicyLabel}); err != nil {
+			t.Fatalf("failed to list the replicated policies: %v", err)
+		}
+
+		if len(replicated.Items) != test.replicated {
+			t.Fatalf("expected %d replicated policies with the feature gates %q, got %d",
+				test.replicated, test.featureGates, len(replicated.Items))
+		}
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+)
+
+// setCondition sets the condition on the root policy for its current generation. The transition
+// time is only updated when the status of the condition changes.
+func setCondition(
+	instance *policiesv1.Policy, conditionType string, status metav1.ConditionStatus, reason, message string,
+) {
+	meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		ObservedGeneration: instance.GetGeneration(),
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+// setPropagationConditions sets the PlacementResolved, TemplatesResolved, and Propagated conditions
+// on the root policy once its placement decisions were handled. The placements, allDecisions,
+// failedClusters, and templateErrors are the ones returned by handleDecisions.
+func setPropagationConditions(
+	instance *policiesv1.Policy,
+	hasTemplates bool,
+	placements []*policiesv1.Placement,
+	allDecisions map[string]bool,
+	failedClusters map[string]replicationFailure,
+	templateErrors map[string]string,
+) {
+	unresolved := []string{}
+
+	for _, placement := range placements {
+		if placement.Message != "" {
+			unresolved = append(unresolved, placement.Message)
+		}
+	}
+
+	if len(unresolved) > 0 {
+		setCondition(instance, policiesv1.PlacementResolvedCondition, metav1.ConditionFalse,
+			reasonClusterSetsNotBound, strings.Join(unresolved, "; "))
+	} else {
+		setCondition(instance, policiesv1.PlacementResolvedCondition, metav1.ConditionTrue, "PlacementResolved",
+			fmt.Sprintf("The placement bindings of the policy selected %d clusters", len(allDecisions)))
+	}
+
+	switch {
+	case !hasTemplates:
+		setCondition(instance, policiesv1.TemplatesResolvedCondition, metav1.ConditionTrue, "NoHubTemplates",
+			"The policy has no hub templates")
+	case len(templateErrors) > 0:
+		setCondition(instance, policiesv1.TemplatesResolvedCondition, metav1.ConditionFalse, "TemplateErrors",
+			fmt.Sprintf("The hub templates failed to resolve for %d of %d clusters, see their status",
+				len(templateErrors), len(allDecisions)))
+	default:
+		setCondition(instance, policiesv1.TemplatesResolvedCondition, metav1.ConditionTrue, "TemplatesResolved",
+			"The hub templates were resolved for all of the clusters")
+	}
+
+	failed := 0
+
+	for _, failure := range failedClusters {
+		if failure.isFailure() {
+			failed++
+		}
+	}
+
+	switch {
+	case instance.Spec.Disabled:
+		setCondition(instance, policiesv1.PropagatedCondition, metav1.ConditionFalse, "PolicyDisabled",
+			"The policy is disabled")
+	case failed > 0:
+		setCondition(instance, policiesv1.PropagatedCondition, metav1.ConditionFalse, reasonReplicationFailed,
+			fmt.Sprintf("The policy failed to be replicated to %d of %d clusters, see their status",
+				failed, len(allDecisions)))
+	default:
+		setCondition(instance, policiesv1.PropagatedCondition, metav1.ConditionTrue, "Propagated",
+			fmt.Sprintf("The policy was replicated to all of the %d clusters", len(allDecisions)-len(failedClusters)))
+	}
+}
+
+// setCompliantCondition sets the Compliant condition on the root policy from its aggregated
+// compliance state
+func setCompliantCondition(instance *policiesv1.Policy) {
+	switch instance.Status.ComplianceState {
+	case policiesv1.Compliant:
+		setCondition(instance, policiesv1.CompliantCondition, metav1.ConditionTrue, "Compliant",
+			"All of the clusters are compliant")
+	case policiesv1.NonCompliant:
+		noncompliant := 0
+
+		for _, cpcs := range instance.Status.Status {
+			if cpcs.ComplianceState == policiesv1.NonCompliant {
+				noncompliant++
+			}
+		}
+
+		setCondition(instance, policiesv1.CompliantCondition, metav1.ConditionFalse, "NonCompliant",
+			fmt.Sprintf("%d of %d clusters are noncompliant", noncompliant, len(instance.Status.Status)))
+	case policiesv1.Pending:
+		setCondition(instance, policiesv1.CompliantCondition, metav1.ConditionFalse, "Pending",
+			"The compliance of some of the clusters is pending")
+	default:
+		setCondition(instance, policiesv1.CompliantCondition, metav1.ConditionUnknown, "NoCompliance",
+			"None of the clusters reported their compliance yet")
+	}
+}
+
+// setFailedCondition sets the condition on the root policy to False for a failure that prevents
+// the propagation, so that the failure is visible in the status and not only in the events. The
+// status update failures are only logged since the failure is returned by the caller.
+func (r *PolicyReconciler) setFailedCondition(
+	ctx context.Context, instance *policiesv1.Policy, conditionType, reason, message string,
+) {
+	original := instance.DeepCopy()
+
+	setCondition(instance, conditionType, metav1.ConditionFalse, reason, message)
+
+	err := r.Status().Patch(ctx, instance, client.MergeFrom(original))
+	if err != nil {
+		log.Error(err, "Failed to set the condition on the root policy...", "Policy-Namespace",
+			instance.GetNamespace(), "Policy-Name", instance.GetName(), "Condition", conditionType)
+	}
+}
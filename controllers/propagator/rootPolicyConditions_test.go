@@ -0,0 +1,126 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+)
+
+func TestSetPropagationConditions(t *testing.T) {
+	allDecisions := map[string]bool{"cluster1/cluster1": true, "cluster2/cluster2": true}
+
+	tests := []struct {
+		name              string
+		disabled          bool
+		hasTemplates      bool
+		failedClusters    map[string]replicationFailure
+		templateErrors    map[string]string
+		expectedTemplates metav1.ConditionStatus
+		expectedReason    string
+	}{
+		{"propagated", false, true, nil, nil, metav1.ConditionTrue, "Propagated"},
+		{
+			"skipped clusters are not failures", false, false,
+			map[string]replicationFailure{"cluster2/cluster2": {reason: reasonClusterIncompatible}},
+			nil, metav1.ConditionTrue, "Propagated",
+		},
+		{
+			"replication failure", false, true,
+			map[string]replicationFailure{"cluster2/cluster2": {reason: reasonReplicationFailed}},
+			nil, metav1.ConditionTrue, reasonReplicationFailed,
+		},
+		{
+			"template error", false, true, nil, map[string]string{"cluster1/cluster1": "bad"},
+			metav1.ConditionFalse, "Propagated",
+		},
+		{"disabled", true, false, nil, nil, metav1.ConditionTrue, "PolicyDisabled"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			policy := &policiesv1.Policy{Spec: policiesv1.PolicySpec{Disabled: test.disabled}}
+
+			setPropagationConditions(policy, test.hasTemplates, nil, allDecisions, test.failedClusters, test.templateErrors)
+
+			if !meta.IsStatusConditionTrue(policy.Status.Conditions, policiesv1.PlacementResolvedCondition) {
+				t.Fatal("expected the placement to be resolved")
+			}
+
+			templates := meta.FindStatusCondition(policy.Status.Conditions, policiesv1.TemplatesResolvedCondition)
+			if templates == nil || templates.Status != test.expectedTemplates {
+				t.Fatalf("expected the TemplatesResolved status %s, got %v", test.expectedTemplates, templates)
+			}
+
+			propagated := meta.FindStatusCondition(policy.Status.Conditions, policiesv1.PropagatedCondition)
+			if propagated == nil || propagated.Reason != test.expectedReason {
+				t.Fatalf("expected the Propagated reason %s, got %v", test.expectedReason, propagated)
+			}
+
+			if (propagated.Status == metav1.ConditionTrue) != (test.expectedReason == "Propagated") {
+				t.Fatalf("expected the Propagated condition to only be True when propagated, got %v", propagated)
+			}
+		})
+	}
+}
+
+func TestSetCompliantCondition(t *testing.T) {
+	tests := map[policiesv1.ComplianceState]metav1.ConditionStatus{
+		policiesv1.Compliant:    metav1.ConditionTrue,
+		policiesv1.NonCompliant: metav1.ConditionFalse,
+		policiesv1.Pending:      metav1.ConditionFalse,
+		"":                      metav1.ConditionUnknown,
+	}
+
+	for compliance, expected := range tests {
+		policy := &policiesv1.Policy{Status: policiesv1.PolicyStatus{ComplianceState: compliance}}
+
+		setCompliantCondition(policy)
+
+		condition := meta.FindStatusCondition(policy.Status.Conditions, policiesv1.CompliantCondition)
+		if condition == nil || condition.Status != expected {
+			t.Fatalf("expected the Compliant status %s for %q, got %v", expected, compliance, condition)
+		}
+	}
+}
+
+func TestHandleRootPolicyConditions(t *testing.T) {
+	root := newTestPolicy("default")
+	root.SetGeneration(2)
+
+	pb := newTestPlacementBinding("pb", "plr", policiesv1.Subject{
+		APIGroup: policiesv1.SchemeGroupVersion.Group, Kind: policiesv1.Kind, Name: "policy",
+	})
+
+	r := newTestReconciler(t, &stubResolver{}, root, pb, newTestPlacementRule("plr", "cluster1"))
+
+	if err := r.handleRootPolicy(context.TODO(), root, nil); err != nil {
+		t.Fatalf("handleRootPolicy returned an error: %v", err)
+	}
+
+	updated := &policiesv1.Policy{}
+	if err := r.Get(context.TODO(), types.NamespacedName{Namespace: "policies", Name: "policy"}, updated); err != nil {
+		t.Fatalf("failed to get the root policy: %v", err)
+	}
+
+	expected := map[string]metav1.ConditionStatus{
+		policiesv1.PlacementResolvedCondition: metav1.ConditionTrue,
+		policiesv1.TemplatesResolvedCondition: metav1.ConditionTrue,
+		policiesv1.PropagatedCondition:        metav1.ConditionTrue,
+		// The managed cluster didn't report the compliance yet
+		policiesv1.CompliantCondition: metav1.ConditionUnknown,
+	}
+
+	for conditionType, status := range expected {
+		condition := meta.FindStatusCondition(updated.Status.Conditions, conditionType)
+		if condition == nil || condition.Status != status || condition.ObservedGeneration != 2 {
+			t.Fatalf("expected the %s condition to be %s for the generation 2, got %v", conditionType, status, condition)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	retry "github.com/avast/retry-go/v3"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+)
+
+// addRootPolicyFinalizer sets the finalizer on the root policy so that its replicated policies are
+// deleted before it is removed, even if the propagator is not running when it is deleted
+func (r *PolicyReconciler) addRootPolicyFinalizer(ctx context.Context, instance *policiesv1.Policy) error {
+	if controllerutil.ContainsFinalizer(instance, common.RootPolicyFinalizer) {
+		return nil
+	}
+
+	original := instance.DeepCopy()
+	controllerutil.AddFinalizer(instance, common.RootPolicyFinalizer)
+
+	return r.Patch(ctx, instance, client.MergeFrom(original))
+}
+
+// finalizeRootPolicy deletes the replicated policies of the root policy being deleted and then
+// removes its finalizer
+func (r *PolicyReconciler) finalizeRootPolicy(
+	ctx context.Context, instance *policiesv1.Policy,
+) (reconcile.Result, error) {
+	reqLogger := log.WithValues("Policy-Namespace", instance.GetNamespace(), "Policy-Name", instance.GetName())
+
+	if !controllerutil.ContainsFinalizer(instance, common.RootPolicyFinalizer) {
+		return reconcile.Result{}, nil
+	}
+
+	reqLogger.Info("Policy is being deleted, deleting the replicated policies...")
+
+	err := retry.Do(
+		func() error { return r.cleanUpPolicy(ctx, instance) },
+		r.getRetryOptions(ctx, reqLogger, "Retrying the policy clean up...")...,
+	)
+	if err != nil {
+		reqLogger.Info("Giving up on the policy clean up, retrying later...")
+		r.recordWarning(instance, "One or more replicated policies could not be deleted")
+
+		requeueDelay := r.requeueBackoff.next(
+			types.NamespacedName{Namespace: instance.GetNamespace(), Name: instance.GetName()}.String(),
+			len(instance.Status.Status),
+		)
+
+		return reconcile.Result{RequeueAfter: requeueDelay}, nil
+	}
+
+	r.forgetRootPolicy(types.NamespacedName{Namespace: instance.GetNamespace(), Name: instance.GetName()})
+
+	original := instance.DeepCopy()
+	controllerutil.RemoveFinalizer(instance, common.RootPolicyFinalizer)
+
+	err = r.Patch(ctx, instance, client.MergeFrom(original))
+	if err != nil && !k8serrors.IsNotFound(err) {
+		reqLogger.Error(err, "Failed to remove the finalizer from the root policy...")
+
+		return reconcile.Result{}, err
+	}
+
+	reqLogger.Info("Policy clean up complete, reconciliation completed.")
+
+	return reconcile.Result{}, nil
+}
+
+// forgetRootPolicy removes the state kept for the deleted root policy
+func (r *PolicyReconciler) forgetRootPolicy(root types.NamespacedName) {
+	r.placementKinds.delete(root.String())
+	replicatedPolicyGauge.DeleteLabelValues(root.Name, root.Namespace)
+	r.propagationState.delete(root.String())
+	r.decisionDiffs.forget(root)
+	r.propagationLatency.forget(root)
+	r.templateCache.deleteRoot(root.String())
+	r.templateWatcher.deleteRoot(root.String())
+	r.requeueBackoff.reset(root.String())
+}
+
+// The configuration in seconds of how often the replicated policies whose root policy or placement
+// decision no longer exists are deleted. Set it to 0 to only delete them when the propagator starts.
+const orphanGCIntervalEnvName = "CONTROLLER_CONFIG_ORPHAN_GC_INTERVAL"
+const orphanGCIntervalDefault = 600
+
+// orphanSweeper deletes the replicated policies whose root policy or placement decision no longer
+// exists when the propagator starts and then periodically, covering the deletions missed while the
+// propagator was not running
+type orphanSweeper struct {
+	reconciler *PolicyReconciler
+	interval   time.Duration
+}
+
+// Start sweeps the orphaned replicated policies once, and then at every interval until the context
+// is canceled if the interval is set
+func (s *orphanSweeper) Start(ctx context.Context) error {
+	if s.interval <= 0 {
+		s.reconciler.sweepOrphans(ctx)
+
+		return nil
+	}
+
+	wait.UntilWithContext(ctx, s.reconciler.sweepOrphans, s.interval)
+
+	return nil
+}
+
+// NeedLeaderElection makes only the leader delete the orphaned replicated policies
+func (s *orphanSweeper) NeedLeaderElection() bool {
+	return true
+}
+
+// sweepOrphans deletes the replicated policies whose root policy doesn't exist, or whose cluster is
+// no longer selected by the last resolved placement decisions of the root policy
+func (r *PolicyReconciler) sweepOrphans(ctx context.Context) {
+	replicatedPlcList := &policiesv1.PolicyList{}
+
+	err := r.List(ctx, replicatedPlcList, client.HasLabels{common.RootPolicyLabel})
+	if err != nil {
+		log.Error(err, "Failed to list the replicated policies to delete the orphaned ones...")
+
+		return
+	}
+
+	for i := range replicatedPlcList.Items {
+		replicatedPlc := &replicatedPlcList.Items[i]
+
+		if !r.shard.ownsReplicated(replicatedPlc) {
+			continue
+		}
+
+		// The root policy label is in the format of <namespace>.<name>
+		rootName := strings.SplitN(replicatedPlc.GetLabels()[common.RootPolicyLabel], ".", 2)
+		if len(rootName) != 2 {
+			continue
+		}
+
+		rootKey := fmt.Sprintf("%s/%s", rootName[0], rootName[1])
+
+		root := &policiesv1.Policy{}
+
+		err := r.Get(ctx, types.NamespacedName{Namespace: rootName[0], Name: rootName[1]}, root)
+		if err != nil && !k8serrors.IsNotFound(err) {
+			continue
+		}
+
+		// The replicated policies of the root policies in dry run or paused are left as is
+		if err == nil && (isDryRun(root) || isPropagationPaused(root) ||
+			!r.decisionRemoved(rootKey, replicatedPlc.GetNamespace())) {
+			continue
+		}
+
+		if r.namespaceTerminating(ctx, replicatedPlc.GetNamespace()) {
+			key := types.NamespacedName{Namespace: replicatedPlc.GetNamespace(), Name: replicatedPlc.GetName()}
+			if r.pruneTerminatingReplicatedPolicy(ctx, key) {
+				r.recordClusterNamespaceEvent(replicatedPlc, rootKey, replicatedPolicyDeleted)
+			}
+
+			continue
+		}
+
+		log.Info("Deleting the orphaned replicated policy...",
+			"Namespace", replicatedPlc.GetNamespace(), "Name", replicatedPlc.GetName())
+
+		err = r.Delete(ctx, replicatedPlc)
+		if err != nil && !k8serrors.IsNotFound(err) {
+			log.Error(err, "Failed to delete the orphaned replicated policy...",
+				"Namespace", replicatedPlc.GetNamespace(), "Name", replicatedPlc.GetName())
+
+			continue
+		}
+
+		r.recordClusterNamespaceEvent(replicatedPlc, rootKey, replicatedPolicyDeleted)
+	}
+}
+
+// decisionRemoved returns whether the last resolved placement decisions of the existing root policy
+// no longer select the cluster namespace. The decisions are not trusted while the root policy is
+// being reconciled or before they are resolved, since the root policy status handles the clean up
+// in that case.
+func (r *PolicyReconciler) decisionRemoved(rootKey string, clusterNamespace string) bool {
+	if r.propagationState.reconciling(rootKey) {
+		return false
+	}
+
+	_, selected, resolved := r.propagationState.clusterDecision(rootKey, clusterNamespace)
+
+	return resolved && !selected
+}
@@ -0,0 +1,137 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+)
+
+func TestRootPolicyFinalizer(t *testing.T) {
+	root := newTestPolicy("default")
+	replicated := newTestReplicatedPolicy(root, "cluster1", policiesv1.Compliant)
+
+	r := newTestReconciler(t, &stubResolver{}, root, replicated)
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "policies", Name: "policy"}}
+
+	if _, err := r.Reconcile(context.TODO(), request); err != nil {
+		t.Fatalf("Reconcile returned an error: %v", err)
+	}
+
+	updatedRoot := &policiesv1.Policy{}
+	if err := r.Get(context.TODO(), request.NamespacedName, updatedRoot); err != nil {
+		t.Fatalf("failed to get the root policy: %v", err)
+	}
+
+	if !controllerutil.ContainsFinalizer(updatedRoot, common.RootPolicyFinalizer) {
+		t.Fatalf("expected the finalizer on the root policy, got %v", updatedRoot.GetFinalizers())
+	}
+
+	if err := r.Delete(context.TODO(), updatedRoot); err != nil {
+		t.Fatalf("failed to delete the root policy: %v", err)
+	}
+
+	if _, err := r.Reconcile(context.TODO(), request); err != nil {
+		t.Fatalf("Reconcile returned an error: %v", err)
+	}
+
+	err := r.Get(context.TODO(), types.NamespacedName{Namespace: "cluster1", Name: "policies.policy"}, replicated)
+	if !k8serrors.IsNotFound(err) {
+		t.Fatalf("expected the replicated policy to be deleted, got the error: %v", err)
+	}
+
+	err = r.Get(context.TODO(), request.NamespacedName, updatedRoot)
+	if !k8serrors.IsNotFound(err) {
+		t.Fatalf("expected the root policy to be removed after its finalizer, got the error: %v", err)
+	}
+}
+
+func TestSweepOrphans(t *testing.T) {
+	root := newTestPolicy("default")
+
+	deletedRoot := newTestPolicy("default")
+	deletedRoot.SetName("deleted")
+
+	r := newTestReconciler(
+		t, &stubResolver{}, root,
+		newTestReplicatedPolicy(root, "cluster1", policiesv1.Compliant),
+		newTestReplicatedPolicy(deletedRoot, "cluster1", policiesv1.Compliant),
+	)
+
+	r.sweepOrphans(context.TODO())
+
+	err := r.Get(
+		context.TODO(), types.NamespacedName{Namespace: "cluster1", Name: "policies.policy"}, &policiesv1.Policy{},
+	)
+	if err != nil {
+		t.Fatalf("expected the replicated policy of the existing root policy to be kept, got the error: %v", err)
+	}
+
+	err = r.Get(
+		context.TODO(), types.NamespacedName{Namespace: "cluster1", Name: "policies.deleted"}, &policiesv1.Policy{},
+	)
+	if !k8serrors.IsNotFound(err) {
+		t.Fatalf("expected the orphaned replicated policy to be deleted, got the error: %v", err)
+	}
+}
+
+func TestSweepOrphansRemovedDecision(t *testing.T) {
+	root := newTestPolicy("default")
+
+	r := newTestReconciler(
+		t, &stubResolver{}, root,
+		newTestReplicatedPolicy(root, "cluster1", policiesv1.Compliant),
+		newTestReplicatedPolicy(root, "cluster2", policiesv1.Compliant),
+	)
+
+	r.sweepOrphans(context.TODO())
+
+	for _, cluster := range []string{"cluster1", "cluster2"} {
+		err := r.Get(
+			context.TODO(), types.NamespacedName{Namespace: cluster, Name: "policies.policy"}, &policiesv1.Policy{},
+		)
+		if err != nil {
+			t.Fatalf("expected the replicated policy in %s to be kept before the decisions are resolved, "+
+				"got the error: %v", cluster, err)
+		}
+	}
+
+	r.propagationState.setResolved("policies/policy", nil, map[string]bool{"cluster1/cluster1": true})
+	r.propagationState.startReconcile("policies/policy")
+
+	r.sweepOrphans(context.TODO())
+
+	err := r.Get(
+		context.TODO(), types.NamespacedName{Namespace: "cluster2", Name: "policies.policy"}, &policiesv1.Policy{},
+	)
+	if err != nil {
+		t.Fatalf("expected the replicated policy to be kept while the root policy is reconciled, got the error: %v", err)
+	}
+
+	r.propagationState.finishReconcile("policies/policy", time.Time{})
+
+	r.sweepOrphans(context.TODO())
+
+	err = r.Get(
+		context.TODO(), types.NamespacedName{Namespace: "cluster1", Name: "policies.policy"}, &policiesv1.Policy{},
+	)
+	if err != nil {
+		t.Fatalf("expected the replicated policy of the selected cluster to be kept, got the error: %v", err)
+	}
+
+	err = r.Get(
+		context.TODO(), types.NamespacedName{Namespace: "cluster2", Name: "policies.policy"}, &policiesv1.Policy{},
+	)
+	if !k8serrors.IsNotFound(err) {
+		t.Fatalf("expected the replicated policy of the removed decision to be deleted, got the error: %v", err)
+	}
+}
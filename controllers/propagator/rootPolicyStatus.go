@@ -0,0 +1,256 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+)
+
+const RootPolicyStatusControllerName string = "root-policy-status"
+
+// replicatedStatusPredicateFuncs only lets through the compliance changes of the replicated
+// policies. The creation and deletion of the replicated policies are handled by the propagator.
+var replicatedStatusPredicateFuncs = predicate.Funcs{
+	CreateFunc: func(e event.CreateEvent) bool { return false },
+	DeleteFunc: func(e event.DeleteEvent) bool { return false },
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		if _, replicated := e.ObjectNew.GetLabels()[common.RootPolicyLabel]; !replicated {
+			return false
+		}
+
+		plcOld, oldOk := e.ObjectOld.(*policiesv1.Policy)
+		plcNew, newOk := e.ObjectNew.(*policiesv1.Policy)
+
+		return oldOk && newOk && plcOld.Status.ComplianceState != plcNew.Status.ComplianceState
+	},
+}
+
+// SetupWithManager sets up the controller with the Manager. The root policies are reconciled once
+// per status batch window, no matter how many of their replicated policies changed in it.
+func (r *RootPolicyStatusReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(RootPolicyStatusControllerName).
+		For(
+			&policiesv1.Policy{},
+			builder.WithPredicates(common.NeverEnqueue)).
+		// The root policy of the replicated policy is reconciled, see the propagator for details
+		Watches(
+			&source.Kind{Type: &policiesv1.Policy{}},
+			&common.EnqueueRequestsFromMapFunc{
+				ToRequests: policyMapper(mgr.GetClient()), Delay: r.StatusBatchWindow,
+			},
+			builder.WithPredicates(replicatedStatusPredicateFuncs)).
+		Complete(r)
+}
+
+// blank assignment to verify that RootPolicyStatusReconciler implements reconcile.Reconciler
+var _ reconcile.Reconciler = &RootPolicyStatusReconciler{}
+
+// RootPolicyStatusReconciler updates the compliance of the root policies when the compliance of
+// their replicated policies changes, without resolving the placements and the hub templates again
+type RootPolicyStatusReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	// StatusBatchWindow is how long the compliance changes of the replicated policies are collected
+	// before the root policy status is patched once for all of them. When it is 0, the root policy
+	// status is patched for every change.
+	StatusBatchWindow time.Duration
+	// DetailedStatus sets the compliance of the policy templates of the replicated policies in the
+	// per-cluster status of the root policies
+	DetailedStatus bool
+	// Shard is the partition of the root policies whose status is updated by the replica. The zero
+	// value updates all of them.
+	Shard Shard
+}
+
+// Reconcile sets the compliance of the clusters in status.status of the root policy to the
+// compliance of their replicated policies and aggregates it in status.complianceState. The
+// clusters the propagator failed to replicate the policy to keep their status.
+func (r *RootPolicyStatusReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+
+	// The root policies of the other shards are updated by their replicas
+	if !r.Shard.Owns(request.NamespacedName) {
+		return reconcile.Result{}, nil
+	}
+
+	instance := &policiesv1.Policy{}
+
+	err := r.Get(ctx, request.NamespacedName, instance)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			reqLogger.Info("Policy not found, may have been deleted, doing nothing...")
+
+			return reconcile.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		return reconcile.Result{}, err
+	}
+
+	// The propagator cleans up the status of the disabled policies
+	if instance.Spec.Disabled {
+		return reconcile.Result{}, nil
+	}
+
+	replicatedPlcList := &policiesv1.PolicyList{}
+
+	err = r.List(ctx, replicatedPlcList, client.MatchingLabels(common.LabelsForRootPolicy(instance)))
+	if err != nil {
+		reqLogger.Error(err, "Failed to list the replicated policies...")
+
+		return reconcile.Result{}, err
+	}
+
+	originalInstance := instance.DeepCopy()
+
+	status := replicatedStatus(instance.Status.Status, replicatedPlcList.Items)
+	setCompliantSince(instance, status, time.Now())
+	setComplianceHistory(instance, status, time.Now())
+
+	if r.DetailedStatus {
+		setTemplateStatus(status, replicatedPlcList.Items)
+	}
+
+	setOperatorPolicyStatus(status, replicatedPlcList.Items)
+
+	instance.Status.Status = status
+	instance.Status.Details = summarizeTemplateDetails(instance, replicatedPlcList.Items)
+	instance.Status.SummaryByControl = summarizeControls(instance, instance.Status.Status)
+	groupStatusByDecisionGroup(instance.Status.Placement, instance.Status.Status)
+	instance.Status.ComplianceState = aggregateCompliance(instance.Status.Status)
+	setCompliantCondition(instance)
+	thresholdExceeded := setAlertThresholdCondition(instance)
+
+	if equality.Semantic.DeepEqual(originalInstance.Status, instance.Status) {
+		return reconcile.Result{}, nil
+	}
+
+	reqLogger.Info("Updating the root policy status...", "ComplianceState", instance.Status.ComplianceState)
+
+	err = r.Status().Patch(ctx, instance, client.MergeFrom(originalInstance))
+	if err != nil {
+		reqLogger.Error(err, "Failed to update the root policy status...")
+
+		return reconcile.Result{}, err
+	}
+
+	if thresholdExceeded {
+		condition := meta.FindStatusCondition(instance.Status.Conditions, policiesv1.AlertThresholdExceeded)
+		r.Recorder.Event(instance, "Warning", "PolicyPropagation",
+			"The noncompliance alert threshold was exceeded: "+condition.Message)
+	}
+
+	return reconcile.Result{}, nil
+}
+
+// replicatedStatus returns the per-cluster status with the compliance of the replicated policies.
+// The entries with a reason are set by the propagator when the policy is not replicated to the
+// cluster, so they are kept as is. The hub template errors in the messages are kept as well.
+func replicatedStatus(
+	status []*policiesv1.CompliancePerClusterStatus, replicatedPlcs []policiesv1.Policy,
+) []*policiesv1.CompliancePerClusterStatus {
+	result := make([]*policiesv1.CompliancePerClusterStatus, 0, len(replicatedPlcs))
+	seen := map[string]bool{}
+	messages := map[string]string{}
+
+	pending := map[string]bool{}
+
+	for _, cpcs := range status {
+		if cpcs.Reason == reasonPendingUpdate {
+			// The out of date replicated policy still reports its compliance
+			pending[cpcs.ClusterNamespace+"/"+cpcs.ClusterName] = true
+			messages[cpcs.ClusterNamespace+"/"+cpcs.ClusterName] = cpcs.Message
+		} else if cpcs.Reason != "" {
+			result = append(result, cpcs.DeepCopy())
+			seen[cpcs.ClusterNamespace+"/"+cpcs.ClusterName] = true
+		} else {
+			messages[cpcs.ClusterNamespace+"/"+cpcs.ClusterName] = cpcs.Message
+		}
+	}
+
+	for _, rPlc := range replicatedPlcs {
+		namespace := rPlc.GetLabels()[common.ClusterNamespaceLabel]
+		name := rPlc.GetLabels()[common.ClusterNameLabel]
+
+		if seen[namespace+"/"+name] {
+			continue
+		}
+
+		// #nosec G601 -- no memory addresses are stored in collections
+		cpcs := &policiesv1.CompliancePerClusterStatus{
+			ComplianceState:  replicatedCompliance(&rPlc),
+			ClusterName:      name,
+			ClusterNamespace: namespace,
+			Message:          messages[namespace+"/"+name],
+		}
+
+		if pending[namespace+"/"+name] {
+			cpcs.Reason = reasonPendingUpdate
+		}
+
+		result = append(result, cpcs)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ClusterName < result[j].ClusterName
+	})
+
+	return result
+}
+
+// setTemplateStatus sets the compliance of the policy templates of the replicated policies in the
+// per-cluster status. The clusters the policy is not replicated to have none.
+func setTemplateStatus(status []*policiesv1.CompliancePerClusterStatus, replicatedPlcs []policiesv1.Policy) {
+	templates := map[string][]*policiesv1.TemplateStatus{}
+
+	for _, rPlc := range replicatedPlcs {
+		key := rPlc.GetLabels()[common.ClusterNamespaceLabel] + "/" + rPlc.GetLabels()[common.ClusterNameLabel]
+
+		for _, details := range rPlc.Status.Details {
+			if details == nil {
+				continue
+			}
+
+			tmplStatus := &policiesv1.TemplateStatus{
+				Name:            details.TemplateMeta.GetName(),
+				ComplianceState: details.ComplianceState,
+			}
+
+			// The latest compliance event is first in the history
+			if len(details.History) != 0 {
+				tmplStatus.Message = details.History[0].Message
+			}
+
+			templates[key] = append(templates[key], tmplStatus)
+		}
+	}
+
+	for _, cpcs := range status {
+		if cpcs.Reason != "" && cpcs.Reason != reasonPendingUpdate {
+			cpcs.Templates = nil
+
+			continue
+		}
+
+		cpcs.Templates = templates[cpcs.ClusterNamespace+"/"+cpcs.ClusterName]
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+)
+
+func newTestReplicatedPolicy(root *policiesv1.Policy, cluster string, compliance policiesv1.ComplianceState) *policiesv1.Policy {
+	replicated := root.DeepCopy()
+	replicated.SetName(common.FullNameForPolicy(root))
+	replicated.SetNamespace(cluster)
+	replicated.SetResourceVersion("")
+	replicated.SetLabels(map[string]string{
+		common.ClusterNameLabel:      cluster,
+		common.ClusterNamespaceLabel: cluster,
+		common.RootPolicyLabel:       common.FullNameForPolicy(root),
+	})
+	replicated.Status = policiesv1.PolicyStatus{ComplianceState: compliance}
+
+	return replicated
+}
+
+func TestRootPolicyStatusReconcile(t *testing.T) {
+	root := newTestPolicy("default")
+	root.Status = policiesv1.PolicyStatus{
+		ComplianceState: policiesv1.Compliant,
+		Status: []*policiesv1.CompliancePerClusterStatus{
+			{ClusterName: "cluster1", ClusterNamespace: "cluster1", ComplianceState: policiesv1.Compliant},
+			{ClusterName: "cluster2", ClusterNamespace: "cluster2", ComplianceState: policiesv1.Compliant},
+			{
+				ClusterName: "cluster3", ClusterNamespace: "cluster3", ComplianceState: policiesv1.NonCompliant,
+				Reason: reasonReplicationFailed, Message: "failed",
+			},
+		},
+	}
+
+	propagator := newTestReconciler(t, &stubResolver{},
+		root,
+		newTestReplicatedPolicy(root, "cluster1", policiesv1.Compliant),
+		newTestReplicatedPolicy(root, "cluster2", policiesv1.NonCompliant),
+	)
+	recorder := record.NewFakeRecorder(10)
+	r := &RootPolicyStatusReconciler{Client: propagator.Client, Scheme: propagator.Scheme, Recorder: recorder}
+
+	_, err := r.Reconcile(context.TODO(), ctrl.Request{
+		NamespacedName: types.NamespacedName{Namespace: root.GetNamespace(), Name: root.GetName()},
+	})
+	if err != nil {
+		t.Fatalf("Reconcile returned an error: %v", err)
+	}
+
+	updated := &policiesv1.Policy{}
+	if err := r.Get(context.TODO(), types.NamespacedName{Namespace: "policies", Name: "policy"}, updated); err != nil {
+		t.Fatalf("failed to get the root policy: %v", err)
+	}
+
+	if updated.Status.ComplianceState != policiesv1.NonCompliant {
+		t.Fatalf("expected the root policy to be NonCompliant, got %q", updated.Status.ComplianceState)
+	}
+
+	expected := map[string]policiesv1.ComplianceState{
+		"cluster1": policiesv1.Compliant, "cluster2": policiesv1.NonCompliant, "cluster3": policiesv1.NonCompliant,
+	}
+
+	if len(updated.Status.Status) != len(expected) {
+		t.Fatalf("expected %d clusters in the status, got %d", len(expected), len(updated.Status.Status))
+	}
+
+	for _, cpcs := range updated.Status.Status {
+		if cpcs.ComplianceState != expected[cpcs.ClusterName] {
+			t.Fatalf("expected %s to be %q, got %q", cpcs.ClusterName, expected[cpcs.ClusterName], cpcs.ComplianceState)
+		}
+	}
+
+	if updated.Status.Status[2].Reason != reasonReplicationFailed {
+		t.Fatal("expected the status of the failed replication to be kept")
+	}
+}
+
+func TestRootPolicyStatusReconcileDetailed(t *testing.T) {
+	root := newTestPolicy("default")
+	root.Status.Status = []*policiesv1.CompliancePerClusterStatus{
+		{ClusterName: "cluster1", ClusterNamespace: "cluster1", ComplianceState: policiesv1.Compliant},
+		{
+			ClusterName: "cluster2", ClusterNamespace: "cluster2", ComplianceState: policiesv1.NonCompliant,
+			Reason: reasonReplicationFailed, Message: "failed",
+		},
+	}
+
+	replicated := newTestReplicatedPolicy(root, "cluster1", policiesv1.NonCompliant)
+	replicated.Status.Details = []*policiesv1.DetailsPerTemplate{
+		{
+			TemplateMeta:    metav1.ObjectMeta{Name: "case1"},
+			ComplianceState: policiesv1.NonCompliant,
+			History: []policiesv1.ComplianceHistory{
+				{Message: "NonCompliant; violation - pods not found"},
+				{Message: "Compliant; notification - pods found"},
+			},
+		},
+		{TemplateMeta: metav1.ObjectMeta{Name: "case2"}, ComplianceState: policiesv1.Compliant},
+	}
+
+	propagator := newTestReconciler(t, &stubResolver{}, root, replicated)
+	r := &RootPolicyStatusReconciler{
+		Client: propagator.Client, Scheme: propagator.Scheme, Recorder: record.NewFakeRecorder(10),
+		DetailedStatus: true,
+	}
+
+	_, err := r.Reconcile(context.TODO(), ctrl.Request{
+		NamespacedName: types.NamespacedName{Namespace: root.GetNamespace(), Name: root.GetName()},
+	})
+	if err != nil {
+		t.Fatalf("Reconcile returned an error: %v", err)
+	}
+
+	updated := &policiesv1.Policy{}
+	if err := r.Get(context.TODO(), types.NamespacedName{Namespace: "policies", Name: "policy"}, updated); err != nil {
+		t.Fatalf("failed to get the root policy: %v", err)
+	}
+
+	expected := []*policiesv1.TemplateStatus{
+		{Name: "case1", ComplianceState: policiesv1.NonCompliant, Message: "NonCompliant; violation - pods not found"},
+		{Name: "case2", ComplianceState: policiesv1.Compliant},
+	}
+
+	if !reflect.DeepEqual(updated.Status.Status[0].Templates, expected) {
+		t.Fatalf("expected the templates of cluster1 to be %v, got %v", expected, updated.Status.Status[0].Templates)
+	}
+
+	if updated.Status.Status[1].Templates != nil {
+		t.Fatalf("expected no templates for the failed replication, got %v", updated.Status.Status[1].Templates)
+	}
+}
+
+func TestPolicyPredicateFuncs(t *testing.T) {
+	root := newTestPolicy("default")
+	replicated := newTestReplicatedPolicy(root, "cluster1", policiesv1.Compliant)
+
+	tests := []struct {
+		name     string
+		old      *policiesv1.Policy
+		update   func(*policiesv1.Policy)
+		expected bool
+		status   bool
+	}{
+		{
+			name:     "replicated compliance",
+			old:      replicated,
+			update:   func(plc *policiesv1.Policy) { plc.Status.ComplianceState = policiesv1.NonCompliant },
+			expected: false,
+			status:   true,
+		},
+		{
+			name:     "replicated spec",
+			old:      replicated,
+			update:   func(plc *policiesv1.Policy) { plc.SetGeneration(plc.GetGeneration() + 1) },
+			expected: false,
+			status:   false,
+		},
+		{
+			name: "root status",
+			old:  root,
+			update: func(plc *policiesv1.Policy) {
+				plc.Status.Status = []*policiesv1.CompliancePerClusterStatus{{ClusterName: "cluster1"}}
+			},
+			expected: false,
+			status:   false,
+		},
+		{
+			name: "root status during a rollout",
+			old:  root,
+			update: func(plc *policiesv1.Policy) {
+				plc.Status.Rollout = &policiesv1.RolloutStatus{State: policiesv1.RolloutProgressing}
+				plc.Status.Status = []*policiesv1.CompliancePerClusterStatus{{ClusterName: "cluster1"}}
+			},
+			expected: true,
+			status:   false,
+		},
+		{
+			name: "root annotation",
+			old:  root,
+			update: func(plc *policiesv1.Policy) {
+				plc.SetAnnotations(map[string]string{triggerUpdateAnnotation: "1"})
+			},
+			expected: true,
+			status:   false,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			updated := test.old.DeepCopy()
+			test.update(updated)
+
+			e := event.UpdateEvent{ObjectOld: test.old, ObjectNew: updated}
+
+			if policyPredicateFuncs.Update(e) != test.expected {
+				t.Fatalf("expected the propagator predicate to return %v", test.expected)
+			}
+
+			if replicatedStatusPredicateFuncs.Update(e) != test.status {
+				t.Fatalf("expected the status predicate to return %v", test.status)
+			}
+		})
+	}
+}
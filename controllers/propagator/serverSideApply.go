@@ -0,0 +1,119 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"encoding/json"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+// fieldManager is the field manager of the replicated policies applied with server-side apply
+const fieldManager = "governance-policy-propagator"
+
+// desiredReplicatedPolicy returns the replicated policy of the root policy for the cluster, before
+// its hub templates are resolved. Only the fields owned by the propagator are set, since it is
+// applied whole with server-side apply.
+func desiredReplicatedPolicy(instance *policiesv1.Policy, decision appsv1.PlacementDecision) *policiesv1.Policy {
+	// The GitOps tracking labels and annotations would make the replicated policies part of the
+	// application of the root policy
+	labels := map[string]string{}
+	for key, value := range common.WithoutGitOpsTrackingKeys(instance.GetLabels()) {
+		labels[key] = value
+	}
+
+	labels[common.ClusterNameLabel] = decision.ClusterName
+	labels[common.ClusterNamespaceLabel] = decision.ClusterNamespace
+	labels[common.RootPolicyLabel] = common.FullNameForPolicy(instance)
+
+	var annotations map[string]string
+
+	if instance.GetAnnotations() != nil {
+		annotations = map[string]string{}
+		for key, value := range common.WithoutGitOpsTrackingKeys(instance.GetAnnotations()) {
+			annotations[key] = value
+		}
+
+		// The propagation hash and the copied labels are only meaningful on the root policy
+		delete(annotations, propagationHashAnnotation)
+		delete(annotations, copyLabelsAnnotation)
+	}
+
+	return &policiesv1.Policy{
+		TypeMeta: metav1.TypeMeta{Kind: policiesv1.Kind, APIVersion: policiesv1.GroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        common.FullNameForPolicy(instance),
+			Namespace:   decision.ClusterNamespace,
+			Labels:      labels,
+			Annotations: annotations,
+		},
+		Spec: *instance.Spec.DeepCopy(),
+	}
+}
+
+// replicatedPolicyApplied returns whether the existing replicated policy has the spec, labels, and
+// annotations of the desired one, and none of the labels and annotations the propagator applied
+// before and no longer sets. The labels and annotations set by others, and the GitOps tracking ones
+// even if the propagator applied them before, are ignored.
+func replicatedPolicyApplied(desiredPlc *policiesv1.Policy, existingPlc *policiesv1.Policy) bool {
+	return equality.Semantic.DeepEqual(desiredPlc.Spec, existingPlc.Spec) &&
+		metadataApplied(desiredPlc.GetLabels(), existingPlc.GetLabels(), appliedMetadataKeys(existingPlc, "labels")) &&
+		metadataApplied(
+			desiredPlc.GetAnnotations(), existingPlc.GetAnnotations(), appliedMetadataKeys(existingPlc, "annotations"),
+		)
+}
+
+// metadataApplied returns whether the existing labels or annotations have the desired ones, and
+// none of the previously applied ones that are no longer desired
+func metadataApplied(desired map[string]string, existing map[string]string, applied map[string]bool) bool {
+	for key, value := range desired {
+		if existingValue, ok := existing[key]; !ok || existingValue != value {
+			return false
+		}
+	}
+
+	for key := range applied {
+		if _, ok := desired[key]; ok || common.IsGitOpsTrackingKey(key) {
+			continue
+		}
+
+		if _, ok := existing[key]; ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// appliedMetadataKeys returns the keys of the labels or the annotations of the replicated policy
+// owned by the propagator, from its managed fields
+func appliedMetadataKeys(replicatedPlc *policiesv1.Policy, field string) map[string]bool {
+	keys := map[string]bool{}
+
+	for _, entry := range replicatedPlc.GetManagedFields() {
+		if entry.Manager != fieldManager || entry.Operation != metav1.ManagedFieldsOperationApply ||
+			entry.FieldsV1 == nil {
+			continue
+		}
+
+		// The fields are in the format of {"f:metadata":{"f:labels":{"f:<key>":{}}}}
+		fields := map[string]map[string]map[string]interface{}{}
+		if err := json.Unmarshal(entry.FieldsV1.Raw, &fields); err != nil {
+			continue
+		}
+
+		for key := range fields["f:metadata"]["f:"+field] {
+			if strings.HasPrefix(key, "f:") {
+				keys[strings.TrimPrefix(key, "f:")] = true
+			}
+		}
+	}
+
+	return keys
+}
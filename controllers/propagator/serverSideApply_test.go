@@ -0,0 +1,228 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	policyv1beta1 "github.com/open-cluster-management/governance-policy-propagator/api/v1beta1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+// applyClient emulates the server-side apply of the replicated policies, since the fake client
+// doesn't support it. The labels and annotations applied by a field manager are tracked in the
+// managed fields, so that the ones it no longer applies are removed.
+type applyClient struct {
+	client.Client
+}
+
+func (c *applyClient) Patch(
+	ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption,
+) error {
+	if patch.Type() != types.ApplyPatchType {
+		return c.Client.Patch(ctx, obj, patch, opts...)
+	}
+
+	patchOpts := &client.PatchOptions{}
+	patchOpts.ApplyOptions(opts)
+
+	applied := obj.(*policiesv1.Policy)
+
+	existing := &policiesv1.Policy{}
+
+	err := c.Get(ctx, client.ObjectKeyFromObject(applied), existing)
+	if k8serrors.IsNotFound(err) {
+		created := applied.DeepCopy()
+		created.SetManagedFields(appliedManagedFields(patchOpts.FieldManager, applied))
+
+		if err := c.Create(ctx, created); err != nil {
+			return err
+		}
+
+		created.DeepCopyInto(applied)
+
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	existing.SetLabels(applyMetadata(
+		existing.GetLabels(), applied.GetLabels(), appliedMetadataKeys(existing, "labels"),
+	))
+	existing.SetAnnotations(applyMetadata(
+		existing.GetAnnotations(), applied.GetAnnotations(), appliedMetadataKeys(existing, "annotations"),
+	))
+	existing.Spec = applied.Spec
+	existing.SetManagedFields(appliedManagedFields(patchOpts.FieldManager, applied))
+
+	if err := c.Update(ctx, existing); err != nil {
+		return err
+	}
+
+	existing.DeepCopyInto(applied)
+
+	return nil
+}
+
+// applyMetadata returns the existing labels or annotations without the previously applied ones,
+// and with the applied ones
+func applyMetadata(existing map[string]string, applied map[string]string, owned map[string]bool) map[string]string {
+	result := map[string]string{}
+
+	for key, value := range existing {
+		if !owned[key] {
+			result[key] = value
+		}
+	}
+
+	for key, value := range applied {
+		result[key] = value
+	}
+
+	return result
+}
+
+// appliedManagedFields returns the managed fields of the labels and the annotations applied by the
+// field manager
+func appliedManagedFields(manager string, plc *policiesv1.Policy) []metav1.ManagedFieldsEntry {
+	metadata := map[string]interface{}{}
+
+	for field, values := range map[string]map[string]string{
+		"labels": plc.GetLabels(), "annotations": plc.GetAnnotations(),
+	} {
+		keys := map[string]interface{}{".": map[string]interface{}{}}
+		for key := range values {
+			keys["f:"+key] = map[string]interface{}{}
+		}
+
+		metadata["f:"+field] = keys
+	}
+
+	raw, _ := json.Marshal(map[string]interface{}{"f:metadata": metadata, "f:spec": map[string]interface{}{}})
+
+	return []metav1.ManagedFieldsEntry{{
+		Manager:    manager,
+		Operation:  metav1.ManagedFieldsOperationApply,
+		APIVersion: policiesv1.GroupVersion.String(),
+		FieldsV1:   &metav1.FieldsV1{Raw: raw},
+	}}
+}
+
+func TestReplicatedPolicyApplied(t *testing.T) {
+	root := newTestPolicy("default")
+	root.SetAnnotations(map[string]string{"team": "a"})
+
+	desired := desiredReplicatedPolicy(root, appsv1.PlacementDecision{
+		ClusterName: "cluster1", ClusterNamespace: "cluster1",
+	})
+
+	existing := desired.DeepCopy()
+	existing.SetManagedFields(appliedManagedFields(fieldManager, existing))
+
+	// The labels and annotations of others are ignored
+	existing.Labels["backup"] = "true"
+	existing.SetAnnotations(map[string]string{"team": "a", "argocd.argoproj.io/sync-options": "Prune=false"})
+
+	if !replicatedPolicyApplied(desired, existing) {
+		t.Fatal("expected the labels and annotations of others to be ignored")
+	}
+
+	// The annotations the propagator no longer applies are removed
+	delete(desired.Annotations, "team")
+
+	if replicatedPolicyApplied(desired, existing) {
+		t.Fatal("expected the annotation removed from the root policy to be detected")
+	}
+
+	desired = existing.DeepCopy()
+	desired.Spec.Disabled = true
+
+	if replicatedPolicyApplied(desired, existing) {
+		t.Fatal("expected the spec change to be detected")
+	}
+}
+
+func TestReplicatedPolicyIgnoresGitOpsTracking(t *testing.T) {
+	root := newTestPolicy("default")
+	root.SetLabels(map[string]string{common.ArgoCDInstanceLabel: "app", "team": "a"})
+	root.SetAnnotations(map[string]string{common.ArgoCDTrackingIDAnnotation: "app:policy/Policy:policies/policy"})
+
+	desired := desiredReplicatedPolicy(root, appsv1.PlacementDecision{
+		ClusterName: "cluster1", ClusterNamespace: "cluster1",
+	})
+
+	if _, ok := desired.GetLabels()[common.ArgoCDInstanceLabel]; ok || desired.GetLabels()["team"] != "a" {
+		t.Fatalf("expected only the GitOps tracking label to not be copied, got %v", desired.GetLabels())
+	}
+
+	if _, ok := desired.GetAnnotations()[common.ArgoCDTrackingIDAnnotation]; ok {
+		t.Fatalf("expected the GitOps tracking annotation to not be copied, got %v", desired.GetAnnotations())
+	}
+
+	// The tracking keys applied by a previous version of the propagator are not a drift either
+	existing := desired.DeepCopy()
+	existing.Labels[common.ArgoCDInstanceLabel] = "app"
+	existing.SetAnnotations(map[string]string{common.ArgoCDTrackingIDAnnotation: "app:policy/Policy:policies/policy"})
+	existing.SetManagedFields(appliedManagedFields(fieldManager, existing))
+
+	if !replicatedPolicyApplied(desired, existing) {
+		t.Fatal("expected the GitOps tracking label and annotation to be ignored")
+	}
+
+	updated := existing.DeepCopy()
+	updated.Labels[common.ArgoCDInstanceLabel] = "other"
+	updated.Annotations[common.ArgoCDTrackingIDAnnotation] = "other"
+
+	if replicatedPolicyPredicateFuncs.Update(event.UpdateEvent{ObjectOld: existing, ObjectNew: updated}) {
+		t.Fatal("expected the GitOps tracking changes to not reconcile the replicated policy")
+	}
+}
+
+func TestHandleDecisionKeepsLabelsOfOthers(t *testing.T) {
+	decision := appsv1.PlacementDecision{ClusterName: "cluster1", ClusterNamespace: "cluster1"}
+	root := newTestPolicy("default")
+	root.SetLabels(map[string]string{"team": "a"})
+
+	r := newTestReconciler(t, &stubResolver{}, root)
+
+	if _, err := r.handleDecision(context.TODO(), root, decision, policyv1beta1.PropagationConfigSpec{}); err != nil {
+		t.Fatalf("handleDecision returned an error: %v", err)
+	}
+
+	key := types.NamespacedName{Namespace: "cluster1", Name: "policies.policy"}
+	replicated := &policiesv1.Policy{}
+
+	if err := r.Get(context.TODO(), key, replicated); err != nil {
+		t.Fatalf("failed to get the replicated policy: %v", err)
+	}
+
+	replicated.Labels["backup"] = "true"
+
+	if err := r.Update(context.TODO(), replicated); err != nil {
+		t.Fatalf("failed to label the replicated policy: %v", err)
+	}
+
+	root.SetLabels(map[string]string{"team": "b"})
+
+	if _, err := r.handleDecision(context.TODO(), root, decision, policyv1beta1.PropagationConfigSpec{}); err != nil {
+		t.Fatalf("handleDecision returned an error: %v", err)
+	}
+
+	if err := r.Get(context.TODO(), key, replicated); err != nil {
+		t.Fatalf("failed to get the replicated policy: %v", err)
+	}
+
+	if replicated.Labels["backup"] != "true" || replicated.Labels["team"] != "b" {
+		t.Fatalf("expected the label of others to be kept and the root label to be updated, got %v", replicated.Labels)
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"fmt"
+	"hash/fnv"
+	"os"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+)
+
+// The configuration of the Sharding feature. The root policies are partitioned between the number
+// of shards, and the propagator replicas of the shard index reconcile one of the partitions.
+const (
+	shardCountEnvName = "CONTROLLER_CONFIG_SHARD_COUNT"
+	shardIndexEnvName = "CONTROLLER_CONFIG_SHARD_INDEX"
+)
+
+// Shard is the partition of the root policies reconciled by a propagator replica when the Sharding
+// feature is enabled, or when the replica is restricted to some namespaces. The zero value
+// reconciles all the root policies.
+type Shard struct {
+	// Count is the number of partitions
+	Count int
+	// Index is the partition reconciled by the replica, from 0 to Count-1
+	Index int
+	// Namespaces restricts the replica to the root policies of these namespaces and their replicated
+	// policies in the cluster namespaces. All the namespaces are reconciled when it is empty.
+	Namespaces []string
+}
+
+// ShardFromEnv returns the shard configured through the CONTROLLER_CONFIG_SHARD_* environment
+// variables. A single shard is returned when the count is unset.
+func ShardFromEnv() (Shard, error) {
+	shard := Shard{Count: 1}
+
+	if value, found := os.LookupEnv(shardCountEnvName); found {
+		count, err := strconv.Atoi(value)
+		if err != nil || count < 1 {
+			return Shard{}, fmt.Errorf("%s must be a positive integer, got %q", shardCountEnvName, value)
+		}
+
+		shard.Count = count
+	}
+
+	if value, found := os.LookupEnv(shardIndexEnvName); found {
+		index, err := strconv.Atoi(value)
+		if err != nil || index < 0 || index >= shard.Count {
+			return Shard{}, fmt.Errorf(
+				"%s must be an integer from 0 to %d, got %q", shardIndexEnvName, shard.Count-1, value,
+			)
+		}
+
+		shard.Index = index
+	}
+
+	return shard, nil
+}
+
+// Owns returns whether the root policy is in the partition of the shard. The root policies are
+// assigned with a consistent hash of their namespace and name, so that few of them move when the
+// number of shards changes.
+func (s Shard) Owns(root types.NamespacedName) bool {
+	if !s.watches(root.Namespace) {
+		return false
+	}
+
+	if s.Count <= 1 {
+		return true
+	}
+
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(root.String()))
+
+	return jumpHash(hash.Sum64(), s.Count) == s.Index
+}
+
+// watches returns whether the root policies of the namespace are reconciled by the replica
+func (s Shard) watches(namespace string) bool {
+	if len(s.Namespaces) == 0 {
+		return true
+	}
+
+	for _, watched := range s.Namespaces {
+		if watched == namespace {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ownsReplicated returns whether the root policy of the replicated policy is in the partition of
+// the shard
+func (s Shard) ownsReplicated(replicatedPlc client.Object) bool {
+	// The root policy label is in the format of <namespace>.<name>
+	rootName := strings.SplitN(replicatedPlc.GetLabels()[common.RootPolicyLabel], ".", 2)
+	if len(rootName) != 2 {
+		return s.Count <= 1 && len(s.Namespaces) == 0
+	}
+
+	return s.Owns(types.NamespacedName{Namespace: rootName[0], Name: rootName[1]})
+}
+
+// jumpHash returns the bucket of the key with the jump consistent hash of Lamping and Veach
+func jumpHash(key uint64, buckets int) int {
+	var b, j int64 = -1, 0
+
+	for j < int64(buckets) {
+		b = j
+		key = key*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((key>>33)+1)))
+	}
+
+	return int(b)
+}
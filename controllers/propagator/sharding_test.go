@@ -0,0 +1,126 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+)
+
+func TestShardFromEnv(t *testing.T) {
+	tests := []struct {
+		count   string
+		index   string
+		want    Shard
+		wantErr bool
+	}{
+		{count: "", index: "", want: Shard{Count: 1}},
+		{count: "3", index: "2", want: Shard{Count: 3, Index: 2}},
+		{count: "3", index: "3", wantErr: true},
+		{count: "0", index: "", wantErr: true},
+		{count: "three", index: "", wantErr: true},
+		{count: "", index: "1", wantErr: true},
+	}
+
+	defer os.Unsetenv(shardCountEnvName)
+	defer os.Unsetenv(shardIndexEnvName)
+
+	for _, test := range tests {
+		os.Unsetenv(shardCountEnvName)
+		os.Unsetenv(shardIndexEnvName)
+
+		if test.count != "" {
+			os.Setenv(shardCountEnvName, test.count)
+		}
+
+		if test.index != "" {
+			os.Setenv(shardIndexEnvName, test.index)
+		}
+
+		shard, err := ShardFromEnv()
+		if (err != nil) != test.wantErr {
+			t.Fatalf("count %q and index %q: expected an error %v, got %v", test.count, test.index, test.wantErr, err)
+		}
+
+		if !reflect.DeepEqual(shard, test.want) {
+			t.Fatalf("count %q and index %q: expected %v, got %v", test.count, test.index, test.want, shard)
+		}
+	}
+}
+
+func TestShardOwns(t *testing.T) {
+	moved := 0
+
+	for i := 0; i < 1000; i++ {
+		root := types.NamespacedName{Namespace: "policies", Name: fmt.Sprintf("policy-%d", i)}
+
+		if !(Shard{}).Owns(root) {
+			t.Fatalf("expected a single shard to own %s", root)
+		}
+
+		owners := []int{}
+
+		for index := 0; index < 3; index++ {
+			if (Shard{Count: 3, Index: index}).Owns(root) {
+				owners = append(owners, index)
+			}
+		}
+
+		if len(owners) != 1 {
+			t.Fatalf("expected %s to be owned by one of 3 shards, got %v", root, owners)
+		}
+
+		// Adding a shard only moves root policies to the new shard
+		if !(Shard{Count: 4, Index: owners[0]}).Owns(root) {
+			if !(Shard{Count: 4, Index: 3}).Owns(root) {
+				t.Fatalf("expected %s to stay in shard %d or move to the new shard", root, owners[0])
+			}
+
+			moved++
+		}
+	}
+
+	if moved < 150 || moved > 350 {
+		t.Fatalf("expected about a quarter of the root policies to move to the new shard, got %d", moved)
+	}
+}
+
+func TestShardOwnsNamespaces(t *testing.T) {
+	shard := Shard{Namespaces: []string{"tenant1", "tenant2"}}
+
+	if !shard.Owns(types.NamespacedName{Namespace: "tenant2", Name: "policy"}) {
+		t.Fatal("expected the root policy of a watched namespace to be owned")
+	}
+
+	if shard.Owns(types.NamespacedName{Namespace: "tenant3", Name: "policy"}) {
+		t.Fatal("expected the root policy of another namespace not to be owned")
+	}
+
+	root := newTestPolicy("default")
+	root.Namespace = "tenant1"
+
+	replicated := newTestReplicatedPolicy(root, "cluster1", "")
+	if !shard.ownsReplicated(replicated) {
+		t.Fatal("expected the replicated policy of a watched root policy to be owned")
+	}
+
+	replicated.Labels[common.RootPolicyLabel] = "tenant3.policy"
+	if shard.ownsReplicated(replicated) {
+		t.Fatal("expected the replicated policy of another namespace not to be owned")
+	}
+
+	// The namespaces also restrict the shards of the Sharding feature
+	shard.Count = 2
+
+	for i := 0; i < 100; i++ {
+		if shard.Owns(types.NamespacedName{Namespace: "tenant3", Name: fmt.Sprintf("policy-%d", i)}) {
+			t.Fatal("expected no root policy of another namespace to be owned by the shard")
+		}
+	}
+}
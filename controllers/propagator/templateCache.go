@@ -0,0 +1,121 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/complianceeventsapi"
+)
+
+const triggerUpdateAnnotation = "policy.open-cluster-management.io/trigger-update"
+
+// templateCacheKey identifies the resolved hub templates of a version of a root policy for a
+// version of a cluster. Bumping the trigger-update annotation of the root policy resolves the templates again.
+type templateCacheKey struct {
+	// root is the root policy in the format of <namespace>/<name>
+	root            string
+	rootUID         types.UID
+	generation      int64
+	triggerUpdate   string
+	lookupNamespace string
+	cluster         string
+	// context is the template context of the cluster
+	context templateContext
+	// encryptionKey is the fingerprint of the key encrypting the values resolved from Secrets
+	encryptionKey string
+	// disabledFunctions is the comma separated list of the disabled template functions
+	disabledFunctions string
+	// impersonate is the user the lookups are made as
+	impersonate string
+	// additionalLookupNamespaces is the comma separated list of the patterns of the other
+	// namespaces the lookups are allowed in
+	additionalLookupNamespaces string
+}
+
+// templateCacheEntry is the result of resolving the hub templates of a root policy for a cluster
+type templateCacheEntry struct {
+	// templates are the resolved policy templates by their index in the policy
+	templates map[int][]byte
+	// lookups are the versions of the objects looked up by the templates
+	lookups []complianceeventsapi.TemplateLookup
+}
+
+// templateCache holds the resolved policy templates of the root policies for every cluster so that
+// unchanged templates are not resolved again on every reconcile
+type templateCache struct {
+	lock    sync.RWMutex
+	entries map[templateCacheKey]templateCacheEntry
+}
+
+func newTemplateCache() *templateCache {
+	return &templateCache{entries: map[templateCacheKey]templateCacheEntry{}}
+}
+
+// get returns the resolved policy templates for the key, if they are cached
+func (c *templateCache) get(key templateCacheKey) (templateCacheEntry, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	resolved, ok := c.entries[key]
+
+	return resolved, ok
+}
+
+// set caches the resolved policy templates for the key and drops the ones of the previous
+// versions of the root policy for the cluster
+func (c *templateCache) set(key templateCacheKey, resolved templateCacheEntry) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for existing := range c.entries {
+		if existing.rootUID == key.rootUID && existing.cluster == key.cluster {
+			delete(c.entries, existing)
+		}
+	}
+
+	c.entries[key] = resolved
+}
+
+// deleteRoot drops the resolved policy templates of the root policy in the format of
+// <namespace>/<name>
+func (c *templateCache) deleteRoot(root string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for key := range c.entries {
+		if key.root == root {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// invalidateNamespace drops the resolved policy templates that could have looked up objects in the
+// namespace
+func (c *templateCache) invalidateNamespace(namespace string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for key := range c.entries {
+		if key.lookupNamespace == namespace ||
+			namespaceMatches(namespace, splitList(key.additionalLookupNamespaces)) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// templateSourceMapper invalidates the cached hub templates that could have looked up the changed
+// ConfigMap or Secret. No reconcile request is returned, so the templates are resolved again on
+// the next reconcile of the root policies.
+func templateSourceMapper(cache *templateCache) handler.MapFunc {
+	return func(object client.Object) []reconcile.Request {
+		cache.invalidateNamespace(object.GetNamespace())
+
+		return nil
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	policyv1beta1 "github.com/open-cluster-management/governance-policy-propagator/api/v1beta1"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+type countingResolver struct {
+	stubResolver
+	calls int
+}
+
+func (c *countingResolver) ResolveTemplate(tmplJSON []byte, context interface{}) ([]byte, error) {
+	c.calls++
+
+	return c.stubResolver.ResolveTemplate(tmplJSON, context)
+}
+
+func TestProcessTemplatesCache(t *testing.T) {
+	decision := appsv1.PlacementDecision{ClusterName: "cluster1", ClusterNamespace: "cluster1"}
+	root := newTestPolicy(`{{hub .ManagedClusterName hub}}`)
+	root.SetUID("uid")
+	root.SetGeneration(1)
+
+	resolver := &countingResolver{stubResolver: stubResolver{result: []byte(configPolicy)}}
+	r := newTestReconciler(t, &resolver.stubResolver, root)
+	r.newTemplateResolver = func(TemplateResolverOptions) (TemplateResolver, error) { return resolver, nil }
+
+	process := func() {
+		t.Helper()
+
+		replicated := root.DeepCopy()
+
+		err := r.processTemplates(context.TODO(), replicated, decision, root, policyv1beta1.PropagationConfigSpec{})
+		if err != nil {
+			t.Fatalf("processTemplates returned an error: %v", err)
+		}
+
+		if string(replicated.Spec.PolicyTemplates[0].ObjectDefinition.Raw) != configPolicy {
+			t.Fatalf("expected the resolved template, got %s", replicated.Spec.PolicyTemplates[0].ObjectDefinition.Raw)
+		}
+	}
+
+	process()
+	process()
+
+	if resolver.calls != 1 {
+		t.Fatalf("expected the cached templates to be used, got %d resolutions", resolver.calls)
+	}
+
+	root.SetGeneration(2)
+	process()
+
+	if resolver.calls != 2 {
+		t.Fatalf("expected the templates to be resolved again for a new generation, got %d resolutions", resolver.calls)
+	}
+
+	root.SetAnnotations(map[string]string{triggerUpdateAnnotation: "1"})
+	process()
+
+	if resolver.calls != 3 {
+		t.Fatalf("expected the trigger-update annotation to resolve the templates again, got %d", resolver.calls)
+	}
+
+	templateSourceMapper(r.templateCache)(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "config", Namespace: "policies"},
+	})
+	process()
+
+	if resolver.calls != 4 {
+		t.Fatalf("expected a ConfigMap change to resolve the templates again, got %d resolutions", resolver.calls)
+	}
+
+	r.templateCache.deleteRoot("policies/policy")
+
+	if len(r.templateCache.entries) != 0 {
+		t.Fatal("expected the cached templates of the deleted root policy to be dropped")
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// templateClientKey identifies the lookup clients of the hub templates
+type templateClientKey struct {
+	impersonate string
+	// namespaces are the lookup namespace and the additional ones the clients are restricted to. It
+	// is empty when the clients are not restricted.
+	namespaces string
+}
+
+// templateClient is the Kubernetes configuration and client of the hub template lookups
+type templateClient struct {
+	kubeConfig *rest.Config
+	kubeClient *kubernetes.Interface
+}
+
+// templateClients shares the Kubernetes clients of the hub template lookups between the reconciles
+// instead of creating them for every template resolver. The clients are created once for every
+// impersonated user and set of lookup namespaces, and they are all created again when the
+// credentials of the Kubernetes configuration of the propagator rotate.
+type templateClients struct {
+	kubeConfig *rest.Config
+	newClient  func(opts TemplateResolverOptions) (*rest.Config, *kubernetes.Interface, error)
+
+	lock sync.Mutex
+	// credentials is the fingerprint of the credentials the clients were created with
+	credentials string
+	clients     map[templateClientKey]templateClient
+}
+
+func newTemplateClients(
+	kubeConfig *rest.Config, newClient func(opts TemplateResolverOptions) (*rest.Config, *kubernetes.Interface, error),
+) *templateClients {
+	return &templateClients{
+		kubeConfig:  kubeConfig,
+		newClient:   newClient,
+		credentials: credentialsFingerprint(kubeConfig),
+		clients:     map[templateClientKey]templateClient{},
+	}
+}
+
+// get returns the lookup clients of the options, which are created when they don't exist yet
+func (c *templateClients) get(opts TemplateResolverOptions) (*rest.Config, *kubernetes.Interface, error) {
+	key := templateClientKey{impersonate: opts.Impersonate}
+
+	if len(opts.AdditionalLookupNamespaces) != 0 {
+		namespaces := append([]string{opts.LookupNamespace}, opts.AdditionalLookupNamespaces...)
+		key.namespaces = strings.Join(namespaces, ",")
+	}
+
+	credentials := credentialsFingerprint(c.kubeConfig)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if credentials != c.credentials {
+		log.Info("The credentials of the propagator changed, creating the template lookup clients again...")
+
+		c.credentials = credentials
+		c.clients = map[templateClientKey]templateClient{}
+	}
+
+	if client, ok := c.clients[key]; ok {
+		return client.kubeConfig, client.kubeClient, nil
+	}
+
+	kubeConfig, kubeClient, err := c.newClient(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c.clients[key] = templateClient{kubeConfig: kubeConfig, kubeClient: kubeClient}
+
+	return kubeConfig, kubeClient, nil
+}
+
+// credentialsFingerprint returns a hash of the credentials of the Kubernetes configuration. The
+// certificate files are identified by their modification time and size, so that the hash changes
+// when they are rotated. The token file is not since the clients read it again when it changes.
+func credentialsFingerprint(kubeConfig *rest.Config) string {
+	if kubeConfig == nil {
+		return ""
+	}
+
+	hash := sha256.New()
+
+	for _, value := range [][]byte{
+		[]byte(kubeConfig.BearerToken), kubeConfig.CertData, kubeConfig.KeyData, kubeConfig.CAData,
+	} {
+		hash.Write(value)
+		hash.Write([]byte{0})
+	}
+
+	for _, file := range []string{kubeConfig.CertFile, kubeConfig.KeyFile, kubeConfig.CAFile} {
+		hash.Write([]byte(file))
+
+		if info, err := os.Stat(file); file != "" && err == nil {
+			hash.Write([]byte(info.ModTime().String() + "/" + strconv.FormatInt(info.Size(), 10)))
+		}
+
+		hash.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
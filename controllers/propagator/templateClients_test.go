@@ -0,0 +1,80 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+)
+
+func TestTemplateClientsShared(t *testing.T) {
+	created := 0
+	clients := newTemplateClients(&rest.Config{BearerToken: "token"}, func(
+		opts TemplateResolverOptions,
+	) (*rest.Config, *kubernetes.Interface, error) {
+		created++
+
+		kubeClient := kubernetes.Interface(fake.NewSimpleClientset())
+
+		return &rest.Config{}, &kubeClient, nil
+	})
+
+	optsList := []TemplateResolverOptions{
+		{Impersonate: "alice", LookupNamespace: "policies"},
+		{Impersonate: "alice", LookupNamespace: "other"},
+		{Impersonate: "bob", LookupNamespace: "policies"},
+		{Impersonate: "alice", LookupNamespace: "policies", AdditionalLookupNamespaces: []string{"shared"}},
+	}
+
+	for i := 0; i < 2; i++ {
+		for _, opts := range optsList {
+			if _, _, err := clients.get(opts); err != nil {
+				t.Fatalf("get returned an error: %v", err)
+			}
+		}
+	}
+
+	// The lookup namespace only restricts the clients with additional lookup namespaces
+	if created != 3 {
+		t.Fatalf("expected the clients to be created once for every user and namespaces, got %d", created)
+	}
+
+	clients.kubeConfig.BearerToken = "rotated"
+
+	if _, _, err := clients.get(optsList[0]); err != nil {
+		t.Fatalf("get returned an error: %v", err)
+	}
+
+	if created != 4 {
+		t.Fatal("expected the clients to be created again when the token rotates")
+	}
+}
+
+func TestCredentialsFingerprintCertFile(t *testing.T) {
+	certFile := filepath.Join(t.TempDir(), "tls.crt")
+	if err := os.WriteFile(certFile, []byte("cert"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	kubeConfig := &rest.Config{TLSClientConfig: rest.TLSClientConfig{CertFile: certFile}}
+	fingerprint := credentialsFingerprint(kubeConfig)
+
+	if credentialsFingerprint(kubeConfig) != fingerprint {
+		t.Fatal("expected the fingerprint not to change when the credentials don't")
+	}
+
+	rotated := time.Now().Add(time.Hour)
+	if err := os.Chtimes(certFile, rotated, rotated); err != nil {
+		t.Fatal(err)
+	}
+
+	if credentialsFingerprint(kubeConfig) == fingerprint {
+		t.Fatal("expected the fingerprint to change when the certificate file is rotated")
+	}
+}
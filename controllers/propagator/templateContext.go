@@ -0,0 +1,194 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	clusterv1 "github.com/open-cluster-management/api/cluster/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+)
+
+// vendorLabel is the label of the ManagedCluster with the Kubernetes distribution of the cluster
+const vendorLabel = "vendor"
+
+// templateValues are the labels or cluster claims of a managed cluster in the hub template
+// context. The template library only accepts string fields in the context, so they are encoded as
+// a JSON object and a value is read with the Get method, e.g.
+// {{hub .ManagedClusterLabels.Get "region" hub}}.
+type templateValues string
+
+func newTemplateValues(values map[string]string) templateValues {
+	if len(values) == 0 {
+		return templateValues("{}")
+	}
+
+	encoded, err := json.Marshal(values)
+	if err != nil {
+		return templateValues("{}")
+	}
+
+	return templateValues(encoded)
+}
+
+// Get returns the value of the key, or an empty string if it is not set
+func (v templateValues) Get(key string) string {
+	values := map[string]string{}
+	_ = json.Unmarshal([]byte(v), &values)
+
+	return values[key]
+}
+
+// templateContext is the context of the hub templates of a policy replicated to a managed cluster
+type templateContext struct {
+	ManagedClusterName    string
+	ManagedClusterLabels  templateValues
+	ManagedClusterClaims  templateValues
+	ManagedClusterVendor  string
+	ManagedClusterVersion string
+}
+
+// getTemplateContext returns the hub template context of the managed cluster. Only the name is set
+// when the ManagedCluster doesn't exist.
+func (r *PolicyReconciler) getTemplateContext(ctx context.Context, clusterName string) (templateContext, error) {
+	tmplCtx := templateContext{
+		ManagedClusterName:   clusterName,
+		ManagedClusterLabels: newTemplateValues(nil),
+		ManagedClusterClaims: newTemplateValues(nil),
+	}
+
+	cluster := &clusterv1.ManagedCluster{}
+
+	err := r.Get(ctx, types.NamespacedName{Name: clusterName}, cluster)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return tmplCtx, nil
+		}
+
+		return tmplCtx, err
+	}
+
+	tmplCtx.ManagedClusterLabels = newTemplateValues(cluster.GetLabels())
+	tmplCtx.ManagedClusterClaims = newTemplateValues(clusterClaims(cluster))
+	tmplCtx.ManagedClusterVendor = cluster.GetLabels()[vendorLabel]
+	tmplCtx.ManagedClusterVersion = cluster.Status.Version.Kubernetes
+
+	return tmplCtx, nil
+}
+
+func clusterClaims(cluster *clusterv1.ManagedCluster) map[string]string {
+	claims := make(map[string]string, len(cluster.Status.ClusterClaims))
+	for _, claim := range cluster.Status.ClusterClaims {
+		claims[claim.Name] = claim.Value
+	}
+
+	return claims
+}
+
+// managedClusterPredicateFuncs only lets through the ManagedCluster updates that change the values
+// available to the hub templates, the cluster requirements, the cluster selectors, the cluster
+// namespace, and the availability of the cluster
+var managedClusterPredicateFuncs = predicate.Funcs{
+	CreateFunc: func(e event.CreateEvent) bool { return false },
+	DeleteFunc: func(e event.DeleteEvent) bool { return false },
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldCluster, oldOk := e.ObjectOld.(*clusterv1.ManagedCluster)
+		newCluster, newOk := e.ObjectNew.(*clusterv1.ManagedCluster)
+
+		if !oldOk || !newOk {
+			return false
+		}
+
+		return common.ClusterNamespace(oldCluster) != common.ClusterNamespace(newCluster) ||
+			!reflect.DeepEqual(oldCluster.GetLabels(), newCluster.GetLabels()) ||
+			!reflect.DeepEqual(clusterClaims(oldCluster), clusterClaims(newCluster)) ||
+			oldCluster.Status.Version.Kubernetes != newCluster.Status.Version.Kubernetes ||
+			clusterAvailable(oldCluster) != clusterAvailable(newCluster)
+	},
+}
+
+// managedClusterMapper returns a reconcile request for every root policy replicated to the managed
+// cluster, for every root policy with a cluster selector since the cluster may now match it, and
+// for every root policy with skipUnavailableClusters since the cluster may now be available
+func managedClusterMapper(c client.Client) handler.MapFunc {
+	return func(object client.Object) []reconcile.Request {
+		result := selectorPolicyRequests(c)
+		queued := make(map[types.NamespacedName]bool, len(result))
+
+		for _, request := range result {
+			queued[request.NamespacedName] = true
+		}
+
+		plcList := &policiesv1.PolicyList{}
+
+		err := c.List(
+			context.TODO(), plcList, client.MatchingLabels{common.ClusterNameLabel: object.GetName()},
+		)
+		if err != nil {
+			log.Error(err, "Failed to list the policies replicated to the cluster...", "Cluster", object.GetName())
+
+			return nil
+		}
+
+		for _, plc := range plcList.Items {
+			// The root policy label is in the format of <namespace>.<name>
+			rootName := strings.SplitN(plc.GetLabels()[common.RootPolicyLabel], ".", 2)
+			if len(rootName) != 2 {
+				continue
+			}
+
+			root := types.NamespacedName{Namespace: rootName[0], Name: rootName[1]}
+			if queued[root] {
+				continue
+			}
+
+			queued[root] = true
+
+			log.Info("Found reconciliation request from the managed cluster...",
+				"Namespace", rootName[0], "Policy-Name", rootName[1])
+			result = append(result, reconcile.Request{NamespacedName: root})
+		}
+
+		return result
+	}
+}
+
+// selectorPolicyRequests returns a reconcile request for every root policy with a cluster selector
+// or skipUnavailableClusters
+func selectorPolicyRequests(c client.Client) []reconcile.Request {
+	plcList := &policiesv1.PolicyList{}
+
+	err := c.List(context.TODO(), plcList)
+	if err != nil {
+		log.Error(err, "Failed to list the policies with a cluster selector...")
+
+		return nil
+	}
+
+	var result []reconcile.Request
+
+	for _, plc := range plcList.Items {
+		if (plc.Spec.ClusterSelector == nil && !plc.Spec.SkipUnavailableClusters) ||
+			plc.GetLabels()[common.RootPolicyLabel] != "" {
+			continue
+		}
+
+		result = append(result, reconcile.Request{NamespacedName: types.NamespacedName{
+			Namespace: plc.GetNamespace(), Name: plc.GetName(),
+		}})
+	}
+
+	return result
+}
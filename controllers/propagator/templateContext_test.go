@@ -0,0 +1,143 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	clusterv1 "github.com/open-cluster-management/api/cluster/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	templates "github.com/open-cluster-management/go-template-utils/pkg/templates"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+)
+
+func newTemplateTestCluster() *clusterv1.ManagedCluster {
+	cluster := newTestManagedCluster(
+		"cluster1", "v1.21.3", clusterv1.ManagedClusterClaim{Name: "id.k8s.io", Value: "1234"},
+	)
+	cluster.SetLabels(map[string]string{"region": "us-east", vendorLabel: "OpenShift"})
+
+	return cluster
+}
+
+func TestGetTemplateContext(t *testing.T) {
+	r := newTestReconciler(t, &stubResolver{}, newTemplateTestCluster())
+
+	tmplCtx, err := r.getTemplateContext(context.TODO(), "cluster1")
+	if err != nil {
+		t.Fatalf("getTemplateContext returned an error: %v", err)
+	}
+
+	if tmplCtx.ManagedClusterName != "cluster1" || tmplCtx.ManagedClusterVendor != "OpenShift" ||
+		tmplCtx.ManagedClusterVersion != "v1.21.3" {
+		t.Fatalf("unexpected template context %+v", tmplCtx)
+	}
+
+	if tmplCtx.ManagedClusterLabels.Get("region") != "us-east" ||
+		tmplCtx.ManagedClusterClaims.Get("id.k8s.io") != "1234" ||
+		tmplCtx.ManagedClusterLabels.Get("missing") != "" {
+		t.Fatalf("unexpected labels or claims in the template context %+v", tmplCtx)
+	}
+
+	tmplCtx, err = r.getTemplateContext(context.TODO(), "cluster2")
+	if err != nil {
+		t.Fatalf("getTemplateContext returned an error: %v", err)
+	}
+
+	if tmplCtx.ManagedClusterName != "cluster2" || tmplCtx.ManagedClusterLabels.Get("region") != "" {
+		t.Fatalf("expected only the name for a missing cluster, got %+v", tmplCtx)
+	}
+}
+
+func TestTemplateContextResolution(t *testing.T) {
+	kubeClient := kubernetes.Interface(fake.NewSimpleClientset())
+
+	resolver, err := templates.NewResolver(&kubeClient, nil, defaultTemplateConfig())
+	if err != nil {
+		t.Fatalf("failed to create the resolver: %v", err)
+	}
+
+	r := newTestReconciler(t, &stubResolver{}, newTemplateTestCluster())
+
+	tmplCtx, err := r.getTemplateContext(context.TODO(), "cluster1")
+	if err != nil {
+		t.Fatalf("getTemplateContext returned an error: %v", err)
+	}
+
+	// The quotes are escaped in the JSON of the policy template
+	tmpl := `{{hub .ManagedClusterLabels.Get \"region\" hub}}-{{hub .ManagedClusterClaims.Get \"id.k8s.io\" hub}}-` +
+		`{{hub .ManagedClusterVendor hub}}`
+
+	resolved, err := resolver.ResolveTemplate([]byte(strings.Replace(configPolicy, "%s", tmpl, 1)), tmplCtx)
+	if err != nil {
+		t.Fatalf("failed to resolve the template: %v", err)
+	}
+
+	if !strings.Contains(string(resolved), `"us-east-1234-OpenShift"`) {
+		t.Fatalf("expected the cluster values in the resolved template, got %s", resolved)
+	}
+}
+
+func TestManagedClusterPredicate(t *testing.T) {
+	oldCluster := newTemplateTestCluster()
+
+	unchanged := oldCluster.DeepCopy()
+	unchanged.Status.Conditions = []metav1.Condition{
+		{Type: "ManagedClusterConditionAvailable", Status: metav1.ConditionTrue},
+	}
+
+	relabeled := oldCluster.DeepCopy()
+	relabeled.Labels["region"] = "us-west"
+
+	upgraded := oldCluster.DeepCopy()
+	upgraded.Status.Version.Kubernetes = "v1.22.0"
+
+	offline := oldCluster.DeepCopy()
+	offline.Status.Conditions = []metav1.Condition{
+		{Type: "ManagedClusterConditionAvailable", Status: metav1.ConditionUnknown},
+	}
+
+	tests := []struct {
+		cluster  *clusterv1.ManagedCluster
+		expected bool
+	}{
+		{unchanged, false},
+		{relabeled, true},
+		{upgraded, true},
+		{offline, true},
+	}
+
+	for _, test := range tests {
+		e := event.UpdateEvent{ObjectOld: oldCluster, ObjectNew: test.cluster}
+		if managedClusterPredicateFuncs.Update(e) != test.expected {
+			t.Fatalf("expected the update to be let through: %t", test.expected)
+		}
+	}
+}
+
+func TestManagedClusterMapper(t *testing.T) {
+	replicated := newTestPolicy("default")
+	replicated.SetName("policies.policy")
+	replicated.SetNamespace("cluster1")
+	replicated.SetLabels(map[string]string{
+		common.ClusterNameLabel: "cluster1",
+		common.RootPolicyLabel:  "policies.policy",
+	})
+
+	other := replicated.DeepCopy()
+	other.SetNamespace("cluster2")
+	other.SetLabels(map[string]string{common.ClusterNameLabel: "cluster2", common.RootPolicyLabel: "policies.other"})
+
+	r := newTestReconciler(t, &stubResolver{}, replicated, other)
+
+	requests := managedClusterMapper(r.Client)(newTemplateTestCluster())
+	if len(requests) != 1 || requests[0].Namespace != "policies" || requests[0].Name != "policy" {
+		t.Fatalf("expected a request for the root policy replicated to the cluster, got %v", requests)
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+)
+
+// DefaultTemplateEligibleKinds are the kinds of the policy templates that may have hub templates
+// when no other kinds are configured
+var DefaultTemplateEligibleKinds = []string{"ConfigurationPolicy", "OperatorPolicy", "CertificatePolicy", "IamPolicy"}
+
+// newTemplateEligibleKinds returns the set of the kinds of the policy templates that may have hub
+// templates
+func newTemplateEligibleKinds(kinds []string) map[string]bool {
+	eligibleKinds := map[string]bool{}
+
+	for _, kind := range kinds {
+		if kind = strings.TrimSpace(kind); kind != "" {
+			eligibleKinds[kind] = true
+		}
+	}
+
+	return eligibleKinds
+}
+
+// hubTemplatesAllowed returns whether the policy template is of a kind eligible for hub templates.
+// The Gatekeeper objects are always eligible, since the kinds of the Constraints are defined by
+// their ConstraintTemplates.
+func (r *PolicyReconciler) hubTemplatesAllowed(policyT *policiesv1.PolicyTemplate) bool {
+	object := &policyTemplateObject{}
+	if err := json.Unmarshal(policyT.ObjectDefinition.Raw, object); err != nil {
+		return false
+	}
+
+	return r.templateEligibleKinds[object.Kind] || isGatekeeperObject(policyT)
+}
+
+// templateEligibleKindsList returns the sorted kinds eligible for hub templates to report them
+func (r *PolicyReconciler) templateEligibleKindsList() string {
+	kinds := make([]string, 0, len(r.templateEligibleKinds))
+	for kind := range r.templateEligibleKinds {
+		kinds = append(kinds, kind)
+	}
+
+	sort.Strings(kinds)
+
+	return strings.Join(kinds, ", ")
+}
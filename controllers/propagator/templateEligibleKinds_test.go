@@ -0,0 +1,90 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	policyv1beta1 "github.com/open-cluster-management/governance-policy-propagator/api/v1beta1"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+const certificatePolicy = `{"apiVersion":"policy.open-cluster-management.io/v1","kind":"CertificatePolicy",` +
+	`"metadata":{"name":"certs"},"spec":{"minimumDuration":"{{hub .ManagedClusterName hub}}"}}`
+
+func TestHubTemplatesAllowed(t *testing.T) {
+	r := newTestReconciler(t, &stubResolver{})
+
+	tests := []struct {
+		objectDefinition string
+		expected         bool
+	}{
+		{objectDefinition: configPolicy, expected: true},
+		{objectDefinition: certificatePolicy, expected: true},
+		{objectDefinition: `{"apiVersion":"policy.open-cluster-management.io/v1","kind":"IamPolicy"}`, expected: true},
+		{objectDefinition: operatorPolicy, expected: true},
+		{objectDefinition: constraint, expected: true},
+		{objectDefinition: `{"apiVersion":"policy.example.com/v1","kind":"CustomPolicy"}`},
+		{objectDefinition: `{"apiVersion":"v1","kind":"ConfigMap"}`},
+	}
+
+	for _, test := range tests {
+		policyT := &policiesv1.PolicyTemplate{ObjectDefinition: runtime.RawExtension{Raw: []byte(test.objectDefinition)}}
+
+		if actual := r.hubTemplatesAllowed(policyT); actual != test.expected {
+			t.Fatalf("expected the hub templates allowed to be %v for %s", test.expected, test.objectDefinition)
+		}
+	}
+
+	// The configured kinds replace the default ones, and the Gatekeeper objects stay eligible
+	r.templateEligibleKinds = newTemplateEligibleKinds([]string{"CustomPolicy", " ConfigurationPolicy", ""})
+
+	if list := r.templateEligibleKindsList(); list != "ConfigurationPolicy, CustomPolicy" {
+		t.Fatalf("expected the eligible kinds to be trimmed and sorted, got %q", list)
+	}
+
+	for objectDefinition, expected := range map[string]bool{
+		`{"apiVersion":"policy.example.com/v1","kind":"CustomPolicy"}`: true,
+		certificatePolicy: false,
+		constraint:        true,
+	} {
+		policyT := &policiesv1.PolicyTemplate{ObjectDefinition: runtime.RawExtension{Raw: []byte(objectDefinition)}}
+
+		if actual := r.hubTemplatesAllowed(policyT); actual != expected {
+			t.Fatalf("expected the hub templates allowed to be %v for %s", expected, objectDefinition)
+		}
+	}
+}
+
+func TestProcessTemplatesEligibleKinds(t *testing.T) {
+	decision := appsv1.PlacementDecision{ClusterName: "cluster1", ClusterNamespace: "cluster1"}
+	resolved := strings.Replace(certificatePolicy, "{{hub .ManagedClusterName hub}}", "720h", 1)
+
+	root := newGatekeeperPolicy(certificatePolicy)
+	r := newTestReconciler(t, &stubResolver{result: []byte(resolved)}, root)
+
+	replicated := root.DeepCopy()
+
+	err := r.processTemplates(context.TODO(), replicated, decision, root, policyv1beta1.PropagationConfigSpec{})
+	if err != nil {
+		t.Fatalf("expected the hub templates of the CertificatePolicy to be resolved, got %v", err)
+	}
+
+	if actual := string(replicated.Spec.PolicyTemplates[0].ObjectDefinition.Raw); actual != resolved {
+		t.Fatalf("expected the resolved CertificatePolicy, got %s", actual)
+	}
+
+	r = newTestReconciler(t, &stubResolver{result: []byte(resolved)}, root)
+	r.templateEligibleKinds = newTemplateEligibleKinds([]string{"ConfigurationPolicy"})
+	replicated = root.DeepCopy()
+
+	err = r.processTemplates(context.TODO(), replicated, decision, root, policyv1beta1.PropagationConfigSpec{})
+	if err == nil || !strings.Contains(err.Error(), "restricted to the policy kinds ConfigurationPolicy") {
+		t.Fatalf("expected the CertificatePolicy not to be eligible, got %v", err)
+	}
+}
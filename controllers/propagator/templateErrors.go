@@ -0,0 +1,127 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+
+	retry "github.com/avast/retry-go/v3"
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+)
+
+// templateErrorPrefix prefixes the hub template errors in the per-cluster status of the root
+// policies
+const templateErrorPrefix = "Failed to resolve the hub templates: "
+
+// templateErrorMessage returns the message of the per-cluster status of the root policy for the
+// hub template error, or an empty message when the templates were resolved
+func templateErrorMessage(templateErr string) string {
+	if templateErr == "" {
+		return ""
+	}
+
+	return templateErrorPrefix + templateErr
+}
+
+// setTemplateError sets the hub template error of the cluster namespace in the per-cluster status
+// of the root policy. The clusters the policy could not be replicated to keep their message.
+func (r *PolicyReconciler) setTemplateError(
+	ctx context.Context, instance *policiesv1.Policy, clusterNamespace string, templateErr error,
+) error {
+	message := ""
+	if templateErr != nil {
+		message = templateErrorMessage(templateErr.Error())
+	}
+
+	original := instance.DeepCopy()
+	changed := false
+
+	for _, cpcs := range instance.Status.Status {
+		if cpcs.ClusterNamespace != clusterNamespace || cpcs.Reason != "" || cpcs.Message == message {
+			continue
+		}
+
+		cpcs.Message = message
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+
+	return r.Status().Patch(ctx, instance, client.MergeFrom(original))
+}
+
+// setTemplateResolverFailure sets the cluster namespace as NonCompliant in the per-cluster status of
+// the root policy with the failure, since the hub templates could not be resolved for it
+func (r *PolicyReconciler) setTemplateResolverFailure(
+	ctx context.Context, instance *policiesv1.Policy, clusterNamespace string, failure replicationFailure,
+) error {
+	original := instance.DeepCopy()
+	changed := false
+
+	for _, cpcs := range instance.Status.Status {
+		if cpcs.ClusterNamespace != clusterNamespace {
+			continue
+		}
+
+		if cpcs.ComplianceState == policiesv1.NonCompliant && cpcs.Reason == failure.reason &&
+			cpcs.Message == failure.message {
+			continue
+		}
+
+		cpcs.ComplianceState = policiesv1.NonCompliant
+		cpcs.Reason = failure.reason
+		cpcs.Message = failure.message
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+
+	return r.Status().Patch(ctx, instance, client.MergeFrom(original))
+}
+
+// templateResolverError is the error of the template resolver that could not be created
+type templateResolverError struct {
+	err error
+}
+
+func (e *templateResolverError) Error() string {
+	return "failed to create the template resolver: " + e.err.Error()
+}
+
+func (e *templateResolverError) Unwrap() error {
+	return e.err
+}
+
+// instantiateResolver creates the template resolver with newResolver, retrying on failures such
+// as an unavailable API server. When it keeps failing, the returned resolver fails every template
+// with a templateResolverError so that the error is reported on the managed cluster instead.
+func (r *PolicyReconciler) instantiateResolver(
+	ctx context.Context, logger logr.Logger, newResolver func() (TemplateResolver, error),
+) TemplateResolver {
+	var resolver TemplateResolver
+
+	err := retry.Do(
+		func() error {
+			var err error
+			resolver, err = newResolver()
+
+			return err
+		},
+		r.getRetryOptions(ctx, logger, "Retrying to create the template resolver...")...,
+	)
+	if err != nil {
+		logger.Error(err, "Failed to create the template resolver...")
+		templateResolverFailures.Inc()
+
+		return failingResolver{err: &templateResolverError{err: err}}
+	}
+
+	return resolver
+}
@@ -0,0 +1,149 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+)
+
+func TestTemplateErrorStatus(t *testing.T) {
+	root := newTestPolicy(`{{hub .ManagedClusterName hub}}`)
+	root.Status = policiesv1.PolicyStatus{
+		Status: []*policiesv1.CompliancePerClusterStatus{
+			{ClusterName: "cluster1", ClusterNamespace: "cluster1"},
+			{
+				ClusterName: "cluster2", ClusterNamespace: "cluster2",
+				Reason: reasonReplicationFailed, Message: "failed",
+			},
+		},
+	}
+
+	resolver := &stubResolver{err: errors.New("template: tmpl:1: function \"bad\" not defined")}
+	r := NewReplicatedPolicyReconciler(newTestReconciler(t, resolver, root))
+	r.propagationState.setResolved("policies/policy", nil, map[string]bool{
+		"cluster1/cluster1": true, "cluster2/cluster2": true,
+	})
+
+	reconcileAndGetStatus := func() []*policiesv1.CompliancePerClusterStatus {
+		t.Helper()
+
+		for _, cluster := range []string{"cluster1", "cluster2"} {
+			_, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{
+				Namespace: cluster, Name: common.FullNameForPolicy(root),
+			}})
+			if err != nil {
+				t.Fatalf("Reconcile returned an error: %v", err)
+			}
+		}
+
+		updated := &policiesv1.Policy{}
+		if err := r.Get(context.TODO(), types.NamespacedName{Namespace: "policies", Name: "policy"}, updated); err != nil {
+			t.Fatalf("failed to get the root policy: %v", err)
+		}
+
+		return updated.Status.Status
+	}
+
+	status := reconcileAndGetStatus()
+
+	expected := templateErrorPrefix + resolver.err.Error()
+	if status[0].Message != expected {
+		t.Fatalf("expected the message %q, got %q", expected, status[0].Message)
+	}
+
+	if status[1].Message != "failed" {
+		t.Fatalf("expected the message of the failed replication to be kept, got %q", status[1].Message)
+	}
+
+	// The status controller keeps the message when it updates the compliance
+	replicated := newTestReplicatedPolicy(root, "cluster1", policiesv1.Compliant)
+
+	rebuilt := replicatedStatus(status, []policiesv1.Policy{*replicated})
+	if rebuilt[0].Message != expected {
+		t.Fatalf("expected the status controller to keep the message %q, got %q", expected, rebuilt[0].Message)
+	}
+
+	// The message is cleared once the hub templates are resolved
+	resolver.err = nil
+	resolver.result = []byte(`{"apiVersion":"policy.open-cluster-management.io/v1","kind":"ConfigurationPolicy",` +
+		`"metadata":{"name":"case"},"spec":{"namespace":"cluster1"}}`)
+
+	status = reconcileAndGetStatus()
+	if status[0].Message != "" {
+		t.Fatalf("expected the message to be cleared, got %q", status[0].Message)
+	}
+}
+
+func TestTemplateErrorMessage(t *testing.T) {
+	if templateErrorMessage("") != "" {
+		t.Fatal("expected no message without a hub template error")
+	}
+
+	if templateErrorMessage("bad") != templateErrorPrefix+"bad" {
+		t.Fatalf("expected the prefixed message, got %q", templateErrorMessage("bad"))
+	}
+}
+
+func TestTemplateResolverFailure(t *testing.T) {
+	root := newTestPolicy(`{{hub .ManagedClusterName hub}}`)
+	pb := newTestPlacementBinding("pb", "plr", policiesv1.Subject{
+		APIGroup: policiesv1.SchemeGroupVersion.Group, Kind: policiesv1.Kind, Name: "policy",
+	})
+
+	r := newTestReconciler(t, &stubResolver{}, root, pb, newTestPlacementRule("plr", "cluster1"))
+
+	attempts := 0
+	r.retryAttempts = 2
+	r.newTemplateResolver = func(TemplateResolverOptions) (TemplateResolver, error) {
+		attempts++
+
+		return nil, errors.New("connection refused")
+	}
+
+	failuresBefore := testutil.ToFloat64(templateResolverFailures)
+
+	if err := r.handleRootPolicy(context.TODO(), root, nil); err != nil {
+		t.Fatalf("handleRootPolicy returned an error: %v", err)
+	}
+
+	if attempts != 2 {
+		t.Fatalf("expected the template resolver to be created twice, got %d attempts", attempts)
+	}
+
+	if failures := testutil.ToFloat64(templateResolverFailures) - failuresBefore; failures != 1 {
+		t.Fatalf("expected one template resolver failure to be counted, got %v", failures)
+	}
+
+	// The replicated policy reports the error on the managed cluster
+	replicated := &policiesv1.Policy{}
+
+	err := r.Get(context.TODO(), types.NamespacedName{Namespace: "cluster1", Name: "policies.policy"}, replicated)
+	if err != nil {
+		t.Fatalf("failed to get the replicated policy: %v", err)
+	}
+
+	if !strings.Contains(string(replicated.Spec.PolicyTemplates[0].ObjectDefinition.Raw), "connection refused") {
+		t.Fatal("expected the hub template error to be set on the policy template")
+	}
+
+	updated := &policiesv1.Policy{}
+	if err := r.Get(context.TODO(), types.NamespacedName{Namespace: "policies", Name: "policy"}, updated); err != nil {
+		t.Fatalf("failed to get the root policy: %v", err)
+	}
+
+	status := updated.Status.Status
+	if len(status) != 1 || status[0].ComplianceState != policiesv1.NonCompliant ||
+		status[0].Reason != reasonTemplateResolverFailed || !strings.HasPrefix(status[0].Message, templateErrorPrefix) {
+		t.Fatalf("expected the cluster to be NonCompliant with the template error, got %+v", status)
+	}
+}
@@ -0,0 +1,302 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+)
+
+// The keys of the ConfigMap configuring the hub template functions. The disabledFunctions key is
+// the comma separated list of the functions disabled for all the root policies, which replaces the
+// default list. The allowedNamespaces.<function> keys are the comma separated lists of the
+// namespaces whose root policies may still use the disabled function. The
+// lookupNamespaces.<namespace> and lookupNamespaces.<namespace>.<policy> keys are the comma
+// separated lists of the other namespaces the hub templates of the root policies in the namespace,
+// or of the named root policy, may look up objects in. Each entry may be a glob pattern such as
+// team-*.
+const (
+	disabledFunctionsKey       = "disabledFunctions"
+	allowedNamespacesKeyPrefix = "allowedNamespaces."
+	lookupNamespacesKeyPrefix  = "lookupNamespaces."
+)
+
+// templateFunctionConfig is the parsed configuration of the hub template functions
+type templateFunctionConfig struct {
+	// disabled are the functions disabled for all the root policies
+	disabled []string
+	// allowedNamespaces maps a disabled function to the patterns of the namespaces of the root
+	// policies that may use it
+	allowedNamespaces map[string][]string
+	// lookupNamespaces maps a namespace, or a root policy in the format of <namespace>.<name>, to
+	// the patterns of the other namespaces its hub templates may look up objects in
+	lookupNamespaces map[string][]string
+}
+
+// parseTemplateFunctionConfig parses the data of the ConfigMap configuring the hub template
+// functions. The defaultDisabled functions are disabled when the ConfigMap doesn't list them.
+func parseTemplateFunctionConfig(data map[string]string, defaultDisabled []string) (templateFunctionConfig, error) {
+	config := templateFunctionConfig{
+		disabled:          append([]string{}, defaultDisabled...),
+		allowedNamespaces: map[string][]string{},
+		lookupNamespaces:  map[string][]string{},
+	}
+
+	if value, ok := data[disabledFunctionsKey]; ok {
+		config.disabled = splitList(value)
+	}
+
+	for key, value := range data {
+		if key == disabledFunctionsKey {
+			continue
+		}
+
+		patterns := splitList(value)
+
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return templateFunctionConfig{}, fmt.Errorf(
+					"the namespace pattern %s of the key %s is invalid: %w", pattern, key, err,
+				)
+			}
+		}
+
+		switch {
+		case strings.HasPrefix(key, allowedNamespacesKeyPrefix):
+			config.allowedNamespaces[strings.TrimPrefix(key, allowedNamespacesKeyPrefix)] = patterns
+		case strings.HasPrefix(key, lookupNamespacesKeyPrefix):
+			config.lookupNamespaces[strings.TrimPrefix(key, lookupNamespacesKeyPrefix)] = patterns
+		default:
+			return templateFunctionConfig{}, fmt.Errorf("the key %s is not known", key)
+		}
+	}
+
+	sort.Strings(config.disabled)
+
+	return config, nil
+}
+
+// splitList returns the non-empty entries of the comma separated list
+func splitList(value string) []string {
+	entries := []string{}
+
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries
+}
+
+// disabledFunctions returns the functions disabled for the hub templates of the root policies in
+// the namespace. The fromSecret function is enabled when the values it resolves are encrypted,
+// unless it is only allowed in other namespaces.
+func (c templateFunctionConfig) disabledFunctions(namespace string, encrypted bool) []string {
+	disabled := []string{}
+
+	for _, function := range c.disabled {
+		if patterns, restricted := c.allowedNamespaces[function]; restricted {
+			if !namespaceMatches(namespace, patterns) {
+				disabled = append(disabled, function)
+			}
+
+			continue
+		}
+
+		if encrypted && function == "fromSecret" {
+			continue
+		}
+
+		disabled = append(disabled, function)
+	}
+
+	return disabled
+}
+
+// additionalLookupNamespaces returns the patterns of the other namespaces the hub templates of the
+// root policy may look up objects in
+func (c templateFunctionConfig) additionalLookupNamespaces(namespace string, name string) []string {
+	patterns := append([]string{}, c.lookupNamespaces[namespace]...)
+
+	return append(patterns, c.lookupNamespaces[namespace+"."+name]...)
+}
+
+// namespaceMatches returns whether the namespace matches one of the validated glob patterns
+func namespaceMatches(namespace string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, namespace); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// templateFunctions holds the current configuration of the hub template functions
+type templateFunctions struct {
+	lock sync.RWMutex
+	// defaultDisabled are the functions disabled when the ConfigMap doesn't exist or doesn't list them
+	defaultDisabled []string
+	config          templateFunctionConfig
+}
+
+func newTemplateFunctions(defaultDisabled []string) *templateFunctions {
+	config, _ := parseTemplateFunctionConfig(nil, defaultDisabled)
+
+	return &templateFunctions{defaultDisabled: defaultDisabled, config: config}
+}
+
+// disabledFunctions returns the functions disabled for the hub templates of the root policies in
+// the namespace
+func (f *templateFunctions) disabledFunctions(namespace string, encrypted bool) []string {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	return f.config.disabledFunctions(namespace, encrypted)
+}
+
+// additionalLookupNamespaces returns the patterns of the other namespaces the hub templates of the
+// root policy may look up objects in
+func (f *templateFunctions) additionalLookupNamespaces(namespace string, name string) []string {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	return f.config.additionalLookupNamespaces(namespace, name)
+}
+
+// load replaces the configuration with the data of the ConfigMap and returns whether it changed. An
+// invalid configuration is not loaded.
+func (f *templateFunctions) load(data map[string]string) (bool, error) {
+	config, err := parseTemplateFunctionConfig(data, f.defaultDisabled)
+	if err != nil {
+		return false, err
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if reflect.DeepEqual(f.config, config) {
+		return false, nil
+	}
+
+	f.config = config
+
+	return true, nil
+}
+
+// templateFunctionsWatcher reloads the configuration of the hub template functions when its
+// ConfigMap changes and reconciles all the root policies again
+type templateFunctionsWatcher struct {
+	kubeClient kubernetes.Interface
+	configMap  types.NamespacedName
+	functions  *templateFunctions
+	// listRoots returns the root policies to reconcile again
+	listRoots func(ctx context.Context) ([]policiesv1.Policy, error)
+	// events are the root policies to reconcile again
+	events chan event.GenericEvent
+}
+
+// Start loads the configuration and watches its ConfigMap until the context is done
+func (w *templateFunctionsWatcher) Start(ctx context.Context) error {
+	configMap, err := w.kubeClient.CoreV1().ConfigMaps(w.configMap.Namespace).Get(
+		ctx, w.configMap.Name, metav1.GetOptions{},
+	)
+	if err == nil {
+		w.reload(ctx, configMap.Data)
+	} else if !k8serrors.IsNotFound(err) {
+		log.Error(err, "Failed to get the template functions ConfigMap...", "ConfigMap", w.configMap.String())
+	}
+
+	factory := informers.NewSharedInformerFactoryWithOptions(
+		w.kubeClient, 0,
+		informers.WithNamespace(w.configMap.Namespace),
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", w.configMap.Name).String()
+		}),
+	)
+
+	factory.Core().V1().ConfigMaps().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if configMap, ok := obj.(*corev1.ConfigMap); ok {
+				w.reload(ctx, configMap.Data)
+			}
+		},
+		UpdateFunc: func(_, obj interface{}) {
+			if configMap, ok := obj.(*corev1.ConfigMap); ok {
+				w.reload(ctx, configMap.Data)
+			}
+		},
+		DeleteFunc: func(interface{}) {
+			w.reload(ctx, nil)
+		},
+	})
+
+	factory.Start(ctx.Done())
+	<-ctx.Done()
+
+	return nil
+}
+
+// NeedLeaderElection makes only the leader reconcile the root policies again
+func (w *templateFunctionsWatcher) NeedLeaderElection() bool {
+	return true
+}
+
+// reload loads the configuration and reconciles all the root policies again when it changed, so
+// that their hub templates are resolved with the new functions
+func (w *templateFunctionsWatcher) reload(ctx context.Context, data map[string]string) {
+	changed, err := w.functions.load(data)
+	if err != nil {
+		log.Error(err, "Ignoring the invalid template functions ConfigMap...", "ConfigMap", w.configMap.String())
+
+		return
+	}
+
+	if !changed {
+		return
+	}
+
+	log.Info("The template functions configuration changed, reconciling the root policies again...")
+
+	roots, err := w.listRoots(ctx)
+	if err != nil {
+		log.Error(err, "Failed to list the root policies to reconcile them again...")
+
+		return
+	}
+
+	for i := range roots {
+		if _, replicated := roots[i].GetLabels()[common.RootPolicyLabel]; replicated {
+			continue
+		}
+
+		plc := &policiesv1.Policy{}
+		plc.SetNamespace(roots[i].GetNamespace())
+		plc.SetName(roots[i].GetName())
+
+		select {
+		case w.events <- event.GenericEvent{Object: plc}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
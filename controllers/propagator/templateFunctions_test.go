@@ -0,0 +1,126 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	policyv1beta1 "github.com/open-cluster-management/governance-policy-propagator/api/v1beta1"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+func TestTemplateFunctionConfigDisabledFunctions(t *testing.T) {
+	config, err := parseTemplateFunctionConfig(map[string]string{
+		"disabledFunctions":            "fromSecret, lookup",
+		"allowedNamespaces.fromSecret": "secure, team-*",
+	}, []string{"fromSecret"})
+	if err != nil {
+		t.Fatalf("failed to parse the configuration: %v", err)
+	}
+
+	tests := []struct {
+		namespace string
+		encrypted bool
+		expected  []string
+	}{
+		{"secure", false, []string{"lookup"}},
+		{"team-a", true, []string{"lookup"}},
+		{"policies", false, []string{"fromSecret", "lookup"}},
+		{"policies", true, []string{"fromSecret", "lookup"}},
+	}
+
+	for _, test := range tests {
+		disabled := config.disabledFunctions(test.namespace, test.encrypted)
+		if !reflect.DeepEqual(disabled, test.expected) {
+			t.Fatalf("expected %v to be disabled in %s, got %v", test.expected, test.namespace, disabled)
+		}
+	}
+
+	defaults, _ := parseTemplateFunctionConfig(nil, []string{"fromSecret"})
+
+	if disabled := defaults.disabledFunctions("policies", true); len(disabled) != 0 {
+		t.Fatalf("expected the encrypted fromSecret function to be enabled by default, got %v", disabled)
+	}
+}
+
+func TestParseTemplateFunctionConfigInvalid(t *testing.T) {
+	for _, data := range []map[string]string{
+		{"fromSecret": "policies"},
+		{"allowedNamespaces.fromSecret": "team-["},
+	} {
+		if _, err := parseTemplateFunctionConfig(data, nil); err == nil {
+			t.Fatalf("expected an error for %v", data)
+		}
+	}
+}
+
+func TestTemplateFunctionsWatcherReload(t *testing.T) {
+	root := newTestPolicy("default")
+	replicated := newTestReplicatedPolicy(root, "cluster1", policiesv1.Compliant)
+
+	watcher := &templateFunctionsWatcher{
+		configMap: types.NamespacedName{Namespace: "ocm", Name: "template-functions"},
+		functions: newTemplateFunctions([]string{"fromSecret"}),
+		listRoots: func(context.Context) ([]policiesv1.Policy, error) {
+			return []policiesv1.Policy{*root, *replicated}, nil
+		},
+		events: make(chan event.GenericEvent, 2),
+	}
+
+	data := map[string]string{"allowedNamespaces.fromSecret": "policies"}
+
+	watcher.reload(context.TODO(), data)
+
+	if len(watcher.events) != 1 {
+		t.Fatalf("expected the root policy to be reconciled again, got %d events", len(watcher.events))
+	}
+
+	if object := (<-watcher.events).Object; object.GetNamespace() != "policies" || object.GetName() != "policy" {
+		t.Fatalf("expected the root policy to be reconciled again, got %s/%s", object.GetNamespace(), object.GetName())
+	}
+
+	watcher.reload(context.TODO(), data)
+	watcher.reload(context.TODO(), map[string]string{"unknown": "value"})
+
+	if len(watcher.events) != 0 {
+		t.Fatalf("expected no reconcile for an unchanged or invalid configuration, got %d events", len(watcher.events))
+	}
+
+	if disabled := watcher.functions.disabledFunctions("policies", false); len(disabled) != 0 {
+		t.Fatalf("expected the fromSecret function to be allowed in the namespace, got %v", disabled)
+	}
+}
+
+func TestProcessTemplatesDisabledFunctions(t *testing.T) {
+	decision := appsv1.PlacementDecision{ClusterName: "cluster1", ClusterNamespace: "cluster1"}
+	root := newTestPolicy(`{{hub .ManagedClusterName hub}}`)
+
+	r := newTestReconciler(t, &stubResolver{result: []byte(configPolicy)}, root)
+
+	var disabled []string
+
+	r.newTemplateResolver = func(opts TemplateResolverOptions) (TemplateResolver, error) {
+		disabled = opts.DisabledFunctions
+
+		return &stubResolver{result: []byte(configPolicy)}, nil
+	}
+
+	if _, err := r.templateFunctions.load(map[string]string{"disabledFunctions": "lookup"}); err != nil {
+		t.Fatalf("failed to load the configuration: %v", err)
+	}
+
+	err := r.processTemplates(context.TODO(), root.DeepCopy(), decision, root, policyv1beta1.PropagationConfigSpec{})
+	if err != nil {
+		t.Fatalf("processTemplates returned an error: %v", err)
+	}
+
+	if !reflect.DeepEqual(disabled, []string{"lookup"}) {
+		t.Fatalf("expected the configured functions to be disabled, got %v", disabled)
+	}
+}
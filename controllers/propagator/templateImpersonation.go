@@ -0,0 +1,88 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	policyv1beta1 "github.com/open-cluster-management/governance-policy-propagator/api/v1beta1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+)
+
+//+kubebuilder:rbac:groups=admissionregistration.k8s.io,resources=mutatingwebhookconfigurations,verbs=get;list;watch
+
+// The configuration of whether the lookups of the hub templates impersonate a user of the namespace
+// of the root policy instead of using the permissions of the propagator
+const templateImpersonationEnvName = "CONTROLLER_CONFIG_TEMPLATE_IMPERSONATION"
+
+// The name of the mutating webhook that records the template user of the root policies
+const templateUserWebhookName = "mutate.policy.open-cluster-management.io"
+
+// serviceAccountUser returns the user name of the ServiceAccount
+func serviceAccountUser(namespace string, name string) string {
+	return "system:serviceaccount:" + namespace + ":" + name
+}
+
+// templateUser returns the user the lookups of the hub templates of the root policy are made as, or
+// an empty string when the template impersonation is disabled. Only the user name is impersonated,
+// so the lookups don't have the permissions granted to the groups of the author of the root policy.
+func (r *PolicyReconciler) templateUser(
+	ctx context.Context, cfg policyv1beta1.PropagationConfigSpec, rootPlc *policiesv1.Policy,
+) string {
+	if !r.templateImpersonation {
+		return ""
+	}
+
+	if cfg.TemplateServiceAccount != "" {
+		return serviceAccountUser(rootPlc.GetNamespace(), cfg.TemplateServiceAccount)
+	}
+
+	if r.trustTemplateUserAnnotation && r.templateUserWebhookFailClosed(ctx) {
+		if user := rootPlc.GetAnnotations()[common.TemplateUserAnnotation]; user != "" {
+			return user
+		}
+	}
+
+	return serviceAccountUser(rootPlc.GetNamespace(), "default")
+}
+
+// templateUserWebhookFailClosed returns whether the mutating webhook recording the template user is
+// called for all the root policies and rejects them when it can't be reached. Otherwise, the root
+// policies created or updated without the webhook would keep the template-user annotation set by
+// their authors. The annotations set before the webhook was made fail-closed are trusted too.
+func (r *PolicyReconciler) templateUserWebhookFailClosed(ctx context.Context) bool {
+	webhookConfigs := &admissionregistrationv1.MutatingWebhookConfigurationList{}
+
+	err := r.List(ctx, webhookConfigs)
+	if err != nil {
+		log.Error(err, "Failed to list the MutatingWebhookConfigurations, ignoring the template-user annotations...")
+
+		return false
+	}
+
+	for _, webhookConfig := range webhookConfigs.Items {
+		for _, webhook := range webhookConfig.Webhooks {
+			if webhook.Name != templateUserWebhookName {
+				continue
+			}
+
+			// The failure policy defaults to Fail
+			failClosed := webhook.FailurePolicy == nil || *webhook.FailurePolicy == admissionregistrationv1.Fail
+
+			if failClosed && selectsAll(webhook.NamespaceSelector) && selectsAll(webhook.ObjectSelector) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// selectsAll returns whether the label selector is unset or empty, so it selects all the objects
+func selectsAll(selector *metav1.LabelSelector) bool {
+	return selector == nil || (len(selector.MatchLabels) == 0 && len(selector.MatchExpressions) == 0)
+}
@@ -0,0 +1,129 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"testing"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	policyv1beta1 "github.com/open-cluster-management/governance-policy-propagator/api/v1beta1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+// newTestWebhookConfig returns the MutatingWebhookConfiguration of the webhook recording the
+// template user with the failure policy
+func newTestWebhookConfig(
+	failurePolicy admissionregistrationv1.FailurePolicyType, namespaceSelector *metav1.LabelSelector,
+) *admissionregistrationv1.MutatingWebhookConfiguration {
+	return &admissionregistrationv1.MutatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: "propagator-webhook"},
+		Webhooks: []admissionregistrationv1.MutatingWebhook{{
+			Name:              templateUserWebhookName,
+			FailurePolicy:     &failurePolicy,
+			NamespaceSelector: namespaceSelector,
+		}},
+	}
+}
+
+func TestTemplateUser(t *testing.T) {
+	root := newTestPolicy("default")
+	root.SetAnnotations(map[string]string{common.TemplateUserAnnotation: "alice"})
+
+	failClosed := newTestWebhookConfig(admissionregistrationv1.Fail, &metav1.LabelSelector{})
+
+	tests := []struct {
+		name          string
+		impersonation bool
+		trusted       bool
+		webhookConfig *admissionregistrationv1.MutatingWebhookConfiguration
+		cfg           policyv1beta1.PropagationConfigSpec
+		expected      string
+	}{
+		{name: "disabled", expected: ""},
+		{
+			name: "service account", impersonation: true, trusted: true, webhookConfig: failClosed,
+			cfg:      policyv1beta1.PropagationConfigSpec{TemplateServiceAccount: "templates"},
+			expected: "system:serviceaccount:policies:templates",
+		},
+		{
+			name: "trusted annotation", impersonation: true, trusted: true, webhookConfig: failClosed,
+			expected: "alice",
+		},
+		{
+			name: "untrusted annotation", impersonation: true, webhookConfig: failClosed,
+			expected: "system:serviceaccount:policies:default",
+		},
+		{
+			name: "no webhook configuration", impersonation: true, trusted: true,
+			expected: "system:serviceaccount:policies:default",
+		},
+		// The annotation could be forged while the webhook is unavailable
+		{
+			name: "fail-open webhook", impersonation: true, trusted: true,
+			webhookConfig: newTestWebhookConfig(admissionregistrationv1.Ignore, nil),
+			expected:      "system:serviceaccount:policies:default",
+		},
+		// The annotation could be forged in the namespaces the webhook is not called for
+		{
+			name: "webhook with a namespace selector", impersonation: true, trusted: true,
+			webhookConfig: newTestWebhookConfig(admissionregistrationv1.Fail, &metav1.LabelSelector{
+				MatchLabels: map[string]string{"policy-webhook": "true"},
+			}),
+			expected: "system:serviceaccount:policies:default",
+		},
+	}
+
+	for _, test := range tests {
+		objects := []client.Object{}
+		if test.webhookConfig != nil {
+			objects = append(objects, test.webhookConfig)
+		}
+
+		r := newTestReconciler(t, &stubResolver{}, objects...)
+		r.templateImpersonation = test.impersonation
+		r.trustTemplateUserAnnotation = test.trusted
+
+		if user := r.templateUser(context.TODO(), test.cfg, root); user != test.expected {
+			t.Fatalf("%s: expected the template user %q, got %q", test.name, test.expected, user)
+		}
+	}
+}
+
+func TestProcessTemplatesImpersonation(t *testing.T) {
+	decision := appsv1.PlacementDecision{ClusterName: "cluster1", ClusterNamespace: "cluster1"}
+	root := newTestPolicy(`{{hub .ManagedClusterName hub}}`)
+
+	r := newTestReconciler(t, &stubResolver{}, root)
+	r.templateImpersonation = true
+
+	impersonated := ""
+
+	r.newTemplateResolver = func(opts TemplateResolverOptions) (TemplateResolver, error) {
+		impersonated = opts.Impersonate
+
+		return &stubResolver{result: []byte(configPolicy)}, nil
+	}
+
+	cfg := policyv1beta1.PropagationConfigSpec{TemplateServiceAccount: "templates"}
+
+	if err := r.processTemplates(context.TODO(), root.DeepCopy(), decision, root, cfg); err != nil {
+		t.Fatalf("processTemplates returned an error: %v", err)
+	}
+
+	if impersonated != "system:serviceaccount:policies:templates" {
+		t.Fatalf("expected the lookups to impersonate the template ServiceAccount, got %q", impersonated)
+	}
+}
+
+func TestLookupClientWithoutConfig(t *testing.T) {
+	r := newTestReconciler(t, &stubResolver{})
+
+	if _, _, err := r.lookupClient(TemplateResolverOptions{Impersonate: "alice"}); err == nil {
+		t.Fatal("expected an error without the Kubernetes configuration")
+	}
+}
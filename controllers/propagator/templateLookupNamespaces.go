@@ -0,0 +1,70 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/transport"
+)
+
+// lookupClient returns the Kubernetes configuration and client of the propagator for the lookups of
+// the hub templates. They impersonate the user of the options, and only allow the requests in the
+// lookup namespace and the additional ones when there are additional lookup namespaces.
+func (r *PolicyReconciler) lookupClient(opts TemplateResolverOptions) (*rest.Config, *kubernetes.Interface, error) {
+	if r.kubeConfig == nil {
+		return nil, nil, errors.New("the Kubernetes configuration is required to restrict the template lookups")
+	}
+
+	kubeConfig := rest.CopyConfig(r.kubeConfig)
+
+	if opts.Impersonate != "" {
+		kubeConfig.Impersonate = rest.ImpersonationConfig{UserName: opts.Impersonate}
+	}
+
+	if len(opts.AdditionalLookupNamespaces) != 0 {
+		namespaces := append([]string{opts.LookupNamespace}, opts.AdditionalLookupNamespaces...)
+
+		kubeConfig.WrapTransport = transport.Wrappers(
+			kubeConfig.WrapTransport,
+			func(next http.RoundTripper) http.RoundTripper {
+				return &namespaceRestrictingTransport{namespaces: namespaces, next: next}
+			},
+		)
+	}
+
+	clientset, err := kubernetes.NewForConfig(kubeConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	kubeClient := kubernetes.Interface(clientset)
+
+	return kubeConfig, &kubeClient, nil
+}
+
+// namespaceRestrictingTransport fails the requests for the objects outside of the namespaces. The
+// requests without a namespace could list the objects of all the namespaces, so they fail too. The
+// discovery requests are allowed.
+type namespaceRestrictingTransport struct {
+	// namespaces are the validated glob patterns of the allowed namespaces
+	namespaces []string
+	next       http.RoundTripper
+}
+
+func (t *namespaceRestrictingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if ref, ok := parseTemplateReference(req.URL.Path); ok {
+		if ref.Namespace == "" || !namespaceMatches(ref.Namespace, t.namespaces) {
+			return nil, fmt.Errorf(
+				"the lookups are restricted to the namespaces %s", strings.Join(t.namespaces, ", "),
+			)
+		}
+	}
+
+	return t.next.RoundTrip(req)
+}
@@ -0,0 +1,131 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	policyv1beta1 "github.com/open-cluster-management/governance-policy-propagator/api/v1beta1"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+func TestTemplateFunctionConfigLookupNamespaces(t *testing.T) {
+	config, err := parseTemplateFunctionConfig(map[string]string{
+		"lookupNamespaces.policies":        "shared",
+		"lookupNamespaces.policies.policy": "team-*",
+	}, nil)
+	if err != nil {
+		t.Fatalf("failed to parse the configuration: %v", err)
+	}
+
+	if namespaces := config.additionalLookupNamespaces("policies", "policy"); !reflect.DeepEqual(
+		namespaces, []string{"shared", "team-*"},
+	) {
+		t.Fatalf("expected the namespace and policy lookup namespaces, got %v", namespaces)
+	}
+
+	if namespaces := config.additionalLookupNamespaces("policies", "other"); !reflect.DeepEqual(
+		namespaces, []string{"shared"},
+	) {
+		t.Fatalf("expected the namespace lookup namespaces, got %v", namespaces)
+	}
+
+	if namespaces := config.additionalLookupNamespaces("other", "policy"); len(namespaces) != 0 {
+		t.Fatalf("expected no additional lookup namespaces, got %v", namespaces)
+	}
+
+	if _, err := parseTemplateFunctionConfig(map[string]string{"lookupNamespaces.policies": "team-["}, nil); err == nil {
+		t.Fatal("expected an error for an invalid lookup namespace pattern")
+	}
+}
+
+func TestNamespaceRestrictingTransport(t *testing.T) {
+	forwarded := 0
+
+	transport := &namespaceRestrictingTransport{
+		namespaces: []string{"policies", "team-*"},
+		next: roundTripperFunc(func(*http.Request) (*http.Response, error) {
+			forwarded++
+
+			return &http.Response{StatusCode: http.StatusOK}, nil
+		}),
+	}
+
+	tests := []struct {
+		path    string
+		allowed bool
+	}{
+		{"/api/v1/namespaces/policies/configmaps/config", true},
+		{"/apis/apps/v1/namespaces/team-a/deployments", true},
+		{"/api/v1/namespaces/other/secrets/secret", false},
+		{"/api/v1/configmaps", false},
+		{"/apis/cluster.open-cluster-management.io/v1/managedclusters/cluster1", false},
+		{"/apis/apps/v1", true},
+		{"/api", true},
+	}
+
+	for _, test := range tests {
+		forwarded = 0
+
+		req, _ := http.NewRequest(http.MethodGet, "https://hub"+test.path, nil)
+
+		_, err := transport.RoundTrip(req)
+		if test.allowed && (err != nil || forwarded != 1) {
+			t.Fatalf("expected the request %s to be allowed, got %v", test.path, err)
+		}
+
+		if !test.allowed && (err == nil || forwarded != 0) {
+			t.Fatalf("expected the request %s to be denied", test.path)
+		}
+	}
+}
+
+func TestProcessTemplatesLookupNamespaces(t *testing.T) {
+	decision := appsv1.PlacementDecision{ClusterName: "cluster1", ClusterNamespace: "cluster1"}
+	root := newTestPolicy(`{{hub .ManagedClusterName hub}}`)
+
+	resolver := &countingResolver{stubResolver: stubResolver{result: []byte(configPolicy)}}
+	r := newTestReconciler(t, &resolver.stubResolver, root)
+
+	var additional []string
+
+	r.newTemplateResolver = func(opts TemplateResolverOptions) (TemplateResolver, error) {
+		additional = opts.AdditionalLookupNamespaces
+
+		return resolver, nil
+	}
+
+	if _, err := r.templateFunctions.load(map[string]string{"lookupNamespaces.policies.policy": "shared"}); err != nil {
+		t.Fatalf("failed to load the configuration: %v", err)
+	}
+
+	process := func() {
+		t.Helper()
+
+		err := r.processTemplates(context.TODO(), root.DeepCopy(), decision, root, policyv1beta1.PropagationConfigSpec{})
+		if err != nil {
+			t.Fatalf("processTemplates returned an error: %v", err)
+		}
+	}
+
+	process()
+
+	if !reflect.DeepEqual(additional, []string{"shared"}) {
+		t.Fatalf("expected the lookups to be allowed in the shared namespace, got %v", additional)
+	}
+
+	templateSourceMapper(r.templateCache)(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "config", Namespace: "shared"},
+	})
+	process()
+
+	if resolver.calls != 2 {
+		t.Fatalf("expected a change in the shared namespace to resolve the templates again, got %d", resolver.calls)
+	}
+}
@@ -0,0 +1,230 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/runtime"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/complianceeventsapi"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+// recordLookup records the version of the object returned for the reference. The content of the
+// object is hashed so that auditors can tell which version of it the replicated policy was rendered
+// from, except for the Secrets whose values could be guessed from the hash. A nil object records
+// that the object was not found.
+func (rec *referenceRecorder) recordLookup(ref templateReference, obj map[string]interface{}) {
+	lookup := complianceeventsapi.TemplateLookup{
+		Group:     ref.Group,
+		Version:   ref.Version,
+		Resource:  ref.Resource,
+		Namespace: ref.Namespace,
+		Name:      ref.Name,
+	}
+
+	if obj != nil {
+		lookup.ResourceVersion = objectResourceVersion(obj)
+
+		if !isSecretReference(ref) {
+			lookup.Hash = objectContentHash(obj)
+		}
+	}
+
+	rec.lock.Lock()
+	rec.lookups[ref] = lookup
+	rec.lock.Unlock()
+}
+
+// listLookups returns the recorded versions of the looked up objects, sorted by reference
+func (rec *referenceRecorder) listLookups() []complianceeventsapi.TemplateLookup {
+	rec.lock.Lock()
+	defer rec.lock.Unlock()
+
+	result := make([]complianceeventsapi.TemplateLookup, 0, len(rec.lookups))
+	for _, lookup := range rec.lookups {
+		result = append(result, lookup)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+
+		for _, pair := range [][2]string{
+			{a.Group, b.Group}, {a.Version, b.Version}, {a.Resource, b.Resource}, {a.Namespace, b.Namespace},
+		} {
+			if pair[0] != pair[1] {
+				return pair[0] < pair[1]
+			}
+		}
+
+		return a.Name < b.Name
+	})
+
+	return result
+}
+
+// isSecretReference returns whether the reference is to Secrets, whose content is never hashed
+func isSecretReference(ref templateReference) bool {
+	return ref.Group == "" && ref.Resource == "secrets"
+}
+
+// toObjectMap returns the object as the map of its JSON representation, or nil if it can't be
+// converted
+func toObjectMap(obj runtime.Object) map[string]interface{} {
+	objMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil
+	}
+
+	return objMap
+}
+
+func objectResourceVersion(obj map[string]interface{}) string {
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	resourceVersion, _ := metadata["resourceVersion"].(string)
+
+	return resourceVersion
+}
+
+// objectContentHash returns the hash of the content of the object, or of the listed objects. Only
+// the identity, labels and annotations of the metadata are hashed so that the hash doesn't change
+// when only the status of the object in the API server does, such as its resource version or its
+// managed fields. The objects returned by the typed clients have no apiVersion or kind, so they are
+// not hashed either.
+func objectContentHash(obj map[string]interface{}) string {
+	content := normalizeObject(obj)
+
+	if items, ok := obj["items"].([]interface{}); ok {
+		normalized := make([]interface{}, 0, len(items))
+
+		for _, item := range items {
+			if itemMap, ok := item.(map[string]interface{}); ok {
+				normalized = append(normalized, normalizeObject(itemMap))
+			}
+		}
+
+		content = map[string]interface{}{"items": normalized}
+	}
+
+	// The keys of the maps are sorted, so the JSON is stable
+	raw, err := json.Marshal(content)
+	if err != nil {
+		return ""
+	}
+
+	sum := sha256.Sum256(raw)
+
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeObject returns the object without its apiVersion and kind, and with only the name,
+// namespace, labels, and annotations in its metadata
+func normalizeObject(obj map[string]interface{}) map[string]interface{} {
+	normalized := make(map[string]interface{}, len(obj))
+
+	for key, value := range obj {
+		switch key {
+		case "apiVersion", "kind":
+		case "metadata":
+			metadata, _ := value.(map[string]interface{})
+			kept := map[string]interface{}{}
+
+			for _, field := range []string{"name", "namespace", "labels", "annotations"} {
+				if fieldValue, ok := metadata[field]; ok {
+					kept[field] = fieldValue
+				}
+			}
+
+			normalized[key] = kept
+		default:
+			normalized[key] = value
+		}
+	}
+
+	return normalized
+}
+
+// setTemplateLookups sets the JSON encoded versions of the objects looked up by the hub templates on
+// the replicated policy, or removes the annotation when there are none
+func setTemplateLookups(replicatedPlc *policiesv1.Policy, lookups []complianceeventsapi.TemplateLookup) {
+	// The annotations may be shared with the root policy
+	annotations := map[string]string{}
+	for key, value := range replicatedPlc.GetAnnotations() {
+		annotations[key] = value
+	}
+
+	delete(annotations, common.TemplateLookupsAnnotation)
+
+	if len(lookups) != 0 {
+		raw, err := json.Marshal(lookups)
+		if err != nil {
+			log.Error(err, "Failed to encode the hub template lookups...")
+		} else {
+			annotations[common.TemplateLookupsAnnotation] = string(raw)
+		}
+	}
+
+	if len(annotations) == 0 {
+		annotations = nil
+	}
+
+	replicatedPlc.SetAnnotations(annotations)
+}
+
+// templateLookupsOf returns the versions of the objects looked up by the hub templates of the
+// replicated policy, or nil if it has none
+func templateLookupsOf(replicatedPlc *policiesv1.Policy) []complianceeventsapi.TemplateLookup {
+	raw, ok := replicatedPlc.GetAnnotations()[common.TemplateLookupsAnnotation]
+	if !ok {
+		return nil
+	}
+
+	lookups := []complianceeventsapi.TemplateLookup{}
+	if err := json.Unmarshal([]byte(raw), &lookups); err != nil {
+		return nil
+	}
+
+	return lookups
+}
+
+// recordTemplateLookups records the versions of the objects looked up by the hub templates of the
+// applied replicated policy in the compliance events database, so that they can be queried along
+// with the compliance events of the cluster. The database only records them when they changed.
+// Failures are only logged since the replicated policy is applied either way.
+func (r *PolicyReconciler) recordTemplateLookups(
+	ctx context.Context, replicatedPlc *policiesv1.Policy, decision appsv1.PlacementDecision,
+) {
+	if r.complianceDB == nil {
+		return
+	}
+
+	lookups := templateLookupsOf(replicatedPlc)
+	if lookups == nil {
+		return
+	}
+
+	// The ID is missing when the database was never available
+	parentID, err := strconv.ParseInt(replicatedPlc.GetAnnotations()[common.ParentPolicyIDAnnotation], 10, 32)
+	if err != nil {
+		return
+	}
+
+	err = r.complianceDB.RecordTemplateLookups(ctx, &complianceeventsapi.TemplateLookups{
+		Cluster:      complianceeventsapi.Cluster{Name: decision.ClusterName},
+		ParentPolicy: complianceeventsapi.ParentPolicy{KeyID: int32(parentID)},
+		Lookups:      lookups,
+		Timestamp:    r.clock.Now(),
+	})
+	if err != nil {
+		log.Error(err, "Failed to record the hub template lookups in the compliance events database...",
+			"Namespace", replicatedPlc.GetNamespace(), "Name", replicatedPlc.GetName())
+	}
+}
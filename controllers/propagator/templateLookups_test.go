@@ -0,0 +1,260 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	policyv1beta1 "github.com/open-cluster-management/governance-policy-propagator/api/v1beta1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/complianceeventsapi"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+const testConfigMapJSON = `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"config","namespace":"policies",` +
+	`"resourceVersion":"%s","labels":{"env":"prod"}},"data":{"key":"%s"}}`
+
+func TestRecordingTransportLookups(t *testing.T) {
+	recorder := newReferenceRecorder()
+	bodies := map[string]string{
+		"/api/v1/namespaces/policies/configmaps/config": testConfigMapJSON,
+		"/api/v1/namespaces/policies/secrets/secret": `{"apiVersion":"v1","kind":"Secret",` +
+			`"metadata":{"name":"secret","namespace":"policies","resourceVersion":"7"},"data":{"password":"YQ=="}}`,
+	}
+
+	var body string
+
+	rt := recorder.wrap(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		content, ok := bodies[req.URL.Path]
+		if !ok {
+			return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(&bytes.Buffer{})}, nil
+		}
+
+		if strings.HasSuffix(req.URL.Path, "/config") {
+			content = body
+		}
+
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(content))}, nil
+	}))
+
+	get := func(path string) {
+		t.Helper()
+
+		req, err := http.NewRequest(http.MethodGet, "https://hub"+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := rt.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("RoundTrip returned an error: %v", err)
+		}
+
+		read, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("failed to read the response body: %v", err)
+		}
+
+		if resp.StatusCode == http.StatusOK && len(read) == 0 {
+			t.Fatal("expected the response body to be readable by the client")
+		}
+	}
+
+	configHash := func(resourceVersion, value string) string {
+		t.Helper()
+
+		body = strings.Replace(strings.Replace(testConfigMapJSON, "%s", resourceVersion, 1), "%s", value, 1)
+		get("/api/v1/namespaces/policies/configmaps/config")
+
+		return recorder.listLookups()[0].Hash
+	}
+
+	get("/api/v1/namespaces/policies/secrets/secret")
+	get("/api/v1/namespaces/policies/secrets/missing")
+
+	hash := configHash("1", "a")
+
+	lookups := recorder.listLookups()
+	if len(lookups) != 3 {
+		t.Fatalf("expected the ConfigMap and the Secrets to be recorded, got %+v", lookups)
+	}
+
+	expected := complianceeventsapi.TemplateLookup{
+		Version: "v1", Resource: "configmaps", Namespace: "policies", Name: "config", ResourceVersion: "1", Hash: hash,
+	}
+	if lookups[0] != expected || hash == "" {
+		t.Fatalf("expected the ConfigMap lookup %+v, got %+v", expected, lookups[0])
+	}
+
+	if lookups[1].Name != "missing" || lookups[1].ResourceVersion != "" || lookups[1].Hash != "" {
+		t.Fatalf("expected the missing Secret to be recorded without a version, got %+v", lookups[1])
+	}
+
+	if lookups[2].ResourceVersion != "7" || lookups[2].Hash != "" {
+		t.Fatalf("expected the Secret to be recorded with only its resource version, got %+v", lookups[2])
+	}
+
+	if configHash("2", "a") != hash {
+		t.Fatal("expected the hash to not depend on the resource version")
+	}
+
+	if configHash("3", "b") == hash {
+		t.Fatal("expected the hash to change with the data")
+	}
+}
+
+func TestRecordingClientLookups(t *testing.T) {
+	recorder := newReferenceRecorder()
+	kubeClient := &recordingClient{
+		Interface: fake.NewSimpleClientset(
+			&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "config", Namespace: "policies", ResourceVersion: "1", Labels: map[string]string{"env": "prod"},
+				},
+				Data: map[string]string{"key": "a"},
+			},
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "secret", Namespace: "policies", ResourceVersion: "7"},
+				Data:       map[string][]byte{"password": []byte("a")},
+			},
+		),
+		recorder: recorder,
+	}
+
+	_, err := kubeClient.CoreV1().ConfigMaps("policies").Get(context.TODO(), "config", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Get returned an error: %v", err)
+	}
+
+	_, err = kubeClient.CoreV1().Secrets("policies").Get(context.TODO(), "secret", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Get returned an error: %v", err)
+	}
+
+	lookups := recorder.listLookups()
+	if len(lookups) != 2 {
+		t.Fatalf("expected the ConfigMap and the Secret to be recorded, got %+v", lookups)
+	}
+
+	// The typed ConfigMap has the same hash as the one returned by the API server
+	transportRecorder := newReferenceRecorder()
+	transportRecorder.recordLookup(
+		templateReference{configMapsGVR, "policies", "config"},
+		toObjectMap(&corev1.ConfigMap{
+			TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "config", Namespace: "policies", ResourceVersion: "2", Labels: map[string]string{"env": "prod"},
+				ManagedFields: []metav1.ManagedFieldsEntry{{Manager: "kubectl"}},
+			},
+			Data: map[string]string{"key": "a"},
+		}),
+	)
+
+	if lookups[0].Hash == "" || lookups[0].Hash != transportRecorder.listLookups()[0].Hash {
+		t.Fatalf("expected the ConfigMap hash to only depend on its content, got %+v", lookups[0])
+	}
+
+	if lookups[1].Resource != "secrets" || lookups[1].ResourceVersion != "7" || lookups[1].Hash != "" {
+		t.Fatalf("expected the Secret to be recorded with only its resource version, got %+v", lookups[1])
+	}
+}
+
+// lookupsResolver is a reference tracking resolver returning the lookups
+type lookupsResolver struct {
+	countingResolver
+	recorded []complianceeventsapi.TemplateLookup
+}
+
+func (l *lookupsResolver) references() []templateReference {
+	return nil
+}
+
+func (l *lookupsResolver) lookups() []complianceeventsapi.TemplateLookup {
+	return l.recorded
+}
+
+func TestProcessTemplatesLookupsAnnotation(t *testing.T) {
+	decision := appsv1.PlacementDecision{ClusterName: "cluster1", ClusterNamespace: "cluster1"}
+	root := newTestPolicy(`{{hub .ManagedClusterName hub}}`)
+	root.SetUID("uid")
+
+	resolver := &lookupsResolver{
+		countingResolver: countingResolver{stubResolver: stubResolver{result: []byte(configPolicy)}},
+		recorded: []complianceeventsapi.TemplateLookup{
+			{Version: "v1", Resource: "configmaps", Namespace: "policies", Name: "config", Hash: "abc"},
+		},
+	}
+
+	r := newTestReconciler(t, &resolver.stubResolver, root)
+	r.newTemplateResolver = func(TemplateResolverOptions) (TemplateResolver, error) { return resolver, nil }
+
+	// The templates are resolved the first time and cached the second time
+	for i := 0; i < 2; i++ {
+		replicated := root.DeepCopy()
+
+		err := r.processTemplates(context.TODO(), replicated, decision, root, policyv1beta1.PropagationConfigSpec{})
+		if err != nil {
+			t.Fatalf("processTemplates returned an error: %v", err)
+		}
+
+		if !reflect.DeepEqual(templateLookupsOf(replicated), resolver.recorded) {
+			t.Fatalf("expected the lookups annotation, got %v", replicated.GetAnnotations())
+		}
+	}
+
+	if resolver.calls != 1 {
+		t.Fatalf("expected the cached templates to be used, got %d resolutions", resolver.calls)
+	}
+
+	if len(root.GetAnnotations()) != 0 {
+		t.Fatal("expected the root policy to be unchanged")
+	}
+}
+
+func TestRecordTemplateLookups(t *testing.T) {
+	decision := appsv1.PlacementDecision{ClusterName: "cluster1", ClusterNamespace: "cluster1"}
+	lookups := []complianceeventsapi.TemplateLookup{{Version: "v1", Resource: "configmaps", Name: "config"}}
+
+	db := &fakeComplianceDB{}
+	r := newTestReconciler(t, &stubResolver{})
+	r.complianceDB = db
+
+	replicated := newTestPolicy("default")
+	setTemplateLookups(replicated, lookups)
+
+	// The lookups are not recorded without the ID of the root policy in the database
+	r.recordTemplateLookups(context.TODO(), replicated, decision)
+
+	if len(db.lookups) != 0 {
+		t.Fatalf("expected no lookups to be recorded, got %+v", db.lookups)
+	}
+
+	replicated.Annotations[common.ParentPolicyIDAnnotation] = "3"
+	r.recordTemplateLookups(context.TODO(), replicated, decision)
+
+	if len(db.lookups) != 1 {
+		t.Fatalf("expected the lookups to be recorded, got %+v", db.lookups)
+	}
+
+	recorded := db.lookups[0]
+	if recorded.Cluster.Name != "cluster1" || recorded.ParentPolicy.KeyID != 3 ||
+		!reflect.DeepEqual(recorded.Lookups, lookups) || recorded.Timestamp.IsZero() {
+		t.Fatalf("unexpected recorded lookups %+v", recorded)
+	}
+
+	setTemplateLookups(replicated, nil)
+
+	if _, ok := replicated.GetAnnotations()[common.TemplateLookupsAnnotation]; ok {
+		t.Fatal("expected the lookups annotation to be removed")
+	}
+}
@@ -0,0 +1,520 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/metadata/metadatainformer"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/transport"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	templates "github.com/open-cluster-management/go-template-utils/pkg/templates"
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/complianceeventsapi"
+)
+
+// templateReference is an object looked up by the hub templates of a root policy
+type templateReference struct {
+	schema.GroupVersionResource
+	Namespace string
+	// Name is empty when all the objects were listed
+	Name string
+}
+
+// matches returns whether the object is the referenced object, or one of the listed objects
+func (ref templateReference) matches(namespace, name string) bool {
+	return ref.Namespace == namespace && (ref.Name == "" || ref.Name == name)
+}
+
+// parseTemplateReference returns the object requested by the API path of a request of the
+// template resolver. The discovery requests don't reference an object.
+func parseTemplateReference(path string) (templateReference, bool) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+
+	var gv schema.GroupVersion
+
+	switch {
+	case len(parts) >= 3 && parts[0] == "api":
+		gv = schema.GroupVersion{Version: parts[1]}
+		parts = parts[2:]
+	case len(parts) >= 4 && parts[0] == "apis":
+		gv = schema.GroupVersion{Group: parts[1], Version: parts[2]}
+		parts = parts[3:]
+	default:
+		return templateReference{}, false
+	}
+
+	ref := templateReference{}
+
+	if parts[0] == "namespaces" && len(parts) >= 3 {
+		ref.Namespace = parts[1]
+		parts = parts[2:]
+	}
+
+	ref.GroupVersionResource = gv.WithResource(parts[0])
+
+	if len(parts) >= 2 {
+		ref.Name = parts[1]
+	}
+
+	return ref, true
+}
+
+// referenceRecorder records the objects requested through the transports it wraps, and the
+// versions of the objects returned, see recordLookup
+type referenceRecorder struct {
+	lock       sync.Mutex
+	references map[templateReference]bool
+	lookups    map[templateReference]complianceeventsapi.TemplateLookup
+}
+
+func newReferenceRecorder() *referenceRecorder {
+	return &referenceRecorder{
+		references: map[templateReference]bool{},
+		lookups:    map[templateReference]complianceeventsapi.TemplateLookup{},
+	}
+}
+
+func (rec *referenceRecorder) wrap(next http.RoundTripper) http.RoundTripper {
+	return &recordingTransport{recorder: rec, next: next}
+}
+
+func (rec *referenceRecorder) record(ref templateReference) {
+	rec.lock.Lock()
+	rec.references[ref] = true
+	rec.lock.Unlock()
+}
+
+// list returns the recorded objects
+func (rec *referenceRecorder) list() []templateReference {
+	rec.lock.Lock()
+	defer rec.lock.Unlock()
+
+	result := make([]templateReference, 0, len(rec.references))
+	for ref := range rec.references {
+		result = append(result, ref)
+	}
+
+	return result
+}
+
+type recordingTransport struct {
+	recorder *referenceRecorder
+	next     http.RoundTripper
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || req.URL.Query().Get("watch") != "" {
+		return t.next.RoundTrip(req)
+	}
+
+	ref, ok := parseTemplateReference(req.URL.Path)
+	if !ok {
+		return t.next.RoundTrip(req)
+	}
+
+	t.recorder.record(ref)
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		// The body is read again by the client
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+
+		if err != nil {
+			return resp, err
+		}
+
+		obj := map[string]interface{}{}
+		if json.Unmarshal(body, &obj) == nil {
+			t.recorder.recordLookup(ref, obj)
+		}
+	case http.StatusNotFound:
+		t.recorder.recordLookup(ref, nil)
+	}
+
+	return resp, nil
+}
+
+// referenceTrackingResolver is a TemplateResolver that reports the objects its lookups requested,
+// and the versions of the objects returned
+type referenceTrackingResolver interface {
+	TemplateResolver
+	references() []templateReference
+	lookups() []complianceeventsapi.TemplateLookup
+}
+
+type trackingResolver struct {
+	*templates.TemplateResolver
+	recorder *referenceRecorder
+}
+
+func (t *trackingResolver) references() []templateReference {
+	return t.recorder.list()
+}
+
+func (t *trackingResolver) lookups() []complianceeventsapi.TemplateLookup {
+	return t.recorder.listLookups()
+}
+
+// newTrackingResolver returns a template resolver whose lookups record the requested objects. The
+// Kubernetes client is shared, so the Secrets and ConfigMaps it gets are recorded by a wrapper of
+// the client, and the other lookups by the transport of a copy of the Kubernetes configuration. The
+// optional wrapClient wraps the Kubernetes client used by the lookups.
+func newTrackingResolver(
+	kubeConfig *rest.Config,
+	kubeClient *kubernetes.Interface,
+	cfg templates.Config,
+	wrapClient func(kubernetes.Interface) kubernetes.Interface,
+) (*trackingResolver, error) {
+	recorder := newReferenceRecorder()
+
+	trackingConfig := rest.CopyConfig(kubeConfig)
+	trackingConfig.WrapTransport = transport.Wrappers(trackingConfig.WrapTransport, recorder.wrap)
+
+	if kubeClient == nil {
+		clientset, err := kubernetes.NewForConfig(kubeConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		sharedClient := kubernetes.Interface(clientset)
+		kubeClient = &sharedClient
+	}
+
+	trackingClient := kubernetes.Interface(&recordingClient{Interface: *kubeClient, recorder: recorder})
+	if wrapClient != nil {
+		trackingClient = wrapClient(trackingClient)
+	}
+
+	resolver, err := templates.NewResolver(&trackingClient, trackingConfig, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &trackingResolver{TemplateResolver: resolver, recorder: recorder}, nil
+}
+
+// recordingClient is a Kubernetes client recording the Secrets and ConfigMaps it gets, which are the
+// objects the template resolver gets with its Kubernetes client
+type recordingClient struct {
+	kubernetes.Interface
+	recorder *referenceRecorder
+}
+
+func (c *recordingClient) CoreV1() corev1client.CoreV1Interface {
+	return &recordingCoreV1{CoreV1Interface: c.Interface.CoreV1(), recorder: c.recorder}
+}
+
+type recordingCoreV1 struct {
+	corev1client.CoreV1Interface
+	recorder *referenceRecorder
+}
+
+func (c *recordingCoreV1) Secrets(namespace string) corev1client.SecretInterface {
+	return &recordingSecrets{
+		SecretInterface: c.CoreV1Interface.Secrets(namespace), namespace: namespace, recorder: c.recorder,
+	}
+}
+
+func (c *recordingCoreV1) ConfigMaps(namespace string) corev1client.ConfigMapInterface {
+	return &recordingConfigMaps{
+		ConfigMapInterface: c.CoreV1Interface.ConfigMaps(namespace), namespace: namespace, recorder: c.recorder,
+	}
+}
+
+type recordingSecrets struct {
+	corev1client.SecretInterface
+	namespace string
+	recorder  *referenceRecorder
+}
+
+func (s *recordingSecrets) Get(ctx context.Context, name string, opts metav1.GetOptions) (*corev1.Secret, error) {
+	ref := templateReference{corev1.SchemeGroupVersion.WithResource("secrets"), s.namespace, name}
+	s.recorder.record(ref)
+
+	secret, err := s.SecretInterface.Get(ctx, name, opts)
+	recordTypedLookup(s.recorder, ref, secret, err)
+
+	return secret, err
+}
+
+type recordingConfigMaps struct {
+	corev1client.ConfigMapInterface
+	namespace string
+	recorder  *referenceRecorder
+}
+
+func (c *recordingConfigMaps) Get(
+	ctx context.Context, name string, opts metav1.GetOptions,
+) (*corev1.ConfigMap, error) {
+	ref := templateReference{corev1.SchemeGroupVersion.WithResource("configmaps"), c.namespace, name}
+	c.recorder.record(ref)
+
+	configMap, err := c.ConfigMapInterface.Get(ctx, name, opts)
+	recordTypedLookup(c.recorder, ref, configMap, err)
+
+	return configMap, err
+}
+
+// recordTypedLookup records the version of the object returned by a typed client for the
+// reference, or that it was not found
+func recordTypedLookup(recorder *referenceRecorder, ref templateReference, obj runtime.Object, err error) {
+	switch {
+	case err == nil:
+		if objMap := toObjectMap(obj); objMap != nil {
+			recorder.recordLookup(ref, objMap)
+		}
+	case k8serrors.IsNotFound(err):
+		recorder.recordLookup(ref, nil)
+	}
+}
+
+// templateWatcher watches the objects looked up by the hub templates of the root policies and
+// reconciles the root policies again when they change
+type templateWatcher struct {
+	client metadata.Interface
+	cache  *templateCache
+	// events are the root policies to reconcile again
+	events chan event.GenericEvent
+
+	lock sync.Mutex
+	// ctx is set when the watcher is started
+	ctx context.Context
+	// references maps the root policies in the format of <namespace>/<name> to the objects looked
+	// up by their hub templates for every cluster
+	references map[string]map[string][]templateReference
+	// informers are the running informers with the function stopping them
+	informers map[schema.GroupVersionResource]context.CancelFunc
+}
+
+func newTemplateWatcher(client metadata.Interface, cache *templateCache) *templateWatcher {
+	return &templateWatcher{
+		client:     client,
+		cache:      cache,
+		events:     make(chan event.GenericEvent),
+		references: map[string]map[string][]templateReference{},
+		informers:  map[schema.GroupVersionResource]context.CancelFunc{},
+	}
+}
+
+// Start starts the informers of the objects referenced so far and stops all the informers when the
+// context is done
+func (w *templateWatcher) Start(ctx context.Context) error {
+	w.lock.Lock()
+	w.ctx = ctx
+	w.syncInformers()
+	w.lock.Unlock()
+
+	<-ctx.Done()
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	for gvr, cancel := range w.informers {
+		cancel()
+		delete(w.informers, gvr)
+	}
+
+	return nil
+}
+
+// setReferences records the objects looked up by the hub templates of the root policy for the
+// cluster. A nil watcher ignores them.
+func (w *templateWatcher) setReferences(root string, cluster string, refs []templateReference) {
+	if w == nil {
+		return
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if len(refs) == 0 {
+		delete(w.references[root], cluster)
+
+		if len(w.references[root]) == 0 {
+			delete(w.references, root)
+		}
+	} else {
+		if w.references[root] == nil {
+			w.references[root] = map[string][]templateReference{}
+		}
+
+		w.references[root][cluster] = refs
+	}
+
+	w.syncInformers()
+}
+
+// deleteRoot forgets the objects looked up by the hub templates of the deleted root policy
+func (w *templateWatcher) deleteRoot(root string) {
+	if w == nil {
+		return
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	delete(w.references, root)
+	w.syncInformers()
+}
+
+// syncInformers starts the informers of the newly referenced resources and stops the ones no
+// longer referenced. The lock must be held.
+func (w *templateWatcher) syncInformers() {
+	if w.ctx == nil {
+		return
+	}
+
+	referenced := map[schema.GroupVersionResource]bool{}
+
+	for _, clusters := range w.references {
+		for _, refs := range clusters {
+			for _, ref := range refs {
+				referenced[ref.GroupVersionResource] = true
+			}
+		}
+	}
+
+	for gvr, cancel := range w.informers {
+		if !referenced[gvr] {
+			log.V(1).Info("Stopping the watch of the hub template objects...", "Resource", gvr.String())
+			cancel()
+			delete(w.informers, gvr)
+		}
+	}
+
+	for gvr := range referenced {
+		if _, ok := w.informers[gvr]; ok {
+			continue
+		}
+
+		log.V(1).Info("Watching the hub template objects...", "Resource", gvr.String())
+
+		ctx, cancel := context.WithCancel(w.ctx)
+		w.informers[gvr] = cancel
+
+		go w.watch(ctx, gvr)
+	}
+}
+
+// watch runs an informer on the metadata of the objects of the resource until the context is done
+func (w *templateWatcher) watch(ctx context.Context, gvr schema.GroupVersionResource) {
+	informer := metadatainformer.NewFilteredMetadataInformer(
+		w.client, gvr, metav1.NamespaceAll, 0, cache.Indexers{}, nil,
+	).Informer()
+
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			// The objects listed when the informer starts didn't change
+			if informer.HasSynced() {
+				w.objectChanged(gvr, obj)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldMeta, oldOk := oldObj.(metav1.Object)
+			newMeta, newOk := newObj.(metav1.Object)
+
+			if oldOk && newOk && oldMeta.GetResourceVersion() == newMeta.GetResourceVersion() {
+				return
+			}
+
+			w.objectChanged(gvr, newObj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+
+			w.objectChanged(gvr, obj)
+		},
+	})
+
+	informer.Run(ctx.Done())
+}
+
+// objectChanged drops the cached hub templates of the root policies that looked up the object and
+// reconciles them again
+func (w *templateWatcher) objectChanged(gvr schema.GroupVersionResource, obj interface{}) {
+	object, ok := obj.(metav1.Object)
+	if !ok {
+		return
+	}
+
+	roots := w.referencingRoots(gvr, object.GetNamespace(), object.GetName())
+
+	for _, root := range roots {
+		log.Info("A hub template object of the policy changed, reconciling the policy again...",
+			"Policy", root, "Resource", gvr.String(), "Namespace", object.GetNamespace(),
+			"Name", object.GetName())
+
+		w.cache.deleteRoot(root)
+
+		namespace, name := splitRootKey(root)
+		plc := &policiesv1.Policy{}
+		plc.SetNamespace(namespace)
+		plc.SetName(name)
+
+		w.events <- event.GenericEvent{Object: plc}
+	}
+}
+
+// referencingRoots returns the root policies in the format of <namespace>/<name> whose hub templates
+// looked up the object
+func (w *templateWatcher) referencingRoots(gvr schema.GroupVersionResource, namespace, name string) []string {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	roots := []string{}
+
+	for root, clusters := range w.references {
+	clusterLoop:
+		for _, refs := range clusters {
+			for _, ref := range refs {
+				if ref.GroupVersionResource == gvr && ref.matches(namespace, name) {
+					roots = append(roots, root)
+
+					break clusterLoop
+				}
+			}
+		}
+	}
+
+	sort.Strings(roots)
+
+	return roots
+}
+
+// splitRootKey returns the namespace and name of the root policy in the format of <namespace>/<name>
+func splitRootKey(root string) (namespace, name string) {
+	if parts := strings.SplitN(root, "/", 2); len(parts) == 2 {
+		return parts[0], parts[1]
+	}
+
+	return "", root
+}
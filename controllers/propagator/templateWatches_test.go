@@ -0,0 +1,153 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var configMapsGVR = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+
+func TestParseTemplateReference(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected templateReference
+		ok       bool
+	}{
+		{"/api/v1/namespaces/policies/configmaps/config", templateReference{configMapsGVR, "policies", "config"}, true},
+		{"/api/v1/namespaces/policies/configmaps", templateReference{configMapsGVR, "policies", ""}, true},
+		{
+			"/apis/cluster.open-cluster-management.io/v1alpha1/clusterclaims/id",
+			templateReference{
+				schema.GroupVersionResource{
+					Group: "cluster.open-cluster-management.io", Version: "v1alpha1", Resource: "clusterclaims",
+				},
+				"", "id",
+			},
+			true,
+		},
+		{"/api/v1/namespaces/policies", templateReference{
+			schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}, "", "policies",
+		}, true},
+		{"/api", templateReference{}, false},
+		{"/api/v1", templateReference{}, false},
+		{"/apis/apps/v1", templateReference{}, false},
+	}
+
+	for _, test := range tests {
+		ref, ok := parseTemplateReference(test.path)
+		if ok != test.ok || ref != test.expected {
+			t.Fatalf("expected %s to be parsed to %v (%t), got %v (%t)", test.path, test.expected, test.ok, ref, ok)
+		}
+	}
+}
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestRecordingTransport(t *testing.T) {
+	recorder := newReferenceRecorder()
+	rt := recorder.wrap(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusNotFound}, nil
+	}))
+
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		req, err := http.NewRequest(method, "https://hub/api/v1/namespaces/policies/configmaps/"+method, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := rt.RoundTrip(req); err != nil {
+			t.Fatalf("RoundTrip returned an error: %v", err)
+		}
+	}
+
+	refs := recorder.list()
+	if len(refs) != 1 || refs[0] != (templateReference{configMapsGVR, "policies", http.MethodGet}) {
+		t.Fatalf("expected only the GET request to be recorded, got %v", refs)
+	}
+}
+
+func TestRecordingClient(t *testing.T) {
+	recorder := newReferenceRecorder()
+	kubeClient := &recordingClient{
+		Interface: fake.NewSimpleClientset(&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "config", Namespace: "policies"},
+		}),
+		recorder: recorder,
+	}
+
+	_, err := kubeClient.CoreV1().ConfigMaps("policies").Get(context.TODO(), "config", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Get returned an error: %v", err)
+	}
+
+	// The objects are recorded even when they don't exist yet
+	_, err = kubeClient.CoreV1().Secrets("policies").Get(context.TODO(), "secret", metav1.GetOptions{})
+	if err == nil {
+		t.Fatal("expected the Secret not to be found")
+	}
+
+	refs := map[templateReference]bool{}
+	for _, ref := range recorder.list() {
+		refs[ref] = true
+	}
+
+	secretsGVR := schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+
+	if len(refs) != 2 || !refs[templateReference{configMapsGVR, "policies", "config"}] ||
+		!refs[templateReference{secretsGVR, "policies", "secret"}] {
+		t.Fatalf("expected the ConfigMap and the Secret to be recorded, got %v", refs)
+	}
+}
+
+func TestTemplateWatcherObjectChanged(t *testing.T) {
+	cache := newTemplateCache()
+	cache.set(templateCacheKey{root: "policies/policy1", cluster: "cluster1"}, templateCacheEntry{})
+
+	w := newTemplateWatcher(nil, cache)
+	w.setReferences("policies/policy1", "cluster1", []templateReference{{configMapsGVR, "policies", "config"}})
+	w.setReferences("policies/policy2", "cluster1", []templateReference{{configMapsGVR, "policies", ""}})
+	w.setReferences("policies/policy3", "cluster1", []templateReference{{configMapsGVR, "other", "config"}})
+
+	requeued := make(chan []string)
+
+	go func() {
+		roots := []string{}
+		for i := 0; i < 2; i++ {
+			e := <-w.events
+			roots = append(roots, e.Object.GetNamespace()+"/"+e.Object.GetName())
+		}
+		requeued <- roots
+	}()
+
+	w.objectChanged(configMapsGVR, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "config", Namespace: "policies"},
+	})
+
+	roots := <-requeued
+	if len(roots) != 2 || roots[0] != "policies/policy1" || roots[1] != "policies/policy2" {
+		t.Fatalf("expected the policies referencing the ConfigMap to be reconciled, got %v", roots)
+	}
+
+	if len(cache.entries) != 0 {
+		t.Fatal("expected the cached templates of the reconciled policies to be dropped")
+	}
+
+	w.deleteRoot("policies/policy1")
+	w.setReferences("policies/policy2", "cluster1", nil)
+
+	if roots := w.referencingRoots(configMapsGVR, "policies", "config"); len(roots) != 0 {
+		t.Fatalf("expected no policy to reference the ConfigMap, got %v", roots)
+	}
+}
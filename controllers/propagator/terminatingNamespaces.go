@@ -0,0 +1,95 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+)
+
+// propagatorFinalizerPrefix is the prefix of the finalizers owned by the propagator
+const propagatorFinalizerPrefix = "propagator." + common.APIGroup + "/"
+
+// namespaceTerminating returns whether the cluster namespace is being deleted, such as when its
+// ManagedCluster is detached. The replicated policies in it are deleted along with it, so they are
+// not deleted again and again while the other finalizers of the namespace are pending.
+func (r *PolicyReconciler) namespaceTerminating(ctx context.Context, name string) bool {
+	namespace := &corev1.Namespace{}
+
+	if err := r.Get(ctx, types.NamespacedName{Name: name}, namespace); err != nil {
+		return false
+	}
+
+	return namespace.GetDeletionTimestamp() != nil || namespace.Status.Phase == corev1.NamespaceTerminating
+}
+
+// isNamespaceTerminatingError returns whether the request failed because its namespace is being
+// deleted, which retrying doesn't change
+func isNamespaceTerminatingError(err error) bool {
+	return k8serrors.HasStatusCause(err, corev1.NamespaceTerminatingCause)
+}
+
+// pruneTerminatingReplicatedPolicy deletes the replicated policy in a terminating cluster namespace
+// once, without retries, and removes the finalizers of the propagator from it so that they don't
+// block the deletion of the namespace. It returns whether the replicated policy was deleted by this
+// call, and not already being deleted. The failures are only logged since the deletion of the
+// namespace deletes the replicated policy either way.
+func (r *PolicyReconciler) pruneTerminatingReplicatedPolicy(ctx context.Context, key types.NamespacedName) bool {
+	replicatedPlc := &policiesv1.Policy{}
+
+	err := r.Get(ctx, key, replicatedPlc)
+	if err != nil {
+		if !k8serrors.IsNotFound(err) {
+			log.Error(err, "Failed to get the replicated policy in the terminating namespace...",
+				"Namespace", key.Namespace, "Name", key.Name)
+		}
+
+		return false
+	}
+
+	finalizers := []string{}
+
+	for _, finalizer := range replicatedPlc.GetFinalizers() {
+		if !strings.HasPrefix(finalizer, propagatorFinalizerPrefix) {
+			finalizers = append(finalizers, finalizer)
+		}
+	}
+
+	if len(finalizers) != len(replicatedPlc.GetFinalizers()) {
+		original := replicatedPlc.DeepCopy()
+		replicatedPlc.SetFinalizers(finalizers)
+
+		err := r.Patch(ctx, replicatedPlc, client.MergeFrom(original))
+		if err != nil && !k8serrors.IsNotFound(err) {
+			log.Error(err, "Failed to remove the propagator finalizers from the replicated policy...",
+				"Namespace", key.Namespace, "Name", key.Name)
+		}
+	}
+
+	if replicatedPlc.GetDeletionTimestamp() != nil {
+		return false
+	}
+
+	log.Info("Deleting the replicated policy in the terminating namespace...",
+		"Namespace", key.Namespace, "Name", key.Name)
+
+	err = r.Delete(ctx, replicatedPlc)
+	if err != nil {
+		if !k8serrors.IsNotFound(err) {
+			log.Error(err, "Failed to delete the replicated policy in the terminating namespace...",
+				"Namespace", key.Namespace, "Name", key.Name)
+		}
+
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,81 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+)
+
+func TestCleanUpOrphansTerminatingNamespace(t *testing.T) {
+	root := newTestPolicy("default")
+	root.Status.Status = []*policiesv1.CompliancePerClusterStatus{
+		{ClusterName: "cluster1", ClusterNamespace: "cluster1"},
+	}
+
+	replicated := newTestReplicatedPolicy(root, "cluster1", policiesv1.Compliant)
+	replicated.SetFinalizers([]string{propagatorFinalizerPrefix + "cleanup", "example.com/cleanup"})
+
+	namespace := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster1"},
+		Status:     corev1.NamespaceStatus{Phase: corev1.NamespaceTerminating},
+	}
+
+	r := newTestReconciler(t, &stubResolver{}, root, replicated, namespace)
+	r.clusterNamespaceEvents = true
+
+	// The orphan is deleted once, and is not reported as a failure while it is being deleted
+	for i := 0; i < 2; i++ {
+		if err := r.cleanUpOrphanedRplPolicies(context.TODO(), root, map[string]bool{}); err != nil {
+			t.Fatalf("cleanUpOrphanedRplPolicies returned an error: %v", err)
+		}
+	}
+
+	pruned := &policiesv1.Policy{}
+
+	err := r.Get(context.TODO(), types.NamespacedName{Namespace: "cluster1", Name: "policies.policy"}, pruned)
+	if err != nil {
+		t.Fatalf("expected the replicated policy to wait for the other finalizers, got the error: %v", err)
+	}
+
+	if pruned.GetDeletionTimestamp() == nil {
+		t.Fatal("expected the replicated policy to be deleted")
+	}
+
+	if !reflect.DeepEqual(pruned.GetFinalizers(), []string{"example.com/cleanup"}) {
+		t.Fatalf("expected only the propagator finalizers to be removed, got %v", pruned.GetFinalizers())
+	}
+
+	expected := []string{
+		"Normal PolicyPropagation The replicated policy was deleted by the propagation of the root " +
+			"policy policies/policy",
+	}
+	if actual := events(r); !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expected the events %v, got %v", expected, actual)
+	}
+}
+
+func TestNamespaceTerminating(t *testing.T) {
+	r := newTestReconciler(t, &stubResolver{},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "active"}},
+		&corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{Name: "terminating"},
+			Status:     corev1.NamespaceStatus{Phase: corev1.NamespaceTerminating},
+		},
+	)
+
+	tests := map[string]bool{"active": false, "terminating": true, "missing": false}
+
+	for name, expected := range tests {
+		if terminating := r.namespaceTerminating(context.TODO(), name); terminating != expected {
+			t.Fatalf("expected the namespace %s to be terminating %t, got %t", name, expected, terminating)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"runtime/debug"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/version"
+)
+
+// The annotations on the replicated policies with the versions of the propagator and the template
+// resolver that produced them. Since they are part of the comparison with the existing replicated
+// policies, upgrading the propagator updates all the replicated policies.
+const (
+	propagatorVersionAnnotation       = "policy.open-cluster-management.io/propagator-version"
+	templateResolverVersionAnnotation = "policy.open-cluster-management.io/template-resolver-version"
+)
+
+const templateResolverModule = "github.com/open-cluster-management/go-template-utils"
+
+var templateResolverVersion = moduleVersion(templateResolverModule)
+
+// moduleVersion returns the version of the module dependency compiled into the binary, or
+// "unknown" if the binary has no module information
+func moduleVersion(path string) string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+
+	for _, dep := range info.Deps {
+		if dep.Path != path {
+			continue
+		}
+
+		if dep.Replace != nil {
+			return dep.Replace.Version
+		}
+
+		return dep.Version
+	}
+
+	return "unknown"
+}
+
+// stampVersions sets the version annotations on the replicated policy. The template resolver
+// version is only set when the root policy has hub templates. The annotations are copied since
+// they may be shared with the root policy.
+func (r *PolicyReconciler) stampVersions(replicatedPlc *policiesv1.Policy, rootPlc *policiesv1.Policy) {
+	annotations := map[string]string{}
+	for key, value := range replicatedPlc.GetAnnotations() {
+		annotations[key] = value
+	}
+
+	annotations[propagatorVersionAnnotation] = version.Version
+
+	if r.policyHasTemplates(rootPlc) {
+		annotations[templateResolverVersionAnnotation] = templateResolverVersion
+	} else {
+		delete(annotations, templateResolverVersionAnnotation)
+	}
+
+	replicatedPlc.SetAnnotations(annotations)
+}
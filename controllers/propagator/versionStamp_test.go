@@ -0,0 +1,69 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagator
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	policyv1beta1 "github.com/open-cluster-management/governance-policy-propagator/api/v1beta1"
+	"github.com/open-cluster-management/governance-policy-propagator/version"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+func TestStampVersions(t *testing.T) {
+	decision := appsv1.PlacementDecision{ClusterName: "cluster1", ClusterNamespace: "cluster1"}
+
+	root := newTestPolicy(`{{hub .ManagedClusterName hub}}`)
+	root.SetAnnotations(map[string]string{"owner": "payments"})
+
+	r := newTestReconciler(t, &stubResolver{result: root.Spec.PolicyTemplates[0].ObjectDefinition.Raw}, root)
+
+	if _, err := r.handleDecision(context.TODO(), root, decision, policyv1beta1.PropagationConfigSpec{}); err != nil {
+		t.Fatalf("handleDecision returned an error: %v", err)
+	}
+
+	replicatedPlc := &policiesv1.Policy{}
+
+	err := r.Get(context.TODO(), types.NamespacedName{Namespace: "cluster1", Name: "policies.policy"}, replicatedPlc)
+	if err != nil {
+		t.Fatalf("failed to get the replicated policy: %v", err)
+	}
+
+	annotations := replicatedPlc.GetAnnotations()
+	if annotations[propagatorVersionAnnotation] != version.Version || annotations["owner"] != "payments" {
+		t.Fatalf("expected the propagator version and the root policy annotations, got %v", annotations)
+	}
+
+	if annotations[templateResolverVersionAnnotation] == "" {
+		t.Fatalf("expected the template resolver version for a policy with hub templates, got %v", annotations)
+	}
+
+	if _, ok := root.GetAnnotations()[propagatorVersionAnnotation]; ok {
+		t.Fatal("expected the root policy annotations to be left as is")
+	}
+
+	// Simulate a replicated policy produced by a previous version of the propagator
+	annotations[propagatorVersionAnnotation] = "0.0.0"
+	replicatedPlc.SetAnnotations(annotations)
+
+	if err := r.Update(context.TODO(), replicatedPlc); err != nil {
+		t.Fatalf("failed to update the replicated policy: %v", err)
+	}
+
+	if _, err := r.handleDecision(context.TODO(), root, decision, policyv1beta1.PropagationConfigSpec{}); err != nil {
+		t.Fatalf("handleDecision returned an error: %v", err)
+	}
+
+	err = r.Get(context.TODO(), types.NamespacedName{Namespace: "cluster1", Name: "policies.policy"}, replicatedPlc)
+	if err != nil {
+		t.Fatalf("failed to get the replicated policy: %v", err)
+	}
+
+	if replicatedPlc.GetAnnotations()[propagatorVersionAnnotation] != version.Version {
+		t.Fatalf("expected the outdated replicated policy to be resynced, got %v", replicatedPlc.GetAnnotations())
+	}
+}
@@ -0,0 +1,258 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package uninstall
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+)
+
+const ControllerName string = "policy-uninstall"
+
+var log = logf.Log.WithName(ControllerName)
+
+// PolicyCRDName is the name of the Policy CustomResourceDefinition
+const PolicyCRDName = "policies." + common.APIGroup
+
+// UninstallingAnnotation on the propagator Deployment starts the uninstall before the Deployment
+// is deleted, such as by the operator installing it
+const UninstallingAnnotation = "policy.open-cluster-management.io/uninstalling"
+
+// cleanUpInterval is how often the policies are cleaned up again during the uninstall, since new
+// root policies may still be created
+const cleanUpInterval = 10 * time.Second
+
+// uninstalling is 1 when the propagator is being uninstalled
+var uninstalling int32
+
+// Uninstalling returns whether the propagator is being uninstalled. The controllers must not
+// create or update the replicated policies nor add finalizers when it is.
+func Uninstalling() bool {
+	return atomic.LoadInt32(&uninstalling) == 1
+}
+
+func setUninstalling(value bool) {
+	if value {
+		atomic.StoreInt32(&uninstalling, 1)
+	} else {
+		atomic.StoreInt32(&uninstalling, 0)
+	}
+}
+
+// CacheSelectors restricts the cache of the Deployments and the CustomResourceDefinitions to the
+// propagator Deployment and the Policy CRD, so that the others are not cached
+func CacheSelectors(deploymentName string) cache.SelectorsByObject {
+	return cache.SelectorsByObject{
+		&appsv1.Deployment{}: {
+			Field: fields.OneTermEqualSelector("metadata.name", deploymentName),
+		},
+		&apiextensionsv1.CustomResourceDefinition{}: {
+			Field: fields.OneTermEqualSelector("metadata.name", PolicyCRDName),
+		},
+	}
+}
+
+//+kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch
+//+kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get;list;watch
+//+kubebuilder:rbac:groups=policy.open-cluster-management.io,resources=policies,verbs=get;list;watch;update;patch;delete
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *UninstallReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	deploymentRequest := reconcile.Request{NamespacedName: types.NamespacedName{
+		Namespace: r.DeploymentNamespace, Name: r.DeploymentName,
+	}}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(ControllerName).
+		For(&appsv1.Deployment{}, builder.WithPredicates(predicate.NewPredicateFuncs(func(o client.Object) bool {
+			return o.GetNamespace() == r.DeploymentNamespace && o.GetName() == r.DeploymentName
+		}))).
+		// The CRD deletion is handled by the same request as the Deployment
+		Watches(
+			&source.Kind{Type: &apiextensionsv1.CustomResourceDefinition{}},
+			handler.EnqueueRequestsFromMapFunc(func(client.Object) []reconcile.Request {
+				return []reconcile.Request{deploymentRequest}
+			}),
+			builder.WithPredicates(predicate.NewPredicateFuncs(func(o client.Object) bool {
+				return o.GetName() == PolicyCRDName
+			}))).
+		Complete(r)
+}
+
+// blank assignment to verify that UninstallReconciler implements reconcile.Reconciler
+var _ reconcile.Reconciler = &UninstallReconciler{}
+
+// UninstallReconciler deletes the replicated policies in all the cluster namespaces and removes
+// the propagator finalizers from the policies when the Policy CRD or the propagator Deployment is
+// being deleted, so that the namespaces are not stuck terminating once the propagator is gone
+type UninstallReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	// DeploymentNamespace and DeploymentName are of the propagator Deployment. When the namespace
+	// is not set, only the deletion of the Policy CRD starts the uninstall.
+	DeploymentNamespace string
+	DeploymentName      string
+	// Namespaces restricts the clean up to the root policies of these namespaces and their
+	// replicated policies, when the propagator is restricted to them
+	Namespaces []string
+}
+
+// Reconcile cleans up the policies while the propagator is being uninstalled
+func (r *UninstallReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	isUninstalling, err := r.isUninstalling(ctx)
+	if err != nil {
+		log.Error(err, "Failed to determine if the propagator is being uninstalled...")
+
+		return reconcile.Result{}, err
+	}
+
+	setUninstalling(isUninstalling)
+
+	if !isUninstalling {
+		return reconcile.Result{}, nil
+	}
+
+	log.Info("The propagator is being uninstalled, cleaning up the policies...")
+
+	err = r.cleanUpPolicies(ctx)
+	if err != nil {
+		log.Error(err, "Failed to clean up the policies, retrying...")
+
+		return reconcile.Result{}, err
+	}
+
+	return reconcile.Result{RequeueAfter: cleanUpInterval}, nil
+}
+
+// isUninstalling returns whether the Policy CRD is being deleted, or the propagator Deployment is
+// being deleted or has the uninstalling annotation set to true
+func (r *UninstallReconciler) isUninstalling(ctx context.Context) (bool, error) {
+	crd := &apiextensionsv1.CustomResourceDefinition{}
+
+	err := r.Get(ctx, types.NamespacedName{Name: PolicyCRDName}, crd)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return false, err
+	}
+
+	if err == nil && crd.GetDeletionTimestamp() != nil {
+		return true, nil
+	}
+
+	if r.DeploymentNamespace == "" {
+		return false, nil
+	}
+
+	deployment := &appsv1.Deployment{}
+
+	err = r.Get(ctx, types.NamespacedName{Namespace: r.DeploymentNamespace, Name: r.DeploymentName}, deployment)
+	if err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+
+	annotated, _ := strconv.ParseBool(deployment.GetAnnotations()[UninstallingAnnotation])
+
+	return deployment.GetDeletionTimestamp() != nil || annotated, nil
+}
+
+// cleanUpPolicies deletes the replicated policies and removes the propagator finalizer from the
+// root policies. It goes through all the policies before returning an error.
+func (r *UninstallReconciler) cleanUpPolicies(ctx context.Context) error {
+	plcList := &policiesv1.PolicyList{}
+
+	err := r.List(ctx, plcList)
+	if err != nil {
+		return err
+	}
+
+	successful := true
+
+	for i := range plcList.Items {
+		plc := &plcList.Items[i]
+
+		if !r.cleansUp(plc) {
+			continue
+		}
+
+		if controllerutil.ContainsFinalizer(plc, common.RootPolicyFinalizer) {
+			original := plc.DeepCopy()
+			controllerutil.RemoveFinalizer(plc, common.RootPolicyFinalizer)
+
+			err := r.Patch(ctx, plc, client.MergeFrom(original))
+			if err != nil && !k8serrors.IsNotFound(err) {
+				log.Error(err, "Failed to remove the finalizer from the policy...",
+					"Namespace", plc.GetNamespace(), "Name", plc.GetName())
+
+				successful = false
+
+				continue
+			}
+		}
+
+		if _, replicated := plc.GetLabels()[common.RootPolicyLabel]; !replicated {
+			continue
+		}
+
+		log.V(1).Info("Deleting the replicated policy...", "Namespace", plc.GetNamespace(), "Name", plc.GetName())
+
+		err := r.Delete(ctx, plc)
+		if err != nil && !k8serrors.IsNotFound(err) {
+			log.Error(err, "Failed to delete the replicated policy...",
+				"Namespace", plc.GetNamespace(), "Name", plc.GetName())
+
+			successful = false
+		}
+	}
+
+	if !successful {
+		return errors.New("one or more policies failed to be cleaned up")
+	}
+
+	return nil
+}
+
+// cleansUp returns whether the root policy, or the root policy of the replicated policy, is in the
+// namespaces the propagator is restricted to
+func (r *UninstallReconciler) cleansUp(plc *policiesv1.Policy) bool {
+	if len(r.Namespaces) == 0 {
+		return true
+	}
+
+	namespace := plc.GetNamespace()
+
+	// The root policy label is in the format of <namespace>.<name>
+	if rootName, replicated := plc.GetLabels()[common.RootPolicyLabel]; replicated {
+		namespace = strings.SplitN(rootName, ".", 2)[0]
+	}
+
+	for _, ns := range r.Namespaces {
+		if ns == namespace {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,162 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package uninstall
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+)
+
+func TestReconcile(t *testing.T) {
+	scheme := runtime.NewScheme()
+	for _, addToScheme := range []func(*runtime.Scheme) error{
+		policiesv1.AddToScheme, appsv1.AddToScheme, apiextensionsv1.AddToScheme,
+	} {
+		if err := addToScheme(scheme); err != nil {
+			t.Fatalf("failed to build the scheme: %v", err)
+		}
+	}
+
+	deletionTimestamp := metav1.Now()
+
+	tests := []struct {
+		name         string
+		deployment   *appsv1.Deployment
+		crd          *apiextensionsv1.CustomResourceDefinition
+		uninstalling bool
+	}{
+		{
+			name: "not uninstalling",
+			deployment: &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "propagator", Namespace: "ocm"},
+			},
+			crd: &apiextensionsv1.CustomResourceDefinition{ObjectMeta: metav1.ObjectMeta{Name: PolicyCRDName}},
+		},
+		{
+			name: "uninstalling annotation",
+			deployment: &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "propagator", Namespace: "ocm", Annotations: map[string]string{UninstallingAnnotation: "true"},
+				},
+			},
+			crd:          &apiextensionsv1.CustomResourceDefinition{ObjectMeta: metav1.ObjectMeta{Name: PolicyCRDName}},
+			uninstalling: true,
+		},
+		{
+			name: "CRD deleted",
+			crd: &apiextensionsv1.CustomResourceDefinition{ObjectMeta: metav1.ObjectMeta{
+				Name:              PolicyCRDName,
+				DeletionTimestamp: &deletionTimestamp,
+				Finalizers:        []string{"customresourcecleanup.apiextensions.k8s.io"},
+			}},
+			uninstalling: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			root := &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{
+				Name: "policy", Namespace: "policies", Finalizers: []string{common.RootPolicyFinalizer},
+			}}
+			replicated := &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{
+				Name:      "policies.policy",
+				Namespace: "cluster1",
+				Labels:    map[string]string{common.RootPolicyLabel: "policies.policy"},
+			}}
+
+			objects := []client.Object{root, replicated, test.crd}
+			if test.deployment != nil {
+				objects = append(objects, test.deployment)
+			}
+
+			r := &UninstallReconciler{
+				Client:              fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(),
+				Scheme:              scheme,
+				DeploymentNamespace: "ocm",
+				DeploymentName:      "propagator",
+			}
+
+			result, err := r.Reconcile(context.TODO(), ctrl.Request{
+				NamespacedName: types.NamespacedName{Namespace: "ocm", Name: "propagator"},
+			})
+			if err != nil {
+				t.Fatalf("Reconcile returned an error: %v", err)
+			}
+
+			if Uninstalling() != test.uninstalling {
+				t.Fatalf("expected Uninstalling to be %v", test.uninstalling)
+			}
+
+			if test.uninstalling && result.RequeueAfter != cleanUpInterval {
+				t.Fatalf("expected the clean up to be requeued after %v, got %v", cleanUpInterval, result.RequeueAfter)
+			}
+
+			updatedRoot := &policiesv1.Policy{}
+			if err := r.Get(context.TODO(), client.ObjectKeyFromObject(root), updatedRoot); err != nil {
+				t.Fatalf("failed to get the root policy: %v", err)
+			}
+
+			if len(updatedRoot.GetFinalizers()) == 0 != test.uninstalling {
+				t.Fatalf("expected the finalizer removal to be %v, got the finalizers %v",
+					test.uninstalling, updatedRoot.GetFinalizers())
+			}
+
+			err = r.Get(context.TODO(), client.ObjectKeyFromObject(replicated), &policiesv1.Policy{})
+			if k8serrors.IsNotFound(err) != test.uninstalling {
+				t.Fatalf("expected the replicated policy deletion to be %v, got the error: %v", test.uninstalling, err)
+			}
+		})
+	}
+
+	setUninstalling(false)
+}
+
+func TestCleansUp(t *testing.T) {
+	r := &UninstallReconciler{Namespaces: []string{"tenant1"}}
+
+	tests := []struct {
+		policy   *policiesv1.Policy
+		expected bool
+	}{
+		{policy: &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{Name: "policy", Namespace: "tenant1"}}, expected: true},
+		{policy: &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{Name: "policy", Namespace: "tenant2"}}},
+		{
+			policy: &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{
+				Name: "tenant1.policy", Namespace: "cluster1",
+				Labels: map[string]string{common.RootPolicyLabel: "tenant1.policy"},
+			}},
+			expected: true,
+		},
+		{
+			policy: &policiesv1.Policy{ObjectMeta: metav1.ObjectMeta{
+				Name: "tenant2.policy", Namespace: "cluster1",
+				Labels: map[string]string{common.RootPolicyLabel: "tenant2.policy"},
+			}},
+		},
+	}
+
+	for _, test := range tests {
+		if actual := r.cleansUp(test.policy); actual != test.expected {
+			t.Fatalf("expected the clean up of %s/%s to be %v", test.policy.Namespace, test.policy.Name, test.expected)
+		}
+	}
+
+	if !(&UninstallReconciler{}).cleansUp(tests[1].policy) {
+		t.Fatal("expected every policy to be cleaned up without namespaces")
+	}
+}
@@ -7,16 +7,23 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"hash/fnv"
 	"os"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensionsclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
@@ -27,14 +34,24 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	clusterv1 "github.com/open-cluster-management/api/cluster/v1"
 	clusterv1alpha1 "github.com/open-cluster-management/api/cluster/v1alpha1"
 	policyv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
 	policyv1beta1 "github.com/open-cluster-management/governance-policy-propagator/api/v1beta1"
 	automationctrl "github.com/open-cluster-management/governance-policy-propagator/controllers/automation"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/complianceeventsapi"
+	reportctrl "github.com/open-cluster-management/governance-policy-propagator/controllers/compliancereport"
+	encryptionkeysctrl "github.com/open-cluster-management/governance-policy-propagator/controllers/encryptionkeys"
+	placementapisctrl "github.com/open-cluster-management/governance-policy-propagator/controllers/placementapis"
+	pbstatusctrl "github.com/open-cluster-management/governance-policy-propagator/controllers/placementbinding"
+	migrationctrl "github.com/open-cluster-management/governance-policy-propagator/controllers/placementmigration"
 	metricsctrl "github.com/open-cluster-management/governance-policy-propagator/controllers/policymetrics"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/policywebhook"
 	propagatorctrl "github.com/open-cluster-management/governance-policy-propagator/controllers/propagator"
+	uninstallctrl "github.com/open-cluster-management/governance-policy-propagator/controllers/uninstall"
 	"github.com/open-cluster-management/governance-policy-propagator/version"
 	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
 	//+kubebuilder:scaffold:imports
@@ -53,6 +70,7 @@ func printVersion() {
 
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(apiextensionsv1.AddToScheme(scheme))
 
 	utilruntime.Must(clusterv1.AddToScheme(scheme))
 	utilruntime.Must(clusterv1alpha1.AddToScheme(scheme))
@@ -67,11 +85,109 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var featureGates string
+	var complianceReportInterval time.Duration
+	var migrationClusterSets string
+	var policyMetricLabels string
+	var encryptionKeyRotation time.Duration
+	var enableMutatingWebhook bool
+	var enableValidatingWebhook bool
+	var complianceEventsAPIAddr string
+	var complianceEventsAPICert string
+	var complianceEventsAPIKey string
+	var complianceEventsMaxAge time.Duration
+	var complianceEventsMaxPerPolicy int
+	var complianceEventsPruneInterval time.Duration
+	var statusBatchWindow time.Duration
+	var maxConcurrentReconciles int
+	var clientQPS float64
+	var clientBurst int
+	var disabledTemplateFunctions string
+	var templateFunctionsConfigMap string
+	var enableDetailedRootStatus bool
+	var watchNamespaces string
+	var templateEligibleKinds string
+	var copyLabels string
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8383", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", true,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.StringVar(&featureGates, "feature-gates", "",
+		"A comma separated list of <feature>=<bool> pairs to toggle experimental features. "+
+			"The available features and their defaults are: "+strings.Join(common.KnownFeatures(), ", "))
+	flag.DurationVar(&complianceReportInterval, "compliance-report-interval", 10*time.Minute,
+		"How often the PolicyComplianceReport of each namespace is rewritten. Set to 0 to disable the reports.")
+	flag.StringVar(&migrationClusterSets, "placement-migration-cluster-sets", "",
+		"A comma separated list of the ManagedClusterSets that Placements generated by the "+
+			"PlacementRuleMigration feature select from.")
+	flag.StringVar(&policyMetricLabels, "policy-metric-labels", strings.Join(metricsctrl.DetailLabels, ","),
+		"A comma separated list of the labels to set on the policy_governance_info metric. The policies "+
+			"that only differ by the other labels are aggregated to bound the metric cardinality.")
+	flag.DurationVar(&encryptionKeyRotation, "encryption-key-rotation", 30*24*time.Hour,
+		"How often the keys encrypting the Secret values resolved by the hub templates are rotated when the "+
+			"EncryptedHubTemplates feature is enabled.")
+	flag.BoolVar(&enableMutatingWebhook, "enable-mutating-webhook", false,
+		"Serve the mutating webhook that defaults and normalizes the root policies. The webhook server "+
+			"certificate must be mounted in the default directory of the webhook server.")
+	flag.BoolVar(&enableValidatingWebhook, "enable-validating-webhook", false,
+		"Serve the validating webhook that denies the users from modifying the replicated policies. The "+
+			"propagator service account is assumed to be named after the OPERATOR_NAME environment variable.")
+	flag.StringVar(&complianceEventsAPIAddr, "compliance-events-api-address", ":8384",
+		"The address the compliance events API binds to. The API is only served when the PostgreSQL "+
+			"connection URL of the compliance events database is set in the "+complianceeventsapi.DBURLEnvName+
+			" environment variable.")
+	flag.StringVar(&complianceEventsAPICert, "compliance-events-api-cert", "",
+		"The TLS certificate file of the compliance events API. The API is served over HTTP when unset.")
+	flag.StringVar(&complianceEventsAPIKey, "compliance-events-api-key", "",
+		"The TLS private key file of the compliance events API.")
+	flag.DurationVar(&complianceEventsMaxAge, "compliance-events-max-age", 0,
+		"How long the compliance events are kept in the compliance events database after their timestamp. "+
+			"Set to 0 to keep them regardless of their age.")
+	flag.IntVar(&complianceEventsMaxPerPolicy, "compliance-events-max-per-policy", 0,
+		"How many of the most recent compliance events are kept in the compliance events database for each "+
+			"policy template of each root policy on each cluster. Set to 0 to keep them regardless of their number.")
+	flag.DurationVar(&complianceEventsPruneInterval, "compliance-events-prune-interval", time.Hour,
+		"How often the compliance events outside of the compliance-events-max-age and "+
+			"compliance-events-max-per-policy retention are deleted. Set to 0 to only delete them when the "+
+			"propagator starts.")
+	flag.DurationVar(&statusBatchWindow, "root-policy-status-batch-window", 5*time.Second,
+		"How long the compliance changes of the replicated policies are collected before the root policy "+
+			"status is patched once for all of them. Set to 0 to patch the root policy status on every change.")
+	flag.IntVar(&maxConcurrentReconciles, "max-concurrent-reconciles", 0,
+		"The number of root policies reconciled at the same time. Defaults to the "+
+			"CONTROLLER_CONFIG_MAX_CONCURRENT_RECONCILES environment variable, or 1 when it is unset.")
+	flag.Float64Var(&clientQPS, "client-qps", getEnvFloat("CONTROLLER_CONFIG_QPS", 200.0),
+		"The maximum queries per second to the Kubernetes API server. Defaults to the CONTROLLER_CONFIG_QPS "+
+			"environment variable when it is set.")
+	flag.IntVar(&clientBurst, "client-burst", getEnvInt("CONTROLLER_CONFIG_BURST", 400),
+		"The maximum burst of queries to the Kubernetes API server. Defaults to the CONTROLLER_CONFIG_BURST "+
+			"environment variable when it is set.")
+	flag.StringVar(&disabledTemplateFunctions, "disabled-template-functions", "fromSecret",
+		"A comma separated list of the hub template functions disabled for all the root policies. The "+
+			"fromSecret function is enabled by the EncryptedHubTemplates feature.")
+	flag.StringVar(&templateEligibleKinds, "template-eligible-kinds",
+		strings.Join(propagatorctrl.DefaultTemplateEligibleKinds, ","),
+		"A comma separated list of the kinds of the policy templates that may have hub templates, such as "+
+			"custom policy kinds. The Gatekeeper ConstraintTemplates and Constraints may always have them.")
+	flag.StringVar(&templateFunctionsConfigMap, "template-functions-configmap", "",
+		"The name of the ConfigMap in the namespace of the propagator restricting the hub template functions "+
+			"to the root policies of some namespaces and allowing their lookups in other namespaces. It is reloaded "+
+			"when it changes.")
+	flag.BoolVar(&enableDetailedRootStatus, "enable-detailed-root-status", false,
+		"Set the compliance and the latest compliance message of the policy templates of the replicated "+
+			"policies in the per-cluster status of the root policies.")
+	flag.StringVar(&watchNamespaces, "watch-namespaces", "",
+		"A comma separated list of the namespaces of the root policies to propagate. The replicated policies "+
+			"of these root policies are propagated to their cluster namespaces, and the other root policies are "+
+			"left to other instances of the propagator. The controllers of the whole hub, such as the policy "+
+			"metrics and the compliance reports, only run when it is unset.")
+	flag.StringVar(&copyLabels, "copy-labels", "",
+		"A comma separated list of the glob patterns of the keys of the labels and annotations of the root "+
+			"policies copied to their replicated policies, in addition to the ones of the "+
+			"policy.open-cluster-management.io/copy-labels annotation of the root policies. All of them are copied "+
+			"when it is unset and the root policy has no such annotation. The keys of the "+
+			"policy.open-cluster-management.io domain are always copied.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -82,35 +198,55 @@ func main() {
 
 	printVersion()
 
-	namespace, err := getWatchNamespace()
+	if err := common.SetFeatureGates(featureGates); err != nil {
+		setupLog.Error(err, "Invalid feature gates")
+		os.Exit(1)
+	}
+
+	metricLabels, err := metricsctrl.ParseMetricLabels(policyMetricLabels)
 	if err != nil {
-		setupLog.Error(err, "Failed to get watch namespace")
+		setupLog.Error(err, "Invalid policy metric labels")
 		os.Exit(1)
 	}
 
-	// Get a config to talk to the apiserver
-	cfg := config.GetConfigOrDie()
+	// The zero value reconciles all the root policies
+	var shard propagatorctrl.Shard
 
-	// Some default tuned values here, but can be overriden via env vars
-	cfg.QPS = 200.0
-	cfg.Burst = 400
-	qpsOverride, found := os.LookupEnv("CONTROLLER_CONFIG_QPS")
-	if found {
-		qpsVal, err := strconv.ParseFloat(qpsOverride, 32)
-		if err == nil {
-			cfg.QPS = float32(qpsVal)
-			setupLog.Info(fmt.Sprintf("Using QPS override: %v", cfg.QPS))
+	if common.FeatureEnabled(common.Sharding) {
+		shard, err = propagatorctrl.ShardFromEnv()
+		if err != nil {
+			setupLog.Error(err, "Invalid shard")
+			os.Exit(1)
 		}
+
+		setupLog.Info("Reconciling a shard of the root policies", "Count", shard.Count, "Index", shard.Index)
 	}
-	burstOverride, found := os.LookupEnv("CONTROLLER_CONFIG_BURST")
-	if found {
-		burstVal, err := strconv.Atoi(burstOverride)
-		if err == nil {
-			cfg.Burst = burstVal
-			setupLog.Info(fmt.Sprintf("Using Burst override: %v", cfg.Burst))
+
+	for _, ns := range strings.Split(watchNamespaces, ",") {
+		if ns = strings.TrimSpace(ns); ns != "" {
+			shard.Namespaces = append(shard.Namespaces, ns)
 		}
 	}
 
+	if len(shard.Namespaces) != 0 {
+		sort.Strings(shard.Namespaces)
+		setupLog.Info("Reconciling the root policies of some namespaces", "Namespaces", shard.Namespaces)
+	}
+
+	namespace, err := getWatchNamespace()
+	if err != nil {
+		setupLog.Error(err, "Failed to get watch namespace")
+		os.Exit(1)
+	}
+
+	// Get a config to talk to the apiserver
+	cfg := config.GetConfigOrDie()
+
+	// Some default tuned values here, but can be overriden via flags or env vars
+	cfg.QPS = float32(clientQPS)
+	cfg.Burst = clientBurst
+	setupLog.Info("Using the Kubernetes client limits", "QPS", cfg.QPS, "Burst", cfg.Burst)
+
 	// Set default manager options
 	options := ctrl.Options{
 		Namespace:              namespace,
@@ -121,6 +257,19 @@ func main() {
 		LeaderElectionID:       "c6e0b7c1.open-cluster-management.io",
 	}
 
+	// Every shard elects its own leader, so that a replica of each shard is active
+	if shard.Count > 1 {
+		options.LeaderElectionID = fmt.Sprintf("%s-shard-%d", options.LeaderElectionID, shard.Index)
+	}
+
+	// The instances restricted to different namespaces may run in the same namespace, so every set of
+	// namespaces elects its own leader
+	if len(shard.Namespaces) != 0 {
+		hash := fnv.New32a()
+		_, _ = hash.Write([]byte(strings.Join(shard.Namespaces, ",")))
+		options.LeaderElectionID = fmt.Sprintf("%s-ns-%x", options.LeaderElectionID, hash.Sum32())
+	}
+
 	// Add support for MultiNamespace set in WATCH_NAMESPACE (e.g ns1,ns2)
 	// Note that this is not intended to be used for excluding namespaces, this is better done via a Predicate
 	// Also note that you may face performance issues when using this with a high number of namespaces.
@@ -128,6 +277,11 @@ func main() {
 	if strings.Contains(namespace, ",") {
 		options.Namespace = ""
 		options.NewCache = cache.MultiNamespacedCacheBuilder(strings.Split(namespace, ","))
+	} else {
+		// Only cache the propagator Deployment and the Policy CRD watched by the uninstall controller
+		options.NewCache = cache.BuilderWithOptions(cache.Options{
+			SelectorsByObject: uninstallctrl.CacheSelectors(os.Getenv("OPERATOR_NAME")),
+		})
 	}
 
 	mgr, err := ctrl.NewManager(cfg, options)
@@ -138,48 +292,282 @@ func main() {
 
 	setupLog.Info("Registering Components.")
 
-	if err = (&propagatorctrl.PolicyReconciler{
+	// Setup config and client for propagator to talk to the apiserver
+	var generatedClient kubernetes.Interface = kubernetes.NewForConfigOrDie(mgr.GetConfig())
+	propagatorOpts := propagatorctrl.PolicyReconcilerOptionsFromEnv(cfg, &generatedClient)
+	propagatorOpts.Recorder = mgr.GetEventRecorderFor(propagatorctrl.ControllerName)
+
+	// The placement APIs that aren't installed are only watched once they are installed
+	placementAvailability, err := common.DetectPlacementAvailability(generatedClient.Discovery())
+	if err != nil {
+		setupLog.Error(err, "unable to detect the installed placement APIs")
+		os.Exit(1)
+	}
+
+	for _, api := range common.PlacementAPIs {
+		if !placementAvailability.Available(api) {
+			setupLog.Info("The placement API is not installed, its PlacementBindings have no decisions", "API", api)
+		}
+	}
+
+	propagatorOpts.PlacementAvailability = placementAvailability
+
+	if err = mgr.Add(&placementapisctrl.CRDWatcher{
+		Client:       apiextensionsclientset.NewForConfigOrDie(mgr.GetConfig()),
+		Availability: placementAvailability,
+	}); err != nil {
+		setupLog.Error(err, "unable to watch the placement CRDs")
+		os.Exit(1)
+	}
+
+	if maxConcurrentReconciles > 0 {
+		propagatorOpts.MaxConcurrentReconciles = maxConcurrentReconciles
+	}
+
+	propagatorOpts.DisabledTemplateFunctions = []string{}
+	for _, function := range strings.Split(disabledTemplateFunctions, ",") {
+		if function = strings.TrimSpace(function); function != "" {
+			propagatorOpts.DisabledTemplateFunctions = append(propagatorOpts.DisabledTemplateFunctions, function)
+		}
+	}
+
+	propagatorOpts.TemplateEligibleKinds = strings.Split(templateEligibleKinds, ",")
+
+	if copyLabels != "" {
+		propagatorOpts.CopyLabels = []string{}
+		for _, pattern := range strings.Split(copyLabels, ",") {
+			if pattern = strings.TrimSpace(pattern); pattern != "" {
+				propagatorOpts.CopyLabels = append(propagatorOpts.CopyLabels, pattern)
+			}
+		}
+	}
+
+	// The template-user annotation of the root policies is only set by the mutating webhook, and only
+	// trusted while the webhook is fail-closed
+	propagatorOpts.TrustTemplateUserAnnotation = enableMutatingWebhook
+
+	propagatorOpts.DetailedRootStatus = enableDetailedRootStatus
+	propagatorOpts.Shard = shard
+
+	propagatorOpts.TemplateFunctionsConfigMap = types.NamespacedName{
+		Namespace: os.Getenv("POD_NAMESPACE"), Name: templateFunctionsConfigMap,
+	}
+
+	var complianceDB *complianceeventsapi.ComplianceDB
+
+	if dbURL := os.Getenv(complianceeventsapi.DBURLEnvName); dbURL != "" {
+		complianceDB, err = complianceeventsapi.OpenComplianceDB(dbURL)
+		if err != nil {
+			setupLog.Error(err, "unable to open the compliance events database")
+			os.Exit(1)
+		}
+
+		if err = mgr.Add(&complianceeventsapi.ComplianceAPIServer{
+			Addr:       complianceEventsAPIAddr,
+			CertFile:   complianceEventsAPICert,
+			KeyFile:    complianceEventsAPIKey,
+			Store:      complianceDB,
+			Authorizer: &complianceeventsapi.KubeAuthorizer{Client: generatedClient},
+		}); err != nil {
+			setupLog.Error(err, "unable to serve the compliance events API")
+			os.Exit(1)
+		}
+
+		retention := complianceeventsapi.Retention{
+			MaxAge: complianceEventsMaxAge, MaxEventsPerPolicy: complianceEventsMaxPerPolicy,
+		}
+
+		if retention.Enabled() {
+			if err = mgr.Add(&complianceeventsapi.CompliancePruner{
+				Store: complianceDB, Retention: retention, Interval: complianceEventsPruneInterval,
+			}); err != nil {
+				setupLog.Error(err, "unable to prune the compliance events database")
+				os.Exit(1)
+			}
+		}
+
+		propagatorOpts.ComplianceDB = complianceDB
+	}
+
+	propagator := propagatorctrl.NewPolicyReconciler(mgr.GetClient(), mgr.GetScheme(), propagatorOpts)
+	if err = propagator.SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", propagatorctrl.ControllerName)
+		os.Exit(1)
+	}
+
+	if err = propagatorctrl.NewReplicatedPolicyReconciler(propagator).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", propagatorctrl.ReplicatedPolicyControllerName)
+		os.Exit(1)
+	}
+
+	if err = (&propagatorctrl.RootPolicyStatusReconciler{
 		Client:   mgr.GetClient(),
 		Scheme:   mgr.GetScheme(),
-		Recorder: mgr.GetEventRecorderFor(propagatorctrl.ControllerName),
+		Recorder: mgr.GetEventRecorderFor(propagatorctrl.RootPolicyStatusControllerName),
+
+		StatusBatchWindow: statusBatchWindow,
+		DetailedStatus:    enableDetailedRootStatus,
+		Shard:             shard,
 	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", propagatorctrl.ControllerName)
+		setupLog.Error(err, "unable to create controller", "controller", propagatorctrl.RootPolicyStatusControllerName)
+		os.Exit(1)
+	}
+
+	if err = (&uninstallctrl.UninstallReconciler{
+		Client:              mgr.GetClient(),
+		Scheme:              mgr.GetScheme(),
+		DeploymentNamespace: os.Getenv("POD_NAMESPACE"),
+		DeploymentName:      os.Getenv("OPERATOR_NAME"),
+		Namespaces:          shard.Namespaces,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", uninstallctrl.ControllerName)
+		os.Exit(1)
+	}
+
+	// The debug endpoint is served with the metrics
+	if err = mgr.AddMetricsExtraHandler(propagatorctrl.DebugPath, propagator.DebugHandler()); err != nil {
+		setupLog.Error(err, "unable to serve the propagation debug endpoint")
 		os.Exit(1)
 	}
 
-	if reportMetrics() {
-		if err = (&metricsctrl.MetricReconciler{
-			Client: mgr.GetClient(),
-			Scheme: mgr.GetScheme(),
+	// The controllers of the whole hub only run in the first shard, and not in the instances
+	// restricted to some namespaces
+	if shard.Index == 0 && len(shard.Namespaces) == 0 {
+		if reportMetrics() {
+			if err = (&metricsctrl.MetricReconciler{
+				Client:       mgr.GetClient(),
+				Scheme:       mgr.GetScheme(),
+				MetricLabels: metricLabels,
+			}).SetupWithManager(mgr); err != nil {
+				setupLog.Error(err, "unable to create controller", "controller", metricsctrl.ControllerName)
+				os.Exit(1)
+			}
+		}
+
+		if err = (&automationctrl.PolicyAutomationReconciler{
+			Client:        mgr.GetClient(),
+			DynamicClient: dynamic.NewForConfigOrDie(mgr.GetConfig()),
+			Scheme:        mgr.GetScheme(),
+			Recorder:      mgr.GetEventRecorderFor(automationctrl.ControllerName),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", automationctrl.ControllerName)
+			os.Exit(1)
+		}
+
+		if err = (&automationctrl.AnsibleJobReconciler{
+			Client:   mgr.GetClient(),
+			Scheme:   mgr.GetScheme(),
+			Recorder: mgr.GetEventRecorderFor(automationctrl.AnsibleJobControllerName),
 		}).SetupWithManager(mgr); err != nil {
-			setupLog.Error(err, "unable to create controller", "controller", metricsctrl.ControllerName)
+			setupLog.Error(err, "unable to create controller", "controller", automationctrl.AnsibleJobControllerName)
 			os.Exit(1)
 		}
+
+		if err = (&pbstatusctrl.PlacementBindingReconciler{
+			Client:                mgr.GetClient(),
+			Scheme:                mgr.GetScheme(),
+			PlacementAvailability: placementAvailability,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", pbstatusctrl.ControllerName)
+			os.Exit(1)
+		}
+
+		if complianceReportInterval > 0 {
+			if err = (&reportctrl.PolicyComplianceReportReconciler{
+				Client:   mgr.GetClient(),
+				Scheme:   mgr.GetScheme(),
+				Interval: complianceReportInterval,
+			}).SetupWithManager(mgr); err != nil {
+				setupLog.Error(err, "unable to create controller", "controller", reportctrl.ControllerName)
+				os.Exit(1)
+			}
+		}
+
+		// The migration watches both placement APIs, so it only runs when both are installed
+		migrationAPIsInstalled := placementAvailability.Available(common.PlacementRuleAPI) &&
+			placementAvailability.Available(common.ClusterPlacementAPI)
+
+		if common.FeatureEnabled(common.PlacementRuleMigration) && !migrationAPIsInstalled {
+			setupLog.Info("The placement APIs are not installed, the PlacementRule migration is disabled")
+		}
+
+		if common.FeatureEnabled(common.PlacementRuleMigration) && migrationAPIsInstalled {
+			clusterSets := []string{}
+			for _, clusterSet := range strings.Split(migrationClusterSets, ",") {
+				if clusterSet = strings.TrimSpace(clusterSet); clusterSet != "" {
+					clusterSets = append(clusterSets, clusterSet)
+				}
+			}
+
+			if err = (&migrationctrl.PlacementMigrationReconciler{
+				Client:      mgr.GetClient(),
+				Scheme:      mgr.GetScheme(),
+				Recorder:    mgr.GetEventRecorderFor(migrationctrl.ControllerName),
+				ClusterSets: clusterSets,
+			}).SetupWithManager(mgr); err != nil {
+				setupLog.Error(err, "unable to create controller", "controller", migrationctrl.ControllerName)
+				os.Exit(1)
+			}
+		}
+
+		if common.FeatureEnabled(common.EncryptedHubTemplates) {
+			if err = (&encryptionkeysctrl.EncryptionKeysReconciler{
+				Client:      mgr.GetClient(),
+				KubeClient:  generatedClient,
+				Scheme:      mgr.GetScheme(),
+				KeyRotation: encryptionKeyRotation,
+				Clock:       clock.RealClock{},
+			}).SetupWithManager(mgr); err != nil {
+				setupLog.Error(err, "unable to create controller", "controller", encryptionkeysctrl.ControllerName)
+				os.Exit(1)
+			}
+		}
 	}
 
-	if err = (&automationctrl.PolicyAutomationReconciler{
-		Client:        mgr.GetClient(),
-		DynamicClient: dynamic.NewForConfigOrDie(mgr.GetConfig()),
-		Scheme:        mgr.GetScheme(),
-		Recorder:      mgr.GetEventRecorderFor(automationctrl.ControllerName),
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", automationctrl.ControllerName)
-		os.Exit(1)
+	if enableMutatingWebhook {
+		mgr.GetWebhookServer().Register(policywebhook.MutatePath, &webhook.Admission{
+			Handler: &policywebhook.PolicyDefaulter{Client: mgr.GetClient()},
+		})
+	}
+
+	if enableValidatingWebhook {
+		mgr.GetWebhookServer().Register(policywebhook.ValidatePath, &webhook.Admission{
+			Handler: &policywebhook.ReplicatedPolicyValidator{
+				PropagatorUser: policywebhook.PropagatorUser(os.Getenv("POD_NAMESPACE"), os.Getenv("OPERATOR_NAME")),
+			},
+		})
 	}
 	//+kubebuilder:scaffold:builder
 
-	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
-		setupLog.Error(err, "unable to set up health check")
-		os.Exit(1)
+	// The process is only restarted when it is unresponsive or its caches never sync, while the
+	// unreachable dependencies only make it unready
+	healthChecks := map[string]healthz.Checker{
+		"healthz":    healthz.Ping,
+		"cache-sync": common.CacheSyncCheck(mgr.GetCache()),
 	}
-	if err := mgr.AddReadyzCheck("readyz", healthz.Ping); err != nil {
-		setupLog.Error(err, "unable to set up ready check")
-		os.Exit(1)
+	readyChecks := map[string]healthz.Checker{
+		"readyz":     healthz.Ping,
+		"cache-sync": common.CacheSyncCheck(mgr.GetCache()),
+		"kube-api":   common.PingCheck(common.APIServerPing(generatedClient)),
 	}
 
-	// Setup config and client for propagator to talk to the apiserver
-	var generatedClient kubernetes.Interface = kubernetes.NewForConfigOrDie(mgr.GetConfig())
-	propagatorctrl.Initialize(cfg, &generatedClient)
+	if complianceDB != nil {
+		readyChecks["compliance-db"] = common.PingCheck(complianceDB.Ping)
+	}
+
+	for name, check := range healthChecks {
+		if err := mgr.AddHealthzCheck(name, check); err != nil {
+			setupLog.Error(err, "unable to set up health check", "check", name)
+			os.Exit(1)
+		}
+	}
+
+	for name, check := range readyChecks {
+		if err := mgr.AddReadyzCheck(name, check); err != nil {
+			setupLog.Error(err, "unable to set up ready check", "check", name)
+			os.Exit(1)
+		}
+	}
 
 	cache := mgr.GetCache()
 
@@ -222,3 +610,25 @@ func getWatchNamespace() (string, error) {
 	}
 	return ns, nil
 }
+
+// getEnvFloat returns the float value of the environment variable, or the default value when it is
+// unset or invalid
+func getEnvFloat(name string, defaultValue float64) float64 {
+	value, err := strconv.ParseFloat(os.Getenv(name), 32)
+	if err != nil {
+		return defaultValue
+	}
+
+	return value
+}
+
+// getEnvInt returns the integer value of the environment variable, or the default value when it is
+// unset or invalid
+func getEnvInt(name string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(name))
+	if err != nil {
+		return defaultValue
+	}
+
+	return value
+}
@@ -0,0 +1,85 @@
+// Copyright Contributors to the Open Cluster Management project
+
+// Package propagation computes what the propagator would create for a root policy without creating
+// anything, so that tools such as CLIs and CI pipelines can preview the replicated policies. It uses
+// the same code as the propagator, so the previews match what the propagator creates with the same
+// options.
+package propagation
+
+import (
+	"context"
+
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/propagator"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+// Previewer previews the propagation of the root policies read with its client. The client only
+// needs to read the PlacementBindings, the placements and their decisions, the managed clusters, the
+// PropagationConfigs, and the objects looked up by the hub templates. Its scheme must have the
+// policy, placement, and cluster APIs of the propagator.
+type Previewer struct {
+	reconciler *propagator.PolicyReconciler
+}
+
+// NewPreviewer returns a Previewer configured like a propagator with the options. The
+// propagator.PolicyReconcilerOptionsFromEnv function returns the options of a propagator deployment
+// from its environment variables. The hub templates are resolved with the KubeConfig and KubeClient
+// of the options, or the NewTemplateResolver when it is set. The events are discarded unless a
+// Recorder is set.
+func NewPreviewer(c client.Client, opts propagator.PolicyReconcilerOptions) *Previewer {
+	if opts.Recorder == nil {
+		opts.Recorder = &record.FakeRecorder{}
+	}
+
+	return &Previewer{reconciler: propagator.NewPolicyReconciler(c, c.Scheme(), opts)}
+}
+
+// PlacementDecisions returns the placement decisions of the clusters the root policy would be
+// replicated to
+func (p *Previewer) PlacementDecisions(
+	ctx context.Context, root *policiesv1.Policy,
+) ([]appsv1.PlacementDecision, error) {
+	return p.reconciler.PreviewDecisions(ctx, root)
+}
+
+// RenderTemplates returns the root policy with its hub templates resolved for the cluster of the
+// placement decision
+func (p *Previewer) RenderTemplates(
+	ctx context.Context, root *policiesv1.Policy, decision appsv1.PlacementDecision,
+) (*policiesv1.Policy, error) {
+	return p.reconciler.PreviewTemplates(ctx, root, decision)
+}
+
+// ReplicatedPolicy returns the replicated policy the propagator would create in the namespace of
+// the placement decision
+func (p *Previewer) ReplicatedPolicy(
+	ctx context.Context, root *policiesv1.Policy, decision appsv1.PlacementDecision,
+) (*policiesv1.Policy, error) {
+	return p.reconciler.PreviewReplicatedPolicy(ctx, root, decision)
+}
+
+// ReplicatedPolicies returns the replicated policies the propagator would create for the root
+// policy, in the order of its placement decisions
+func (p *Previewer) ReplicatedPolicies(ctx context.Context, root *policiesv1.Policy) ([]*policiesv1.Policy, error) {
+	decisions, err := p.PlacementDecisions(ctx, root)
+	if err != nil {
+		return nil, err
+	}
+
+	replicatedPlcs := make([]*policiesv1.Policy, 0, len(decisions))
+
+	for _, decision := range decisions {
+		replicatedPlc, err := p.ReplicatedPolicy(ctx, root, decision)
+		if err != nil {
+			return nil, err
+		}
+
+		replicatedPlcs = append(replicatedPlcs, replicatedPlc)
+	}
+
+	return replicatedPlcs, nil
+}
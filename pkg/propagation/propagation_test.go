@@ -0,0 +1,143 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package propagation
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	clusterv1 "github.com/open-cluster-management/api/cluster/v1"
+	clusterv1alpha1 "github.com/open-cluster-management/api/cluster/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	policiesv1 "github.com/open-cluster-management/governance-policy-propagator/api/v1"
+	policyv1beta1 "github.com/open-cluster-management/governance-policy-propagator/api/v1beta1"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
+	"github.com/open-cluster-management/governance-policy-propagator/controllers/propagator"
+	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
+)
+
+const configPolicy = `{"apiVersion":"policy.open-cluster-management.io/v1","kind":"ConfigurationPolicy",` +
+	`"metadata":{"name":"case"},"spec":{"namespaceSelector":{"include":["{{hub .ManagedClusterName hub}}"]}}}`
+
+// clusterNameResolver replaces the hub template of the ConfigurationPolicy with the cluster name
+type clusterNameResolver struct{}
+
+func (clusterNameResolver) ResolveTemplate(tmplJSON []byte, context interface{}) ([]byte, error) {
+	clusterName := reflect.ValueOf(context).FieldByName("ManagedClusterName").String()
+
+	return []byte(strings.Replace(string(tmplJSON), "{{hub .ManagedClusterName hub}}", clusterName, 1)), nil
+}
+
+func newTestPreviewer(t *testing.T, objects ...client.Object) *Previewer {
+	t.Helper()
+
+	scheme := runtime.NewScheme()
+	for _, addToScheme := range []func(*runtime.Scheme) error{
+		policiesv1.AddToScheme, appsv1.AddToScheme, clusterv1alpha1.AddToScheme, clusterv1.AddToScheme,
+		corev1.AddToScheme, policyv1beta1.AddToScheme,
+	} {
+		if err := addToScheme(scheme); err != nil {
+			t.Fatalf("failed to build the scheme: %v", err)
+		}
+	}
+
+	return NewPreviewer(
+		fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(),
+		propagator.PolicyReconcilerOptions{
+			RetryAttempts: 1,
+			NewTemplateResolver: func(propagator.TemplateResolverOptions) (propagator.TemplateResolver, error) {
+				return clusterNameResolver{}, nil
+			},
+		},
+	)
+}
+
+func TestPreviewer(t *testing.T) {
+	root := &policiesv1.Policy{
+		TypeMeta:   metav1.TypeMeta{APIVersion: policiesv1.SchemeGroupVersion.String(), Kind: policiesv1.Kind},
+		ObjectMeta: metav1.ObjectMeta{Name: "policy", Namespace: "policies"},
+		Spec: policiesv1.PolicySpec{
+			RemediationAction: policiesv1.Inform,
+			PolicyTemplates: []*policiesv1.PolicyTemplate{
+				{ObjectDefinition: runtime.RawExtension{Raw: []byte(configPolicy)}},
+			},
+		},
+	}
+	plr := &appsv1.PlacementRule{
+		ObjectMeta: metav1.ObjectMeta{Name: "plr", Namespace: "policies"},
+		Status: appsv1.PlacementRuleStatus{Decisions: []appsv1.PlacementDecision{
+			{ClusterName: "cluster1", ClusterNamespace: "cluster1"},
+			{ClusterName: "cluster2", ClusterNamespace: "cluster2"},
+		}},
+	}
+	pb := &policiesv1.PlacementBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "pb", Namespace: "policies"},
+		PlacementRef: policiesv1.Subject{
+			APIGroup: appsv1.SchemeGroupVersion.Group, Kind: "PlacementRule", Name: "plr",
+		},
+		Subjects: []policiesv1.Subject{
+			{APIGroup: policiesv1.SchemeGroupVersion.Group, Kind: policiesv1.Kind, Name: "policy"},
+		},
+	}
+
+	p := newTestPreviewer(t, root, plr, pb)
+
+	decisions, err := p.PlacementDecisions(context.TODO(), root)
+	if err != nil {
+		t.Fatalf("failed to preview the placement decisions: %v", err)
+	}
+
+	if len(decisions) != 2 || decisions[0].ClusterName != "cluster1" || decisions[1].ClusterName != "cluster2" {
+		t.Fatalf("expected the decisions of cluster1 and cluster2, got %v", decisions)
+	}
+
+	rendered, err := p.RenderTemplates(context.TODO(), root, decisions[1])
+	if err != nil {
+		t.Fatalf("failed to preview the hub templates: %v", err)
+	}
+
+	if raw := string(rendered.Spec.PolicyTemplates[0].ObjectDefinition.Raw); !strings.Contains(raw, `["cluster2"]`) {
+		t.Fatalf("expected the hub template to be resolved for cluster2, got %s", raw)
+	}
+
+	replicatedPlcs, err := p.ReplicatedPolicies(context.TODO(), root)
+	if err != nil {
+		t.Fatalf("failed to preview the replicated policies: %v", err)
+	}
+
+	if len(replicatedPlcs) != 2 {
+		t.Fatalf("expected 2 replicated policies, got %d", len(replicatedPlcs))
+	}
+
+	replicatedPlc := replicatedPlcs[0]
+	if replicatedPlc.GetNamespace() != "cluster1" || replicatedPlc.GetName() != common.FullNameForPolicy(root) {
+		t.Fatalf("expected the replicated policy cluster1/%s, got %s/%s",
+			common.FullNameForPolicy(root), replicatedPlc.GetNamespace(), replicatedPlc.GetName())
+	}
+
+	if replicatedPlc.GetLabels()[common.RootPolicyLabel] != common.FullNameForPolicy(root) {
+		t.Fatalf("expected the root policy label on the replicated policy, got %v", replicatedPlc.GetLabels())
+	}
+
+	raw := string(replicatedPlc.Spec.PolicyTemplates[0].ObjectDefinition.Raw)
+	if !strings.Contains(raw, `["cluster1"]`) {
+		t.Fatalf("expected the hub template to be resolved for cluster1, got %s", raw)
+	}
+
+	// Nothing is created by the preview
+	plcList := &policiesv1.PolicyList{}
+	if err := p.reconciler.List(context.TODO(), plcList); err != nil {
+		t.Fatalf("failed to list the policies: %v", err)
+	}
+
+	if len(plcList.Items) != 1 {
+		t.Fatalf("expected only the root policy, got %d policies", len(plcList.Items))
+	}
+}
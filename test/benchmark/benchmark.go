@@ -0,0 +1,294 @@
+// Copyright Contributors to the Open Cluster Management project
+
+// Package benchmark measures the propagation throughput and the status aggregation latency of a
+// running propagator. It works against any hub the propagator is watching, such as a kind cluster
+// or an envtest environment with the propagator started in-process.
+package benchmark
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+var (
+	gvrNamespace        = schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+	gvrPolicy           = schema.GroupVersionResource{Group: "policy.open-cluster-management.io", Version: "v1", Resource: "policies"}
+	gvrPlacementBinding = schema.GroupVersionResource{
+		Group: "policy.open-cluster-management.io", Version: "v1", Resource: "placementbindings",
+	}
+	gvrPlacementRule = schema.GroupVersionResource{
+		Group: "apps.open-cluster-management.io", Version: "v1", Resource: "placementrules",
+	}
+)
+
+const (
+	benchmarkLabel  = "policy.open-cluster-management.io/benchmark"
+	rootPolicyLabel = "policy.open-cluster-management.io/root-policy"
+)
+
+// Config configures a benchmark run
+type Config struct {
+	// Clusters is the number of cluster namespaces to propagate to
+	Clusters int `json:"clusters"`
+	// Policies is the number of root policies to propagate
+	Policies int `json:"policies"`
+	// Namespace is where the root policies are created. It must already exist.
+	Namespace string `json:"namespace"`
+	// Timeout is how long to wait for each phase before giving up
+	Timeout time.Duration `json:"timeout"`
+}
+
+// Result is the machine-readable report of a benchmark run
+type Result struct {
+	Config Config `json:"config"`
+	// ReplicatedPolicies is the number of replicated policies created by the propagator
+	ReplicatedPolicies int `json:"replicatedPolicies"`
+	// PropagationSeconds is the time from binding the policies until all were replicated
+	PropagationSeconds float64 `json:"propagationSeconds"`
+	// PoliciesPerSecond is the number of replicated policies created per second
+	PoliciesPerSecond float64 `json:"policiesPerSecond"`
+	// AggregationSeconds is the time from setting the replicated policy statuses until all root
+	// policy statuses reflected them
+	AggregationSeconds float64 `json:"aggregationSeconds"`
+}
+
+// Run creates the cluster namespaces, root policies, PlacementRule, and PlacementBinding for the
+// configuration, measures how long the propagator takes to replicate the policies and to aggregate
+// their statuses, and then deletes everything it created.
+func Run(ctx context.Context, client dynamic.Interface, cfg Config) (*Result, error) {
+	result := &Result{Config: cfg}
+	defer cleanUp(client, cfg)
+
+	clusters := make([]string, 0, cfg.Clusters)
+	for i := 0; i < cfg.Clusters; i++ {
+		clusters = append(clusters, fmt.Sprintf("benchmark-cluster-%d", i))
+	}
+
+	for _, cluster := range clusters {
+		err := create(ctx, client, gvrNamespace, "", object("v1", "Namespace", cluster, nil))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := createPlacement(ctx, client, cfg.Namespace, clusters)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < cfg.Policies; i++ {
+		policy := object("policy.open-cluster-management.io/v1", "Policy", fmt.Sprintf("benchmark-policy-%d", i),
+			map[string]interface{}{"disabled": false, "remediationAction": "inform", "policy-templates": []interface{}{}})
+
+		err := create(ctx, client, gvrPolicy, cfg.Namespace, policy)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// The propagation starts once the PlacementBinding exists
+	subjects := make([]interface{}, 0, cfg.Policies)
+	for i := 0; i < cfg.Policies; i++ {
+		subjects = append(subjects, map[string]interface{}{
+			"apiGroup": "policy.open-cluster-management.io", "kind": "Policy", "name": fmt.Sprintf("benchmark-policy-%d", i),
+		})
+	}
+
+	binding := object("policy.open-cluster-management.io/v1", "PlacementBinding", "benchmark-binding", nil)
+	binding.Object["placementRef"] = map[string]interface{}{
+		"apiGroup": "apps.open-cluster-management.io", "kind": "PlacementRule", "name": "benchmark-placement",
+	}
+	binding.Object["subjects"] = subjects
+
+	start := time.Now()
+
+	err = create(ctx, client, gvrPlacementBinding, cfg.Namespace, binding)
+	if err != nil {
+		return nil, err
+	}
+
+	expected := cfg.Clusters * cfg.Policies
+	err = waitFor(ctx, cfg.Timeout, func() (bool, error) {
+		list, err := listReplicated(ctx, client, cfg.Namespace)
+		if err != nil {
+			return false, err
+		}
+
+		return len(list.Items) == expected, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("the policies were not all replicated: %w", err)
+	}
+
+	result.ReplicatedPolicies = expected
+	result.PropagationSeconds = time.Since(start).Seconds()
+	if result.PropagationSeconds > 0 {
+		result.PoliciesPerSecond = float64(expected) / result.PropagationSeconds
+	}
+
+	list, err := listReplicated(ctx, client, cfg.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	start = time.Now()
+
+	for i := range list.Items {
+		replicatedPlc := &list.Items[i]
+		replicatedPlc.Object["status"] = map[string]interface{}{"compliant": "Compliant"}
+
+		_, err := client.Resource(gvrPolicy).Namespace(replicatedPlc.GetNamespace()).
+			UpdateStatus(ctx, replicatedPlc, metav1.UpdateOptions{})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = waitFor(ctx, cfg.Timeout, func() (bool, error) {
+		for i := 0; i < cfg.Policies; i++ {
+			root, err := client.Resource(gvrPolicy).Namespace(cfg.Namespace).
+				Get(ctx, fmt.Sprintf("benchmark-policy-%d", i), metav1.GetOptions{})
+			if err != nil {
+				return false, err
+			}
+
+			compliant, _, _ := unstructured.NestedString(root.Object, "status", "compliant")
+			if compliant != "Compliant" {
+				return false, nil
+			}
+		}
+
+		return true, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("the policy statuses were not all aggregated: %w", err)
+	}
+
+	result.AggregationSeconds = time.Since(start).Seconds()
+
+	return result, nil
+}
+
+// createPlacement creates the PlacementRule with a decision for each cluster
+func createPlacement(ctx context.Context, client dynamic.Interface, namespace string, clusters []string) error {
+	plr := object("apps.open-cluster-management.io/v1", "PlacementRule", "benchmark-placement", map[string]interface{}{})
+
+	err := create(ctx, client, gvrPlacementRule, namespace, plr)
+	if err != nil {
+		return err
+	}
+
+	plr, err = client.Resource(gvrPlacementRule).Namespace(namespace).Get(ctx, plr.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	decisions := make([]interface{}, 0, len(clusters))
+	for _, cluster := range clusters {
+		decisions = append(decisions, map[string]interface{}{"clusterName": cluster, "clusterNamespace": cluster})
+	}
+
+	plr.Object["status"] = map[string]interface{}{"decisions": decisions}
+	_, err = client.Resource(gvrPlacementRule).Namespace(namespace).UpdateStatus(ctx, plr, metav1.UpdateOptions{})
+
+	return err
+}
+
+func object(apiVersion, kind, name string, spec map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	obj.SetName(name)
+	obj.SetLabels(map[string]string{benchmarkLabel: "true"})
+
+	if spec != nil {
+		obj.Object["spec"] = spec
+	}
+
+	return obj
+}
+
+func create(
+	ctx context.Context, client dynamic.Interface, gvr schema.GroupVersionResource, namespace string,
+	obj *unstructured.Unstructured,
+) error {
+	var err error
+	if namespace == "" {
+		_, err = client.Resource(gvr).Create(ctx, obj, metav1.CreateOptions{})
+	} else {
+		_, err = client.Resource(gvr).Namespace(namespace).Create(ctx, obj, metav1.CreateOptions{})
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to create the %s %s: %w", obj.GetKind(), obj.GetName(), err)
+	}
+
+	return nil
+}
+
+// listReplicated lists the replicated policies of the benchmark's root policies
+func listReplicated(ctx context.Context, client dynamic.Interface, namespace string) (*unstructured.UnstructuredList, error) {
+	list, err := client.Resource(gvrPolicy).List(ctx, metav1.ListOptions{LabelSelector: rootPolicyLabel})
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := &unstructured.UnstructuredList{}
+	for _, item := range list.Items {
+		if strings.HasPrefix(item.GetLabels()[rootPolicyLabel], namespace+".benchmark-policy-") {
+			filtered.Items = append(filtered.Items, item)
+		}
+	}
+
+	return filtered, nil
+}
+
+// waitFor polls the condition every 100 milliseconds until it is met or the timeout is reached
+func waitFor(ctx context.Context, timeout time.Duration, condition func() (bool, error)) error {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for {
+		done, err := condition()
+		if err != nil {
+			return err
+		}
+
+		if done {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// cleanUp deletes everything created by the benchmark. Errors are ignored since this is best
+// effort.
+func cleanUp(client dynamic.Interface, cfg Config) {
+	ctx := context.Background()
+	opts := metav1.ListOptions{LabelSelector: benchmarkLabel}
+
+	_ = client.Resource(gvrPlacementBinding).Namespace(cfg.Namespace).
+		DeleteCollection(ctx, metav1.DeleteOptions{}, opts)
+	_ = client.Resource(gvrPolicy).Namespace(cfg.Namespace).DeleteCollection(ctx, metav1.DeleteOptions{}, opts)
+	_ = client.Resource(gvrPlacementRule).Namespace(cfg.Namespace).
+		DeleteCollection(ctx, metav1.DeleteOptions{}, opts)
+
+	for i := 0; i < cfg.Clusters; i++ {
+		_ = client.Resource(gvrNamespace).Delete(ctx, fmt.Sprintf("benchmark-cluster-%d", i), metav1.DeleteOptions{})
+	}
+}
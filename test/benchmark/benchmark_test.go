@@ -0,0 +1,75 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package benchmark
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+)
+
+// TestPropagationThroughput runs the benchmark against the hub in the current kubeconfig. It is
+// skipped unless BENCHMARK_ENABLED is set to true. The cluster and policy counts are configured
+// with BENCHMARK_CLUSTERS and BENCHMARK_POLICIES, and the JSON report is written to the file in
+// BENCHMARK_REPORT or to the test log if it is not set.
+func TestPropagationThroughput(t *testing.T) {
+	if os.Getenv("BENCHMARK_ENABLED") != "true" {
+		t.Skip("Set BENCHMARK_ENABLED=true to run the propagation benchmark")
+	}
+
+	cfg := Config{
+		Clusters:  envInt(t, "BENCHMARK_CLUSTERS", 10),
+		Policies:  envInt(t, "BENCHMARK_POLICIES", 10),
+		Namespace: os.Getenv("BENCHMARK_NAMESPACE"),
+		Timeout:   time.Duration(envInt(t, "BENCHMARK_TIMEOUT_SECONDS", 300)) * time.Second,
+	}
+	if cfg.Namespace == "" {
+		cfg.Namespace = "policy-propagator-test"
+	}
+
+	restConfig, err := config.GetConfig()
+	if err != nil {
+		t.Fatalf("Failed to load the kubeconfig: %v", err)
+	}
+
+	result, err := Run(context.TODO(), dynamic.NewForConfigOrDie(restConfig), cfg)
+	if err != nil {
+		t.Fatalf("The benchmark failed: %v", err)
+	}
+
+	report, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		t.Fatalf("Failed to marshal the report: %v", err)
+	}
+
+	if path := os.Getenv("BENCHMARK_REPORT"); path != "" {
+		if err := ioutil.WriteFile(path, report, 0o644); err != nil {
+			t.Fatalf("Failed to write the report: %v", err)
+		}
+
+		return
+	}
+
+	t.Log(string(report))
+}
+
+func envInt(t *testing.T, name string, defaultValue int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < 1 {
+		t.Fatalf("%s must be a positive integer, got %s", name, value)
+	}
+
+	return parsed
+}
@@ -42,7 +42,7 @@ var _ = Describe("Test policy status aggregation", func() {
 			yamlPlc := utils.ParseYaml("../resources/case2_aggregation/managed1-status.yaml")
 			Eventually(func() interface{} {
 				rootPlc := utils.GetWithTimeout(clientHubDynamic, gvrPolicy, case2PolicyName, testNamespace, true, defaultTimeoutSeconds)
-				return rootPlc.Object["status"]
+				return utils.RootPolicyStatus(rootPlc)
 			}, defaultTimeoutSeconds, 1).Should(utils.SemanticEqual(yamlPlc.Object["status"]))
 		})
 		It("should contain status.placement with both managed1 and managed2", func() {
@@ -59,7 +59,7 @@ var _ = Describe("Test policy status aggregation", func() {
 			yamlPlc := utils.ParseYaml("../resources/case2_aggregation/managed-both-status.yaml")
 			Eventually(func() interface{} {
 				rootPlc := utils.GetWithTimeout(clientHubDynamic, gvrPolicy, case2PolicyName, testNamespace, true, defaultTimeoutSeconds)
-				return rootPlc.Object["status"]
+				return utils.RootPolicyStatus(rootPlc)
 			}, defaultTimeoutSeconds, 1).Should(utils.SemanticEqual(yamlPlc.Object["status"]))
 		})
 		It("should contain status.placement with managed2", func() {
@@ -76,7 +76,7 @@ var _ = Describe("Test policy status aggregation", func() {
 			yamlPlc := utils.ParseYaml("../resources/case2_aggregation/managed2-status.yaml")
 			Eventually(func() interface{} {
 				rootPlc := utils.GetWithTimeout(clientHubDynamic, gvrPolicy, case2PolicyName, testNamespace, true, defaultTimeoutSeconds)
-				return rootPlc.Object["status"]
+				return utils.RootPolicyStatus(rootPlc)
 			}, defaultTimeoutSeconds, 1).Should(utils.SemanticEqual(yamlPlc.Object["status"]))
 		})
 		It("should contain status.placement with two pb/plr", func() {
@@ -88,7 +88,7 @@ var _ = Describe("Test policy status aggregation", func() {
 			yamlPlc := utils.ParseYaml("../resources/case2_aggregation/managed-both-placement-single-status.yaml")
 			Eventually(func() interface{} {
 				rootPlc := utils.GetWithTimeout(clientHubDynamic, gvrPolicy, case2PolicyName, testNamespace, true, defaultTimeoutSeconds)
-				return rootPlc.Object["status"]
+				return utils.RootPolicyStatus(rootPlc)
 			}, defaultTimeoutSeconds, 1).Should(utils.SemanticEqual(yamlPlc.Object["status"]))
 		})
 		It("should contain status.placement with two pb/plr and both status", func() {
@@ -101,7 +101,7 @@ var _ = Describe("Test policy status aggregation", func() {
 			yamlPlc := utils.ParseYaml("../resources/case2_aggregation/managed-both-placement-status.yaml")
 			Eventually(func() interface{} {
 				rootPlc := utils.GetWithTimeout(clientHubDynamic, gvrPolicy, case2PolicyName, testNamespace, true, defaultTimeoutSeconds)
-				return rootPlc.Object["status"]
+				return utils.RootPolicyStatus(rootPlc)
 			}, defaultTimeoutSeconds, 1).Should(utils.SemanticEqual(yamlPlc.Object["status"]))
 		})
 		It("should still contain status.placement with two pb/plr and both status", func() {
@@ -114,7 +114,7 @@ var _ = Describe("Test policy status aggregation", func() {
 			yamlPlc := utils.ParseYaml("../resources/case2_aggregation/managed-both-placement-status.yaml")
 			Eventually(func() interface{} {
 				rootPlc := utils.GetWithTimeout(clientHubDynamic, gvrPolicy, case2PolicyName, testNamespace, true, defaultTimeoutSeconds)
-				return rootPlc.Object["status"]
+				return utils.RootPolicyStatus(rootPlc)
 			}, defaultTimeoutSeconds, 1).Should(utils.SemanticEqual(yamlPlc.Object["status"]))
 		})
 		It("should still contain status.placement with two pb, one plr and both status", func() {
@@ -126,7 +126,7 @@ var _ = Describe("Test policy status aggregation", func() {
 			yamlPlc := utils.ParseYaml("../resources/case2_aggregation/managed-both-placement-status-missing-plr.yaml")
 			Eventually(func() interface{} {
 				rootPlc := utils.GetWithTimeout(clientHubDynamic, gvrPolicy, case2PolicyName, testNamespace, true, defaultTimeoutSeconds)
-				return rootPlc.Object["status"]
+				return utils.RootPolicyStatus(rootPlc)
 			}, defaultTimeoutSeconds, 1).Should(utils.SemanticEqual(yamlPlc.Object["status"]))
 		})
 		It("should clear out status.status", func() {
@@ -138,7 +138,7 @@ var _ = Describe("Test policy status aggregation", func() {
 			yamlPlc := utils.ParseYaml("../resources/case2_aggregation/managed-both-placementbinding.yaml")
 			Eventually(func() interface{} {
 				rootPlc := utils.GetWithTimeout(clientHubDynamic, gvrPolicy, case2PolicyName, testNamespace, true, defaultTimeoutSeconds)
-				return rootPlc.Object["status"]
+				return utils.RootPolicyStatus(rootPlc)
 			}, defaultTimeoutSeconds, 1).Should(utils.SemanticEqual(yamlPlc.Object["status"]))
 		})
 		It("should clear out status", func() {
@@ -153,7 +153,7 @@ var _ = Describe("Test policy status aggregation", func() {
 			emptyStatus := map[string]interface{}{}
 			Eventually(func() interface{} {
 				rootPlc := utils.GetWithTimeout(clientHubDynamic, gvrPolicy, case2PolicyName, testNamespace, true, defaultTimeoutSeconds)
-				return rootPlc.Object["status"]
+				return utils.RootPolicyStatus(rootPlc)
 			}, defaultTimeoutSeconds, 1).Should(utils.SemanticEqual(emptyStatus))
 		})
 		It("should clean up", func() {
@@ -195,8 +195,13 @@ var _ = Describe("Test policy status aggregation", func() {
 			yamlPlc := utils.ParseYaml("../resources/case2_aggregation/managed-both-status-compliant.yaml")
 			Eventually(func() interface{} {
 				rootPlc := utils.GetWithTimeout(clientHubDynamic, gvrPolicy, case2PolicyName, testNamespace, true, defaultTimeoutSeconds)
-				return rootPlc.Object["status"]
+				return utils.RootPolicyStatus(rootPlc)
 			}, defaultTimeoutSeconds, 1).Should(utils.SemanticEqual(yamlPlc.Object["status"]))
+			By("Checking the Compliant condition of root policy")
+			Eventually(func() interface{} {
+				rootPlc := utils.GetWithTimeout(clientHubDynamic, gvrPolicy, case2PolicyName, testNamespace, true, defaultTimeoutSeconds)
+				return utils.GetConditionStatus(rootPlc, policiesv1.CompliantCondition)
+			}, defaultTimeoutSeconds, 1).Should(Equal("True"))
 			By("Patching both replicated policy status to noncompliant")
 			replicatedPlcList = utils.ListWithTimeout(clientHubDynamic, gvrPolicy, opt, 2, true, defaultTimeoutSeconds)
 			for _, replicatedPlc := range replicatedPlcList.Items {
@@ -210,8 +215,13 @@ var _ = Describe("Test policy status aggregation", func() {
 			yamlPlc = utils.ParseYaml("../resources/case2_aggregation/managed-both-status-noncompliant.yaml")
 			Eventually(func() interface{} {
 				rootPlc := utils.GetWithTimeout(clientHubDynamic, gvrPolicy, case2PolicyName, testNamespace, true, defaultTimeoutSeconds)
-				return rootPlc.Object["status"]
+				return utils.RootPolicyStatus(rootPlc)
 			}, defaultTimeoutSeconds, 1).Should(utils.SemanticEqual(yamlPlc.Object["status"]))
+			By("Checking the Compliant condition of root policy")
+			Eventually(func() interface{} {
+				rootPlc := utils.GetWithTimeout(clientHubDynamic, gvrPolicy, case2PolicyName, testNamespace, true, defaultTimeoutSeconds)
+				return utils.GetConditionStatus(rootPlc, policiesv1.CompliantCondition)
+			}, defaultTimeoutSeconds, 1).Should(Equal("False"))
 		})
 		It("should clean up", func() {
 			utils.Kubectl("delete",
@@ -5,6 +5,7 @@ package e2e
 
 import (
 	"context"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -12,6 +13,7 @@ import (
 	"github.com/open-cluster-management/governance-policy-propagator/controllers/common"
 	"github.com/open-cluster-management/governance-policy-propagator/test/utils"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 const case3PolicyName string = "case3-test-policy"
@@ -44,7 +46,7 @@ var _ = Describe("Test unexpected policy mutation", func() {
 		yamlPlc := utils.ParseYaml("../resources/case3_mutation_recovery/managed-both-status-compliant.yaml")
 		Eventually(func() interface{} {
 			rootPlc := utils.GetWithTimeout(clientHubDynamic, gvrPolicy, case3PolicyName, testNamespace, true, defaultTimeoutSeconds)
-			return rootPlc.Object["status"]
+			return utils.RootPolicyStatus(rootPlc)
 		}, defaultTimeoutSeconds, 1).Should(utils.SemanticEqual(yamlPlc.Object["status"]))
 	})
 	AfterEach(func() {
@@ -115,7 +117,21 @@ var _ = Describe("Test unexpected policy mutation", func() {
 		yamlPlc := utils.ParseYaml("../resources/case3_mutation_recovery/managed-both-status-compliant.yaml")
 		Eventually(func() interface{} {
 			rootPlc = utils.GetWithTimeout(clientHubDynamic, gvrPolicy, case3PolicyName, testNamespace, true, defaultTimeoutSeconds)
-			return rootPlc.Object["status"]
+			return utils.RootPolicyStatus(rootPlc)
 		}, defaultTimeoutSeconds, 1).Should(utils.SemanticEqual(yamlPlc.Object["status"]))
 	})
+	It("Should converge after random deletions and mutations of replicated policies", func() {
+		By("Randomly deleting and mutating the replicated policies")
+		utils.RunChaos(clientHubDynamic, 6, time.Second,
+			utils.DeleteRandomReplicatedPolicy(gvrPolicy, case3PolicyName, testNamespace),
+			utils.MutateRandomReplicatedPolicy(gvrPolicy, case3PolicyName, testNamespace,
+				func(plc *unstructured.Unstructured) {
+					plc.Object["spec"].(map[string]interface{})["remediationAction"] = "enforce"
+				},
+			),
+		)
+		By("Checking that the replicated policies converge back to the root policy")
+		utils.ExpectReplicatedPoliciesToConverge(clientHubDynamic, gvrPolicy, case3PolicyName, testNamespace,
+			[]string{"managed1", "managed2"}, defaultTimeoutSeconds)
+	})
 })
@@ -45,7 +45,7 @@ var _ = Describe("Test metrics appear locally", func() {
 		yamlPlc := utils.ParseYaml("../resources/case8_metrics/managed-both-status-compliant.yaml")
 		Eventually(func() interface{} {
 			rootPlc := utils.GetWithTimeout(clientHubDynamic, gvrPolicy, case8PolicyName, testNamespace, true, defaultTimeoutSeconds)
-			return rootPlc.Object["status"]
+			return utils.RootPolicyStatus(rootPlc)
 		}, defaultTimeoutSeconds, 1).Should(utils.SemanticEqual(yamlPlc.Object["status"]))
 		By("Checking metric endpoint for root policy status")
 		Eventually(func() interface{} {
@@ -75,7 +75,7 @@ var _ = Describe("Test metrics appear locally", func() {
 		yamlPlc := utils.ParseYaml("../resources/case8_metrics/managed-both-status-noncompliant.yaml")
 		Eventually(func() interface{} {
 			rootPlc := utils.GetWithTimeout(clientHubDynamic, gvrPolicy, case8PolicyName, testNamespace, true, defaultTimeoutSeconds)
-			return rootPlc.Object["status"]
+			return utils.RootPolicyStatus(rootPlc)
 		}, defaultTimeoutSeconds, 1).Should(utils.SemanticEqual(yamlPlc.Object["status"]))
 		By("Checking metric endpoint for root policy status")
 		Eventually(func() interface{} {
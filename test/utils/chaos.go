@@ -0,0 +1,171 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+var gvrNamespace = schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+
+// ChaosAction disrupts the state on the hub that the propagator is expected to recover from
+type ChaosAction func(clientHubDynamic dynamic.Interface) error
+
+// replicatedPolicies lists the replicated policies of the given root policy
+func replicatedPolicies(
+	clientHubDynamic dynamic.Interface, gvrPolicy schema.GroupVersionResource, rootName, rootNamespace string,
+) ([]unstructured.Unstructured, error) {
+	list, err := clientHubDynamic.Resource(gvrPolicy).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: "policy.open-cluster-management.io/root-policy=" + rootNamespace + "." + rootName,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return list.Items, nil
+}
+
+// DeleteRandomReplicatedPolicy returns a ChaosAction that deletes one of the replicated policies
+// of the given root policy at random
+func DeleteRandomReplicatedPolicy(gvrPolicy schema.GroupVersionResource, rootName, rootNamespace string) ChaosAction {
+	return func(clientHubDynamic dynamic.Interface) error {
+		policies, err := replicatedPolicies(clientHubDynamic, gvrPolicy, rootName, rootNamespace)
+		if err != nil || len(policies) == 0 {
+			return err
+		}
+
+		policy := policies[rand.Intn(len(policies))]
+		fmt.Fprintf(GinkgoWriter, "Chaos: deleting the replicated policy %s/%s\n", policy.GetNamespace(), policy.GetName())
+
+		err = clientHubDynamic.Resource(gvrPolicy).Namespace(policy.GetNamespace()).
+			Delete(context.TODO(), policy.GetName(), metav1.DeleteOptions{})
+		if errors.IsNotFound(err) {
+			return nil
+		}
+
+		return err
+	}
+}
+
+// MutateRandomReplicatedPolicy returns a ChaosAction that applies the mutate function to one of
+// the replicated policies of the given root policy at random and updates it
+func MutateRandomReplicatedPolicy(
+	gvrPolicy schema.GroupVersionResource, rootName, rootNamespace string, mutate func(*unstructured.Unstructured),
+) ChaosAction {
+	return func(clientHubDynamic dynamic.Interface) error {
+		policies, err := replicatedPolicies(clientHubDynamic, gvrPolicy, rootName, rootNamespace)
+		if err != nil || len(policies) == 0 {
+			return err
+		}
+
+		policy := policies[rand.Intn(len(policies))]
+		fmt.Fprintf(GinkgoWriter, "Chaos: mutating the replicated policy %s/%s\n", policy.GetNamespace(), policy.GetName())
+		mutate(&policy)
+
+		_, err = clientHubDynamic.Resource(gvrPolicy).Namespace(policy.GetNamespace()).
+			Update(context.TODO(), &policy, metav1.UpdateOptions{})
+		if errors.IsNotFound(err) || errors.IsConflict(err) {
+			return nil
+		}
+
+		return err
+	}
+}
+
+// RecreateRandomNamespace returns a ChaosAction that deletes one of the given namespaces at
+// random, waits for it to be gone, and creates it again. This is meant for cluster namespaces.
+func RecreateRandomNamespace(namespaces []string, timeout int) ChaosAction {
+	return func(clientHubDynamic dynamic.Interface) error {
+		name := namespaces[rand.Intn(len(namespaces))]
+		fmt.Fprintf(GinkgoWriter, "Chaos: recreating the namespace %s\n", name)
+
+		err := clientHubDynamic.Resource(gvrNamespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+
+		GetClusterLevelWithTimeout(clientHubDynamic, gvrNamespace, name, false, timeout)
+
+		namespace := &unstructured.Unstructured{}
+		namespace.SetAPIVersion("v1")
+		namespace.SetKind("Namespace")
+		namespace.SetName(name)
+		_, err = clientHubDynamic.Resource(gvrNamespace).Create(context.TODO(), namespace, metav1.CreateOptions{})
+
+		return err
+	}
+}
+
+// RunChaos runs the given number of rounds, applying a ChaosAction chosen at random in each round
+// and pausing for the interval between rounds. It fails the test if an action returns an error.
+func RunChaos(clientHubDynamic dynamic.Interface, rounds int, interval time.Duration, actions ...ChaosAction) {
+	Expect(actions).ToNot(BeEmpty())
+
+	for i := 0; i < rounds; i++ {
+		action := actions[rand.Intn(len(actions))]
+		Expect(action(clientHubDynamic)).To(Succeed())
+		time.Sleep(interval)
+	}
+}
+
+// ExpectReplicatedPoliciesToConverge keeps polling for timeout seconds until the root policy is
+// replicated to exactly the given cluster namespaces with the root policy's spec. Root policies
+// with hub templates are not supported since their replicated specs differ.
+func ExpectReplicatedPoliciesToConverge(
+	clientHubDynamic dynamic.Interface,
+	gvrPolicy schema.GroupVersionResource,
+	rootName, rootNamespace string,
+	clusterNamespaces []string,
+	timeout int,
+) {
+	if timeout < 1 {
+		timeout = 1
+	}
+
+	expected := append([]string{}, clusterNamespaces...)
+	sort.Strings(expected)
+
+	Eventually(func() error {
+		root, err := clientHubDynamic.Resource(gvrPolicy).Namespace(rootNamespace).
+			Get(context.TODO(), rootName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		policies, err := replicatedPolicies(clientHubDynamic, gvrPolicy, rootName, rootNamespace)
+		if err != nil {
+			return err
+		}
+
+		actual := make([]string, 0, len(policies))
+		for _, policy := range policies {
+			actual = append(actual, policy.GetNamespace())
+
+			if !equality.Semantic.DeepEqual(policy.Object["spec"], root.Object["spec"]) {
+				return fmt.Errorf(
+					"the replicated policy %s/%s doesn't match the root policy", policy.GetNamespace(), policy.GetName(),
+				)
+			}
+		}
+		sort.Strings(actual)
+
+		if !equality.Semantic.DeepEqual(actual, expected) {
+			return fmt.Errorf("expected replicated policies in %v, got %v", expected, actual)
+		}
+
+		return nil
+	}, timeout, 1).Should(BeNil())
+}
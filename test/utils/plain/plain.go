@@ -0,0 +1,192 @@
+// Copyright Contributors to the Open Cluster Management project
+
+// Package plain provides versions of the test utilities that return errors instead of relying on
+// Ginkgo and Gomega, so that they can be used in plain Go tests and command line tools.
+package plain
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os/exec"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+)
+
+// PollInterval is how often the *WithTimeout functions retry
+var PollInterval = time.Second
+
+// ParseYaml reads the given yaml file and unmarshals it to &unstructured.Unstructured{}
+func ParseYaml(file string) (*unstructured.Unstructured, error) {
+	yamlFile, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	obj := &unstructured.Unstructured{}
+
+	err = yaml.Unmarshal(yamlFile, obj)
+	if err != nil {
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+// poll calls the condition every PollInterval for timeout seconds until it returns nil. If the
+// timeout is reached, the last error returned by the condition is returned.
+func poll(timeout int, condition func() error) error {
+	if timeout < 1 {
+		timeout = 1
+	}
+
+	var lastErr error
+
+	err := wait.PollImmediate(PollInterval, time.Duration(timeout)*time.Second, func() (bool, error) {
+		lastErr = condition()
+
+		return lastErr == nil, nil
+	})
+	if err != nil && lastErr != nil {
+		return fmt.Errorf("timed out after %d seconds: %w", timeout, lastErr)
+	}
+
+	return err
+}
+
+// getCondition returns an error until the result of get matches wantFound (true for found, false
+// for not found)
+func getCondition(wantFound bool, get func() (*unstructured.Unstructured, error)) (*unstructured.Unstructured, error) {
+	obj, err := get()
+	if wantFound && err != nil {
+		return nil, err
+	}
+
+	if !wantFound && err == nil {
+		return nil, fmt.Errorf("expected to return IsNotFound error")
+	}
+
+	if !wantFound && err != nil && !errors.IsNotFound(err) {
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+// GetClusterLevelWithTimeout keeps polling to get the cluster scoped object for timeout seconds
+// until wantFound is met (true for found, false for not found). The object is returned when
+// wantFound is true.
+func GetClusterLevelWithTimeout(
+	clientHubDynamic dynamic.Interface,
+	gvr schema.GroupVersionResource,
+	name string,
+	wantFound bool,
+	timeout int,
+) (*unstructured.Unstructured, error) {
+	var obj *unstructured.Unstructured
+
+	err := poll(timeout, func() error {
+		var err error
+		obj, err = getCondition(wantFound, func() (*unstructured.Unstructured, error) {
+			return clientHubDynamic.Resource(gvr).Get(context.TODO(), name, metav1.GetOptions{})
+		})
+
+		return err
+	})
+	if err != nil || !wantFound {
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+// GetWithTimeout keeps polling to get the object for timeout seconds until wantFound is met
+// (true for found, false for not found). The object is returned when wantFound is true.
+func GetWithTimeout(
+	clientHubDynamic dynamic.Interface,
+	gvr schema.GroupVersionResource,
+	name, namespace string,
+	wantFound bool,
+	timeout int,
+) (*unstructured.Unstructured, error) {
+	var obj *unstructured.Unstructured
+
+	err := poll(timeout, func() error {
+		var err error
+		obj, err = getCondition(wantFound, func() (*unstructured.Unstructured, error) {
+			return clientHubDynamic.Resource(gvr).Namespace(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		})
+
+		return err
+	})
+	if err != nil || !wantFound {
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+// ListWithTimeout keeps polling to list the objects for timeout seconds until there are size of
+// them. The list is returned when wantFound is true.
+func ListWithTimeout(
+	clientHubDynamic dynamic.Interface,
+	gvr schema.GroupVersionResource,
+	opts metav1.ListOptions,
+	size int,
+	wantFound bool,
+	timeout int,
+) (*unstructured.UnstructuredList, error) {
+	return ListWithTimeoutByNamespace(clientHubDynamic, gvr, opts, metav1.NamespaceAll, size, wantFound, timeout)
+}
+
+// ListWithTimeoutByNamespace keeps polling to list the objects in the namespace for timeout
+// seconds until there are size of them. The list is returned when wantFound is true.
+func ListWithTimeoutByNamespace(
+	clientHubDynamic dynamic.Interface,
+	gvr schema.GroupVersionResource,
+	opts metav1.ListOptions,
+	ns string,
+	size int,
+	wantFound bool,
+	timeout int,
+) (*unstructured.UnstructuredList, error) {
+	var list *unstructured.UnstructuredList
+
+	err := poll(timeout, func() error {
+		var err error
+		list, err = clientHubDynamic.Resource(gvr).Namespace(ns).List(context.TODO(), opts)
+		if err != nil {
+			return err
+		}
+
+		if len(list.Items) != size {
+			return fmt.Errorf("list size doesn't match, expected %d actual %d", size, len(list.Items))
+		}
+
+		return nil
+	})
+	if err != nil || !wantFound {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// Kubectl starts the kubectl cli without waiting for it to finish
+func Kubectl(args ...string) error {
+	return exec.Command("kubectl", args...).Start()
+}
+
+// KubectlWithOutput executes the kubectl cli and returns its combined output
+func KubectlWithOutput(args ...string) (string, error) {
+	output, err := exec.Command("kubectl", args...).CombinedOutput()
+
+	return string(output), err
+}
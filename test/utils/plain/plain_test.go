@@ -0,0 +1,77 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package plain
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+var gvrConfigMap = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+
+func TestGetAndListWithTimeout(t *testing.T) {
+	PollInterval = 10 * time.Millisecond
+
+	configMap := &unstructured.Unstructured{}
+	configMap.SetAPIVersion("v1")
+	configMap.SetKind("ConfigMap")
+	configMap.SetName("config")
+	configMap.SetNamespace("default")
+
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(
+		runtime.NewScheme(), map[schema.GroupVersionResource]string{gvrConfigMap: "ConfigMapList"}, configMap,
+	)
+
+	obj, err := GetWithTimeout(client, gvrConfigMap, "config", "default", true, 1)
+	if err != nil || obj.GetName() != "config" {
+		t.Fatalf("Expected to get the ConfigMap, got %v (err: %v)", obj, err)
+	}
+
+	if _, err := GetWithTimeout(client, gvrConfigMap, "missing", "default", true, 1); err == nil {
+		t.Fatal("Expected an error for a missing object")
+	}
+
+	if _, err := GetWithTimeout(client, gvrConfigMap, "missing", "default", false, 1); err != nil {
+		t.Fatalf("Unexpected error when the object is expected to be missing: %v", err)
+	}
+
+	list, err := ListWithTimeoutByNamespace(client, gvrConfigMap, metav1.ListOptions{}, "default", 1, true, 1)
+	if err != nil || len(list.Items) != 1 {
+		t.Fatalf("Expected to list one ConfigMap, got %v (err: %v)", list, err)
+	}
+
+	if _, err := ListWithTimeout(client, gvrConfigMap, metav1.ListOptions{}, 2, true, 1); err == nil {
+		t.Fatal("Expected an error when the list size doesn't match")
+	}
+}
+
+func TestParseYaml(t *testing.T) {
+	dir, err := ioutil.TempDir("", "plain")
+	if err != nil {
+		t.Fatalf("Failed to create a temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "configmap.yaml")
+	if err := ioutil.WriteFile(file, []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: config\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write the file: %v", err)
+	}
+
+	obj, err := ParseYaml(file)
+	if err != nil || obj.GetName() != "config" {
+		t.Fatalf("Expected to parse the ConfigMap, got %v (err: %v)", obj, err)
+	}
+
+	if _, err := ParseYaml(filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Fatal("Expected an error for a missing file")
+	}
+}
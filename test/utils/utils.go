@@ -4,19 +4,16 @@
 package utils
 
 import (
-	"context"
 	"fmt"
-	"io/ioutil"
 	"os/exec"
 	"strings"
 	"time"
 
-	"github.com/ghodss/yaml"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	clusterv1alpha1 "github.com/open-cluster-management/api/cluster/v1alpha1"
+	"github.com/open-cluster-management/governance-policy-propagator/test/utils/plain"
 	appsv1 "github.com/open-cluster-management/multicloud-operators-placementrule/pkg/apis/apps/v1"
-	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -57,10 +54,7 @@ func Pause(s uint) {
 
 // ParseYaml read given yaml file and unmarshal it to &unstructured.Unstructured{}
 func ParseYaml(file string) *unstructured.Unstructured {
-	yamlFile, err := ioutil.ReadFile(file)
-	Expect(err).To(BeNil())
-	yamlPlc := &unstructured.Unstructured{}
-	err = yaml.Unmarshal(yamlFile, yamlPlc)
+	yamlPlc, err := plain.ParseYaml(file)
 	Expect(err).To(BeNil())
 	return yamlPlc
 }
@@ -73,30 +67,9 @@ func GetClusterLevelWithTimeout(
 	wantFound bool,
 	timeout int,
 ) *unstructured.Unstructured {
-	if timeout < 1 {
-		timeout = 1
-	}
-	var obj *unstructured.Unstructured
-
-	Eventually(func() error {
-		var err error
-		namespace := clientHubDynamic.Resource(gvr)
-		obj, err = namespace.Get(context.TODO(), name, metav1.GetOptions{})
-		if wantFound && err != nil {
-			return err
-		}
-		if !wantFound && err == nil {
-			return fmt.Errorf("expected to return IsNotFound error")
-		}
-		if !wantFound && err != nil && !errors.IsNotFound(err) {
-			return err
-		}
-		return nil
-	}, timeout, 1).Should(BeNil())
-	if wantFound {
-		return obj
-	}
-	return nil
+	obj, err := plain.GetClusterLevelWithTimeout(clientHubDynamic, gvr, name, wantFound, timeout)
+	Expect(err).To(BeNil())
+	return obj
 }
 
 // GetWithTimeout keeps polling to get the object for timeout seconds until wantFound is met (true for found, false for not found)
@@ -107,31 +80,9 @@ func GetWithTimeout(
 	wantFound bool,
 	timeout int,
 ) *unstructured.Unstructured {
-	if timeout < 1 {
-		timeout = 1
-	}
-	var obj *unstructured.Unstructured
-
-	Eventually(func() error {
-		var err error
-		namespace := clientHubDynamic.Resource(gvr).Namespace(namespace)
-		obj, err = namespace.Get(context.TODO(), name, metav1.GetOptions{})
-		if wantFound && err != nil {
-			return err
-		}
-		if !wantFound && err == nil {
-			return fmt.Errorf("expected to return IsNotFound error")
-		}
-		if !wantFound && err != nil && !errors.IsNotFound(err) {
-			return err
-		}
-		return nil
-	}, timeout, 1).Should(BeNil())
-	if wantFound {
-		return obj
-	}
-	return nil
-
+	obj, err := plain.GetWithTimeout(clientHubDynamic, gvr, name, namespace, wantFound, timeout)
+	Expect(err).To(BeNil())
+	return obj
 }
 
 // ListWithTimeout keeps polling to list the object for timeout seconds until wantFound is met (true for found, false for not found)
@@ -143,29 +94,9 @@ func ListWithTimeout(
 	wantFound bool,
 	timeout int,
 ) *unstructured.UnstructuredList {
-	if timeout < 1 {
-		timeout = 1
-	}
-	var list *unstructured.UnstructuredList
-
-	Eventually(func() error {
-		var err error
-		list, err = clientHubDynamic.Resource(gvr).List(context.TODO(), opts)
-		if err != nil {
-			return err
-		} else {
-			if len(list.Items) != size {
-				return fmt.Errorf("list size doesn't match, expected %d actual %d", size, len(list.Items))
-			} else {
-				return nil
-			}
-		}
-	}, timeout, 1).Should(BeNil())
-	if wantFound {
-		return list
-	}
-	return nil
-
+	list, err := plain.ListWithTimeout(clientHubDynamic, gvr, opts, size, wantFound, timeout)
+	Expect(err).To(BeNil())
+	return list
 }
 
 // ListWithTimeoutByNamespace keeps polling to list the object for timeout seconds until wantFound is met (true for found, false for not found)
@@ -178,35 +109,14 @@ func ListWithTimeoutByNamespace(
 	wantFound bool,
 	timeout int,
 ) *unstructured.UnstructuredList {
-	if timeout < 1 {
-		timeout = 1
-	}
-	var list *unstructured.UnstructuredList
-
-	Eventually(func() error {
-		var err error
-		list, err = clientHubDynamic.Resource(gvr).Namespace(ns).List(context.TODO(), opts)
-		if err != nil {
-			return err
-		} else {
-			if len(list.Items) != size {
-				return fmt.Errorf("list size doesn't match, expected %d actual %d", size, len(list.Items))
-			} else {
-				return nil
-			}
-		}
-	}, timeout, 1).Should(BeNil())
-	if wantFound {
-		return list
-	}
-	return nil
-
+	list, err := plain.ListWithTimeoutByNamespace(clientHubDynamic, gvr, opts, ns, size, wantFound, timeout)
+	Expect(err).To(BeNil())
+	return list
 }
 
 // Kubectl execute kubectl cli
 func Kubectl(args ...string) {
-	cmd := exec.Command("kubectl", args...)
-	err := cmd.Start()
+	err := plain.Kubectl(args...)
 	if err != nil {
 		Fail(fmt.Sprintf("Error: %v", err))
 	}
@@ -214,9 +124,9 @@ func Kubectl(args ...string) {
 
 // KubectlWithOutput execute kubectl cli and return output and error
 func KubectlWithOutput(args ...string) (string, error) {
-	output, err := exec.Command("kubectl", args...).CombinedOutput()
-	fmt.Println(string(output))
-	return string(output), err
+	output, err := plain.KubectlWithOutput(args...)
+	fmt.Println(output)
+	return output, err
 }
 
 // GetMetrics execs into the propagator pod and curls the metrics endpoint, filters
@@ -252,3 +162,45 @@ func GetMetrics(metricPatterns ...string) []string {
 
 	return values
 }
+
+// RootPolicyStatus returns the compliant, placement, and status fields of the status of the root
+// policy. The conditions and the compliance transition times of the clusters are left out since
+// they can't be compared to a fixture.
+func RootPolicyStatus(plc *unstructured.Unstructured) map[string]interface{} {
+	status, _, _ := unstructured.NestedMap(plc.Object, "status")
+	result := map[string]interface{}{}
+	for _, field := range []string{"compliant", "placement", "status"} {
+		if value, ok := status[field]; ok {
+			result[field] = value
+		}
+	}
+	clusters, _ := result["status"].([]interface{})
+	for _, cluster := range clusters {
+		cluster, ok := cluster.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		delete(cluster, "lastTransitionTime")
+		history, _ := cluster["complianceHistory"].([]interface{})
+		for _, transition := range history {
+			if transition, ok := transition.(map[string]interface{}); ok {
+				delete(transition, "lastTransitionTime")
+			}
+		}
+	}
+	return result
+}
+
+// GetConditionStatus returns the status of the condition of the given type in the status of the
+// object, or an empty string when the condition isn't set
+func GetConditionStatus(obj *unstructured.Unstructured, conditionType string) string {
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, condition := range conditions {
+		condition, ok := condition.(map[string]interface{})
+		if ok && condition["type"] == conditionType {
+			status, _ := condition["status"].(string)
+			return status
+		}
+	}
+	return ""
+}